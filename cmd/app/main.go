@@ -4,9 +4,12 @@ import (
 	config "golang/internal/configs/database"
 	"golang/internal/logger"
 	"golang/internal/module"
+	"golang/internal/notification"
 	"golang/internal/server"
+	"golang/internal/storage"
 	"log"
 	"net/http"
+	"os"
 )
 
 func main() {
@@ -21,20 +24,51 @@ func main() {
 
 	mux := http.NewServeMux()
 
+	// Kênh gửi thông báo dùng chung (mặc định ghi log)
+	notifier := notification.NewLogNotifier()
+
+	// Nơi lưu file upload dùng chung (mặc định thư mục trên server)
+	fileStorage := storage.NewLocalStorage(os.Getenv("STORAGE_DIR"))
+
+	// Module thống kê khởi tạo trước để các module khác đăng ký Cron Job
+	cronManager := module.InitStatsModule(db.Connection, mux, notifier)
+
 	// KHỞI TẠO CÁC MODULE
-	module.InitUserModule(db.Connection, mux)
+	// Shipping khởi tạo trước Cart / Order để tính phí giao hàng
+	shippingController := module.InitShippingModule(db.Connection, mux)
+
+	// Cart khởi tạo trước User để gộp giỏ hàng khách khi đăng nhập
+	cartController := module.InitCartModule(db.Connection, mux, cronManager, shippingController)
+
+	module.InitUserModule(db.Connection, mux, cartController)
 
-	module.InitAddressModule(db.Connection, mux)
+	// Dữ liệu đơn vị hành chính nạp trước Address để kiểm tra / chuẩn hoá địa chỉ
+	locationController := module.InitLocationModule(mux)
 
-	module.InitProductModule(db.Connection, mux)
+	module.InitAddressModule(db.Connection, mux, locationController)
+
+	// Wishlist khởi tạo trước Product để nhận sự kiện biến thể có hàng trở lại
+	wishlistController := module.InitWishlistModule(db.Connection, mux, notifier)
+
+	// Product trả về controller biến thể để module trả hàng cộng lại tồn kho
+	variantController := module.InitProductModule(db.Connection, mux, cronManager, wishlistController)
 
 	module.InitCategoryModule(db.Connection, mux)
 
-	module.InitCartModule(db.Connection, mux)
+	// Invoice khởi tạo trước Order để cấp số hóa đơn khi đơn được thanh toán / hoàn thành
+	invoiceController := module.InitInvoiceModule(db.Connection, mux)
+
+	// Order báo cho Stats tính lại số liệu khi đơn cũ đổi trạng thái, báo cho Cart ghi sự kiện tạo đơn (phễu bán hàng)
+	module.InitOrderModule(db.Connection, mux, shippingController, cronManager.StatsController, cartController, invoiceController)
+
+	// Tin nhắn / ghi chú nội bộ trong đơn hàng (file đính kèm lưu qua Storage)
+	module.InitOrderMessageModule(db.Connection, mux, fileStorage)
 
-	module.InitOrderModule(db.Connection, mux)
+	// Trả hàng (RMA): ảnh lưu qua Storage, nhận hàng thì cộng lại tồn kho, hoàn tiền thì báo Stats tính lại
+	module.InitReturnModule(db.Connection, mux, fileStorage, variantController, cronManager.StatsController)
 
-	cronManager := module.InitStatsModule(db.Connection, mux)
+	// Export CSV / XLSX (đọc dữ liệu đơn hàng, thống kê, user)
+	module.InitExportModule(db.Connection, mux, cronManager)
 
 	// Kích hoạt Cron Job chạy ngầm
 	cronManager.Start()
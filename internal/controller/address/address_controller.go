@@ -6,32 +6,128 @@ import (
 	"golang/internal/logger"
 	"golang/internal/model"
 	"golang/internal/repository/address"
+	"golang/internal/utils"
 )
 
+// LocationResolver - Kiểm tra / chuẩn hoá tỉnh - quận - phường (do module Location cung cấp)
+type LocationResolver interface {
+	Resolve(in model.LocationInput) (*model.ResolvedLocation, error)
+}
+
 type addressController struct {
 	AddressRepo address.AddressRepo
+	Locations   LocationResolver
 }
 
-func NewAddressController(addressRepo address.AddressRepo) AddressController {
+func NewAddressController(addressRepo address.AddressRepo, locations LocationResolver) AddressController {
 	return &addressController{
 		AddressRepo: addressRepo,
+		Locations:   locations,
+	}
+}
+
+func toAddressResponse(addr model.Address) model.AddressResponse {
+	return model.AddressResponse{
+		ID:                addr.ID,
+		UserID:            addr.UserID,
+		Label:             addr.Label,
+		RecipientName:     addr.RecipientName,
+		Phone:             addr.Phone,
+		Line1:             addr.Line1,
+		Line2:             addr.Line2,
+		City:              addr.City,
+		State:             addr.State,
+		Ward:              addr.Ward,
+		Country:           addr.Country,
+		ProvinceCode:      addr.ProvinceCode,
+		DistrictCode:      addr.DistrictCode,
+		WardCode:          addr.WardCode,
+		IsDefaultShipping: addr.IsDefaultShipping,
+		CreatedAt:         addr.CreatedAt,
+		UpdatedAt:         addr.UpdatedAt,
 	}
 }
 
+// normalizePhone: Số điện thoại lưu theo E.164, số nội địa mặc định là số Việt Nam
+func normalizePhone(phone string) (string, error) {
+	return utils.NormalizePhoneE164(phone, utils.VietnamCallingCode)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// pickLevel: Lấy tên / mã của 1 cấp hành chính khi cập nhật địa chỉ.
+// Cấp cha đã đổi thì cấp con không giữ giá trị cũ (tránh quận cũ nằm trong tỉnh mới)
+func pickLevel(keep bool, oldName, oldCode string, name, code *string) (string, string, bool) {
+	if name == nil && code == nil {
+		if keep {
+			return oldName, oldCode, true
+		}
+		return "", "", false
+	}
+	return stringValue(name), stringValue(code), false
+}
+
+// mergeLocationInput: Gộp phần địa chỉ hành chính của request cập nhật với địa chỉ hiện tại
+func mergeLocationInput(existing model.Address, req model.UpdateAddressRequest) model.LocationInput {
+	in := model.LocationInput{Country: existing.Country}
+	keep := true
+	if req.Country != nil {
+		in.Country = *req.Country
+		keep = false
+	}
+	in.Province, in.ProvinceCode, keep = pickLevel(keep, existing.City, existing.ProvinceCode, req.City, req.ProvinceCode)
+	in.District, in.DistrictCode, keep = pickLevel(keep, existing.State, existing.DistrictCode, req.State, req.DistrictCode)
+	in.Ward, in.WardCode, _ = pickLevel(keep, existing.Ward, existing.WardCode, req.Ward, req.WardCode)
+	return in
+}
+
+func locationChanged(req model.UpdateAddressRequest) bool {
+	return req.Country != nil || req.City != nil || req.State != nil || req.Ward != nil ||
+		req.ProvinceCode != nil || req.DistrictCode != nil || req.WardCode != nil
+}
+
 // Tạo địa chỉ mới
 func (c *addressController) CreateAddress(userID int64, req model.CreateAddressRequest) (model.AddressResponse, error) {
 	logger.InfoLogger.Printf("User %d đang tạo địa chỉ mới", userID)
 
+	phone, err := normalizePhone(req.Phone)
+	if err != nil {
+		return model.AddressResponse{}, err
+	}
+
+	loc, err := c.Locations.Resolve(model.LocationInput{
+		Country:      req.Country,
+		ProvinceCode: req.ProvinceCode,
+		Province:     req.City,
+		DistrictCode: req.DistrictCode,
+		District:     req.State,
+		WardCode:     req.WardCode,
+		Ward:         req.Ward,
+	})
+	if err != nil {
+		logger.WarnLogger.Printf("User %d nhập địa chỉ không hợp lệ: %v", userID, err)
+		return model.AddressResponse{}, err
+	}
+
 	newAddress := model.Address{
 		UserID:            userID,
 		Label:             req.Label,
 		RecipientName:     req.RecipientName,
-		Phone:             req.Phone,
+		Phone:             phone,
 		Line1:             req.Line1,
 		Line2:             req.Line2,
-		City:              req.City,
-		State:             req.State,
-		Country:           req.Country,
+		City:              loc.Province,
+		State:             loc.District,
+		Ward:              loc.Ward,
+		Country:           loc.Country,
+		ProvinceCode:      loc.ProvinceCode,
+		DistrictCode:      loc.DistrictCode,
+		WardCode:          loc.WardCode,
 		IsDefaultShipping: req.IsDefaultShipping,
 	}
 
@@ -41,21 +137,7 @@ func (c *addressController) CreateAddress(userID int64, req model.CreateAddressR
 		return model.AddressResponse{}, err
 	}
 
-	res := model.AddressResponse{
-		ID:                createdAddr.ID,
-		UserID:            createdAddr.UserID,
-		Label:             createdAddr.Label,
-		RecipientName:     createdAddr.RecipientName,
-		Phone:             createdAddr.Phone,
-		Line1:             createdAddr.Line1,
-		Line2:             createdAddr.Line2,
-		City:              createdAddr.City,
-		State:             createdAddr.State,
-		Country:           createdAddr.Country,
-		IsDefaultShipping: createdAddr.IsDefaultShipping,
-		CreatedAt:         createdAddr.CreatedAt,
-		UpdatedAt:         createdAddr.UpdatedAt,
-	}
+	res := toAddressResponse(createdAddr)
 
 	logger.InfoLogger.Printf("Tạo địa chỉ thành công ID: %d", createdAddr.ID)
 	return res, nil
@@ -73,21 +155,7 @@ func (c *addressController) GetMyAddresses(userID int64) ([]model.AddressRespons
 
 	var res []model.AddressResponse
 	for _, addr := range addresses {
-		res = append(res, model.AddressResponse{
-			ID:                addr.ID,
-			UserID:            addr.UserID,
-			Label:             addr.Label,
-			RecipientName:     addr.RecipientName,
-			Phone:             addr.Phone,
-			Line1:             addr.Line1,
-			Line2:             addr.Line2,
-			City:              addr.City,
-			State:             addr.State,
-			Country:           addr.Country,
-			IsDefaultShipping: addr.IsDefaultShipping,
-			CreatedAt:         addr.CreatedAt,
-			UpdatedAt:         addr.UpdatedAt,
-		})
+		res = append(res, toAddressResponse(addr))
 	}
 
 	return res, nil
@@ -106,21 +174,7 @@ func (c *addressController) GetAddressByID(id int64, userID int64) (model.Addres
 		return model.AddressResponse{}, err
 	}
 
-	res := model.AddressResponse{
-		ID:                addr.ID,
-		UserID:            addr.UserID,
-		Label:             addr.Label,
-		RecipientName:     addr.RecipientName,
-		Phone:             addr.Phone,
-		Line1:             addr.Line1,
-		Line2:             addr.Line2,
-		City:              addr.City,
-		State:             addr.State,
-		Country:           addr.Country,
-		IsDefaultShipping: addr.IsDefaultShipping,
-		CreatedAt:         addr.CreatedAt,
-		UpdatedAt:         addr.UpdatedAt,
-	}
+	res := toAddressResponse(addr)
 
 	return res, nil
 }
@@ -129,6 +183,39 @@ func (c *addressController) GetAddressByID(id int64, userID int64) (model.Addres
 func (c *addressController) UpdateAddress(id int64, userID int64, req model.UpdateAddressRequest) (model.AddressResponse, error) {
 	logger.InfoLogger.Printf("User %d cập nhật địa chỉ %d", userID, id)
 
+	existing, err := c.AddressRepo.GetAddressByID(id, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.AddressResponse{}, errors.New("địa chỉ không tồn tại")
+		}
+		logger.ErrorLogger.Printf("Lỗi lấy địa chỉ cần cập nhật: %v", err)
+		return model.AddressResponse{}, err
+	}
+
+	if req.Phone != nil {
+		phone, err := normalizePhone(*req.Phone)
+		if err != nil {
+			return model.AddressResponse{}, err
+		}
+		req.Phone = &phone
+	}
+
+	// Chỉ kiểm tra lại địa chỉ hành chính khi request có sửa phần này
+	if locationChanged(req) {
+		loc, err := c.Locations.Resolve(mergeLocationInput(existing, req))
+		if err != nil {
+			logger.WarnLogger.Printf("User %d nhập địa chỉ không hợp lệ: %v", userID, err)
+			return model.AddressResponse{}, err
+		}
+		req.Country = &loc.Country
+		req.City = &loc.Province
+		req.State = &loc.District
+		req.Ward = &loc.Ward
+		req.ProvinceCode = &loc.ProvinceCode
+		req.DistrictCode = &loc.DistrictCode
+		req.WardCode = &loc.WardCode
+	}
+
 	updatedAddr, err := c.AddressRepo.UpdateAddress(id, userID, req)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -138,21 +225,7 @@ func (c *addressController) UpdateAddress(id int64, userID int64, req model.Upda
 		return model.AddressResponse{}, err
 	}
 
-	res := model.AddressResponse{
-		ID:                updatedAddr.ID,
-		UserID:            updatedAddr.UserID,
-		Label:             updatedAddr.Label,
-		RecipientName:     updatedAddr.RecipientName,
-		Phone:             updatedAddr.Phone,
-		Line1:             updatedAddr.Line1,
-		Line2:             updatedAddr.Line2,
-		City:              updatedAddr.City,
-		State:             updatedAddr.State,
-		Country:           updatedAddr.Country,
-		IsDefaultShipping: updatedAddr.IsDefaultShipping,
-		CreatedAt:         updatedAddr.CreatedAt,
-		UpdatedAt:         updatedAddr.UpdatedAt,
-	}
+	res := toAddressResponse(updatedAddr)
 
 	return res, nil
 }
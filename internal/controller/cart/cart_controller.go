@@ -3,18 +3,30 @@ package cart
 import (
 	"context"
 	"errors"
+	"math"
+	"time"
 
 	"golang/internal/logger"
 	"golang/internal/model"
+	addressRepo "golang/internal/repository/address"
 	cartRepo "golang/internal/repository/cart"
 	productRepo "golang/internal/repository/product"
 	variantRepo "golang/internal/repository/productvariant"
+	"golang/internal/utils"
 )
 
+// ShippingQuoter: Tính phí giao hàng theo địa chỉ (module shipping cài đặt)
+type ShippingQuoter interface {
+	QuoteAll(ctx context.Context, dest model.ShippingDestination, subtotal float64, weightGrams int) ([]model.ShippingQuote, error)
+	Quote(ctx context.Context, dest model.ShippingDestination, methodCode string, subtotal float64, weightGrams int) (*model.ShippingQuote, error)
+}
+
 type cartController struct {
 	CartRepo    cartRepo.ICartRepository
 	ProductRepo productRepo.ProductRepository
 	VariantRepo variantRepo.ProductVariantsRepository
+	AddressRepo addressRepo.AddressRepo
+	Shipping    ShippingQuoter
 }
 
 // Constructor: Inject các Repo cần thiết
@@ -22,112 +34,257 @@ func NewCartController(
 	cRepo cartRepo.ICartRepository,
 	pRepo productRepo.ProductRepository,
 	vRepo variantRepo.ProductVariantsRepository,
+	aRepo addressRepo.AddressRepo,
+	shipping ShippingQuoter,
 ) CartController {
 	return &cartController{
 		CartRepo:    cRepo,
 		ProductRepo: pRepo,
 		VariantRepo: vRepo,
+		AddressRepo: aRepo,
+		Shipping:    shipping,
 	}
 }
 
-// GetCart: Lấy chi tiết giỏ hàng
-func (c *cartController) GetCart(ctx context.Context, userID int64) (model.CartResponse, error) {
-	logger.DebugLogger.Printf("Controller: Getting cart for user %d", userID)
+var (
+	ErrInvalidCartToken   = errors.New("cart token không hợp lệ")
+	ErrAddressNeedsLogin  = errors.New("vui lòng đăng nhập để chọn địa chỉ giao hàng")
+	ErrInvalidCartAddress = errors.New("địa chỉ giao hàng không hợp lệ hoặc không tồn tại")
+)
 
-	//  Lấy ID giỏ hàng
-	cartID, err := c.CartRepo.GetCartIDByUserID(ctx, userID)
+// findCartID: Lấy ID giỏ hàng theo chủ sở hữu (user hoặc khách), trả về 0 nếu chưa có
+func (c *cartController) findCartID(ctx context.Context, owner model.CartOwner) (int64, error) {
+	if owner.IsGuest() {
+		if owner.GuestToken == "" {
+			return 0, nil
+		}
+		return c.CartRepo.GetCartIDByGuestToken(ctx, owner.GuestToken)
+	}
+	return c.CartRepo.GetCartIDByUserID(ctx, owner.UserID)
+}
+
+// getOrCreateCartID: Lấy ID giỏ hàng, nếu chưa có thì tạo mới; giỏ khách được gia hạn mỗi lần ghi
+func (c *cartController) getOrCreateCartID(ctx context.Context, owner model.CartOwner) (int64, error) {
+	cartID, err := c.findCartID(ctx, owner)
 	if err != nil {
-		return model.CartResponse{}, err
+		return 0, err
+	}
+
+	if !owner.IsGuest() {
+		if cartID == 0 {
+			return c.CartRepo.CreateCart(ctx, owner.UserID)
+		}
+		return cartID, nil
 	}
 
+	if owner.GuestToken == "" {
+		return 0, ErrInvalidCartToken
+	}
+	expiresAt := time.Now().Add(utils.GuestCartTTL())
 	if cartID == 0 {
-		return model.CartResponse{UserID: userID, Items: []model.CartItemResponse{}}, nil
+		return c.CartRepo.CreateGuestCart(ctx, owner.GuestToken, expiresAt)
 	}
+	if err := c.CartRepo.TouchGuestCart(ctx, cartID, expiresAt); err != nil {
+		return 0, err
+	}
+	return cartID, nil
+}
 
-	// Lấy danh sách items thô
-	rawItems, err := c.CartRepo.GetCartItems(ctx, cartID)
-	if err != nil {
-		return model.CartResponse{}, err
+// recordEvent: Ghi sự kiện giỏ hàng cho báo cáo phễu; lỗi chỉ ghi log, không làm hỏng thao tác của user
+func (c *cartController) recordEvent(ctx context.Context, owner model.CartOwner, cartID int64, event model.CartEvent) {
+	if cartID > 0 {
+		event.CartID = &cartID
+	}
+	if !owner.IsGuest() {
+		userID := owner.UserID
+		event.UserID = &userID
+	}
+	if err := c.CartRepo.RecordCartEvent(ctx, event); err != nil {
+		logger.WarnLogger.Printf("Controller: Record cart event %s failed (cart %d): %v", event.EventType, cartID, err)
 	}
+}
 
-	var responseItems []model.CartItemResponse
+// priceEpsilon: chênh lệch nhỏ hơn mức này coi như giá không đổi (DECIMAL(12,2))
+const priceEpsilon = 0.005
 
-	// Duyệt và map dữ liệu
-	for _, item := range rawItems {
-		// Lấy Variant
-		variant, err := c.VariantRepo.GetVariantByID(item.VariantID)
-		if err != nil || variant == nil {
-			logger.WarnLogger.Printf("Variant ID %d not found, skipping...", item.VariantID)
-			continue
+// currentPrice: Giá hiện tại của biến thể (PriceOverride, nếu không có thì lấy giá gốc của sản phẩm)
+func currentPrice(variant *model.ProductsVariants, product *model.Product) float64 {
+	return utils.VariantPrice(variant.PriceOverride, product.BasePrice)
+}
+
+// buildCartLine: Map 1 dòng giỏ hàng sang response, đồng thời kiểm tra lại giá / tồn kho / trạng thái bán
+func (c *cartController) buildCartLine(item model.CartItem, now time.Time) model.CartItemResponse {
+	line := model.CartItemResponse{
+		ItemID:        item.ID,
+		ProductID:     item.ProductID,
+		VariantID:     item.VariantID,
+		Quantity:      item.Quantity,
+		PriceSnapshot: item.PriceSnapshot,
+	}
+
+	unavailable := func(reason string) model.CartItemResponse {
+		line.Available = false
+		line.Notices = append(line.Notices, model.CartNotice{
+			Type:      model.CartNoticeUnavailable,
+			VariantID: item.VariantID,
+			Reason:    reason,
+		})
+		return line
+	}
+
+	// Lấy Variant
+	variant, err := c.VariantRepo.GetVariantByID(item.VariantID)
+	if err != nil || variant == nil {
+		logger.WarnLogger.Printf("Variant ID %d not found in cart %d", item.VariantID, item.CartID)
+		return unavailable(model.CartUnavailableVariantMissing)
+	}
+
+	// Nếu Title có giá trị -> Dùng nó
+	if variant.Title != nil {
+		line.VariantName = *variant.Title
+	} else {
+		line.VariantName = variant.SKU
+	}
+	line.StockQuantity = variant.StockQuantity
+	line.WeightGrams = utils.LineWeightGrams(variant.WeightGrams, 1)
+
+	// Lấy Product (GetProductByID đã loại sản phẩm trong thùng rác)
+	product, err := c.ProductRepo.GetProductByID(item.ProductID)
+	if err != nil || product == nil {
+		logger.WarnLogger.Printf("Product ID %d not found in cart %d", item.ProductID, item.CartID)
+		return unavailable(model.CartUnavailableProductMissing)
+	}
+	line.ProductName = product.Name
+
+	// Xử lý Giá (Price)
+	line.Price = currentPrice(variant, product)
+	line.SubTotal = line.Price * float64(item.Quantity)
+
+	if !variant.IsActive {
+		return unavailable(model.CartUnavailableVariantInactive)
+	}
+	if !product.IsVisible(now) {
+		return unavailable(model.CartUnavailableProductNotPublic)
+	}
+	line.Available = true
+
+	// So sánh với giá lúc thêm vào giỏ
+	if item.PriceSnapshot != nil && math.Abs(line.Price-*item.PriceSnapshot) >= priceEpsilon {
+		noticeType := model.CartNoticePriceDecreased
+		if line.Price > *item.PriceSnapshot {
+			noticeType = model.CartNoticePriceIncreased
 		}
+		oldPrice, newPrice := *item.PriceSnapshot, line.Price
+		line.Notices = append(line.Notices, model.CartNotice{
+			Type:      noticeType,
+			VariantID: item.VariantID,
+			OldPrice:  &oldPrice,
+			NewPrice:  &newPrice,
+		})
+	}
 
-		// Lấy Product
-		product, err := c.ProductRepo.GetProductByID(item.ProductID)
-		if err != nil || product == nil {
-			logger.WarnLogger.Printf("Product ID %d not found, skipping...", item.ProductID)
-			continue
+	// Kiểm tra tồn kho (cho phép đặt trước thì bỏ qua)
+	line.StockCheck = variant.AllowBackorder || item.Quantity <= variant.StockQuantity
+	if !line.StockCheck {
+		noticeType := model.CartNoticeQuantityReduced
+		oldQty, newQty := item.Quantity, variant.StockQuantity
+		if newQty <= 0 {
+			noticeType, newQty = model.CartNoticeOutOfStock, 0
 		}
+		line.Notices = append(line.Notices, model.CartNotice{
+			Type:        noticeType,
+			VariantID:   item.VariantID,
+			OldQuantity: &oldQty,
+			NewQuantity: &newQty,
+		})
+	}
 
+	return line
+}
 
-		// Xử lý Giá (Price)
-		var currentPrice float64
-		// Nếu PriceOverride có giá trị (không nil) -> Dùng nó
-		if variant.PriceOverride != nil {
-			currentPrice = *variant.PriceOverride // Dereference (*) để lấy giá trị thực
-		} else {
-			currentPrice = product.MinPrice
+// applyAutoFix: Giảm số lượng về tồn kho và chấp nhận giá mới cho 1 dòng, đánh dấu các notice đã xử lý
+func (c *cartController) applyAutoFix(ctx context.Context, cartID int64, line *model.CartItemResponse) error {
+	for i := range line.Notices {
+		n := &line.Notices[i]
+		switch n.Type {
+		case model.CartNoticeQuantityReduced:
+			if err := c.CartRepo.UpdateItemQuantity(ctx, cartID, line.VariantID, *n.NewQuantity); err != nil {
+				return err
+			}
+			line.Quantity = *n.NewQuantity
+			line.SubTotal = line.Price * float64(line.Quantity)
+			line.StockCheck = true
+			n.Fixed = true
+		case model.CartNoticePriceIncreased, model.CartNoticePriceDecreased:
+			if err := c.CartRepo.UpdateItemPriceSnapshot(ctx, cartID, line.VariantID, line.Price); err != nil {
+				return err
+			}
+			price := line.Price
+			line.PriceSnapshot = &price
+			n.Fixed = true
 		}
+	}
+	return nil
+}
 
-		var variantName string
-		// Nếu Title có giá trị -> Dùng nó
-		if variant.Title != nil {
-			variantName = *variant.Title 
-		} else {
-			variantName = variant.SKU
-		}
+// GetCart: Lấy chi tiết giỏ hàng, kèm thông báo thay đổi giá / tồn kho trên từng dòng
+func (c *cartController) GetCart(ctx context.Context, owner model.CartOwner, autoFix bool) (model.CartResponse, error) {
+	logger.DebugLogger.Printf("Controller: Getting cart for user %d (guest=%v)", owner.UserID, owner.IsGuest())
+
+	//  Lấy ID giỏ hàng
+	cartID, err := c.findCartID(ctx, owner)
+	if err != nil {
+		return model.CartResponse{}, err
+	}
+
+	if cartID == 0 {
+		return model.CartResponse{UserID: owner.UserID, IsGuest: owner.IsGuest(), Items: []model.CartItemResponse{}}, nil
+	}
+
+	// Lấy danh sách items thô
+	rawItems, err := c.CartRepo.GetCartItems(ctx, cartID)
+	if err != nil {
+		return model.CartResponse{}, err
+	}
 
+	now := time.Now()
+	responseItems := make([]model.CartItemResponse, 0, len(rawItems))
+	hasNotices := false
 
-		subTotal := currentPrice * float64(item.Quantity)
-		stockCheck := item.Quantity <= variant.StockQuantity
+	// Duyệt, map dữ liệu và kiểm tra lại từng dòng
+	for _, item := range rawItems {
+		line := c.buildCartLine(item, now)
 
-		resItem := model.CartItemResponse{
-			ItemID:        item.ID,
-			ProductID:     product.ID,
-			ProductName:   product.Name,
-			VariantID:     variant.ID,
-			VariantName:   variantName, 
-			Price:         currentPrice, 
-			Quantity:      item.Quantity,
-			SubTotal:      subTotal,
-			StockCheck:    stockCheck,
-			StockQuantity: variant.StockQuantity,
+		if autoFix && len(line.Notices) > 0 {
+			if err := c.applyAutoFix(ctx, cartID, &line); err != nil {
+				return model.CartResponse{}, err
+			}
 		}
 
-		responseItems = append(responseItems, resItem)
+		if len(line.Notices) > 0 {
+			hasNotices = true
+		}
+		responseItems = append(responseItems, line)
 	}
 
 	return model.CartResponse{
-		ID:     cartID,
-		UserID: userID,
-		Items:  responseItems,
+		ID:         cartID,
+		UserID:     owner.UserID,
+		IsGuest:    owner.IsGuest(),
+		Items:      responseItems,
+		HasNotices: hasNotices,
 	}, nil
 }
 
 // AddToCart: Thêm vào giỏ (Cộng dồn)
-func (c *cartController) AddToCart(ctx context.Context, userID int64, req model.AddToCartRequest) error {
-	logger.DebugLogger.Printf("Controller: User %d adding variant %d to cart", userID, req.VariantID)
+func (c *cartController) AddToCart(ctx context.Context, owner model.CartOwner, req model.AddToCartRequest) error {
+	logger.DebugLogger.Printf("Controller: User %d (guest=%v) adding variant %d to cart", owner.UserID, owner.IsGuest(), req.VariantID)
 
 	//  Lấy ID giỏ hàng, nếu chưa có thì tạo mới
-	cartID, err := c.CartRepo.GetCartIDByUserID(ctx, userID)
+	cartID, err := c.getOrCreateCartID(ctx, owner)
 	if err != nil {
 		return err
 	}
-	if cartID == 0 {
-		cartID, err = c.CartRepo.CreateCart(ctx, userID)
-		if err != nil {
-			return err
-		}
-	}
 
 	//  Kiểm tra sản phẩm và biến thể có tồn tại không
 	variant, err := c.VariantRepo.GetVariantByID(req.VariantID)
@@ -140,24 +297,38 @@ func (c *cartController) AddToCart(ctx context.Context, userID int64, req model.
 		req.ProductID = variant.ProductID
 	}
 
-	// Kiểm tra tồn kho 
+	// Sản phẩm phải còn đang bán
+	product, err := c.ProductRepo.GetProductByID(variant.ProductID)
+	if err != nil || product == nil || !variant.IsActive || !product.IsVisible(time.Now()) {
+		return errors.New("sản phẩm hiện không còn được bán")
+	}
+
+	// Kiểm tra tồn kho
 	if req.Quantity > variant.StockQuantity {
 		return errors.New("số lượng yêu cầu vượt quá tồn kho hiện tại")
 	}
 
-	// Gọi Repo để Upsert (Thêm mới hoặc cộng dồn)
-	err = c.CartRepo.UpsertCartItem(ctx, cartID, req)
+	// Gọi Repo để Upsert (Thêm mới hoặc cộng dồn), snapshot giá hiện tại
+	price := currentPrice(variant, product)
+	err = c.CartRepo.UpsertCartItem(ctx, cartID, req, price)
 	if err != nil {
 		return err
 	}
 
+	c.recordEvent(ctx, owner, cartID, model.CartEvent{
+		EventType: model.CartEventAddToCart,
+		ProductID: &variant.ProductID,
+		VariantID: &variant.ID,
+		Quantity:  req.Quantity,
+		Amount:    price * float64(req.Quantity),
+	})
 	return nil
 }
 
-//  UpdateCartItem: Cập nhật số lượng 
-func (c *cartController) UpdateCartItem(ctx context.Context, userID int64, variantID int64, req model.UpdateCartItemRequest) error {
+// UpdateCartItem: Cập nhật số lượng
+func (c *cartController) UpdateCartItem(ctx context.Context, owner model.CartOwner, variantID int64, req model.UpdateCartItemRequest) error {
 	//  Tìm giỏ hàng
-	cartID, err := c.CartRepo.GetCartIDByUserID(ctx, userID)
+	cartID, err := c.findCartID(ctx, owner)
 	if err != nil || cartID == 0 {
 		return errors.New("giỏ hàng không tìm thấy")
 	}
@@ -167,37 +338,76 @@ func (c *cartController) UpdateCartItem(ctx context.Context, userID int64, varia
 	if err != nil || variant == nil {
 		return errors.New("sản phẩm không tồn tại")
 	}
-	
+
 	// Check số lượng tồn kho (req.Quantity lấy từ Body JSON)
 	if req.Quantity > variant.StockQuantity {
 		return errors.New("số lượng trong kho không đủ")
 	}
 
+	// Gia hạn giỏ khách
+	if owner.IsGuest() {
+		if err := c.CartRepo.TouchGuestCart(ctx, cartID, time.Now().Add(utils.GuestCartTTL())); err != nil {
+			return err
+		}
+	}
+
 	// Gọi Repo Update
 	return c.CartRepo.UpdateItemQuantity(ctx, cartID, variantID, req.Quantity)
 }
 
 // RemoveCartItems: Xóa sản phẩm
-func (c *cartController) RemoveCartItems(ctx context.Context, userID int64, req model.RemoveFromCartRequest) error {
-	cartID, err := c.CartRepo.GetCartIDByUserID(ctx, userID)
+func (c *cartController) RemoveCartItems(ctx context.Context, owner model.CartOwner, req model.RemoveFromCartRequest) error {
+	cartID, err := c.findCartID(ctx, owner)
 	if err != nil || cartID == 0 {
 		return errors.New("giỏ hàng không tìm thấy")
 	}
 
-	return c.CartRepo.RemoveItems(ctx, cartID, req.VariantIDs)
+	// Lấy các dòng sắp xóa để ghi lại số lượng / giá trị bị bỏ khỏi giỏ
+	items, err := c.CartRepo.GetCartItems(ctx, cartID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.CartRepo.RemoveItems(ctx, cartID, req.VariantIDs); err != nil {
+		return err
+	}
+
+	removed := make(map[int64]bool, len(req.VariantIDs))
+	for _, id := range req.VariantIDs {
+		removed[id] = true
+	}
+	for _, item := range items {
+		if !removed[item.VariantID] {
+			continue
+		}
+		event := model.CartEvent{
+			EventType: model.CartEventRemoveFromCart,
+			ProductID: &item.ProductID,
+			VariantID: &item.VariantID,
+			Quantity:  item.Quantity,
+		}
+		if item.PriceSnapshot != nil {
+			event.Amount = *item.PriceSnapshot * float64(item.Quantity)
+		}
+		c.recordEvent(ctx, owner, cartID, event)
+	}
+	return nil
 }
 
-//  CalculateCheckoutPreview: Tính tiền cho các món được chọn
-func (c *cartController) CalculateCheckoutPreview(ctx context.Context, userID int64, req model.CheckoutPreviewRequest) (model.CheckoutPreviewResponse, error) {
-	//  Lấy toàn bộ giỏ hàng 
-	fullCart, err := c.GetCart(ctx, userID)
+// CalculateCheckoutPreview: Tính tiền cho các món được chọn
+func (c *cartController) CalculateCheckoutPreview(ctx context.Context, owner model.CartOwner, req model.CheckoutPreviewRequest) (model.CheckoutPreviewResponse, error) {
+	//  Lấy toàn bộ giỏ hàng (đã kiểm tra lại giá / tồn kho)
+	fullCart, err := c.GetCart(ctx, owner, req.AutoFix)
 	if err != nil {
 		return model.CheckoutPreviewResponse{}, err
 	}
 
 	var totalPrice float64 = 0
 	var totalItems int = 0
-	var selectedItems []model.CartItemResponse
+	var totalWeight int = 0
+	selectedItems := []model.CartItemResponse{}
+	notices := []model.CartNotice{}
+	canCheckout := true
 
 	// Map để tra cứu nhanh các ID được chọn
 	selectedMap := make(map[int64]bool)
@@ -207,22 +417,177 @@ func (c *cartController) CalculateCheckoutPreview(ctx context.Context, userID in
 
 	// Lọc ra những món user chọn và tính tổng
 	for _, item := range fullCart.Items {
-		if selectedMap[item.VariantID] {
-			// Check lại tồn kho 
-			if !item.StockCheck {
-				return model.CheckoutPreviewResponse{}, errors.New("một số sản phẩm đã hết hàng, vui lòng kiểm tra lại")
+		if !selectedMap[item.VariantID] {
+			continue
+		}
+		selectedItems = append(selectedItems, item)
+		for _, n := range item.Notices {
+			if !n.Fixed {
+				notices = append(notices, n)
 			}
+		}
+
+		// Dòng không mua được (ngừng bán / hết hàng / thiếu hàng) không tính vào tổng
+		if !item.Available || !item.StockCheck {
+			canCheckout = false
+			continue
+		}
+
+		totalPrice += item.SubTotal
+		totalItems += item.Quantity
+		totalWeight += item.WeightGrams * item.Quantity
+	}
+
+	resp := model.CheckoutPreviewResponse{
+		TotalPrice:  totalPrice,
+		TotalItems:  totalItems,
+		Items:       selectedItems,
+		Notices:     notices,
+		CanCheckout: canCheckout && len(selectedItems) > 0,
+		GrandTotal:  totalPrice,
+	}
 
-			totalPrice += item.SubTotal
-			totalItems += item.Quantity
-			selectedItems = append(selectedItems, item)
+	// Tính phí giao hàng khi đã chọn địa chỉ
+	if req.AddressID > 0 && totalItems > 0 {
+		if err := c.applyShipping(ctx, owner, req, totalWeight, &resp); err != nil {
+			return model.CheckoutPreviewResponse{}, err
 		}
 	}
 
+	if fullCart.ID > 0 && len(selectedItems) > 0 {
+		c.recordEvent(ctx, owner, fullCart.ID, model.CartEvent{
+			EventType: model.CartEventCheckoutPreview,
+			Quantity:  totalItems,
+			Amount:    resp.GrandTotal,
+		})
+	}
+
 	//  Trả về kết quả
-	return model.CheckoutPreviewResponse{
-		TotalPrice: totalPrice,
-		TotalItems: totalItems,
-		Items:      selectedItems,
-	}, nil
-}
\ No newline at end of file
+	return resp, nil
+}
+
+// applyShipping: Gắn các phương thức giao hàng + phí của phương thức đang chọn vào preview.
+// Địa chỉ chưa được hỗ trợ / phương thức không dùng được thì báo qua ShippingError và chặn checkout
+func (c *cartController) applyShipping(ctx context.Context, owner model.CartOwner, req model.CheckoutPreviewRequest, totalWeight int, resp *model.CheckoutPreviewResponse) error {
+	if owner.IsGuest() {
+		return ErrAddressNeedsLogin
+	}
+
+	addr, err := c.AddressRepo.GetAddressByID(req.AddressID, owner.UserID)
+	if err != nil {
+		logger.WarnLogger.Printf("Preview: Address %d not found for user %d", req.AddressID, owner.UserID)
+		return ErrInvalidCartAddress
+	}
+	dest := model.ShippingDestination{Country: addr.Country, State: addr.State, City: addr.City, ProvinceCode: addr.ProvinceCode}
+
+	options, err := c.Shipping.QuoteAll(ctx, dest, resp.TotalPrice, totalWeight)
+	if err != nil {
+		resp.ShippingError = err.Error()
+		resp.CanCheckout = false
+		return nil
+	}
+	resp.ShippingOptions = options
+
+	methodCode := req.ShippingMethod
+	if methodCode == "" {
+		methodCode = model.ShippingMethodStandard
+	}
+	quote, err := c.Shipping.Quote(ctx, dest, methodCode, resp.TotalPrice, totalWeight)
+	if err != nil {
+		resp.ShippingError = err.Error()
+		resp.CanCheckout = false
+		return nil
+	}
+
+	resp.Shipping = quote
+	resp.ShippingFee = quote.Fee
+	resp.GrandTotal = resp.TotalPrice + quote.Fee
+	return nil
+}
+
+// MergeGuestCart: Gộp giỏ hàng khách vào giỏ của user (cộng dồn như AddToCart, giới hạn theo tồn kho)
+func (c *cartController) MergeGuestCart(ctx context.Context, userID int64, cartToken string) error {
+	guestToken, ok := utils.ParseCartToken(cartToken)
+	if !ok {
+		return ErrInvalidCartToken
+	}
+
+	guestCartID, err := c.CartRepo.GetCartIDByGuestToken(ctx, guestToken)
+	if err != nil {
+		return err
+	}
+	if guestCartID == 0 {
+		// Giỏ khách không tồn tại hoặc đã hết hạn -> không có gì để gộp
+		return nil
+	}
+
+	guestItems, err := c.CartRepo.GetCartItems(ctx, guestCartID)
+	if err != nil {
+		return err
+	}
+
+	var lines []model.CartMergeLine
+	for _, item := range guestItems {
+		variant, err := c.VariantRepo.GetVariantByID(item.VariantID)
+		if err != nil || variant == nil || variant.StockQuantity <= 0 {
+			logger.WarnLogger.Printf("Merge cart: bỏ qua variant %d (không tồn tại hoặc hết hàng)", item.VariantID)
+			continue
+		}
+
+		quantity := item.Quantity
+		if quantity > variant.StockQuantity {
+			quantity = variant.StockQuantity
+		}
+
+		lines = append(lines, model.CartMergeLine{
+			ProductID:     item.ProductID,
+			VariantID:     item.VariantID,
+			Quantity:      quantity,
+			MaxQuantity:   variant.StockQuantity,
+			PriceSnapshot: item.PriceSnapshot,
+		})
+	}
+
+	userCartID, err := c.getOrCreateCartID(ctx, model.CartOwner{UserID: userID})
+	if err != nil {
+		return err
+	}
+
+	if err := c.CartRepo.MergeCartItems(ctx, guestCartID, userCartID, lines); err != nil {
+		return err
+	}
+
+	// Lịch sử của giỏ khách tính tiếp vào giỏ user (phễu bán hàng)
+	if err := c.CartRepo.ReassignCartEvents(ctx, guestCartID, userCartID, userID); err != nil {
+		logger.WarnLogger.Printf("Merge cart: không chuyển được lịch sử giỏ khách %d: %v", guestCartID, err)
+	}
+
+	logger.InfoLogger.Printf("Đã gộp giỏ khách %d vào giỏ của user %d (%d sản phẩm)", guestCartID, userID, len(lines))
+	return nil
+}
+
+// PurgeAbandonedGuestCarts: Cron Job xóa giỏ hàng khách đã hết hạn
+func (c *cartController) PurgeAbandonedGuestCarts(ctx context.Context) error {
+	purged, err := c.CartRepo.DeleteExpiredGuestCarts(ctx)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		logger.InfoLogger.Printf("Đã xóa %d giỏ hàng khách hết hạn", purged)
+	}
+	return nil
+}
+
+// RecordOrderCreated: Ghi sự kiện tạo đơn vào giỏ của user (bước cuối của phễu bán hàng)
+func (c *cartController) RecordOrderCreated(ctx context.Context, userID int64, orderID int64, totalItems int, totalAmount float64) {
+	cartID, err := c.CartRepo.GetCartIDByUserID(ctx, userID)
+	if err != nil {
+		logger.WarnLogger.Printf("Controller: Cannot find cart of user %d for order event: %v", userID, err)
+	}
+	c.recordEvent(ctx, model.CartOwner{UserID: userID}, cartID, model.CartEvent{
+		EventType: model.CartEventOrderCreated,
+		Quantity:  totalItems,
+		Amount:    totalAmount,
+		OrderID:   &orderID,
+	})
+}
@@ -6,18 +6,28 @@ import (
 )
 
 type CartController interface {
-	//  Lấy chi tiết giỏ hàng (kèm thông tin sản phẩm, giá, tính tổng tiền...)
-	GetCart(ctx context.Context, userID int64) (model.CartResponse, error)
+	//  Lấy chi tiết giỏ hàng (kèm thông tin sản phẩm, giá, tính tổng tiền...) và thông báo thay đổi giá / tồn kho.
+	//  autoFix = true: giảm số lượng về tồn kho và chấp nhận giá mới
+	GetCart(ctx context.Context, owner model.CartOwner, autoFix bool) (model.CartResponse, error)
 
 	//  Thêm sản phẩm vào giỏ (Logic cộng dồn)
-	AddToCart(ctx context.Context, userID int64, req model.AddToCartRequest) error
+	AddToCart(ctx context.Context, owner model.CartOwner, req model.AddToCartRequest) error
 
 	//  Cập nhật số lượng item (Logic ghi đè)
-	UpdateCartItem(ctx context.Context, userID int64, variantID int64, req model.UpdateCartItemRequest) error
+	UpdateCartItem(ctx context.Context, owner model.CartOwner, variantID int64, req model.UpdateCartItemRequest) error
 
 	//  Xóa một hoặc nhiều sản phẩm khỏi giỏ
-	RemoveCartItems(ctx context.Context, userID int64, req model.RemoveFromCartRequest) error
+	RemoveCartItems(ctx context.Context, owner model.CartOwner, req model.RemoveFromCartRequest) error
 
 	//  (Tính năng nâng cao) Tính toán tạm tính cho các sản phẩm được chọn (Checkbox)
-	CalculateCheckoutPreview(ctx context.Context, userID int64, req model.CheckoutPreviewRequest) (model.CheckoutPreviewResponse, error)
+	CalculateCheckoutPreview(ctx context.Context, owner model.CartOwner, req model.CheckoutPreviewRequest) (model.CheckoutPreviewResponse, error)
+
+	//  Gộp giỏ hàng khách (theo cart token) vào giỏ của user khi đăng nhập / đăng ký
+	MergeGuestCart(ctx context.Context, userID int64, cartToken string) error
+
+	//  Cron Job: xóa giỏ hàng khách bị bỏ quên (đã hết hạn)
+	PurgeAbandonedGuestCarts(ctx context.Context) error
+
+	//  Ghi sự kiện tạo đơn cho báo cáo phễu bán hàng (module order gọi sau khi tạo đơn)
+	RecordOrderCreated(ctx context.Context, userID int64, orderID int64, totalItems int, totalAmount float64)
 }
\ No newline at end of file
@@ -1,11 +1,13 @@
 package category
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"golang/internal/logger"
 	"golang/internal/model"
 	"golang/internal/repository/category"
+	"golang/internal/utils"
 
 	"github.com/gosimple/slug"
 )
@@ -43,6 +45,13 @@ func (c *categoryController) CreateCategory(req model.CreateCategoryRequest) (mo
 		return model.AdminCategoryResponse{}, errors.New("slug danh mục đã tồn tại, vui lòng chọn tên khác")
 	}
 
+	// Kiểm tra danh mục cha (nếu có)
+	if req.ParentID != nil {
+		if _, err := c.CategoryRepo.GetCategoryByID(*req.ParentID); err != nil {
+			return model.AdminCategoryResponse{}, errors.New("không tìm thấy danh mục cha")
+		}
+	}
+
 	isActive := true
 	if req.IsActive != nil {
 		isActive = *req.IsActive
@@ -53,6 +62,7 @@ func (c *categoryController) CreateCategory(req model.CreateCategoryRequest) (mo
 		Slug:        finalSlug,
 		Description: &req.Description, 
 		IsActive:    isActive,
+		ParentID:    req.ParentID,
 	}
 	if req.Description == "" {
 		newCat.Description = nil
@@ -74,6 +84,8 @@ func (c *categoryController) CreateCategory(req model.CreateCategoryRequest) (mo
 		Slug:        createdCat.Slug,
 		Description: createdCat.Description,
 		IsActive:    createdCat.IsActive,
+		ParentID:    createdCat.ParentID,
+		Position:    createdCat.Position,
 		CreatedAt:   createdCat.CreatedAt,
 		UpdatedAt:   createdCat.UpdatedAt,
 	}
@@ -122,6 +134,8 @@ func (c *categoryController) UpdateCategory(id int64, req model.UpdateCategoryRe
 		Slug:        updatedCat.Slug,
 		Description: updatedCat.Description,
 		IsActive:    updatedCat.IsActive,
+		ParentID:    updatedCat.ParentID,
+		Position:    updatedCat.Position,
 		CreatedAt:   updatedCat.CreatedAt,
 		UpdatedAt:   updatedCat.UpdatedAt,
 	}
@@ -143,7 +157,15 @@ func (c *categoryController) UpdateCategory(id int64, req model.UpdateCategoryRe
 // DeleteManyCategories - Xóa mềm nhiều danh mục
 func (c *categoryController) DeleteSoftCategories(req model.DeleteManyCategoriesRequest) error {
 	logger.WarnLogger.Printf("Admin yêu cầu xóa %d danh mục", len(req.IDs))
-	err := c.CategoryRepo.DeleteSoftCategories(req.IDs)
+
+	// Ẩn danh mục thì ẩn luôn toàn bộ cây con
+	rows, err := c.CategoryRepo.GetCategoryTreeRows()
+	if err != nil {
+		return err
+	}
+	ids := descendantIDs(rows, req.IDs)
+
+	err = c.CategoryRepo.DeleteSoftCategories(ids)
 	if err != nil {
 		logger.ErrorLogger.Printf("Lỗi xóa nhiều danh mục: %v", err)
 		return err
@@ -179,6 +201,8 @@ func (c *categoryController) AdminGetAllCategories(req model.AdminGetCategoriesR
 			Slug:        cat.Slug,
 			Description: cat.Description,
 			IsActive:    cat.IsActive,
+			ParentID:    cat.ParentID,
+			Position:    cat.Position,
 			CreatedAt:   cat.CreatedAt,
 			UpdatedAt:   cat.UpdatedAt,
 		})
@@ -204,6 +228,8 @@ func (c *categoryController) AdminGetCategoryByID(id int64) (model.AdminCategory
 		Slug:        cat.Slug,
 		Description: cat.Description,
 		IsActive:    cat.IsActive,
+		ParentID:    cat.ParentID,
+		Position:    cat.Position,
 		CreatedAt:   cat.CreatedAt,
 		UpdatedAt:   cat.UpdatedAt,
 	}, nil
@@ -225,6 +251,8 @@ func (c *categoryController) AdminSearchCategories(keyword string, isActive *boo
 			Slug:        cat.Slug,
 			Description: cat.Description,
 			IsActive:    cat.IsActive, 
+			ParentID:    cat.ParentID,
+			Position:    cat.Position,
 			CreatedAt:   cat.CreatedAt,
 			UpdatedAt:   cat.UpdatedAt,
 		})
@@ -287,4 +315,238 @@ func (c *categoryController) UserSearchCategories(keyword string) ([]model.UserC
 	return response, nil
 }
 
+// MoveCategory - Chuyển danh mục sang cha khác / đổi thứ tự trong nhóm anh em
+func (c *categoryController) MoveCategory(id int64, req model.MoveCategoryRequest) (model.AdminCategoryResponse, error) {
+	logger.InfoLogger.Printf("Admin chuyển danh mục ID: %d", id)
+
+	rows, err := c.CategoryRepo.GetCategoryTreeRows()
+	if err != nil {
+		return model.AdminCategoryResponse{}, err
+	}
+
+	parentOf := make(map[int64]*int64, len(rows))
+	for _, cat := range rows {
+		parentOf[cat.ID] = cat.ParentID
+	}
+	if _, ok := parentOf[id]; !ok {
+		return model.AdminCategoryResponse{}, errors.New("không tìm thấy danh mục cần chuyển")
+	}
+
+	if req.ParentID != nil {
+		if _, ok := parentOf[*req.ParentID]; !ok {
+			return model.AdminCategoryResponse{}, errors.New("không tìm thấy danh mục cha")
+		}
+		// Chống vòng lặp: cha mới không được là chính nó hoặc nằm trong cây con của nó
+		for cur := req.ParentID; cur != nil; cur = parentOf[*cur] {
+			if *cur == id {
+				return model.AdminCategoryResponse{}, errors.New("danh mục cha không hợp lệ: không thể chuyển danh mục vào chính nó hoặc danh mục con của nó")
+			}
+		}
+	}
+
+	// Không truyền position -> đứng cuối
+	position := -1
+	if req.Position != nil {
+		position = *req.Position
+	}
+
+	if err := c.CategoryRepo.MoveCategory(id, req.ParentID, position); err != nil {
+		logger.ErrorLogger.Printf("Lỗi chuyển danh mục: %v", err)
+		return model.AdminCategoryResponse{}, err
+	}
+
+	return c.AdminGetCategoryByID(id)
+}
+
+// GetCategoryTree - Dựng cây danh mục (activeOnly: bỏ nhánh đang ẩn)
+func (c *categoryController) GetCategoryTree(activeOnly bool) ([]*model.CategoryTreeNode, error) {
+	rows, err := c.CategoryRepo.GetCategoryTreeRows()
+	if err != nil {
+		logger.ErrorLogger.Printf("Lỗi lấy cây danh mục: %v", err)
+		return nil, err
+	}
+
+	nodes := make(map[int64]*model.CategoryTreeNode, len(rows))
+	for _, cat := range rows {
+		if activeOnly && !cat.IsActive {
+			continue
+		}
+		nodes[cat.ID] = &model.CategoryTreeNode{
+			ID:       cat.ID,
+			Name:     cat.Name,
+			Slug:     cat.Slug,
+			IsActive: cat.IsActive,
+			Position: cat.Position,
+			Children: []*model.CategoryTreeNode{},
+		}
+	}
+
+	// rows đã sắp theo position nên thứ tự con được giữ nguyên
+	roots := []*model.CategoryTreeNode{}
+	for _, cat := range rows {
+		node, ok := nodes[cat.ID]
+		if !ok {
+			continue
+		}
+		if cat.ParentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		// Cha bị ẩn thì cả nhánh con cũng không hiển thị
+		if parent, ok := nodes[*cat.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	return roots, nil
+}
+
+// descendantIDs - Trả về ids kèm toàn bộ danh mục con cháu của chúng
+func descendantIDs(rows []model.Category, ids []int64) []int64 {
+	children := make(map[int64][]int64)
+	for _, cat := range rows {
+		if cat.ParentID != nil {
+			children[*cat.ParentID] = append(children[*cat.ParentID], cat.ID)
+		}
+	}
+
+	seen := make(map[int64]bool)
+	result := []int64{}
+	queue := append([]int64{}, ids...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+		queue = append(queue, children[id]...)
+	}
+	return result
+}
+
+// GetCategoryAttributes - Schema thông số của danh mục (gồm cả thông số kế thừa từ danh mục cha)
+func (c *categoryController) GetCategoryAttributes(categoryID int64, filterableOnly bool) ([]model.CategoryAttribute, error) {
+	if _, err := c.CategoryRepo.GetCategoryByID(categoryID); err != nil {
+		return nil, errors.New("không tìm thấy danh mục")
+	}
+
+	attrs, err := c.CategoryRepo.GetEffectiveAttributes([]int64{categoryID})
+	if err != nil {
+		logger.ErrorLogger.Printf("Lỗi lấy thông số danh mục: %v", err)
+		return nil, err
+	}
+	if !filterableOnly {
+		return attrs, nil
+	}
+
+	filterable := []model.CategoryAttribute{}
+	for _, attr := range attrs {
+		if attr.IsFilterable {
+			filterable = append(filterable, attr)
+		}
+	}
+	return filterable, nil
+}
+
+// CreateCategoryAttribute - Thêm thông số cho danh mục
+func (c *categoryController) CreateCategoryAttribute(categoryID int64, req model.CreateCategoryAttributeRequest) (*model.CategoryAttribute, error) {
+	logger.InfoLogger.Printf("Admin thêm thông số '%s' cho danh mục ID: %d", req.Code, categoryID)
+
+	if _, err := c.CategoryRepo.GetCategoryByID(categoryID); err != nil {
+		return nil, errors.New("không tìm thấy danh mục")
+	}
+
+	code := slug.Make(req.Code)
+	if code == "" {
+		return nil, errors.New("code thuộc tính không hợp lệ")
+	}
+
+	var unit *string
+	if req.Unit != "" {
+		unit = &req.Unit
+	}
+	if err := utils.ValidateAttributeDefinition(req.DataType, unit, req.EnumValues); err != nil {
+		return nil, fmt.Errorf("thuộc tính không hợp lệ: %w", err)
+	}
+
+	// Code phải duy nhất trên cả nhánh cây (cha + con) để sản phẩm không gặp 2 định nghĩa cùng code
+	isExist, err := c.CategoryRepo.CheckAttributeCodeInLineage(categoryID, code)
+	if err != nil {
+		return nil, err
+	}
+	if isExist {
+		return nil, fmt.Errorf("code '%s' đã tồn tại trong nhánh danh mục", code)
+	}
+
+	attr, err := c.CategoryRepo.CreateCategoryAttribute(&model.CategoryAttribute{
+		CategoryID:   categoryID,
+		Code:         code,
+		Name:         req.Name,
+		DataType:     req.DataType,
+		Unit:         unit,
+		EnumValues:   req.EnumValues,
+		IsRequired:   req.IsRequired,
+		IsFilterable: req.IsFilterable,
+		Position:     req.Position,
+	})
+	if err != nil {
+		logger.ErrorLogger.Printf("Lỗi thêm thông số: %v", err)
+		return nil, err
+	}
+	return attr, nil
+}
 
+// UpdateCategoryAttribute - Cập nhật thông số (giá trị cũ của sản phẩm được kiểm tra lại ở lần lưu kế tiếp)
+func (c *categoryController) UpdateCategoryAttribute(categoryID, attrID int64, req model.UpdateCategoryAttributeRequest) (*model.CategoryAttribute, error) {
+	logger.InfoLogger.Printf("Admin cập nhật thông số ID %d của danh mục ID: %d", attrID, categoryID)
+
+	attr, err := c.CategoryRepo.GetCategoryAttributeByID(categoryID, attrID)
+	if err != nil {
+		return nil, errors.New("không tìm thấy thông số")
+	}
+
+	if req.Name != nil {
+		attr.Name = *req.Name
+	}
+	if req.Unit != nil {
+		attr.Unit = req.Unit
+		if *req.Unit == "" {
+			attr.Unit = nil
+		}
+	}
+	if req.EnumValues != nil {
+		attr.EnumValues = req.EnumValues
+	}
+	if req.IsRequired != nil {
+		attr.IsRequired = *req.IsRequired
+	}
+	if req.IsFilterable != nil {
+		attr.IsFilterable = *req.IsFilterable
+	}
+	if req.Position != nil {
+		attr.Position = *req.Position
+	}
+
+	if err := utils.ValidateAttributeDefinition(attr.DataType, attr.Unit, attr.EnumValues); err != nil {
+		return nil, fmt.Errorf("thuộc tính không hợp lệ: %w", err)
+	}
+
+	if err := c.CategoryRepo.UpdateCategoryAttribute(attr); err != nil {
+		logger.ErrorLogger.Printf("Lỗi cập nhật thông số: %v", err)
+		return nil, err
+	}
+	return c.CategoryRepo.GetCategoryAttributeByID(categoryID, attrID)
+}
+
+// DeleteCategoryAttribute - Xóa thông số (kèm giá trị đã lưu của sản phẩm)
+func (c *categoryController) DeleteCategoryAttribute(categoryID, attrID int64) error {
+	logger.WarnLogger.Printf("Admin xóa thông số ID %d của danh mục ID: %d", attrID, categoryID)
+
+	err := c.CategoryRepo.DeleteCategoryAttribute(categoryID, attrID)
+	if err == sql.ErrNoRows {
+		return errors.New("không tìm thấy thông số")
+	}
+	return err
+}
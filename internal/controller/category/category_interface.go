@@ -29,6 +29,20 @@ type CategoryController interface {
 	// Tìm kiếm danh mục
 	AdminSearchCategories(keyword string, isActive *bool) ([]model.AdminCategoryResponse, error)
 
+	// Chuyển danh mục sang cha khác / đổi vị trí
+	MoveCategory(id int64, req model.MoveCategoryRequest) (model.AdminCategoryResponse, error)
+
+	// Dựng cây danh mục (activeOnly = true cho User)
+	GetCategoryTree(activeOnly bool) ([]*model.CategoryTreeNode, error)
+
+	// Schema thông số của danh mục (kể cả kế thừa từ cha)
+	GetCategoryAttributes(categoryID int64, filterableOnly bool) ([]model.CategoryAttribute, error)
+
+	// Thêm / sửa / xóa thông số của danh mục
+	CreateCategoryAttribute(categoryID int64, req model.CreateCategoryAttributeRequest) (*model.CategoryAttribute, error)
+	UpdateCategoryAttribute(categoryID, attrID int64, req model.UpdateCategoryAttributeRequest) (*model.CategoryAttribute, error)
+	DeleteCategoryAttribute(categoryID, attrID int64) error
+
 	// User Methods
 	// Lấy danh mục đang hoạt động
 	UserGetActiveCategories() ([]model.UserCategoryResponse, error)
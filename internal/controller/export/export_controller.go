@@ -0,0 +1,485 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang/internal/logger"
+	"golang/internal/model"
+	exportRepo "golang/internal/repository/export"
+	orderRepo "golang/internal/repository/order"
+	statsRepo "golang/internal/repository/stats"
+	userRepo "golang/internal/repository/user"
+	"golang/internal/utils"
+)
+
+// Giá trị mặc định, ghi đè bằng biến môi trường EXPORT_*
+const (
+	defaultExportDir      = "exports"
+	defaultSyncMaxRows    = 5000 // Nhiều hơn thì chạy nền
+	defaultMaxConcurrent  = 2    // Số job nền chạy cùng lúc
+	defaultRetentionHours = 24   // Thời gian giữ file để tải về
+)
+
+const exportTimeLayout = "2006-01-02 15:04:05"
+
+var (
+	ErrExportJobNotFound = errors.New("không tìm thấy job export")
+	ErrExportNotReady    = errors.New("file export đang được tạo, vui lòng thử lại sau")
+	ErrExportJobFailed   = errors.New("job export bị lỗi, vui lòng export lại")
+	ErrExportExpired     = errors.New("file export đã hết hạn, vui lòng export lại")
+	ErrExportTooManyRows = errors.New("dữ liệu vượt quá 1.048.575 dòng của Excel, hãy thu hẹp bộ lọc hoặc dùng định dạng csv")
+)
+
+// exportSource: Nguồn dữ liệu của 1 lần export
+type exportSource struct {
+	kind     string
+	baseName string // Tên file (không gồm phần mở rộng)
+	sheet    string
+	filters  interface{}
+	count    func(ctx context.Context) (int64, error)
+	write    func(ctx context.Context, tw utils.TableWriter) (int64, error) // Trả về số dòng dữ liệu đã ghi
+}
+
+type exportController struct {
+	ExportRepo exportRepo.ExportRepository
+	OrderRepo  orderRepo.IOrderRepository
+	UserRepo   userRepo.UserRepo
+	StatsRepo  statsRepo.IStatsRepository
+
+	dir         string
+	syncMaxRows int64
+	retention   time.Duration
+
+	// Giới hạn số job nền chạy cùng lúc
+	slots chan struct{}
+}
+
+func NewExportController(
+	exportRepository exportRepo.ExportRepository,
+	orderRepository orderRepo.IOrderRepository,
+	userRepository userRepo.UserRepo,
+	statsRepository statsRepo.IStatsRepository,
+) ExportController {
+	dir := os.Getenv("EXPORT_DIR")
+	if dir == "" {
+		dir = defaultExportDir
+	}
+	return &exportController{
+		ExportRepo:  exportRepository,
+		OrderRepo:   orderRepository,
+		UserRepo:    userRepository,
+		StatsRepo:   statsRepository,
+		dir:         dir,
+		syncMaxRows: int64(utils.EnvInt("EXPORT_SYNC_MAX_ROWS", defaultSyncMaxRows)),
+		retention:   time.Duration(utils.EnvInt("EXPORT_RETENTION_HOURS", defaultRetentionHours)) * time.Hour,
+		slots:       make(chan struct{}, utils.EnvInt("EXPORT_MAX_CONCURRENT", defaultMaxConcurrent)),
+	}
+}
+
+// ===== Nguồn dữ liệu =====
+
+// exportBaseName: VD orders_2025-01-01_2025-01-31, không lọc ngày thì dùng ngày export
+func exportBaseName(prefix, startDate, endDate string) string {
+	switch {
+	case startDate != "" && endDate != "":
+		return prefix + "_" + startDate + "_" + endDate
+	case startDate != "":
+		return prefix + "_from_" + startDate
+	case endDate != "":
+		return prefix + "_to_" + endDate
+	}
+	return prefix + "_" + time.Now().Format("20060102")
+}
+
+func timeText(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format(exportTimeLayout)
+}
+
+// optionText: {"Màu":"Đỏ","Size":"L"} -> "Màu: Đỏ, Size: L"
+func optionText(raw *string) string {
+	if raw == nil || *raw == "" {
+		return ""
+	}
+	values, err := utils.ParseOptionValues(*raw)
+	if err != nil {
+		return *raw
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + ": " + values[k]
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (c *exportController) ordersSource(filter model.OrderFilter) exportSource {
+	return exportSource{
+		kind:     model.ExportKindOrders,
+		baseName: exportBaseName("orders", filter.StartDate, filter.EndDate),
+		sheet:    "Đơn hàng",
+		filters:  filter,
+		count: func(ctx context.Context) (int64, error) {
+			return c.OrderRepo.CountOrderExportLines(ctx, filter)
+		},
+		write: func(ctx context.Context, tw utils.TableWriter) (int64, error) {
+			if err := tw.WriteRow(
+				"Mã đơn", "Ngày đặt", "Trạng thái", "Thanh toán", "Mã KH", "Tài khoản", "Email",
+				"Người nhận", "SĐT", "Địa chỉ giao hàng",
+				"SKU", "Sản phẩm", "Phân loại", "Đơn giá", "Số lượng", "Thành tiền", "Thành tiền (số)",
+				"Tiền hàng", "Phí giao hàng", "Tổng đơn", "Tổng đơn (số)", "Ngày thanh toán", "Ngày hoàn thành",
+			); err != nil {
+				return 0, err
+			}
+
+			var rows, lastOrderID int64
+			err := c.OrderRepo.EachOrderExportLine(ctx, filter, func(l model.OrderExportLine) error {
+				rows++
+				// Tiền của đơn chỉ ghi ở dòng đầu tiên của đơn để cộng cột không bị nhân lên.
+				// SubtotalAmount của đơn cũ chưa tách phí giao hàng đã được repo lấy theo total_amount
+				var subtotal, shippingFee, total, totalNumber interface{}
+				if l.OrderID != lastOrderID {
+					lastOrderID = l.OrderID
+					subtotal = utils.FormatVND(l.SubtotalAmount)
+					shippingFee = utils.FormatVND(l.ShippingFee)
+					total = utils.FormatVND(l.TotalAmount)
+					totalNumber = l.TotalAmount
+				}
+				return tw.WriteRow(
+					l.OrderNumber, timeText(&l.PlacedAt), l.Status, l.PaymentStatus, l.UserID, l.Username, l.Email,
+					l.RecipientName, l.Phone, l.ShippingAddress,
+					l.SKU, l.Title, optionText(l.OptionValues), utils.FormatVND(l.UnitPrice), l.Quantity,
+					utils.FormatVND(l.LineSubtotal), l.LineSubtotal,
+					subtotal, shippingFee, total, totalNumber, timeText(l.PaidAt), timeText(l.CompletedAt),
+				)
+			})
+			return rows, err
+		},
+	}
+}
+
+func (c *exportController) topProductsSource(filter model.StatsFilter) exportSource {
+	// Mặc định 30 ngày gần nhất giống màn hình Top sản phẩm
+	if filter.StartDate == "" {
+		filter.StartDate = time.Now().AddDate(0, 0, -30).Format("2006-01-02")
+	}
+	if filter.EndDate == "" {
+		filter.EndDate = time.Now().Format("2006-01-02")
+	}
+	return exportSource{
+		kind:     model.ExportKindTopProducts,
+		baseName: exportBaseName("top_products", filter.StartDate, filter.EndDate),
+		sheet:    "Sản phẩm bán chạy",
+		filters:  filter,
+		count: func(ctx context.Context) (int64, error) {
+			total, err := c.StatsRepo.CountProductSales(ctx, filter.StartDate, filter.EndDate)
+			if err == nil && filter.Limit > 0 && total > int64(filter.Limit) {
+				total = int64(filter.Limit)
+			}
+			return total, err
+		},
+		write: func(ctx context.Context, tw utils.TableWriter) (int64, error) {
+			if err := tw.WriteRow(
+				"Hạng", "Mã SP", "Mã biến thể", "Sản phẩm", "Biến thể", "SKU",
+				"Số lượng bán", "Số đơn", "Doanh thu", "Doanh thu (số)",
+			); err != nil {
+				return 0, err
+			}
+
+			var rows int64
+			err := c.StatsRepo.EachProductSales(ctx, filter, func(p model.ProductSalesStatsResponse) error {
+				rows++
+				return tw.WriteRow(
+					rows, p.ProductID, p.VariantID, p.ProductName, p.VariantTitle, p.SKU,
+					p.TotalUnitsSold, p.TotalOrders, utils.FormatVND(p.TotalRevenue), p.TotalRevenue,
+				)
+			})
+			return rows, err
+		},
+	}
+}
+
+func (c *exportController) usersSource(filter model.UserFilter) exportSource {
+	return exportSource{
+		kind:     model.ExportKindUsers,
+		baseName: exportBaseName("users", "", ""),
+		sheet:    "Người dùng",
+		filters:  filter,
+		count: func(ctx context.Context) (int64, error) {
+			return c.UserRepo.CountUsers(filter)
+		},
+		write: func(ctx context.Context, tw utils.TableWriter) (int64, error) {
+			if err := tw.WriteRow("ID", "Tài khoản", "Email", "Vai trò", "Hoạt động", "Ngày tạo", "Ngày cập nhật", "Ngày xóa"); err != nil {
+				return 0, err
+			}
+
+			var rows int64
+			err := c.UserRepo.EachUser(filter, func(u model.User) error {
+				// Dừng khi client ngắt kết nối / job bị hủy
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				rows++
+				active := "Không"
+				if u.IsActive {
+					active = "Có"
+				}
+				return tw.WriteRow(u.ID, u.Username, u.Email, u.Role, active, timeText(&u.CreatedAt), timeText(&u.UpdatedAt), timeText(u.DeletedAt))
+			})
+			return rows, err
+		},
+	}
+}
+
+// ===== Export =====
+
+func (c *exportController) ExportOrders(ctx context.Context, adminID int64, filter model.OrderFilter, req model.ExportRequest, start StreamStarter) (*model.ExportJobResponse, error) {
+	return c.export(ctx, adminID, c.ordersSource(filter), req, start)
+}
+
+func (c *exportController) ExportTopProducts(ctx context.Context, adminID int64, filter model.StatsFilter, req model.ExportRequest, start StreamStarter) (*model.ExportJobResponse, error) {
+	return c.export(ctx, adminID, c.topProductsSource(filter), req, start)
+}
+
+func (c *exportController) ExportUsers(ctx context.Context, adminID int64, filter model.UserFilter, req model.ExportRequest, start StreamStarter) (*model.ExportJobResponse, error) {
+	return c.export(ctx, adminID, c.usersSource(filter), req, start)
+}
+
+// export: Ít dòng thì ghi thẳng ra response, nhiều dòng (hoặc async=true) thì tạo job nền
+func (c *exportController) export(ctx context.Context, adminID int64, src exportSource, req model.ExportRequest, start StreamStarter) (*model.ExportJobResponse, error) {
+	format := req.Format
+	if format == "" {
+		format = utils.TableFormatCSV
+	}
+
+	total, err := src.count(ctx)
+	if err != nil {
+		logger.ErrorLogger.Printf("ExportController: Count %s failed: %v", src.kind, err)
+		return nil, err
+	}
+	// +1 dòng tiêu đề
+	if format == utils.TableFormatXLSX && total+1 > utils.XLSXMaxRows {
+		return nil, ErrExportTooManyRows
+	}
+
+	if req.Async || total > c.syncMaxRows {
+		return c.enqueue(ctx, adminID, src, format)
+	}
+
+	fileName := src.baseName + "." + format
+	tw, err := utils.NewTableWriter(format, start(fileName, format), src.sheet)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := src.write(ctx, tw)
+	if err == nil {
+		err = tw.Close()
+	}
+	if err != nil {
+		logger.ErrorLogger.Printf("ExportController: Stream %s interrupted after %d rows: %v", src.kind, rows, err)
+		return nil, err
+	}
+	logger.InfoLogger.Printf("ExportController: Streamed %s (%d rows, %s) for admin %d", src.kind, rows, format, adminID)
+	return nil, nil
+}
+
+// enqueue: Lưu job rồi chạy nền, trả về ngay để client theo dõi trạng thái
+func (c *exportController) enqueue(ctx context.Context, adminID int64, src exportSource, format string) (*model.ExportJobResponse, error) {
+	filters, err := json.Marshal(src.filters)
+	if err != nil {
+		return nil, err
+	}
+	job := &model.ExportJob{
+		Kind:        src.kind,
+		Format:      format,
+		Filters:     string(filters),
+		FileName:    src.baseName + "." + format,
+		RequestedBy: adminID,
+	}
+	if err := c.ExportRepo.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+	logger.InfoLogger.Printf("ExportController: Queued export job %d (%s, %s) for admin %d", job.ID, src.kind, format, adminID)
+
+	go c.runJob(job.ID, src, format)
+	return toJobResponse(job), nil
+}
+
+func (c *exportController) runJob(jobID int64, src exportSource, format string) {
+	c.slots <- struct{}{}
+	defer func() { <-c.slots }()
+
+	ctx := context.Background()
+	if err := c.ExportRepo.MarkJobRunning(ctx, jobID); err != nil {
+		logger.ErrorLogger.Printf("ExportController: MarkJobRunning %d failed: %v", jobID, err)
+	}
+
+	path, rows, err := c.writeFile(ctx, jobID, src, format)
+	if err != nil {
+		logger.ErrorLogger.Printf("ExportController: Export job %d failed: %v", jobID, err)
+		if err := c.ExportRepo.MarkJobFailed(ctx, jobID, err.Error()); err != nil {
+			logger.ErrorLogger.Printf("ExportController: MarkJobFailed %d failed: %v", jobID, err)
+		}
+		return
+	}
+
+	if err := c.ExportRepo.MarkJobDone(ctx, jobID, path, rows, time.Now().Add(c.retention)); err != nil {
+		logger.ErrorLogger.Printf("ExportController: MarkJobDone %d failed: %v", jobID, err)
+		os.Remove(path)
+		return
+	}
+	logger.InfoLogger.Printf("ExportController: Export job %d done (%d rows)", jobID, rows)
+}
+
+// writeFile: Ghi ra file tạm rồi đổi tên, file dở dang không bao giờ được tải về
+func (c *exportController) writeFile(ctx context.Context, jobID int64, src exportSource, format string) (string, int64, error) {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return "", 0, err
+	}
+	tmp, err := os.CreateTemp(c.dir, fmt.Sprintf("export_%d_*.tmp", jobID))
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name()) // Không còn tác dụng sau khi đã đổi tên
+	defer tmp.Close()
+
+	buf := bufio.NewWriterSize(tmp, 64*1024)
+	tw, err := utils.NewTableWriter(format, buf, src.sheet)
+	if err != nil {
+		return "", 0, err
+	}
+	rows, err := src.write(ctx, tw)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := tw.Close(); err != nil {
+		return "", 0, err
+	}
+	if err := buf.Flush(); err != nil {
+		return "", 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, err
+	}
+
+	path := filepath.Join(c.dir, fmt.Sprintf("export_%d.%s", jobID, format))
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, err
+	}
+	return path, rows, nil
+}
+
+// ===== Job =====
+
+func toJobResponse(j *model.ExportJob) *model.ExportJobResponse {
+	resp := &model.ExportJobResponse{
+		ID:           j.ID,
+		Kind:         j.Kind,
+		Format:       j.Format,
+		Status:       j.Status,
+		RowCount:     j.RowCount,
+		FileName:     j.FileName,
+		ErrorMessage: j.ErrorMessage,
+		CreatedAt:    j.CreatedAt,
+		FinishedAt:   j.FinishedAt,
+		ExpiresAt:    j.ExpiresAt,
+	}
+	if j.Status == model.ExportStatusDone {
+		resp.DownloadURL = fmt.Sprintf("/api/admin/exports/jobs/%d/download", j.ID)
+	}
+	return resp
+}
+
+func (c *exportController) GetJob(ctx context.Context, id int64) (*model.ExportJobResponse, error) {
+	job, err := c.ExportRepo.GetJobByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrExportJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toJobResponse(job), nil
+}
+
+func (c *exportController) OpenJobFile(ctx context.Context, id int64) (*model.ExportJob, *os.File, error) {
+	job, err := c.ExportRepo.GetJobByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrExportJobNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch job.Status {
+	case model.ExportStatusDone:
+	case model.ExportStatusFailed:
+		return nil, nil, ErrExportJobFailed
+	case model.ExportStatusExpired:
+		return nil, nil, ErrExportExpired
+	default:
+		return nil, nil, ErrExportNotReady
+	}
+	// Cron dọn file chạy theo giờ, hết hạn rồi thì không cho tải dù file còn
+	if job.FilePath == nil || (job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt)) {
+		return nil, nil, ErrExportExpired
+	}
+
+	f, err := os.Open(*job.FilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		logger.WarnLogger.Printf("ExportController: File of job %d is missing: %s", id, *job.FilePath)
+		return nil, nil, ErrExportExpired
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, f, nil
+}
+
+func (c *exportController) FailInterruptedJobs(ctx context.Context) error {
+	n, err := c.ExportRepo.FailInterruptedJobs(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		logger.WarnLogger.Printf("ExportController: Marked %d interrupted export jobs as failed", n)
+	}
+	return nil
+}
+
+func (c *exportController) PurgeExpiredExports(ctx context.Context) error {
+	jobs, err := c.ExportRepo.GetExpiredJobs(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.FilePath != nil {
+			if err := os.Remove(*job.FilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				logger.ErrorLogger.Printf("ExportController: Remove export file %s failed: %v", *job.FilePath, err)
+				continue
+			}
+		}
+		if err := c.ExportRepo.MarkJobExpired(ctx, job.ID); err != nil {
+			return err
+		}
+	}
+	if len(jobs) > 0 {
+		logger.InfoLogger.Printf("ExportController: Purged %d expired export files", len(jobs))
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package export
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExportBaseName(t *testing.T) {
+	today := time.Now().Format("20060102")
+	tests := []struct {
+		name      string
+		prefix    string
+		startDate string
+		endDate   string
+		want      string
+	}{
+		{"both dates", "orders", "2025-01-01", "2025-01-31", "orders_2025-01-01_2025-01-31"},
+		{"start only", "orders", "2025-01-01", "", "orders_from_2025-01-01"},
+		{"end only", "top_products", "", "2025-01-31", "top_products_to_2025-01-31"},
+		{"no dates", "users", "", "", "users_" + today},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exportBaseName(tt.prefix, tt.startDate, tt.endDate); got != tt.want {
+				t.Errorf("exportBaseName(%q, %q, %q) = %q, want %q", tt.prefix, tt.startDate, tt.endDate, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package export
+
+import (
+	"context"
+	"golang/internal/model"
+	"io"
+	"os"
+)
+
+// StreamStarter: Handler ghi header (Content-Type, Content-Disposition) rồi trả về writer của response.
+// Chỉ được gọi khi controller quyết định export trực tiếp
+type StreamStarter func(fileName string, format string) io.Writer
+
+type ExportController interface {
+	// Export đơn hàng (mỗi dòng sản phẩm 1 hàng). Trả về job != nil nếu đã chuyển sang chạy nền
+	ExportOrders(ctx context.Context, adminID int64, filter model.OrderFilter, req model.ExportRequest, start StreamStarter) (*model.ExportJobResponse, error)
+
+	// Export doanh số sản phẩm theo thứ tự bán chạy (Limit = 0 -> tất cả)
+	ExportTopProducts(ctx context.Context, adminID int64, filter model.StatsFilter, req model.ExportRequest, start StreamStarter) (*model.ExportJobResponse, error)
+
+	// Export danh sách user
+	ExportUsers(ctx context.Context, adminID int64, filter model.UserFilter, req model.ExportRequest, start StreamStarter) (*model.ExportJobResponse, error)
+
+	// Trạng thái job chạy nền
+	GetJob(ctx context.Context, id int64) (*model.ExportJobResponse, error)
+
+	// Mở file kết quả của job đã xong (người gọi phải Close)
+	OpenJobFile(ctx context.Context, id int64) (*model.ExportJob, *os.File, error)
+
+	// Đánh dấu lỗi các job bị bỏ dở khi server tắt (gọi lúc khởi động)
+	FailInterruptedJobs(ctx context.Context) error
+
+	// Xóa file export đã hết hạn (Cron)
+	PurgeExpiredExports(ctx context.Context) error
+}
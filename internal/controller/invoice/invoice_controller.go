@@ -0,0 +1,163 @@
+package invoice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"time"
+
+	"golang/internal/logger"
+	"golang/internal/model"
+	invoiceRepo "golang/internal/repository/invoice"
+	orderRepo "golang/internal/repository/order"
+	"golang/internal/utils"
+)
+
+var (
+	ErrOrderNotFound       = errors.New("không tìm thấy đơn hàng")
+	ErrInvoiceForbidden    = errors.New("bạn không có quyền xem hóa đơn này")
+	ErrInvoiceNotAvailable = errors.New("đơn hàng chưa thanh toán hoặc chưa hoàn thành, chưa thể xuất hóa đơn")
+)
+
+type invoiceController struct {
+	InvoiceRepo invoiceRepo.InvoiceRepository
+	OrderRepo   orderRepo.IOrderRepository
+
+	store model.InvoiceStore
+	// Font nhúng vào PDF (nil -> Helvetica, mất dấu tiếng Việt)
+	regular *utils.TrueTypeFont
+	bold    *utils.TrueTypeFont
+}
+
+// loadFont: Đọc font từ biến môi trường, lỗi thì ghi log và dùng font chuẩn
+func loadFont(envKey string) *utils.TrueTypeFont {
+	path := os.Getenv(envKey)
+	if path == "" {
+		return nil
+	}
+	font, err := utils.LoadTrueTypeFont(path)
+	if err != nil {
+		logger.WarnLogger.Printf("Invoice: Cannot load %s=%s, fallback to Helvetica: %v", envKey, path, err)
+		return nil
+	}
+	return font
+}
+
+func NewInvoiceController(invoiceRepository invoiceRepo.InvoiceRepository, orderRepository orderRepo.IOrderRepository) InvoiceController {
+	c := &invoiceController{
+		InvoiceRepo: invoiceRepository,
+		OrderRepo:   orderRepository,
+		store: model.InvoiceStore{
+			Name:    os.Getenv("STORE_NAME"),
+			Address: os.Getenv("STORE_ADDRESS"),
+			Phone:   os.Getenv("STORE_PHONE"),
+			Email:   os.Getenv("STORE_EMAIL"),
+			TaxCode: os.Getenv("STORE_TAX_CODE"),
+		},
+		regular: loadFont("INVOICE_FONT_FILE"),
+		bold:    loadFont("INVOICE_FONT_BOLD_FILE"),
+	}
+	if c.regular == nil {
+		logger.WarnLogger.Println("Invoice: INVOICE_FONT_FILE not set, Vietnamese diacritics will be stripped in invoice PDFs")
+	}
+	if c.store.Name == "" {
+		c.store.Name = "ECommerce"
+	}
+	return c
+}
+
+// invoiceEligible: Đơn đã thanh toán hoặc đã hoàn thành mới được xuất hóa đơn
+func invoiceEligible(o *model.Order) bool {
+	return o.Status == model.OrderStatusPaid || o.Status == model.OrderStatusCompleted || o.PaymentStatus == model.PaymentStatusPaid
+}
+
+func (c *invoiceController) getOrder(ctx context.Context, orderID int64) (*model.Order, error) {
+	order, err := c.OrderRepo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+// issue: Trả về hóa đơn đã có, chưa có thì cấp số + render PDF
+func (c *invoiceController) issue(ctx context.Context, order *model.Order) (*model.Invoice, error) {
+	inv, err := c.InvoiceRepo.GetInvoiceByOrderID(ctx, order.ID)
+	if err == nil {
+		return inv, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if !invoiceEligible(order) {
+		return nil, ErrInvoiceNotAvailable
+	}
+
+	// Dữ liệu in trên hóa đơn lấy từ snapshot lúc đặt hàng
+	items, err := c.OrderRepo.GetOrderItems(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	address, err := c.OrderRepo.GetOrderAddress(ctx, order.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	shipping, _ := c.OrderRepo.GetOrderShippingLine(ctx, order.ID)
+	payments, _ := c.OrderRepo.GetOrderPayments(ctx, order.ID)
+
+	data := invoiceData{
+		Store:    c.store,
+		Order:    order,
+		Items:    items,
+		Address:  address,
+		Shipping: shipping,
+		Payments: payments,
+	}
+	render := func(invoiceNumber string, issuedAt time.Time) ([]byte, error) {
+		data.InvoiceNumber = invoiceNumber
+		data.IssuedAt = issuedAt
+		return renderInvoicePDF(data, c.regular, c.bold)
+	}
+
+	// DATETIME chỉ lưu đến giây -> làm tròn trước để ngày in trên PDF khớp DB
+	inv, err = c.InvoiceRepo.CreateInvoice(ctx, order.ID, time.Now().Truncate(time.Second), render)
+	if err != nil {
+		logger.ErrorLogger.Printf("Invoice: Issue for OrderID %d failed: %v", order.ID, err)
+		return nil, err
+	}
+	return inv, nil
+}
+
+// Cấp hóa đơn (gọi từ module order khi đơn chuyển sang paid / completed)
+func (c *invoiceController) IssueInvoice(ctx context.Context, orderID int64) (*model.Invoice, error) {
+	order, err := c.getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return c.issue(ctx, order)
+}
+
+// User tải hóa đơn
+func (c *invoiceController) GetMyInvoice(ctx context.Context, userID int64, orderID int64) (*model.Invoice, error) {
+	order, err := c.getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		logger.WarnLogger.Printf("User %d tried to download invoice of order %d", userID, orderID)
+		return nil, ErrInvoiceForbidden
+	}
+	return c.issue(ctx, order)
+}
+
+// Admin tải hóa đơn
+func (c *invoiceController) GetInvoice(ctx context.Context, orderID int64) (*model.Invoice, error) {
+	order, err := c.getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return c.issue(ctx, order)
+}
@@ -0,0 +1,17 @@
+package invoice
+
+import (
+	"context"
+	"golang/internal/model"
+)
+
+type InvoiceController interface {
+	// Cấp hóa đơn cho đơn đã thanh toán / hoàn thành (đã có thì trả về hóa đơn cũ)
+	IssueInvoice(ctx context.Context, orderID int64) (*model.Invoice, error)
+
+	// User tải hóa đơn của đơn hàng của mình (chưa có mà đơn đủ điều kiện thì cấp luôn)
+	GetMyInvoice(ctx context.Context, userID int64, orderID int64) (*model.Invoice, error)
+
+	// Admin tải hóa đơn của đơn hàng bất kỳ
+	GetInvoice(ctx context.Context, orderID int64) (*model.Invoice, error)
+}
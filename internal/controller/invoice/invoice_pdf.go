@@ -0,0 +1,295 @@
+package invoice
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang/internal/model"
+	"golang/internal/utils"
+)
+
+// Bố cục trang (point)
+const (
+	pageMargin   = 40.0
+	pageRight    = utils.PDFPageWidth - pageMargin
+	pageBottom   = utils.PDFPageHeight - 60
+	lineHeight   = 13.0
+	invoiceDate  = "02/01/2006"
+	invoiceTime  = "02/01/2006 15:04"
+	itemColNo    = pageMargin
+	itemColTitle = 62.0
+	itemColSKU   = 280.0
+	itemColQty   = 395.0 // căn phải
+	itemColPrice = 475.0 // căn phải
+	titleWidth   = itemColSKU - itemColTitle - 8
+	skuWidth     = 345.0 - itemColSKU
+)
+
+var paymentStatusLabels = map[string]string{
+	model.PaymentStatusUnpaid:            "Chưa thanh toán",
+	model.PaymentStatusPaid:              "Đã thanh toán",
+	model.PaymentStatusPartiallyRefunded: "Hoàn tiền một phần",
+	model.PaymentStatusRefunded:          "Đã hoàn tiền",
+}
+
+var paymentMethodLabels = map[string]string{
+	model.PaymentMethodCOD:          "Thanh toán khi nhận hàng (COD)",
+	model.PaymentMethodBankTransfer: "Chuyển khoản ngân hàng",
+}
+
+// invoiceData: Toàn bộ dữ liệu in trên 1 hóa đơn
+type invoiceData struct {
+	Store         model.InvoiceStore
+	InvoiceNumber string
+	IssuedAt      time.Time
+	Order         *model.Order
+	Items         []model.OrderItem
+	Address       *model.OrderAddress
+	Shipping      *model.OrderShippingLine
+	Payments      []model.OrderPayment
+}
+
+// formatOptionValues: option_values là JSON object hoặc chuỗi "Màu: Đen, Dung lượng: 128GB"
+func formatOptionValues(raw *string) string {
+	if raw == nil || *raw == "" {
+		return ""
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(*raw), &obj); err != nil {
+		return *raw
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s: %v", k, obj[k]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// addressLines: Địa chỉ giao hàng (snapshot order_addresses)
+func addressLines(a *model.OrderAddress) []string {
+	if a == nil {
+		return []string{"(không có địa chỉ)"}
+	}
+	lines := []string{a.RecipientName}
+	if a.Phone != "" {
+		lines = append(lines, "ĐT: "+a.Phone)
+	}
+	var parts []string
+	for _, p := range []string{a.Line1, a.Line2, a.Ward, a.State, a.City, a.Country} {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) > 0 {
+		lines = append(lines, strings.Join(parts, ", "))
+	}
+	return lines
+}
+
+// paidAt: Thời điểm thanh toán (đơn hoặc giao dịch thành công gần nhất)
+func paidAt(d invoiceData) *time.Time {
+	if d.Order.PaidAt != nil {
+		return d.Order.PaidAt
+	}
+	var latest *time.Time
+	for i := range d.Payments {
+		if p := d.Payments[i].PaidAt; p != nil && (latest == nil || p.After(*latest)) {
+			latest = p
+		}
+	}
+	return latest
+}
+
+func paymentMethod(d invoiceData) string {
+	if len(d.Payments) == 0 {
+		return ""
+	}
+	if label, ok := paymentMethodLabels[d.Payments[0].Method]; ok {
+		return label
+	}
+	return d.Payments[0].Method
+}
+
+// renderInvoicePDF: Vẽ hóa đơn A4 (tự sang trang khi nhiều sản phẩm)
+func renderInvoicePDF(d invoiceData, regular, bold *utils.TrueTypeFont) ([]byte, error) {
+	doc := utils.NewPDFDocument(regular, bold)
+	doc.AddPage()
+
+	// Thông tin cửa hàng (bên trái)
+	y := 56.0
+	doc.SetFont(true, 16)
+	doc.Text(pageMargin, y, d.Store.Name)
+	doc.SetFont(false, 9)
+	y += 16
+	storeLines := doc.WrapText(d.Store.Address, 260)
+	if d.Store.Phone != "" {
+		storeLines = append(storeLines, "Điện thoại: "+d.Store.Phone)
+	}
+	if d.Store.Email != "" {
+		storeLines = append(storeLines, "Email: "+d.Store.Email)
+	}
+	if d.Store.TaxCode != "" {
+		storeLines = append(storeLines, "Mã số thuế: "+d.Store.TaxCode)
+	}
+	for _, line := range storeLines {
+		doc.Text(pageMargin, y, line)
+		y += 12
+	}
+
+	// Số hóa đơn (bên phải)
+	ry := 56.0
+	doc.SetFont(true, 18)
+	doc.TextRight(pageRight, ry, "HÓA ĐƠN BÁN HÀNG")
+	ry += 18
+	doc.SetFont(false, 9)
+	for _, line := range []string{
+		"Số hóa đơn: " + d.InvoiceNumber,
+		"Ngày xuất: " + d.IssuedAt.Format(invoiceDate),
+		"Mã đơn hàng: " + d.Order.OrderNumber,
+		"Ngày đặt: " + d.Order.PlacedAt.Format(invoiceDate),
+	} {
+		doc.TextRight(pageRight, ry, line)
+		ry += 12
+	}
+
+	y = max(y, ry) + 6
+	doc.Line(pageMargin, y, pageRight, y, 0.8)
+	y += 18
+
+	// Giao đến / Thanh toán
+	col2 := 320.0
+	doc.SetFont(true, 10)
+	doc.Text(pageMargin, y, "Giao đến")
+	doc.Text(col2, y, "Thanh toán")
+	doc.SetFont(false, 9)
+	ly, ry := y+lineHeight, y+lineHeight
+	for _, line := range addressLines(d.Address) {
+		for _, wrapped := range doc.WrapText(line, col2-pageMargin-16) {
+			doc.Text(pageMargin, ly, wrapped)
+			ly += 12
+		}
+	}
+	status := paymentStatusLabels[d.Order.PaymentStatus]
+	if status == "" {
+		status = d.Order.PaymentStatus
+	}
+	payLines := []string{"Trạng thái: " + status}
+	if method := paymentMethod(d); method != "" {
+		payLines = append(payLines, "Phương thức: "+method)
+	}
+	if t := paidAt(d); t != nil {
+		payLines = append(payLines, "Ngày thanh toán: "+t.Format(invoiceTime))
+	}
+	if d.Shipping != nil {
+		payLines = append(payLines, "Giao hàng: "+d.Shipping.MethodName)
+	}
+	for _, line := range payLines {
+		doc.Text(col2, ry, line)
+		ry += 12
+	}
+	y = max(ly, ry) + 14
+
+	// Bảng sản phẩm
+	tableHeader := func() {
+		doc.FillRect(pageMargin, y-12, pageRight-pageMargin, 18, 0.92)
+		doc.SetFont(true, 9)
+		doc.Text(itemColNo+2, y, "#")
+		doc.Text(itemColTitle, y, "Sản phẩm")
+		doc.Text(itemColSKU, y, "SKU")
+		doc.TextRight(itemColQty, y, "SL")
+		doc.TextRight(itemColPrice, y, "Đơn giá")
+		doc.TextRight(pageRight-2, y, "Thành tiền")
+		y += 18
+	}
+	tableHeader()
+
+	for i, item := range d.Items {
+		doc.SetFont(false, 9)
+		titleLines := doc.WrapText(item.Title, titleWidth)
+		skuLines := doc.WrapText(item.SKU, skuWidth)
+		doc.SetFont(false, 8)
+		optionLines := doc.WrapText(formatOptionValues(item.OptionValues), titleWidth)
+
+		rows := max(len(titleLines)+len(optionLines), len(skuLines), 1)
+		if y+float64(rows)*11 > pageBottom {
+			doc.AddPage()
+			y = 60
+			tableHeader()
+		}
+
+		doc.SetFont(false, 9)
+		doc.Text(itemColNo+2, y, strconv.Itoa(i+1))
+		ty := y
+		for _, line := range titleLines {
+			doc.Text(itemColTitle, ty, line)
+			ty += 11
+		}
+		doc.SetFont(false, 8)
+		for _, line := range optionLines {
+			doc.Text(itemColTitle, ty, line)
+			ty += 11
+		}
+		doc.SetFont(false, 9)
+		sy := y
+		for _, line := range skuLines {
+			doc.Text(itemColSKU, sy, line)
+			sy += 11
+		}
+		doc.TextRight(itemColQty, y, strconv.Itoa(item.Quantity))
+		doc.TextRight(itemColPrice, y, utils.FormatVND(item.UnitPrice))
+		doc.TextRight(pageRight-2, y, utils.FormatVND(item.LineSubtotal))
+
+		y = max(ty, sy, y+11) + 4
+		doc.Line(pageMargin, y-9, pageRight, y-9, 0.3)
+	}
+
+	// Tổng tiền
+	if y+4*lineHeight+40 > pageBottom {
+		doc.AddPage()
+		y = 60
+	}
+	y += 8
+	subtotal := d.Order.SubtotalAmount
+	if subtotal == 0 && d.Order.ShippingFee == 0 {
+		subtotal = d.Order.TotalAmount // Đơn cũ chưa tách phí giao hàng
+	}
+	labelX := itemColPrice - 60
+	doc.SetFont(false, 10)
+	doc.TextRight(labelX, y, "Tạm tính")
+	doc.TextRight(pageRight-2, y, utils.FormatVND(subtotal))
+	y += lineHeight + 2
+	doc.TextRight(labelX, y, "Phí giao hàng")
+	doc.TextRight(pageRight-2, y, utils.FormatVND(d.Order.ShippingFee))
+	y += 8
+	doc.Line(labelX-100, y, pageRight, y, 0.5)
+	y += lineHeight + 2
+	doc.SetFont(true, 11)
+	doc.TextRight(labelX, y, "Tổng cộng")
+	doc.TextRight(pageRight-2, y, utils.FormatVND(d.Order.TotalAmount))
+
+	// Chân trang: lời cảm ơn + số trang
+	pages := doc.PageCount()
+	for p := 1; p <= pages; p++ {
+		doc.SetPage(p)
+		doc.SetFont(false, 8)
+		doc.Line(pageMargin, utils.PDFPageHeight-45, pageRight, utils.PDFPageHeight-45, 0.3)
+		doc.Text(pageMargin, utils.PDFPageHeight-32, "Cảm ơn quý khách đã mua hàng tại "+d.Store.Name)
+		doc.TextRight(pageRight, utils.PDFPageHeight-32, fmt.Sprintf("%s - Trang %d/%d", d.InvoiceNumber, p, pages))
+	}
+
+	var buf bytes.Buffer
+	if err := doc.Output(&buf, "Hóa đơn "+d.InvoiceNumber, d.IssuedAt); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,305 @@
+package location
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang/internal/model"
+	locationRepo "golang/internal/repository/location"
+	"golang/internal/utils"
+)
+
+// Tên hiển thị chuẩn của quốc gia khi địa chỉ thuộc Việt Nam
+const VietnamCountryName = "Việt Nam"
+
+// Số gợi ý tối đa trả về khi nhập sai tên
+const maxSuggestions = 3
+
+var (
+	ErrProvinceNotFound = errors.New("tỉnh / thành phố không tồn tại")
+	ErrDistrictNotFound = errors.New("quận / huyện không tồn tại")
+)
+
+// LocationError - Tên / mã đơn vị hành chính không hợp lệ, kèm các gợi ý gần đúng
+type LocationError struct {
+	Level       string   `json:"level"`
+	Value       string   `json:"value"`
+	Message     string   `json:"message"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+func (e *LocationError) Error() string {
+	if len(e.Suggestions) > 0 {
+		return fmt.Sprintf("%s. Có phải bạn muốn nhập: %s?", e.Message, strings.Join(e.Suggestions, ", "))
+	}
+	return e.Message
+}
+
+var levelLabels = map[string]string{
+	model.LocationLevelProvince: "tỉnh / thành phố",
+	model.LocationLevelDistrict: "quận / huyện",
+	model.LocationLevelWard:     "phường / xã",
+}
+
+// Tiền tố hành chính người dùng hay gõ kèm tên (đã bỏ dấu), bỏ đi trước khi so khớp
+var unitPrefixes = []string{"thanh pho", "tinh", "tp", "quan", "q", "huyen", "thi xa", "tx", "thi tran", "tt", "phuong", "p", "xa"}
+
+// "q1", "p12" -> số thứ tự quận / phường
+var numberedUnitPattern = regexp.MustCompile(`^(?:q|p)([0-9]+)$`)
+
+// Tên gọi tắt / tên tỉnh cũ đã sáp nhập từ 07/2025 -> mã tỉnh hiện hành (khóa đã qua matchKey).
+// Chỉ dùng khi không khớp tên trong bộ dữ liệu, nên file dữ liệu cũ 63 tỉnh vẫn khớp đúng tỉnh cũ.
+var provinceAliases = map[string]string{
+	"hcm":             "79",
+	"tphcm":           "79",
+	"sai gon":         "79",
+	"saigon":          "79",
+	"hn":              "01",
+	"thua thien hue":  "46",
+	"brvt":            "79",
+	"ba ria vung tau": "79",
+	"binh duong":      "79",
+	"ha giang":        "08",
+	"yen bai":         "15",
+	"bac kan":         "19",
+	"vinh phuc":       "25",
+	"hoa binh":        "25",
+	"bac giang":       "24",
+	"hai duong":       "31",
+	"thai binh":       "33",
+	"ha nam":          "37",
+	"nam dinh":        "37",
+	"quang binh":      "44",
+	"quang nam":       "48",
+	"kon tum":         "51",
+	"binh dinh":       "52",
+	"ninh thuan":      "56",
+	"phu yen":         "66",
+	"dak nong":        "68",
+	"binh thuan":      "68",
+	"binh phuoc":      "75",
+	"long an":         "80",
+	"tien giang":      "82",
+	"ben tre":         "86",
+	"tra vinh":        "86",
+	"kien giang":      "91",
+	"soc trang":       "92",
+	"hau giang":       "92",
+	"bac lieu":        "96",
+}
+
+// Mã tỉnh cũ (trước 07/2025) không còn trong danh mục -> mã tỉnh đã sáp nhập vào.
+// Địa chỉ lưu từ trước vẫn cập nhật được mà không phải chọn lại tỉnh.
+var legacyProvinceCodes = map[string]string{
+	"02": "08", "06": "19", "10": "15", "17": "25", "26": "25", "27": "24",
+	"30": "31", "34": "33", "35": "37", "36": "37", "45": "44", "49": "48",
+	"54": "66", "58": "56", "60": "68", "62": "51", "64": "52", "67": "68",
+	"70": "75", "72": "80", "74": "79", "77": "79", "83": "86", "84": "86",
+	"87": "82", "89": "91", "93": "92", "94": "92", "95": "96",
+}
+
+var vietnamNames = map[string]bool{"viet nam": true, "vietnam": true, "vn": true}
+
+type locationController struct {
+	LocationRepo locationRepo.LocationRepository
+}
+
+func NewLocationController(lRepo locationRepo.LocationRepository) LocationController {
+	return &locationController{
+		LocationRepo: lRepo,
+	}
+}
+
+// IsVietnam: quốc gia người dùng nhập có phải Việt Nam (không phân biệt dấu / hoa thường)
+func IsVietnam(country string) bool {
+	return vietnamNames[utils.FoldText(country)]
+}
+
+// matchKey: khóa so khớp tên đơn vị hành chính ("Quận 01" -> "1", "TP. Hồ Chí Minh" -> "ho chi minh")
+func matchKey(name string) string {
+	key := utils.FoldText(name)
+	if m := numberedUnitPattern.FindStringSubmatch(key); m != nil {
+		key = m[1]
+	}
+	for _, prefix := range unitPrefixes {
+		if rest, ok := strings.CutPrefix(key, prefix+" "); ok && rest != "" {
+			key = rest
+			break
+		}
+	}
+	if trimmed := strings.TrimLeft(key, "0"); trimmed != "" && strings.Trim(key, "0123456789") == "" {
+		key = trimmed
+	}
+	return key
+}
+
+// filterUnits: Lọc danh sách theo từ khóa (không dấu, chứa chuỗi con)
+func filterUnits(units []model.AdministrativeUnit, q string) []model.AdministrativeUnit {
+	res := []model.AdministrativeUnit{}
+	key := matchKey(q)
+	for _, u := range units {
+		if key == "" || strings.Contains(matchKey(u.Name), key) {
+			res = append(res, u)
+		}
+	}
+	return res
+}
+
+func (c *locationController) ListProvinces(q string) []model.AdministrativeUnit {
+	return filterUnits(c.LocationRepo.GetProvinces(), q)
+}
+
+func (c *locationController) ListDistricts(provinceCode string, q string) ([]model.AdministrativeUnit, error) {
+	if c.LocationRepo.GetProvinceByCode(provinceCode) == nil {
+		return nil, ErrProvinceNotFound
+	}
+	return filterUnits(c.LocationRepo.GetDistricts(provinceCode), q), nil
+}
+
+func (c *locationController) ListWards(districtCode string, q string) ([]model.AdministrativeUnit, error) {
+	if c.LocationRepo.GetDistrictByCode(districtCode) == nil {
+		return nil, ErrDistrictNotFound
+	}
+	return filterUnits(c.LocationRepo.GetWards(districtCode), q), nil
+}
+
+func (c *locationController) ListProvinceWards(provinceCode string, q string) ([]model.AdministrativeUnit, error) {
+	if c.LocationRepo.GetProvinceByCode(provinceCode) == nil {
+		return nil, ErrProvinceNotFound
+	}
+	return filterUnits(c.LocationRepo.GetWards(provinceCode), q), nil
+}
+
+// suggest: Các tên gần đúng nhất (khoảng cách chỉnh sửa nhỏ hoặc chứa nhau)
+func suggest(units []model.AdministrativeUnit, key string) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	threshold := min(max(len([]rune(key))/3, 1), 3)
+	var candidates []candidate
+	for _, u := range units {
+		uKey := matchKey(u.Name)
+		d := utils.EditDistance(key, uKey)
+		if d > threshold && !(len(key) >= 3 && (strings.Contains(uKey, key) || strings.Contains(key, uKey))) {
+			continue
+		}
+		candidates = append(candidates, candidate{name: u.Name, distance: d})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+	var names []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		names = append(names, candidates[i].name)
+	}
+	return names
+}
+
+// matchUnit: Tìm đơn vị theo mã (ưu tiên) hoặc theo tên; nil nếu không nhập gì
+func (c *locationController) matchUnit(level string, units []model.AdministrativeUnit, code string, name string) (*model.AdministrativeUnit, error) {
+	code, name = strings.TrimSpace(code), strings.TrimSpace(name)
+	label := levelLabels[level]
+
+	if code != "" {
+		for i := range units {
+			if units[i].Code == code {
+				return &units[i], nil
+			}
+		}
+		if newCode, ok := legacyProvinceCodes[code]; ok && level == model.LocationLevelProvince {
+			if p := c.LocationRepo.GetProvinceByCode(newCode); p != nil {
+				return p, nil
+			}
+		}
+		return nil, &LocationError{Level: level, Value: code, Message: fmt.Sprintf("Mã %s '%s' không hợp lệ", label, code)}
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	key := matchKey(name)
+	for i := range units {
+		if matchKey(units[i].Name) == key {
+			return &units[i], nil
+		}
+	}
+	if aliasCode, ok := provinceAliases[key]; ok && level == model.LocationLevelProvince {
+		if p := c.LocationRepo.GetProvinceByCode(aliasCode); p != nil {
+			return p, nil
+		}
+	}
+
+	return nil, &LocationError{
+		Level:       level,
+		Value:       name,
+		Message:     fmt.Sprintf("Không tìm thấy %s '%s'", label, name),
+		Suggestions: suggest(units, key),
+	}
+}
+
+// resolveLevel: Cấp có dữ liệu thì bắt buộc khớp; cấp chưa có dữ liệu thì giữ nguyên tên người dùng nhập
+func (c *locationController) resolveLevel(level string, units []model.AdministrativeUnit, code string, name string) (unitCode string, unitName string, err error) {
+	if len(units) == 0 {
+		if strings.TrimSpace(code) != "" {
+			return "", "", &LocationError{Level: level, Value: code, Message: fmt.Sprintf("Chưa có dữ liệu %s để chọn theo mã", levelLabels[level])}
+		}
+		return "", strings.TrimSpace(name), nil
+	}
+
+	unit, err := c.matchUnit(level, units, code, name)
+	if err != nil {
+		return "", "", err
+	}
+	if unit == nil {
+		return "", "", &LocationError{Level: level, Message: fmt.Sprintf("Vui lòng chọn %s", levelLabels[level])}
+	}
+	return unit.Code, unit.Name, nil
+}
+
+func (c *locationController) Resolve(in model.LocationInput) (*model.ResolvedLocation, error) {
+	// Địa chỉ nước ngoài: không có dữ liệu hành chính, giữ nguyên tên
+	if !IsVietnam(in.Country) {
+		if strings.TrimSpace(in.Province) == "" {
+			return nil, &LocationError{Level: model.LocationLevelProvince, Message: fmt.Sprintf("Vui lòng nhập %s", levelLabels[model.LocationLevelProvince])}
+		}
+		return &model.ResolvedLocation{
+			Country:  strings.TrimSpace(in.Country),
+			Province: strings.TrimSpace(in.Province),
+			District: strings.TrimSpace(in.District),
+			Ward:     strings.TrimSpace(in.Ward),
+		}, nil
+	}
+
+	res := &model.ResolvedLocation{Country: VietnamCountryName}
+	var err error
+
+	res.ProvinceCode, res.Province, err = c.resolveLevel(model.LocationLevelProvince, c.LocationRepo.GetProvinces(), in.ProvinceCode, in.Province)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cấu trúc 2 cấp hiện hành không còn quận / huyện: giữ tên người dùng nhập, bỏ mã quận cũ (nếu có)
+	if districts := c.LocationRepo.GetDistricts(res.ProvinceCode); len(districts) > 0 {
+		res.DistrictCode, res.District, err = c.resolveLevel(model.LocationLevelDistrict, districts, in.DistrictCode, in.District)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		res.District = strings.TrimSpace(in.District)
+	}
+
+	// Phường / xã trực thuộc quận (file cũ) hoặc trực thuộc tỉnh (hiện hành)
+	wardParent := res.ProvinceCode
+	if res.DistrictCode != "" {
+		wardParent = res.DistrictCode
+	}
+	res.WardCode, res.Ward, err = c.resolveLevel(model.LocationLevelWard, c.LocationRepo.GetWards(wardParent), in.WardCode, in.Ward)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
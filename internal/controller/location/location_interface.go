@@ -0,0 +1,20 @@
+package location
+
+import "golang/internal/model"
+
+type LocationController interface {
+	// Danh sách tỉnh / thành phố (q: lọc theo tên, không phân biệt dấu)
+	ListProvinces(q string) []model.AdministrativeUnit
+
+	// Danh sách quận / huyện của tỉnh
+	ListDistricts(provinceCode string, q string) ([]model.AdministrativeUnit, error)
+
+	// Danh sách phường / xã của quận / huyện (file dữ liệu cũ 3 cấp)
+	ListWards(districtCode string, q string) ([]model.AdministrativeUnit, error)
+
+	// Danh sách phường / xã trực thuộc tỉnh (cấu trúc 2 cấp hiện hành)
+	ListProvinceWards(provinceCode string, q string) ([]model.AdministrativeUnit, error)
+
+	// Kiểm tra + chuẩn hoá địa chỉ người dùng nhập; sai tên thì trả về *LocationError kèm gợi ý
+	Resolve(in model.LocationInput) (*model.ResolvedLocation, error)
+}
@@ -16,11 +16,35 @@ import (
 	"golang/internal/utils"
 )
 
+// ShippingCalculator: Tính phí giao hàng theo địa chỉ + phương thức (module shipping cài đặt)
+type ShippingCalculator interface {
+	Quote(ctx context.Context, dest model.ShippingDestination, methodCode string, subtotal float64, weightGrams int) (*model.ShippingQuote, error)
+}
+
+// StatsRecomputer: Tính lại thống kê các ngày đã chốt của đơn (module stats cài đặt)
+type StatsRecomputer interface {
+	RecomputeOrderDays(ctx context.Context, order *model.Order)
+}
+
+// CartEventRecorder: Ghi sự kiện tạo đơn cho báo cáo phễu bán hàng (module cart cài đặt)
+type CartEventRecorder interface {
+	RecordOrderCreated(ctx context.Context, userID int64, orderID int64, totalItems int, totalAmount float64)
+}
+
+// InvoiceIssuer: Cấp số hóa đơn khi đơn đã thanh toán / hoàn thành (module invoice cài đặt)
+type InvoiceIssuer interface {
+	IssueInvoice(ctx context.Context, orderID int64) (*model.Invoice, error)
+}
+
 type orderController struct {
 	OrderRepo          repository.IOrderRepository
 	ProductRepo        product.ProductRepository
 	ProductVariantRepo productvariant.ProductVariantsRepository
 	AddressRepo        address.AddressRepo
+	Shipping           ShippingCalculator
+	Stats              StatsRecomputer
+	CartEvents         CartEventRecorder
+	Invoices           InvoiceIssuer
 }
 
 func NewOrderController(
@@ -28,12 +52,50 @@ func NewOrderController(
 	productRepo product.ProductRepository,
 	variantRepo productvariant.ProductVariantsRepository,
 	addrRepo address.AddressRepo,
+	shipping ShippingCalculator,
+	stats StatsRecomputer,
+	cartEvents CartEventRecorder,
+	invoices InvoiceIssuer,
 ) OrderController {
 	return &orderController{
 		OrderRepo:          orderRepo,
 		ProductRepo:        productRepo,
 		ProductVariantRepo: variantRepo,
 		AddressRepo:        addrRepo,
+		Shipping:           shipping,
+		Stats:              stats,
+		CartEvents:         cartEvents,
+		Invoices:           invoices,
+	}
+}
+
+// issueInvoice: Cấp hóa đơn ngay khi đơn được thanh toán / hoàn thành.
+// Lỗi thì chỉ ghi log, hóa đơn sẽ được cấp lại khi khách tải về
+func (c *orderController) issueInvoice(ctx context.Context, orderID int64) {
+	if _, err := c.Invoices.IssueInvoice(ctx, orderID); err != nil {
+		logger.ErrorLogger.Printf("Issue invoice for OrderID %d failed: %v", orderID, err)
+	}
+}
+
+// orderSubtotal: Tiền hàng của đơn (đơn cũ chưa tách phí giao hàng thì lấy total_amount)
+func orderSubtotal(o *model.Order) float64 {
+	if o.SubtotalAmount == 0 && o.ShippingFee == 0 {
+		return o.TotalAmount
+	}
+	return o.SubtotalAmount
+}
+
+// mapToShippingResponse: Dòng phí giao hàng trả về cho client
+func mapToShippingResponse(l *model.OrderShippingLine) *model.OrderShippingLineResponse {
+	if l == nil {
+		return nil
+	}
+	return &model.OrderShippingLineResponse{
+		MethodCode:       l.MethodCode,
+		MethodName:       l.MethodName,
+		ZoneName:         l.ZoneName,
+		TotalWeightGrams: l.TotalWeightGrams,
+		Fee:              utils.FormatVND(l.Fee),
 	}
 }
 
@@ -57,11 +119,16 @@ func (c *orderController) CreateOrder(ctx context.Context, userID int64, req mod
 		Line2:         realAddress.Line2,
 		City:          realAddress.City,
 		State:         realAddress.State,
+		Ward:          realAddress.Ward,
 		Country:       realAddress.Country,
+		ProvinceCode:  realAddress.ProvinceCode,
+		DistrictCode:  realAddress.DistrictCode,
+		WardCode:      realAddress.WardCode,
 	}
 
 	var orderItems []model.OrderItem
-	var totalAmount float64 = 0
+	var subtotalAmount float64 = 0
+	var totalWeight int = 0
 
 	for _, reqItem := range req.Items {
 		//  lấy thông tin sản phẩm gốc trước để check trạng thái
@@ -72,7 +139,7 @@ func (c *orderController) CreateOrder(ctx context.Context, userID int64, req mod
 		}
 
 		// Check xem sản phẩm có đang được bán không
-		if !parentProduct.IsPublished {
+		if !parentProduct.IsVisible(time.Now()) {
 			logger.WarnLogger.Printf("CreateOrder: Product unpublished (ID: %d)", reqItem.ProductID)
 			return nil, fmt.Errorf("sản phẩm '%s' hiện đang ngừng kinh doanh", parentProduct.Name)
 		}
@@ -100,14 +167,8 @@ func (c *orderController) CreateOrder(ctx context.Context, userID int64, req mod
 		}
 
 		// Tính toán giá & Tên hiển thị
-		var finalPrice float64
-
-		// Ưu tiên lấy giá đè của Variant, nếu không có thì lấy MinPrice của Product
-		if variant.PriceOverride != nil {
-			finalPrice = *variant.PriceOverride
-		} else {
-			finalPrice = parentProduct.MinPrice
-		}
+		// Ưu tiên lấy giá đè của Variant, nếu không có thì lấy giá gốc (BasePrice) của Product
+		finalPrice := utils.VariantPrice(variant.PriceOverride, parentProduct.BasePrice)
 
 		// Tên hiển thị (VD: Áo Thun - Màu Đỏ)
 		variantTitle := ""
@@ -118,7 +179,8 @@ func (c *orderController) CreateOrder(ctx context.Context, userID int64, req mod
 
 		//  Tính tổng tiền
 		lineSubtotal := finalPrice * float64(reqItem.Quantity)
-		totalAmount += lineSubtotal
+		subtotalAmount += lineSubtotal
+		totalWeight += utils.LineWeightGrams(variant.WeightGrams, reqItem.Quantity)
 
 		//  Tạo Snapshot Item để lưu DB
 		variantIDVal := reqItem.VariantID
@@ -128,6 +190,7 @@ func (c *orderController) CreateOrder(ctx context.Context, userID int64, req mod
 			VariantID:    &variantIDVal,
 			Quantity:     reqItem.Quantity,
 			UnitPrice:    finalPrice,
+			UnitCost:     variant.CostPrice, // Snapshot giá vốn để báo cáo lợi nhuận không đổi khi sửa cost_price
 			LineSubtotal: lineSubtotal,
 			Title:        finalTitle,
 			SKU:          variant.SKU,
@@ -137,16 +200,42 @@ func (c *orderController) CreateOrder(ctx context.Context, userID int64, req mod
 		orderItems = append(orderItems, item)
 	}
 
+	// Tính phí giao hàng theo địa chỉ + phương thức đã chọn
+	shippingMethod := req.ShippingMethod
+	if shippingMethod == "" {
+		shippingMethod = model.ShippingMethodStandard
+	}
+	dest := model.ShippingDestination{Country: realAddress.Country, State: realAddress.State, City: realAddress.City, ProvinceCode: realAddress.ProvinceCode}
+	quote, err := c.Shipping.Quote(ctx, dest, shippingMethod, subtotalAmount, totalWeight)
+	if err != nil {
+		logger.WarnLogger.Printf("CreateOrder: Shipping quote failed for user %d (method: %s): %v", userID, shippingMethod, err)
+		return nil, err
+	}
+
+	zoneID := quote.ZoneID
+	shippingLine := &model.OrderShippingLine{
+		ZoneID:           &zoneID,
+		ZoneName:         quote.ZoneName,
+		MethodCode:       quote.Code,
+		MethodName:       quote.Name,
+		RateType:         quote.RateType,
+		TotalWeightGrams: quote.TotalWeightGrams,
+		Fee:              quote.Fee,
+	}
+	totalAmount := subtotalAmount + quote.Fee
+
 	orderNumber := fmt.Sprintf("ORD-%d", time.Now().UnixNano())
 
 	newOrder := &model.Order{
-		OrderNumber:   orderNumber,
-		UserID:        userID,
-		Status:        model.OrderStatusPending,
-		PaymentStatus: model.PaymentStatusUnpaid,
-		TotalAmount:   totalAmount,
-		Note:          &req.Note,
-		PlacedAt:      time.Now(),
+		OrderNumber:    orderNumber,
+		UserID:         userID,
+		Status:         model.OrderStatusPending,
+		PaymentStatus:  model.PaymentStatusUnpaid,
+		SubtotalAmount: subtotalAmount,
+		ShippingFee:    quote.Fee,
+		TotalAmount:    totalAmount,
+		Note:           &req.Note,
+		PlacedAt:       time.Now(),
 	}
 
 	// Tạo Payment
@@ -156,20 +245,31 @@ func (c *orderController) CreateOrder(ctx context.Context, userID int64, req mod
 		Status: model.PaymentTransStatusPending,
 	}
 
-	err = c.OrderRepo.CreateOrder(ctx, newOrder, orderItems, addressSnapshot, initialPayment)
+	err = c.OrderRepo.CreateOrder(ctx, newOrder, orderItems, addressSnapshot, shippingLine, initialPayment)
 	if err != nil {
 		logger.ErrorLogger.Printf("CreateOrder failed for user %d: %v", userID, err)
 		return nil, err
 	}
 
+	if c.CartEvents != nil {
+		totalItems := 0
+		for _, item := range orderItems {
+			totalItems += item.Quantity
+		}
+		c.CartEvents.RecordOrderCreated(ctx, userID, newOrder.ID, totalItems, totalAmount)
+	}
+
 	// Trả về kết quả
 	return &model.OrderResponse{
-		ID:            newOrder.ID,
-		OrderNumber:   newOrder.OrderNumber,
-		Status:        newOrder.Status,
-		TotalAmount:   utils.FormatVND(newOrder.TotalAmount),
-		PaymentStatus: newOrder.PaymentStatus,
-		Note:          req.Note,
+		ID:             newOrder.ID,
+		OrderNumber:    newOrder.OrderNumber,
+		Status:         newOrder.Status,
+		SubtotalAmount: utils.FormatVND(newOrder.SubtotalAmount),
+		ShippingFee:    utils.FormatVND(newOrder.ShippingFee),
+		TotalAmount:    utils.FormatVND(newOrder.TotalAmount),
+		PaymentStatus:  newOrder.PaymentStatus,
+		Note:           req.Note,
+		Shipping:       mapToShippingResponse(shippingLine),
 		Payments: []model.OrderPaymentResponse{
 			{
 				ID:     initialPayment.ID,
@@ -200,6 +300,7 @@ func (c *orderController) GetMyOrder(ctx context.Context, userID int64, orderID
 	//  Lấy các thông tin
 	items, _ := c.OrderRepo.GetOrderItems(ctx, orderID)
 	address, _ := c.OrderRepo.GetOrderAddress(ctx, orderID)
+	shippingLine, _ := c.OrderRepo.GetOrderShippingLine(ctx, orderID)
 	payments, _ := c.OrderRepo.GetOrderPayments(ctx, orderID)
 
 	var itemRes []model.OrderItemResponse
@@ -223,10 +324,13 @@ func (c *orderController) GetMyOrder(ctx context.Context, userID int64, orderID
 		ID:              order.ID,
 		OrderNumber:     order.OrderNumber,
 		Status:          order.Status,
+		SubtotalAmount:  utils.FormatVND(orderSubtotal(order)),
+		ShippingFee:     utils.FormatVND(order.ShippingFee),
 		TotalAmount:     utils.FormatVND(order.TotalAmount),
 		PaymentStatus:   order.PaymentStatus,
 		Note:            noteStr,
 		ShippingAddress: address,
+		Shipping:        mapToShippingResponse(shippingLine),
 		Items:           itemRes,
 		Payments:        payRes,
 		PlacedAt:        order.PlacedAt,
@@ -258,17 +362,19 @@ func (c *orderController) GetMyListOrders(ctx context.Context, userID int64, fil
 		}
 
 		response = append(response, model.OrderResponse{
-			ID:            o.ID,
-			OrderNumber:   o.OrderNumber,
-			Status:        o.Status,
-			TotalAmount:   utils.FormatVND(o.TotalAmount),
-			PaymentStatus: o.PaymentStatus,
-			Note:          noteStr,
-			PlacedAt:      o.PlacedAt,
-			UpdatedAt:     o.UpdatedAt,
-			PaidAt:        o.PaidAt,
-			CompletedAt:   o.CompletedAt,
-			CancelledAt:   o.CancelledAt,
+			ID:             o.ID,
+			OrderNumber:    o.OrderNumber,
+			Status:         o.Status,
+			SubtotalAmount: utils.FormatVND(orderSubtotal(&o)),
+			ShippingFee:    utils.FormatVND(o.ShippingFee),
+			TotalAmount:    utils.FormatVND(o.TotalAmount),
+			PaymentStatus:  o.PaymentStatus,
+			Note:           noteStr,
+			PlacedAt:       o.PlacedAt,
+			UpdatedAt:      o.UpdatedAt,
+			PaidAt:         o.PaidAt,
+			CompletedAt:    o.CompletedAt,
+			CancelledAt:    o.CancelledAt,
 		})
 	}
 	logger.InfoLogger.Printf("GetMyOrders success. UserID: %d. Found: %d", userID, total)
@@ -304,6 +410,7 @@ func (c *orderController) CancelOrder(ctx context.Context, userID int64, orderID
 		logger.ErrorLogger.Printf("CancelOrder: UpdateStatus failed. Error: %v", err)
 		return err
 	}
+	c.Stats.RecomputeOrderDays(ctx, order)
 
 	logger.InfoLogger.Printf("CancelOrder success. OrderID: %d", orderID)
 	return nil
@@ -322,6 +429,7 @@ func (c *orderController) GetAdminOrderDetail(ctx context.Context, orderID int64
 	//  Lấy Full thông tin
 	items, _ := c.OrderRepo.GetOrderItems(ctx, orderID)
 	address, _ := c.OrderRepo.GetOrderAddress(ctx, orderID)
+	shippingLine, _ := c.OrderRepo.GetOrderShippingLine(ctx, orderID)
 	payments, _ := c.OrderRepo.GetOrderPayments(ctx, orderID)
 	histories, _ := c.OrderRepo.GetOrderStatusHistory(ctx, orderID)
 
@@ -352,8 +460,9 @@ func (c *orderController) GetAdminOrderDetail(ctx context.Context, orderID int64
 	//  Admin Response
 	baseResponse := model.OrderResponse{
 		ID: order.ID, OrderNumber: order.OrderNumber, Status: order.Status,
+		SubtotalAmount: utils.FormatVND(orderSubtotal(order)), ShippingFee: utils.FormatVND(order.ShippingFee),
 		TotalAmount: utils.FormatVND(order.TotalAmount), PaymentStatus: order.PaymentStatus, Note: noteStr,
-		ShippingAddress: address, Items: itemRes, Payments: payRes,
+		ShippingAddress: address, Shipping: mapToShippingResponse(shippingLine), Items: itemRes, Payments: payRes,
 		PlacedAt: order.PlacedAt, UpdatedAt: order.UpdatedAt,
 		PaidAt:      order.PaidAt,
 		CompletedAt: order.CompletedAt,
@@ -384,6 +493,7 @@ func (c *orderController) SearchOrders(ctx context.Context, filter model.OrderFi
 		}
 		response = append(response, model.OrderResponse{
 			ID: o.ID, OrderNumber: o.OrderNumber, Status: o.Status,
+			SubtotalAmount: utils.FormatVND(orderSubtotal(&o)), ShippingFee: utils.FormatVND(o.ShippingFee),
 			TotalAmount: utils.FormatVND(o.TotalAmount), PaymentStatus: o.PaymentStatus, Note: noteStr,
 			PlacedAt: o.PlacedAt, UpdatedAt: o.UpdatedAt,
 			PaidAt:      o.PaidAt,
@@ -400,13 +510,25 @@ func (c *orderController) UpdateOrderStatus(ctx context.Context, orderID int64,
 	logger.InfoLogger.Printf("Starting UpdateOrderStatus. OrderID: %d, AdminID: %d", orderID, adminID)
 
 	if req.Status != "" {
+		// Lấy đơn trước khi đổi để biết ngày hoàn thành cũ (nếu có)
+		order, err := c.OrderRepo.GetOrderByID(ctx, orderID)
+		if err != nil {
+			logger.ErrorLogger.Printf("UpdateOrderStatus: GetOrder failed. Error: %v", err)
+			return err
+		}
+
 		adminIDPtr := &adminID
 		note := "Admin updated status"
-		err := c.OrderRepo.UpdateOrderStatus(ctx, orderID, req.Status, note, adminIDPtr)
+		err = c.OrderRepo.UpdateOrderStatus(ctx, orderID, req.Status, note, adminIDPtr)
 		if err != nil {
 			logger.ErrorLogger.Printf("UpdateOrderStatus failed. Error: %v", err)
 			return err
 		}
+		c.Stats.RecomputeOrderDays(ctx, order)
+
+		if req.Status == model.OrderStatusPaid || req.Status == model.OrderStatusCompleted {
+			c.issueInvoice(ctx, orderID)
+		}
 	}
 	logger.InfoLogger.Printf("UpdateOrderStatus success. OrderID: %d", orderID)
 	return nil
@@ -463,6 +585,10 @@ func (c *orderController) ConfirmPayment(ctx context.Context, orderID int64, sta
 		return err
 	}
 
+	if status == "completed" {
+		c.issueInvoice(ctx, orderID)
+	}
+
 	logger.InfoLogger.Printf("ConfirmPayment success. OrderID: %d confirmed by AdminID: %d", orderID, adminID)
 	return nil
 }
@@ -476,14 +602,15 @@ func mapToItemResponse(item model.OrderItem) model.OrderItemResponse {
 	}
 
 	return model.OrderItemResponse{
-		ID:           item.ID,
-		ProductID:    item.ProductID,
-		VariantID:    item.VariantID,
-		SKU:          item.SKU,
-		Title:        item.Title,
-		OptionValues: optionsParsed,
-		UnitPrice:    utils.FormatVND(item.UnitPrice),
-		Quantity:     item.Quantity,
-		LineSubtotal: utils.FormatVND(item.LineSubtotal),
+		ID:               item.ID,
+		ProductID:        item.ProductID,
+		VariantID:        item.VariantID,
+		SKU:              item.SKU,
+		Title:            item.Title,
+		OptionValues:     optionsParsed,
+		UnitPrice:        utils.FormatVND(item.UnitPrice),
+		Quantity:         item.Quantity,
+		ReturnedQuantity: item.ReturnedQuantity,
+		LineSubtotal:     utils.FormatVND(item.LineSubtotal),
 	}
 }
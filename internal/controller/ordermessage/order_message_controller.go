@@ -0,0 +1,313 @@
+package ordermessage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang/internal/logger"
+	"golang/internal/model"
+	orderRepo "golang/internal/repository/order"
+	repository "golang/internal/repository/ordermessage"
+	"golang/internal/storage"
+	"golang/internal/utils"
+)
+
+// Giá trị mặc định, ghi đè bằng biến môi trường ORDER_MESSAGE_*
+const (
+	defaultMaxAttachments = 5 // Số file tối đa mỗi tin nhắn
+	defaultMaxFileMB      = 5 // Dung lượng tối đa mỗi file
+)
+
+var (
+	ErrOrderNotFound       = errors.New("không tìm thấy đơn hàng")
+	ErrOrderForbidden      = errors.New("bạn không có quyền xem đơn hàng này")
+	ErrAttachmentNotFound  = errors.New("không tìm thấy file đính kèm")
+	ErrTooManyAttachments  = errors.New("vượt quá số file đính kèm cho phép")
+	ErrAttachmentTooLarge  = errors.New("file đính kèm vượt quá dung lượng cho phép")
+	ErrAttachmentType      = errors.New("chỉ hỗ trợ file ảnh (jpg, png, gif, webp) và pdf")
+	ErrCustomerInternalMsg = errors.New("khách hàng không thể tạo ghi chú nội bộ")
+)
+
+type orderMessageController struct {
+	MessageRepo repository.OrderMessageRepository
+	OrderRepo   orderRepo.IOrderRepository
+	Storage     storage.Storage
+
+	maxAttachments int
+	maxFileBytes   int64
+}
+
+func NewOrderMessageController(
+	messageRepository repository.OrderMessageRepository,
+	orderRepository orderRepo.IOrderRepository,
+	fileStorage storage.Storage,
+) OrderMessageController {
+	return &orderMessageController{
+		MessageRepo:    messageRepository,
+		OrderRepo:      orderRepository,
+		Storage:        fileStorage,
+		maxAttachments: utils.EnvInt("ORDER_MESSAGE_MAX_ATTACHMENTS", defaultMaxAttachments),
+		maxFileBytes:   int64(utils.EnvInt("ORDER_MESSAGE_MAX_FILE_MB", defaultMaxFileMB)) << 20,
+	}
+}
+
+// Toàn bộ file + 1MB cho nội dung tin nhắn và phần đầu multipart
+func (c *orderMessageController) MaxRequestBytes() int64 {
+	return int64(c.maxAttachments)*c.maxFileBytes + 1<<20
+}
+
+func (c *orderMessageController) getOrder(ctx context.Context, orderID int64) (*model.Order, error) {
+	order, err := c.OrderRepo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+// getMyOrder: Đơn hàng phải thuộc về user
+func (c *orderMessageController) getMyOrder(ctx context.Context, userID int64, orderID int64) (*model.Order, error) {
+	order, err := c.getOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		logger.WarnLogger.Printf("User %d tried to access messages of order %d", userID, orderID)
+		return nil, ErrOrderForbidden
+	}
+	return order, nil
+}
+
+func toResponse(m model.OrderMessage) model.OrderMessageResponse {
+	res := model.OrderMessageResponse{
+		ID:          m.ID,
+		OrderID:     m.OrderID,
+		AuthorID:    m.AuthorID,
+		AuthorName:  m.AuthorName,
+		AuthorRole:  m.AuthorRole,
+		Visibility:  m.Visibility,
+		Body:        m.Body,
+		Attachments: []model.OrderMessageAttachmentResponse{},
+		CreatedAt:   m.CreatedAt,
+	}
+	for _, a := range m.Attachments {
+		res.Attachments = append(res.Attachments, model.OrderMessageAttachmentResponse{
+			ID: a.ID, FileName: a.FileName, ContentType: a.ContentType, SizeBytes: a.SizeBytes,
+		})
+	}
+	return res
+}
+
+// listMessages: Lấy tin nhắn rồi đánh dấu phía reader đã đọc đến tin cuối cùng
+func (c *orderMessageController) listMessages(ctx context.Context, orderID int64, reader string) ([]model.OrderMessageResponse, error) {
+	messages, err := c.MessageRepo.GetMessages(ctx, orderID, reader == model.OrderMessageAuthorStaff)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]model.OrderMessageResponse, 0, len(messages))
+	for _, m := range messages {
+		res = append(res, toResponse(m))
+	}
+	if len(messages) > 0 {
+		if err := c.MessageRepo.MarkRead(ctx, orderID, reader, messages[len(messages)-1].ID); err != nil {
+			logger.WarnLogger.Printf("MarkRead %s for OrderID %d failed: %v", reader, orderID, err)
+		}
+	}
+	return res, nil
+}
+
+// saveAttachments: Kiểm tra định dạng / dung lượng rồi lưu vào Storage. Lỗi giữa chừng thì xóa các file đã lưu
+func (c *orderMessageController) saveAttachments(ctx context.Context, orderID int64, files []model.AttachmentUpload) ([]model.OrderMessageAttachment, error) {
+	if len(files) > c.maxAttachments {
+		return nil, fmt.Errorf("%w (tối đa %d file)", ErrTooManyAttachments, c.maxAttachments)
+	}
+
+	saved := make([]model.OrderMessageAttachment, 0, len(files))
+	for _, f := range files {
+		att, err := c.saveAttachment(ctx, orderID, f)
+		if err != nil {
+			c.deleteAttachments(ctx, saved)
+			return nil, err
+		}
+		saved = append(saved, *att)
+	}
+	return saved, nil
+}
+
+func (c *orderMessageController) saveAttachment(ctx context.Context, orderID int64, f model.AttachmentUpload) (*model.OrderMessageAttachment, error) {
+	if f.Size > c.maxFileBytes {
+		return nil, fmt.Errorf("%w (tối đa %d MB)", ErrAttachmentTooLarge, c.maxFileBytes>>20)
+	}
+
+	up, err := storage.SaveUpload(ctx, c.Storage, fmt.Sprintf("order-messages/%d", orderID), f.FileName, f.Content, c.maxFileBytes, storage.ImageAndPDFTypes)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrFileTooLarge):
+			return nil, fmt.Errorf("%w (tối đa %d MB)", ErrAttachmentTooLarge, c.maxFileBytes>>20)
+		case errors.Is(err, storage.ErrFileType):
+			return nil, ErrAttachmentType
+		}
+		logger.ErrorLogger.Printf("Save attachment for OrderID %d failed: %v", orderID, err)
+		return nil, err
+	}
+
+	return &model.OrderMessageAttachment{
+		FileName:    up.FileName,
+		ContentType: up.ContentType,
+		SizeBytes:   up.SizeBytes,
+		StorageKey:  up.Key,
+	}, nil
+}
+
+func (c *orderMessageController) deleteAttachments(ctx context.Context, attachments []model.OrderMessageAttachment) {
+	for _, a := range attachments {
+		if err := c.Storage.Delete(context.WithoutCancel(ctx), a.StorageKey); err != nil {
+			logger.WarnLogger.Printf("Delete attachment %s failed: %v", a.StorageKey, err)
+		}
+	}
+}
+
+// send: Lưu file đính kèm + tin nhắn, người gửi coi như đã đọc cả cuộc trò chuyện
+func (c *orderMessageController) send(ctx context.Context, msg *model.OrderMessage, files []model.AttachmentUpload) (*model.OrderMessageResponse, error) {
+	attachments, err := c.saveAttachments(ctx, msg.OrderID, files)
+	if err != nil {
+		return nil, err
+	}
+	msg.Attachments = attachments
+
+	if err := c.MessageRepo.CreateMessage(ctx, msg); err != nil {
+		c.deleteAttachments(ctx, attachments)
+		return nil, err
+	}
+	if err := c.MessageRepo.MarkRead(ctx, msg.OrderID, msg.AuthorRole, msg.ID); err != nil {
+		logger.WarnLogger.Printf("MarkRead %s for OrderID %d failed: %v", msg.AuthorRole, msg.OrderID, err)
+	}
+
+	logger.InfoLogger.Printf("Order message %d (%s, %s) created for OrderID %d by UserID %d",
+		msg.ID, msg.AuthorRole, msg.Visibility, msg.OrderID, msg.AuthorID)
+	res := toResponse(*msg)
+	return &res, nil
+}
+
+func (c *orderMessageController) openAttachment(ctx context.Context, orderID int64, attachmentID int64, includeInternal bool) (*model.OrderMessageAttachment, io.ReadCloser, error) {
+	att, visibility, err := c.MessageRepo.GetAttachment(ctx, orderID, attachmentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+	// Khách không được biết file của ghi chú nội bộ có tồn tại
+	if visibility == model.OrderMessageInternal && !includeInternal {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	file, err := c.Storage.Open(ctx, att.StorageKey)
+	if err != nil {
+		logger.ErrorLogger.Printf("Open attachment %s failed: %v", att.StorageKey, err)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+	return att, file, nil
+}
+
+func unreadResponse(orders []model.OrderUnreadMessages) *model.OrderUnreadResponse {
+	res := &model.OrderUnreadResponse{Orders: orders}
+	for _, o := range orders {
+		res.TotalUnread += o.Unread
+	}
+	return res
+}
+
+// Khách xem tin nhắn
+func (c *orderMessageController) GetMyMessages(ctx context.Context, userID int64, orderID int64) ([]model.OrderMessageResponse, error) {
+	if _, err := c.getMyOrder(ctx, userID, orderID); err != nil {
+		return nil, err
+	}
+	return c.listMessages(ctx, orderID, model.OrderMessageAuthorCustomer)
+}
+
+// Khách gửi tin nhắn
+func (c *orderMessageController) SendMyMessage(ctx context.Context, userID int64, orderID int64, req model.SendOrderMessageRequest, files []model.AttachmentUpload) (*model.OrderMessageResponse, error) {
+	if req.Visibility == model.OrderMessageInternal {
+		return nil, ErrCustomerInternalMsg
+	}
+	if _, err := c.getMyOrder(ctx, userID, orderID); err != nil {
+		return nil, err
+	}
+	return c.send(ctx, &model.OrderMessage{
+		OrderID:    orderID,
+		AuthorID:   userID,
+		AuthorRole: model.OrderMessageAuthorCustomer,
+		Visibility: model.OrderMessagePublic,
+		Body:       strings.TrimSpace(req.Body),
+	}, files)
+}
+
+// Khách tải file đính kèm
+func (c *orderMessageController) OpenMyAttachment(ctx context.Context, userID int64, orderID int64, attachmentID int64) (*model.OrderMessageAttachment, io.ReadCloser, error) {
+	if _, err := c.getMyOrder(ctx, userID, orderID); err != nil {
+		return nil, nil, err
+	}
+	return c.openAttachment(ctx, orderID, attachmentID, false)
+}
+
+// Số tin chưa đọc của khách
+func (c *orderMessageController) GetMyUnread(ctx context.Context, userID int64) (*model.OrderUnreadResponse, error) {
+	orders, err := c.MessageRepo.GetCustomerUnread(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return unreadResponse(orders), nil
+}
+
+// Admin xem tin nhắn
+func (c *orderMessageController) GetMessages(ctx context.Context, orderID int64) ([]model.OrderMessageResponse, error) {
+	if _, err := c.getOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+	return c.listMessages(ctx, orderID, model.OrderMessageAuthorStaff)
+}
+
+// Admin gửi tin nhắn / ghi chú nội bộ
+func (c *orderMessageController) SendStaffMessage(ctx context.Context, adminID int64, orderID int64, req model.SendOrderMessageRequest, files []model.AttachmentUpload) (*model.OrderMessageResponse, error) {
+	if _, err := c.getOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = model.OrderMessagePublic
+	}
+	return c.send(ctx, &model.OrderMessage{
+		OrderID:    orderID,
+		AuthorID:   adminID,
+		AuthorRole: model.OrderMessageAuthorStaff,
+		Visibility: visibility,
+		Body:       strings.TrimSpace(req.Body),
+	}, files)
+}
+
+// Admin tải file đính kèm
+func (c *orderMessageController) OpenAttachment(ctx context.Context, orderID int64, attachmentID int64) (*model.OrderMessageAttachment, io.ReadCloser, error) {
+	if _, err := c.getOrder(ctx, orderID); err != nil {
+		return nil, nil, err
+	}
+	return c.openAttachment(ctx, orderID, attachmentID, true)
+}
+
+// Số tin chưa đọc của nhân viên
+func (c *orderMessageController) GetStaffUnread(ctx context.Context) (*model.OrderUnreadResponse, error) {
+	orders, err := c.MessageRepo.GetStaffUnread(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return unreadResponse(orders), nil
+}
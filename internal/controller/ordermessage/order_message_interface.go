@@ -0,0 +1,36 @@
+package ordermessage
+
+import (
+	"context"
+	"golang/internal/model"
+	"io"
+)
+
+type OrderMessageController interface {
+	// Khách xem tin nhắn public của đơn hàng của mình (đánh dấu đã đọc)
+	GetMyMessages(ctx context.Context, userID int64, orderID int64) ([]model.OrderMessageResponse, error)
+
+	// Khách gửi tin nhắn / trả lời nhân viên
+	SendMyMessage(ctx context.Context, userID int64, orderID int64, req model.SendOrderMessageRequest, files []model.AttachmentUpload) (*model.OrderMessageResponse, error)
+
+	// Khách tải file đính kèm của tin public (người gọi phải Close)
+	OpenMyAttachment(ctx context.Context, userID int64, orderID int64, attachmentID int64) (*model.OrderMessageAttachment, io.ReadCloser, error)
+
+	// Số tin nhân viên gửi mà khách chưa đọc
+	GetMyUnread(ctx context.Context, userID int64) (*model.OrderUnreadResponse, error)
+
+	// Admin xem toàn bộ tin nhắn (kể cả ghi chú nội bộ, đánh dấu đã đọc)
+	GetMessages(ctx context.Context, orderID int64) ([]model.OrderMessageResponse, error)
+
+	// Admin gửi tin cho khách (public) hoặc ghi chú nội bộ (internal)
+	SendStaffMessage(ctx context.Context, adminID int64, orderID int64, req model.SendOrderMessageRequest, files []model.AttachmentUpload) (*model.OrderMessageResponse, error)
+
+	// Admin tải file đính kèm
+	OpenAttachment(ctx context.Context, orderID int64, attachmentID int64) (*model.OrderMessageAttachment, io.ReadCloser, error)
+
+	// Số tin khách gửi mà nhân viên chưa đọc
+	GetStaffUnread(ctx context.Context) (*model.OrderUnreadResponse, error)
+
+	// Dung lượng tối đa 1 request gửi tin (handler giới hạn body)
+	MaxRequestBytes() int64
+}
@@ -5,11 +5,17 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	productVariantController "golang/internal/controller/productvariant"
+	"golang/internal/logger"
 	"golang/internal/model"
+	category "golang/internal/repository/category"
 	product "golang/internal/repository/product"
 	producthistory "golang/internal/repository/producthistory"
 	productreview "golang/internal/repository/productreview"
 	productVariant "golang/internal/repository/productvariant"
+	"golang/internal/utils"
+	"reflect"
+	"sort"
 	"time"
 
 	"github.com/gosimple/slug"
@@ -20,15 +26,19 @@ type productController struct {
 	RepoVariants productVariant.ProductVariantsRepository
 	HistoryRepo  producthistory.ProductHistoryRepository
 	ReviewRepo   productreview.ProductReviewRepository
+	VariantCtrl  productVariantController.ProductVariantController
+	CategoryRepo category.CategoryRepo
 }
 
 // NewProductController - Khởi tạo product controller
-func NewProductController(repo product.ProductRepository, repoVariants productVariant.ProductVariantsRepository, repoHistory producthistory.ProductHistoryRepository, repoReview productreview.ProductReviewRepository) ProductController {
+func NewProductController(repo product.ProductRepository, repoVariants productVariant.ProductVariantsRepository, repoHistory producthistory.ProductHistoryRepository, repoReview productreview.ProductReviewRepository, variantCtrl productVariantController.ProductVariantController, repoCategory category.CategoryRepo) ProductController {
 	return &productController{
 		Repo:         repo,
 		RepoVariants: repoVariants,
 		HistoryRepo:  repoHistory,
 		ReviewRepo:   repoReview,
+		VariantCtrl:  variantCtrl,
+		CategoryRepo: repoCategory,
 	}
 }
 
@@ -40,6 +50,29 @@ func stringToPtr(s string) *string {
 	return &s
 }
 
+// stringPtrEqual - So sánh 2 giá trị có thể NULL (NULL khác chuỗi rỗng)
+func stringPtrEqual(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func timePtrEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+// validatePublishWindow - Thời điểm unpublish phải sau thời điểm publish
+func validatePublishWindow(publishedAt, unpublishedAt *time.Time) error {
+	if publishedAt != nil && unpublishedAt != nil && !unpublishedAt.After(*publishedAt) {
+		return fmt.Errorf("unpublished_at must be after published_at")
+	}
+	return nil
+}
+
 // CreateProductController - Tạo sản phẩm mới kèm danh mục
 func (prt *productController) CreateProductController(product model.CreateProductRequest) (*model.AdminCreateProductResponse, error) {
 	// 1. Check trùng tên
@@ -85,6 +118,24 @@ func (prt *productController) CreateProductController(product model.CreateProduc
 		publishedAt = &parsedTime
 	}
 
+	var unpublishedAt *time.Time
+	if product.UnpublishedAt != "" {
+		parsedTime, err := time.Parse(time.RFC3339, product.UnpublishedAt)
+		if err != nil {
+			return nil, err
+		}
+		unpublishedAt = &parsedTime
+	}
+	if err := validatePublishWindow(publishedAt, unpublishedAt); err != nil {
+		return nil, err
+	}
+
+	// Kiểm tra thông số theo schema của các danh mục (kể cả required)
+	attributeValues, err := prt.buildAttributeValues(product.CategoryIDs, product.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
 	productToCreate := &model.Product{
 		Name:             product.Name,
 		Slug:             finalSlug,
@@ -94,13 +145,19 @@ func (prt *productController) CreateProductController(product model.CreateProduc
 		Status:           product.Status,
 		IsPublished:      product.IsPublished,
 		PublishedAt:      publishedAt,
-		MinPrice:         product.MinPrice,
+		UnpublishedAt:    unpublishedAt,
+		BasePrice:        product.BasePrice,
+	}
+	// min_price: tên cũ của base_price, vẫn nhận trong 1 bản phát hành
+	if productToCreate.BasePrice == 0 {
+		productToCreate.BasePrice = product.MinPrice
 	}
 
-	createdProduct, err := prt.Repo.CreateProduct(productToCreate, product.CategoryIDs)
+	createdProduct, err := prt.Repo.CreateProduct(productToCreate, product.CategoryIDs, attributeValues)
 	if err != nil {
 		return nil, err
 	}
+	attributes, _ := prt.Repo.GetProductAttributeValues(createdProduct.ID)
 
 	cats, err := prt.Repo.GetCategoriesByProductID(createdProduct.ID)
 	if err == nil {
@@ -119,10 +176,14 @@ func (prt *productController) CreateProductController(product model.CreateProduc
 			Status:           createdProduct.Status,
 			IsPublished:      createdProduct.IsPublished,
 			PublishedAt:      createdProduct.PublishedAt,
-			MinPrice:         createdProduct.MinPrice,
+			UnpublishedAt:    createdProduct.UnpublishedAt,
+			BasePrice:        createdProduct.BasePrice,
+			MinPrice:         createdProduct.BasePrice,
+			MaxPrice:         createdProduct.BasePrice,
 			CreatedAt:        createdProduct.CreatedAt,
 			UpdatedAt:        createdProduct.UpdatedAt,
 			Categories:       createdProduct.Categories,
+			Attributes:       attributes,
 		},
 	}, nil
 }
@@ -154,16 +215,7 @@ func (prt *productController) getProductCommon(reqProduct *model.GetProductReque
 	if err == nil {
 		pro.Categories = cats
 	}
-	//lay rate va count reviews
-	avgRating, err := prt.ReviewRepo.GetAverageRatingByProductID(pro.ID)
-	if err == nil {
-		pro.AvgRating = avgRating
-	}
-
-	if ratingCount, err := prt.ReviewRepo.GetCountRatingByProductID(pro.ID); err == nil {
-		pro.RatingCount = int(ratingCount)
-	}
-
+	// avg_rating / rating_count đã được cập nhật cùng transaction với review
 	return pro, nil
 }
 
@@ -181,37 +233,35 @@ func (prt *productController) AdminGetProductController(reqProduct *model.GetPro
 	}
 
 	variantResponses := make([]model.AdminVariantResponse, 0, len(variantsModel))
-	minPrice := pro.MinPrice
-	hasActiveVariants := false
 
 	for _, v := range variantsModel {
 		variantResponses = append(variantResponses, model.AdminVariantResponse{
-			ID:             v.ID,
-			ProductID:      v.ProductID,
-			SKU:            v.SKU,
-			Title:          v.Title,
-			OptionValues:   v.OptionValues,
-			PriceOverride:  v.PriceOverride,
-			CostPrice:      v.CostPrice,
-			StockQuantity:  v.StockQuantity,
-			AllowBackorder: v.AllowBackorder,
-			IsActive:       v.IsActive,
-			CreatedAt:      v.CreatedAt.String(),
-			UpdatedAt:      v.UpdatedAt.String(),
+			ID:               v.ID,
+			ProductID:        v.ProductID,
+			SKU:              v.SKU,
+			Title:            v.Title,
+			OptionValues:     v.OptionValues,
+			PriceOverride:    v.PriceOverride,
+			CostPrice:        v.CostPrice,
+			StockQuantity:    v.StockQuantity,
+			AllowBackorder:   v.AllowBackorder,
+			IsActive:         v.IsActive,
+			WeightGrams:      v.WeightGrams,
+			ReorderThreshold: v.ReorderThreshold,
+			CreatedAt:        v.CreatedAt.String(),
+			UpdatedAt:        v.UpdatedAt.String(),
 		})
-
-		if v.IsActive && v.PriceOverride != nil {
-			if !hasActiveVariants || *v.PriceOverride < minPrice {
-				minPrice = *v.PriceOverride
-				hasActiveVariants = true
-			}
-		}
 	}
 	reviewsResponses, err := prt.ReviewRepo.GetProductReviewsByProductID(pro.ID)
 	if err != nil {
 		reviewsResponses = []model.ProductReview{}
 	}
 
+	attributes, err := prt.Repo.GetProductAttributeValues(pro.ID)
+	if err != nil {
+		attributes = []model.ProductAttributeValue{}
+	}
+
 	return &model.AdminProductDetailResponse{
 		Message: "Product retrieved successfully",
 		Product: model.AdminProductResponse{
@@ -224,7 +274,10 @@ func (prt *productController) AdminGetProductController(reqProduct *model.GetPro
 			Status:           pro.Status,
 			IsPublished:      pro.IsPublished,
 			PublishedAt:      pro.PublishedAt,
-			MinPrice:         minPrice,
+			UnpublishedAt:    pro.UnpublishedAt,
+			BasePrice:        pro.BasePrice,
+			MinPrice:         pro.MinPrice,
+			MaxPrice:         pro.MaxPrice,
 			AvgRating:        pro.AvgRating,
 			RatingCount:      pro.RatingCount,
 			CreatedBy:        pro.CreatedBy,
@@ -233,6 +286,7 @@ func (prt *productController) AdminGetProductController(reqProduct *model.GetPro
 			UpdatedAt:        pro.UpdatedAt,
 			DeletedAt:        pro.DeletedAt,
 			Categories:       pro.Categories,
+			Attributes:       attributes,
 			Reviews:          reviewsResponses,
 		},
 		Variants: variantResponses,
@@ -247,7 +301,7 @@ func (prt *productController) UserGetProductDetailController(reqProduct *model.G
 		return nil, err
 	}
 
-	if !pro.IsPublished {
+	if !pro.IsVisible(time.Now()) {
 		return nil, fmt.Errorf("product not available")
 	}
 
@@ -261,14 +315,25 @@ func (prt *productController) UserGetProductDetailController(reqProduct *model.G
 	}
 	pro.Categories = activeCategories
 
+	// Breadcrumb: lấy đường dẫn sâu nhất trong các danh mục của SP (bỏ nhánh có danh mục cha đang ẩn)
+	var breadcrumb []model.CategoryBreadcrumb
+	for _, cat := range activeCategories {
+		crumbs, ok, err := prt.Repo.GetCategoryBreadcrumb(cat.ID)
+		if err != nil {
+			logger.WarnLogger.Printf("Cannot build breadcrumb for category %d: %v", cat.ID, err)
+			continue
+		}
+		if ok && len(crumbs) > len(breadcrumb) {
+			breadcrumb = crumbs
+		}
+	}
+
 	variantsModel, err := prt.RepoVariants.GetProductVariantByID(pro.ID)
 	if err != nil {
 		variantsModel = []model.ProductsVariants{}
 	}
 
 	variantResponses := make([]model.UserVariantResponse, 0)
-	minPrice := pro.MinPrice
-	hasActiveVariants := false
 
 	reviewReponse, err := prt.ReviewRepo.GetProductReviewsByProductID(pro.ID)
 	for _, v := range variantsModel {
@@ -283,19 +348,23 @@ func (prt *productController) UserGetProductDetailController(reqProduct *model.G
 				resp.OptionValues = *v.OptionValues
 			}
 
-			if v.PriceOverride != nil {
-				resp.Price = *v.PriceOverride
-				if !hasActiveVariants || *v.PriceOverride < minPrice {
-					minPrice = *v.PriceOverride
-					hasActiveVariants = true
-				}
-			} else {
-				resp.Price = pro.MinPrice
-			}
+			resp.Price = utils.VariantPrice(v.PriceOverride, pro.BasePrice)
 			variantResponses = append(variantResponses, resp)
 		}
 	}
 
+	optionMatrix := prt.buildOptionMatrix(pro, variantsModel)
+
+	attributes, err := prt.Repo.GetProductAttributeValues(pro.ID)
+	if err != nil {
+		attributes = nil
+	}
+
+	var histogram *model.RatingHistogram
+	if summary, err := prt.ReviewRepo.GetRatingSummary(pro.ID); err == nil {
+		histogram = &summary.Histogram
+	}
+
 	return &model.UserProductDetailResponse{
 		Message:          "Product retrieved successfully",
 		ID:               pro.ID,
@@ -303,16 +372,53 @@ func (prt *productController) UserGetProductDetailController(reqProduct *model.G
 		ShortDescription: pro.ShortDescription,
 		Description:      pro.Description,
 		Brand:            pro.Brand,
-		MinPrice:         minPrice,
+		MinPrice:         pro.MinPrice,
+		MaxPrice:         pro.MaxPrice,
 		AvgRating:        pro.AvgRating,
 		RatingCount:      pro.RatingCount,
 		PublishedAt:      pro.PublishedAt,
 		Categories:       pro.Categories,
+		Breadcrumb:       breadcrumb,
+		Attributes:       attributes,
 		Variants:         variantResponses,
+		OptionMatrix:     optionMatrix,
 		Reviews:          reviewReponse,
+		RatingHistogram:  histogram,
 	}, nil
 }
 
+// buildOptionMatrix - Dựng ma trận option kèm tình trạng còn hàng của từng tổ hợp
+func (prt *productController) buildOptionMatrix(pro *model.Product, variants []model.ProductsVariants) *model.VariantOptionMatrix {
+	options, err := prt.RepoVariants.GetProductOptions(pro.ID)
+	if err != nil || len(options) == 0 {
+		return nil
+	}
+
+	byKey := make(map[string]model.ProductsVariants)
+	for _, v := range variants {
+		if !v.IsActive || v.OptionValues == nil {
+			continue
+		}
+		if values, err := utils.ParseOptionValues(*v.OptionValues); err == nil {
+			byKey[utils.CanonicalOptionValues(values)] = v
+		}
+	}
+
+	matrix := &model.VariantOptionMatrix{Options: options}
+	for _, combo := range utils.CartesianOptions(options) {
+		c := model.OptionCombination{Options: combo}
+		if v, ok := byKey[utils.CanonicalOptionValues(combo)]; ok {
+			id := v.ID
+			price := utils.VariantPrice(v.PriceOverride, pro.BasePrice)
+			c.VariantID = &id
+			c.Price = &price
+			c.Available = v.StockQuantity > 0 || v.AllowBackorder
+		}
+		matrix.Combinations = append(matrix.Combinations, c)
+	}
+	return matrix
+}
+
 // UpdateProductController - Cập nhật thông tin sản phẩm và danh mục
 func (prt *productController) UpdateProductController(ctx context.Context, req model.UpdateProductRequest, id int64) (*model.AdminUpdateProductResponse, error) {
 	existingProduct, err := prt.Repo.GetProductByID(id)
@@ -320,6 +426,10 @@ func (prt *productController) UpdateProductController(ctx context.Context, req m
 		return nil, fmt.Errorf("Product not found")
 	}
 	// Compare to check changed fields
+	// min_price: tên cũ của base_price, vẫn nhận trong 1 bản phát hành
+	if req.BasePrice == nil && req.MinPrice != nil {
+		req.BasePrice = req.MinPrice
+	}
 
 	finalSlug := existingProduct.Slug
 	if req.Slug != "" {
@@ -343,14 +453,28 @@ func (prt *productController) UpdateProductController(ctx context.Context, req m
 		return oldVal
 	}
 
+	finalShortDescription := mergeStringPtr(req.ShortDescription, existingProduct.ShortDescription)
+	finalDescription := mergeStringPtr(req.Description, existingProduct.Description)
+	finalBrand := mergeStringPtr(req.Brand, existingProduct.Brand)
+	// Revert lịch sử: gán đúng giá trị cũ (nil = NULL, khác với chuỗi rỗng)
+	if v, ok := req.RevertText["short_description"]; ok {
+		finalShortDescription = v
+	}
+	if v, ok := req.RevertText["description"]; ok {
+		finalDescription = v
+	}
+	if v, ok := req.RevertText["brand"]; ok {
+		finalBrand = v
+	}
+
 	finalName := existingProduct.Name
 	if req.Name != "" {
 		finalName = req.Name
 	}
 
-	finalMinPrice := existingProduct.MinPrice
-	if req.MinPrice != nil {
-		finalMinPrice = *req.MinPrice
+	finalBasePrice := existingProduct.BasePrice
+	if req.BasePrice != nil {
+		finalBasePrice = *req.BasePrice
 	}
 
 	finalIsPublished := existingProduct.IsPublished
@@ -358,26 +482,34 @@ func (prt *productController) UpdateProductController(ctx context.Context, req m
 		finalIsPublished = *req.IsPublished
 	}
 
+	// Không gửi thì giữ lịch cũ, gửi null / "" thì xóa lịch
 	var finalPublishedAt = existingProduct.PublishedAt
-	if req.PublishedAt != "" {
-		parsedTime, err := time.Parse(time.RFC3339, req.PublishedAt)
-		if err != nil {
-			return nil, err
-		}
-		finalPublishedAt = &parsedTime
+	if req.PublishedAt.Set {
+		finalPublishedAt = req.PublishedAt.Value
+	}
+
+	var finalUnpublishedAt = existingProduct.UnpublishedAt
+	if req.UnpublishedAt.Set {
+		finalUnpublishedAt = req.UnpublishedAt.Value
+	}
+	if err := validatePublishWindow(finalPublishedAt, finalUnpublishedAt); err != nil {
+		return nil, err
 	}
 
 	productToUpdate := &model.Product{
 		ID:               id,
 		Name:             finalName,
 		Slug:             finalSlug,
-		ShortDescription: mergeStringPtr(req.ShortDescription, existingProduct.ShortDescription),
-		Description:      mergeStringPtr(req.Description, existingProduct.Description),
-		Brand:            mergeStringPtr(req.Brand, existingProduct.Brand),
+		ShortDescription: finalShortDescription,
+		Description:      finalDescription,
+		Brand:            finalBrand,
 		Status:           req.Status,
 		IsPublished:      finalIsPublished,
 		PublishedAt:      finalPublishedAt,
-		MinPrice:         finalMinPrice,
+		UnpublishedAt:    finalUnpublishedAt,
+		BasePrice:        finalBasePrice,
+		MinPrice:         existingProduct.MinPrice,
+		MaxPrice:         existingProduct.MaxPrice,
 		UpdatedAt:        time.Now(),
 	}
 
@@ -385,11 +517,49 @@ func (prt *productController) UpdateProductController(ctx context.Context, req m
 		productToUpdate.Status = existingProduct.Status
 	}
 
-	updatedProduct, err := prt.Repo.UpdateProduct(productToUpdate, req.CategoryIDs)
+	// Đổi danh mục thì schema thông số đổi theo: đối chiếu lại thông số hiện tại (kèm attributes gửi lên) với danh mục mới,
+	// thông số không còn thuộc danh mục nào bị bỏ
+	var attributeValues []model.ProductAttributeValueInput
+	var oldAttributes []model.ProductAttributeValue
+	if req.CategoryIDs != nil {
+		oldAttributes, err = prt.Repo.GetProductAttributeValues(id)
+		if err != nil {
+			return nil, err
+		}
+		defs, err := prt.CategoryRepo.GetEffectiveAttributes(req.CategoryIDs)
+		if err != nil {
+			return nil, err
+		}
+		known := make(map[string]bool, len(defs))
+		for _, def := range defs {
+			known[def.Code] = true
+		}
+		input := make(map[string]any, len(oldAttributes)+len(req.Attributes))
+		for _, a := range oldAttributes {
+			if known[a.Code] {
+				input[a.Code] = a.Value
+			}
+		}
+		for code, v := range req.Attributes {
+			input[code] = v
+		}
+		attributeValues, err = utils.BuildProductAttributeValues(defs, input)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid attributes: %w", err)
+		}
+	}
+
+	updatedProduct, err := prt.Repo.UpdateProduct(productToUpdate, req.CategoryIDs, attributeValues)
 	if err != nil {
 		return nil, err
 	}
 	changes := make(map[string]model.ProductChangeLog)
+	if attributeValues != nil {
+		newAttributes, err := prt.Repo.GetProductAttributeValues(id)
+		if err == nil && !reflect.DeepEqual(attributeMap(oldAttributes), attributeMap(newAttributes)) {
+			changes["attributes"] = model.ProductChangeLog{Field: "attributes", OldValue: attributeMap(oldAttributes), NewValue: attributeMap(newAttributes)}
+		}
+	}
 
 	if existingProduct.Name != req.Name && req.Name != "" {
 		changes["name"] = model.ProductChangeLog{
@@ -405,26 +575,25 @@ func (prt *productController) UpdateProductController(ctx context.Context, req m
 			NewValue: req.Slug,
 		}
 	}
-	if req.ShortDescription != "" &&
-		(existingProduct.ShortDescription == nil || *existingProduct.ShortDescription != req.ShortDescription) {
+	if !stringPtrEqual(existingProduct.ShortDescription, finalShortDescription) {
 		changes["short_description"] = model.ProductChangeLog{
 			Field:    "short_description",
 			OldValue: existingProduct.ShortDescription,
-			NewValue: req.ShortDescription,
+			NewValue: finalShortDescription,
 		}
 	}
-	if existingProduct.Description != stringToPtr(req.Description) && req.Description != "" {
+	if !stringPtrEqual(existingProduct.Description, finalDescription) {
 		changes["description"] = model.ProductChangeLog{
 			Field:    "description",
 			OldValue: existingProduct.Description,
-			NewValue: req.Description,
+			NewValue: finalDescription,
 		}
 	}
-	if existingProduct.Brand != stringToPtr(req.Brand) && req.Brand != "" {
+	if !stringPtrEqual(existingProduct.Brand, finalBrand) {
 		changes["brand"] = model.ProductChangeLog{
 			Field:    "brand",
 			OldValue: existingProduct.Brand,
-			NewValue: req.Brand,
+			NewValue: finalBrand,
 		}
 	}
 	if existingProduct.Status != req.Status && req.Status != "" {
@@ -441,25 +610,32 @@ func (prt *productController) UpdateProductController(ctx context.Context, req m
 			NewValue: *req.IsPublished,
 		}
 	}
-	if req.MinPrice != nil && existingProduct.MinPrice != *req.MinPrice {
-		changes["min_price"] = model.ProductChangeLog{
-			Field:    "min_price",
-			OldValue: existingProduct.MinPrice,
-			NewValue: *req.MinPrice,
+	if req.BasePrice != nil && existingProduct.BasePrice != *req.BasePrice {
+		changes["base_price"] = model.ProductChangeLog{
+			Field:    "base_price",
+			OldValue: existingProduct.BasePrice,
+			NewValue: *req.BasePrice,
 		}
 	}
+	// Khoảng giá được UpdateProduct tính lại trong cùng transaction
+	if existingProduct.MinPrice != updatedProduct.MinPrice || existingProduct.MaxPrice != updatedProduct.MaxPrice {
+		changes["min_price"] = model.ProductChangeLog{Field: "min_price", OldValue: existingProduct.MinPrice, NewValue: updatedProduct.MinPrice}
+		changes["max_price"] = model.ProductChangeLog{Field: "max_price", OldValue: existingProduct.MaxPrice, NewValue: updatedProduct.MaxPrice}
+	}
 
-	if req.PublishedAt != "" {
-		newPublishedAt, err := time.Parse(time.RFC3339, req.PublishedAt)
-		if err != nil {
-			return nil, err
+	if !timePtrEqual(existingProduct.PublishedAt, finalPublishedAt) {
+		changes["published_at"] = model.ProductChangeLog{
+			Field:    "published_at",
+			OldValue: existingProduct.PublishedAt,
+			NewValue: finalPublishedAt,
 		}
-		if existingProduct.PublishedAt == nil || !existingProduct.PublishedAt.Equal(newPublishedAt) {
-			changes["published_at"] = model.ProductChangeLog{
-				Field:    "published_at",
-				OldValue: existingProduct.PublishedAt,
-				NewValue: newPublishedAt,
-			}
+	}
+
+	if !timePtrEqual(existingProduct.UnpublishedAt, finalUnpublishedAt) {
+		changes["unpublished_at"] = model.ProductChangeLog{
+			Field:    "unpublished_at",
+			OldValue: existingProduct.UnpublishedAt,
+			NewValue: finalUnpublishedAt,
 		}
 	}
 
@@ -493,23 +669,8 @@ func (prt *productController) UpdateProductController(ctx context.Context, req m
 		changesBytes, err := json.Marshal(changes)
 		if err == nil {
 
-			// === 1. KHAI BÁO BIẾN ADMIN ID ===
-			var adminID *int64 // Mặc định là nil
-
-			// === 2. GỌI ADMIN ID TỪ CONTEXT (Dùng key "userID" như trong middleware) ===
-			if val := ctx.Value("userID"); val != nil {
-				// Cần ép kiểu vì ctx.Value trả về interface{}
-				// JWT thường lưu số dưới dạng float64, nên cần kiểm tra cả 2 trường hợp
-				if idFloat, ok := val.(float64); ok {
-					v := int64(idFloat)
-					adminID = &v
-				} else if idInt, ok := val.(int64); ok {
-					adminID = &idInt
-				} else if idInt, ok := val.(int); ok {
-					v := int64(idInt)
-					adminID = &v
-				}
-			}
+			// === 1-2. LẤY ADMIN ID TỪ CONTEXT ===
+			adminID := utils.AdminIDFromContext(ctx)
 			var note *string
 			if req.Note != "" {
 				note = &req.Note
@@ -543,7 +704,10 @@ func (prt *productController) UpdateProductController(ctx context.Context, req m
 		Status:           updatedProduct.Status,
 		IsPublished:      updatedProduct.IsPublished,
 		PublishedAt:      updatedProduct.PublishedAt,
+		UnpublishedAt:    updatedProduct.UnpublishedAt,
+		BasePrice:        updatedProduct.BasePrice,
 		MinPrice:         updatedProduct.MinPrice,
+		MaxPrice:         updatedProduct.MaxPrice,
 		AvgRating:        updatedProduct.AvgRating,
 		RatingCount:      updatedProduct.RatingCount,
 		CreatedBy:        existingProduct.CreatedBy,
@@ -578,7 +742,10 @@ func (prt *productController) AdminGetAllProductsController() (*model.AdminProdu
 			Status:           pro.Status,
 			IsPublished:      pro.IsPublished,
 			PublishedAt:      pro.PublishedAt,
+			UnpublishedAt:    pro.UnpublishedAt,
+			BasePrice:        pro.BasePrice,
 			MinPrice:         pro.MinPrice,
+			MaxPrice:         pro.MaxPrice,
 			AvgRating:        pro.AvgRating,
 			RatingCount:      pro.RatingCount,
 			CreatedBy:        pro.CreatedBy,
@@ -601,16 +768,20 @@ func (prt *productController) UserGetAllProductsController() (*model.UserProduct
 	if err != nil {
 		return nil, err
 	}
+	now := time.Now()
 	var responses []model.UserProductResponse
 	for _, pro := range products {
-		if !pro.IsPublished {
+		if !pro.IsVisible(now) {
 			continue
 		}
 		responses = append(responses, model.UserProductResponse{
-			ID:       pro.ID,
-			Name:     pro.Name,
-			Brand:    pro.Brand,
-			MinPrice: pro.MinPrice,
+			ID:          pro.ID,
+			Name:        pro.Name,
+			Brand:       pro.Brand,
+			MinPrice:    pro.MinPrice,
+			MaxPrice:    pro.MaxPrice,
+			AvgRating:   pro.AvgRating,
+			RatingCount: pro.RatingCount,
 		})
 	}
 	return &model.UserProductListResponse{
@@ -625,16 +796,20 @@ func (prt *productController) UserSearchProductByNameController(req *model.Searc
 	if err != nil {
 		return nil, err
 	}
+	now := time.Now()
 	var res []model.UserProductResponse
 	for _, pro := range products {
-		if !pro.IsPublished {
+		if !pro.IsVisible(now) {
 			continue
 		}
 		res = append(res, model.UserProductResponse{
-			ID:       pro.ID,
-			Name:     pro.Name,
-			Brand:    pro.Brand,
-			MinPrice: pro.MinPrice,
+			ID:          pro.ID,
+			Name:        pro.Name,
+			Brand:       pro.Brand,
+			MinPrice:    pro.MinPrice,
+			MaxPrice:    pro.MaxPrice,
+			AvgRating:   pro.AvgRating,
+			RatingCount: pro.RatingCount,
 		})
 	}
 	return &model.UserProductListResponse{
@@ -661,7 +836,10 @@ func (prt *productController) AdminSearchProductsController(req *model.SearchPro
 			Status:           pro.Status,
 			IsPublished:      pro.IsPublished,
 			PublishedAt:      pro.PublishedAt,
+			UnpublishedAt:    pro.UnpublishedAt,
+			BasePrice:        pro.BasePrice,
 			MinPrice:         pro.MinPrice,
+			MaxPrice:         pro.MaxPrice,
 			AvgRating:        pro.AvgRating,
 			RatingCount:      pro.RatingCount,
 			CreatedBy:        pro.CreatedBy,
@@ -696,7 +874,10 @@ func (prt *productController) AdminGetManyProductByIDController(ids []int64) ([]
 			Status:           pro.Status,
 			IsPublished:      pro.IsPublished,
 			PublishedAt:      pro.PublishedAt,
+			UnpublishedAt:    pro.UnpublishedAt,
+			BasePrice:        pro.BasePrice,
 			MinPrice:         pro.MinPrice,
+			MaxPrice:         pro.MaxPrice,
 			AvgRating:        pro.AvgRating,
 			RatingCount:      pro.RatingCount,
 			CreatedBy:        pro.CreatedBy,
@@ -717,14 +898,17 @@ func (prt *productController) UserGetProductController(reqProduct *model.GetProd
 	if err != nil {
 		return nil, err
 	}
-	if !pro.IsPublished {
+	if !pro.IsVisible(time.Now()) {
 		return nil, fmt.Errorf("product not available")
 	}
 	return &model.UserProductResponse{
-		ID:       pro.ID,
-		Name:     pro.Name,
-		Brand:    pro.Brand,
-		MinPrice: pro.MinPrice,
+		ID:          pro.ID,
+		Name:        pro.Name,
+		Brand:       pro.Brand,
+		MinPrice:    pro.MinPrice,
+		MaxPrice:    pro.MaxPrice,
+		AvgRating:   pro.AvgRating,
+		RatingCount: pro.RatingCount,
 	}, nil
 }
 
@@ -751,7 +935,10 @@ func (prt *productController) AdminGetAllSoftDeletedProductsController() (*model
 			Status:           pro.Status,
 			IsPublished:      pro.IsPublished,
 			PublishedAt:      pro.PublishedAt,
+			UnpublishedAt:    pro.UnpublishedAt,
+			BasePrice:        pro.BasePrice,
 			MinPrice:         pro.MinPrice,
+			MaxPrice:         pro.MaxPrice,
 			AvgRating:        pro.AvgRating,
 			RatingCount:      pro.RatingCount,
 			CreatedBy:        pro.CreatedBy,
@@ -772,7 +959,608 @@ func (prt *productController) AdminDeleteAllSoftDeletedProductsController() erro
 	return prt.Repo.DeleteAllProductsSoftDeleted()
 }
 
-// AdminDeleteAllProductsController - Xóa cứng tất cả sản phẩm
-func (prt *productController) AdminDeleteAllProductsController() error {
-	return prt.Repo.DeleteAllProducts()
+// AdminDeleteAllProductsController - Dọn sạch thùng rác (xóa cứng), bỏ qua sản phẩm còn nằm trong đơn chưa kết thúc
+func (prt *productController) AdminDeleteAllProductsController() (*model.AdminPurgeProductsResponse, error) {
+	trashed, err := prt.Repo.GetAllProductsSoftDeleted()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(trashed))
+	for _, pro := range trashed {
+		ids = append(ids, pro.ID)
+	}
+	return prt.purgeProducts(ids)
+}
+
+// AdminHardDeleteProductController - Xóa cứng 1 sản phẩm trong thùng rác
+func (prt *productController) AdminHardDeleteProductController(id int64) (*model.AdminPurgeProductsResponse, error) {
+	trashed, err := prt.Repo.GetSoftDeletedProductsByIDs([]int64{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(trashed) == 0 {
+		return nil, fmt.Errorf("Product not found in trash")
+	}
+
+	res, err := prt.purgeProducts([]int64{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Blocked) > 0 {
+		return res, fmt.Errorf("Product is referenced by active orders")
+	}
+	return res, nil
+}
+
+// purgeProducts - Xóa cứng các sản phẩm không bị đơn hàng chưa kết thúc tham chiếu
+func (prt *productController) purgeProducts(ids []int64) (*model.AdminPurgeProductsResponse, error) {
+	blocking, err := prt.Repo.GetBlockingOrders(ids)
+	if err != nil {
+		return nil, err
+	}
+	blocked := make(map[int64]bool)
+	for _, b := range blocking {
+		blocked[b.ProductID] = true
+	}
+
+	deletable := []int64{}
+	for _, id := range ids {
+		if !blocked[id] {
+			deletable = append(deletable, id)
+		}
+	}
+
+	if err := prt.Repo.HardDeleteProducts(deletable); err != nil {
+		return nil, err
+	}
+
+	return &model.AdminPurgeProductsResponse{
+		Message: fmt.Sprintf("Deleted %d products, %d blocked by active orders", len(deletable), len(blocked)),
+		Deleted: deletable,
+		Blocked: blocking,
+	}, nil
+}
+
+// Số ngày lưu sản phẩm trong thùng rác, ghi đè bằng PRODUCT_TRASH_RETENTION_DAYS
+const defaultTrashRetentionDays = 30
+
+// trashRetentionDays - Số ngày lưu sản phẩm trong thùng rác
+func trashRetentionDays() int {
+	return utils.EnvInt("PRODUCT_TRASH_RETENTION_DAYS", defaultTrashRetentionDays)
+}
+
+// PurgeExpiredTrash - Job: xóa cứng sản phẩm đã nằm trong thùng rác quá thời gian lưu trữ
+func (prt *productController) PurgeExpiredTrash(ctx context.Context) error {
+	days := trashRetentionDays()
+	ids, err := prt.Repo.GetExpiredSoftDeletedProductIDs(days)
+	if err != nil {
+		logger.ErrorLogger.Printf("PurgeExpiredTrash: cannot load expired products: %v", err)
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	res, err := prt.purgeProducts(ids)
+	if err != nil {
+		logger.ErrorLogger.Printf("PurgeExpiredTrash: purge failed: %v", err)
+		return err
+	}
+	for _, b := range res.Blocked {
+		logger.WarnLogger.Printf("PurgeExpiredTrash: product %d kept, blocked by order %s (%s)", b.ProductID, b.OrderNumber, b.Status)
+	}
+	logger.InfoLogger.Printf("PurgeExpiredTrash: deleted %d products older than %d days", len(res.Deleted), days)
+	return nil
+}
+
+// AdminRestoreProductsController - Khôi phục sản phẩm từ thùng rác (kiểm tra lại trùng name / slug)
+func (prt *productController) AdminRestoreProductsController(ctx context.Context, ids []int64) (*model.AdminRestoreProductsResponse, error) {
+	trashed, err := prt.Repo.GetSoftDeletedProductsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[int64]model.Product)
+	for _, pro := range trashed {
+		found[pro.ID] = pro
+	}
+
+	adminID := utils.AdminIDFromContext(ctx)
+	res := &model.AdminRestoreProductsResponse{Restored: []int64{}}
+	for _, id := range ids {
+		pro, ok := found[id]
+		if !ok {
+			res.Conflicts = append(res.Conflicts, model.ProductRestoreConflict{ProductID: id, Reason: "product not found in trash"})
+			continue
+		}
+
+		nameConflict, slugConflict, err := prt.Repo.CheckRestoreConflict(pro.ID, pro.Name, pro.Slug)
+		if err != nil {
+			return nil, err
+		}
+		if nameConflict || slugConflict {
+			reason := "slug already used by another product"
+			if nameConflict {
+				reason = "name already used by another product"
+			}
+			res.Conflicts = append(res.Conflicts, model.ProductRestoreConflict{ProductID: id, Reason: reason})
+			continue
+		}
+
+		if err := prt.Repo.RestoreProduct(pro.ID); err != nil {
+			res.Conflicts = append(res.Conflicts, model.ProductRestoreConflict{ProductID: id, Reason: err.Error()})
+			continue
+		}
+
+		changesBytes, err := json.Marshal(map[string]model.ProductChangeLog{
+			"deleted_at":   {Field: "deleted_at", OldValue: pro.DeletedAt, NewValue: nil},
+			"status":       {Field: "status", OldValue: pro.Status, NewValue: "draft"},
+			"is_published": {Field: "is_published", OldValue: pro.IsPublished, NewValue: false},
+		})
+		if err == nil {
+			note := "Restored from trash"
+			prt.HistoryRepo.CreateProductHistory(&model.ProductHistory{
+				ProductID: pro.ID,
+				AdminID:   adminID,
+				Changes:   json.RawMessage(changesBytes),
+				Note:      &note,
+				ChangedAt: time.Now(),
+			})
+		}
+		res.Restored = append(res.Restored, pro.ID)
+	}
+
+	res.Message = fmt.Sprintf("Restored %d/%d products", len(res.Restored), len(ids))
+	return res, nil
+}
+
+// AdminGetScheduledChangesController - Lấy danh sách publish / unpublish sắp diễn ra
+func (prt *productController) AdminGetScheduledChangesController() (*model.AdminScheduledChangesResponse, error) {
+	changes, err := prt.Repo.GetUpcomingScheduledChanges()
+	if err != nil {
+		return nil, err
+	}
+	return &model.AdminScheduledChangesResponse{
+		Message: "Scheduled changes retrieved successfully",
+		Changes: changes,
+	}, nil
+}
+
+// RunScheduledPublishing - Job: bật / tắt publish các sản phẩm đã đến lịch và ghi lịch sử (actor hệ thống)
+func (prt *productController) RunScheduledPublishing(ctx context.Context) error {
+	changes, err := prt.Repo.GetDueScheduledChanges()
+	if err != nil {
+		logger.ErrorLogger.Printf("RunScheduledPublishing: cannot load due products: %v", err)
+		return err
+	}
+
+	for _, c := range changes {
+		isPublished := c.Action == model.ScheduleActionPublish
+		changed, err := prt.Repo.ApplyScheduledChange(c.ProductID, c.Action)
+		if err != nil {
+			logger.ErrorLogger.Printf("RunScheduledPublishing: product %d (%s) failed: %v", c.ProductID, c.Action, err)
+			continue
+		}
+		// Sản phẩm đã ở trạng thái đích (Admin bật / tắt tay trước giờ hẹn) -> chỉ đánh dấu lịch, không ghi lịch sử
+		if !changed {
+			continue
+		}
+
+		changesBytes, err := json.Marshal(map[string]model.ProductChangeLog{
+			"is_published": {Field: "is_published", OldValue: !isPublished, NewValue: isPublished},
+		})
+		if err != nil {
+			continue
+		}
+		note := fmt.Sprintf("[SYSTEM] Scheduled %s at %s", c.Action, c.ScheduledAt.Format(time.RFC3339))
+		// AdminID = nil -> thay đổi do hệ thống thực hiện
+		if _, err := prt.HistoryRepo.CreateProductHistory(&model.ProductHistory{
+			ProductID: c.ProductID,
+			Changes:   json.RawMessage(changesBytes),
+			Note:      &note,
+			ChangedAt: time.Now(),
+		}); err != nil {
+			logger.ErrorLogger.Printf("RunScheduledPublishing: cannot write history for product %d: %v", c.ProductID, err)
+		}
+
+		logger.InfoLogger.Printf("RunScheduledPublishing: product %d -> %s", c.ProductID, c.Action)
+	}
+	return nil
+}
+
+// AdminRecomputePricesController - Tính lại min_price / max_price cho toàn bộ sản phẩm và ghi lịch sử
+func (prt *productController) AdminRecomputePricesController(ctx context.Context) (*model.AdminRecomputePricesResponse, error) {
+	products, err := prt.Repo.GetAllProducts()
+	if err != nil {
+		return nil, err
+	}
+
+	adminID := utils.AdminIDFromContext(ctx)
+	note := "Recompute price range from active variants"
+	updated := []model.PriceRangeChange{}
+	for _, pro := range products {
+		change, err := prt.Repo.SyncPriceRange(pro.ID)
+		if err != nil {
+			logger.ErrorLogger.Printf("AdminRecomputePrices: product %d failed: %v", pro.ID, err)
+			continue
+		}
+		if change == nil {
+			continue
+		}
+
+		changesBytes, err := json.Marshal(map[string]model.ProductChangeLog{
+			"min_price": {Field: "min_price", OldValue: change.OldMinPrice, NewValue: change.NewMinPrice},
+			"max_price": {Field: "max_price", OldValue: change.OldMaxPrice, NewValue: change.NewMaxPrice},
+		})
+		if err == nil {
+			prt.HistoryRepo.CreateProductHistory(&model.ProductHistory{
+				ProductID: pro.ID,
+				AdminID:   adminID,
+				Changes:   json.RawMessage(changesBytes),
+				Note:      &note,
+				ChangedAt: time.Now(),
+			})
+		}
+		updated = append(updated, *change)
+	}
+
+	return &model.AdminRecomputePricesResponse{
+		Message: fmt.Sprintf("Recomputed price range for %d products", len(updated)),
+		Updated: updated,
+	}, nil
+}
+
+// RevertProductHistoryController - Khôi phục giá trị cũ của một bản ghi lịch sử qua luồng update thông thường
+// (bản thân việc revert cũng được ghi thành một bản ghi lịch sử mới)
+func (prt *productController) RevertProductHistoryController(ctx context.Context, productID, historyID int64) (*model.RevertProductHistoryResponse, error) {
+	h, err := prt.HistoryRepo.GetProductHistoryByID(historyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("History not found")
+		}
+		return nil, err
+	}
+	if h.ProductID != productID {
+		return nil, fmt.Errorf("History not found")
+	}
+
+	var changes map[string]model.ProductChangeLog
+	if err := json.Unmarshal(h.Changes, &changes); err != nil {
+		return nil, fmt.Errorf("cannot parse history changes: %w", err)
+	}
+
+	fields := make([]string, 0, len(changes))
+	for k := range changes {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+
+	note := fmt.Sprintf("Revert history #%d", h.ID)
+	if h.VariantID != nil {
+		return prt.revertVariantHistory(ctx, h, fields, changes, note)
+	}
+	// Lịch sử của biến thể đã xóa: variant_id bị đặt NULL (hoặc là bản ghi xóa biến thể)
+	if _, isVariant := changes["sku"]; isVariant {
+		return nil, fmt.Errorf("Variant has been deleted")
+	}
+
+	req := model.UpdateProductRequest{Note: note, RevertText: map[string]*string{}}
+	reverted := []string{}
+	skipped := []string{}
+	for _, field := range fields {
+		old := changes[field].OldValue
+		ok := false
+		switch field {
+		case "name", "slug", "status":
+			var v string
+			if v, ok = old.(string); ok && v != "" {
+				switch field {
+				case "name":
+					req.Name = v
+				case "slug":
+					req.Slug = v
+				case "status":
+					req.Status = v
+				}
+			} else {
+				ok = false
+			}
+		case "short_description", "description", "brand":
+			// Field có thể NULL: giá trị cũ NULL -> đặt lại NULL, chuỗi rỗng -> đặt lại chuỗi rỗng
+			if old == nil {
+				ok = true
+				req.RevertText[field] = nil
+			} else {
+				var v string
+				if v, ok = old.(string); ok {
+					req.RevertText[field] = &v
+				}
+			}
+		case "is_published":
+			var v bool
+			if v, ok = old.(bool); ok {
+				req.IsPublished = &v
+			}
+		case "base_price":
+			var v float64
+			if v, ok = old.(float64); ok && v > 0 {
+				req.BasePrice = &v
+			} else {
+				ok = false
+			}
+		case "published_at", "unpublished_at":
+			// Lịch cũ NULL -> xóa lịch
+			value := model.OptionalTime{Set: true}
+			if old == nil {
+				ok = true
+			} else if v, isString := old.(string); isString {
+				t, err := time.Parse(time.RFC3339Nano, v)
+				if ok = err == nil; ok {
+					value.Value = &t
+				}
+			}
+			if ok {
+				if field == "published_at" {
+					req.PublishedAt = value
+				} else {
+					req.UnpublishedAt = value
+				}
+			}
+		case "categories":
+			ids := utils.ToInt64Slice(old)
+			if ok = len(ids) > 0; ok {
+				req.CategoryIDs = ids
+			}
+		}
+
+		if ok {
+			reverted = append(reverted, field)
+		} else {
+			skipped = append(skipped, field)
+		}
+	}
+
+	if len(reverted) == 0 {
+		return nil, fmt.Errorf("Nothing to revert")
+	}
+
+	if _, err := prt.UpdateProductController(ctx, req, productID); err != nil {
+		return nil, err
+	}
+
+	return &model.RevertProductHistoryResponse{
+		Message:        "History reverted successfully",
+		HistoryID:      h.ID,
+		RevertedFields: reverted,
+		SkippedFields:  skipped,
+	}, nil
+}
+
+// revertVariantHistory - Khôi phục thay đổi của biến thể qua luồng cập nhật biến thể
+func (prt *productController) revertVariantHistory(ctx context.Context, h *model.ProductHistory, fields []string, changes map[string]model.ProductChangeLog, note string) (*model.RevertProductHistoryResponse, error) {
+	// Bản ghi tạo biến thể (mọi giá trị cũ đều NULL): khôi phục nghĩa là xóa biến thể, không làm qua revert
+	created := true
+	for _, c := range changes {
+		if c.OldValue != nil {
+			created = false
+			break
+		}
+	}
+	if created {
+		return nil, fmt.Errorf("Cannot revert variant creation")
+	}
+
+	current, err := prt.RepoVariants.GetVariantByID(*h.VariantID)
+	if err != nil {
+		return nil, fmt.Errorf("Variant no longer exists")
+	}
+
+	req := model.UpdateVariantRequest{
+		SKU:              current.SKU,
+		StockQuantity:    current.StockQuantity,
+		IsActive:         current.IsActive,
+		AllowBackorder:   current.AllowBackorder,
+		WeightGrams:      current.WeightGrams,
+		ReorderThreshold: current.ReorderThreshold,
+	}
+	if current.Title != nil {
+		req.Title = *current.Title
+	}
+	if current.OptionValues != nil {
+		req.OptionValues = *current.OptionValues
+	}
+	if current.PriceOverride != nil {
+		req.PriceOverride = *current.PriceOverride
+	}
+	req.CostPrice = current.CostPrice
+
+	reverted := []string{}
+	skipped := []string{}
+	for _, field := range fields {
+		old := changes[field].OldValue
+		ok := false
+		switch field {
+		case "sku", "title", "option_values":
+			var v string
+			// sku / option_values bắt buộc; title cho phép khôi phục chuỗi rỗng (NULL thì bỏ qua)
+			if v, ok = old.(string); ok && (v != "" || field == "title") {
+				switch field {
+				case "sku":
+					req.SKU = v
+				case "title":
+					req.Title = v
+				case "option_values":
+					req.OptionValues = v
+				}
+			} else {
+				ok = false
+			}
+		case "price_override", "cost_price":
+			// price_override NULL = dùng giá gốc sản phẩm, tương đương 0 (utils.VariantPrice); cost_price NULL = chưa có giá vốn
+			if old == nil && field == "price_override" {
+				req.PriceOverride, ok = 0, true
+				break
+			}
+			if old == nil && field == "cost_price" {
+				req.CostPrice, ok = nil, true
+				break
+			}
+			var v float64
+			if v, ok = old.(float64); ok {
+				switch field {
+				case "price_override":
+					req.PriceOverride = v
+				case "cost_price":
+					req.CostPrice = &v
+				}
+			}
+		case "stock_quantity":
+			// Không khôi phục tồn kho: sau bản ghi này tồn kho còn thay đổi theo đơn hàng / điều chỉnh kho,
+			// ghi đè giá trị cũ sẽ làm lệch kho (cần đổi thì dùng điều chỉnh tồn kho)
+			ok = false
+		case "weight_grams":
+			if old == nil {
+				req.WeightGrams, ok = nil, true
+			} else {
+				var v float64
+				if v, ok = old.(float64); ok {
+					w := int(v)
+					req.WeightGrams = &w
+				}
+			}
+		case "reorder_threshold":
+			if old == nil {
+				req.ReorderThreshold, ok = nil, true
+			} else {
+				var v float64
+				if v, ok = old.(float64); ok {
+					t := int(v)
+					req.ReorderThreshold = &t
+				}
+			}
+		case "allow_backorder", "is_active":
+			var v bool
+			if v, ok = old.(bool); ok {
+				if field == "is_active" {
+					req.IsActive = v
+				} else {
+					req.AllowBackorder = v
+				}
+			}
+		}
+
+		if ok {
+			reverted = append(reverted, field)
+		} else {
+			skipped = append(skipped, field)
+		}
+	}
+
+	if len(reverted) == 0 {
+		return nil, fmt.Errorf("Nothing to revert")
+	}
+
+	if _, err := prt.VariantCtrl.UpdateVariant(ctx, req, current.ID, h.ProductID); err != nil {
+		return nil, err
+	}
+
+	return &model.RevertProductHistoryResponse{
+		Message:        note,
+		HistoryID:      h.ID,
+		RevertedFields: reverted,
+		SkippedFields:  skipped,
+	}, nil
+}
+
+// buildAttributeValues - Đối chiếu thông số gửi lên với schema của danh mục (kể cả danh mục cha)
+func (prt *productController) buildAttributeValues(categoryIDs []int64, input map[string]any) ([]model.ProductAttributeValueInput, error) {
+	defs, err := prt.CategoryRepo.GetEffectiveAttributes(categoryIDs)
+	if err != nil {
+		return nil, err
+	}
+	values, err := utils.BuildProductAttributeValues(defs, input)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid attributes: %w", err)
+	}
+	return values, nil
+}
+
+// attributeMap - Thông số dạng map code -> value để ghi lịch sử cho dễ đọc
+func attributeMap(attrs []model.ProductAttributeValue) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		m[a.Code] = a.Value
+	}
+	return m
+}
+
+// AdminGetProductAttributesController - Lấy thông số của sản phẩm
+func (prt *productController) AdminGetProductAttributesController(productID int64) (*model.ProductAttributesResponse, error) {
+	if _, err := prt.Repo.GetProductByID(productID); err != nil {
+		return nil, fmt.Errorf("Product not found")
+	}
+
+	attributes, err := prt.Repo.GetProductAttributeValues(productID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ProductAttributesResponse{
+		Message:    "Product attributes retrieved successfully",
+		ProductID:  productID,
+		Attributes: attributes,
+	}, nil
+}
+
+// AdminSetProductAttributesController - Ghi đè thông số của sản phẩm và ghi lịch sử
+func (prt *productController) AdminSetProductAttributesController(ctx context.Context, productID int64, req model.SetProductAttributesRequest) (*model.ProductAttributesResponse, error) {
+	if _, err := prt.Repo.GetProductByID(productID); err != nil {
+		return nil, fmt.Errorf("Product not found")
+	}
+
+	cats, err := prt.Repo.GetCategoriesByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+	categoryIDs := make([]int64, 0, len(cats))
+	for _, c := range cats {
+		categoryIDs = append(categoryIDs, c.ID)
+	}
+
+	values, err := prt.buildAttributeValues(categoryIDs, req.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	oldAttributes, err := prt.Repo.GetProductAttributeValues(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := prt.Repo.ReplaceProductAttributeValues(productID, values); err != nil {
+		return nil, err
+	}
+
+	newAttributes, err := prt.Repo.GetProductAttributeValues(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	changesBytes, err := json.Marshal(map[string]model.ProductChangeLog{
+		"attributes": {Field: "attributes", OldValue: attributeMap(oldAttributes), NewValue: attributeMap(newAttributes)},
+	})
+	if err == nil {
+		note := "Updated product attributes"
+		prt.HistoryRepo.CreateProductHistory(&model.ProductHistory{
+			ProductID: productID,
+			AdminID:   utils.AdminIDFromContext(ctx),
+			Changes:   json.RawMessage(changesBytes),
+			Note:      &note,
+			ChangedAt: time.Now(),
+		})
+	}
+
+	return &model.ProductAttributesResponse{
+		Message:    "Product attributes updated successfully",
+		ProductID:  productID,
+		Attributes: newAttributes,
+	}, nil
 }
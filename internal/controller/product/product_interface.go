@@ -50,6 +50,32 @@ type ProductController interface {
 	// Xóa mềm nhiều sản phẩm
 	AdminDeleteAllSoftDeletedProductsController() error
 	
-	// Xóa cứng tất cả sản phẩm
-	AdminDeleteAllProductsController() error
+	// Dọn sạch thùng rác (xóa cứng, bỏ qua sản phẩm còn trong đơn chưa kết thúc)
+	AdminDeleteAllProductsController() (*model.AdminPurgeProductsResponse, error)
+
+	// Xóa cứng 1 sản phẩm trong thùng rác
+	AdminHardDeleteProductController(id int64) (*model.AdminPurgeProductsResponse, error)
+
+	// Khôi phục sản phẩm từ thùng rác
+	AdminRestoreProductsController(ctx context.Context, ids []int64) (*model.AdminRestoreProductsResponse, error)
+
+	// Thông số kỹ thuật của sản phẩm
+	AdminGetProductAttributesController(productID int64) (*model.ProductAttributesResponse, error)
+	AdminSetProductAttributesController(ctx context.Context, productID int64, req model.SetProductAttributesRequest) (*model.ProductAttributesResponse, error)
+
+	// Job chạy định kỳ: xóa cứng sản phẩm quá hạn lưu trong thùng rác
+	PurgeExpiredTrash(ctx context.Context) error
+
+	// Scheduled publish
+	// Lấy danh sách publish / unpublish sắp diễn ra
+	AdminGetScheduledChangesController() (*model.AdminScheduledChangesResponse, error)
+
+	// Job chạy định kỳ: publish / unpublish các sản phẩm đến lịch
+	RunScheduledPublishing(ctx context.Context) error
+
+	// Tính lại min_price / max_price từ biến thể cho toàn bộ sản phẩm
+	AdminRecomputePricesController(ctx context.Context) (*model.AdminRecomputePricesResponse, error)
+
+	// Khôi phục giá trị cũ từ một bản ghi lịch sử
+	RevertProductHistoryController(ctx context.Context, productID, historyID int64) (*model.RevertProductHistoryResponse, error)
 }
\ No newline at end of file
@@ -1,13 +1,24 @@
 package productvariant
 
-import "golang/internal/model"
+import (
+	"context"
+	"golang/internal/model"
+)
 
 // ProductVariantController - Interface định nghĩa nghiệp vụ biến thể
 type ProductVariantController interface {
 	// tạo mới biến thể cho sản phẩm
-	CreateVariant(req model.CreateVariantRequest, productID int64) (*model.CreateVariantResponse, error)
+	CreateVariant(ctx context.Context, req model.CreateVariantRequest, productID int64) (*model.CreateVariantResponse, error)
 	// Cập nhật biến thể sản phẩm
-	UpdateVariant(req model.UpdateVariantRequest, variantID int64, productID int64) (*model.UpdateVariantResponse, error)
+	UpdateVariant(ctx context.Context, req model.UpdateVariantRequest, variantID int64, productID int64) (*model.UpdateVariantResponse, error)
+	// Điều chỉnh tồn kho (nhập / xuất kho)
+	AdjustVariantStock(ctx context.Context, req model.AdjustVariantStockRequest, variantID int64, productID int64) (*model.AdjustVariantStockResponse, error)
 	// Xóa biến thể sản phẩm
-	DeleteVariant(variantID int64, productID int64) (*model.DeleteVariantResponse, error)
+	DeleteVariant(ctx context.Context, variantID int64, productID int64) (*model.DeleteVariantResponse, error)
+	// Lấy option của sản phẩm
+	GetProductOptions(productID int64) (*model.ProductOptionsResponse, error)
+	// Ghi đè option của sản phẩm
+	SetProductOptions(req model.SetProductOptionsRequest, productID int64) (*model.ProductOptionsResponse, error)
+	// Sinh biến thể theo tổ hợp option
+	GenerateVariantMatrix(ctx context.Context, req model.GenerateVariantMatrixRequest, productID int64) (*model.GenerateVariantMatrixResponse, error)
 }
\ No newline at end of file
@@ -1,57 +1,249 @@
 package productvariant
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"golang/internal/logger"
 	"golang/internal/model"
+	"golang/internal/repository/producthistory"
 	"golang/internal/repository/productvariant"
+	"golang/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/gosimple/slug"
 )
 
+// ErrInvalidOptions - Lỗi dữ liệu option không hợp lệ (handler trả về 400)
+var ErrInvalidOptions = errors.New("invalid options")
+
+// ErrInvalidStockAdjust - Điều chỉnh tồn kho không hợp lệ (handler trả về 400)
+var ErrInvalidStockAdjust = errors.New("invalid stock adjustment")
+
+// ErrMatrixTooLarge - Số tổ hợp option vượt giới hạn sinh matrix (handler trả về 400)
+var ErrMatrixTooLarge = errors.New("variant matrix too large")
+
+// ErrVariantConflict - SKU hoặc tổ hợp option đã thuộc biến thể khác (handler trả về 409)
+var ErrVariantConflict = errors.New("variant conflict")
+
+// Số tổ hợp tối đa cho 1 lần sinh matrix, ghi đè bằng VARIANT_MATRIX_MAX_COMBINATIONS
+const defaultMatrixMaxCombinations = 100
+
+// RestockNotifier - Nhận sự kiện biến thể có hàng trở lại (tồn kho từ 0 lên dương)
+type RestockNotifier interface {
+	NotifyBackInStock(ctx context.Context, variantID int64)
+}
+
+// LowStockChecker - Kiểm tra + gửi cảnh báo sắp hết hàng (module Stats cung cấp; Cron mỗi giờ vẫn chạy để bù)
+type LowStockChecker interface {
+	CheckLowStock(ctx context.Context) error
+}
+
 type productVariantController struct {
-	VariantRepo productvariant.ProductVariantsRepository
+	VariantRepo     productvariant.ProductVariantsRepository
+	HistoryRepo     producthistory.ProductHistoryRepository
+	RestockNotifier RestockNotifier
+	LowStock        LowStockChecker
+}
+
+func NewProductVariantController(repoVariant productvariant.ProductVariantsRepository, repoHistory producthistory.ProductHistoryRepository, restockNotifier RestockNotifier, lowStock LowStockChecker) ProductVariantController {
+	return &productVariantController{
+		VariantRepo:     repoVariant,
+		HistoryRepo:     repoHistory,
+		RestockNotifier: restockNotifier,
+		LowStock:        lowStock,
+	}
+}
+
+// notifyIfRestocked - Tồn kho chuyển từ 0 (hoặc âm) lên dương thì báo cho người đăng ký (chạy nền, không chặn request)
+func (c *productVariantController) notifyIfRestocked(variantID int64, oldStock int, newStock int) {
+	if c.RestockNotifier == nil || oldStock > 0 || newStock <= 0 {
+		return
+	}
+	go c.RestockNotifier.NotifyBackInStock(context.Background(), variantID)
+}
+
+// checkLowStock - Tồn kho giảm (hoặc đổi ngưỡng đặt hàng lại) thì kiểm tra cảnh báo ngay, không đợi Cron (chạy nền)
+func (c *productVariantController) checkLowStock() {
+	if c.LowStock == nil {
+		return
+	}
+	go func() {
+		if err := c.LowStock.CheckLowStock(context.Background()); err != nil {
+			logger.ErrorLogger.Printf("Kiểm tra cảnh báo sắp hết hàng thất bại: %v", err)
+		}
+	}()
+}
+
+// variantSnapshot - Giá trị các field của biến thể dùng để ghi lịch sử
+func variantSnapshot(v *model.ProductsVariants) map[string]interface{} {
+	return map[string]interface{}{
+		"sku":               v.SKU,
+		"title":             v.Title,
+		"option_values":     v.OptionValues,
+		"price_override":    v.PriceOverride,
+		"cost_price":        v.CostPrice,
+		"stock_quantity":    v.StockQuantity,
+		"allow_backorder":   v.AllowBackorder,
+		"is_active":         v.IsActive,
+		"weight_grams":      v.WeightGrams,
+		"reorder_threshold": v.ReorderThreshold,
+	}
+}
+
+// recordVariantHistory - Ghi lịch sử thay đổi biến thể vào product_history
+func (c *productVariantController) recordVariantHistory(ctx context.Context, productID int64, variantID *int64, changes map[string]model.ProductChangeLog, note string) {
+	if len(changes) == 0 {
+		return
+	}
+	changesBytes, err := json.Marshal(changes)
+	if err != nil {
+		return
+	}
+	if _, err := c.HistoryRepo.CreateProductHistory(&model.ProductHistory{
+		ProductID: productID,
+		VariantID: variantID,
+		AdminID:   utils.AdminIDFromContext(ctx),
+		Changes:   json.RawMessage(changesBytes),
+		Note:      &note,
+		ChangedAt: time.Now(),
+	}); err != nil {
+		logger.ErrorLogger.Printf("recordVariantHistory: product %d failed: %v", productID, err)
+	}
+}
+
+// recordPriceRangeChange - Ghi lịch sử min_price / max_price do repo tính lại trong transaction ghi biến thể
+func (c *productVariantController) recordPriceRangeChange(productID int64, variantID *int64, change *model.PriceRangeChange) {
+	if change == nil {
+		return
+	}
+
+	changesBytes, err := json.Marshal(map[string]model.ProductChangeLog{
+		"min_price": {Field: "min_price", OldValue: change.OldMinPrice, NewValue: change.NewMinPrice},
+		"max_price": {Field: "max_price", OldValue: change.OldMaxPrice, NewValue: change.NewMaxPrice},
+	})
+	if err != nil {
+		return
+	}
+	note := "[SYSTEM] Price range derived from active variants"
+	if _, err := c.HistoryRepo.CreateProductHistory(&model.ProductHistory{
+		ProductID: productID,
+		VariantID: variantID,
+		Changes:   json.RawMessage(changesBytes),
+		Note:      &note,
+		ChangedAt: time.Now(),
+	}); err != nil {
+		logger.ErrorLogger.Printf("recordPriceRangeChange: cannot write history for product %d: %v", productID, err)
+	}
 }
 
-func NewProductVariantController(repoVariant productvariant.ProductVariantsRepository) ProductVariantController {
-	return &productVariantController{VariantRepo: repoVariant}
+// checkVariantOptions - Kiểm tra option_values khớp option của sản phẩm và không trùng biến thể khác
+// Trả về option_values đã chuẩn hóa để lưu DB
+func (c *productVariantController) checkVariantOptions(productID int64, raw string, excludeVariantID int64) (string, error) {
+	values, err := utils.ParseOptionValues(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidOptions, err)
+	}
+	// Bỏ khoảng trắng thừa trước khi so với tên / giá trị option của sản phẩm
+	values, err = utils.TrimOptionValues(values)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidOptions, err)
+	}
+
+	options, err := c.VariantRepo.GetProductOptions(productID)
+	if err != nil {
+		return "", err
+	}
+	if len(options) > 0 {
+		if err := utils.ValidateOptionValues(values, options); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidOptions, err)
+		}
+	}
+
+	canonical := utils.CanonicalOptionValues(values)
+	variants, err := c.VariantRepo.GetProductVariantByID(productID)
+	if err != nil {
+		return "", err
+	}
+	for _, v := range variants {
+		if v.ID == excludeVariantID || v.OptionValues == nil {
+			continue
+		}
+		existing, err := utils.ParseOptionValues(*v.OptionValues)
+		if err != nil {
+			continue
+		}
+		if utils.CanonicalOptionValues(existing) == canonical {
+			return "", fmt.Errorf("%w: tổ hợp option đã tồn tại ở biến thể %s", ErrInvalidOptions, v.SKU)
+		}
+	}
+	return canonical, nil
+}
+
+// variantWriteError - Lỗi trùng khóa UNIQUE (SKU / tổ hợp option) khi ghi biến thể -> ErrVariantConflict
+func variantWriteError(err error) error {
+	if errors.Is(err, productvariant.ErrDuplicateVariant) {
+		return fmt.Errorf("%w: SKU hoặc tổ hợp option đã tồn tại", ErrVariantConflict)
+	}
+	return err
 }
 
 // CreateVariant - Tạo biến thể mới cho sản phẩm
-func (c *productVariantController) CreateVariant(req model.CreateVariantRequest, productID int64) (*model.CreateVariantResponse, error) {
-	newVariant := &model.ProductsVariants{
-		ProductID:      productID,
-		SKU:            req.SKU,
-		Title:          &req.Title,
-		OptionValues:   &req.OptionValues,
-		PriceOverride:  &req.PriceOverride,
-		CostPrice:      &req.CostPrice,
-		StockQuantity:  req.StockQuantity,
-		IsActive:       req.IsActive,
-		AllowBackorder: req.AllowBackorder,
-	}
-	createVariant, err := c.VariantRepo.CreateProductVariant(newVariant)
+func (c *productVariantController) CreateVariant(ctx context.Context, req model.CreateVariantRequest, productID int64) (*model.CreateVariantResponse, error) {
+	optionValues, err := c.checkVariantOptions(productID, req.OptionValues, 0)
 	if err != nil {
 		return nil, err
 	}
+	req.OptionValues = optionValues
+
+	newVariant := &model.ProductsVariants{
+		ProductID:        productID,
+		SKU:              req.SKU,
+		Title:            &req.Title,
+		OptionValues:     &req.OptionValues,
+		PriceOverride:    &req.PriceOverride,
+		CostPrice:        req.CostPrice,
+		StockQuantity:    req.StockQuantity,
+		IsActive:         req.IsActive,
+		AllowBackorder:   req.AllowBackorder,
+		WeightGrams:      req.WeightGrams,
+		ReorderThreshold: req.ReorderThreshold,
+	}
+	createVariant, priceChange, err := c.VariantRepo.CreateProductVariant(newVariant)
+	if err != nil {
+		return nil, variantWriteError(err)
+	}
+	created := make(map[string]model.ProductChangeLog)
+	for field, val := range variantSnapshot(createVariant) {
+		created[field] = model.ProductChangeLog{Field: field, OldValue: nil, NewValue: val}
+	}
+	c.recordVariantHistory(ctx, productID, &createVariant.ID, created, fmt.Sprintf("Variant %s created", createVariant.SKU))
+	c.recordPriceRangeChange(productID, &createVariant.ID, priceChange)
 	reponseVariant := &model.CreateVariantResponse{
 		Message: "Create successfully",
 		ProVariant: model.AdminVariantResponse{
-			ID:             createVariant.ID,
-			ProductID:      createVariant.ProductID,
-			SKU:            createVariant.SKU,
-			Title:          createVariant.Title,
-			OptionValues:   createVariant.OptionValues,
-			PriceOverride:  createVariant.PriceOverride,
-			CostPrice:      createVariant.CostPrice,
-			StockQuantity:  createVariant.StockQuantity,
-			IsActive:       createVariant.IsActive,
-			AllowBackorder: createVariant.AllowBackorder,
-			CreatedAt:      createVariant.CreatedAt.String(),
+			ID:               createVariant.ID,
+			ProductID:        createVariant.ProductID,
+			SKU:              createVariant.SKU,
+			Title:            createVariant.Title,
+			OptionValues:     createVariant.OptionValues,
+			PriceOverride:    createVariant.PriceOverride,
+			CostPrice:        createVariant.CostPrice,
+			StockQuantity:    createVariant.StockQuantity,
+			IsActive:         createVariant.IsActive,
+			AllowBackorder:   createVariant.AllowBackorder,
+			WeightGrams:      createVariant.WeightGrams,
+			ReorderThreshold: createVariant.ReorderThreshold,
+			CreatedAt:        createVariant.CreatedAt.String(),
 		},
 	}
 	return reponseVariant, nil
 }
 
 // UpdateVariant - Cập nhật thông tin biến thể sản phẩm
-func (c *productVariantController) UpdateVariant(req model.UpdateVariantRequest, variantID int64, productID int64) (*model.UpdateVariantResponse, error) {
+func (c *productVariantController) UpdateVariant(ctx context.Context, req model.UpdateVariantRequest, variantID int64, productID int64) (*model.UpdateVariantResponse, error) {
 	existingVariant, err := c.VariantRepo.GetVariantByID(variantID)
 	if err != nil {
 		return nil, err
@@ -61,22 +253,47 @@ func (c *productVariantController) UpdateVariant(req model.UpdateVariantRequest,
 		return nil, fmt.Errorf("Variant does not belong to this product")
 	}
 
+	optionValues, err := c.checkVariantOptions(productID, req.OptionValues, variantID)
+	if err != nil {
+		return nil, err
+	}
+	req.OptionValues = optionValues
+
 	updatedVariant := &model.ProductsVariants{
-		ID:             variantID,
-		ProductID:      productID,
-		SKU:            req.SKU,
-		Title:          &req.Title,
-		OptionValues:   &req.OptionValues,
-		PriceOverride:  &req.PriceOverride,
-		CostPrice:      &req.CostPrice,
-		StockQuantity:  req.StockQuantity,
-		IsActive:       req.IsActive,
-		AllowBackorder: req.AllowBackorder,
+		ID:               variantID,
+		ProductID:        productID,
+		SKU:              req.SKU,
+		Title:            &req.Title,
+		OptionValues:     &req.OptionValues,
+		PriceOverride:    &req.PriceOverride,
+		CostPrice:        req.CostPrice,
+		StockQuantity:    req.StockQuantity,
+		IsActive:         req.IsActive,
+		AllowBackorder:   req.AllowBackorder,
+		WeightGrams:      req.WeightGrams,
+		ReorderThreshold: req.ReorderThreshold,
 	}
 
-	err = c.VariantRepo.UpdateProductVariant(updatedVariant)
+	priceChange, err := c.VariantRepo.UpdateProductVariant(updatedVariant)
 	if err != nil {
-		return nil, err
+		return nil, variantWriteError(err)
+	}
+	oldValues := variantSnapshot(existingVariant)
+	changes := make(map[string]model.ProductChangeLog)
+	for field, newVal := range variantSnapshot(updatedVariant) {
+		oldJSON, _ := json.Marshal(oldValues[field])
+		newJSON, _ := json.Marshal(newVal)
+		if string(oldJSON) != string(newJSON) {
+			changes[field] = model.ProductChangeLog{Field: field, OldValue: oldValues[field], NewValue: newVal}
+		}
+	}
+	c.recordVariantHistory(ctx, productID, &variantID, changes, fmt.Sprintf("Variant %s updated", updatedVariant.SKU))
+	c.recordPriceRangeChange(productID, &variantID, priceChange)
+	c.notifyIfRestocked(variantID, existingVariant.StockQuantity, updatedVariant.StockQuantity)
+	_, stockChanged := changes["stock_quantity"]
+	_, thresholdChanged := changes["reorder_threshold"]
+	if stockChanged || thresholdChanged {
+		c.checkLowStock()
 	}
 
 	updatedData, err := c.VariantRepo.GetVariantByID(variantID)
@@ -87,23 +304,64 @@ func (c *productVariantController) UpdateVariant(req model.UpdateVariantRequest,
 	return &model.UpdateVariantResponse{
 		Message: "Variant updated successfully",
 		ProVariant: model.AdminVariantResponse{
-			ID:             updatedData.ID,
-			ProductID:      updatedData.ProductID,
-			SKU:            updatedData.SKU,
-			Title:          updatedData.Title,
-			OptionValues:   updatedData.OptionValues,
-			PriceOverride:  updatedData.PriceOverride,
-			CostPrice:      updatedData.CostPrice,
-			StockQuantity:  updatedData.StockQuantity,
-			IsActive:       updatedData.IsActive,
-			AllowBackorder: updatedData.AllowBackorder,
-			CreatedAt:      updatedData.CreatedAt.String(),
+			ID:               updatedData.ID,
+			ProductID:        updatedData.ProductID,
+			SKU:              updatedData.SKU,
+			Title:            updatedData.Title,
+			OptionValues:     updatedData.OptionValues,
+			PriceOverride:    updatedData.PriceOverride,
+			CostPrice:        updatedData.CostPrice,
+			StockQuantity:    updatedData.StockQuantity,
+			IsActive:         updatedData.IsActive,
+			AllowBackorder:   updatedData.AllowBackorder,
+			WeightGrams:      updatedData.WeightGrams,
+			ReorderThreshold: updatedData.ReorderThreshold,
+			CreatedAt:        updatedData.CreatedAt.String(),
 		},
 	}, nil
 }
 
+// AdjustVariantStock - Nhập / xuất kho theo chênh lệch, ghi lịch sử và báo có hàng nếu tồn kho từ 0 lên dương
+func (c *productVariantController) AdjustVariantStock(ctx context.Context, req model.AdjustVariantStockRequest, variantID int64, productID int64) (*model.AdjustVariantStockResponse, error) {
+	existingVariant, err := c.VariantRepo.GetVariantByID(variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existingVariant.ProductID != productID {
+		return nil, fmt.Errorf("Variant does not belong to this product")
+	}
+
+	oldStock, newStock, err := c.VariantRepo.AdjustStock(variantID, req.Delta)
+	if err != nil {
+		if errors.Is(err, productvariant.ErrNegativeStock) {
+			return nil, fmt.Errorf("%w: stock %d cannot go below 0 (delta %d)", ErrInvalidStockAdjust, oldStock, req.Delta)
+		}
+		return nil, err
+	}
+
+	note := fmt.Sprintf("Variant %s stock adjusted by %+d", existingVariant.SKU, req.Delta)
+	if req.Reason != "" {
+		note += ": " + req.Reason
+	}
+	c.recordVariantHistory(ctx, productID, &variantID, map[string]model.ProductChangeLog{
+		"stock_quantity": {Field: "stock_quantity", OldValue: oldStock, NewValue: newStock},
+	}, note)
+	c.notifyIfRestocked(variantID, oldStock, newStock)
+	if newStock < oldStock {
+		c.checkLowStock()
+	}
+
+	return &model.AdjustVariantStockResponse{
+		Message:   "Stock adjusted successfully",
+		VariantID: variantID,
+		OldStock:  oldStock,
+		NewStock:  newStock,
+	}, nil
+}
+
 // DeleteVariant - Xóa biến thể sản phẩm
-func (c *productVariantController) DeleteVariant(variantID int64, productID int64) (*model.DeleteVariantResponse, error) {
+func (c *productVariantController) DeleteVariant(ctx context.Context, variantID int64, productID int64) (*model.DeleteVariantResponse, error) {
 	existingVariant, err := c.VariantRepo.GetVariantByID(variantID)
 	if err != nil {
 		return nil, err
@@ -113,12 +371,216 @@ func (c *productVariantController) DeleteVariant(variantID int64, productID int6
 		return nil, fmt.Errorf("Variant does not belong to this product")
 	}
 
-	err = c.VariantRepo.DeleteProductVariant(variantID)
+	priceChange, err := c.VariantRepo.DeleteProductVariant(productID, variantID)
 	if err != nil {
 		return nil, err
 	}
+	// Biến thể đã bị xóa nên không gắn variant_id vào lịch sử (khóa ngoại)
+	deleted := map[string]model.ProductChangeLog{
+		"variant_id": {Field: "variant_id", OldValue: variantID, NewValue: nil},
+	}
+	for field, val := range variantSnapshot(existingVariant) {
+		deleted[field] = model.ProductChangeLog{Field: field, OldValue: val, NewValue: nil}
+	}
+	c.recordVariantHistory(ctx, productID, nil, deleted, fmt.Sprintf("Variant %s deleted", existingVariant.SKU))
+	c.recordPriceRangeChange(productID, nil, priceChange)
 
 	return &model.DeleteVariantResponse{
 		Message: "Variant deleted successfully",
 	}, nil
 }
+
+// GetProductOptions - Lấy option của sản phẩm
+func (c *productVariantController) GetProductOptions(productID int64) (*model.ProductOptionsResponse, error) {
+	options, err := c.VariantRepo.GetProductOptions(productID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ProductOptionsResponse{
+		Message: "Options retrieved successfully",
+		Options: options,
+	}, nil
+}
+
+// SetProductOptions - Ghi đè option của sản phẩm, các biến thể hiện có phải khớp schema mới
+func (c *productVariantController) SetProductOptions(req model.SetProductOptionsRequest, productID int64) (*model.ProductOptionsResponse, error) {
+	options := make([]model.ProductOption, 0, len(req.Options))
+	names := make(map[string]bool)
+	for i, in := range req.Options {
+		name := strings.TrimSpace(in.Name)
+		if names[name] {
+			return nil, fmt.Errorf("%w: thuộc tính '%s' bị trùng", ErrInvalidOptions, name)
+		}
+		names[name] = true
+
+		seen := make(map[string]bool)
+		values := make([]string, 0, len(in.Values))
+		for _, v := range in.Values {
+			v = strings.TrimSpace(v)
+			if seen[v] {
+				return nil, fmt.Errorf("%w: giá trị '%s' của thuộc tính '%s' bị trùng", ErrInvalidOptions, v, name)
+			}
+			seen[v] = true
+			values = append(values, v)
+		}
+		options = append(options, model.ProductOption{ProductID: productID, Name: name, Values: values, Position: i})
+	}
+
+	variants, err := c.VariantRepo.GetProductVariantByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range variants {
+		raw := ""
+		if v.OptionValues != nil {
+			raw = *v.OptionValues
+		}
+		values, err := utils.ParseOptionValues(raw)
+		if err == nil {
+			err = utils.ValidateOptionValues(values, options)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: biến thể %s không khớp option mới (%v)", ErrInvalidOptions, v.SKU, err)
+		}
+	}
+
+	if err := c.VariantRepo.ReplaceProductOptions(productID, options); err != nil {
+		return nil, err
+	}
+	return c.GetProductOptions(productID)
+}
+
+// GenerateVariantMatrix - Sinh biến thể cho các tổ hợp option chưa có (SKU tự động)
+func (c *productVariantController) GenerateVariantMatrix(ctx context.Context, req model.GenerateVariantMatrixRequest, productID int64) (*model.GenerateVariantMatrixResponse, error) {
+	options, err := c.VariantRepo.GetProductOptions(productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(options) == 0 {
+		return nil, fmt.Errorf("%w: sản phẩm chưa định nghĩa option", ErrInvalidOptions)
+	}
+	// Kiểm tra số tổ hợp trước khi sinh để tránh bùng nổ cartesian
+	maxCombinations := utils.EnvInt("VARIANT_MATRIX_MAX_COMBINATIONS", defaultMatrixMaxCombinations)
+	combinations := 1
+	for _, opt := range options {
+		combinations *= len(opt.Values)
+		if combinations > maxCombinations {
+			return nil, fmt.Errorf("%w: số tổ hợp option vượt quá %d", ErrMatrixTooLarge, maxCombinations)
+		}
+	}
+
+	variants, err := c.VariantRepo.GetProductVariantByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool)
+	for _, v := range variants {
+		if v.OptionValues == nil {
+			continue
+		}
+		if values, err := utils.ParseOptionValues(*v.OptionValues); err == nil {
+			existing[utils.CanonicalOptionValues(values)] = true
+		}
+	}
+
+	prefix := strings.ToUpper(slug.Make(req.SKUPrefix))
+	if prefix == "" {
+		prefix = fmt.Sprintf("P%d", productID)
+	}
+
+	skipped := 0
+	toCreate := []model.ProductsVariants{}
+	for _, combo := range utils.CartesianOptions(options) {
+		canonical := utils.CanonicalOptionValues(combo)
+		if existing[canonical] {
+			skipped++
+			continue
+		}
+
+		skuParts := []string{prefix}
+		for _, opt := range options {
+			skuParts = append(skuParts, strings.ToUpper(slug.Make(combo[opt.Name])))
+		}
+		title := utils.OptionTitle(combo, options)
+		// Không truyền giá / giá vốn thì để NULL (giá sản phẩm / chưa có giá vốn), dùng bản sao riêng cho từng biến thể
+		var price, cost *float64
+		if req.PriceOverride != nil {
+			v := *req.PriceOverride
+			price = &v
+		}
+		if req.CostPrice != nil {
+			v := *req.CostPrice
+			cost = &v
+		}
+
+		toCreate = append(toCreate, model.ProductsVariants{
+			ProductID:        productID,
+			SKU:              strings.Join(skuParts, "-"),
+			Title:            &title,
+			OptionValues:     &canonical,
+			PriceOverride:    price,
+			CostPrice:        cost,
+			StockQuantity:    req.StockQuantity,
+			IsActive:         req.IsActive,
+			AllowBackorder:   req.AllowBackorder,
+			WeightGrams:      req.WeightGrams,
+			ReorderThreshold: req.ReorderThreshold,
+		})
+	}
+
+	// SKU sinh ra trùng nhau (slug của 2 giá trị giống nhau) hoặc trùng SKU đã có thì báo lỗi trước khi ghi
+	skus := make([]string, 0, len(toCreate))
+	seenSKU := make(map[string]bool, len(toCreate))
+	for _, v := range toCreate {
+		if seenSKU[v.SKU] {
+			return nil, fmt.Errorf("%w: SKU %s được sinh cho nhiều tổ hợp", ErrVariantConflict, v.SKU)
+		}
+		seenSKU[v.SKU] = true
+		skus = append(skus, v.SKU)
+	}
+	taken, err := c.VariantRepo.GetExistingSKUs(skus)
+	if err != nil {
+		return nil, err
+	}
+	if len(taken) > 0 {
+		return nil, fmt.Errorf("%w: SKU đã tồn tại: %s", ErrVariantConflict, strings.Join(taken, ", "))
+	}
+
+	created := []model.AdminVariantResponse{}
+	if len(toCreate) > 0 {
+		createdVariants, priceChange, err := c.VariantRepo.CreateProductVariants(productID, toCreate)
+		if err != nil {
+			return nil, variantWriteError(err)
+		}
+		for _, v := range createdVariants {
+			snapshot := make(map[string]model.ProductChangeLog)
+			for field, val := range variantSnapshot(&v) {
+				snapshot[field] = model.ProductChangeLog{Field: field, OldValue: nil, NewValue: val}
+			}
+			c.recordVariantHistory(ctx, productID, &v.ID, snapshot, fmt.Sprintf("Variant %s generated from option matrix", v.SKU))
+
+			created = append(created, model.AdminVariantResponse{
+				ID:               v.ID,
+				ProductID:        v.ProductID,
+				SKU:              v.SKU,
+				Title:            v.Title,
+				OptionValues:     v.OptionValues,
+				PriceOverride:    v.PriceOverride,
+				CostPrice:        v.CostPrice,
+				StockQuantity:    v.StockQuantity,
+				IsActive:         v.IsActive,
+				AllowBackorder:   v.AllowBackorder,
+				WeightGrams:      v.WeightGrams,
+				ReorderThreshold: v.ReorderThreshold,
+				CreatedAt:        v.CreatedAt.String(),
+			})
+		}
+		c.recordPriceRangeChange(productID, nil, priceChange)
+	}
+
+	return &model.GenerateVariantMatrixResponse{
+		Message: "Variant matrix generated successfully",
+		Created: created,
+		Skipped: skipped,
+	}, nil
+}
@@ -1,9 +1,14 @@
 package producthistory
 
 import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"golang/internal/model"
 	"golang/internal/repository/producthistory"
+	"golang/internal/utils"
 	"math"
+	"sort"
 )
 
 type productHistoryController struct {
@@ -85,3 +90,67 @@ func (ph *productHistoryController) GetAllProductsHistory(page, limit int) (*mod
 	}, nil
 
 }
+
+// GetProductHistoryDiffController - Diff từng field của một bản ghi lịch sử
+func (ph *productHistoryController) GetProductHistoryDiffController(productID, historyID int64) (*model.ProductHistoryDiffResponse, error) {
+	h, err := ph.HistoryRepo.GetProductHistoryByID(historyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("History not found")
+		}
+		return nil, err
+	}
+	if h.ProductID != productID {
+		return nil, fmt.Errorf("History not found")
+	}
+
+	var changes map[string]model.ProductChangeLog
+	if err := json.Unmarshal(h.Changes, &changes); err != nil {
+		return nil, fmt.Errorf("cannot parse history changes: %w", err)
+	}
+
+	keys := make([]string, 0, len(changes))
+	for k := range changes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]model.HistoryFieldDiff, 0, len(keys))
+	for _, k := range keys {
+		c := changes[k]
+		diff := model.HistoryFieldDiff{Field: k, OldValue: c.OldValue, NewValue: c.NewValue}
+
+		// Field dạng tập hợp: tính phần thêm / bớt
+		if k == "categories" {
+			oldSet := make(map[int64]bool)
+			for _, id := range utils.ToInt64Slice(c.OldValue) {
+				oldSet[id] = true
+			}
+			newSet := make(map[int64]bool)
+			for _, id := range utils.ToInt64Slice(c.NewValue) {
+				newSet[id] = true
+				if !oldSet[id] {
+					diff.Added = append(diff.Added, id)
+				}
+			}
+			for id := range oldSet {
+				if !newSet[id] {
+					diff.Removed = append(diff.Removed, id)
+				}
+			}
+			sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i] < diff.Removed[j] })
+		}
+		fields = append(fields, diff)
+	}
+
+	return &model.ProductHistoryDiffResponse{
+		Message:   "History diff retrieved successfully",
+		HistoryID: h.ID,
+		ProductID: h.ProductID,
+		VariantID: h.VariantID,
+		AdminID:   h.AdminID,
+		ChangedAt: h.ChangedAt,
+		Note:      h.Note,
+		Fields:    fields,
+	}, nil
+}
@@ -5,4 +5,5 @@ import "golang/internal/model"
 type ProductHistoryController interface {
 	GetProductHistoryByProductIDController(productID []int64) ([]model.GetProductHistoryResponse, error)
 	GetAllProductsHistory(page,limit int) (*model.GetAllProductsHistoryReponse, error)
+	GetProductHistoryDiffController(productID, historyID int64) (*model.ProductHistoryDiffResponse, error)
 }
@@ -2,10 +2,31 @@ package productreviews
 
 import (
 	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"golang/internal/logger"
 	"golang/internal/model"
 	order "golang/internal/repository/order"
 	"golang/internal/repository/productreview"
+	"golang/internal/utils"
+	"os"
+	"strconv"
+)
+
+// Lỗi nghiệp vụ để handler map sang HTTP status
+var (
+	ErrReviewNotFound   = errors.New("review not found")
+	ErrReviewForbidden  = errors.New("you can only modify your own review")
+	ErrReviewExists     = errors.New("you have already reviewed this product")
+	ErrPurchaseRequired = errors.New("bạn phải mua sản phẩm này và đơn hàng đã hoàn thành mới được đánh giá")
+	ErrProductNotFound  = errors.New("product not found")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrAlreadyVoted     = errors.New("you have already voted on this review")
+	ErrVoteNotFound     = errors.New("you have not marked this review as helpful")
+	ErrVoteOwnReview    = errors.New("you cannot vote on your own review")
 )
 
 type productReviewsController struct {
@@ -18,85 +39,376 @@ func NewProductReviewsController(repo productreview.ProductReviewRepository, ord
 	return &productReviewsController{reviewRepo: repo, orderRepo: orderRepo}
 }
 
+// requirePurchase - REVIEW_REQUIRE_PURCHASE: chỉ người đã mua mới được đánh giá (mặc định true như trước;
+// đặt false để ai cũng được đánh giá, người đã mua có badge verified)
+func requirePurchase() bool {
+	if v, err := strconv.ParseBool(os.Getenv("REVIEW_REQUIRE_PURCHASE")); err == nil {
+		return v
+	}
+	return true
+}
+
+// reportThreshold - REVIEW_REPORT_THRESHOLD: số báo cáo để review bị đưa về hàng đợi kiểm duyệt (mặc định 3)
+func reportThreshold() int {
+	if v, err := strconv.Atoi(os.Getenv("REVIEW_REPORT_THRESHOLD")); err == nil && v > 0 {
+		return v
+	}
+	return 3
+}
+
+func toReviewResponse(r model.ProductReview) model.ProductReviewResponse {
+	resp := model.ProductReviewResponse{
+		ID:                 r.ID,
+		ProductID:          r.ProductID,
+		Body:               r.Body,
+		Rating:             r.Rating,
+		UserID:             r.UserID,
+		Status:             r.Status,
+		IsVerifiedPurchase: r.IsVerifiedPurchase,
+		ModerationNote:     r.ModerationNote,
+		HelpfulCount:       r.HelpfulCount,
+		ReportCount:        r.ReportCount,
+		CreatedAt:          r.CreatedAt,
+		UpdatedAt:          r.UpdatedAt,
+	}
+	if r.Username != nil {
+		resp.Reviewer = &model.UserPublicResponse{ID: r.UserID, Username: *r.Username}
+	}
+	return resp
+}
+
+// encodeReviewCursor / decodeReviewCursor - Cursor là JSON của khóa sắp xếp, mã hóa base64 (URL-safe)
+func encodeReviewCursor(sort string, r model.ProductReview) string {
+	data, _ := json.Marshal(model.ReviewCursor{
+		Sort:         sort,
+		Rating:       r.Rating,
+		HelpfulCount: r.HelpfulCount,
+		CreatedAt:    r.CreatedAt,
+		ID:           r.ID,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeReviewCursor(sort, raw string) (*model.ReviewCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c model.ReviewCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	// Cursor phải đi cùng kiểu sắp xếp đã tạo ra nó
+	if c.Sort != sort {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
 func (c *productReviewsController) CreateReview(ctx context.Context, req model.CreateProductReviewRequest, productID int64, userID int64) (*model.CreateProductReviewResponse, error) {
-	// ===> LOGIC MỚI: KIỂM TRA ĐIỀU KIỆN MUA HÀNG <===
-	hasPurchased, err := c.orderRepo.HasUserPurchasedProduct(ctx, userID, productID)
+	// Mỗi user chỉ được đánh giá 1 lần cho 1 sản phẩm
+	if _, err := c.reviewRepo.GetReviewByUserAndProduct(userID, productID); err == nil {
+		return nil, ErrReviewExists
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// Liên kết với dòng đơn hàng đã hoàn thành (verified purchase)
+	orderItemID, err := c.orderRepo.GetCompletedOrderItemForProduct(ctx, userID, productID)
 	if err != nil {
 		return nil, errors.New("lỗi hệ thống khi kiểm tra lịch sử mua hàng")
 	}
 
-	if !hasPurchased {
-		return nil, errors.New("bạn phải mua sản phẩm này và đơn hàng đã hoàn thành mới được đánh giá")
+	if orderItemID == nil && requirePurchase() {
+		return nil, ErrPurchaseRequired
 	}
 	toCreate := &model.ProductReview{
-		ProductID: productID,
-		Body:      req.Body,
-		Rating:    req.Rating,
-		UserID:    userID,
+		ProductID:          productID,
+		Body:               req.Body,
+		Rating:             req.Rating,
+		UserID:             userID,
+		Status:             model.ReviewStatusPending,
+		OrderItemID:        orderItemID,
+		IsVerifiedPurchase: orderItemID != nil,
 	}
 
 	created, err := c.reviewRepo.CreateProductReview(toCreate)
 	if err != nil {
+		// 2 request đồng thời cùng vượt qua bước kiểm tra trên -> khóa uq_review_user_product chặn lại
+		if utils.IsDuplicateKey(err) {
+			return nil, ErrReviewExists
+		}
 		return nil, err
 	}
 
-	resp := model.ProductReviewResponse{
-		ID:        created.ID,
-		ProductID: created.ProductID,
-		Body:      created.Body,
-		Rating:    created.Rating,
-		UserID:    created.UserID,
-		CreatedAt: created.CreatedAt,
-		UpdatedAt: created.UpdatedAt,
-	}
-
 	return &model.CreateProductReviewResponse{
-		Message: "Review created successfully",
-		Review:  resp,
+		Message: "Review submitted and waiting for moderation",
+		Review:  toReviewResponse(*created),
 	}, nil
 }
 
-func (c *productReviewsController) ListReviews(productID int64) (*model.ProductReviewListResponse, error) {
-	reviews, err := c.reviewRepo.GetProductReviewsByProductID(productID)
+func (c *productReviewsController) ListReviews(productID int64, query model.ReviewListQuery) (*model.ProductReviewListResponse, error) {
+	// Rating đã được tổng hợp sẵn khi review thay đổi, không cần tính lại
+	summary, err := c.reviewRepo.GetRatingSummary(productID)
+	if err == sql.ErrNoRows {
+		return nil, ErrProductNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	avg, err := c.reviewRepo.GetAverageRatingByProductID(productID)
-	if err != nil {
-		return nil, err
+	if query.Sort == "" {
+		query.Sort = model.ReviewSortNewest
+	}
+	if query.Cursor != "" {
+		if query.After, err = decodeReviewCursor(query.Sort, query.Cursor); err != nil {
+			return nil, err
+		}
 	}
 
-	count, err := c.reviewRepo.GetCountRatingByProductID(productID)
+	reviews, err := c.reviewRepo.ListApprovedReviews(productID, query)
 	if err != nil {
 		return nil, err
 	}
 
+	// Repo trả về limit+1 dòng: dòng thừa cho biết còn trang sau
+	hasMore := len(reviews) > query.Limit
+	if hasMore {
+		reviews = reviews[:query.Limit]
+	}
+
 	respReviews := make([]model.ProductReviewResponse, 0, len(reviews))
 	for _, r := range reviews {
-		respReviews = append(respReviews, model.ProductReviewResponse{
-			ID:        r.ID,
-			ProductID: r.ProductID,
-			Body:      r.Body,
-			Rating:    r.Rating,
-			UserID:    r.UserID,
-			CreatedAt: r.CreatedAt,
-			UpdatedAt: r.UpdatedAt,
-		})
-	}
-
-	return &model.ProductReviewListResponse{
+		resp := toReviewResponse(r)
+		resp.Status = ""
+		resp.ModerationNote = nil
+		resp.ReportCount = 0
+		respReviews = append(respReviews, resp)
+	}
+
+	res := &model.ProductReviewListResponse{
 		Message:     "Reviews fetched successfully",
 		ProductID:   productID,
-		AvgRating:   avg,
-		RatingCount: count,
+		AvgRating:   summary.AvgRating,
+		RatingCount: summary.RatingCount,
+		Histogram:   summary.Histogram,
 		Reviews:     respReviews,
-	}, nil
+		HasMore:     hasMore,
+	}
+	if hasMore {
+		res.NextCursor = encodeReviewCursor(query.Sort, reviews[len(reviews)-1])
+	}
+	return res, nil
 }
 
 func (c *productReviewsController) DeleteReview(reviewID int64) (*model.DeleteProductReviewResponse, error) {
-	if err := c.reviewRepo.DeleteReviewByID(reviewID); err != nil {
+	err := c.reviewRepo.DeleteReviewByID(reviewID)
+	if err == sql.ErrNoRows {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
 		return nil, err
 	}
 	return &model.DeleteProductReviewResponse{Message: "Review deleted successfully"}, nil
 }
+
+// getOwnReview - Lấy review và kiểm tra quyền sở hữu
+func (c *productReviewsController) getOwnReview(reviewID, userID int64) (*model.ProductReview, error) {
+	review, err := c.reviewRepo.GetReviewByID(reviewID)
+	if err == sql.ErrNoRows {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if review.UserID != userID {
+		return nil, ErrReviewForbidden
+	}
+	return review, nil
+}
+
+// UpdateOwnReview - Tác giả sửa review của mình, review quay lại hàng đợi kiểm duyệt
+func (c *productReviewsController) UpdateOwnReview(reviewID int64, userID int64, req model.UpdateProductReviewRequest) (*model.CreateProductReviewResponse, error) {
+	review, err := c.getOwnReview(reviewID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Body != nil {
+		review.Body = req.Body
+	}
+	if req.Rating != nil {
+		review.Rating = *req.Rating
+	}
+
+	err = c.reviewRepo.UpdateReviewContent(review.ID, review.Body, review.Rating)
+	if err == sql.ErrNoRows {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := c.reviewRepo.GetReviewByID(review.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CreateProductReviewResponse{
+		Message: "Review updated and waiting for moderation",
+		Review:  toReviewResponse(*updated),
+	}, nil
+}
+
+// DeleteOwnReview - Tác giả xóa review của mình
+func (c *productReviewsController) DeleteOwnReview(reviewID int64, userID int64) (*model.DeleteProductReviewResponse, error) {
+	if _, err := c.getOwnReview(reviewID, userID); err != nil {
+		return nil, err
+	}
+	return c.DeleteReview(reviewID)
+}
+
+// ModerateReview - Admin duyệt / từ chối review
+func (c *productReviewsController) ModerateReview(ctx context.Context, reviewID int64, req model.ModerateReviewRequest) (*model.CreateProductReviewResponse, error) {
+	var adminID *int64
+	if id, ok := ctx.Value("userID").(int64); ok {
+		adminID = &id
+	}
+
+	var note *string
+	if req.Note != "" {
+		note = &req.Note
+	}
+
+	err := c.reviewRepo.ModerateReview(reviewID, req.Status, adminID, note)
+	if err == sql.ErrNoRows {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	logger.InfoLogger.Printf("Review %d moderated: %s", reviewID, req.Status)
+
+	review, err := c.reviewRepo.GetReviewByID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CreateProductReviewResponse{
+		Message: "Review moderated successfully",
+		Review:  toReviewResponse(*review),
+	}, nil
+}
+
+// ListModerationQueue - Danh sách review theo trạng thái (mặc định: pending)
+func (c *productReviewsController) ListModerationQueue(status string, page, limit int) (*model.ReviewModerationQueueResponse, error) {
+	reviews, total, err := c.reviewRepo.GetReviewsByStatus(status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	respReviews := make([]model.ProductReviewResponse, 0, len(reviews))
+	for _, r := range reviews {
+		respReviews = append(respReviews, toReviewResponse(r))
+	}
+
+	return &model.ReviewModerationQueueResponse{
+		Message: "Moderation queue fetched successfully",
+		Status:  status,
+		Page:    page,
+		Limit:   limit,
+		Total:   total,
+		Reviews: respReviews,
+	}, nil
+}
+
+// RecomputeRatings - Sửa các sản phẩm có rating tổng hợp lệch so với review đã duyệt
+func (c *productReviewsController) RecomputeRatings(ctx context.Context) (*model.AdminRecomputeRatingsResponse, error) {
+	drifts, err := c.reviewRepo.GetRatingDrifts()
+	if err != nil {
+		return nil, err
+	}
+
+	fixed := []model.RatingDrift{}
+	for _, d := range drifts {
+		if err := c.reviewRepo.RefreshProductRating(d.ProductID); err != nil {
+			logger.ErrorLogger.Printf("RecomputeRatings: product %d failed: %v", d.ProductID, err)
+			continue
+		}
+		logger.WarnLogger.Printf("RecomputeRatings: product %d drifted (avg %.2f -> %.2f, count %d -> %d)",
+			d.ProductID, d.OldAvgRating, d.NewAvgRating, d.OldRatingCount, d.NewRatingCount)
+		fixed = append(fixed, d)
+	}
+
+	return &model.AdminRecomputeRatingsResponse{
+		Message: fmt.Sprintf("Recomputed rating for %d products", len(fixed)),
+		Fixed:   fixed,
+	}, nil
+}
+
+// RunRatingRecompute - Job: định kỳ sửa lệch rating
+func (c *productReviewsController) RunRatingRecompute(ctx context.Context) error {
+	_, err := c.RecomputeRatings(ctx)
+	return err
+}
+
+// getVotableReview - Chỉ review đã duyệt mới được vote / báo cáo, và không phải của chính mình
+func (c *productReviewsController) getVotableReview(reviewID, userID int64) (*model.ProductReview, error) {
+	review, err := c.reviewRepo.GetReviewByID(reviewID)
+	if err == sql.ErrNoRows || (err == nil && review.Status != model.ReviewStatusApproved) {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if review.UserID == userID {
+		return nil, ErrVoteOwnReview
+	}
+	return review, nil
+}
+
+// MarkHelpful - User đánh dấu review hữu ích (mỗi user 1 lần)
+func (c *productReviewsController) MarkHelpful(reviewID int64, userID int64) (*model.ReviewVoteResponse, error) {
+	if _, err := c.getVotableReview(reviewID, userID); err != nil {
+		return nil, err
+	}
+
+	count, err := c.reviewRepo.AddHelpfulVote(reviewID, userID)
+	if errors.Is(err, productreview.ErrAlreadyVoted) {
+		return nil, ErrAlreadyVoted
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model.ReviewVoteResponse{Message: "Marked as helpful", ReviewID: reviewID, HelpfulCount: count}, nil
+}
+
+// UnmarkHelpful - User bỏ đánh dấu hữu ích
+func (c *productReviewsController) UnmarkHelpful(reviewID int64, userID int64) (*model.ReviewVoteResponse, error) {
+	count, err := c.reviewRepo.RemoveHelpfulVote(reviewID, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrVoteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model.ReviewVoteResponse{Message: "Helpful vote removed", ReviewID: reviewID, HelpfulCount: count}, nil
+}
+
+// ReportReview - User báo cáo review, đủ ngưỡng thì review quay lại hàng đợi kiểm duyệt
+func (c *productReviewsController) ReportReview(reviewID int64, userID int64, req model.ReportReviewRequest) (*model.ReviewVoteResponse, error) {
+	review, err := c.getVotableReview(reviewID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	flagged, err := c.reviewRepo.AddReport(reviewID, userID, req.Reason, reportThreshold())
+	if errors.Is(err, productreview.ErrAlreadyVoted) {
+		return nil, ErrAlreadyVoted
+	}
+	if err != nil {
+		return nil, err
+	}
+	if flagged {
+		logger.WarnLogger.Printf("Review %d reached %d reports, moved back to moderation queue", reviewID, reportThreshold())
+	}
+	return &model.ReviewVoteResponse{Message: "Review reported", ReviewID: reviewID, HelpfulCount: review.HelpfulCount}, nil
+}
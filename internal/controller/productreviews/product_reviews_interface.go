@@ -7,6 +7,23 @@ import (
 
 type ProductReviewsController interface {
 	CreateReview(ctx context.Context, req model.CreateProductReviewRequest, productID int64, userID int64) (*model.CreateProductReviewResponse, error)
-	ListReviews(productID int64) (*model.ProductReviewListResponse, error)
+	ListReviews(productID int64, query model.ReviewListQuery) (*model.ProductReviewListResponse, error)
 	DeleteReview(reviewID int64) (*model.DeleteProductReviewResponse, error)
+
+	// Tác giả sửa / xóa review của mình
+	UpdateOwnReview(reviewID int64, userID int64, req model.UpdateProductReviewRequest) (*model.CreateProductReviewResponse, error)
+	DeleteOwnReview(reviewID int64, userID int64) (*model.DeleteProductReviewResponse, error)
+
+	// User vote hữu ích / báo cáo review của người khác
+	MarkHelpful(reviewID int64, userID int64) (*model.ReviewVoteResponse, error)
+	UnmarkHelpful(reviewID int64, userID int64) (*model.ReviewVoteResponse, error)
+	ReportReview(reviewID int64, userID int64, req model.ReportReviewRequest) (*model.ReviewVoteResponse, error)
+
+	// Admin kiểm duyệt
+	ModerateReview(ctx context.Context, reviewID int64, req model.ModerateReviewRequest) (*model.CreateProductReviewResponse, error)
+	ListModerationQueue(status string, page, limit int) (*model.ReviewModerationQueueResponse, error)
+
+	// Sửa lệch avg_rating / rating_count / histogram (Admin + Cron)
+	RecomputeRatings(ctx context.Context) (*model.AdminRecomputeRatingsResponse, error)
+	RunRatingRecompute(ctx context.Context) error
 }
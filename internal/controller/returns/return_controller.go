@@ -0,0 +1,486 @@
+package returns
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang/internal/logger"
+	"golang/internal/model"
+	orderRepo "golang/internal/repository/order"
+	repository "golang/internal/repository/returns"
+	"golang/internal/storage"
+	"golang/internal/utils"
+)
+
+// Giá trị mặc định, ghi đè bằng biến môi trường RETURN_*
+const (
+	defaultWindowDays = 7 // Số ngày được yêu cầu trả hàng kể từ khi đơn hoàn thành
+	defaultMaxPhotos  = 5 // Số ảnh tối đa mỗi yêu cầu
+	defaultMaxPhotoMB = 5 // Dung lượng tối đa mỗi ảnh
+)
+
+var (
+	ErrOrderNotFound       = errors.New("không tìm thấy đơn hàng")
+	ErrOrderForbidden      = errors.New("bạn không có quyền trả hàng cho đơn hàng này")
+	ErrOrderNotCompleted   = errors.New("chỉ được trả hàng với đơn hàng đã giao thành công")
+	ErrReturnWindowExpired = errors.New("đã quá thời hạn yêu cầu trả hàng")
+	ErrReturnNotFound      = errors.New("không tìm thấy yêu cầu trả hàng")
+	ErrReturnForbidden     = errors.New("bạn không có quyền xem yêu cầu trả hàng này")
+	ErrPhotoNotFound       = errors.New("không tìm thấy ảnh")
+	ErrTooManyPhotos       = errors.New("vượt quá số ảnh cho phép")
+	ErrPhotoTooLarge       = errors.New("ảnh vượt quá dung lượng cho phép")
+	ErrPhotoType           = errors.New("chỉ hỗ trợ ảnh jpg, png, gif, webp")
+	ErrOverrideNoteMissing = errors.New("hoàn vượt giá trị yêu cầu trả hàng bắt buộc nhập ghi chú")
+
+	// Lỗi nghiệp vụ kiểm tra trong transaction của repository
+	ErrItemNotInOrder    = repository.ErrItemNotInOrder
+	ErrQuantityExceeded  = repository.ErrQuantityExceeded
+	ErrInvalidTransition = repository.ErrInvalidTransition
+	ErrOrderNotPaid      = repository.ErrOrderNotPaid
+	ErrRefundExceeded    = repository.ErrRefundExceeded
+	ErrRefundAboveReturn = repository.ErrRefundAboveReturn
+)
+
+// StockAdjuster: Cộng lại tồn kho khi nhận hàng trả về, ghi lịch sử biến thể (module product cài đặt)
+type StockAdjuster interface {
+	AdjustVariantStock(ctx context.Context, req model.AdjustVariantStockRequest, variantID int64, productID int64) (*model.AdjustVariantStockResponse, error)
+}
+
+// StatsRecomputer: Tính lại thống kê các ngày đã chốt của đơn (module stats cài đặt)
+type StatsRecomputer interface {
+	RecomputeOrderDays(ctx context.Context, order *model.Order)
+}
+
+type returnController struct {
+	ReturnRepo repository.ReturnRepository
+	OrderRepo  orderRepo.IOrderRepository
+	Storage    storage.Storage
+	Stock      StockAdjuster
+	Stats      StatsRecomputer
+
+	windowDays    int
+	maxPhotos     int
+	maxPhotoBytes int64
+}
+
+func NewReturnController(
+	returnRepository repository.ReturnRepository,
+	orderRepository orderRepo.IOrderRepository,
+	fileStorage storage.Storage,
+	stock StockAdjuster,
+	stats StatsRecomputer,
+) ReturnController {
+	return &returnController{
+		ReturnRepo:    returnRepository,
+		OrderRepo:     orderRepository,
+		Storage:       fileStorage,
+		Stock:         stock,
+		Stats:         stats,
+		windowDays:    utils.EnvInt("RETURN_WINDOW_DAYS", defaultWindowDays),
+		maxPhotos:     utils.EnvInt("RETURN_MAX_PHOTOS", defaultMaxPhotos),
+		maxPhotoBytes: int64(utils.EnvInt("RETURN_MAX_PHOTO_MB", defaultMaxPhotoMB)) << 20,
+	}
+}
+
+// Toàn bộ ảnh + 1MB cho lý do, danh sách sản phẩm và phần đầu multipart
+func (c *returnController) MaxRequestBytes() int64 {
+	return int64(c.maxPhotos)*c.maxPhotoBytes + 1<<20
+}
+
+func (c *returnController) getReturn(ctx context.Context, returnID int64) (*model.ReturnRequest, error) {
+	ret, err := c.ReturnRepo.GetReturnByID(ctx, returnID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReturnNotFound
+		}
+		return nil, err
+	}
+	return ret, nil
+}
+
+// getMyReturn: Yêu cầu trả hàng phải thuộc về user
+func (c *returnController) getMyReturn(ctx context.Context, userID int64, returnID int64) (*model.ReturnRequest, error) {
+	ret, err := c.getReturn(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+	if ret.UserID != userID {
+		logger.WarnLogger.Printf("User %d tried to access return %d", userID, returnID)
+		return nil, ErrReturnForbidden
+	}
+	return ret, nil
+}
+
+// toResponse: staff = false thì ẩn người đổi trạng thái trong lịch sử
+func toResponse(ret *model.ReturnRequest, history []model.ReturnStatusHistory, staff bool) *model.ReturnResponse {
+	res := &model.ReturnResponse{
+		ID:           ret.ID,
+		ReturnNumber: ret.ReturnNumber,
+		OrderID:      ret.OrderID,
+		OrderNumber:  ret.OrderNumber,
+		UserID:       ret.UserID,
+		Status:       ret.Status,
+		Reason:       ret.Reason,
+		RefundAmount: utils.FormatVND(ret.RefundAmount),
+		Restocked:    ret.Restocked,
+		RequestedAt:  ret.RequestedAt,
+		ApprovedAt:   ret.ApprovedAt,
+		RejectedAt:   ret.RejectedAt,
+		ReceivedAt:   ret.ReceivedAt,
+		RefundedAt:   ret.RefundedAt,
+		CancelledAt:  ret.CancelledAt,
+		UpdatedAt:    ret.UpdatedAt,
+	}
+	if ret.RefundedAmount != nil {
+		res.RefundedAmount = utils.FormatVND(*ret.RefundedAmount)
+	}
+	for _, i := range ret.Items {
+		res.Items = append(res.Items, model.ReturnItemResponse{
+			ID:          i.ID,
+			OrderItemID: i.OrderItemID,
+			ProductID:   i.ProductID,
+			VariantID:   i.VariantID,
+			SKU:         i.SKU,
+			Title:       i.Title,
+			Quantity:    i.Quantity,
+			UnitPrice:   utils.FormatVND(i.UnitPrice),
+			LineAmount:  utils.FormatVND(i.LineAmount),
+		})
+	}
+	for _, p := range ret.Photos {
+		res.Photos = append(res.Photos, model.ReturnPhotoResponse{
+			ID: p.ID, FileName: p.FileName, ContentType: p.ContentType, SizeBytes: p.SizeBytes,
+		})
+	}
+	for _, h := range history {
+		item := model.ReturnStatusHistoryResponse{ID: h.ID, ToStatus: h.ToStatus, Note: h.Note, CreatedAt: h.CreatedAt}
+		if h.FromStatus != nil {
+			item.FromStatus = *h.FromStatus
+		}
+		if staff {
+			item.ChangedBy = h.ChangedBy
+		}
+		res.History = append(res.History, item)
+	}
+	return res
+}
+
+// detail: Chi tiết yêu cầu kèm lịch sử trạng thái
+func (c *returnController) detail(ctx context.Context, ret *model.ReturnRequest, staff bool) (*model.ReturnResponse, error) {
+	history, err := c.ReturnRepo.GetStatusHistory(ctx, ret.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(ret, history, staff), nil
+}
+
+func (c *returnController) list(ctx context.Context, filter model.ReturnFilter) (*model.ReturnListResponse, error) {
+	returns, total, err := c.ReturnRepo.GetReturns(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	res := &model.ReturnListResponse{Returns: []model.ReturnResponse{}, Total: total}
+	for i := range returns {
+		res.Returns = append(res.Returns, *toResponse(&returns[i], nil, false))
+	}
+	return res, nil
+}
+
+// savePhotos: Kiểm tra định dạng / dung lượng rồi lưu vào Storage. Lỗi giữa chừng thì xóa các ảnh đã lưu
+func (c *returnController) savePhotos(ctx context.Context, orderID int64, files []model.AttachmentUpload) ([]model.ReturnPhoto, error) {
+	if len(files) > c.maxPhotos {
+		return nil, fmt.Errorf("%w (tối đa %d ảnh)", ErrTooManyPhotos, c.maxPhotos)
+	}
+
+	saved := make([]model.ReturnPhoto, 0, len(files))
+	for _, f := range files {
+		if f.Size > c.maxPhotoBytes {
+			c.deletePhotos(ctx, saved)
+			return nil, fmt.Errorf("%w (tối đa %d MB)", ErrPhotoTooLarge, c.maxPhotoBytes>>20)
+		}
+		up, err := storage.SaveUpload(ctx, c.Storage, fmt.Sprintf("returns/%d", orderID), f.FileName, f.Content, c.maxPhotoBytes, storage.ImageTypes)
+		if err != nil {
+			c.deletePhotos(ctx, saved)
+			switch {
+			case errors.Is(err, storage.ErrFileTooLarge):
+				return nil, fmt.Errorf("%w (tối đa %d MB)", ErrPhotoTooLarge, c.maxPhotoBytes>>20)
+			case errors.Is(err, storage.ErrFileType):
+				return nil, ErrPhotoType
+			}
+			logger.ErrorLogger.Printf("Save return photo for OrderID %d failed: %v", orderID, err)
+			return nil, err
+		}
+		saved = append(saved, model.ReturnPhoto{
+			FileName:    up.FileName,
+			ContentType: up.ContentType,
+			SizeBytes:   up.SizeBytes,
+			StorageKey:  up.Key,
+		})
+	}
+	return saved, nil
+}
+
+func (c *returnController) deletePhotos(ctx context.Context, photos []model.ReturnPhoto) {
+	for _, p := range photos {
+		if err := c.Storage.Delete(context.WithoutCancel(ctx), p.StorageKey); err != nil {
+			logger.WarnLogger.Printf("Delete return photo %s failed: %v", p.StorageKey, err)
+		}
+	}
+}
+
+func (c *returnController) openPhoto(ctx context.Context, returnID int64, photoID int64) (*model.ReturnPhoto, io.ReadCloser, error) {
+	photo, err := c.ReturnRepo.GetPhoto(ctx, returnID, photoID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrPhotoNotFound
+		}
+		return nil, nil, err
+	}
+	file, err := c.Storage.Open(ctx, photo.StorageKey)
+	if err != nil {
+		logger.ErrorLogger.Printf("Open return photo %s failed: %v", photo.StorageKey, err)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrPhotoNotFound
+		}
+		return nil, nil, err
+	}
+	return photo, file, nil
+}
+
+// transition: Đổi trạng thái rồi trả về chi tiết mới nhất
+func (c *returnController) transition(ctx context.Context, adminID int64, returnID int64, from string, to string, note string) (*model.ReturnResponse, error) {
+	if _, err := c.getReturn(ctx, returnID); err != nil {
+		return nil, err
+	}
+	if err := c.ReturnRepo.UpdateStatus(ctx, returnID, from, to, &adminID, strings.TrimSpace(note)); err != nil {
+		return nil, err
+	}
+	logger.InfoLogger.Printf("Return %d: %s -> %s by UserID %d", returnID, from, to, adminID)
+	return c.GetReturn(ctx, returnID)
+}
+
+// restock: Cộng lại tồn kho các biến thể đã nhận về. Tồn kho nằm ở module product (transaction riêng) nên
+// 1 biến thể lỗi thì hoàn tác các biến thể đã cộng trước đó, trả lỗi để không đánh dấu restocked
+func (c *returnController) restock(ctx context.Context, ret *model.ReturnRequest) error {
+	var done []model.ReturnItem
+	for _, i := range ret.Items {
+		if i.VariantID == nil {
+			continue
+		}
+		_, err := c.Stock.AdjustVariantStock(ctx, model.AdjustVariantStockRequest{
+			Delta:  i.Quantity,
+			Reason: "Nhận hàng trả về " + ret.ReturnNumber,
+		}, *i.VariantID, i.ProductID)
+		if err != nil {
+			logger.ErrorLogger.Printf("Restock VariantID %d for return %s failed: %v", *i.VariantID, ret.ReturnNumber, err)
+			for _, d := range done {
+				_, rbErr := c.Stock.AdjustVariantStock(ctx, model.AdjustVariantStockRequest{
+					Delta:  -d.Quantity,
+					Reason: "Hoàn tác nhận hàng trả về " + ret.ReturnNumber,
+				}, *d.VariantID, d.ProductID)
+				if rbErr != nil {
+					logger.ErrorLogger.Printf("Undo restock VariantID %d for return %s failed: %v", *d.VariantID, ret.ReturnNumber, rbErr)
+				}
+			}
+			return err
+		}
+		done = append(done, i)
+	}
+	return nil
+}
+
+// recomputeStats: Nhận hàng trả về (returned_quantity đổi) hoặc đơn chuyển sang refunded thì số liệu
+// ngày đặt / ngày hoàn thành đã chốt bị sai -> tính lại (chạy nền)
+func (c *returnController) recomputeStats(ctx context.Context, orderID int64) {
+	order, err := c.OrderRepo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Recompute stats: GetOrder %d failed: %v", orderID, err)
+		return
+	}
+	c.Stats.RecomputeOrderDays(ctx, order)
+}
+
+// Khách tạo yêu cầu trả hàng
+func (c *returnController) CreateReturn(ctx context.Context, userID int64, orderID int64, req model.CreateReturnRequest, photos []model.AttachmentUpload) (*model.ReturnResponse, error) {
+	order, err := c.OrderRepo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	if order.UserID != userID {
+		logger.WarnLogger.Printf("User %d tried to return items of order %d", userID, orderID)
+		return nil, ErrOrderForbidden
+	}
+	if order.Status != model.OrderStatusCompleted || order.CompletedAt == nil {
+		return nil, ErrOrderNotCompleted
+	}
+	deadline := order.CompletedAt.AddDate(0, 0, c.windowDays)
+	if time.Now().After(deadline) {
+		return nil, fmt.Errorf("%w (%d ngày kể từ khi nhận hàng)", ErrReturnWindowExpired, c.windowDays)
+	}
+
+	// Gộp các dòng trùng sản phẩm
+	var items []model.ReturnItem
+	index := map[int64]int{}
+	for _, i := range req.Items {
+		if pos, ok := index[i.OrderItemID]; ok {
+			items[pos].Quantity += i.Quantity
+			continue
+		}
+		index[i.OrderItemID] = len(items)
+		items = append(items, model.ReturnItem{OrderItemID: i.OrderItemID, Quantity: i.Quantity})
+	}
+
+	saved, err := c.savePhotos(ctx, orderID, photos)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &model.ReturnRequest{
+		ReturnNumber: fmt.Sprintf("RMA-%d", time.Now().UnixNano()),
+		OrderID:      orderID,
+		UserID:       userID,
+		Status:       model.ReturnStatusRequested,
+		Reason:       strings.TrimSpace(req.Reason),
+		Items:        items,
+		Photos:       saved,
+	}
+	if err := c.ReturnRepo.CreateReturn(ctx, ret); err != nil {
+		c.deletePhotos(ctx, saved)
+		return nil, err
+	}
+
+	logger.InfoLogger.Printf("Return %s created for OrderID %d by UserID %d (%d items, %d photos)",
+		ret.ReturnNumber, orderID, userID, len(items), len(saved))
+	return c.GetMyReturn(ctx, userID, ret.ID)
+}
+
+// Khách xem danh sách yêu cầu trả hàng
+func (c *returnController) GetMyReturns(ctx context.Context, userID int64, filter model.ReturnFilter) (*model.ReturnListResponse, error) {
+	filter.UserID = userID
+	return c.list(ctx, filter)
+}
+
+// Khách xem chi tiết yêu cầu trả hàng
+func (c *returnController) GetMyReturn(ctx context.Context, userID int64, returnID int64) (*model.ReturnResponse, error) {
+	ret, err := c.getMyReturn(ctx, userID, returnID)
+	if err != nil {
+		return nil, err
+	}
+	return c.detail(ctx, ret, false)
+}
+
+// Khách hủy yêu cầu trả hàng
+func (c *returnController) CancelMyReturn(ctx context.Context, userID int64, returnID int64) (*model.ReturnResponse, error) {
+	if _, err := c.getMyReturn(ctx, userID, returnID); err != nil {
+		return nil, err
+	}
+	if err := c.ReturnRepo.UpdateStatus(ctx, returnID, model.ReturnStatusRequested, model.ReturnStatusCancelled, &userID, ""); err != nil {
+		return nil, err
+	}
+	logger.InfoLogger.Printf("Return %d cancelled by UserID %d", returnID, userID)
+	return c.GetMyReturn(ctx, userID, returnID)
+}
+
+// Khách tải ảnh
+func (c *returnController) OpenMyPhoto(ctx context.Context, userID int64, returnID int64, photoID int64) (*model.ReturnPhoto, io.ReadCloser, error) {
+	if _, err := c.getMyReturn(ctx, userID, returnID); err != nil {
+		return nil, nil, err
+	}
+	return c.openPhoto(ctx, returnID, photoID)
+}
+
+// Admin tìm kiếm yêu cầu trả hàng
+func (c *returnController) SearchReturns(ctx context.Context, filter model.ReturnFilter) (*model.ReturnListResponse, error) {
+	return c.list(ctx, filter)
+}
+
+// Admin xem chi tiết
+func (c *returnController) GetReturn(ctx context.Context, returnID int64) (*model.ReturnResponse, error) {
+	ret, err := c.getReturn(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+	return c.detail(ctx, ret, true)
+}
+
+// Admin duyệt
+func (c *returnController) ApproveReturn(ctx context.Context, adminID int64, returnID int64, req model.ReturnDecisionRequest) (*model.ReturnResponse, error) {
+	return c.transition(ctx, adminID, returnID, model.ReturnStatusRequested, model.ReturnStatusApproved, req.Note)
+}
+
+// Admin từ chối
+func (c *returnController) RejectReturn(ctx context.Context, adminID int64, returnID int64, req model.ReturnDecisionRequest) (*model.ReturnResponse, error) {
+	return c.transition(ctx, adminID, returnID, model.ReturnStatusRequested, model.ReturnStatusRejected, req.Note)
+}
+
+// Admin xác nhận đã nhận hàng
+func (c *returnController) ReceiveReturn(ctx context.Context, adminID int64, returnID int64, req model.ReceiveReturnRequest) (*model.ReturnResponse, error) {
+	ret, err := c.getReturn(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ReturnRepo.MarkReceived(ctx, returnID, &adminID, strings.TrimSpace(req.Note)); err != nil {
+		return nil, err
+	}
+	c.recomputeStats(ctx, ret.OrderID)
+
+	// Chỉ ghi restocked sau khi đã cộng tồn kho thành công; lỗi thì giữ restocked = false để admin điều chỉnh tay
+	restocked := false
+	if req.Restock {
+		if err := c.restock(ctx, ret); err == nil {
+			if err := c.ReturnRepo.MarkRestocked(ctx, returnID); err != nil {
+				logger.ErrorLogger.Printf("Return %s: stock restored but MarkRestocked failed: %v", ret.ReturnNumber, err)
+			} else {
+				restocked = true
+			}
+		}
+	}
+
+	logger.InfoLogger.Printf("Return %s received by UserID %d (restock requested: %t, restocked: %t)", ret.ReturnNumber, adminID, req.Restock, restocked)
+	return c.GetReturn(ctx, returnID)
+}
+
+// Admin hoàn tiền
+func (c *returnController) RefundReturn(ctx context.Context, adminID int64, returnID int64, req model.RefundReturnRequest) (*model.ReturnResponse, error) {
+	ret, err := c.getReturn(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+	amount := ret.RefundAmount
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	note := strings.TrimSpace(req.Note)
+	if req.Override && note == "" {
+		return nil, ErrOverrideNoteMissing
+	}
+
+	result, err := c.ReturnRepo.Refund(ctx, returnID, amount, req.Override, &adminID, note)
+	if err != nil {
+		return nil, err
+	}
+	if result.OrderRefunded {
+		c.recomputeStats(ctx, ret.OrderID)
+	}
+
+	logger.InfoLogger.Printf("Return %s refunded %.0f by UserID %d (order payment_status: %s, order refunded: %t)",
+		ret.ReturnNumber, amount, adminID, result.PaymentStatus, result.OrderRefunded)
+	return c.GetReturn(ctx, returnID)
+}
+
+// Admin tải ảnh
+func (c *returnController) OpenPhoto(ctx context.Context, returnID int64, photoID int64) (*model.ReturnPhoto, io.ReadCloser, error) {
+	if _, err := c.getReturn(ctx, returnID); err != nil {
+		return nil, nil, err
+	}
+	return c.openPhoto(ctx, returnID, photoID)
+}
@@ -0,0 +1,42 @@
+package returns
+
+import (
+	"context"
+	"golang/internal/model"
+	"io"
+)
+
+type ReturnController interface {
+	// Khách tạo yêu cầu trả hàng cho đơn đã hoàn thành (trong thời hạn trả hàng), kèm ảnh
+	CreateReturn(ctx context.Context, userID int64, orderID int64, req model.CreateReturnRequest, photos []model.AttachmentUpload) (*model.ReturnResponse, error)
+
+	// Khách xem danh sách / chi tiết yêu cầu trả hàng của mình
+	GetMyReturns(ctx context.Context, userID int64, filter model.ReturnFilter) (*model.ReturnListResponse, error)
+	GetMyReturn(ctx context.Context, userID int64, returnID int64) (*model.ReturnResponse, error)
+
+	// Khách hủy yêu cầu khi shop chưa xử lý
+	CancelMyReturn(ctx context.Context, userID int64, returnID int64) (*model.ReturnResponse, error)
+
+	// Khách tải ảnh của yêu cầu (người gọi phải Close)
+	OpenMyPhoto(ctx context.Context, userID int64, returnID int64, photoID int64) (*model.ReturnPhoto, io.ReadCloser, error)
+
+	// Admin tìm kiếm / xem chi tiết yêu cầu trả hàng
+	SearchReturns(ctx context.Context, filter model.ReturnFilter) (*model.ReturnListResponse, error)
+	GetReturn(ctx context.Context, returnID int64) (*model.ReturnResponse, error)
+
+	// Admin duyệt / từ chối yêu cầu
+	ApproveReturn(ctx context.Context, adminID int64, returnID int64, req model.ReturnDecisionRequest) (*model.ReturnResponse, error)
+	RejectReturn(ctx context.Context, adminID int64, returnID int64, req model.ReturnDecisionRequest) (*model.ReturnResponse, error)
+
+	// Admin xác nhận đã nhận hàng trả về (tùy chọn cộng lại tồn kho)
+	ReceiveReturn(ctx context.Context, adminID int64, returnID int64, req model.ReceiveReturnRequest) (*model.ReturnResponse, error)
+
+	// Admin hoàn tiền cho yêu cầu đã nhận hàng
+	RefundReturn(ctx context.Context, adminID int64, returnID int64, req model.RefundReturnRequest) (*model.ReturnResponse, error)
+
+	// Admin tải ảnh của yêu cầu
+	OpenPhoto(ctx context.Context, returnID int64, photoID int64) (*model.ReturnPhoto, io.ReadCloser, error)
+
+	// Dung lượng tối đa 1 request tạo yêu cầu (handler giới hạn body)
+	MaxRequestBytes() int64
+}
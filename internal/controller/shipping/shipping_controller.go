@@ -0,0 +1,336 @@
+package shipping
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+
+	"golang/internal/controller/location"
+	"golang/internal/logger"
+	"golang/internal/model"
+	shippingRepo "golang/internal/repository/shipping"
+)
+
+var (
+	ErrZoneNotFound        = errors.New("vùng giao hàng không tồn tại")
+	ErrMethodNotFound      = errors.New("phương thức giao hàng không tồn tại")
+	ErrDuplicateMethod     = errors.New("vùng đã có phương thức giao hàng này")
+	ErrWeightTiersRequired = errors.New("phương thức tính theo khối lượng phải có ít nhất 1 bậc khối lượng")
+	ErrDuplicateWeightTier = errors.New("các bậc khối lượng không được trùng nhau")
+	ErrNoShippingZone      = errors.New("chưa hỗ trợ giao hàng tới địa chỉ này")
+	ErrMethodUnavailable   = errors.New("phương thức giao hàng không khả dụng cho địa chỉ này")
+	ErrOverweight          = errors.New("đơn hàng vượt quá khối lượng tối đa của phương thức giao hàng")
+	ErrNoAvailableMethod   = errors.New("không có phương thức giao hàng nào phù hợp với đơn hàng")
+)
+
+type shippingController struct {
+	ShippingRepo shippingRepo.ShippingRepository
+}
+
+func NewShippingController(sRepo shippingRepo.ShippingRepository) ShippingController {
+	return &shippingController{
+		ShippingRepo: sRepo,
+	}
+}
+
+// optionalString: chuỗi rỗng => NULL (áp dụng cho mọi giá trị)
+func optionalString(s string) *string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// normalizeCountry: "Vietnam" / "VN" / "viet nam"... => "Việt Nam" để vùng và địa chỉ so khớp cùng một tên
+func normalizeCountry(country string) string {
+	if location.IsVietnam(country) {
+		return location.VietnamCountryName
+	}
+	return strings.TrimSpace(country)
+}
+
+func boolOrDefault(b *bool, def bool) bool {
+	if b == nil {
+		return def
+	}
+	return *b
+}
+
+// ListZones: Danh sách vùng giao hàng
+func (c *shippingController) ListZones(ctx context.Context) ([]model.ShippingZone, error) {
+	return c.ShippingRepo.GetZones(ctx)
+}
+
+// GetZone: Chi tiết vùng giao hàng
+func (c *shippingController) GetZone(ctx context.Context, zoneID int64) (*model.ShippingZone, error) {
+	zone, err := c.ShippingRepo.GetZoneByID(ctx, zoneID)
+	if err == sql.ErrNoRows {
+		return nil, ErrZoneNotFound
+	}
+	return zone, err
+}
+
+// CreateZone: Tạo vùng giao hàng
+func (c *shippingController) CreateZone(ctx context.Context, req model.ShippingZoneRequest) (*model.ShippingZone, error) {
+	zone := &model.ShippingZone{
+		Name:         strings.TrimSpace(req.Name),
+		Country:      normalizeCountry(req.Country),
+		State:        optionalString(req.State),
+		City:         optionalString(req.City),
+		Priority:     req.Priority,
+		IsActive:     boolOrDefault(req.IsActive, true),
+		ProvinceCode: optionalString(req.ProvinceCode),
+	}
+	if err := c.ShippingRepo.CreateZone(ctx, zone); err != nil {
+		return nil, err
+	}
+	logger.InfoLogger.Printf("Shipping zone %d (%s) created", zone.ID, zone.Name)
+	return c.GetZone(ctx, zone.ID)
+}
+
+// UpdateZone: Cập nhật vùng giao hàng (ghi đè toàn bộ thông tin vùng)
+func (c *shippingController) UpdateZone(ctx context.Context, zoneID int64, req model.ShippingZoneRequest) (*model.ShippingZone, error) {
+	existing, err := c.GetZone(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing.Name = strings.TrimSpace(req.Name)
+	existing.Country = normalizeCountry(req.Country)
+	existing.State = optionalString(req.State)
+	existing.City = optionalString(req.City)
+	existing.ProvinceCode = optionalString(req.ProvinceCode)
+	existing.Priority = req.Priority
+	existing.IsActive = boolOrDefault(req.IsActive, existing.IsActive)
+
+	if err := c.ShippingRepo.UpdateZone(ctx, existing); err != nil {
+		return nil, err
+	}
+	logger.InfoLogger.Printf("Shipping zone %d updated", zoneID)
+	return c.GetZone(ctx, zoneID)
+}
+
+// DeleteZone: Xóa vùng giao hàng (các phương thức của vùng bị xóa theo)
+func (c *shippingController) DeleteZone(ctx context.Context, zoneID int64) error {
+	err := c.ShippingRepo.DeleteZone(ctx, zoneID)
+	if err == sql.ErrNoRows {
+		return ErrZoneNotFound
+	}
+	if err == nil {
+		logger.InfoLogger.Printf("Shipping zone %d deleted", zoneID)
+	}
+	return err
+}
+
+// normalizeWeightTiers: Sắp xếp bậc khối lượng tăng dần và kiểm tra trùng
+func normalizeWeightTiers(req model.ShippingMethodRequest) ([]model.ShippingWeightTier, error) {
+	if req.RateType != model.ShippingRateWeight {
+		return nil, nil
+	}
+	if len(req.WeightTiers) == 0 {
+		return nil, ErrWeightTiersRequired
+	}
+
+	tiers := append([]model.ShippingWeightTier(nil), req.WeightTiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MaxWeightGrams < tiers[j].MaxWeightGrams })
+	for i := 1; i < len(tiers); i++ {
+		if tiers[i].MaxWeightGrams == tiers[i-1].MaxWeightGrams {
+			return nil, ErrDuplicateWeightTier
+		}
+	}
+	return tiers, nil
+}
+
+// findMethodInZone: Tìm phương thức của vùng theo ID
+func findMethodInZone(zone *model.ShippingZone, methodID int64) *model.ShippingMethod {
+	for i := range zone.Methods {
+		if zone.Methods[i].ID == methodID {
+			return &zone.Methods[i]
+		}
+	}
+	return nil
+}
+
+// CreateMethod: Thêm phương thức giao hàng cho vùng (mỗi vùng chỉ có 1 standard, 1 express)
+func (c *shippingController) CreateMethod(ctx context.Context, zoneID int64, req model.ShippingMethodRequest) (*model.ShippingMethod, error) {
+	zone, err := c.GetZone(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range zone.Methods {
+		if m.Code == req.Code {
+			return nil, ErrDuplicateMethod
+		}
+	}
+
+	tiers, err := normalizeWeightTiers(req)
+	if err != nil {
+		return nil, err
+	}
+
+	method := &model.ShippingMethod{
+		ZoneID:        zoneID,
+		Code:          req.Code,
+		Name:          strings.TrimSpace(req.Name),
+		RateType:      req.RateType,
+		FlatFee:       req.FlatFee,
+		FreeThreshold: req.FreeThreshold,
+		IsActive:      boolOrDefault(req.IsActive, true),
+		WeightTiers:   tiers,
+	}
+	if err := c.ShippingRepo.CreateMethod(ctx, method); err != nil {
+		return nil, err
+	}
+	logger.InfoLogger.Printf("Shipping method %d (%s) created for zone %d", method.ID, method.Code, zoneID)
+	return c.ShippingRepo.GetMethodByID(ctx, method.ID)
+}
+
+// UpdateMethod: Cập nhật phương thức giao hàng (ghi đè bậc khối lượng)
+func (c *shippingController) UpdateMethod(ctx context.Context, zoneID int64, methodID int64, req model.ShippingMethodRequest) (*model.ShippingMethod, error) {
+	zone, err := c.GetZone(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	existing := findMethodInZone(zone, methodID)
+	if existing == nil {
+		return nil, ErrMethodNotFound
+	}
+	for _, m := range zone.Methods {
+		if m.ID != methodID && m.Code == req.Code {
+			return nil, ErrDuplicateMethod
+		}
+	}
+
+	tiers, err := normalizeWeightTiers(req)
+	if err != nil {
+		return nil, err
+	}
+
+	existing.Code = req.Code
+	existing.Name = strings.TrimSpace(req.Name)
+	existing.RateType = req.RateType
+	existing.FlatFee = req.FlatFee
+	existing.FreeThreshold = req.FreeThreshold
+	existing.IsActive = boolOrDefault(req.IsActive, existing.IsActive)
+	existing.WeightTiers = tiers
+
+	if err := c.ShippingRepo.UpdateMethod(ctx, existing); err != nil {
+		return nil, err
+	}
+	logger.InfoLogger.Printf("Shipping method %d updated", methodID)
+	return c.ShippingRepo.GetMethodByID(ctx, methodID)
+}
+
+// DeleteMethod: Xóa phương thức giao hàng của vùng
+func (c *shippingController) DeleteMethod(ctx context.Context, zoneID int64, methodID int64) error {
+	zone, err := c.GetZone(ctx, zoneID)
+	if err != nil {
+		return err
+	}
+	if findMethodInZone(zone, methodID) == nil {
+		return ErrMethodNotFound
+	}
+
+	err = c.ShippingRepo.DeleteMethod(ctx, methodID)
+	if err == sql.ErrNoRows {
+		return ErrMethodNotFound
+	}
+	if err == nil {
+		logger.InfoLogger.Printf("Shipping method %d deleted from zone %d", methodID, zoneID)
+	}
+	return err
+}
+
+// methodFee: Phí giao hàng của phương thức; miễn phí nếu tiền hàng đạt ngưỡng
+func methodFee(m model.ShippingMethod, subtotal float64, weightGrams int) (float64, bool, error) {
+	if m.FreeThreshold != nil && subtotal >= *m.FreeThreshold {
+		return 0, true, nil
+	}
+
+	if m.RateType == model.ShippingRateWeight {
+		// WeightTiers đã được sắp xếp tăng dần theo max_weight_grams
+		for _, t := range m.WeightTiers {
+			if weightGrams <= t.MaxWeightGrams {
+				return t.Fee, t.Fee == 0, nil
+			}
+		}
+		return 0, false, ErrOverweight
+	}
+	return m.FlatFee, m.FlatFee == 0, nil
+}
+
+func toQuote(zone *model.ShippingZone, m model.ShippingMethod, fee float64, isFree bool, weightGrams int) model.ShippingQuote {
+	return model.ShippingQuote{
+		ZoneID:           zone.ID,
+		ZoneName:         zone.Name,
+		MethodID:         m.ID,
+		Code:             m.Code,
+		Name:             m.Name,
+		RateType:         m.RateType,
+		TotalWeightGrams: weightGrams,
+		Fee:              fee,
+		IsFree:           isFree,
+	}
+}
+
+// matchZone: Vùng giao hàng của địa chỉ, lỗi nếu chưa hỗ trợ
+func (c *shippingController) matchZone(ctx context.Context, dest model.ShippingDestination) (*model.ShippingZone, error) {
+	dest.Country = normalizeCountry(dest.Country)
+	zone, err := c.ShippingRepo.MatchZone(ctx, dest)
+	if err != nil {
+		return nil, err
+	}
+	if zone == nil {
+		logger.WarnLogger.Printf("No shipping zone for %s / %s / %s", dest.Country, dest.State, dest.City)
+		return nil, ErrNoShippingZone
+	}
+	return zone, nil
+}
+
+// QuoteAll: Phí của các phương thức đang hoạt động (bỏ qua phương thức không nhận được khối lượng này)
+func (c *shippingController) QuoteAll(ctx context.Context, dest model.ShippingDestination, subtotal float64, weightGrams int) ([]model.ShippingQuote, error) {
+	zone, err := c.matchZone(ctx, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := []model.ShippingQuote{}
+	for _, m := range zone.Methods {
+		if !m.IsActive {
+			continue
+		}
+		fee, isFree, err := methodFee(m, subtotal, weightGrams)
+		if err != nil {
+			continue
+		}
+		quotes = append(quotes, toQuote(zone, m, fee, isFree, weightGrams))
+	}
+	if len(quotes) == 0 {
+		return nil, ErrNoAvailableMethod
+	}
+	return quotes, nil
+}
+
+// Quote: Phí của phương thức được chọn
+func (c *shippingController) Quote(ctx context.Context, dest model.ShippingDestination, methodCode string, subtotal float64, weightGrams int) (*model.ShippingQuote, error) {
+	zone, err := c.matchZone(ctx, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range zone.Methods {
+		if m.Code != methodCode || !m.IsActive {
+			continue
+		}
+		fee, isFree, err := methodFee(m, subtotal, weightGrams)
+		if err != nil {
+			return nil, err
+		}
+		quote := toQuote(zone, m, fee, isFree, weightGrams)
+		return &quote, nil
+	}
+	return nil, ErrMethodUnavailable
+}
@@ -0,0 +1,75 @@
+package shipping
+
+import (
+	"errors"
+	"testing"
+
+	"golang/internal/model"
+)
+
+func TestMethodFee(t *testing.T) {
+	threshold := func(v float64) *float64 { return &v }
+	flat := model.ShippingMethod{RateType: model.ShippingRateFlat, FlatFee: 30000, FreeThreshold: threshold(500000)}
+	weight := model.ShippingMethod{
+		RateType:      model.ShippingRateWeight,
+		FreeThreshold: threshold(1000000),
+		WeightTiers: []model.ShippingWeightTier{
+			{MaxWeightGrams: 500, Fee: 0},
+			{MaxWeightGrams: 2000, Fee: 25000},
+			{MaxWeightGrams: 5000, Fee: 40000},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		method   model.ShippingMethod
+		subtotal float64
+		weight   int
+		wantFee  float64
+		wantFree bool
+		wantErr  error
+	}{
+		{"flat fee", flat, 200000, 1000, 30000, false, nil},
+		{"flat below threshold", flat, 499999, 0, 30000, false, nil},
+		{"flat reaches threshold", flat, 500000, 0, 0, true, nil},
+		{"flat without threshold", model.ShippingMethod{RateType: model.ShippingRateFlat, FlatFee: 15000}, 10000000, 0, 15000, false, nil},
+		{"flat zero fee is free", model.ShippingMethod{RateType: model.ShippingRateFlat}, 100, 0, 0, true, nil},
+		{"weight zero-fee tier", weight, 100000, 300, 0, true, nil},
+		{"weight tier boundary", weight, 100000, 500, 0, true, nil},
+		{"weight middle tier", weight, 100000, 501, 25000, false, nil},
+		{"weight last tier", weight, 100000, 5000, 40000, false, nil},
+		{"overweight", weight, 100000, 5001, 0, false, ErrOverweight},
+		{"overweight but free by threshold", weight, 1000000, 99999, 0, true, nil},
+		{"weight without tiers", model.ShippingMethod{RateType: model.ShippingRateWeight}, 100, 1, 0, false, ErrOverweight},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, isFree, err := methodFee(tt.method, tt.subtotal, tt.weight)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if fee != tt.wantFee || isFree != tt.wantFree {
+				t.Errorf("methodFee() = (%v, %v), want (%v, %v)", fee, isFree, tt.wantFee, tt.wantFree)
+			}
+		})
+	}
+}
+
+func TestNormalizeCountry(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Việt Nam", "Việt Nam"},
+		{"Vietnam", "Việt Nam"},
+		{" VN ", "Việt Nam"},
+		{"viet nam", "Việt Nam"},
+		{" Japan ", "Japan"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeCountry(tt.in); got != tt.want {
+			t.Errorf("normalizeCountry(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package shipping
+
+import (
+	"context"
+	"golang/internal/model"
+)
+
+type ShippingController interface {
+	// Admin: danh sách vùng giao hàng (kèm phương thức)
+	ListZones(ctx context.Context) ([]model.ShippingZone, error)
+
+	// Admin: chi tiết 1 vùng
+	GetZone(ctx context.Context, zoneID int64) (*model.ShippingZone, error)
+
+	// Admin: tạo / cập nhật / xóa vùng
+	CreateZone(ctx context.Context, req model.ShippingZoneRequest) (*model.ShippingZone, error)
+	UpdateZone(ctx context.Context, zoneID int64, req model.ShippingZoneRequest) (*model.ShippingZone, error)
+	DeleteZone(ctx context.Context, zoneID int64) error
+
+	// Admin: tạo / cập nhật / xóa phương thức giao hàng của vùng
+	CreateMethod(ctx context.Context, zoneID int64, req model.ShippingMethodRequest) (*model.ShippingMethod, error)
+	UpdateMethod(ctx context.Context, zoneID int64, methodID int64, req model.ShippingMethodRequest) (*model.ShippingMethod, error)
+	DeleteMethod(ctx context.Context, zoneID int64, methodID int64) error
+
+	// Tính phí cho tất cả phương thức dùng được tới địa chỉ
+	QuoteAll(ctx context.Context, dest model.ShippingDestination, subtotal float64, weightGrams int) ([]model.ShippingQuote, error)
+
+	// Tính phí cho 1 phương thức (standard / express) tới địa chỉ
+	Quote(ctx context.Context, dest model.ShippingDestination, methodCode string, subtotal float64, weightGrams int) (*model.ShippingQuote, error)
+}
@@ -2,19 +2,125 @@ package stats
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"golang/internal/logger"
 	"golang/internal/model"
+	"golang/internal/notification"
 	repository "golang/internal/repository/stats"
+	userRepository "golang/internal/repository/user"
+	"golang/internal/utils"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Số ngày tối đa của 1 lần backfill
+const maxBackfillDays = 366
+
+// Số ngày gần nhất được kiểm tra khi bù dữ liệu lúc khởi động (mặc định, ghi đè bằng STATS_GAP_FILL_DAYS)
+const defaultGapFillDays = 90
+
+// Múi giờ chia kỳ báo cáo (mặc định, ghi đè bằng STATS_TIMEZONE)
+const defaultReportTimezone = "Asia/Ho_Chi_Minh"
+
+// Khoảng ngày tối đa của báo cáo doanh thu theo thời gian (~3 năm)
+const maxRevenueSeriesDays = 1096
+
+// Số tháng cohort tối đa của 1 lần xem / mặc định
+const (
+	maxCohortMonths     = 36
+	defaultCohortMonths = 12
+)
+
+// Tồn kho (mặc định, ghi đè bằng INVENTORY_DEFAULT_REORDER_THRESHOLD / INVENTORY_VELOCITY_DAYS /
+// INVENTORY_FORECAST_DAYS / INVENTORY_DEAD_STOCK_DAYS)
+const (
+	defaultReorderThreshold = 5
+	defaultVelocityDays     = 28
+	defaultForecastDays     = 14
+	defaultDeadStockDays    = 60
+)
+
+// Phễu giỏ hàng (mặc định, ghi đè bằng CART_CONVERSION_WINDOW_DAYS / CART_ABANDON_HOURS / CART_ABANDON_MAX_DAYS)
+const (
+	defaultConversionWindowDays = 7
+	defaultAbandonHours         = 24
+	defaultAbandonMaxDays       = 30
+	maxFunnelDays               = 366
+)
+
+// Số biến thể tối đa liệt kê trong nội dung thông báo sắp hết hàng
+const lowStockDigestLimit = 10
+
+const dateLayout = "2006-01-02"
+
+var (
+	ErrInvalidBackfillRange = errors.New("khoảng ngày không hợp lệ: from phải nhỏ hơn hoặc bằng to")
+	ErrBackfillRangeTooLong = errors.New("khoảng ngày tính lại tối đa 366 ngày")
+	ErrBackfillFutureDate   = errors.New("chỉ được tính lại các ngày trước hôm nay")
+	ErrInvalidRevenueRange  = errors.New("khoảng ngày không hợp lệ: from phải nhỏ hơn hoặc bằng to")
+	ErrRevenueRangeTooLong  = errors.New("khoảng ngày báo cáo tối đa 1096 ngày")
+	ErrInvalidCohortRange   = errors.New("khoảng tháng không hợp lệ: from_month phải nhỏ hơn hoặc bằng to_month")
+	ErrCohortRangeTooLong   = errors.New("chỉ xem tối đa 36 cohort một lần")
+	ErrInvalidFunnelRange   = errors.New("khoảng ngày không hợp lệ: start_date phải nhỏ hơn hoặc bằng end_date")
+	ErrFunnelRangeTooLong   = errors.New("khoảng ngày báo cáo phễu tối đa 366 ngày")
 )
 
 type statsController struct {
 	StatsRepo repository.IStatsRepository
+	UserRepo  userRepository.UserRepo
+	Notifier  notification.Notifier
+
+	// Tránh cron, backfill và đơn đổi trạng thái cùng ghi đè số liệu 1 ngày
+	aggregateMu sync.Mutex
+
+	// Job khách hàng xóa và ghi lại toàn bộ bảng, không cho chạy chồng
+	customerMu sync.Mutex
+
+	// Tránh gửi trùng cảnh báo sắp hết hàng khi job đêm và job hàng giờ chạy cùng lúc
+	inventoryMu sync.Mutex
+
+	// Job phễu giỏ hàng xóa và ghi lại các ngày gần nhất, không cho chạy chồng
+	funnelMu sync.Mutex
+
+	// Múi giờ xác định "hôm nay" của báo cáo, không phụ thuộc loc của server / kết nối DB
+	reportLoc *time.Location
+}
+
+// dateOnly: Cắt về 00:00 theo múi giờ báo cáo (không theo giờ server), repo lấy mốc này làm đầu ngày khi tổng hợp
+func (c *statsController) dateOnly(t time.Time) time.Time {
+	y, m, d := t.In(c.reportLoc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, c.reportLoc)
+}
+
+// loadReportLocation: Máy chủ thiếu tzdata thì dùng UTC+7 cố định (Việt Nam không có giờ mùa hè)
+func loadReportLocation() *time.Location {
+	name := os.Getenv("STATS_TIMEZONE")
+	if name == "" {
+		name = defaultReportTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		logger.WarnLogger.Printf("StatsController: Cannot load timezone %s, fallback to UTC+7: %v", name, err)
+		return time.FixedZone("UTC+7", 7*60*60)
+	}
+	return loc
+}
+
+func gapFillDays() int {
+	return utils.EnvInt("STATS_GAP_FILL_DAYS", defaultGapFillDays)
 }
 
 //  Khởi tạo Controller 
-func NewStatsController(repo repository.IStatsRepository) StatsController {
+func NewStatsController(repo repository.IStatsRepository, userRepo userRepository.UserRepo, notifier notification.Notifier) StatsController {
 	return &statsController{
 		StatsRepo: repo,
+		UserRepo:  userRepo,
+		Notifier:  notifier,
+		reportLoc: loadReportLocation(),
 	}
 }
 
@@ -59,11 +165,19 @@ func (c *statsController) GetProductStats(ctx context.Context, productID int64,
 	return resp, nil
 }
 
+// aggregate: Tính lại thống kê khoảng ngày (tuần tự, 1 luồng tại 1 thời điểm)
+func (c *statsController) aggregate(ctx context.Context, from time.Time, to time.Time) error {
+	c.aggregateMu.Lock()
+	defer c.aggregateMu.Unlock()
+	return c.StatsRepo.AggregateDailyStats(ctx, from, to)
+}
+
 // Refresh dữ liệu thủ công 
 func (c *statsController) SyncDailyStats(ctx context.Context) error {
 	logger.InfoLogger.Println("StatsController: Starting SyncDailyStats (Manual/Job Trigger)")
 
-	err := c.StatsRepo.RunDailyStatJob(ctx)
+	yesterday := c.dateOnly(time.Now()).AddDate(0, 0, -1)
+	err := c.aggregate(ctx, yesterday, yesterday)
 	if err != nil {
 		logger.ErrorLogger.Printf("StatsController: SyncDailyStats failed: %v", err)
 		return err
@@ -72,3 +186,791 @@ func (c *statsController) SyncDailyStats(ctx context.Context) error {
 	logger.InfoLogger.Println("StatsController: SyncDailyStats completed successfully")
 	return nil
 }
+
+// Backfill: Tính lại thống kê cho khoảng ngày admin chọn
+func (c *statsController) Backfill(ctx context.Context, req model.StatsBackfillRequest) (*model.StatsBackfillResponse, error) {
+	from, err := time.ParseInLocation("2006-01-02", req.From, c.reportLoc)
+	if err != nil {
+		return nil, ErrInvalidBackfillRange
+	}
+	to, err := time.ParseInLocation("2006-01-02", req.To, c.reportLoc)
+	if err != nil {
+		return nil, ErrInvalidBackfillRange
+	}
+
+	if from.After(to) {
+		return nil, ErrInvalidBackfillRange
+	}
+	if !to.Before(c.dateOnly(time.Now())) {
+		return nil, ErrBackfillFutureDate
+	}
+	days := int(to.Sub(from).Hours()/24) + 1
+	if days > maxBackfillDays {
+		return nil, ErrBackfillRangeTooLong
+	}
+
+	logger.InfoLogger.Printf("StatsController: Backfill %s -> %s (%d days)", req.From, req.To, days)
+	if err := c.aggregate(ctx, from, to); err != nil {
+		logger.ErrorLogger.Printf("StatsController: Backfill failed: %v", err)
+		return nil, err
+	}
+
+	return &model.StatsBackfillResponse{From: req.From, To: req.To, Days: days}, nil
+}
+
+// FillGaps: Bù các ngày chưa có dòng tổng hợp (server tắt lúc chạy cron...) trong STATS_GAP_FILL_DAYS ngày gần nhất
+func (c *statsController) FillGaps(ctx context.Context) (int, error) {
+	first, err := c.StatsRepo.GetFirstOrderDate(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if first == nil {
+		return 0, nil
+	}
+
+	to := c.dateOnly(time.Now()).AddDate(0, 0, -1)
+	from := to.AddDate(0, 0, -(gapFillDays() - 1))
+	if firstDay := c.dateOnly(*first); firstDay.After(from) {
+		from = firstDay
+	}
+	if from.After(to) {
+		return 0, nil
+	}
+
+	summarized, err := c.StatsRepo.GetSummarizedDays(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	filled := 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if summarized[day.Format("2006-01-02")] {
+			continue
+		}
+		if err := c.aggregate(ctx, day, day); err != nil {
+			return filled, err
+		}
+		filled++
+	}
+
+	if filled > 0 {
+		logger.InfoLogger.Printf("StatsController: Filled %d missing stats days (%s -> %s)", filled, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+	return filled, nil
+}
+
+// RecomputeDays: Tính lại các ngày (đã chốt) bị ảnh hưởng bởi thay đổi của đơn hàng
+func (c *statsController) RecomputeDays(ctx context.Context, days []time.Time) error {
+	today := c.dateOnly(time.Now())
+	unique := make(map[time.Time]bool)
+	for _, d := range days {
+		if day := c.dateOnly(d); day.Before(today) {
+			unique[day] = true
+		}
+	}
+
+	sorted := make([]time.Time, 0, len(unique))
+	for day := range unique {
+		sorted = append(sorted, day)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	for _, day := range sorted {
+		logger.InfoLogger.Printf("StatsController: Recompute stats for %s", day.Format("2006-01-02"))
+		if err := c.aggregate(ctx, day, day); err != nil {
+			logger.ErrorLogger.Printf("StatsController: Recompute %s failed: %v", day.Format("2006-01-02"), err)
+			return err
+		}
+	}
+	return nil
+}
+
+// RecomputeOrderDays: Số liệu ngày đặt / ngày hoàn thành đã chốt của đơn bị sai -> tính lại (chạy nền, lỗi chỉ ghi log)
+func (c *statsController) RecomputeOrderDays(ctx context.Context, order *model.Order) {
+	days := []time.Time{order.PlacedAt}
+	if order.CompletedAt != nil {
+		days = append(days, *order.CompletedAt)
+	}
+
+	go func() {
+		if err := c.RecomputeDays(context.WithoutCancel(ctx), days); err != nil {
+			logger.ErrorLogger.Printf("Recompute stats for OrderID %d failed: %v", order.ID, err)
+		}
+	}()
+}
+
+// GetProductMargins: Lợi nhuận gộp theo sản phẩm
+func (c *statsController) GetProductMargins(ctx context.Context, filter model.MarginFilter) ([]model.ProductMarginResponse, error) {
+	resp, err := c.StatsRepo.GetProductMargins(ctx, filter)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsController: GetProductMargins failed: %v", err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetVariantMargins: Lợi nhuận gộp theo biến thể
+func (c *statsController) GetVariantMargins(ctx context.Context, filter model.MarginFilter) ([]model.VariantMarginResponse, error) {
+	resp, err := c.StatsRepo.GetVariantMargins(ctx, filter)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsController: GetVariantMargins failed: %v", err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetCategoryMargins: Lợi nhuận gộp theo danh mục
+func (c *statsController) GetCategoryMargins(ctx context.Context, filter model.MarginFilter) ([]model.CategoryMarginResponse, error) {
+	resp, err := c.StatsRepo.GetCategoryMargins(ctx, filter)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsController: GetCategoryMargins failed: %v", err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetDailyMargins: Lợi nhuận gộp theo ngày
+func (c *statsController) GetDailyMargins(ctx context.Context, filter model.MarginFilter) ([]model.DailyMarginResponse, error) {
+	resp, err := c.StatsRepo.GetDailyMargins(ctx, filter)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsController: GetDailyMargins failed: %v", err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetMarginAlerts: Biến thể bán dưới giá vốn
+func (c *statsController) GetMarginAlerts(ctx context.Context, filter model.MarginFilter) ([]model.MarginAlertResponse, error) {
+	resp, err := c.StatsRepo.GetMarginAlerts(ctx, filter)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsController: GetMarginAlerts failed: %v", err)
+		return nil, err
+	}
+	if len(resp) > 0 {
+		logger.WarnLogger.Printf("StatsController: %d variants selling below cost", len(resp))
+	}
+	return resp, nil
+}
+
+// Các hàm chia kỳ dưới đây làm việc trên ngày lịch thuần (UTC, 00:00) nên không bị lệch múi giờ / DST
+
+func bucketStart(day time.Time, granularity string) time.Time {
+	switch granularity {
+	case model.RevenueGranularityWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case model.RevenueGranularityMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+func bucketNext(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case model.RevenueGranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case model.RevenueGranularityMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func bucketLabel(start time.Time, granularity string) string {
+	switch granularity {
+	case model.RevenueGranularityWeek:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case model.RevenueGranularityMonth:
+		return start.Format("2006-01")
+	}
+	return start.Format(dateLayout)
+}
+
+func isLastDayOfMonth(day time.Time) bool {
+	return day.AddDate(0, 0, 1).Day() == 1
+}
+
+// shiftMonths: Lùi n tháng, giữ "ngày cuối tháng" là ngày cuối tháng (31/03 -> 28/02)
+func shiftMonths(day time.Time, months int) time.Time {
+	first := time.Date(day.Year(), day.Month()-time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	d := day.Day()
+	if d > lastDay || isLastDayOfMonth(day) {
+		d = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), d, 0, 0, 0, 0, time.UTC)
+}
+
+// compareShift: Hàm ánh xạ 1 ngày của kỳ hiện tại sang kỳ so sánh (nil nếu không so sánh).
+// Kỳ liền trước của khoảng trọn tháng (chia theo tháng) lùi đúng số tháng để các cột tháng khớp nhau
+func compareShift(from time.Time, to time.Time, granularity string, compare string) func(time.Time) time.Time {
+	switch compare {
+	case model.RevenueComparePreviousYear:
+		return func(d time.Time) time.Time { return shiftMonths(d, 12) }
+	case model.RevenueComparePreviousPeriod:
+		if granularity == model.RevenueGranularityMonth && from.Day() == 1 && isLastDayOfMonth(to) {
+			months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+			return func(d time.Time) time.Time { return shiftMonths(d, months) }
+		}
+		days := int(to.Sub(from).Hours()/24) + 1
+		return func(d time.Time) time.Time { return d.AddDate(0, 0, -days) }
+	}
+	return nil
+}
+
+// sumDays: Cộng dồn số liệu các ngày trong [from, to]; ngày chưa có dòng tổng hợp tính là 0
+func sumDays(days map[string]model.RevenueMetrics, from time.Time, to time.Time) model.RevenueMetrics {
+	var total model.RevenueMetrics
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		m := days[d.Format(dateLayout)]
+		total.GMV += m.GMV
+		total.RealRevenue += m.RealRevenue
+		total.Orders += m.Orders
+		total.RealOrders += m.RealOrders
+		total.Units += m.Units
+	}
+	if total.Orders > 0 {
+		total.AOV = total.GMV / float64(total.Orders)
+	}
+	return total
+}
+
+func metricDelta(current float64, previous float64) model.MetricDelta {
+	d := model.MetricDelta{Change: current - previous}
+	if previous != 0 {
+		pct := (current - previous) / previous * 100
+		d.Percent = &pct
+	}
+	return d
+}
+
+func revenueDeltas(current model.RevenueMetrics, previous model.RevenueMetrics) *model.RevenueDeltas {
+	return &model.RevenueDeltas{
+		GMV:         metricDelta(current.GMV, previous.GMV),
+		RealRevenue: metricDelta(current.RealRevenue, previous.RealRevenue),
+		Orders:      metricDelta(float64(current.Orders), float64(previous.Orders)),
+		AOV:         metricDelta(current.AOV, previous.AOV),
+		Units:       metricDelta(float64(current.Units), float64(previous.Units)),
+	}
+}
+
+// GetRevenueSeries: Doanh thu theo ngày / tuần / tháng từ sales_summary_daily, có thể kèm kỳ so sánh
+func (c *statsController) GetRevenueSeries(ctx context.Context, req model.RevenueSeriesRequest) (*model.RevenueSeriesResponse, error) {
+	from, err := time.Parse(dateLayout, req.From)
+	if err != nil {
+		return nil, ErrInvalidRevenueRange
+	}
+	to, err := time.Parse(dateLayout, req.To)
+	if err != nil {
+		return nil, ErrInvalidRevenueRange
+	}
+	if from.After(to) {
+		return nil, ErrInvalidRevenueRange
+	}
+	if int(to.Sub(from).Hours()/24)+1 > maxRevenueSeriesDays {
+		return nil, ErrRevenueRangeTooLong
+	}
+
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = model.RevenueGranularityDay
+	}
+
+	current, err := c.StatsRepo.GetDailySummaries(ctx, req.From, req.To)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsController: GetRevenueSeries failed: %v", err)
+		return nil, err
+	}
+
+	resp := &model.RevenueSeriesResponse{
+		From:        req.From,
+		To:          req.To,
+		Granularity: granularity,
+		Timezone:    c.reportLoc.String(),
+		Totals:      sumDays(current, from, to),
+		Buckets:     []model.RevenueBucket{},
+	}
+
+	shift := compareShift(from, to, granularity, req.Compare)
+	var previous map[string]model.RevenueMetrics
+	if shift != nil {
+		compareFrom, compareTo := shift(from), shift(to)
+		previous, err = c.StatsRepo.GetDailySummaries(ctx, compareFrom.Format(dateLayout), compareTo.Format(dateLayout))
+		if err != nil {
+			logger.ErrorLogger.Printf("StatsController: GetRevenueSeries (compare) failed: %v", err)
+			return nil, err
+		}
+
+		prevTotals := sumDays(previous, compareFrom, compareTo)
+		resp.Compare = req.Compare
+		resp.CompareFrom = compareFrom.Format(dateLayout)
+		resp.CompareTo = compareTo.Format(dateLayout)
+		resp.PreviousTotals = &prevTotals
+		resp.Delta = revenueDeltas(resp.Totals, prevTotals)
+	}
+
+	// Hôm nay theo múi giờ báo cáo: các ngày từ hôm nay trở đi chưa được cron tổng hợp
+	y, m, d := time.Now().In(c.reportLoc).Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	for start := bucketStart(from, granularity); !start.After(to); start = bucketNext(start, granularity) {
+		bStart, bEnd := start, bucketNext(start, granularity).AddDate(0, 0, -1)
+		if bStart.Before(from) {
+			bStart = from
+		}
+		if bEnd.After(to) {
+			bEnd = to
+		}
+
+		bucket := model.RevenueBucket{
+			Period:     bucketLabel(start, granularity),
+			Start:      bStart.Format(dateLayout),
+			End:        bEnd.Format(dateLayout),
+			Incomplete: !bEnd.Before(today),
+			Current:    sumDays(current, bStart, bEnd),
+		}
+		if shift != nil {
+			pStart, pEnd := shift(bStart), shift(bEnd)
+			prev := sumDays(previous, pStart, pEnd)
+			bucket.PreviousStart = pStart.Format(dateLayout)
+			bucket.PreviousEnd = pEnd.Format(dateLayout)
+			bucket.Previous = &prev
+			bucket.Delta = revenueDeltas(bucket.Current, prev)
+		}
+		resp.Buckets = append(resp.Buckets, bucket)
+	}
+
+	return resp, nil
+}
+
+// RefreshCustomerAnalytics: Tính lại chỉ số khách hàng, RFM và cohort (job hàng đêm / admin chạy tay)
+func (c *statsController) RefreshCustomerAnalytics(ctx context.Context) error {
+	c.customerMu.Lock()
+	defer c.customerMu.Unlock()
+
+	logger.InfoLogger.Println("StatsController: Starting RefreshCustomerAnalytics")
+	if err := c.StatsRepo.RefreshCustomerStats(ctx, time.Now()); err != nil {
+		logger.ErrorLogger.Printf("StatsController: RefreshCustomerAnalytics failed: %v", err)
+		return err
+	}
+	logger.InfoLogger.Println("StatsController: RefreshCustomerAnalytics completed successfully")
+	return nil
+}
+
+// EnsureCustomerAnalytics: Chưa có dữ liệu khách hàng (lần đầu triển khai) thì tính ngay, không đợi job đêm
+func (c *statsController) EnsureCustomerAnalytics(ctx context.Context) error {
+	computedAt, err := c.StatsRepo.GetCustomerStatsComputedAt(ctx)
+	if err != nil {
+		return err
+	}
+	if computedAt != nil {
+		return nil
+	}
+	return c.RefreshCustomerAnalytics(ctx)
+}
+
+// SearchCustomers: Danh sách khách hàng theo LTV / số đơn / độ gần đây
+func (c *statsController) SearchCustomers(ctx context.Context, filter model.CustomerStatsFilter) ([]model.CustomerStatsResponse, int64, error) {
+	customers, total, err := c.StatsRepo.SearchCustomerStats(ctx, filter)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsController: SearchCustomers failed: %v", err)
+		return nil, 0, err
+	}
+	return customers, total, nil
+}
+
+// ExportCustomers: Duyệt toàn bộ khách theo filter để ghi file
+func (c *statsController) ExportCustomers(ctx context.Context, filter model.CustomerStatsFilter, fn func(model.CustomerStatsResponse) error) error {
+	if err := c.StatsRepo.EachCustomerStats(ctx, filter, fn); err != nil {
+		logger.ErrorLogger.Printf("StatsController: ExportCustomers failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetRFMSegments: Tổng hợp theo phân khúc RFM
+func (c *statsController) GetRFMSegments(ctx context.Context) ([]model.RFMSegmentSummary, error) {
+	resp, err := c.StatsRepo.GetRFMSegmentSummary(ctx)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsController: GetRFMSegments failed: %v", err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// monthsBetween: Số tháng từ from tới to (cùng tháng = 0)
+func monthsBetween(from time.Time, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+}
+
+func percentOf(part int64, whole int64) *float64 {
+	if whole == 0 {
+		return nil
+	}
+	pct := float64(part) / float64(whole) * 100
+	return &pct
+}
+
+// GetCohorts: Ma trận giữ chân theo tháng mua đầu tiên; các tháng không có đơn được điền 0 tới tháng hiện tại
+func (c *statsController) GetCohorts(ctx context.Context, filter model.CohortFilter) ([]model.CohortResponse, error) {
+	y, m, _ := time.Now().In(c.reportLoc).Date()
+	currentMonth := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+
+	toMonth := currentMonth
+	if filter.ToMonth != "" {
+		t, err := time.Parse("2006-01", filter.ToMonth)
+		if err != nil {
+			return nil, ErrInvalidCohortRange
+		}
+		toMonth = t
+	}
+	fromMonth := toMonth.AddDate(0, -(defaultCohortMonths - 1), 0)
+	if filter.FromMonth != "" {
+		f, err := time.Parse("2006-01", filter.FromMonth)
+		if err != nil {
+			return nil, ErrInvalidCohortRange
+		}
+		fromMonth = f
+	}
+
+	if fromMonth.After(toMonth) {
+		return nil, ErrInvalidCohortRange
+	}
+	if monthsBetween(fromMonth, toMonth)+1 > maxCohortMonths {
+		return nil, ErrCohortRangeTooLong
+	}
+
+	rows, err := c.StatsRepo.GetCohortRows(ctx, fromMonth.Format(dateLayout), toMonth.Format(dateLayout))
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsController: GetCohorts failed: %v", err)
+		return nil, err
+	}
+
+	// Gom các ô theo cohort
+	cohorts := make(map[string]*model.CohortResponse)
+	cells := make(map[string]map[int]model.CohortCell)
+	for _, r := range rows {
+		if cohorts[r.CohortMonth] == nil {
+			cohorts[r.CohortMonth] = &model.CohortResponse{Cohort: r.CohortMonth, Size: r.CohortSize}
+			cells[r.CohortMonth] = make(map[int]model.CohortCell)
+		}
+		cells[r.CohortMonth][r.MonthOffset] = r.CohortCell
+	}
+
+	res := make([]model.CohortResponse, 0, len(cohorts))
+	for month := fromMonth; !month.After(toMonth); month = month.AddDate(0, 1, 0) {
+		key := month.Format("2006-01")
+		cohort := cohorts[key]
+		if cohort == nil {
+			continue // Tháng không có khách mới
+		}
+
+		maxOffset := max(monthsBetween(month, currentMonth), 0)
+		cohort.Retention = make([]model.CohortCell, 0, maxOffset+1)
+		for offset := 0; offset <= maxOffset; offset++ {
+			cell := cells[key][offset]
+			cell.MonthOffset = offset
+			cell.RetentionRate = percentOf(cell.ActiveCustomers, cohort.Size)
+			cohort.Retention = append(cohort.Retention, cell)
+		}
+		res = append(res, *cohort)
+	}
+	return res, nil
+}
+
+// GetRepeatPurchase: Tỷ lệ khách quay lại mua trong N ngày (chỉ xét khách có đơn đầu tiên cách đây đủ N ngày)
+func (c *statsController) GetRepeatPurchase(ctx context.Context, filter model.RepeatPurchaseFilter) (*model.RepeatPurchaseResponse, error) {
+	resp, err := c.StatsRepo.GetRepeatPurchase(ctx, filter.WithinDays)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsController: GetRepeatPurchase failed: %v", err)
+		return nil, err
+	}
+	resp.RepeatRate = percentOf(resp.RepeatCustomers, resp.EligibleCustomers)
+	resp.EverRepeatRate = percentOf(resp.EverRepeated, resp.TotalCustomers)
+	return resp, nil
+}
+
+
+// today: Ngày hiện tại theo múi giờ báo cáo
+func (c *statsController) today() time.Time {
+	y, m, d := time.Now().In(c.reportLoc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+// inventoryParams: Ngưỡng phân loại tồn kho, filter không truyền thì lấy theo cấu hình
+func (c *statsController) inventoryParams(horizonDays int, deadDays int) model.InventoryHealthParams {
+	if horizonDays <= 0 {
+		horizonDays = utils.EnvInt("INVENTORY_FORECAST_DAYS", defaultForecastDays)
+	}
+	if deadDays <= 0 {
+		deadDays = utils.EnvInt("INVENTORY_DEAD_STOCK_DAYS", defaultDeadStockDays)
+	}
+	return model.InventoryHealthParams{
+		DefaultThreshold: utils.EnvInt("INVENTORY_DEFAULT_REORDER_THRESHOLD", defaultReorderThreshold),
+		HorizonDays:      horizonDays,
+		DeadDays:         deadDays,
+		Today:            c.today(),
+	}
+}
+
+// RefreshInventoryForecast: Tính lại tốc độ bán từng biến thể rồi gửi cảnh báo sắp hết hàng (job hàng đêm / admin chạy tay)
+func (c *statsController) RefreshInventoryForecast(ctx context.Context) error {
+	logger.InfoLogger.Println("StatsController: Starting RefreshInventoryForecast")
+	windowDays := utils.EnvInt("INVENTORY_VELOCITY_DAYS", defaultVelocityDays)
+	if err := c.StatsRepo.RefreshInventoryForecast(ctx, c.today(), windowDays); err != nil {
+		logger.ErrorLogger.Printf("StatsController: RefreshInventoryForecast failed: %v", err)
+		return err
+	}
+	logger.InfoLogger.Println("StatsController: RefreshInventoryForecast completed successfully")
+	return c.CheckLowStock(ctx)
+}
+
+// EnsureInventoryForecast: Chưa có dữ liệu velocity (lần đầu triển khai) thì tính ngay, không đợi job đêm
+func (c *statsController) EnsureInventoryForecast(ctx context.Context) error {
+	computedAt, err := c.StatsRepo.GetInventoryForecastComputedAt(ctx)
+	if err != nil {
+		return err
+	}
+	if computedAt != nil {
+		return nil
+	}
+	return c.RefreshInventoryForecast(ctx)
+}
+
+// CheckLowStock: Gửi 1 thông báo tổng hợp cho các admin khi có biến thể mới xuống dưới ngưỡng đặt hàng lại.
+// Mỗi biến thể chỉ báo 1 lần, tới khi tồn kho lên lại trên ngưỡng
+func (c *statsController) CheckLowStock(ctx context.Context) error {
+	c.inventoryMu.Lock()
+	defer c.inventoryMu.Unlock()
+
+	p := c.inventoryParams(0, 0)
+	if _, err := c.StatsRepo.ResetLowStockAlerts(ctx, p.DefaultThreshold); err != nil {
+		return err
+	}
+
+	items, err := c.StatsRepo.GetPendingLowStockAlerts(ctx, p)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 || c.Notifier == nil {
+		return nil
+	}
+
+	var body strings.Builder
+	ids := make([]int64, 0, len(items))
+	variants := make([]map[string]interface{}, 0, len(items))
+	for i, item := range items {
+		ids = append(ids, item.VariantID)
+		variants = append(variants, map[string]interface{}{
+			"variant_id":        item.VariantID,
+			"product_id":        item.ProductID,
+			"sku":               item.SKU,
+			"stock_quantity":    item.StockQuantity,
+			"reorder_threshold": item.ReorderThreshold,
+			"status":            item.Status,
+		})
+		if i < lowStockDigestLimit {
+			fmt.Fprintf(&body, "- %s %s: còn %d (ngưỡng %d)\n", item.SKU, item.ProductName, item.StockQuantity, item.ReorderThreshold)
+		}
+	}
+	if len(items) > lowStockDigestLimit {
+		fmt.Fprintf(&body, "... và %d biến thể khác", len(items)-lowStockDigestLimit)
+	}
+
+	isActive := true
+	sent := 0
+	err = c.UserRepo.EachUser(model.UserFilter{Role: "admin", IsActive: &isActive}, func(admin model.User) error {
+		n := model.Notification{
+			Type:   model.NotificationLowStock,
+			UserID: admin.ID,
+			Email:  admin.Email,
+			Title:  fmt.Sprintf("%d biến thể sắp hết hàng", len(items)),
+			Body:   strings.TrimSpace(body.String()),
+			Data: map[string]interface{}{
+				"variants": variants,
+				"sent_at":  time.Now().Format(time.RFC3339),
+			},
+		}
+		if err := c.Notifier.Send(ctx, n); err != nil {
+			logger.ErrorLogger.Printf("Gửi cảnh báo sắp hết hàng cho admin %d thất bại: %v", admin.ID, err)
+			return nil
+		}
+		sent++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Không gửi được cho ai thì để lần chạy sau thử lại
+	if sent == 0 {
+		logger.WarnLogger.Printf("StatsController: %d biến thể sắp hết hàng nhưng chưa báo được cho admin nào", len(items))
+		return nil
+	}
+	logger.InfoLogger.Printf("Đã báo %d biến thể sắp hết hàng cho %d admin", len(items), sent)
+	return c.StatsRepo.MarkLowStockNotified(ctx, ids)
+}
+
+// GetInventoryHealth: Báo cáo tồn kho - hết hàng, dưới ngưỡng, sắp hết theo tốc độ bán, tồn đọng
+func (c *statsController) GetInventoryHealth(ctx context.Context, filter model.InventoryHealthFilter) (*model.InventoryHealthResponse, error) {
+	p := c.inventoryParams(filter.HorizonDays, filter.DeadDays)
+
+	summary, items, err := c.StatsRepo.GetInventoryHealth(ctx, p, filter)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsController: GetInventoryHealth failed: %v", err)
+		return nil, err
+	}
+	computedAt, err := c.StatsRepo.GetInventoryForecastComputedAt(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	total := summary.OutOfStock + summary.Low + summary.ProjectedOut + summary.Dead + summary.OK
+	switch filter.Status {
+	case model.InventoryStatusOutOfStock:
+		total = summary.OutOfStock
+	case model.InventoryStatusLow:
+		total = summary.Low
+	case model.InventoryStatusProjectedOut:
+		total = summary.ProjectedOut
+	case model.InventoryStatusDead:
+		total = summary.Dead
+	case model.InventoryStatusOK:
+		total = summary.OK
+	}
+
+	return &model.InventoryHealthResponse{
+		Summary:            *summary,
+		Items:              items,
+		Total:              total,
+		HorizonDays:        p.HorizonDays,
+		DeadDays:           p.DeadDays,
+		VelocityWindowDays: utils.EnvInt("INVENTORY_VELOCITY_DAYS", defaultVelocityDays),
+		ComputedAt:         computedAt,
+	}, nil
+}
+
+// funnelRange: Khoảng ngày báo cáo phễu, mặc định 30 ngày tới hôm qua (hôm nay chưa được job tổng hợp)
+func (c *statsController) funnelRange(filter model.StatsFilter) (time.Time, time.Time, error) {
+	end := c.today().AddDate(0, 0, -1)
+	if filter.EndDate != "" {
+		end, _ = time.ParseInLocation(dateLayout, filter.EndDate, time.Local)
+	}
+	start := end.AddDate(0, 0, -29)
+	if filter.StartDate != "" {
+		start, _ = time.ParseInLocation(dateLayout, filter.StartDate, time.Local)
+	}
+	if start.After(end) {
+		return start, end, ErrInvalidFunnelRange
+	}
+	if int(end.Sub(start).Hours()/24)+1 > maxFunnelDays {
+		return start, end, ErrFunnelRangeTooLong
+	}
+	return start, end, nil
+}
+
+// funnelRates: Tỷ lệ xem checkout / chuyển đổi / bỏ quên trên số giỏ bắt đầu
+func funnelRates(m *model.FunnelMetrics) {
+	m.CheckoutRate = percentOf(m.CartsCheckout, m.CartsStarted)
+	m.ConversionRate = percentOf(m.CartsConverted, m.CartsStarted)
+	m.AbandonmentRate = percentOf(m.CartsAbandoned, m.CartsStarted)
+}
+
+// RefreshCartFunnel: Tính lại phễu các ngày còn trong cửa sổ chuyển đổi (job hàng đêm / admin chạy tay)
+func (c *statsController) RefreshCartFunnel(ctx context.Context) error {
+	c.funnelMu.Lock()
+	defer c.funnelMu.Unlock()
+
+	windowDays := utils.EnvInt("CART_CONVERSION_WINDOW_DAYS", defaultConversionWindowDays)
+	to := c.today().AddDate(0, 0, -1)
+	from := to.AddDate(0, 0, -(windowDays + 1))
+
+	logger.InfoLogger.Printf("StatsController: Starting RefreshCartFunnel %s -> %s", from.Format(dateLayout), to.Format(dateLayout))
+	if err := c.StatsRepo.RefreshCartFunnel(ctx, from, to, windowDays); err != nil {
+		logger.ErrorLogger.Printf("StatsController: RefreshCartFunnel failed: %v", err)
+		return err
+	}
+	logger.InfoLogger.Println("StatsController: RefreshCartFunnel completed successfully")
+	return nil
+}
+
+// GetCartFunnel: Phễu giỏ hàng -> checkout -> đơn hàng theo ngày, các ngày không có giỏ nào được điền 0
+func (c *statsController) GetCartFunnel(ctx context.Context, filter model.StatsFilter) (*model.CartFunnelResponse, error) {
+	start, end, err := c.funnelRange(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, computedAt, err := c.StatsRepo.GetCartFunnel(ctx, start.Format(dateLayout), end.Format(dateLayout))
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsController: GetCartFunnel failed: %v", err)
+		return nil, err
+	}
+	byDate := make(map[string]model.FunnelDay, len(rows))
+	for _, r := range rows {
+		byDate[r.Date] = r
+	}
+
+	windowDays := utils.EnvInt("CART_CONVERSION_WINDOW_DAYS", defaultConversionWindowDays)
+	today := c.today()
+	resp := &model.CartFunnelResponse{
+		StartDate:  start.Format(dateLayout),
+		EndDate:    end.Format(dateLayout),
+		WindowDays: windowDays,
+		Days:       []model.FunnelDay{},
+		ComputedAt: computedAt,
+	}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		d := byDate[day.Format(dateLayout)]
+		d.Date = day.Format(dateLayout)
+		d.Complete = !day.AddDate(0, 0, windowDays+1).After(today)
+		funnelRates(&d.FunnelMetrics)
+		resp.Days = append(resp.Days, d)
+
+		resp.Totals.CartsStarted += d.CartsStarted
+		resp.Totals.CartsCheckout += d.CartsCheckout
+		resp.Totals.CartsConverted += d.CartsConverted
+		resp.Totals.CartsAbandoned += d.CartsAbandoned
+		resp.Totals.AddEvents += d.AddEvents
+		resp.Totals.RemoveEvents += d.RemoveEvents
+		resp.Totals.PreviewEvents += d.PreviewEvents
+		resp.Totals.OrderEvents += d.OrderEvents
+	}
+	funnelRates(&resp.Totals)
+	return resp, nil
+}
+
+// GetAbandonedProducts: Sản phẩm bị bỏ lại trong giỏ nhiều nhất trong khoảng ngày
+func (c *statsController) GetAbandonedProducts(ctx context.Context, filter model.StatsFilter) ([]model.AbandonedProductResponse, error) {
+	start, end, err := c.funnelRange(filter)
+	if err != nil {
+		return nil, err
+	}
+	filter.StartDate, filter.EndDate = start.Format(dateLayout), end.Format(dateLayout)
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+
+	resp, err := c.StatsRepo.GetAbandonedProducts(ctx, filter)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsController: GetAbandonedProducts failed: %v", err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetAbandonedCarts: Giỏ bỏ quên của user đã đồng ý nhận marketing (để gửi email nhắc)
+func (c *statsController) GetAbandonedCarts(ctx context.Context, filter model.AbandonedCartFilter) ([]model.AbandonedCartResponse, int64, error) {
+	if filter.MinHours <= 0 {
+		filter.MinHours = utils.EnvInt("CART_ABANDON_HOURS", defaultAbandonHours)
+	}
+	if filter.MaxDays <= 0 {
+		filter.MaxDays = utils.EnvInt("CART_ABANDON_MAX_DAYS", defaultAbandonMaxDays)
+	}
+
+	carts, total, err := c.StatsRepo.GetAbandonedCarts(ctx, filter)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsController: GetAbandonedCarts failed: %v", err)
+		return nil, 0, err
+	}
+	return carts, total, nil
+}
@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"golang/internal/model"
+)
+
+func day(s string) time.Time {
+	d, err := time.Parse(dateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestBucketStart(t *testing.T) {
+	tests := []struct {
+		day         string
+		granularity string
+		want        string
+	}{
+		{"2025-01-01", model.RevenueGranularityDay, "2025-01-01"},
+		{"2025-01-01", model.RevenueGranularityWeek, "2024-12-30"}, // Thứ 4 -> thứ 2 của tuần, lùi sang năm trước
+		{"2025-01-05", model.RevenueGranularityWeek, "2024-12-30"}, // Chủ nhật thuộc tuần bắt đầu từ thứ 2 trước đó
+		{"2025-01-06", model.RevenueGranularityWeek, "2025-01-06"},
+		{"2025-02-17", model.RevenueGranularityMonth, "2025-02-01"},
+		{"2024-02-29", model.RevenueGranularityMonth, "2024-02-01"},
+		{"2025-03-31", "", "2025-03-31"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.granularity+"_"+tt.day, func(t *testing.T) {
+			if got := bucketStart(day(tt.day), tt.granularity).Format(dateLayout); got != tt.want {
+				t.Errorf("bucketStart(%s, %q) = %s, want %s", tt.day, tt.granularity, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareShift(t *testing.T) {
+	tests := []struct {
+		name        string
+		from, to    string
+		granularity string
+		compare     string
+		day         string
+		want        string
+	}{
+		{"previous year", "2025-03-01", "2025-03-31", model.RevenueGranularityDay, model.RevenueComparePreviousYear, "2025-03-15", "2024-03-15"},
+		{"previous year leap day", "2024-02-01", "2024-02-29", model.RevenueGranularityDay, model.RevenueComparePreviousYear, "2024-02-29", "2023-02-28"},
+		{"previous year end of month", "2025-02-01", "2025-02-28", model.RevenueGranularityDay, model.RevenueComparePreviousYear, "2025-02-28", "2024-02-29"},
+		{"previous period by days", "2025-03-10", "2025-03-16", model.RevenueGranularityDay, model.RevenueComparePreviousPeriod, "2025-03-10", "2025-03-03"},
+		{"previous period full month", "2025-03-01", "2025-03-31", model.RevenueGranularityMonth, model.RevenueComparePreviousPeriod, "2025-03-31", "2025-02-28"},
+		{"previous period full month mid", "2025-03-01", "2025-03-31", model.RevenueGranularityMonth, model.RevenueComparePreviousPeriod, "2025-03-30", "2025-02-28"},
+		{"previous period quarter", "2025-01-01", "2025-03-31", model.RevenueGranularityMonth, model.RevenueComparePreviousPeriod, "2025-02-28", "2024-11-30"},
+		{"previous period partial month", "2025-03-02", "2025-03-31", model.RevenueGranularityMonth, model.RevenueComparePreviousPeriod, "2025-03-31", "2025-03-01"},
+		{"full month by week keeps days", "2025-03-01", "2025-03-31", model.RevenueGranularityWeek, model.RevenueComparePreviousPeriod, "2025-03-31", "2025-02-28"},
+		{"full month by week start", "2025-03-01", "2025-03-31", model.RevenueGranularityWeek, model.RevenueComparePreviousPeriod, "2025-03-01", "2025-01-29"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shift := compareShift(day(tt.from), day(tt.to), tt.granularity, tt.compare)
+			if shift == nil {
+				t.Fatalf("compareShift returned nil")
+			}
+			if got := shift(day(tt.day)).Format(dateLayout); got != tt.want {
+				t.Errorf("shift(%s) = %s, want %s", tt.day, got, tt.want)
+			}
+		})
+	}
+
+	if compareShift(day("2025-03-01"), day("2025-03-31"), model.RevenueGranularityDay, "") != nil {
+		t.Errorf("compareShift without compare should be nil")
+	}
+}
@@ -3,6 +3,7 @@ package stats
 import (
 	"context"
 	"golang/internal/model"
+	"time"
 )
 
 type StatsController interface {
@@ -15,6 +16,73 @@ type StatsController interface {
 	//  Lấy số liệu thống kê của 1 sản phẩm 
 	GetProductStats(ctx context.Context, productID int64, filter model.StatsFilter) ([]model.ProductDailyStatsResponse, error)
 
-	// Refresh thống kê hàng ngày
+	// Refresh thống kê hàng ngày (tính lại ngày hôm qua)
 	SyncDailyStats(ctx context.Context) error
+
+	// Tính lại thống kê cho khoảng ngày [from, to] (YYYY-MM-DD)
+	Backfill(ctx context.Context, req model.StatsBackfillRequest) (*model.StatsBackfillResponse, error)
+
+	// Tìm và tính bù các ngày chưa có số liệu (chạy lúc khởi động), trả về số ngày đã bù
+	FillGaps(ctx context.Context) (int, error)
+
+	// Tính lại thống kê các ngày bị ảnh hưởng khi đơn cũ đổi trạng thái (bỏ qua ngày hôm nay)
+	RecomputeDays(ctx context.Context, days []time.Time) error
+
+	// Tính lại (chạy nền) ngày đặt + ngày hoàn thành của đơn khi đơn đổi trạng thái / có hàng trả về
+	RecomputeOrderDays(ctx context.Context, order *model.Order)
+
+	// Báo cáo lợi nhuận gộp theo sản phẩm / biến thể / danh mục / ngày
+	GetProductMargins(ctx context.Context, filter model.MarginFilter) ([]model.ProductMarginResponse, error)
+	GetVariantMargins(ctx context.Context, filter model.MarginFilter) ([]model.VariantMarginResponse, error)
+	GetCategoryMargins(ctx context.Context, filter model.MarginFilter) ([]model.CategoryMarginResponse, error)
+	GetDailyMargins(ctx context.Context, filter model.MarginFilter) ([]model.DailyMarginResponse, error)
+
+	// Doanh thu theo ngày / tuần / tháng, có thể so sánh với kỳ trước / cùng kỳ năm trước
+	GetRevenueSeries(ctx context.Context, req model.RevenueSeriesRequest) (*model.RevenueSeriesResponse, error)
+
+	// Cảnh báo biến thể bán dưới giá vốn
+	GetMarginAlerts(ctx context.Context, filter model.MarginFilter) ([]model.MarginAlertResponse, error)
+
+	// Tính lại chỉ số khách hàng, RFM, cohort (job hàng đêm)
+	RefreshCustomerAnalytics(ctx context.Context) error
+
+	// Tính ngay nếu chưa từng có dữ liệu khách hàng (lúc khởi động)
+	EnsureCustomerAnalytics(ctx context.Context) error
+
+	// Danh sách khách hàng (LTV, số đơn, RFM) và export toàn bộ
+	SearchCustomers(ctx context.Context, filter model.CustomerStatsFilter) ([]model.CustomerStatsResponse, int64, error)
+	ExportCustomers(ctx context.Context, filter model.CustomerStatsFilter, fn func(model.CustomerStatsResponse) error) error
+
+	// Tổng hợp theo phân khúc RFM
+	GetRFMSegments(ctx context.Context) ([]model.RFMSegmentSummary, error)
+
+	// Ma trận cohort theo tháng mua đầu tiên
+	GetCohorts(ctx context.Context, filter model.CohortFilter) ([]model.CohortResponse, error)
+
+	// Tỷ lệ mua lặp lại trong N ngày
+	GetRepeatPurchase(ctx context.Context, filter model.RepeatPurchaseFilter) (*model.RepeatPurchaseResponse, error)
+
+	// Tính lại tốc độ bán từng biến thể + cảnh báo sắp hết hàng (job hàng đêm)
+	RefreshInventoryForecast(ctx context.Context) error
+
+	// Tính ngay nếu chưa từng có dữ liệu velocity (lúc khởi động)
+	EnsureInventoryForecast(ctx context.Context) error
+
+	// Báo admin các biến thể mới xuống dưới ngưỡng đặt hàng lại
+	CheckLowStock(ctx context.Context) error
+
+	// Báo cáo tồn kho: hết hàng, dưới ngưỡng, sắp hết, tồn đọng
+	GetInventoryHealth(ctx context.Context, filter model.InventoryHealthFilter) (*model.InventoryHealthResponse, error)
+
+	// Tính lại phễu giỏ hàng các ngày gần nhất (job hàng đêm)
+	RefreshCartFunnel(ctx context.Context) error
+
+	// Phễu giỏ hàng -> checkout -> đơn hàng, tỷ lệ chuyển đổi / bỏ quên
+	GetCartFunnel(ctx context.Context, filter model.StatsFilter) (*model.CartFunnelResponse, error)
+
+	// Sản phẩm bị bỏ lại trong giỏ nhiều nhất
+	GetAbandonedProducts(ctx context.Context, filter model.StatsFilter) ([]model.AbandonedProductResponse, error)
+
+	// Giỏ bỏ quên của user đã đồng ý nhận marketing
+	GetAbandonedCarts(ctx context.Context, filter model.AbandonedCartFilter) ([]model.AbandonedCartResponse, int64, error)
 }
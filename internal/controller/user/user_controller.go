@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"errors"
 	"golang/internal/logger"
 	"golang/internal/model"
@@ -11,35 +12,55 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// CartMerger: gộp giỏ hàng khách vào giỏ của user (cart.CartController đáp ứng interface này)
+type CartMerger interface {
+	MergeGuestCart(ctx context.Context, userID int64, cartToken string) error
+}
+
 type userController struct {
-	UserRepo user.UserRepo
+	UserRepo   user.UserRepo
+	CartMerger CartMerger
 }
 
-func NewUserController(userRepo user.UserRepo) UserController {
+func NewUserController(userRepo user.UserRepo, cartMerger CartMerger) UserController {
 	return &userController{
-		UserRepo: userRepo,
+		UserRepo:   userRepo,
+		CartMerger: cartMerger,
+	}
+}
+
+// mergeGuestCart: gộp giỏ khách sau khi xác thực thành công, lỗi chỉ ghi log không chặn đăng nhập
+// Trả về true khi đã gộp xong (giữ cart token để lần đăng nhập sau gộp lại nếu lỗi)
+func (c *userController) mergeGuestCart(userID int64, cartToken string) bool {
+	if cartToken == "" || c.CartMerger == nil {
+		return false
+	}
+	if err := c.CartMerger.MergeGuestCart(context.Background(), userID, cartToken); err != nil {
+		logger.WarnLogger.Printf("Không gộp được giỏ hàng khách cho user %d: %v", userID, err)
+		return false
 	}
+	return true
 }
 
 // Hàm Register để đăng ký user mới
-func (c *userController) Register(req model.RegisterRequest) (model.UserProfileResponse, error) {
+func (c *userController) Register(req model.RegisterRequest) (model.UserProfileResponse, bool, error) {
 	logger.InfoLogger.Printf("Bắt đầu đăng ký user mới: %s", req.Username)
 
 	// Kiểm tra User đã tồn tại chưa (Check Username hoặc Email)
 	existingUser, _ := c.UserRepo.GetUserByIdentifier(req.Username)
 	if existingUser.ID != 0 {
-		return model.UserProfileResponse{}, errors.New("tên đăng nhập đã tồn tại")
+		return model.UserProfileResponse{}, false, errors.New("tên đăng nhập đã tồn tại")
 	}
 	existingEmail, _ := c.UserRepo.GetUserByIdentifier(req.Email)
 	if existingEmail.ID != 0 {
-		return model.UserProfileResponse{}, errors.New("email đã tồn tại")
+		return model.UserProfileResponse{}, false, errors.New("email đã tồn tại")
 	}
 
 	// Mã hóa mật khẩu (Hashing)
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		logger.ErrorLogger.Printf("Lỗi hash password: %v", err)
-		return model.UserProfileResponse{}, err
+		return model.UserProfileResponse{}, false, err
 	}
 
 	// Map từ Request -> Model User (Entity)
@@ -55,7 +76,7 @@ func (c *userController) Register(req model.RegisterRequest) (model.UserProfileR
 	createdUser, err := c.UserRepo.CreateUser(newUser)
 	if err != nil {
 		logger.ErrorLogger.Printf("Lỗi khi tạo user trong DB: %v", err)
-		return model.UserProfileResponse{}, err
+		return model.UserProfileResponse{}, false, err
 	}
 
 	// Chuyển đổi sang Response
@@ -69,44 +90,47 @@ func (c *userController) Register(req model.RegisterRequest) (model.UserProfileR
 		UpdatedAt: createdUser.UpdatedAt,
 	}
 
+	// Gộp giỏ hàng khách (nếu có) vào giỏ của tài khoản mới
+	cartMerged := c.mergeGuestCart(createdUser.ID, req.CartToken)
+
 	logger.InfoLogger.Printf("Đăng ký thành công user ID: %d", createdUser.ID)
-	return res, nil
+	return res, cartMerged, nil
 }
 
 // Hàm Login để xác thực user
-func (c *userController) Login(req model.LoginRequest) (model.LoginResponse, error) {
+func (c *userController) Login(req model.LoginRequest) (model.LoginResponse, bool, error) {
 	logger.InfoLogger.Printf("Yêu cầu login từ: %s", req.Identifier)
 
 	//  Tìm user trong DB
 	user, err := c.UserRepo.GetUserByIdentifier(req.Identifier)
 	if err != nil {
 		logger.ErrorLogger.Printf("Login thất bại (User not found): %v", err)
-		return model.LoginResponse{}, errors.New("tài khoản hoặc mật khẩu không đúng")
+		return model.LoginResponse{}, false, errors.New("tài khoản hoặc mật khẩu không đúng")
 	}
 
 	//  Check nếu user bị xóa
 	if user.DeletedAt != nil {
 		logger.WarnLogger.Printf("Login thất bại (User deleted) cho user: %s", user.Username)
-		return model.LoginResponse{}, errors.New("tài khoản này đã bị xóa")
+		return model.LoginResponse{}, false, errors.New("tài khoản này đã bị xóa")
 	}
 
 	//  Check khóa
 	if !user.IsActive {
-		return model.LoginResponse{}, errors.New("tài khoản này đã bị khóa")
+		return model.LoginResponse{}, false, errors.New("tài khoản này đã bị khóa")
 	}
 
 	//  So sánh mật khẩu
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password))
 	if err != nil {
 		logger.WarnLogger.Printf("Login thất bại (Sai pass) cho user: %s", user.Username)
-		return model.LoginResponse{}, errors.New("tài khoản hoặc mật khẩu không đúng")
+		return model.LoginResponse{}, false, errors.New("tài khoản hoặc mật khẩu không đúng")
 	}
 
 	//  Tạo Token
 	accessToken, refreshToken, err := generateTokens(user.ID, user.Role)
 	if err != nil {
 		logger.ErrorLogger.Printf("Lỗi tạo token: %v", err)
-		return model.LoginResponse{}, err
+		return model.LoginResponse{}, false, err
 	}
 
 	//  Lưu Refresh Token
@@ -114,7 +138,7 @@ func (c *userController) Login(req model.LoginRequest) (model.LoginResponse, err
 	err = c.UserRepo.UpdateRefreshToken(user.ID, refreshToken, refreshTokenExpiry)
 	if err != nil {
 		logger.ErrorLogger.Printf("Lỗi lưu refresh token: %v", err)
-		return model.LoginResponse{}, err
+		return model.LoginResponse{}, false, err
 	}
 
 	//  Trả kết quả
@@ -126,14 +150,18 @@ func (c *userController) Login(req model.LoginRequest) (model.LoginResponse, err
 			Username:  user.Username,
 			Email:     user.Email,
 			Role:      user.Role,
-			IsActive:  user.IsActive,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
+			IsActive:       user.IsActive,
+			MarketingOptIn: user.MarketingOptIn,
+			CreatedAt:      user.CreatedAt,
+			UpdatedAt:      user.UpdatedAt,
 		},
 	}
 
+	// Gộp giỏ hàng khách (nếu có) vào giỏ của user
+	cartMerged := c.mergeGuestCart(user.ID, req.CartToken)
+
 	logger.InfoLogger.Printf("Login thành công: %s", user.Username)
-	return response, nil
+	return response, cartMerged, nil
 }
 
 // Hàm Logout: Hủy refresh token của user
@@ -311,7 +339,7 @@ func (c *userController) UpdateUserProfile(id int64, req model.UserUpdateProfile
 	// Trả về kết quả
 	return model.UserProfileResponse{
 		ID: updatedUser.ID, Username: updatedUser.Username, Email: updatedUser.Email, Role: updatedUser.Role,
-		IsActive: updatedUser.IsActive, CreatedAt: updatedUser.CreatedAt, UpdatedAt: updatedUser.UpdatedAt,
+		IsActive: updatedUser.IsActive, MarketingOptIn: updatedUser.MarketingOptIn, CreatedAt: updatedUser.CreatedAt, UpdatedAt: updatedUser.UpdatedAt,
 	}, nil
 }
 
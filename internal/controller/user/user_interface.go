@@ -4,11 +4,11 @@ import "golang/internal/model"
 
 // UserController - Interface định nghĩa các nghiệp vụ (Logic)
 type UserController interface {
-	// Đăng ký người dùng mới
-	Register(req model.RegisterRequest) (model.UserProfileResponse, error)
+	// Đăng ký người dùng mới (cartMerged = true khi giỏ khách đã được gộp, handler mới xóa cookie cart token)
+	Register(req model.RegisterRequest) (res model.UserProfileResponse, cartMerged bool, err error)
 
-	// Đăng nhập người dùng
-	Login(req model.LoginRequest) (model.LoginResponse, error)
+	// Đăng nhập người dùng (cartMerged như Register)
+	Login(req model.LoginRequest) (res model.LoginResponse, cartMerged bool, err error)
 
 	// Đăng xuất người dùng
 	Logout(userID int64) error
@@ -0,0 +1,229 @@
+package wishlist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang/internal/logger"
+	"golang/internal/model"
+	"golang/internal/notification"
+	productRepo "golang/internal/repository/product"
+	variantRepo "golang/internal/repository/productvariant"
+	wishlistRepo "golang/internal/repository/wishlist"
+	"golang/internal/utils"
+)
+
+var (
+	ErrProductNotFound   = errors.New("sản phẩm không tồn tại")
+	ErrVariantNotFound   = errors.New("biến thể không tồn tại")
+	ErrVariantMismatch   = errors.New("biến thể không thuộc sản phẩm này")
+	ErrWishlistNotFound  = errors.New("không tìm thấy sản phẩm trong wishlist")
+	ErrAlertNotFound     = errors.New("không tìm thấy đăng ký báo có hàng")
+	ErrVariantInStock    = errors.New("biến thể đang còn hàng")
+	ErrVariantNotForSale = errors.New("biến thể không còn được bán")
+)
+
+type wishlistController struct {
+	WishlistRepo wishlistRepo.WishlistRepository
+	ProductRepo  productRepo.ProductRepository
+	VariantRepo  variantRepo.ProductVariantsRepository
+	Notifier     notification.Notifier
+}
+
+func NewWishlistController(
+	wRepo wishlistRepo.WishlistRepository,
+	pRepo productRepo.ProductRepository,
+	vRepo variantRepo.ProductVariantsRepository,
+	notifier notification.Notifier,
+) WishlistController {
+	return &wishlistController{
+		WishlistRepo: wRepo,
+		ProductRepo:  pRepo,
+		VariantRepo:  vRepo,
+		Notifier:     notifier,
+	}
+}
+
+// toWishlistResponse: giá và tồn kho lấy theo biến thể nếu dòng lưu ở mức biến thể
+func toWishlistResponse(i model.WishlistItem) model.WishlistItemResponse {
+	res := model.WishlistItemResponse{
+		ID:           i.ID,
+		ProductID:    i.ProductID,
+		ProductName:  i.ProductName,
+		ProductSlug:  i.ProductSlug,
+		VariantID:    i.VariantID,
+		VariantTitle: i.VariantTitle,
+		Price:        i.MinPrice,
+		InStock:      true,
+		Available:    i.IsPublished,
+		CreatedAt:    i.CreatedAt,
+	}
+	if i.VariantID != nil {
+		if i.VariantTitle == nil {
+			res.VariantTitle = i.VariantSKU
+		}
+		res.Price = utils.VariantPrice(i.PriceOverride, i.BasePrice)
+		res.InStock = i.StockQuantity != nil && *i.StockQuantity > 0
+	}
+	return res
+}
+
+// GetWishlist: Lấy wishlist của user
+func (c *wishlistController) GetWishlist(ctx context.Context, userID int64) ([]model.WishlistItemResponse, error) {
+	items, err := c.WishlistRepo.GetWishlistByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]model.WishlistItemResponse, 0, len(items))
+	for _, i := range items {
+		res = append(res, toWishlistResponse(i))
+	}
+	return res, nil
+}
+
+// AddToWishlist: Thêm vào wishlist (đã có thì trả về dòng cũ)
+func (c *wishlistController) AddToWishlist(ctx context.Context, userID int64, req model.AddWishlistItemRequest) (model.WishlistItemResponse, error) {
+	product, err := c.ProductRepo.GetProductByID(req.ProductID)
+	if err != nil || product == nil {
+		return model.WishlistItemResponse{}, ErrProductNotFound
+	}
+
+	if req.VariantID != nil {
+		variant, err := c.VariantRepo.GetVariantByID(*req.VariantID)
+		if err != nil || variant == nil {
+			return model.WishlistItemResponse{}, ErrVariantNotFound
+		}
+		if variant.ProductID != product.ID {
+			return model.WishlistItemResponse{}, ErrVariantMismatch
+		}
+	}
+
+	itemID, err := c.WishlistRepo.AddWishlistItem(ctx, userID, req.ProductID, req.VariantID)
+	if err != nil {
+		return model.WishlistItemResponse{}, err
+	}
+	logger.InfoLogger.Printf("User %d thêm sản phẩm %d vào wishlist", userID, req.ProductID)
+
+	items, err := c.WishlistRepo.GetWishlistByUserID(ctx, userID)
+	if err != nil {
+		return model.WishlistItemResponse{}, err
+	}
+	for _, i := range items {
+		if i.ID == itemID {
+			return toWishlistResponse(i), nil
+		}
+	}
+
+	return model.WishlistItemResponse{ID: itemID, ProductID: req.ProductID, VariantID: req.VariantID}, nil
+}
+
+// RemoveFromWishlist: Xóa 1 dòng khỏi wishlist
+func (c *wishlistController) RemoveFromWishlist(ctx context.Context, userID int64, itemID int64) error {
+	err := c.WishlistRepo.DeleteWishlistItem(ctx, userID, itemID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrWishlistNotFound
+	}
+	return err
+}
+
+// SubscribeStockAlert: Chỉ đăng ký được khi biến thể đang hết hàng
+func (c *wishlistController) SubscribeStockAlert(ctx context.Context, userID int64, req model.StockAlertRequest) error {
+	variant, err := c.VariantRepo.GetVariantByID(req.VariantID)
+	if err != nil || variant == nil {
+		return ErrVariantNotFound
+	}
+	if !variant.IsActive {
+		return ErrVariantNotForSale
+	}
+	if _, err := c.ProductRepo.GetProductByID(variant.ProductID); err != nil {
+		return ErrVariantNotForSale
+	}
+	if variant.StockQuantity > 0 {
+		return ErrVariantInStock
+	}
+
+	if err := c.WishlistRepo.UpsertStockSubscription(ctx, userID, req.VariantID); err != nil {
+		return err
+	}
+
+	logger.InfoLogger.Printf("User %d đăng ký báo có hàng cho variant %d", userID, req.VariantID)
+	return nil
+}
+
+// UnsubscribeStockAlert: Hủy đăng ký báo có hàng
+func (c *wishlistController) UnsubscribeStockAlert(ctx context.Context, userID int64, variantID int64) error {
+	err := c.WishlistRepo.DeleteStockSubscription(ctx, userID, variantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAlertNotFound
+	}
+	return err
+}
+
+// GetStockAlerts: Danh sách đăng ký báo có hàng của user
+func (c *wishlistController) GetStockAlerts(ctx context.Context, userID int64) ([]model.StockAlertResponse, error) {
+	subs, err := c.WishlistRepo.GetStockSubscriptionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]model.StockAlertResponse, 0, len(subs))
+	for _, s := range subs {
+		title := s.VariantTitle
+		if title == nil {
+			title = &s.VariantSKU
+		}
+		res = append(res, model.StockAlertResponse{
+			ID:           s.ID,
+			VariantID:    s.VariantID,
+			ProductID:    s.ProductID,
+			ProductName:  s.ProductName,
+			VariantTitle: title,
+			Notified:     s.NotifiedAt != nil,
+			NotifiedAt:   s.NotifiedAt,
+			CreatedAt:    s.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
+// NotifyBackInStock: Lấy (và đánh dấu) các đăng ký đang chờ rồi gửi thông báo.
+// Đăng ký đã được đánh dấu trước khi gửi nên mỗi đăng ký chỉ báo 1 lần, lỗi gửi chỉ ghi log.
+func (c *wishlistController) NotifyBackInStock(ctx context.Context, variantID int64) {
+	subs, err := c.WishlistRepo.ClaimPendingSubscriptions(ctx, variantID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Không lấy được đăng ký báo có hàng của variant %d: %v", variantID, err)
+		return
+	}
+
+	for _, s := range subs {
+		name := s.ProductName
+		if s.VariantTitle != nil && *s.VariantTitle != "" {
+			name = fmt.Sprintf("%s (%s)", s.ProductName, *s.VariantTitle)
+		}
+
+		n := model.Notification{
+			Type:   model.NotificationBackInStock,
+			UserID: s.UserID,
+			Email:  s.Email,
+			Title:  "Sản phẩm đã có hàng trở lại",
+			Body:   fmt.Sprintf("%s đã có hàng trở lại, đặt mua ngay trước khi hết!", name),
+			Data: map[string]interface{}{
+				"product_id": s.ProductID,
+				"variant_id": s.VariantID,
+				"sku":        s.VariantSKU,
+				"sent_at":    time.Now().Format(time.RFC3339),
+			},
+		}
+		if err := c.Notifier.Send(ctx, n); err != nil {
+			logger.ErrorLogger.Printf("Gửi thông báo có hàng cho user %d thất bại: %v", s.UserID, err)
+		}
+	}
+
+	if len(subs) > 0 {
+		logger.InfoLogger.Printf("Đã báo có hàng variant %d cho %d người đăng ký", variantID, len(subs))
+	}
+}
@@ -0,0 +1,29 @@
+package wishlist
+
+import (
+	"context"
+	"golang/internal/model"
+)
+
+type WishlistController interface {
+	// Lấy wishlist của user
+	GetWishlist(ctx context.Context, userID int64) ([]model.WishlistItemResponse, error)
+
+	// Thêm sản phẩm / biến thể vào wishlist
+	AddToWishlist(ctx context.Context, userID int64, req model.AddWishlistItemRequest) (model.WishlistItemResponse, error)
+
+	// Xóa 1 dòng khỏi wishlist
+	RemoveFromWishlist(ctx context.Context, userID int64, itemID int64) error
+
+	// Đăng ký nhận thông báo khi biến thể có hàng trở lại
+	SubscribeStockAlert(ctx context.Context, userID int64, req model.StockAlertRequest) error
+
+	// Hủy đăng ký báo có hàng
+	UnsubscribeStockAlert(ctx context.Context, userID int64, variantID int64) error
+
+	// Danh sách đăng ký báo có hàng của user
+	GetStockAlerts(ctx context.Context, userID int64) ([]model.StockAlertResponse, error)
+
+	// Gửi thông báo cho người đăng ký khi biến thể có hàng trở lại (tồn kho 0 -> dương)
+	NotifyBackInStock(ctx context.Context, variantID int64)
+}
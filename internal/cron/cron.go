@@ -10,9 +10,17 @@ import (
 	"golang/internal/logger"
 )
 
+// Job - Một công việc chạy định kỳ do các module đăng ký
+type Job struct {
+	Name string
+	Spec string
+	Run  func(ctx context.Context) error
+}
+
 type CronManager struct {
 	StatsController statsController.StatsController
 	cron            *cron.Cron
+	jobs            []Job
 }
 
 func NewCronManager(statsCtrl statsController.StatsController) *CronManager {
@@ -22,6 +30,11 @@ func NewCronManager(statsCtrl statsController.StatsController) *CronManager {
 	}
 }
 
+// Register: Các module khác đăng ký Job của mình (gọi trước Start)
+func (m *CronManager) Register(name, spec string, run func(ctx context.Context) error) {
+	m.jobs = append(m.jobs, Job{Name: name, Spec: spec, Run: run})
+}
+
 // Start: Đăng ký các Job và bắt đầu chạy
 func (m *CronManager) Start() {
 	// Job 1: Cập nhật thống kê doanh thu hàng ngày (00:30 sáng)
@@ -40,6 +53,20 @@ func (m *CronManager) Start() {
 		fmt.Printf("Lỗi đăng ký Cron Job: %v\n", err)
 	}
 
+	// Các Job do module đăng ký
+	for _, job := range m.jobs {
+		_, err := m.cron.AddFunc(job.Spec, func() {
+			logger.DebugLogger.Printf("[CRON] Bắt đầu chạy Job %s...", job.Name)
+
+			if err := job.Run(context.Background()); err != nil {
+				logger.ErrorLogger.Printf("[CRON] Lỗi Job %s: %v", job.Name, err)
+			}
+		})
+		if err != nil {
+			fmt.Printf("Lỗi đăng ký Cron Job %s: %v\n", job.Name, err)
+		}
+	}
+
 	// Bắt đầu chạy background
 	m.cron.Start()
 	logger.InfoLogger.Println("Cron Job Manager đã khởi động...")
@@ -51,4 +78,4 @@ func (m *CronManager) Stop() {
 		m.cron.Stop()
 		logger.InfoLogger.Println("Cron Job Manager đã dừng.")
 	}
-}
\ No newline at end of file
+}
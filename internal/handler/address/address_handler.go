@@ -2,7 +2,9 @@ package address
 
 import (
 	"encoding/json"
+	"errors"
 	"golang/internal/controller/address"
+	"golang/internal/controller/location"
 	"golang/internal/model"
 	"golang/internal/utils"
 	"golang/internal/validator"
@@ -21,6 +23,19 @@ func NewAddressHandler(addressController address.AddressController) AddressHandl
 	}
 }
 
+// Helper: Lỗi nhập địa chỉ (tỉnh / quận / phường sai, số điện thoại sai) trả về 400 kèm gợi ý, còn lại dùng status mặc định
+func writeAddressError(w http.ResponseWriter, status int, message string, err error) {
+	var locErr *location.LocationError
+	switch {
+	case errors.As(err, &locErr):
+		utils.WriteError(w, http.StatusBadRequest, "Địa chỉ không hợp lệ", locErr)
+	case errors.Is(err, utils.ErrInvalidPhone):
+		utils.WriteError(w, http.StatusBadRequest, message, err.Error())
+	default:
+		utils.WriteError(w, status, message, err.Error())
+	}
+}
+
 // Tạo địa chỉ mới
 func (h *addressHandler) CreateAddress(w http.ResponseWriter, r *http.Request) {
 	// Lấy UserID từ Context (Bắt buộc phải đăng nhập)
@@ -46,7 +61,7 @@ func (h *addressHandler) CreateAddress(w http.ResponseWriter, r *http.Request) {
 	//  Gọi Controller
 	res, err := h.AddressController.CreateAddress(userID, req)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, "Lỗi tạo địa chỉ", err.Error())
+		writeAddressError(w, http.StatusInternalServerError, "Lỗi tạo địa chỉ", err)
 		return
 	}
 
@@ -122,7 +137,7 @@ func (h *addressHandler) UpdateAddress(w http.ResponseWriter, r *http.Request) {
 
 	res, err := h.AddressController.UpdateAddress(id, userID, req)
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, "Lỗi cập nhật địa chỉ", err.Error())
+		writeAddressError(w, http.StatusNotFound, "Lỗi cập nhật địa chỉ", err)
 		return
 	}
 
@@ -2,6 +2,7 @@ package cart
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -30,17 +31,53 @@ func getUserIDFromContext(r *http.Request) int64 {
 	return userID
 }
 
+// Helper: Xác định chủ giỏ hàng — user đã đăng nhập hoặc khách mang cart token (header/cookie).
+// Trả về false và đã ghi response lỗi nếu không xác định được.
+func resolveCartOwner(w http.ResponseWriter, r *http.Request) (model.CartOwner, bool) {
+	if userID := getUserIDFromContext(r); userID != 0 {
+		return model.CartOwner{UserID: userID}, true
+	}
+
+	token := utils.CartTokenFromRequest(r)
+	if token == "" {
+		return model.CartOwner{}, true
+	}
+
+	guestToken, ok := utils.ParseCartToken(token)
+	if !ok {
+		utils.ClearCartTokenCookie(w)
+		utils.WriteError(w, http.StatusUnauthorized, "Cart token không hợp lệ", nil)
+		return model.CartOwner{}, false
+	}
+	return model.CartOwner{GuestToken: guestToken}, true
+}
+
+// Helper: Bắt buộc phải có giỏ hàng (user hoặc khách đã có cart token)
+func requireCartOwner(w http.ResponseWriter, r *http.Request) (model.CartOwner, bool) {
+	owner, ok := resolveCartOwner(w, r)
+	if !ok {
+		return owner, false
+	}
+	if owner.IsGuest() && owner.GuestToken == "" {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Cần đăng nhập hoặc cart token")
+		return owner, false
+	}
+	return owner, true
+}
+
 // GetCart: Lấy giỏ hàng
 func (h *cartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
-	// Lấy UserID từ middleware
-	userID := getUserIDFromContext(r)
-	if userID == 0 {
-		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+	// Lấy UserID từ middleware hoặc cart token của khách
+	owner, ok := resolveCartOwner(w, r)
+	if !ok {
 		return
 	}
 
+	// ?auto_fix=true: tự giảm số lượng về tồn kho và chấp nhận giá mới
+	autoFix, _ := strconv.ParseBool(r.URL.Query().Get("auto_fix"))
+
 	//  Gọi Controller
-	cartResp, err := h.CartController.GetCart(r.Context(), userID)
+	cartResp, err := h.CartController.GetCart(r.Context(), owner, autoFix)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "Lỗi lấy giỏ hàng", err.Error())
 		return
@@ -52,9 +89,8 @@ func (h *cartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
 
 //  AddToCart: Thêm sản phẩm
 func (h *cartHandler) AddToCart(w http.ResponseWriter, r *http.Request) {
-	userID := getUserIDFromContext(r)
-	if userID == 0 {
-		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+	owner, ok := resolveCartOwner(w, r)
+	if !ok {
 		return
 	}
 
@@ -70,20 +106,41 @@ func (h *cartHandler) AddToCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Khách chưa có cart token -> cấp token mới
+	var newToken string
+	if owner.IsGuest() && owner.GuestToken == "" {
+		token, nonce, err := utils.NewCartToken()
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "Không thể tạo giỏ hàng", err.Error())
+			return
+		}
+		newToken = token
+		owner.GuestToken = nonce
+	}
+
 	// Gọi Controller
-	if err := h.CartController.AddToCart(r.Context(), userID, req); err != nil {
+	if err := h.CartController.AddToCart(r.Context(), owner, req); err != nil {
+		if errors.Is(err, cart.ErrInvalidCartToken) {
+			utils.WriteError(w, http.StatusUnauthorized, "Cart token không hợp lệ", nil)
+			return
+		}
 		utils.WriteError(w, http.StatusBadRequest, "Không thể thêm vào giỏ", err.Error())
 		return
 	}
 
+	if newToken != "" {
+		utils.SetCartTokenCookie(w, newToken)
+		utils.WriteJSON(w, http.StatusCreated, "Thêm sản phẩm vào giỏ thành công", model.GuestCartTokenResponse{CartToken: newToken})
+		return
+	}
+
 	utils.WriteJSON(w, http.StatusCreated, "Thêm sản phẩm vào giỏ thành công", nil)
 }
 
 // UpdateCartItem 
 func (h *cartHandler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
-	userID := getUserIDFromContext(r)
-	if userID == 0 {
-		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+	owner, ok := requireCartOwner(w, r)
+	if !ok {
 		return
 	}
 
@@ -107,7 +164,7 @@ func (h *cartHandler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Gọi Controller
-	if err := h.CartController.UpdateCartItem(r.Context(), userID, variantID, req); err != nil {
+	if err := h.CartController.UpdateCartItem(r.Context(), owner, variantID, req); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, "Lỗi cập nhật giỏ hàng", err.Error())
 		return
 	}
@@ -117,9 +174,8 @@ func (h *cartHandler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
 
 //  RemoveCartItems 
 func (h *cartHandler) RemoveCartItems(w http.ResponseWriter, r *http.Request) {
-	userID := getUserIDFromContext(r)
-	if userID == 0 {
-		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+	owner, ok := requireCartOwner(w, r)
+	if !ok {
 		return
 	}
 
@@ -135,7 +191,7 @@ func (h *cartHandler) RemoveCartItems(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.CartController.RemoveCartItems(r.Context(), userID, req); err != nil {
+	if err := h.CartController.RemoveCartItems(r.Context(), owner, req); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "Lỗi xóa sản phẩm", err.Error())
 		return
 	}
@@ -145,9 +201,8 @@ func (h *cartHandler) RemoveCartItems(w http.ResponseWriter, r *http.Request) {
 
 //  CalculateCheckoutPreview 
 func (h *cartHandler) CalculateCheckoutPreview(w http.ResponseWriter, r *http.Request) {
-	userID := getUserIDFromContext(r)
-	if userID == 0 {
-		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+	owner, ok := requireCartOwner(w, r)
+	if !ok {
 		return
 	}
 
@@ -162,7 +217,7 @@ func (h *cartHandler) CalculateCheckoutPreview(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	resp, err := h.CartController.CalculateCheckoutPreview(r.Context(), userID, req)
+	resp, err := h.CartController.CalculateCheckoutPreview(r.Context(), owner, req)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, "Lỗi tính toán", err.Error())
 		return
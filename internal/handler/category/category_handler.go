@@ -264,3 +264,193 @@ func (h *categoryHandler) UserSearchCategories(w http.ResponseWriter, r *http.Re
 
 	utils.WriteJSON(w, http.StatusOK, "Tìm kiếm thành công", cats)
 }
+
+// MoveCategory: Chuyển danh mục sang cha khác / đổi vị trí
+func (h *categoryHandler) MoveCategory(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "ID không hợp lệ", "ID phải là số nguyên")
+		return
+	}
+
+	var req model.MoveCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu JSON không hợp lệ", err.Error())
+		return
+	}
+
+	if errs := validator.Validate(req); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errs)
+		return
+	}
+
+	res, err := h.CategoryController.MoveCategory(id, req)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "không tìm thấy"):
+			utils.WriteError(w, http.StatusNotFound, "Không tìm thấy danh mục", err.Error())
+		case strings.Contains(err.Error(), "không hợp lệ"):
+			utils.WriteError(w, http.StatusBadRequest, "Không thể chuyển danh mục", err.Error())
+		default:
+			utils.WriteError(w, http.StatusInternalServerError, "Lỗi chuyển danh mục", err.Error())
+		}
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Chuyển danh mục thành công", res)
+}
+
+// AdminGetCategoryTree: Cây danh mục đầy đủ (cả nhánh ẩn)
+func (h *categoryHandler) AdminGetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.CategoryController.GetCategoryTree(false)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Lỗi lấy cây danh mục", err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Lấy cây danh mục thành công", tree)
+}
+
+// UserGetCategoryTree: Cây danh mục cho menu (Chỉ Active)
+func (h *categoryHandler) UserGetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.CategoryController.GetCategoryTree(true)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Lỗi lấy cây danh mục", err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Lấy cây danh mục thành công", tree)
+}
+
+// attributeErrorStatus - Chọn HTTP status theo nội dung lỗi của nghiệp vụ thông số
+func attributeErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "không tìm thấy"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "đã tồn tại"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "không hợp lệ"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// parseAttributePath - Lấy {id} và {attrId} từ URL
+func parseAttributePath(r *http.Request) (int64, int64, bool) {
+	categoryID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	attrID, err := strconv.ParseInt(r.PathValue("attrId"), 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return categoryID, attrID, true
+}
+
+// AdminGetCategoryAttributes: Schema thông số của danh mục (gồm thông số kế thừa)
+func (h *categoryHandler) AdminGetCategoryAttributes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "ID không hợp lệ", "ID phải là số nguyên")
+		return
+	}
+
+	attrs, err := h.CategoryController.GetCategoryAttributes(id, false)
+	if err != nil {
+		utils.WriteError(w, attributeErrorStatus(err), "Lỗi lấy thông số danh mục", err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Lấy thông số danh mục thành công", attrs)
+}
+
+// CreateCategoryAttribute: Thêm thông số cho danh mục
+func (h *categoryHandler) CreateCategoryAttribute(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "ID không hợp lệ", "ID phải là số nguyên")
+		return
+	}
+
+	var req model.CreateCategoryAttributeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu JSON không hợp lệ", err.Error())
+		return
+	}
+
+	if errs := validator.Validate(req); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errs)
+		return
+	}
+
+	attr, err := h.CategoryController.CreateCategoryAttribute(id, req)
+	if err != nil {
+		utils.WriteError(w, attributeErrorStatus(err), "Lỗi thêm thông số", err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, "Thêm thông số thành công", attr)
+}
+
+// UpdateCategoryAttribute: Cập nhật thông số của danh mục
+func (h *categoryHandler) UpdateCategoryAttribute(w http.ResponseWriter, r *http.Request) {
+	categoryID, attrID, ok := parseAttributePath(r)
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID không hợp lệ", "ID phải là số nguyên")
+		return
+	}
+
+	var req model.UpdateCategoryAttributeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu JSON không hợp lệ", err.Error())
+		return
+	}
+
+	if errs := validator.Validate(req); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errs)
+		return
+	}
+
+	attr, err := h.CategoryController.UpdateCategoryAttribute(categoryID, attrID, req)
+	if err != nil {
+		utils.WriteError(w, attributeErrorStatus(err), "Lỗi cập nhật thông số", err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Cập nhật thông số thành công", attr)
+}
+
+// DeleteCategoryAttribute: Xóa thông số của danh mục
+func (h *categoryHandler) DeleteCategoryAttribute(w http.ResponseWriter, r *http.Request) {
+	categoryID, attrID, ok := parseAttributePath(r)
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID không hợp lệ", "ID phải là số nguyên")
+		return
+	}
+
+	if err := h.CategoryController.DeleteCategoryAttribute(categoryID, attrID); err != nil {
+		utils.WriteError(w, attributeErrorStatus(err), "Lỗi xóa thông số", err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Xóa thông số thành công", nil)
+}
+
+// UserGetCategoryFilters: Các thông số dùng để lọc sản phẩm của danh mục
+func (h *categoryHandler) UserGetCategoryFilters(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "ID không hợp lệ", "ID phải là số nguyên")
+		return
+	}
+
+	attrs, err := h.CategoryController.GetCategoryAttributes(id, true)
+	if err != nil {
+		utils.WriteError(w, attributeErrorStatus(err), "Lỗi lấy bộ lọc danh mục", err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Lấy bộ lọc danh mục thành công", attrs)
+}
@@ -21,7 +21,19 @@ type CategoryHandler interface {
 
 	AdminSearchCategories(w http.ResponseWriter, r *http.Request)	// Tìm kiếm danh mục
 
+	MoveCategory(w http.ResponseWriter, r *http.Request)			// Chuyển danh mục sang cha khác / đổi vị trí
+
+	AdminGetCategoryTree(w http.ResponseWriter, r *http.Request)	// Cây danh mục đầy đủ
+
+	// Thông số kỹ thuật của danh mục
+	AdminGetCategoryAttributes(w http.ResponseWriter, r *http.Request)	// Schema thông số (kể cả kế thừa)
+	CreateCategoryAttribute(w http.ResponseWriter, r *http.Request)		// Thêm thông số
+	UpdateCategoryAttribute(w http.ResponseWriter, r *http.Request)		// Cập nhật thông số
+	DeleteCategoryAttribute(w http.ResponseWriter, r *http.Request)		// Xóa thông số
+
 	// User
 	UserGetActiveCategories(w http.ResponseWriter, r *http.Request)	// Lấy danh mục đang hoạt động
 	UserSearchCategories(w http.ResponseWriter, r *http.Request)	// Tìm kiếm danh mục đang hoạt động
+	UserGetCategoryTree(w http.ResponseWriter, r *http.Request)		// Cây danh mục đang hoạt động
+	UserGetCategoryFilters(w http.ResponseWriter, r *http.Request)	// Thông số lọc sản phẩm của danh mục
 }
\ No newline at end of file
@@ -0,0 +1,248 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang/internal/controller/export"
+	"golang/internal/logger"
+	"golang/internal/model"
+	"golang/internal/utils"
+	"golang/internal/validator"
+)
+
+// Thời gian tối đa ghi 1 file ra response (server mặc định chỉ cho 10 giây)
+const exportWriteTimeout = 5 * time.Minute
+
+type exportHandler struct {
+	ExportController export.ExportController
+}
+
+func NewExportHandler(controller export.ExportController) ExportHandler {
+	return &exportHandler{
+		ExportController: controller,
+	}
+}
+
+// Helper: Lấy UserID từ Context
+func getUserIDFromContext(r *http.Request) int64 {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		return 0
+	}
+	return userID
+}
+
+// parseExportRequest: ?format=csv|xlsx&async=true
+func parseExportRequest(r *http.Request) (model.ExportRequest, map[string]string) {
+	query := r.URL.Query()
+	req := model.ExportRequest{Format: query.Get("format")}
+	if val := query.Get("async"); val != "" {
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return req, map[string]string{"async": "async phải là true hoặc false"}
+		}
+		req.Async = b
+	}
+	return req, validator.Validate(req)
+}
+
+// exportFunc: Gọi controller tương ứng với writer stream đã chuẩn bị
+type exportFunc func(start export.StreamStarter) (*model.ExportJobResponse, error)
+
+// serveExport: Stream file nếu controller export trực tiếp, trả 202 + job nếu chạy nền
+func serveExport(w http.ResponseWriter, run exportFunc) {
+	started := false
+	start := func(fileName string, format string) io.Writer {
+		started = true
+		// Nới thời gian ghi cho request này, file lớn hơn thì đã chuyển sang job nền
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil {
+			logger.WarnLogger.Printf("ExportHandler: Cannot extend write deadline: %v", err)
+		}
+		w.Header().Set("Content-Type", utils.TableContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+		w.WriteHeader(http.StatusOK)
+		return w
+	}
+
+	job, err := run(start)
+	if err != nil {
+		if started {
+			// Header đã gửi, chỉ có thể ghi log (file tải về sẽ bị thiếu dòng)
+			return
+		}
+		if errors.Is(err, export.ErrExportTooManyRows) {
+			utils.WriteError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "Lỗi export dữ liệu", err.Error())
+		return
+	}
+	if job != nil {
+		utils.WriteJSON(w, http.StatusAccepted, "Dữ liệu lớn, đang tạo file export", job)
+	}
+}
+
+// Export đơn hàng (?status=&payment_status=&order_id=&keyword=&user_id=&start_date=&end_date=&format=&async=)
+func (h *exportHandler) ExportOrders(w http.ResponseWriter, r *http.Request) {
+	req, errs := parseExportRequest(r)
+	if errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số export không hợp lệ", errs)
+		return
+	}
+
+	query := r.URL.Query()
+	targetUserID, _ := strconv.ParseInt(query.Get("user_id"), 10, 64)
+	filter := model.OrderFilter{
+		// Export lấy toàn bộ, Page / Limit chỉ để qua validate
+		Page:          1,
+		Limit:         100,
+		Status:        query.Get("status"),
+		PaymentStatus: query.Get("payment_status"),
+		OrderID:       query.Get("order_id"),
+		Keyword:       query.Get("keyword"),
+		UserID:        targetUserID,
+		StartDate:     query.Get("start_date"),
+		EndDate:       query.Get("end_date"),
+	}
+	if errs := validator.Validate(filter); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số lọc không hợp lệ", errs)
+		return
+	}
+
+	adminID := getUserIDFromContext(r)
+	serveExport(w, func(start export.StreamStarter) (*model.ExportJobResponse, error) {
+		return h.ExportController.ExportOrders(r.Context(), adminID, filter, req, start)
+	})
+}
+
+// Export sản phẩm bán chạy (?start_date=&end_date=&limit=&format=&async=), không có limit thì lấy tất cả
+func (h *exportHandler) ExportTopProducts(w http.ResponseWriter, r *http.Request) {
+	req, errs := parseExportRequest(r)
+	if errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số export không hợp lệ", errs)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	filter := model.StatsFilter{
+		StartDate: query.Get("start_date"),
+		EndDate:   query.Get("end_date"),
+		Limit:     limit,
+	}
+	if errs := validator.Validate(filter); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số lọc không hợp lệ", errs)
+		return
+	}
+
+	adminID := getUserIDFromContext(r)
+	serveExport(w, func(start export.StreamStarter) (*model.ExportJobResponse, error) {
+		return h.ExportController.ExportTopProducts(r.Context(), adminID, filter, req, start)
+	})
+}
+
+// Export user (?keyword=&role=&is_active=&is_deleted=&format=&async=)
+func (h *exportHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	req, errs := parseExportRequest(r)
+	if errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số export không hợp lệ", errs)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := model.UserFilter{
+		Keyword: query.Get("keyword"),
+		Role:    query.Get("role"),
+		// Export lấy toàn bộ, Page / Limit chỉ để qua validate
+		Page:  1,
+		Limit: 100,
+	}
+	if val := query.Get("is_active"); val != "" {
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Tham số không hợp lệ", err.Error())
+			return
+		}
+		filter.IsActive = &b
+	}
+	if val := query.Get("is_deleted"); val != "" {
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Tham số không hợp lệ", err.Error())
+			return
+		}
+		filter.IsDeleted = &b
+	}
+	if errs := validator.Validate(filter); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số lọc không hợp lệ", errs)
+		return
+	}
+
+	adminID := getUserIDFromContext(r)
+	serveExport(w, func(start export.StreamStarter) (*model.ExportJobResponse, error) {
+		return h.ExportController.ExportUsers(r.Context(), adminID, filter, req, start)
+	})
+}
+
+// Trạng thái job
+func (h *exportHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "ID job không hợp lệ", nil)
+		return
+	}
+
+	job, err := h.ExportController.GetJob(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, export.ErrExportJobNotFound) {
+			utils.WriteError(w, http.StatusNotFound, err.Error(), nil)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "Lỗi lấy trạng thái export", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", job)
+}
+
+// Tải file export
+func (h *exportHandler) DownloadJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "ID job không hợp lệ", nil)
+		return
+	}
+
+	job, file, err := h.ExportController.OpenJobFile(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, export.ErrExportJobNotFound):
+			utils.WriteError(w, http.StatusNotFound, err.Error(), nil)
+		case errors.Is(err, export.ErrExportNotReady), errors.Is(err, export.ErrExportJobFailed):
+			utils.WriteError(w, http.StatusConflict, err.Error(), nil)
+		case errors.Is(err, export.ErrExportExpired):
+			utils.WriteError(w, http.StatusGone, err.Error(), nil)
+		default:
+			utils.WriteError(w, http.StatusInternalServerError, "Lỗi tải file export", err.Error())
+		}
+		return
+	}
+	defer file.Close()
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil {
+		logger.WarnLogger.Printf("ExportHandler: Cannot extend write deadline: %v", err)
+	}
+	w.Header().Set("Content-Type", utils.TableContentType(job.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, job.FileName))
+
+	// ServeContent hỗ trợ Range (tải tiếp khi bị ngắt)
+	var modTime time.Time
+	if job.FinishedAt != nil {
+		modTime = *job.FinishedAt
+	}
+	http.ServeContent(w, r, job.FileName, modTime, file)
+}
@@ -0,0 +1,20 @@
+package export
+
+import "net/http"
+
+type ExportHandler interface {
+	// Export đơn hàng (kèm dòng sản phẩm) theo bộ lọc của màn hình đơn hàng
+	ExportOrders(w http.ResponseWriter, r *http.Request)
+
+	// Export doanh số sản phẩm bán chạy
+	ExportTopProducts(w http.ResponseWriter, r *http.Request)
+
+	// Export danh sách user
+	ExportUsers(w http.ResponseWriter, r *http.Request)
+
+	// Trạng thái job export chạy nền
+	GetJob(w http.ResponseWriter, r *http.Request)
+
+	// Tải file của job đã xong
+	DownloadJob(w http.ResponseWriter, r *http.Request)
+}
@@ -0,0 +1,88 @@
+package invoice
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"golang/internal/controller/invoice"
+	"golang/internal/model"
+	"golang/internal/utils"
+)
+
+type invoiceHandler struct {
+	InvoiceController invoice.InvoiceController
+}
+
+func NewInvoiceHandler(controller invoice.InvoiceController) InvoiceHandler {
+	return &invoiceHandler{
+		InvoiceController: controller,
+	}
+}
+
+// Helper: Lấy UserID từ Context
+func getUserIDFromContext(r *http.Request) int64 {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		return 0
+	}
+	return userID
+}
+
+func parseOrderID(r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// writeInvoice: Trả file PDF đã lưu (hoặc lỗi tương ứng)
+func writeInvoice(w http.ResponseWriter, r *http.Request, inv *model.Invoice, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, invoice.ErrOrderNotFound):
+			utils.WriteError(w, http.StatusNotFound, err.Error(), nil)
+		case errors.Is(err, invoice.ErrInvoiceForbidden):
+			utils.WriteError(w, http.StatusForbidden, err.Error(), nil)
+		case errors.Is(err, invoice.ErrInvoiceNotAvailable):
+			utils.WriteError(w, http.StatusConflict, err.Error(), nil)
+		default:
+			utils.WriteError(w, http.StatusInternalServerError, "Lỗi xuất hóa đơn", err.Error())
+		}
+		return
+	}
+
+	fileName := inv.InvoiceNumber + ".pdf"
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	http.ServeContent(w, r, fileName, inv.IssuedAt, bytes.NewReader(inv.PDF))
+}
+
+// User tải hóa đơn
+func (h *invoiceHandler) DownloadMyInvoice(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	orderID, ok := parseOrderID(r)
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID đơn hàng không hợp lệ", nil)
+		return
+	}
+
+	inv, err := h.InvoiceController.GetMyInvoice(r.Context(), userID, orderID)
+	writeInvoice(w, r, inv, err)
+}
+
+// Admin tải hóa đơn
+func (h *invoiceHandler) DownloadInvoice(w http.ResponseWriter, r *http.Request) {
+	orderID, ok := parseOrderID(r)
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID đơn hàng không hợp lệ", nil)
+		return
+	}
+
+	inv, err := h.InvoiceController.GetInvoice(r.Context(), orderID)
+	writeInvoice(w, r, inv, err)
+}
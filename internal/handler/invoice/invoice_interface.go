@@ -0,0 +1,11 @@
+package invoice
+
+import "net/http"
+
+type InvoiceHandler interface {
+	// User tải hóa đơn PDF của đơn hàng của mình
+	DownloadMyInvoice(w http.ResponseWriter, r *http.Request)
+
+	// Admin tải hóa đơn PDF của đơn hàng bất kỳ
+	DownloadInvoice(w http.ResponseWriter, r *http.Request)
+}
@@ -0,0 +1,57 @@
+package location
+
+import (
+	"net/http"
+
+	"golang/internal/controller/location"
+	"golang/internal/utils"
+)
+
+type locationHandler struct {
+	LocationController location.LocationController
+}
+
+func NewLocationHandler(lController location.LocationController) LocationHandler {
+	return &locationHandler{
+		LocationController: lController,
+	}
+}
+
+// ListProvinces: Danh sách tỉnh / thành phố (?q= lọc theo tên, không phân biệt dấu)
+func (h *locationHandler) ListProvinces(w http.ResponseWriter, r *http.Request) {
+	res := h.LocationController.ListProvinces(r.URL.Query().Get("q"))
+	utils.WriteJSON(w, http.StatusOK, "Lấy danh sách tỉnh / thành phố thành công", res)
+}
+
+// ListDistricts: Danh sách quận / huyện của tỉnh
+func (h *locationHandler) ListDistricts(w http.ResponseWriter, r *http.Request) {
+	res, err := h.LocationController.ListDistricts(r.PathValue("code"), r.URL.Query().Get("q"))
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Lỗi lấy danh sách quận / huyện", err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Lấy danh sách quận / huyện thành công", res)
+}
+
+// ListProvinceWards: Danh sách phường / xã trực thuộc tỉnh
+func (h *locationHandler) ListProvinceWards(w http.ResponseWriter, r *http.Request) {
+	res, err := h.LocationController.ListProvinceWards(r.PathValue("code"), r.URL.Query().Get("q"))
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Lỗi lấy danh sách phường / xã", err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Lấy danh sách phường / xã thành công", res)
+}
+
+// ListWards: Danh sách phường / xã của quận / huyện
+func (h *locationHandler) ListWards(w http.ResponseWriter, r *http.Request) {
+	res, err := h.LocationController.ListWards(r.PathValue("code"), r.URL.Query().Get("q"))
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Lỗi lấy danh sách phường / xã", err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Lấy danh sách phường / xã thành công", res)
+}
@@ -0,0 +1,11 @@
+package location
+
+import "net/http"
+
+// LocationHandler định nghĩa các API tra cứu đơn vị hành chính (dropdown địa chỉ)
+type LocationHandler interface {
+	ListProvinces(w http.ResponseWriter, r *http.Request)
+	ListDistricts(w http.ResponseWriter, r *http.Request)
+	ListProvinceWards(w http.ResponseWriter, r *http.Request)
+	ListWards(w http.ResponseWriter, r *http.Request)
+}
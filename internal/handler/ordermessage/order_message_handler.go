@@ -0,0 +1,271 @@
+package ordermessage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang/internal/controller/ordermessage"
+	"golang/internal/logger"
+	"golang/internal/model"
+	"golang/internal/utils"
+	"golang/internal/validator"
+)
+
+// Phần multipart giữ trong bộ nhớ, phần còn lại ghi ra file tạm
+const multipartMemory = 8 << 20
+
+// Thời gian tối đa đọc request có file đính kèm (server mặc định chỉ cho 10 giây)
+const uploadReadTimeout = 2 * time.Minute
+
+type orderMessageHandler struct {
+	MessageController ordermessage.OrderMessageController
+}
+
+func NewOrderMessageHandler(controller ordermessage.OrderMessageController) OrderMessageHandler {
+	return &orderMessageHandler{
+		MessageController: controller,
+	}
+}
+
+// Helper: Lấy UserID từ Context
+func getUserIDFromContext(r *http.Request) int64 {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		return 0
+	}
+	return userID
+}
+
+func parsePathID(r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// writeError: Map lỗi controller sang HTTP status
+func writeError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, ordermessage.ErrOrderNotFound), errors.Is(err, ordermessage.ErrAttachmentNotFound):
+		utils.WriteError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, ordermessage.ErrOrderForbidden):
+		utils.WriteError(w, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, ordermessage.ErrTooManyAttachments), errors.Is(err, ordermessage.ErrAttachmentType),
+		errors.Is(err, ordermessage.ErrCustomerInternalMsg):
+		utils.WriteError(w, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, ordermessage.ErrAttachmentTooLarge):
+		utils.WriteError(w, http.StatusRequestEntityTooLarge, err.Error(), nil)
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// sendFunc: Gọi controller với nội dung + file đính kèm đã đọc từ request
+type sendFunc func(req model.SendOrderMessageRequest, files []model.AttachmentUpload) (*model.OrderMessageResponse, error)
+
+// sendMessage: Nhận JSON {"body", "visibility"} hoặc multipart/form-data (body, visibility, attachments[])
+func (h *orderMessageHandler) sendMessage(w http.ResponseWriter, r *http.Request, send sendFunc) {
+	var req model.SendOrderMessageRequest
+	var files []model.AttachmentUpload
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := http.NewResponseController(w).SetReadDeadline(time.Now().Add(uploadReadTimeout)); err != nil {
+			logger.WarnLogger.Printf("OrderMessageHandler: Cannot extend read deadline: %v", err)
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, h.MessageController.MaxRequestBytes())
+		if err := r.ParseMultipartForm(multipartMemory); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				utils.WriteError(w, http.StatusRequestEntityTooLarge, ordermessage.ErrAttachmentTooLarge.Error(), nil)
+				return
+			}
+			utils.WriteError(w, http.StatusBadRequest, "Dữ liệu form lỗi", err.Error())
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		req.Body = r.FormValue("body")
+		req.Visibility = r.FormValue("visibility")
+		for _, fh := range r.MultipartForm.File["attachments"] {
+			f, err := fh.Open()
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "Không đọc được file đính kèm", err.Error())
+				return
+			}
+			defer f.Close()
+			files = append(files, model.AttachmentUpload{FileName: fh.Filename, Size: fh.Size, Content: f})
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu JSON lỗi", err.Error())
+		return
+	}
+
+	req.Body = strings.TrimSpace(req.Body)
+	if errs := validator.Validate(req); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu không hợp lệ", errs)
+		return
+	}
+
+	msg, err := send(req, files)
+	if err != nil {
+		writeError(w, "Gửi tin nhắn thất bại", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, "Gửi tin nhắn thành công", msg)
+}
+
+// serveAttachment: Trả file đính kèm (ảnh / pdf xem trực tiếp trên trình duyệt)
+func serveAttachment(w http.ResponseWriter, att *model.OrderMessageAttachment, file io.ReadCloser) {
+	defer file.Close()
+	w.Header().Set("Content-Type", att.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(att.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename*=UTF-8''%s`, url.PathEscape(att.FileName)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, file)
+}
+
+// Khách xem tin nhắn
+func (h *orderMessageHandler) GetMyMessages(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	orderID, ok := parsePathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID đơn hàng không hợp lệ", nil)
+		return
+	}
+
+	messages, err := h.MessageController.GetMyMessages(r.Context(), userID, orderID)
+	if err != nil {
+		writeError(w, "Lỗi lấy tin nhắn", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Lấy tin nhắn thành công", messages)
+}
+
+// Khách gửi tin nhắn
+func (h *orderMessageHandler) SendMyMessage(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	orderID, ok := parsePathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID đơn hàng không hợp lệ", nil)
+		return
+	}
+
+	h.sendMessage(w, r, func(req model.SendOrderMessageRequest, files []model.AttachmentUpload) (*model.OrderMessageResponse, error) {
+		return h.MessageController.SendMyMessage(r.Context(), userID, orderID, req, files)
+	})
+}
+
+// Khách tải file đính kèm
+func (h *orderMessageHandler) DownloadMyAttachment(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	orderID, ok := parsePathID(r, "id")
+	attachmentID, ok2 := parsePathID(r, "attachmentId")
+	if !ok || !ok2 {
+		utils.WriteError(w, http.StatusBadRequest, "ID không hợp lệ", nil)
+		return
+	}
+
+	att, file, err := h.MessageController.OpenMyAttachment(r.Context(), userID, orderID, attachmentID)
+	if err != nil {
+		writeError(w, "Lỗi tải file đính kèm", err)
+		return
+	}
+	serveAttachment(w, att, file)
+}
+
+// Số tin chưa đọc của khách
+func (h *orderMessageHandler) GetMyUnread(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	unread, err := h.MessageController.GetMyUnread(r.Context(), userID)
+	if err != nil {
+		writeError(w, "Lỗi lấy số tin chưa đọc", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Số tin nhắn chưa đọc", unread)
+}
+
+// Admin xem tin nhắn
+func (h *orderMessageHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
+	orderID, ok := parsePathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID đơn hàng không hợp lệ", nil)
+		return
+	}
+
+	messages, err := h.MessageController.GetMessages(r.Context(), orderID)
+	if err != nil {
+		writeError(w, "Lỗi lấy tin nhắn", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Lấy tin nhắn thành công", messages)
+}
+
+// Admin gửi tin nhắn / ghi chú nội bộ
+func (h *orderMessageHandler) SendStaffMessage(w http.ResponseWriter, r *http.Request) {
+	adminID := getUserIDFromContext(r)
+	if adminID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	orderID, ok := parsePathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID đơn hàng không hợp lệ", nil)
+		return
+	}
+
+	h.sendMessage(w, r, func(req model.SendOrderMessageRequest, files []model.AttachmentUpload) (*model.OrderMessageResponse, error) {
+		return h.MessageController.SendStaffMessage(r.Context(), adminID, orderID, req, files)
+	})
+}
+
+// Admin tải file đính kèm
+func (h *orderMessageHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	orderID, ok := parsePathID(r, "id")
+	attachmentID, ok2 := parsePathID(r, "attachmentId")
+	if !ok || !ok2 {
+		utils.WriteError(w, http.StatusBadRequest, "ID không hợp lệ", nil)
+		return
+	}
+
+	att, file, err := h.MessageController.OpenAttachment(r.Context(), orderID, attachmentID)
+	if err != nil {
+		writeError(w, "Lỗi tải file đính kèm", err)
+		return
+	}
+	serveAttachment(w, att, file)
+}
+
+// Số tin khách gửi mà nhân viên chưa đọc
+func (h *orderMessageHandler) GetStaffUnread(w http.ResponseWriter, r *http.Request) {
+	unread, err := h.MessageController.GetStaffUnread(r.Context())
+	if err != nil {
+		writeError(w, "Lỗi lấy số tin chưa đọc", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Số tin nhắn chưa đọc", unread)
+}
@@ -0,0 +1,17 @@
+package ordermessage
+
+import "net/http"
+
+type OrderMessageHandler interface {
+	// Khách: xem / gửi tin nhắn của đơn hàng, tải file đính kèm, số tin chưa đọc
+	GetMyMessages(w http.ResponseWriter, r *http.Request)
+	SendMyMessage(w http.ResponseWriter, r *http.Request)
+	DownloadMyAttachment(w http.ResponseWriter, r *http.Request)
+	GetMyUnread(w http.ResponseWriter, r *http.Request)
+
+	// Admin: xem / gửi tin nhắn và ghi chú nội bộ, tải file đính kèm, số tin chưa đọc
+	GetMessages(w http.ResponseWriter, r *http.Request)
+	SendStaffMessage(w http.ResponseWriter, r *http.Request)
+	DownloadAttachment(w http.ResponseWriter, r *http.Request)
+	GetStaffUnread(w http.ResponseWriter, r *http.Request)
+}
@@ -2,12 +2,16 @@ package product
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"golang/internal/controller/product"
+	productVariantController "golang/internal/controller/productvariant"
 	"golang/internal/model"
 	"golang/internal/validator"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
 // ProductHandler - Struct xử lý các HTTP request liên quan đến sản phẩm
@@ -52,6 +56,10 @@ func (h *productHandler) CreateProductHandler(w http.ResponseWriter, r *http.Req
 			h.errJson(w, http.StatusConflict, err.Error())
 			return
 		}
+		if strings.HasPrefix(err.Error(), "Invalid attributes") {
+			h.errJson(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		h.errJson(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -84,6 +92,10 @@ func (h *productHandler) UpdateProductHandler(w http.ResponseWriter, r *http.Req
 			h.errJson(w, http.StatusNotFound, err.Error())
 			return
 		}
+		if strings.HasPrefix(err.Error(), "Invalid attributes") {
+			h.errJson(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		h.errJson(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -233,16 +245,35 @@ func (h *productHandler) UserSearchProductHandler(w http.ResponseWriter, r *http
 		categoryID = parsedID
 	}
 
+	minRating, err := parseMinRating(r.URL.Query().Get("min_rating"))
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// [VALIDATION]: Ít nhất phải có 1 tham số tìm kiếm
-	if searchParam == "" && brandParam == "" && categoryID == 0 {
-		h.errJson(w, http.StatusBadRequest, "At least one search parameter (name, brand, or category_id) is required")
+	if searchParam == "" && brandParam == "" && categoryID == 0 && minRating == 0 && !hasAttributeFilters(r.URL.Query()) {
+		h.errJson(w, http.StatusBadRequest, "At least one search parameter (name, brand, category_id or min_rating) is required")
+		return
+	}
+
+	// ?include_descendants=true -> lọc cả danh mục con cháu
+	includeDescendants, _ := strconv.ParseBool(r.URL.Query().Get("include_descendants"))
+
+	attributeFilters, err := parseAttributeFilters(r.URL.Query())
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	req := &model.SearchProductsRequest{
-		Search:     searchParam,
-		Brand:      brandParam,
-		CategoryID: categoryID,
+		Search:             searchParam,
+		Brand:              brandParam,
+		CategoryID:         categoryID,
+		IncludeDescendants: includeDescendants,
+		AttributeFilters:   attributeFilters,
+		MinRating:          minRating,
+		Sort:               r.URL.Query().Get("sort"), // ?sort=newest|rating|reviews
 	}
 
 	// Validate struct nếu cần (tùy logic validator của bạn)
@@ -277,15 +308,34 @@ func (h *productHandler) AdminSearchProductsHandler(w http.ResponseWriter, r *ht
 		categoryID = parsedID
 	}
 
-	if searchParam == "" && brandParam == "" && categoryID == 0 {
-		h.errJson(w, http.StatusBadRequest, "At least one search parameter (name, brand, or category_id) is required")
+	minRating, err := parseMinRating(r.URL.Query().Get("min_rating"))
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if searchParam == "" && brandParam == "" && categoryID == 0 && minRating == 0 && !hasAttributeFilters(r.URL.Query()) {
+		h.errJson(w, http.StatusBadRequest, "At least one search parameter (name, brand, category_id or min_rating) is required")
+		return
+	}
+
+	// ?include_descendants=true -> lọc cả danh mục con cháu
+	includeDescendants, _ := strconv.ParseBool(r.URL.Query().Get("include_descendants"))
+
+	attributeFilters, err := parseAttributeFilters(r.URL.Query())
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	req := &model.SearchProductsRequest{
-		Search:     searchParam,
-		Brand:      brandParam,
-		CategoryID: categoryID,
+		Search:             searchParam,
+		Brand:              brandParam,
+		CategoryID:         categoryID,
+		IncludeDescendants: includeDescendants,
+		AttributeFilters:   attributeFilters,
+		MinRating:          minRating,
+		Sort:               r.URL.Query().Get("sort"), // ?sort=newest|rating|reviews
 	}
 
 	if err := validator.Validate(req); err != nil {
@@ -368,12 +418,252 @@ func (h *productHandler) AdminGetAllSoftDeletedProductsHandler(w http.ResponseWr
 	h.writeJson(w, http.StatusOK, productsResponse)
 }
 
-// AdminDeleteAllProductsHandler - Xóa cứng tất cả (Nguy hiểm)
+// AdminDeleteAllProductsHandler - Dọn sạch thùng rác (Xóa cứng)
 func (h *productHandler) AdminDeleteAllProductsHandler(w http.ResponseWriter, r *http.Request) {
-	err := h.PrtController.AdminDeleteAllProductsController()
+	response, err := h.PrtController.AdminDeleteAllProductsController()
 	if err != nil {
 		h.errJson(w, http.StatusInternalServerError, "Cannot delete all products")
 		return
 	}
-	h.writeJson(w, http.StatusOK, map[string]string{"message": "All products deleted successfully"})
+	h.writeJson(w, http.StatusOK, response)
+}
+
+// AdminHardDeleteProductHandler - Xóa cứng 1 SP trong thùng rác
+func (h *productHandler) AdminHardDeleteProductHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, "Invalid product ID in path")
+		return
+	}
+
+	response, err := h.PrtController.AdminHardDeleteProductController(id)
+	if err != nil {
+		switch err.Error() {
+		case "Product not found in trash":
+			h.errJson(w, http.StatusNotFound, err.Error())
+		case "Product is referenced by active orders":
+			// Trả về danh sách đơn hàng đang chặn việc xóa
+			h.writeJson(w, http.StatusConflict, map[string]any{"error": err.Error(), "blocked": response.Blocked})
+		default:
+			h.errJson(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	h.writeJson(w, http.StatusOK, response)
+}
+
+// AdminRestoreProductHandler - Khôi phục 1 SP từ thùng rác
+func (h *productHandler) AdminRestoreProductHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, "Invalid product ID in path")
+		return
+	}
+
+	response, err := h.PrtController.AdminRestoreProductsController(r.Context(), []int64{id})
+	if err != nil {
+		h.errJson(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(response.Restored) == 0 {
+		h.writeJson(w, http.StatusConflict, response)
+		return
+	}
+	h.writeJson(w, http.StatusOK, response)
+}
+
+// AdminBulkRestoreProductsHandler - Khôi phục nhiều SP từ thùng rác
+func (h *productHandler) AdminBulkRestoreProductsHandler(w http.ResponseWriter, r *http.Request) {
+	var req model.RestoreProductsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errJson(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := validator.Validate(req); err != nil {
+		h.errJson(w, http.StatusBadRequest, fmt.Sprintf("Validation failed: %v", err))
+		return
+	}
+
+	response, err := h.PrtController.AdminRestoreProductsController(r.Context(), req.IDs)
+	if err != nil {
+		h.errJson(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJson(w, http.StatusOK, response)
+}
+
+// AdminGetScheduledChangesHandler - Danh sách publish / unpublish sắp diễn ra
+func (h *productHandler) AdminGetScheduledChangesHandler(w http.ResponseWriter, r *http.Request) {
+	response, err := h.PrtController.AdminGetScheduledChangesController()
+	if err != nil {
+		h.errJson(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJson(w, http.StatusOK, response)
+}
+
+// AdminRecomputePricesHandler - Tính lại min_price / max_price cho toàn bộ sản phẩm
+func (h *productHandler) AdminRecomputePricesHandler(w http.ResponseWriter, r *http.Request) {
+	response, err := h.PrtController.AdminRecomputePricesController(r.Context())
+	if err != nil {
+		h.errJson(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJson(w, http.StatusOK, response)
+}
+
+// AdminRevertProductHistoryHandler - Khôi phục giá trị cũ từ một bản ghi lịch sử
+func (h *productHandler) AdminRevertProductHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, "Invalid product ID in path")
+		return
+	}
+	historyID, err := strconv.ParseInt(r.PathValue("historyId"), 10, 64)
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, "Invalid history ID in path")
+		return
+	}
+
+	response, err := h.PrtController.RevertProductHistoryController(r.Context(), productID, historyID)
+	if err != nil {
+		switch err.Error() {
+		case "History not found", "Product not found", "Variant no longer exists":
+			h.errJson(w, http.StatusNotFound, err.Error())
+		case "Variant has been deleted":
+			h.errJson(w, http.StatusGone, err.Error())
+		case "Cannot revert variant creation":
+			h.errJson(w, http.StatusConflict, err.Error())
+		case "Nothing to revert":
+			h.errJson(w, http.StatusBadRequest, err.Error())
+		default:
+			if errors.Is(err, productVariantController.ErrInvalidOptions) {
+				h.errJson(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			if errors.Is(err, productVariantController.ErrVariantConflict) {
+				h.errJson(w, http.StatusConflict, err.Error())
+				return
+			}
+			h.errJson(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	h.writeJson(w, http.StatusOK, response)
+}
+
+// hasAttributeFilters - Query có tham số lọc thông số (attr.<code>) hay không
+func hasAttributeFilters(query url.Values) bool {
+	for key := range query {
+		if strings.HasPrefix(key, "attr.") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAttributeFilters - Đọc bộ lọc thông số: ?attr.ram=8,12 (1 trong các giá trị) | ?attr.screen_size=6..7 (khoảng số, có thể bỏ 1 đầu)
+func parseAttributeFilters(query url.Values) ([]model.AttributeFilter, error) {
+	filters := []model.AttributeFilter{}
+	for key, vals := range query {
+		if !strings.HasPrefix(key, "attr.") {
+			continue
+		}
+		code := strings.TrimPrefix(key, "attr.")
+		raw := strings.TrimSpace(vals[0])
+		if code == "" || raw == "" {
+			return nil, fmt.Errorf("Invalid attribute filter '%s'", key)
+		}
+
+		f := model.AttributeFilter{Code: code}
+		if lo, hi, isRange := strings.Cut(raw, ".."); isRange {
+			if lo != "" {
+				n, err := strconv.ParseFloat(lo, 64)
+				if err != nil {
+					return nil, fmt.Errorf("Invalid range for attribute '%s'", code)
+				}
+				f.Min = &n
+			}
+			if hi != "" {
+				n, err := strconv.ParseFloat(hi, 64)
+				if err != nil {
+					return nil, fmt.Errorf("Invalid range for attribute '%s'", code)
+				}
+				f.Max = &n
+			}
+		} else {
+			for _, v := range strings.Split(raw, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					f.Values = append(f.Values, v)
+				}
+			}
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// parseMinRating - Đọc ?min_rating=4 (0..5), bỏ trống = không lọc
+func parseMinRating(raw string) (float64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseFloat(raw, 64)
+	if err != nil || n < 0 || n > 5 {
+		return 0, fmt.Errorf("Invalid min_rating (0 - 5)")
+	}
+	return n, nil
+}
+
+// AdminGetProductAttributesHandler - Lấy thông số kỹ thuật của SP
+func (h *productHandler) AdminGetProductAttributesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, "Invalid product ID in path")
+		return
+	}
+
+	response, err := h.PrtController.AdminGetProductAttributesController(id)
+	if err != nil {
+		if err.Error() == "Product not found" {
+			h.errJson(w, http.StatusNotFound, err.Error())
+			return
+		}
+		h.errJson(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJson(w, http.StatusOK, response)
+}
+
+// AdminSetProductAttributesHandler - Ghi đè thông số kỹ thuật của SP
+func (h *productHandler) AdminSetProductAttributesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, "Invalid product ID in path")
+		return
+	}
+
+	var req model.SetProductAttributesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errJson(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := validator.Validate(req); err != nil {
+		h.errJson(w, http.StatusBadRequest, fmt.Sprintf("Validation failed: %v", err))
+		return
+	}
+
+	response, err := h.PrtController.AdminSetProductAttributesController(r.Context(), id, req)
+	if err != nil {
+		switch {
+		case err.Error() == "Product not found":
+			h.errJson(w, http.StatusNotFound, err.Error())
+		case strings.HasPrefix(err.Error(), "Invalid attributes"):
+			h.errJson(w, http.StatusBadRequest, err.Error())
+		default:
+			h.errJson(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	h.writeJson(w, http.StatusOK, response)
 }
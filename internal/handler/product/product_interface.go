@@ -24,5 +24,23 @@ type ProductHandler interface {
 	AdminDeleteSoftProductHandler(w http.ResponseWriter, r *http.Request) 		// Xóa mềm sản phẩm
 	AdminBulkDeleteSoftProductsHandler(w http.ResponseWriter, r *http.Request)	// Xóa mềm nhiều sản phẩm
 	AdminGetAllSoftDeletedProductsHandler(w http.ResponseWriter, r *http.Request)	// Lấy tất cả sản phẩm đã xóa mềm 
-	AdminDeleteAllProductsHandler(w http.ResponseWriter, r *http.Request)			// Xóa cứng tất cả sản phẩm
+	AdminDeleteAllProductsHandler(w http.ResponseWriter, r *http.Request)			// Dọn sạch thùng rác (xóa cứng)
+	AdminHardDeleteProductHandler(w http.ResponseWriter, r *http.Request)			// Xóa cứng 1 sản phẩm trong thùng rác
+
+	// Thông số kỹ thuật
+	AdminGetProductAttributesHandler(w http.ResponseWriter, r *http.Request)		// Lấy thông số của sản phẩm
+	AdminSetProductAttributesHandler(w http.ResponseWriter, r *http.Request)		// Ghi đè thông số của sản phẩm
+
+	// Restore
+	AdminRestoreProductHandler(w http.ResponseWriter, r *http.Request)				// Khôi phục 1 sản phẩm
+	AdminBulkRestoreProductsHandler(w http.ResponseWriter, r *http.Request)			// Khôi phục nhiều sản phẩm
+
+	// Scheduled publish
+	AdminGetScheduledChangesHandler(w http.ResponseWriter, r *http.Request)		// Lịch publish / unpublish sắp tới
+
+	// Price range
+	AdminRecomputePricesHandler(w http.ResponseWriter, r *http.Request)			// Tính lại min/max price từ biến thể
+
+	// History
+	AdminRevertProductHistoryHandler(w http.ResponseWriter, r *http.Request)		// Khôi phục theo bản ghi lịch sử
 }
\ No newline at end of file
@@ -2,6 +2,7 @@ package productvariant
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"golang/internal/controller/productvariant"
 	"golang/internal/model"
@@ -47,8 +48,16 @@ func (h *VariantHandler) CreateVariantHandler(w http.ResponseWriter, r *http.Req
 		h.errJson(w, http.StatusBadRequest, fmt.Sprintf("Validation failed: %v", err))
 		return
 	}
-	variantReponse, err := h.VariantController.CreateVariant(req, productID)
+	variantReponse, err := h.VariantController.CreateVariant(r.Context(), req, productID)
 	if err != nil {
+		if errors.Is(err, productvariant.ErrInvalidOptions) {
+			h.errJson(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, productvariant.ErrVariantConflict) {
+			h.errJson(w, http.StatusConflict, err.Error())
+			return
+		}
 		fmt.Printf("Lỗi DB %v \n", err)
 		h.errJson(w, http.StatusInternalServerError, "Cannot create variant")
 		return
@@ -83,8 +92,16 @@ func (h *VariantHandler) UpdateVariantHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	variantResponse, err := h.VariantController.UpdateVariant(req, variantID, productID)
+	variantResponse, err := h.VariantController.UpdateVariant(r.Context(), req, variantID, productID)
 	if err != nil {
+		if errors.Is(err, productvariant.ErrInvalidOptions) {
+			h.errJson(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, productvariant.ErrVariantConflict) {
+			h.errJson(w, http.StatusConflict, err.Error())
+			return
+		}
 		fmt.Printf("Lỗi DB %v \n", err)
 		h.errJson(w, http.StatusInternalServerError, err.Error())
 		return
@@ -93,6 +110,44 @@ func (h *VariantHandler) UpdateVariantHandler(w http.ResponseWriter, r *http.Req
 	h.writeJson(w, http.StatusOK, variantResponse)
 }
 
+// AdjustVariantStockHandler - Nhập / xuất kho theo chênh lệch
+func (h *VariantHandler) AdjustVariantStockHandler(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, "Product ID invalid")
+		return
+	}
+
+	variantID, err := strconv.ParseInt(r.PathValue("variantId"), 10, 64)
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, "Variant ID invalid")
+		return
+	}
+
+	var req model.AdjustVariantStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errJson(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if err := validator.Validate(req); err != nil {
+		h.errJson(w, http.StatusBadRequest, fmt.Sprintf("Validation failed: %v", err))
+		return
+	}
+
+	response, err := h.VariantController.AdjustVariantStock(r.Context(), req, variantID, productID)
+	if err != nil {
+		if errors.Is(err, productvariant.ErrInvalidStockAdjust) {
+			h.errJson(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.errJson(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeJson(w, http.StatusOK, response)
+}
+
 // DeleteVariantHandler - Xử lý request xóa biến thể
 func (h *VariantHandler) DeleteVariantHandler(w http.ResponseWriter, r *http.Request) {
 	productIdStr := r.PathValue("id")
@@ -109,7 +164,7 @@ func (h *VariantHandler) DeleteVariantHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	response, err := h.VariantController.DeleteVariant(variantID, productID)
+	response, err := h.VariantController.DeleteVariant(r.Context(), variantID, productID)
 	if err != nil {
 		fmt.Printf("Lỗi DB %v \n", err)
 		h.errJson(w, http.StatusInternalServerError, err.Error())
@@ -118,3 +173,83 @@ func (h *VariantHandler) DeleteVariantHandler(w http.ResponseWriter, r *http.Req
 
 	h.writeJson(w, http.StatusOK, response)
 }
+
+// GetProductOptionsHandler - Lấy option của sản phẩm
+func (h *VariantHandler) GetProductOptionsHandler(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, "Product ID invalid")
+		return
+	}
+
+	response, err := h.VariantController.GetProductOptions(productID)
+	if err != nil {
+		h.errJson(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJson(w, http.StatusOK, response)
+}
+
+// SetProductOptionsHandler - Ghi đè option của sản phẩm
+func (h *VariantHandler) SetProductOptionsHandler(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, "Product ID invalid")
+		return
+	}
+
+	var req model.SetProductOptionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errJson(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+	if err := validator.Validate(req); err != nil {
+		h.errJson(w, http.StatusBadRequest, fmt.Sprintf("Validation failed: %v", err))
+		return
+	}
+
+	response, err := h.VariantController.SetProductOptions(req, productID)
+	if err != nil {
+		if errors.Is(err, productvariant.ErrInvalidOptions) {
+			h.errJson(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.errJson(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJson(w, http.StatusOK, response)
+}
+
+// GenerateVariantMatrixHandler - Sinh biến thể theo tổ hợp option
+func (h *VariantHandler) GenerateVariantMatrixHandler(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.errJson(w, http.StatusBadRequest, "Product ID invalid")
+		return
+	}
+
+	var req model.GenerateVariantMatrixRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errJson(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+	if err := validator.Validate(req); err != nil {
+		h.errJson(w, http.StatusBadRequest, fmt.Sprintf("Validation failed: %v", err))
+		return
+	}
+
+	response, err := h.VariantController.GenerateVariantMatrix(r.Context(), req, productID)
+	if err != nil {
+		if errors.Is(err, productvariant.ErrInvalidOptions) || errors.Is(err, productvariant.ErrMatrixTooLarge) {
+			h.errJson(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, productvariant.ErrVariantConflict) {
+			h.errJson(w, http.StatusConflict, err.Error())
+			return
+		}
+		h.errJson(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJson(w, http.StatusCreated, response)
+}
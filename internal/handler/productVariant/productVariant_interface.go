@@ -8,6 +8,14 @@ type ProductVariantHandler interface {
 	CreateVariantHandler(w http.ResponseWriter, r *http.Request)
 	// Cập nhật biến thể sản phẩm
 	UpdateVariantHandler(w http.ResponseWriter, r *http.Request)
+	// Điều chỉnh tồn kho biến thể
+	AdjustVariantStockHandler(w http.ResponseWriter, r *http.Request)
 	// Xóa biến thể sản phẩm
 	DeleteVariantHandler(w http.ResponseWriter, r *http.Request)
+	// Lấy option của sản phẩm
+	GetProductOptionsHandler(w http.ResponseWriter, r *http.Request)
+	// Ghi đè option của sản phẩm
+	SetProductOptionsHandler(w http.ResponseWriter, r *http.Request)
+	// Sinh biến thể theo tổ hợp option
+	GenerateVariantMatrixHandler(w http.ResponseWriter, r *http.Request)
 }
\ No newline at end of file
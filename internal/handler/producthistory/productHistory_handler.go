@@ -68,3 +68,27 @@ func (ph *productHistoryHandler) GetAllProductsHistoryHandler(w http.ResponseWri
 	ph.writeJson(w, http.StatusOK, histories)
 }
 
+// GetProductHistoryDiffHandler - Diff từng field của một bản ghi lịch sử
+func (ph *productHistoryHandler) GetProductHistoryDiffHandler(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		ph.errJson(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	historyID, err := strconv.ParseInt(r.PathValue("historyId"), 10, 64)
+	if err != nil {
+		ph.errJson(w, http.StatusBadRequest, "Invalid history ID")
+		return
+	}
+
+	diff, err := ph.HistoryController.GetProductHistoryDiffController(productID, historyID)
+	if err != nil {
+		if err.Error() == "History not found" {
+			ph.errJson(w, http.StatusNotFound, err.Error())
+			return
+		}
+		ph.errJson(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ph.writeJson(w, http.StatusOK, diff)
+}
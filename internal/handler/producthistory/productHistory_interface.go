@@ -5,4 +5,5 @@ import "net/http"
 type ProductHistoryHandler interface {
 	GetProductHistoryByProductIDHandler(w http.ResponseWriter, r *http.Request)
 	GetAllProductsHistoryHandler(w http.ResponseWriter, r *http.Request)
+	GetProductHistoryDiffHandler(w http.ResponseWriter, r *http.Request)
 }
@@ -2,6 +2,7 @@ package productreview
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"golang/internal/controller/productreviews"
 	"golang/internal/model"
@@ -60,14 +61,15 @@ func (h *productReviewHandler) CreateReviewHandler(w http.ResponseWriter, r *htt
 
 	resp, err := h.controller.CreateReview(r.Context(), req, productID, userID)
 	if err != nil {
-		h.errJson(w, http.StatusInternalServerError, err.Error())
+		h.reviewErrJson(w, err)
 		return
 	}
 
 	h.writeJson(w, http.StatusCreated, resp)
 }
 
-// ListReviewsHandler returns all reviews for a product along with summary
+// ListReviewsHandler returns a page of approved reviews for a product along with summary
+// (?sort=newest|highest|lowest|helpful&rating=5&with_text=true&limit=10&cursor=...)
 func (h *productReviewHandler) ListReviewsHandler(w http.ResponseWriter, r *http.Request) {
 	productIdStr := r.PathValue("id")
 	productID, err := strconv.ParseInt(productIdStr, 10, 64)
@@ -76,9 +78,34 @@ func (h *productReviewHandler) ListReviewsHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	resp, err := h.controller.ListReviews(productID)
+	query := r.URL.Query()
+	req := model.ReviewListQuery{
+		Sort:   query.Get("sort"),
+		Cursor: query.Get("cursor"),
+		Limit:  10,
+	}
+	if v := query.Get("rating"); v != "" {
+		if req.Rating, err = strconv.Atoi(v); err != nil {
+			h.errJson(w, http.StatusBadRequest, "Invalid rating")
+			return
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if req.Limit, err = strconv.Atoi(v); err != nil {
+			h.errJson(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+	req.WithText, _ = strconv.ParseBool(query.Get("with_text"))
+
+	if err := validator.Validate(req); err != nil {
+		h.errJson(w, http.StatusBadRequest, fmt.Sprintf("Validation failed: %v", err))
+		return
+	}
+
+	resp, err := h.controller.ListReviews(productID, req)
 	if err != nil {
-		h.errJson(w, http.StatusInternalServerError, err.Error())
+		h.reviewErrJson(w, err)
 		return
 	}
 
@@ -95,6 +122,240 @@ func (h *productReviewHandler) DeleteReviewHandler(w http.ResponseWriter, r *htt
 	}
 
 	resp, err := h.controller.DeleteReview(reviewID)
+	if err != nil {
+		h.reviewErrJson(w, err)
+		return
+	}
+
+	h.writeJson(w, http.StatusOK, resp)
+}
+
+// reviewErrJson maps controller errors to HTTP status codes
+func (h *productReviewHandler) reviewErrJson(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, productreviews.ErrReviewNotFound), errors.Is(err, productreviews.ErrProductNotFound),
+		errors.Is(err, productreviews.ErrVoteNotFound):
+		h.errJson(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, productreviews.ErrReviewForbidden), errors.Is(err, productreviews.ErrPurchaseRequired),
+		errors.Is(err, productreviews.ErrVoteOwnReview):
+		h.errJson(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, productreviews.ErrReviewExists), errors.Is(err, productreviews.ErrAlreadyVoted):
+		h.errJson(w, http.StatusConflict, err.Error())
+	case errors.Is(err, productreviews.ErrInvalidCursor):
+		h.errJson(w, http.StatusBadRequest, err.Error())
+	default:
+		h.errJson(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// parseReviewID reads {reviewId} from the path
+func parseReviewID(r *http.Request) (int64, bool) {
+	reviewID, err := strconv.ParseInt(r.PathValue("reviewId"), 10, 64)
+	if err != nil || reviewID <= 0 {
+		return 0, false
+	}
+	return reviewID, true
+}
+
+// UpdateMyReviewHandler lets the author edit their review (goes back to pending)
+func (h *productReviewHandler) UpdateMyReviewHandler(w http.ResponseWriter, r *http.Request) {
+	reviewID, ok := parseReviewID(r)
+	if !ok {
+		h.errJson(w, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok || userID == 0 {
+		h.errJson(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req model.UpdateProductReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errJson(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := validator.Validate(req); err != nil {
+		h.errJson(w, http.StatusBadRequest, fmt.Sprintf("Validation failed: %v", err))
+		return
+	}
+
+	resp, err := h.controller.UpdateOwnReview(reviewID, userID, req)
+	if err != nil {
+		h.reviewErrJson(w, err)
+		return
+	}
+
+	h.writeJson(w, http.StatusOK, resp)
+}
+
+// DeleteMyReviewHandler lets the author delete their review
+func (h *productReviewHandler) DeleteMyReviewHandler(w http.ResponseWriter, r *http.Request) {
+	reviewID, ok := parseReviewID(r)
+	if !ok {
+		h.errJson(w, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok || userID == 0 {
+		h.errJson(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	resp, err := h.controller.DeleteOwnReview(reviewID, userID)
+	if err != nil {
+		h.reviewErrJson(w, err)
+		return
+	}
+
+	h.writeJson(w, http.StatusOK, resp)
+}
+
+// MarkHelpfulHandler marks a review as helpful (one vote per user)
+func (h *productReviewHandler) MarkHelpfulHandler(w http.ResponseWriter, r *http.Request) {
+	reviewID, ok := parseReviewID(r)
+	if !ok {
+		h.errJson(w, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok || userID == 0 {
+		h.errJson(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	resp, err := h.controller.MarkHelpful(reviewID, userID)
+	if err != nil {
+		h.reviewErrJson(w, err)
+		return
+	}
+
+	h.writeJson(w, http.StatusOK, resp)
+}
+
+// UnmarkHelpfulHandler removes the user's helpful vote
+func (h *productReviewHandler) UnmarkHelpfulHandler(w http.ResponseWriter, r *http.Request) {
+	reviewID, ok := parseReviewID(r)
+	if !ok {
+		h.errJson(w, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok || userID == 0 {
+		h.errJson(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	resp, err := h.controller.UnmarkHelpful(reviewID, userID)
+	if err != nil {
+		h.reviewErrJson(w, err)
+		return
+	}
+
+	h.writeJson(w, http.StatusOK, resp)
+}
+
+// ReportReviewHandler reports a review (one report per user)
+func (h *productReviewHandler) ReportReviewHandler(w http.ResponseWriter, r *http.Request) {
+	reviewID, ok := parseReviewID(r)
+	if !ok {
+		h.errJson(w, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok || userID == 0 {
+		h.errJson(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req model.ReportReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errJson(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := validator.Validate(req); err != nil {
+		h.errJson(w, http.StatusBadRequest, fmt.Sprintf("Validation failed: %v", err))
+		return
+	}
+
+	resp, err := h.controller.ReportReview(reviewID, userID, req)
+	if err != nil {
+		h.reviewErrJson(w, err)
+		return
+	}
+
+	h.writeJson(w, http.StatusOK, resp)
+}
+
+// ModerateReviewHandler approves or rejects a review (admin)
+func (h *productReviewHandler) ModerateReviewHandler(w http.ResponseWriter, r *http.Request) {
+	reviewID, ok := parseReviewID(r)
+	if !ok {
+		h.errJson(w, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	var req model.ModerateReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errJson(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := validator.Validate(req); err != nil {
+		h.errJson(w, http.StatusBadRequest, fmt.Sprintf("Validation failed: %v", err))
+		return
+	}
+
+	resp, err := h.controller.ModerateReview(r.Context(), reviewID, req)
+	if err != nil {
+		h.reviewErrJson(w, err)
+		return
+	}
+
+	h.writeJson(w, http.StatusOK, resp)
+}
+
+// ModerationQueueHandler lists reviews by status (?status=pending&page=1&limit=20)
+func (h *productReviewHandler) ModerationQueueHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	status := query.Get("status")
+	if status == "" {
+		status = model.ReviewStatusPending
+	}
+	if status != model.ReviewStatusPending && status != model.ReviewStatusApproved && status != model.ReviewStatusRejected {
+		h.errJson(w, http.StatusBadRequest, "Invalid status (pending, approved, rejected)")
+		return
+	}
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	resp, err := h.controller.ListModerationQueue(status, page, limit)
+	if err != nil {
+		h.errJson(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeJson(w, http.StatusOK, resp)
+}
+
+// RecomputeRatingsHandler repairs drifted product rating aggregates (admin)
+func (h *productReviewHandler) RecomputeRatingsHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.controller.RecomputeRatings(r.Context())
 	if err != nil {
 		h.errJson(w, http.StatusInternalServerError, err.Error())
 		return
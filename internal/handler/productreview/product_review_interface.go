@@ -6,4 +6,18 @@ type ProductReviewHandler interface {
 	CreateReviewHandler(w http.ResponseWriter, r *http.Request)
 	ListReviewsHandler(w http.ResponseWriter, r *http.Request)
 	DeleteReviewHandler(w http.ResponseWriter, r *http.Request)
+
+	// Author manages own review
+	UpdateMyReviewHandler(w http.ResponseWriter, r *http.Request)
+	DeleteMyReviewHandler(w http.ResponseWriter, r *http.Request)
+
+	// Helpful votes & reports
+	MarkHelpfulHandler(w http.ResponseWriter, r *http.Request)
+	UnmarkHelpfulHandler(w http.ResponseWriter, r *http.Request)
+	ReportReviewHandler(w http.ResponseWriter, r *http.Request)
+
+	// Admin moderation
+	ModerateReviewHandler(w http.ResponseWriter, r *http.Request)
+	ModerationQueueHandler(w http.ResponseWriter, r *http.Request)
+	RecomputeRatingsHandler(w http.ResponseWriter, r *http.Request)
 }
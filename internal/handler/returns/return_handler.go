@@ -0,0 +1,419 @@
+package returns
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang/internal/controller/returns"
+	"golang/internal/logger"
+	"golang/internal/model"
+	"golang/internal/utils"
+	"golang/internal/validator"
+)
+
+// Phần multipart giữ trong bộ nhớ, phần còn lại ghi ra file tạm
+const multipartMemory = 8 << 20
+
+// Thời gian tối đa đọc request có ảnh (server mặc định chỉ cho 10 giây)
+const uploadReadTimeout = 2 * time.Minute
+
+type returnHandler struct {
+	ReturnController returns.ReturnController
+}
+
+func NewReturnHandler(controller returns.ReturnController) ReturnHandler {
+	return &returnHandler{
+		ReturnController: controller,
+	}
+}
+
+// Helper: Lấy UserID từ Context
+func getUserIDFromContext(r *http.Request) int64 {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		return 0
+	}
+	return userID
+}
+
+func parsePathID(r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// writeError: Map lỗi controller sang HTTP status
+func writeError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, returns.ErrOrderNotFound), errors.Is(err, returns.ErrReturnNotFound), errors.Is(err, returns.ErrPhotoNotFound):
+		utils.WriteError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, returns.ErrOrderForbidden), errors.Is(err, returns.ErrReturnForbidden):
+		utils.WriteError(w, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, returns.ErrTooManyPhotos), errors.Is(err, returns.ErrPhotoType),
+		errors.Is(err, returns.ErrItemNotInOrder), errors.Is(err, returns.ErrQuantityExceeded),
+		errors.Is(err, returns.ErrRefundExceeded), errors.Is(err, returns.ErrRefundAboveReturn),
+		errors.Is(err, returns.ErrOverrideNoteMissing):
+		utils.WriteError(w, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, returns.ErrOrderNotCompleted), errors.Is(err, returns.ErrReturnWindowExpired),
+		errors.Is(err, returns.ErrInvalidTransition), errors.Is(err, returns.ErrOrderNotPaid):
+		utils.WriteError(w, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, returns.ErrPhotoTooLarge):
+		utils.WriteError(w, http.StatusRequestEntityTooLarge, err.Error(), nil)
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// parseFilter: Query page, limit, status, order_id
+func parseFilter(r *http.Request) model.ReturnFilter {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	orderID, _ := strconv.ParseInt(query.Get("order_id"), 10, 64)
+	return model.ReturnFilter{
+		Status:  query.Get("status"),
+		OrderID: orderID,
+		Page:    page,
+		Limit:   limit,
+	}
+}
+
+// decodeJSON: Body rỗng coi như không truyền gì (note / amount đều không bắt buộc)
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil && err != io.EOF {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu JSON lỗi", err.Error())
+		return false
+	}
+	if errs := validator.Validate(dst); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu không hợp lệ", errs)
+		return false
+	}
+	return true
+}
+
+// servePhoto: Trả ảnh (xem trực tiếp trên trình duyệt)
+func servePhoto(w http.ResponseWriter, photo *model.ReturnPhoto, file io.ReadCloser) {
+	defer file.Close()
+	w.Header().Set("Content-Type", photo.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(photo.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename*=UTF-8''%s`, url.PathEscape(photo.FileName)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, file)
+}
+
+// Khách tạo yêu cầu trả hàng: JSON {"items", "reason"} hoặc multipart/form-data (items là chuỗi JSON, reason, photos[])
+func (h *returnHandler) CreateReturn(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	orderID, ok := parsePathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID đơn hàng không hợp lệ", nil)
+		return
+	}
+
+	var req model.CreateReturnRequest
+	var photos []model.AttachmentUpload
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := http.NewResponseController(w).SetReadDeadline(time.Now().Add(uploadReadTimeout)); err != nil {
+			logger.WarnLogger.Printf("ReturnHandler: Cannot extend read deadline: %v", err)
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, h.ReturnController.MaxRequestBytes())
+		if err := r.ParseMultipartForm(multipartMemory); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				utils.WriteError(w, http.StatusRequestEntityTooLarge, returns.ErrPhotoTooLarge.Error(), nil)
+				return
+			}
+			utils.WriteError(w, http.StatusBadRequest, "Dữ liệu form lỗi", err.Error())
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		req.Reason = r.FormValue("reason")
+		if items := r.FormValue("items"); items != "" {
+			if err := json.Unmarshal([]byte(items), &req.Items); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "Danh sách sản phẩm (items) lỗi", err.Error())
+				return
+			}
+		}
+		for _, fh := range r.MultipartForm.File["photos"] {
+			f, err := fh.Open()
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "Không đọc được ảnh", err.Error())
+				return
+			}
+			defer f.Close()
+			photos = append(photos, model.AttachmentUpload{FileName: fh.Filename, Size: fh.Size, Content: f})
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu JSON lỗi", err.Error())
+		return
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if errs := validator.Validate(req); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu không hợp lệ", errs)
+		return
+	}
+
+	ret, err := h.ReturnController.CreateReturn(r.Context(), userID, orderID, req, photos)
+	if err != nil {
+		writeError(w, "Tạo yêu cầu trả hàng thất bại", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, "Tạo yêu cầu trả hàng thành công", ret)
+}
+
+// Khách xem danh sách yêu cầu trả hàng
+func (h *returnHandler) GetMyReturns(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	filter := parseFilter(r)
+	if errs := validator.Validate(filter); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số lọc không hợp lệ", errs)
+		return
+	}
+
+	res, err := h.ReturnController.GetMyReturns(r.Context(), userID, filter)
+	if err != nil {
+		writeError(w, "Lỗi lấy danh sách yêu cầu trả hàng", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", res)
+}
+
+// Khách xem chi tiết yêu cầu trả hàng
+func (h *returnHandler) GetMyReturn(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	returnID, ok := parsePathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID yêu cầu trả hàng không hợp lệ", nil)
+		return
+	}
+
+	ret, err := h.ReturnController.GetMyReturn(r.Context(), userID, returnID)
+	if err != nil {
+		writeError(w, "Lỗi lấy yêu cầu trả hàng", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Chi tiết yêu cầu trả hàng", ret)
+}
+
+// Khách hủy yêu cầu trả hàng
+func (h *returnHandler) CancelMyReturn(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	returnID, ok := parsePathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID yêu cầu trả hàng không hợp lệ", nil)
+		return
+	}
+
+	ret, err := h.ReturnController.CancelMyReturn(r.Context(), userID, returnID)
+	if err != nil {
+		writeError(w, "Hủy yêu cầu trả hàng thất bại", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Đã hủy yêu cầu trả hàng", ret)
+}
+
+// Khách tải ảnh
+func (h *returnHandler) DownloadMyPhoto(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r)
+	if userID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	returnID, ok := parsePathID(r, "id")
+	photoID, ok2 := parsePathID(r, "photoId")
+	if !ok || !ok2 {
+		utils.WriteError(w, http.StatusBadRequest, "ID không hợp lệ", nil)
+		return
+	}
+
+	photo, file, err := h.ReturnController.OpenMyPhoto(r.Context(), userID, returnID, photoID)
+	if err != nil {
+		writeError(w, "Lỗi tải ảnh", err)
+		return
+	}
+	servePhoto(w, photo, file)
+}
+
+// Admin tìm kiếm yêu cầu trả hàng
+func (h *returnHandler) SearchReturns(w http.ResponseWriter, r *http.Request) {
+	filter := parseFilter(r)
+	filter.UserID, _ = strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	if errs := validator.Validate(filter); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số lọc không hợp lệ", errs)
+		return
+	}
+
+	res, err := h.ReturnController.SearchReturns(r.Context(), filter)
+	if err != nil {
+		writeError(w, "Lỗi tìm kiếm yêu cầu trả hàng", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", res)
+}
+
+// Admin xem chi tiết
+func (h *returnHandler) GetReturn(w http.ResponseWriter, r *http.Request) {
+	returnID, ok := parsePathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID yêu cầu trả hàng không hợp lệ", nil)
+		return
+	}
+
+	ret, err := h.ReturnController.GetReturn(r.Context(), returnID)
+	if err != nil {
+		writeError(w, "Lỗi lấy yêu cầu trả hàng", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Chi tiết yêu cầu trả hàng (Admin)", ret)
+}
+
+// Admin duyệt
+func (h *returnHandler) ApproveReturn(w http.ResponseWriter, r *http.Request) {
+	adminID := getUserIDFromContext(r)
+	if adminID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	returnID, ok := parsePathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID yêu cầu trả hàng không hợp lệ", nil)
+		return
+	}
+	var req model.ReturnDecisionRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	ret, err := h.ReturnController.ApproveReturn(r.Context(), adminID, returnID, req)
+	if err != nil {
+		writeError(w, "Duyệt yêu cầu trả hàng thất bại", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Đã duyệt yêu cầu trả hàng", ret)
+}
+
+// Admin từ chối
+func (h *returnHandler) RejectReturn(w http.ResponseWriter, r *http.Request) {
+	adminID := getUserIDFromContext(r)
+	if adminID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	returnID, ok := parsePathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID yêu cầu trả hàng không hợp lệ", nil)
+		return
+	}
+	var req model.ReturnDecisionRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	ret, err := h.ReturnController.RejectReturn(r.Context(), adminID, returnID, req)
+	if err != nil {
+		writeError(w, "Từ chối yêu cầu trả hàng thất bại", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Đã từ chối yêu cầu trả hàng", ret)
+}
+
+// Admin xác nhận đã nhận hàng
+func (h *returnHandler) ReceiveReturn(w http.ResponseWriter, r *http.Request) {
+	adminID := getUserIDFromContext(r)
+	if adminID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	returnID, ok := parsePathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID yêu cầu trả hàng không hợp lệ", nil)
+		return
+	}
+	var req model.ReceiveReturnRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	ret, err := h.ReturnController.ReceiveReturn(r.Context(), adminID, returnID, req)
+	if err != nil {
+		writeError(w, "Xác nhận nhận hàng thất bại", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Đã nhận hàng trả về", ret)
+}
+
+// Admin hoàn tiền
+func (h *returnHandler) RefundReturn(w http.ResponseWriter, r *http.Request) {
+	adminID := getUserIDFromContext(r)
+	if adminID == 0 {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+	returnID, ok := parsePathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID yêu cầu trả hàng không hợp lệ", nil)
+		return
+	}
+	var req model.RefundReturnRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	ret, err := h.ReturnController.RefundReturn(r.Context(), adminID, returnID, req)
+	if err != nil {
+		writeError(w, "Hoàn tiền thất bại", err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Hoàn tiền thành công", ret)
+}
+
+// Admin tải ảnh
+func (h *returnHandler) DownloadPhoto(w http.ResponseWriter, r *http.Request) {
+	returnID, ok := parsePathID(r, "id")
+	photoID, ok2 := parsePathID(r, "photoId")
+	if !ok || !ok2 {
+		utils.WriteError(w, http.StatusBadRequest, "ID không hợp lệ", nil)
+		return
+	}
+
+	photo, file, err := h.ReturnController.OpenPhoto(r.Context(), returnID, photoID)
+	if err != nil {
+		writeError(w, "Lỗi tải ảnh", err)
+		return
+	}
+	servePhoto(w, photo, file)
+}
@@ -0,0 +1,21 @@
+package returns
+
+import "net/http"
+
+type ReturnHandler interface {
+	// Khách: tạo / xem / hủy yêu cầu trả hàng, tải ảnh
+	CreateReturn(w http.ResponseWriter, r *http.Request)
+	GetMyReturns(w http.ResponseWriter, r *http.Request)
+	GetMyReturn(w http.ResponseWriter, r *http.Request)
+	CancelMyReturn(w http.ResponseWriter, r *http.Request)
+	DownloadMyPhoto(w http.ResponseWriter, r *http.Request)
+
+	// Admin: tìm kiếm / xem, duyệt / từ chối, nhận hàng, hoàn tiền, tải ảnh
+	SearchReturns(w http.ResponseWriter, r *http.Request)
+	GetReturn(w http.ResponseWriter, r *http.Request)
+	ApproveReturn(w http.ResponseWriter, r *http.Request)
+	RejectReturn(w http.ResponseWriter, r *http.Request)
+	ReceiveReturn(w http.ResponseWriter, r *http.Request)
+	RefundReturn(w http.ResponseWriter, r *http.Request)
+	DownloadPhoto(w http.ResponseWriter, r *http.Request)
+}
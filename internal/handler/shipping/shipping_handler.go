@@ -0,0 +1,224 @@
+package shipping
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"golang/internal/controller/shipping"
+	"golang/internal/model"
+	"golang/internal/utils"
+	"golang/internal/validator"
+)
+
+type shippingHandler struct {
+	ShippingController shipping.ShippingController
+}
+
+func NewShippingHandler(sController shipping.ShippingController) ShippingHandler {
+	return &shippingHandler{
+		ShippingController: sController,
+	}
+}
+
+// Helper: Map lỗi nghiệp vụ sang HTTP status
+func writeShippingError(w http.ResponseWriter, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, shipping.ErrZoneNotFound),
+		errors.Is(err, shipping.ErrMethodNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, shipping.ErrWeightTiersRequired),
+		errors.Is(err, shipping.ErrDuplicateWeightTier):
+		status = http.StatusBadRequest
+	case errors.Is(err, shipping.ErrDuplicateMethod):
+		status = http.StatusConflict
+	}
+	utils.WriteError(w, status, message, err.Error())
+}
+
+// Helper: Lấy ID từ path
+func pathID(r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// ListZones: Danh sách vùng giao hàng
+func (h *shippingHandler) ListZones(w http.ResponseWriter, r *http.Request) {
+	res, err := h.ShippingController.ListZones(r.Context())
+	if err != nil {
+		writeShippingError(w, "Lỗi lấy danh sách vùng giao hàng", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Lấy danh sách vùng giao hàng thành công", res)
+}
+
+// GetZone: Chi tiết vùng giao hàng
+func (h *shippingHandler) GetZone(w http.ResponseWriter, r *http.Request) {
+	zoneID, ok := pathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID vùng không hợp lệ", nil)
+		return
+	}
+
+	res, err := h.ShippingController.GetZone(r.Context(), zoneID)
+	if err != nil {
+		writeShippingError(w, "Lỗi lấy vùng giao hàng", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Lấy vùng giao hàng thành công", res)
+}
+
+// CreateZone: Tạo vùng giao hàng
+func (h *shippingHandler) CreateZone(w http.ResponseWriter, r *http.Request) {
+	var req model.ShippingZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu JSON không hợp lệ", err.Error())
+		return
+	}
+
+	if errs := validator.Validate(req); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errs)
+		return
+	}
+
+	res, err := h.ShippingController.CreateZone(r.Context(), req)
+	if err != nil {
+		writeShippingError(w, "Không thể tạo vùng giao hàng", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, "Tạo vùng giao hàng thành công", res)
+}
+
+// UpdateZone: Cập nhật vùng giao hàng
+func (h *shippingHandler) UpdateZone(w http.ResponseWriter, r *http.Request) {
+	zoneID, ok := pathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID vùng không hợp lệ", nil)
+		return
+	}
+
+	var req model.ShippingZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu JSON không hợp lệ", err.Error())
+		return
+	}
+
+	if errs := validator.Validate(req); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errs)
+		return
+	}
+
+	res, err := h.ShippingController.UpdateZone(r.Context(), zoneID, req)
+	if err != nil {
+		writeShippingError(w, "Không thể cập nhật vùng giao hàng", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Cập nhật vùng giao hàng thành công", res)
+}
+
+// DeleteZone: Xóa vùng giao hàng
+func (h *shippingHandler) DeleteZone(w http.ResponseWriter, r *http.Request) {
+	zoneID, ok := pathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID vùng không hợp lệ", nil)
+		return
+	}
+
+	if err := h.ShippingController.DeleteZone(r.Context(), zoneID); err != nil {
+		writeShippingError(w, "Không thể xóa vùng giao hàng", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Đã xóa vùng giao hàng", nil)
+}
+
+// CreateMethod: Thêm phương thức giao hàng cho vùng
+func (h *shippingHandler) CreateMethod(w http.ResponseWriter, r *http.Request) {
+	zoneID, ok := pathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID vùng không hợp lệ", nil)
+		return
+	}
+
+	var req model.ShippingMethodRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu JSON không hợp lệ", err.Error())
+		return
+	}
+
+	if errs := validator.Validate(req); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errs)
+		return
+	}
+
+	res, err := h.ShippingController.CreateMethod(r.Context(), zoneID, req)
+	if err != nil {
+		writeShippingError(w, "Không thể tạo phương thức giao hàng", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, "Tạo phương thức giao hàng thành công", res)
+}
+
+// UpdateMethod: Cập nhật phương thức giao hàng
+func (h *shippingHandler) UpdateMethod(w http.ResponseWriter, r *http.Request) {
+	zoneID, ok := pathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID vùng không hợp lệ", nil)
+		return
+	}
+	methodID, ok := pathID(r, "methodId")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID phương thức không hợp lệ", nil)
+		return
+	}
+
+	var req model.ShippingMethodRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu JSON không hợp lệ", err.Error())
+		return
+	}
+
+	if errs := validator.Validate(req); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errs)
+		return
+	}
+
+	res, err := h.ShippingController.UpdateMethod(r.Context(), zoneID, methodID, req)
+	if err != nil {
+		writeShippingError(w, "Không thể cập nhật phương thức giao hàng", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Cập nhật phương thức giao hàng thành công", res)
+}
+
+// DeleteMethod: Xóa phương thức giao hàng
+func (h *shippingHandler) DeleteMethod(w http.ResponseWriter, r *http.Request) {
+	zoneID, ok := pathID(r, "id")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID vùng không hợp lệ", nil)
+		return
+	}
+	methodID, ok := pathID(r, "methodId")
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "ID phương thức không hợp lệ", nil)
+		return
+	}
+
+	if err := h.ShippingController.DeleteMethod(r.Context(), zoneID, methodID); err != nil {
+		writeShippingError(w, "Không thể xóa phương thức giao hàng", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Đã xóa phương thức giao hàng", nil)
+}
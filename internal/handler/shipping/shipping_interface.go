@@ -0,0 +1,16 @@
+package shipping
+
+import "net/http"
+
+// ShippingHandler định nghĩa các hàm xử lý request cấu hình vùng / phương thức giao hàng (Admin)
+type ShippingHandler interface {
+	ListZones(w http.ResponseWriter, r *http.Request)
+	GetZone(w http.ResponseWriter, r *http.Request)
+	CreateZone(w http.ResponseWriter, r *http.Request)
+	UpdateZone(w http.ResponseWriter, r *http.Request)
+	DeleteZone(w http.ResponseWriter, r *http.Request)
+
+	CreateMethod(w http.ResponseWriter, r *http.Request)
+	UpdateMethod(w http.ResponseWriter, r *http.Request)
+	DeleteMethod(w http.ResponseWriter, r *http.Request)
+}
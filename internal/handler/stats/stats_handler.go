@@ -1,10 +1,15 @@
 package stats
 
 import (
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"golang/internal/controller/stats"
+	"golang/internal/logger"
 	"golang/internal/model"
 	"golang/internal/utils"
 	"golang/internal/validator"
@@ -116,4 +121,434 @@ func (h *statsHandler) SyncDailyStats(w http.ResponseWriter, r *http.Request) {
 
 	// Trả về thông báo thành công
 	utils.WriteJSON(w, http.StatusOK, "Đã cập nhật dữ liệu báo cáo thành công (Data refreshed)", nil)
-}
\ No newline at end of file
+}
+
+// Tính lại thống kê cho khoảng ngày (?from=YYYY-MM-DD&to=YYYY-MM-DD)
+func (h *statsHandler) Backfill(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := model.StatsBackfillRequest{
+		From: query.Get("from"),
+		To:   query.Get("to"),
+	}
+
+	if errs := validator.Validate(req); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số ngày tháng không hợp lệ", errs)
+		return
+	}
+
+	resp, err := h.StatsController.Backfill(r.Context(), req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, stats.ErrInvalidBackfillRange) ||
+			errors.Is(err, stats.ErrBackfillRangeTooLong) ||
+			errors.Is(err, stats.ErrBackfillFutureDate) {
+			status = http.StatusBadRequest
+		}
+		utils.WriteError(w, status, "Tính lại thống kê thất bại", err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Đã tính lại thống kê thành công", resp)
+}
+
+// parseMarginFilter: Đọc + validate filter báo cáo lợi nhuận từ query, ghi lỗi 400 nếu không hợp lệ
+func parseMarginFilter(w http.ResponseWriter, r *http.Request) (model.MarginFilter, bool) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	filter := model.MarginFilter{
+		StartDate: query.Get("start_date"),
+		EndDate:   query.Get("end_date"),
+		Limit:     limit,
+		SortBy:    query.Get("sort_by"),
+	}
+
+	if errs := validator.Validate(filter); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số lọc không hợp lệ", errs)
+		return filter, false
+	}
+	return filter, true
+}
+
+// Lợi nhuận gộp theo sản phẩm
+func (h *statsHandler) GetProductMargins(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseMarginFilter(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.StatsController.GetProductMargins(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Lỗi lấy báo cáo lợi nhuận", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", resp)
+}
+
+// Lợi nhuận gộp theo biến thể
+func (h *statsHandler) GetVariantMargins(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseMarginFilter(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.StatsController.GetVariantMargins(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Lỗi lấy báo cáo lợi nhuận", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", resp)
+}
+
+// Lợi nhuận gộp theo danh mục
+func (h *statsHandler) GetCategoryMargins(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseMarginFilter(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.StatsController.GetCategoryMargins(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Lỗi lấy báo cáo lợi nhuận", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", resp)
+}
+
+// Lợi nhuận gộp theo ngày
+func (h *statsHandler) GetDailyMargins(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseMarginFilter(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.StatsController.GetDailyMargins(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Lỗi lấy báo cáo lợi nhuận", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", resp)
+}
+
+// Cảnh báo biến thể bán dưới giá vốn
+func (h *statsHandler) GetMarginAlerts(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseMarginFilter(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.StatsController.GetMarginAlerts(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Lỗi lấy cảnh báo giá vốn", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", resp)
+}
+
+// Doanh thu theo thời gian (?from=&to=&granularity=&compare=)
+func (h *statsHandler) GetRevenueSeries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := model.RevenueSeriesRequest{
+		From:        query.Get("from"),
+		To:          query.Get("to"),
+		Granularity: query.Get("granularity"),
+		Compare:     query.Get("compare"),
+	}
+
+	if errs := validator.Validate(req); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số báo cáo không hợp lệ", errs)
+		return
+	}
+
+	resp, err := h.StatsController.GetRevenueSeries(r.Context(), req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, stats.ErrInvalidRevenueRange) || errors.Is(err, stats.ErrRevenueRangeTooLong) {
+			status = http.StatusBadRequest
+		}
+		utils.WriteError(w, status, "Lỗi lấy báo cáo doanh thu", err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Thành công", resp)
+}
+
+// Danh sách khách hàng (?segment=&sort_by=&page=&limit=), ?format=csv tải toàn bộ danh sách cho marketing
+func (h *statsHandler) SearchCustomers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	filter := model.CustomerStatsFilter{
+		Segment: query.Get("segment"),
+		SortBy:  query.Get("sort_by"),
+		Page:    page,
+		Limit:   limit,
+	}
+	if errs := validator.Validate(filter); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số lọc không hợp lệ", errs)
+		return
+	}
+
+	if query.Get("format") == "csv" {
+		h.exportCustomersCSV(w, r, filter)
+		return
+	}
+
+	customers, total, err := h.StatsController.SearchCustomers(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Lỗi lấy danh sách khách hàng", err.Error())
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Thành công", map[string]interface{}{
+		"customers": customers,
+		"total":     total,
+	})
+}
+
+// exportCustomersCSV: Ghi dần từng dòng ra response (có BOM để Excel đọc đúng tiếng Việt)
+func (h *statsHandler) exportCustomersCSV(w http.ResponseWriter, r *http.Request, filter model.CustomerStatsFilter) {
+	filename := "customers_rfm_" + time.Now().Format("20060102") + ".csv"
+	if filter.Segment != "" {
+		filename = "customers_rfm_" + filter.Segment + "_" + time.Now().Format("20060102") + ".csv"
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("\xEF\xBB\xBF"))
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"user_id", "username", "email", "cohort_month", "first_order_at", "last_order_at",
+		"order_count", "lifetime_value", "avg_order_value", "recency_days", "r_score", "f_score", "m_score", "rfm", "segment"})
+
+	err := h.StatsController.ExportCustomers(r.Context(), filter, func(c model.CustomerStatsResponse) error {
+		return cw.Write([]string{
+			strconv.FormatInt(c.UserID, 10), c.Username, c.Email, c.CohortMonth,
+			c.FirstOrderAt.Format("2006-01-02 15:04:05"), c.LastOrderAt.Format("2006-01-02 15:04:05"),
+			strconv.FormatInt(c.OrderCount, 10),
+			strconv.FormatFloat(c.LifetimeValue, 'f', 2, 64), strconv.FormatFloat(c.AvgOrderValue, 'f', 2, 64),
+			strconv.Itoa(c.RecencyDays), strconv.Itoa(c.RScore), strconv.Itoa(c.FScore), strconv.Itoa(c.MScore),
+			fmt.Sprintf("%d%d%d", c.RScore, c.FScore, c.MScore), c.Segment,
+		})
+	})
+	cw.Flush()
+	if err == nil {
+		err = cw.Error()
+	}
+	if err != nil {
+		// Header đã gửi, chỉ có thể ghi log (file tải về sẽ bị thiếu dòng)
+		logger.ErrorLogger.Printf("StatsHandler: Export customers CSV interrupted: %v", err)
+	}
+}
+
+// Tổng hợp phân khúc RFM
+func (h *statsHandler) GetRFMSegments(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.StatsController.GetRFMSegments(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Lỗi lấy phân khúc khách hàng", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", resp)
+}
+
+// Ma trận cohort (?from_month=YYYY-MM&to_month=YYYY-MM)
+func (h *statsHandler) GetCohorts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := model.CohortFilter{
+		FromMonth: query.Get("from_month"),
+		ToMonth:   query.Get("to_month"),
+	}
+	if errs := validator.Validate(filter); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số tháng không hợp lệ", errs)
+		return
+	}
+
+	resp, err := h.StatsController.GetCohorts(r.Context(), filter)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, stats.ErrInvalidCohortRange) || errors.Is(err, stats.ErrCohortRangeTooLong) {
+			status = http.StatusBadRequest
+		}
+		utils.WriteError(w, status, "Lỗi lấy báo cáo cohort", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", resp)
+}
+
+// Tỷ lệ mua lặp lại (?within_days=60)
+func (h *statsHandler) GetRepeatPurchase(w http.ResponseWriter, r *http.Request) {
+	withinDays := 60
+	if v := r.URL.Query().Get("within_days"); v != "" {
+		withinDays, _ = strconv.Atoi(v)
+	}
+
+	filter := model.RepeatPurchaseFilter{WithinDays: withinDays}
+	if errs := validator.Validate(filter); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số within_days không hợp lệ", errs)
+		return
+	}
+
+	resp, err := h.StatsController.GetRepeatPurchase(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Lỗi lấy tỷ lệ mua lặp lại", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", resp)
+}
+
+// Chạy thủ công job chỉ số khách hàng
+func (h *statsHandler) RefreshCustomerAnalytics(w http.ResponseWriter, r *http.Request) {
+	if err := h.StatsController.RefreshCustomerAnalytics(r.Context()); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Tính lại chỉ số khách hàng thất bại", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Đã tính lại chỉ số khách hàng thành công", nil)
+}
+
+// Báo cáo tồn kho (?status=&horizon_days=&dead_days=&page=&limit=)
+func (h *statsHandler) GetInventoryHealth(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	horizonDays, _ := strconv.Atoi(query.Get("horizon_days"))
+	deadDays, _ := strconv.Atoi(query.Get("dead_days"))
+
+	filter := model.InventoryHealthFilter{
+		Status:      query.Get("status"),
+		HorizonDays: horizonDays,
+		DeadDays:    deadDays,
+		Page:        page,
+		Limit:       limit,
+	}
+	if errs := validator.Validate(filter); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số lọc không hợp lệ", errs)
+		return
+	}
+
+	resp, err := h.StatsController.GetInventoryHealth(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Lỗi lấy báo cáo tồn kho", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", resp)
+}
+
+// Chạy thủ công job dự báo tồn kho (tính lại velocity + gửi cảnh báo sắp hết hàng)
+func (h *statsHandler) RefreshInventoryForecast(w http.ResponseWriter, r *http.Request) {
+	if err := h.StatsController.RefreshInventoryForecast(r.Context()); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Tính lại dự báo tồn kho thất bại", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Đã tính lại dự báo tồn kho thành công", nil)
+}
+
+// parseFunnelFilter: Đọc + validate khoảng ngày báo cáo phễu (?start_date=&end_date=&limit=), ghi lỗi 400 nếu không hợp lệ
+func parseFunnelFilter(w http.ResponseWriter, r *http.Request) (model.StatsFilter, bool) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	filter := model.StatsFilter{
+		StartDate: query.Get("start_date"),
+		EndDate:   query.Get("end_date"),
+		Limit:     limit,
+	}
+	if errs := validator.Validate(filter); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số lọc không hợp lệ", errs)
+		return filter, false
+	}
+	return filter, true
+}
+
+// funnelErrorStatus: Lỗi khoảng ngày -> 400, còn lại 500
+func funnelErrorStatus(err error) int {
+	if errors.Is(err, stats.ErrInvalidFunnelRange) || errors.Is(err, stats.ErrFunnelRangeTooLong) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// Phễu giỏ hàng -> checkout -> đơn hàng (?start_date=&end_date=)
+func (h *statsHandler) GetCartFunnel(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseFunnelFilter(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.StatsController.GetCartFunnel(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, funnelErrorStatus(err), "Lỗi lấy báo cáo phễu giỏ hàng", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", resp)
+}
+
+// Sản phẩm bị bỏ lại trong giỏ nhiều nhất (?start_date=&end_date=&limit=)
+func (h *statsHandler) GetAbandonedProducts(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseFunnelFilter(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.StatsController.GetAbandonedProducts(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, funnelErrorStatus(err), "Lỗi lấy sản phẩm bị bỏ quên", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", resp)
+}
+
+// Giỏ hàng bỏ quên của user đã đồng ý nhận marketing (?min_hours=&max_days=&page=&limit=)
+func (h *statsHandler) GetAbandonedCarts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	minHours, _ := strconv.Atoi(query.Get("min_hours"))
+	maxDays, _ := strconv.Atoi(query.Get("max_days"))
+
+	filter := model.AbandonedCartFilter{
+		MinHours: minHours,
+		MaxDays:  maxDays,
+		Page:     page,
+		Limit:    limit,
+	}
+	if errs := validator.Validate(filter); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Tham số lọc không hợp lệ", errs)
+		return
+	}
+
+	carts, total, err := h.StatsController.GetAbandonedCarts(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Lỗi lấy danh sách giỏ hàng bỏ quên", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Thành công", map[string]interface{}{
+		"carts": carts,
+		"total": total,
+	})
+}
+
+// Chạy thủ công job phễu giỏ hàng
+func (h *statsHandler) RefreshCartFunnel(w http.ResponseWriter, r *http.Request) {
+	if err := h.StatsController.RefreshCartFunnel(r.Context()); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Tính lại phễu giỏ hàng thất bại", err.Error())
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, "Đã tính lại phễu giỏ hàng thành công", nil)
+}
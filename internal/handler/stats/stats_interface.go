@@ -15,4 +15,52 @@ type StatsHandler interface {
 
 	// Chạy thủ công tính toán thống kê 
 	SyncDailyStats(w http.ResponseWriter, r *http.Request)
+
+	// Tính lại thống kê cho khoảng ngày
+	Backfill(w http.ResponseWriter, r *http.Request)
+
+	// Báo cáo lợi nhuận gộp theo sản phẩm / biến thể / danh mục / ngày
+	GetProductMargins(w http.ResponseWriter, r *http.Request)
+	GetVariantMargins(w http.ResponseWriter, r *http.Request)
+	GetCategoryMargins(w http.ResponseWriter, r *http.Request)
+	GetDailyMargins(w http.ResponseWriter, r *http.Request)
+
+	// Doanh thu theo thời gian (biểu đồ)
+	GetRevenueSeries(w http.ResponseWriter, r *http.Request)
+
+	// Cảnh báo biến thể bán dưới giá vốn
+	GetMarginAlerts(w http.ResponseWriter, r *http.Request)
+
+	// Danh sách khách hàng (LTV, RFM), ?format=csv để tải file
+	SearchCustomers(w http.ResponseWriter, r *http.Request)
+
+	// Tổng hợp phân khúc RFM
+	GetRFMSegments(w http.ResponseWriter, r *http.Request)
+
+	// Ma trận cohort
+	GetCohorts(w http.ResponseWriter, r *http.Request)
+
+	// Tỷ lệ mua lặp lại
+	GetRepeatPurchase(w http.ResponseWriter, r *http.Request)
+
+	// Chạy thủ công job chỉ số khách hàng
+	RefreshCustomerAnalytics(w http.ResponseWriter, r *http.Request)
+
+	// Báo cáo tồn kho: hết hàng, dưới ngưỡng, sắp hết, tồn đọng
+	GetInventoryHealth(w http.ResponseWriter, r *http.Request)
+
+	// Chạy thủ công job dự báo tồn kho
+	RefreshInventoryForecast(w http.ResponseWriter, r *http.Request)
+
+	// Phễu giỏ hàng -> checkout -> đơn hàng
+	GetCartFunnel(w http.ResponseWriter, r *http.Request)
+
+	// Sản phẩm bị bỏ lại trong giỏ nhiều nhất
+	GetAbandonedProducts(w http.ResponseWriter, r *http.Request)
+
+	// Giỏ hàng bỏ quên (remarketing)
+	GetAbandonedCarts(w http.ResponseWriter, r *http.Request)
+
+	// Chạy thủ công job phễu giỏ hàng
+	RefreshCartFunnel(w http.ResponseWriter, r *http.Request)
 }
@@ -35,12 +35,22 @@ func (h *userHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := h.UserController.Register(req)
+	// Cart token của khách: ưu tiên body, sau đó header / cookie
+	if req.CartToken == "" {
+		req.CartToken = utils.CartTokenFromRequest(r)
+	}
+
+	res, cartMerged, err := h.UserController.Register(req)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "Lỗi đăng ký tài khoản", err.Error())
 		return
 	}
 
+	// Chỉ xóa cookie cart token khi giỏ khách đã gộp thành công
+	if cartMerged {
+		utils.ClearCartTokenCookie(w)
+	}
+
 	utils.WriteJSON(w, http.StatusCreated, "Đăng ký thành công", res)
 }
 
@@ -58,12 +68,22 @@ func (h *userHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := h.UserController.Login(req)
+	// Cart token của khách: ưu tiên body, sau đó header / cookie
+	if req.CartToken == "" {
+		req.CartToken = utils.CartTokenFromRequest(r)
+	}
+
+	res, cartMerged, err := h.UserController.Login(req)
 	if err != nil {
 		utils.WriteError(w, http.StatusUnauthorized, "Đăng nhập thất bại", err.Error())
 		return
 	}
 
+	// Chỉ xóa cookie cart token khi giỏ khách đã gộp thành công
+	if cartMerged {
+		utils.ClearCartTokenCookie(w)
+	}
+
 	utils.WriteJSON(w, http.StatusOK, "Đăng nhập thành công", res)
 }
 
@@ -0,0 +1,174 @@
+package wishlist
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"golang/internal/controller/wishlist"
+	"golang/internal/model"
+	"golang/internal/utils"
+	"golang/internal/validator"
+)
+
+type wishlistHandler struct {
+	WishlistController wishlist.WishlistController
+}
+
+func NewWishlistHandler(wController wishlist.WishlistController) WishlistHandler {
+	return &wishlistHandler{
+		WishlistController: wController,
+	}
+}
+
+// Helper: Map lỗi nghiệp vụ sang HTTP status
+func writeWishlistError(w http.ResponseWriter, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, wishlist.ErrProductNotFound),
+		errors.Is(err, wishlist.ErrVariantNotFound),
+		errors.Is(err, wishlist.ErrWishlistNotFound),
+		errors.Is(err, wishlist.ErrAlertNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, wishlist.ErrVariantMismatch),
+		errors.Is(err, wishlist.ErrVariantNotForSale):
+		status = http.StatusBadRequest
+	case errors.Is(err, wishlist.ErrVariantInStock):
+		status = http.StatusConflict
+	}
+	utils.WriteError(w, status, message, err.Error())
+}
+
+// GetWishlist: Lấy wishlist của tôi
+func (h *wishlistHandler) GetWishlist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Token không hợp lệ", nil)
+		return
+	}
+
+	res, err := h.WishlistController.GetWishlist(r.Context(), userID)
+	if err != nil {
+		writeWishlistError(w, "Lỗi lấy wishlist", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Lấy wishlist thành công", res)
+}
+
+// AddToWishlist: Thêm sản phẩm / biến thể vào wishlist
+func (h *wishlistHandler) AddToWishlist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Token không hợp lệ", nil)
+		return
+	}
+
+	var req model.AddWishlistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu JSON không hợp lệ", err.Error())
+		return
+	}
+
+	if errs := validator.Validate(req); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errs)
+		return
+	}
+
+	res, err := h.WishlistController.AddToWishlist(r.Context(), userID, req)
+	if err != nil {
+		writeWishlistError(w, "Không thể thêm vào wishlist", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, "Đã thêm vào wishlist", res)
+}
+
+// RemoveFromWishlist: Xóa 1 dòng khỏi wishlist
+func (h *wishlistHandler) RemoveFromWishlist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Token không hợp lệ", nil)
+		return
+	}
+
+	itemID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || itemID <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "ID không hợp lệ", nil)
+		return
+	}
+
+	if err := h.WishlistController.RemoveFromWishlist(r.Context(), userID, itemID); err != nil {
+		writeWishlistError(w, "Không thể xóa khỏi wishlist", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Đã xóa khỏi wishlist", nil)
+}
+
+// GetStockAlerts: Danh sách đăng ký báo có hàng
+func (h *wishlistHandler) GetStockAlerts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Token không hợp lệ", nil)
+		return
+	}
+
+	res, err := h.WishlistController.GetStockAlerts(r.Context(), userID)
+	if err != nil {
+		writeWishlistError(w, "Lỗi lấy danh sách đăng ký", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Lấy danh sách đăng ký thành công", res)
+}
+
+// SubscribeStockAlert: Đăng ký báo có hàng cho 1 biến thể
+func (h *wishlistHandler) SubscribeStockAlert(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Token không hợp lệ", nil)
+		return
+	}
+
+	var req model.StockAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu JSON không hợp lệ", err.Error())
+		return
+	}
+
+	if errs := validator.Validate(req); errs != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Dữ liệu đầu vào không hợp lệ", errs)
+		return
+	}
+
+	if err := h.WishlistController.SubscribeStockAlert(r.Context(), userID, req); err != nil {
+		writeWishlistError(w, "Không thể đăng ký báo có hàng", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, "Đã đăng ký báo có hàng", nil)
+}
+
+// UnsubscribeStockAlert: Hủy đăng ký báo có hàng
+func (h *wishlistHandler) UnsubscribeStockAlert(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Token không hợp lệ", nil)
+		return
+	}
+
+	variantID, err := strconv.ParseInt(r.PathValue("variantId"), 10, 64)
+	if err != nil || variantID <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "ID biến thể không hợp lệ", nil)
+		return
+	}
+
+	if err := h.WishlistController.UnsubscribeStockAlert(r.Context(), userID, variantID); err != nil {
+		writeWishlistError(w, "Không thể hủy đăng ký", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Đã hủy đăng ký báo có hàng", nil)
+}
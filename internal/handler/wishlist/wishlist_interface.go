@@ -0,0 +1,14 @@
+package wishlist
+
+import "net/http"
+
+// WishlistHandler định nghĩa các hàm xử lý request cho wishlist và báo có hàng
+type WishlistHandler interface {
+	GetWishlist(w http.ResponseWriter, r *http.Request)
+	AddToWishlist(w http.ResponseWriter, r *http.Request)
+	RemoveFromWishlist(w http.ResponseWriter, r *http.Request)
+
+	GetStockAlerts(w http.ResponseWriter, r *http.Request)
+	SubscribeStockAlert(w http.ResponseWriter, r *http.Request)
+	UnsubscribeStockAlert(w http.ResponseWriter, r *http.Request)
+}
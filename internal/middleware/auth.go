@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"golang/internal/logger"
 	"golang/internal/model"
 	"net/http"
@@ -11,27 +12,42 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// errMissingToken: request không gửi header Authorization
+var errMissingToken = errors.New("missing token")
+
+// parseClaims: Lấy token "Bearer ..." từ header Authorization, parse và validate JWT
+func parseClaims(r *http.Request) (*model.MyClaims, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return nil, errMissingToken
+	}
+
+	// Cắt bỏ chữ "Bearer " để lấy token thuần
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+	claims := &model.MyClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
 
 // AdminOnlyMiddleware: Chỉ cho phép Admin truy cập
 func AdminOnlyMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		//  Lấy token từ Header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
+		// Lấy token từ Header, parse và validate
+		claims, err := parseClaims(r)
+		if err == errMissingToken {
 			http.Error(w, "Thiếu token xác thực", http.StatusUnauthorized)
 			return
 		}
-
-		// Cắt bỏ chữ "Bearer " để lấy token thuần
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-
-		// Parse và Validate Token
-		claims := &model.MyClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
-
-		if err != nil || !token.Valid {
+		if err != nil {
 			logger.ErrorLogger.Printf("Token không hợp lệ: %v", err)
 			http.Error(w, "Token không hợp lệ hoặc đã hết hạn", http.StatusUnauthorized)
 			return
@@ -57,30 +73,41 @@ func AdminOnlyMiddleware(next http.Handler) http.Handler {
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-		// Lấy token từ Header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
+		// Lấy token từ Header, parse và validate
+		claims, err := parseClaims(r)
+		if err == errMissingToken {
 			http.Error(w, "Thiếu token xác thực", http.StatusUnauthorized)
 			return
 		}
+		if err != nil {
+			http.Error(w, "Token không hợp lệ", http.StatusUnauthorized)
+			return
+		}
 
-		// Cắt chuỗi "Bearer "
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		// Token hợp lệ -> Lưu UserID vào Context và cho đi tiếp
+		ctx := context.WithValue(r.Context(), "userID", claims.UserID)
+		ctx = context.WithValue(ctx, "userRole", claims.Role) // Lưu thêm role nếu cần
 
-		// Parse Token
-		claims := &model.MyClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
-		if err != nil || !token.Valid {
+// OptionalAuthMiddleware: Cho phép cả khách chưa đăng nhập đi qua (VD: giỏ hàng khách).
+// Có token hợp lệ -> lưu UserID vào Context như AuthMiddleware; token sai -> 401; không có token -> đi tiếp.
+func OptionalAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := parseClaims(r)
+		if err == errMissingToken {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err != nil {
 			http.Error(w, "Token không hợp lệ", http.StatusUnauthorized)
 			return
 		}
 
-		// Token hợp lệ -> Lưu UserID vào Context và cho đi tiếp
 		ctx := context.WithValue(r.Context(), "userID", claims.UserID)
-		ctx = context.WithValue(ctx, "userRole", claims.Role) // Lưu thêm role nếu cần
+		ctx = context.WithValue(ctx, "userRole", claims.Role)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
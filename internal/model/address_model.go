@@ -7,29 +7,37 @@ import (
 type Address struct {
 	ID                int64     `db:"id" json:"id"`
 	UserID            int64     `db:"user_id" json:"user_id"`
-	Label             string    `db:"label" json:"label"`                       
-	RecipientName     string    `db:"recipient_name" json:"recipient_name"`     
-	Phone             string    `db:"phone" json:"phone"`                       
-	Line1             string    `db:"line1" json:"line1"`                      
-	Line2             string    `db:"line2" json:"line2"`                       
-	City              string    `db:"city" json:"city"`                         
-	State             string    `db:"state" json:"state"`                       
-	Country           string    `db:"country" json:"country"`                   
+	Label             string    `db:"label" json:"label"`
+	RecipientName     string    `db:"recipient_name" json:"recipient_name"`
+	Phone             string    `db:"phone" json:"phone"`
+	Line1             string    `db:"line1" json:"line1"`
+	Line2             string    `db:"line2" json:"line2"`
+	City              string    `db:"city" json:"city"`   // Tỉnh / thành phố
+	State             string    `db:"state" json:"state"` // Quận / huyện
+	Ward              string    `db:"ward" json:"ward"`   // Phường / xã
+	Country           string    `db:"country" json:"country"`
+	ProvinceCode      string    `db:"province_code" json:"province_code"` // Mã hành chính (rỗng nếu địa chỉ nước ngoài / chưa có dữ liệu)
+	DistrictCode      string    `db:"district_code" json:"district_code"`
+	WardCode          string    `db:"ward_code" json:"ward_code"`
 	IsDefaultShipping bool      `db:"is_default_shipping" json:"is_default_shipping"`
 	CreatedAt         time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
 }
 
-//CreateAddressRequest: Dùng khi user thêm địa chỉ mới
+// CreateAddressRequest: Dùng khi user thêm địa chỉ mới
 type CreateAddressRequest struct {
 	Label             string `json:"label" validate:"omitempty,max=50"`
 	RecipientName     string `json:"recipient_name" validate:"required,min=2,max=150"`
-	Phone             string `json:"phone" validate:"required,min=9,max=20,numeric"`
+	Phone             string `json:"phone" validate:"required,min=9,max=20"` // Chuẩn hoá về E.164 khi lưu
 	Line1             string `json:"line1" validate:"required,max=255"`
 	Line2             string `json:"line2" validate:"omitempty,max=255"`
-	City              string `json:"city" validate:"required,max=100"`
-	State             string `json:"state" validate:"omitempty,max=100"`
+	City              string `json:"city" validate:"required_without=ProvinceCode,max=100"` // Tỉnh / thành phố
+	State             string `json:"state" validate:"omitempty,max=100"`                    // Quận / huyện
+	Ward              string `json:"ward" validate:"omitempty,max=100"`                     // Phường / xã
 	Country           string `json:"country" validate:"required,max=100"`
+	ProvinceCode      string `json:"province_code" validate:"omitempty,max=10"` // Mã chọn từ /api/locations (ưu tiên hơn tên)
+	DistrictCode      string `json:"district_code" validate:"omitempty,max=10"`
+	WardCode          string `json:"ward_code" validate:"omitempty,max=10"`
 	IsDefaultShipping bool   `json:"is_default_shipping"`
 }
 
@@ -37,12 +45,16 @@ type CreateAddressRequest struct {
 type UpdateAddressRequest struct {
 	Label             *string `json:"label,omitempty" validate:"omitempty,max=50"`
 	RecipientName     *string `json:"recipient_name,omitempty" validate:"omitempty,min=2,max=150"`
-	Phone             *string `json:"phone,omitempty" validate:"omitempty,min=9,max=20,numeric"`
+	Phone             *string `json:"phone,omitempty" validate:"omitempty,min=9,max=20"`
 	Line1             *string `json:"line1,omitempty" validate:"omitempty,max=255"`
 	Line2             *string `json:"line2,omitempty" validate:"omitempty,max=255"`
 	City              *string `json:"city,omitempty" validate:"omitempty,max=100"`
 	State             *string `json:"state,omitempty" validate:"omitempty,max=100"`
+	Ward              *string `json:"ward,omitempty" validate:"omitempty,max=100"`
 	Country           *string `json:"country,omitempty" validate:"omitempty,max=100"`
+	ProvinceCode      *string `json:"province_code,omitempty" validate:"omitempty,max=10"`
+	DistrictCode      *string `json:"district_code,omitempty" validate:"omitempty,max=10"`
+	WardCode          *string `json:"ward_code,omitempty" validate:"omitempty,max=10"`
 	IsDefaultShipping *bool   `json:"is_default_shipping,omitempty"`
 }
 
@@ -50,15 +62,19 @@ type UpdateAddressRequest struct {
 type AddressResponse struct {
 	ID                int64     `json:"id"`
 	UserID            int64     `json:"user_id"`
-	Label             string    `json:"label,omitempty"` 
+	Label             string    `json:"label,omitempty"`
 	RecipientName     string    `json:"recipient_name"`
 	Phone             string    `json:"phone"`
 	Line1             string    `json:"line1"`
 	Line2             string    `json:"line2,omitempty"`
 	City              string    `json:"city"`
 	State             string    `json:"state,omitempty"`
+	Ward              string    `json:"ward,omitempty"`
 	Country           string    `json:"country"`
+	ProvinceCode      string    `json:"province_code,omitempty"`
+	DistrictCode      string    `json:"district_code,omitempty"`
+	WardCode          string    `json:"ward_code,omitempty"`
 	IsDefaultShipping bool      `json:"is_default_shipping"`
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
-}
\ No newline at end of file
+}
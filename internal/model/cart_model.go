@@ -7,9 +7,11 @@ import (
 
 // Cart ánh xạ bảng 'carts'
 type Cart struct {
-	ID        int64     `db:"id"`
-	UserID    int64     `db:"user_id"`
-	CreatedAt time.Time `db:"created_at"`
+	ID         int64      `db:"id"`
+	UserID     *int64     `db:"user_id"`     // NULL với giỏ hàng của khách
+	GuestToken *string    `db:"guest_token"` // Nonce của cart token (chỉ có ở giỏ khách)
+	ExpiresAt  *time.Time `db:"expires_at"`  // Hạn của giỏ khách
+	CreatedAt  time.Time  `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
 	
 	// Field ảo để preload dữ liệu khi query, không lưu trực tiếp vào bảng carts
@@ -18,13 +20,15 @@ type Cart struct {
 
 // CartItem ánh xạ bảng 'cart_items'
 type CartItem struct {
-	ID        int64     `db:"id"`
-	CartID    int64     `db:"cart_id"`
-	VariantID int64     `db:"variant_id"`
-	ProductID int64     `db:"product_id"`
-	Quantity  int       `db:"quantity"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	ID        int64 `db:"id"`
+	CartID    int64 `db:"cart_id"`
+	VariantID int64 `db:"variant_id"`
+	ProductID int64 `db:"product_id"`
+	Quantity  int   `db:"quantity"`
+	// Giá tại thời điểm thêm vào giỏ (NULL với dữ liệu cũ)
+	PriceSnapshot *float64  `db:"price_snapshot"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
 
 	// Preload relations (dùng khi join bảng để lấy thông tin chi tiết)
 	Product *Product          `json:"product,omitempty"` 
@@ -32,6 +36,78 @@ type CartItem struct {
 }
 
 
+// CartOwner: chủ sở hữu giỏ hàng — user đã đăng nhập hoặc khách (qua cart token)
+type CartOwner struct {
+	UserID     int64
+	GuestToken string // Nonce đã được kiểm tra chữ ký
+}
+
+// IsGuest: giỏ hàng của khách chưa đăng nhập
+func (o CartOwner) IsGuest() bool {
+	return o.UserID == 0
+}
+
+// CartMergeLine: một dòng cần gộp từ giỏ khách sang giỏ user (đã giới hạn theo tồn kho)
+type CartMergeLine struct {
+	ProductID   int64
+	VariantID   int64
+	Quantity    int
+	MaxQuantity int // Tồn kho hiện tại, số lượng sau khi cộng dồn không vượt quá giá trị này
+
+	PriceSnapshot *float64 // Giá snapshot của giỏ khách (chỉ dùng khi user chưa có dòng này)
+}
+
+// Loại sự kiện giỏ hàng (bảng cart_events, dùng cho báo cáo phễu bán hàng)
+const (
+	CartEventAddToCart       = "add_to_cart"
+	CartEventRemoveFromCart  = "remove_from_cart"
+	CartEventCheckoutPreview = "checkout_preview"
+	CartEventOrderCreated    = "order_created"
+)
+
+// CartEvent: 1 dòng bảng cart_events (lịch sử thao tác giỏ hàng, giữ lại cả khi giỏ đã bị xóa)
+type CartEvent struct {
+	ID        int64     `db:"id"`
+	EventType string    `db:"event_type"`
+	CartID    *int64    `db:"cart_id"`
+	UserID    *int64    `db:"user_id"` // NULL với giỏ khách
+	ProductID *int64    `db:"product_id"`
+	VariantID *int64    `db:"variant_id"`
+	Quantity  int       `db:"quantity"`
+	Amount    float64   `db:"amount"` // Giá trị dòng (thêm / xóa) hoặc tổng tiền (preview / đơn hàng)
+	OrderID   *int64    `db:"order_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Loại thông báo trên từng dòng giỏ hàng khi kiểm tra lại giá / tồn kho
+const (
+	CartNoticePriceIncreased  = "price_increased"
+	CartNoticePriceDecreased  = "price_decreased"
+	CartNoticeOutOfStock      = "out_of_stock"
+	CartNoticeQuantityReduced = "quantity_reduced"
+	CartNoticeUnavailable     = "unavailable"
+)
+
+// Lý do sản phẩm không còn bán (đi kèm notice unavailable)
+const (
+	CartUnavailableVariantMissing   = "variant_not_found"
+	CartUnavailableVariantInactive  = "variant_inactive"
+	CartUnavailableProductMissing   = "product_not_found"
+	CartUnavailableProductNotPublic = "product_unpublished"
+)
+
+// CartNotice: Thông báo thay đổi trên 1 dòng giỏ hàng (giá trị cũ / mới)
+type CartNotice struct {
+	Type        string   `json:"type"`
+	VariantID   int64    `json:"variant_id"`
+	OldPrice    *float64 `json:"old_price,omitempty"`
+	NewPrice    *float64 `json:"new_price,omitempty"`
+	OldQuantity *int     `json:"old_quantity,omitempty"`
+	NewQuantity *int     `json:"new_quantity,omitempty"`
+	Reason      string   `json:"reason,omitempty"`
+	Fixed       bool     `json:"fixed"` // Đã được tự động điều chỉnh (auto_fix)
+}
+
 // REQUEST DTOs 
 
 // AddToCartRequest: Dùng khi user thêm hàng vào giỏ
@@ -73,13 +149,25 @@ type CartItemResponse struct {
 	
 	StockCheck    bool `json:"stock_check"`    
 	StockQuantity int  `json:"stock_quantity"` // Tồn kho thực tế 
+
+	PriceSnapshot *float64     `json:"price_snapshot,omitempty"` // Giá lúc thêm vào giỏ
+	Available     bool         `json:"available"`                // Biến thể / sản phẩm còn được bán
+	WeightGrams   int          `json:"weight_grams"`             // Khối lượng 1 sản phẩm (tính phí giao hàng)
+	Notices       []CartNotice `json:"notices,omitempty"`
 }
 
 // CartResponse: Trả về toàn bộ giỏ hàng
 type CartResponse struct {
 	ID         int64              `json:"id"`
 	UserID     int64              `json:"user_id"`
+	IsGuest    bool               `json:"is_guest"`
 	Items      []CartItemResponse `json:"items"`
+	HasNotices bool               `json:"has_notices"`
+}
+
+// GuestCartTokenResponse: Trả về cart token khi khách thêm sản phẩm lần đầu
+type GuestCartTokenResponse struct {
+	CartToken string `json:"cart_token"`
 }
 
 //  CHECKOUT / CALCULATION MODELS (Tính toán trước khi đặt hàng)
@@ -87,6 +175,12 @@ type CartResponse struct {
 // CheckoutPreviewRequest danh sách các sản phẩm được tích chọn 
 type CheckoutPreviewRequest struct {
 	SelectedVariantIDs []int64 `json:"selected_variant_ids" validate:"required,min=1"`
+	// Tự động giảm số lượng về tồn kho và chấp nhận giá mới trước khi tính
+	AutoFix bool `json:"auto_fix"`
+
+	// Địa chỉ nhận hàng (chỉ user đăng nhập) + phương thức giao hàng (mặc định standard) để tính phí ship
+	AddressID      int64  `json:"address_id" validate:"omitempty,gt=0"`
+	ShippingMethod string `json:"shipping_method" validate:"omitempty,oneof=standard express"`
 }
 
 // CheckoutPreviewResponse: Trả về tổng tiền của các món đã chọn
@@ -96,4 +190,15 @@ type CheckoutPreviewResponse struct {
 	
 	// Trả lại danh sách chi tiết để hiển thị
 	Items         []CartItemResponse `json:"items"` 
+
+	// Các thông báo của dòng được chọn; CanCheckout = false nếu còn dòng không mua được
+	Notices     []CartNotice `json:"notices"`
+	CanCheckout bool         `json:"can_checkout"`
+
+	// Phí giao hàng (khi có address_id): các phương thức dùng được + phương thức đang chọn
+	ShippingOptions []ShippingQuote `json:"shipping_options,omitempty"`
+	Shipping        *ShippingQuote  `json:"shipping,omitempty"`
+	ShippingError   string          `json:"shipping_error,omitempty"`
+	ShippingFee     float64         `json:"shipping_fee"`
+	GrandTotal      float64         `json:"grand_total"` // TotalPrice + ShippingFee
 }
\ No newline at end of file
@@ -10,6 +10,8 @@ type Category struct {
 	Slug        string    `db:"slug"`
 	Description *string   `db:"description"` 
 	IsActive    bool      `db:"is_active"`
+	ParentID    *int64    `db:"parent_id"`
+	Position    int       `db:"position"`
 	CreatedAt   time.Time `db:"created_at"`
 	UpdatedAt   time.Time `db:"updated_at"`
 }
@@ -21,6 +23,7 @@ type CreateCategoryRequest struct {
 	Slug        string  `json:"slug" validate:"omitempty,min=2,max=100"` 
 	Description string  `json:"description" validate:"omitempty,max=500"`
 	IsActive    *bool   `json:"is_active,omitempty"` 
+	ParentID    *int64  `json:"parent_id,omitempty" validate:"omitempty,min=1"`
 }
 
 // UpdateCategoryRequest: Dùng khi cập nhật danh mục (Partial Update)
@@ -32,6 +35,12 @@ type UpdateCategoryRequest struct {
 	UpdatedAt   time.Time `db:"updated_at,omitempty"`
 }
 
+// MoveCategoryRequest: Dùng khi chuyển danh mục sang cha khác / đổi vị trí (parent_id null = lên gốc)
+type MoveCategoryRequest struct {
+	ParentID *int64 `json:"parent_id" validate:"omitempty,min=1"`
+	Position *int   `json:"position,omitempty" validate:"omitempty,min=0"`
+}
+
 // DeleteManyCategoriesRequest: Dùng để xóa nhiều danh mục cùng lúc
 type DeleteManyCategoriesRequest struct {
 	IDs []int64 `json:"ids" validate:"required,min=1"`
@@ -58,6 +67,8 @@ type AdminCategoryResponse struct {
 	Slug        string    `json:"slug"`
 	Description *string   `json:"description,omitempty"` 
 	IsActive    bool      `json:"is_active"`
+	ParentID    *int64    `json:"parent_id,omitempty"`
+	Position    int       `json:"position"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -76,4 +87,21 @@ type UserCategoryResponse struct {
 // Response cho danh sách User (Menu)
 type UserCategoryListResponse struct {
 	Categories []UserCategoryResponse `json:"categories"`
-}
\ No newline at end of file
+}
+
+// CategoryTreeNode: Một nút trong cây danh mục
+type CategoryTreeNode struct {
+	ID       int64               `json:"id"`
+	Name     string              `json:"name"`
+	Slug     string              `json:"slug"`
+	IsActive bool                `json:"is_active"`
+	Position int                 `json:"position"`
+	Children []*CategoryTreeNode `json:"children"`
+}
+
+// CategoryBreadcrumb: Một mắt xích trong đường dẫn danh mục (VD: Thời trang > Nam > Áo thun)
+type CategoryBreadcrumb struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
@@ -0,0 +1,86 @@
+package model
+
+import "time"
+
+// Kiểu dữ liệu của thuộc tính danh mục
+const (
+	AttributeTypeText    = "text"
+	AttributeTypeNumber  = "number"
+	AttributeTypeEnum    = "enum"
+	AttributeTypeBoolean = "boolean"
+)
+
+// CategoryAttribute - Định nghĩa 1 thông số của danh mục (ánh xạ bảng category_attributes)
+type CategoryAttribute struct {
+	ID           int64     `db:"id" json:"id"`
+	CategoryID   int64     `db:"category_id" json:"category_id"`
+	Code         string    `db:"code" json:"code"`
+	Name         string    `db:"name" json:"name"`
+	DataType     string    `db:"data_type" json:"data_type"`
+	Unit         *string   `db:"unit" json:"unit,omitempty"`
+	EnumValues   []string  `db:"enum_values" json:"enum_values,omitempty"`
+	IsRequired   bool      `db:"is_required" json:"is_required"`
+	IsFilterable bool      `db:"is_filterable" json:"is_filterable"`
+	Position     int       `db:"position" json:"position"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// CreateCategoryAttributeRequest: Thêm thông số cho danh mục
+type CreateCategoryAttributeRequest struct {
+	Code         string   `json:"code" validate:"required,min=1,max=100"`
+	Name         string   `json:"name" validate:"required,min=1,max=255"`
+	DataType     string   `json:"data_type" validate:"required,oneof=text number enum boolean"`
+	Unit         string   `json:"unit" validate:"omitempty,max=50"`
+	EnumValues   []string `json:"enum_values" validate:"omitempty,dive,required,max=255"`
+	IsRequired   bool     `json:"is_required"`
+	IsFilterable bool     `json:"is_filterable"`
+	Position     int      `json:"position" validate:"omitempty,min=0"`
+}
+
+// UpdateCategoryAttributeRequest: Cập nhật thông số (không cho đổi code / data_type)
+type UpdateCategoryAttributeRequest struct {
+	Name         *string  `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	Unit         *string  `json:"unit,omitempty" validate:"omitempty,max=50"`
+	EnumValues   []string `json:"enum_values,omitempty" validate:"omitempty,dive,required,max=255"`
+	IsRequired   *bool    `json:"is_required,omitempty"`
+	IsFilterable *bool    `json:"is_filterable,omitempty"`
+	Position     *int     `json:"position,omitempty" validate:"omitempty,min=0"`
+}
+
+// ProductAttributeValue - Giá trị thông số của sản phẩm (kèm định nghĩa để hiển thị)
+type ProductAttributeValue struct {
+	AttributeID int64   `json:"attribute_id"`
+	Code        string  `json:"code"`
+	Name        string  `json:"name"`
+	DataType    string  `json:"data_type"`
+	Unit        *string `json:"unit,omitempty"`
+	Value       any     `json:"value"`
+}
+
+// ProductAttributeValueInput - Giá trị đã chuẩn hóa để lưu DB
+type ProductAttributeValueInput struct {
+	AttributeID int64
+	ValueText   string
+	ValueNumber *float64
+}
+
+// SetProductAttributesRequest: Ghi đè thông số của sản phẩm (key = code của thuộc tính)
+type SetProductAttributesRequest struct {
+	Attributes map[string]any `json:"attributes" validate:"required"`
+}
+
+// ProductAttributesResponse: Thông số của sản phẩm
+type ProductAttributesResponse struct {
+	Message    string                  `json:"message,omitempty"`
+	ProductID  int64                   `json:"product_id"`
+	Attributes []ProductAttributeValue `json:"attributes"`
+}
+
+// AttributeFilter - Lọc sản phẩm theo thông số (Values: khớp 1 trong các giá trị, Min/Max: khoảng số)
+type AttributeFilter struct {
+	Code   string
+	Values []string
+	Min    *float64
+	Max    *float64
+}
@@ -0,0 +1,85 @@
+package model
+
+import "time"
+
+// Loại dữ liệu export
+const (
+	ExportKindOrders      = "orders"
+	ExportKindTopProducts = "top_products"
+	ExportKindUsers       = "users"
+)
+
+// Trạng thái job export chạy nền
+const (
+	ExportStatusPending = "pending" // Chờ chạy
+	ExportStatusRunning = "running" // Đang ghi file
+	ExportStatusDone    = "done"    // Có thể tải về
+	ExportStatusFailed  = "failed"  // Lỗi, xem error_message
+	ExportStatusExpired = "expired" // File đã bị dọn
+)
+
+// ExportRequest: Tham số chung của các API export (?format=csv|xlsx&async=true)
+type ExportRequest struct {
+	Format string `validate:"omitempty,oneof=csv xlsx"` // Mặc định csv
+	Async  bool   // true = luôn chạy nền, bất kể số dòng
+}
+
+// ExportJob: Bảng export_jobs
+type ExportJob struct {
+	ID           int64      `db:"id"`
+	Kind         string     `db:"kind"`
+	Format       string     `db:"format"`
+	Filters      string     `db:"filters"` // JSON của filter lúc yêu cầu
+	Status       string     `db:"status"`
+	RowCount     int64      `db:"row_count"`
+	FileName     string     `db:"file_name"`
+	FilePath     *string    `db:"file_path"`
+	ErrorMessage *string    `db:"error_message"`
+	RequestedBy  int64      `db:"requested_by"`
+	CreatedAt    time.Time  `db:"created_at"`
+	StartedAt    *time.Time `db:"started_at"`
+	FinishedAt   *time.Time `db:"finished_at"`
+	ExpiresAt    *time.Time `db:"expires_at"`
+}
+
+// ExportJobResponse: Trạng thái job + link tải khi đã xong
+type ExportJobResponse struct {
+	ID           int64      `json:"id"`
+	Kind         string     `json:"kind"`
+	Format       string     `json:"format"`
+	Status       string     `json:"status"`
+	RowCount     int64      `json:"row_count"`
+	FileName     string     `json:"file_name"`
+	ErrorMessage *string    `json:"error_message,omitempty"`
+	DownloadURL  string     `json:"download_url,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// OrderExportLine: 1 dòng sản phẩm của đơn hàng khi export (thông tin đơn lặp lại trên mỗi dòng)
+type OrderExportLine struct {
+	OrderID         int64
+	OrderNumber     string
+	PlacedAt        time.Time
+	Status          string
+	PaymentStatus   string
+	UserID          int64
+	Username        string
+	Email           string
+	RecipientName   string
+	Phone           string
+	ShippingAddress string
+	SubtotalAmount  float64 // Đơn cũ chưa tách phí giao hàng: đã lấy theo total_amount
+	ShippingFee     float64
+	TotalAmount     float64
+	PaidAt          *time.Time
+	CompletedAt     *time.Time
+
+	SKU          string
+	Title        string
+	OptionValues *string
+	UnitPrice    float64
+	Quantity     int
+	LineSubtotal float64
+}
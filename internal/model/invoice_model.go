@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Tiền tố số hóa đơn: INV-<năm>-<số thứ tự 6 chữ số>
+const InvoiceNumberPrefix = "INV"
+
+// Invoice: Bảng invoices (file PDF đã render được lưu lại, tải lại luôn giống hệt)
+type Invoice struct {
+	ID             int64     `json:"id"              db:"id"`
+	OrderID        int64     `json:"order_id"        db:"order_id"`
+	InvoiceNumber  string    `json:"invoice_number"  db:"invoice_number"`
+	Year           int       `json:"year"            db:"year"`
+	SequenceNumber int64     `json:"sequence_number" db:"sequence_number"`
+	PDF            []byte    `json:"-"               db:"pdf"`
+	IssuedAt       time.Time `json:"issued_at"       db:"issued_at"`
+	CreatedAt      time.Time `json:"created_at"      db:"created_at"`
+}
+
+// InvoiceStore: Thông tin cửa hàng in trên hóa đơn (biến môi trường STORE_*)
+type InvoiceStore struct {
+	Name    string
+	Address string
+	Phone   string
+	Email   string
+	TaxCode string
+}
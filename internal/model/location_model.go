@@ -0,0 +1,39 @@
+package model
+
+// Cấp đơn vị hành chính
+const (
+	LocationLevelProvince = "province" // Tỉnh / thành phố trực thuộc trung ương
+	LocationLevelDistrict = "district" // Quận / huyện / thị xã
+	LocationLevelWard     = "ward"     // Phường / xã / thị trấn
+)
+
+// AdministrativeUnit - Một đơn vị hành chính Việt Nam (mã theo Tổng cục Thống kê)
+type AdministrativeUnit struct {
+	Code         string `json:"code"`
+	Name         string `json:"name"`
+	DivisionType string `json:"division_type"`
+	ParentCode   string `json:"parent_code,omitempty"`
+	HasChildren  bool   `json:"has_children"` // Bộ dữ liệu có cấp con hay không (để FE biết có cần hiện dropdown tiếp)
+}
+
+// LocationInput - Địa chỉ người dùng nhập: theo mã (từ dropdown) hoặc theo tên (gõ tay)
+type LocationInput struct {
+	Country      string
+	ProvinceCode string
+	Province     string
+	DistrictCode string
+	District     string
+	WardCode     string
+	Ward         string
+}
+
+// ResolvedLocation - Địa chỉ đã chuẩn hoá: tên hiển thị chuẩn + mã hành chính (rỗng nếu không xác định)
+type ResolvedLocation struct {
+	Country      string
+	ProvinceCode string
+	Province     string
+	DistrictCode string
+	District     string
+	WardCode     string
+	Ward         string
+}
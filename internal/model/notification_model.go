@@ -0,0 +1,17 @@
+package model
+
+// Loại thông báo gửi tới người dùng / admin
+const (
+	NotificationBackInStock = "back_in_stock"
+	NotificationLowStock    = "low_stock" // Gửi admin khi biến thể xuống dưới ngưỡng đặt hàng lại
+)
+
+// Notification - Một thông báo cần gửi qua Notifier (email, push, log...)
+type Notification struct {
+	Type   string                 `json:"type"`
+	UserID int64                  `json:"user_id,omitempty"`
+	Email  string                 `json:"email,omitempty"`
+	Title  string                 `json:"title"`
+	Body   string                 `json:"body"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+}
@@ -18,7 +18,11 @@ type OrderAddress struct {
 	Line2         string    `json:"line2"          db:"line2"` 
 	City          string    `json:"city"           db:"city"`
 	State         string    `json:"state"          db:"state"`
+	Ward          string    `json:"ward"           db:"ward"`
 	Country       string    `json:"country"        db:"country"`
+	ProvinceCode  string    `json:"province_code"  db:"province_code"`
+	DistrictCode  string    `json:"district_code"  db:"district_code"`
+	WardCode      string    `json:"ward_code"      db:"ward_code"`
 	
 	CreatedAt     time.Time `json:"created_at"     db:"created_at"`
 }
@@ -13,7 +13,9 @@ type OrderItem struct {
 	Title        string    `json:"title"         db:"title"`
 	OptionValues *string   `json:"option_values" db:"option_values"`
 	UnitPrice    float64   `json:"unit_price"    db:"unit_price"`
+	UnitCost     *float64  `json:"-"             db:"unit_cost"` // Giá vốn lúc đặt đơn, chỉ dùng cho báo cáo nội bộ
 	Quantity     int       `json:"quantity"      db:"quantity"`
+	ReturnedQuantity int   `json:"returned_quantity" db:"returned_quantity"` // Số lượng đã nhận hàng trả lại (module returns)
 	LineSubtotal float64   `json:"line_subtotal" db:"line_subtotal"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 }
@@ -31,5 +33,6 @@ type OrderItemResponse struct {
 
 	UnitPrice    string `json:"unit_price"`
 	Quantity     int    `json:"quantity"`
+	ReturnedQuantity int `json:"returned_quantity,omitempty"`
 	LineSubtotal string `json:"line_subtotal"`
 }
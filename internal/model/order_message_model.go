@@ -0,0 +1,88 @@
+package model
+
+import (
+	"io"
+	"time"
+)
+
+// Người viết tin nhắn trong đơn hàng
+const (
+	OrderMessageAuthorCustomer = "customer"
+	OrderMessageAuthorStaff    = "staff"
+)
+
+// Phạm vi hiển thị: public = khách thấy và trả lời được, internal = ghi chú nội bộ, khách không bao giờ thấy
+const (
+	OrderMessagePublic   = "public"
+	OrderMessageInternal = "internal"
+)
+
+// OrderMessage: Bảng order_messages
+type OrderMessage struct {
+	ID          int64     `db:"id"`
+	OrderID     int64     `db:"order_id"`
+	AuthorID    int64     `db:"author_id"`
+	AuthorRole  string    `db:"author_role"`
+	AuthorName  string    `db:"-"` // users.username
+	Visibility  string    `db:"visibility"`
+	Body        string    `db:"body"`
+	CreatedAt   time.Time `db:"created_at"`
+	Attachments []OrderMessageAttachment
+}
+
+// OrderMessageAttachment: Bảng order_message_attachments (file nằm trong Storage theo storage_key)
+type OrderMessageAttachment struct {
+	ID          int64     `db:"id"`
+	MessageID   int64     `db:"message_id"`
+	FileName    string    `db:"file_name"`
+	ContentType string    `db:"content_type"`
+	SizeBytes   int64     `db:"size_bytes"`
+	StorageKey  string    `db:"storage_key"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// Gửi tin nhắn (JSON hoặc multipart/form-data kèm file "attachments")
+type SendOrderMessageRequest struct {
+	Body string `json:"body" validate:"required,max=5000"`
+	// Chỉ admin dùng, mặc định public
+	Visibility string `json:"visibility" validate:"omitempty,oneof=public internal"`
+}
+
+// AttachmentUpload: File đính kèm handler đọc từ multipart
+type AttachmentUpload struct {
+	FileName string
+	Size     int64
+	Content  io.Reader
+}
+
+type OrderMessageAttachmentResponse struct {
+	ID          int64  `json:"id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	SizeBytes   int64  `json:"size_bytes"`
+}
+
+type OrderMessageResponse struct {
+	ID          int64                            `json:"id"`
+	OrderID     int64                            `json:"order_id"`
+	AuthorID    int64                            `json:"author_id"`
+	AuthorName  string                           `json:"author_name"`
+	AuthorRole  string                           `json:"author_role"`
+	Visibility  string                           `json:"visibility"`
+	Body        string                           `json:"body"`
+	Attachments []OrderMessageAttachmentResponse `json:"attachments"`
+	CreatedAt   time.Time                        `json:"created_at"`
+}
+
+// Số tin chưa đọc của 1 đơn hàng
+type OrderUnreadMessages struct {
+	OrderID       int64     `json:"order_id"`
+	OrderNumber   string    `json:"order_number"`
+	Unread        int       `json:"unread"`
+	LastMessageAt time.Time `json:"last_message_at"`
+}
+
+type OrderUnreadResponse struct {
+	TotalUnread int                   `json:"total_unread"`
+	Orders      []OrderUnreadMessages `json:"orders"`
+}
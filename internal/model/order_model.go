@@ -32,7 +32,9 @@ type Order struct {
 	OrderNumber   string     `json:"order_number"    db:"order_number"`
 	UserID        int64      `json:"user_id"         db:"user_id"`
 	Status        string     `json:"status"          db:"status"`
-	TotalAmount   float64    `json:"total_amount"    db:"total_amount"`
+	SubtotalAmount float64   `json:"subtotal_amount" db:"subtotal_amount"` // Tiền hàng
+	ShippingFee   float64    `json:"shipping_fee"    db:"shipping_fee"`
+	TotalAmount   float64    `json:"total_amount"    db:"total_amount"`    // Tiền hàng + phí giao hàng
 	PaymentStatus string     `json:"payment_status"  db:"payment_status"`
 	Note          *string    `json:"note"            db:"note"`       
 	PlacedAt      time.Time  `json:"placed_at"       db:"placed_at"`
@@ -55,6 +57,8 @@ type CreateOrderRequest struct {
 	AddressID int64  `json:"address_id" validate:"required,gt=0"` 
 	Note      string `json:"note"       validate:"omitempty,max=1000"`
 	PaymentMethod string `json:"payment_method" validate:"required,oneof=cod bank_transfer"`
+	// Phương thức giao hàng, mặc định standard
+	ShippingMethod string `json:"shipping_method" validate:"omitempty,oneof=standard express"`
 	Items []CreateOrderItemRequest `json:"items" validate:"required,min=1,dive"`
 	
 }
@@ -87,10 +91,13 @@ type OrderResponse struct {
 	ID            int64      `json:"id"`
 	OrderNumber   string     `json:"order_number"`
 	Status        string     `json:"status"`
+	SubtotalAmount string   `json:"subtotal_amount"`
+	ShippingFee   string     `json:"shipping_fee"`
 	TotalAmount   string    `json:"total_amount"`
 	PaymentStatus string     `json:"payment_status"`
 	Note          string     `json:"note,omitempty"` 
 	ShippingAddress *OrderAddress `json:"shipping_address,omitempty"`
+	Shipping *OrderShippingLineResponse `json:"shipping,omitempty"`
 	Items []OrderItemResponse `json:"items,omitempty"`
 	Payments []OrderPaymentResponse `json:"payments,omitempty"`
 	PlacedAt      time.Time  `json:"placed_at"`
@@ -58,3 +58,33 @@ type ProductHistoryResponse struct {
 	Changes   json.RawMessage `json:"changes"`
 	Note      *string         `json:"note"`
 }
+
+// HistoryFieldDiff - Diff của một field trong bản ghi lịch sử
+// Với field dạng tập hợp (categories) sẽ có thêm Added / Removed
+type HistoryFieldDiff struct {
+	Field    string      `json:"field"`
+	OldValue interface{} `json:"old_value"`
+	NewValue interface{} `json:"new_value"`
+	Added    []int64     `json:"added,omitempty"`
+	Removed  []int64     `json:"removed,omitempty"`
+}
+
+// ProductHistoryDiffResponse - DTO trả về diff của một bản ghi lịch sử
+type ProductHistoryDiffResponse struct {
+	Message   string             `json:"message,omitempty"`
+	HistoryID int64              `json:"history_id"`
+	ProductID int64              `json:"product_id"`
+	VariantID *int64             `json:"variant_id"`
+	AdminID   *int64             `json:"admin_id"`
+	ChangedAt time.Time          `json:"changed_at"`
+	Note      *string            `json:"note"`
+	Fields    []HistoryFieldDiff `json:"fields"`
+}
+
+// RevertProductHistoryResponse - Kết quả revert một bản ghi lịch sử
+type RevertProductHistoryResponse struct {
+	Message        string   `json:"message"`
+	HistoryID      int64    `json:"history_id"`
+	RevertedFields []string `json:"reverted_fields"`
+	SkippedFields  []string `json:"skipped_fields,omitempty"` // Field không thể khôi phục qua luồng update (VD: giá trị cũ NULL)
+}
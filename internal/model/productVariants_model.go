@@ -3,40 +3,46 @@ package model
 import "time"
 
 type ProductsVariants struct {
-	ID             int64     `db:"id"`
-	ProductID      int64     `db:"product_id"`
-	SKU            string    `db:"sku"`
-	Title          *string   `db:"title"`
-	OptionValues   *string   `db:"option_value"`
-	PriceOverride  *float64  `db:"price_override"`
-	CostPrice      *float64  `db:"cost_price"`
-	StockQuantity  int       `db:"stock_quantity"`
-	AllowBackorder bool      `db:"allow_backorder"`
-	IsActive       bool      `db:"is_active"`
-	CreatedAt      time.Time `db:"created_at"`
-	UpdatedAt      time.Time `db:"update_at"`
+	ID               int64     `db:"id"`
+	ProductID        int64     `db:"product_id"`
+	SKU              string    `db:"sku"`
+	Title            *string   `db:"title"`
+	OptionValues     *string   `db:"option_value"`
+	PriceOverride    *float64  `db:"price_override"`
+	CostPrice        *float64  `db:"cost_price"`
+	StockQuantity    int       `db:"stock_quantity"`
+	AllowBackorder   bool      `db:"allow_backorder"`
+	IsActive         bool      `db:"is_active"`
+	WeightGrams      *int      `db:"weight_grams"`      // Khối lượng (gram) dùng tính phí giao hàng
+	ReorderThreshold *int      `db:"reorder_threshold"` // Ngưỡng cảnh báo sắp hết hàng (nil = INVENTORY_DEFAULT_REORDER_THRESHOLD)
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"update_at"`
 }
 
 type CreateVariantRequest struct {
-	SKU            string  `json:"sku" validate:"required"`
-	Title          string  `json:"title" validate:"omitempty,min=3"`
-	OptionValues   string  `json:"option_value" validate:"required"`
-	PriceOverride  float64 `json:"price_override" validate:"gte=0"`
-	CostPrice      float64 `json:"cost_price" validate:"gte=0"`
-	StockQuantity  int     `json:"stock_quantity" validate:"gte=0"`
-	IsActive       bool    `json:"is_active"`
-	AllowBackorder bool    `json:"allow_backorder"`
+	SKU              string   `json:"sku" validate:"required"`
+	Title            string   `json:"title" validate:"omitempty,min=3"`
+	OptionValues     string   `json:"option_value" validate:"required"`
+	PriceOverride    float64  `json:"price_override" validate:"gte=0"`
+	CostPrice        *float64 `json:"cost_price" validate:"omitempty,gte=0"` // nil -> chưa có giá vốn
+	StockQuantity    int      `json:"stock_quantity" validate:"gte=0"`
+	IsActive         bool     `json:"is_active"`
+	AllowBackorder   bool     `json:"allow_backorder"`
+	WeightGrams      *int     `json:"weight_grams" validate:"omitempty,gte=0"`
+	ReorderThreshold *int     `json:"reorder_threshold" validate:"omitempty,gte=0"`
 }
 
 type UpdateVariantRequest struct {
-	SKU            string  `json:"sku" validate:"required"`
-	Title          string  `json:"title" validate:"omitempty,min=3"`
-	OptionValues   string  `json:"option_value" validate:"required"`
-	PriceOverride  float64 `json:"price_override" validate:"gte=0"`
-	CostPrice      float64 `json:"cost_price" validate:"gte=0"`
-	StockQuantity  int     `json:"stock_quantity" validate:"gte=0"`
-	IsActive       bool    `json:"is_active"`
-	AllowBackorder bool    `json:"allow_backorder"`
+	SKU              string   `json:"sku" validate:"required"`
+	Title            string   `json:"title" validate:"omitempty,min=3"`
+	OptionValues     string   `json:"option_value" validate:"required"`
+	PriceOverride    float64  `json:"price_override" validate:"gte=0"`
+	CostPrice        *float64 `json:"cost_price" validate:"omitempty,gte=0"` // nil -> chưa có giá vốn
+	StockQuantity    int      `json:"stock_quantity" validate:"gte=0"`
+	IsActive         bool     `json:"is_active"`
+	AllowBackorder   bool     `json:"allow_backorder"`
+	WeightGrams      *int     `json:"weight_grams" validate:"omitempty,gte=0"`
+	ReorderThreshold *int     `json:"reorder_threshold" validate:"omitempty,gte=0"`
 }
 
 type CreateVariantResponse struct {
@@ -54,18 +60,20 @@ type DeleteVariantResponse struct {
 }
 
 type AdminVariantResponse struct {
-	ID             int64    `json:"id"`
-	ProductID      int64    `json:"product_id"`
-	SKU            string   `json:"sku"`
-	Title          *string  `json:"title"`
-	OptionValues   *string  `json:"option_values"`
-	PriceOverride  *float64 `json:"price_override"`
-	CostPrice      *float64 `json:"cost_price"`
-	StockQuantity  int      `json:"stock_quantity"`
-	IsActive       bool     `json:"is_active"`
-	AllowBackorder bool     `json:"allow_backorder"`
-	CreatedAt      string   `json:"created_at"`
-	UpdatedAt      string   `json:"updated_at"`
+	ID               int64    `json:"id"`
+	ProductID        int64    `json:"product_id"`
+	SKU              string   `json:"sku"`
+	Title            *string  `json:"title"`
+	OptionValues     *string  `json:"option_values"`
+	PriceOverride    *float64 `json:"price_override"`
+	CostPrice        *float64 `json:"cost_price"`
+	StockQuantity    int      `json:"stock_quantity"`
+	IsActive         bool     `json:"is_active"`
+	AllowBackorder   bool     `json:"allow_backorder"`
+	WeightGrams      *int     `json:"weight_grams"`
+	ReorderThreshold *int     `json:"reorder_threshold"`
+	CreatedAt        string   `json:"created_at"`
+	UpdatedAt        string   `json:"updated_at"`
 }
 
 type UserVariantResponse struct {
@@ -74,3 +82,75 @@ type UserVariantResponse struct {
 	Price         float64 `json:"price"`
 	StockQuantity int     `json:"stock_quantity"`
 }
+
+// =================================================================
+// OPTION MATRIX (Định nghĩa thuộc tính biến thể ở cấp sản phẩm)
+// =================================================================
+
+// ProductOption - Một thuộc tính của sản phẩm (VD: Color: Red/Blue)
+type ProductOption struct {
+	ID        int64    `db:"id" json:"id"`
+	ProductID int64    `db:"product_id" json:"product_id"`
+	Name      string   `db:"name" json:"name"`
+	Values    []string `db:"value_list" json:"values"`
+	Position  int      `db:"position" json:"position"`
+}
+
+type ProductOptionInput struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Values []string `json:"values" validate:"required,min=1,dive,required,max=100"`
+}
+
+// SetProductOptionsRequest - Ghi đè toàn bộ option của sản phẩm (thứ tự mảng = position)
+type SetProductOptionsRequest struct {
+	Options []ProductOptionInput `json:"options" validate:"required,min=1,dive"`
+}
+
+// GenerateVariantMatrixRequest - Sinh các biến thể còn thiếu từ option matrix
+type GenerateVariantMatrixRequest struct {
+	SKUPrefix        string   `json:"sku_prefix" validate:"omitempty,max=50"`
+	PriceOverride    *float64 `json:"price_override" validate:"omitempty,gte=0"` // nil -> dùng giá sản phẩm
+	CostPrice        *float64 `json:"cost_price" validate:"omitempty,gte=0"`     // nil -> chưa có giá vốn
+	StockQuantity    int      `json:"stock_quantity" validate:"gte=0"`
+	IsActive         bool     `json:"is_active"`
+	AllowBackorder   bool     `json:"allow_backorder"`
+	WeightGrams      *int     `json:"weight_grams" validate:"omitempty,gte=0"`
+	ReorderThreshold *int     `json:"reorder_threshold" validate:"omitempty,gte=0"`
+}
+
+// AdjustVariantStockRequest - Điều chỉnh tồn kho theo chênh lệch (dương: nhập kho, âm: xuất kho)
+type AdjustVariantStockRequest struct {
+	Delta  int    `json:"delta" validate:"required,ne=0"`
+	Reason string `json:"reason" validate:"omitempty,max=255"`
+}
+
+type AdjustVariantStockResponse struct {
+	Message   string `json:"msg"`
+	VariantID int64  `json:"variant_id"`
+	OldStock  int    `json:"old_stock"`
+	NewStock  int    `json:"new_stock"`
+}
+
+type ProductOptionsResponse struct {
+	Message string          `json:"msg"`
+	Options []ProductOption `json:"options"`
+}
+
+type GenerateVariantMatrixResponse struct {
+	Message string                 `json:"msg"`
+	Created []AdminVariantResponse `json:"created"`
+	Skipped int                    `json:"skipped"` // Số tổ hợp đã có biến thể
+}
+
+// OptionCombination - Một tổ hợp option kèm tình trạng còn hàng (Storefront)
+type OptionCombination struct {
+	Options   map[string]string `json:"options"`
+	VariantID *int64            `json:"variant_id,omitempty"`
+	Price     *float64          `json:"price,omitempty"`
+	Available bool              `json:"available"`
+}
+
+type VariantOptionMatrix struct {
+	Options      []ProductOption     `json:"options"`
+	Combinations []OptionCombination `json:"combinations"`
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -19,9 +21,12 @@ type Product struct {
 	Status           string  `db:"status"`
 	IsPublished      bool    `db:"is_published"`
 
-	PublishedAt *time.Time `db:"published_at"` // Có thể NULL
+	PublishedAt   *time.Time `db:"published_at"`   // Có thể NULL
+	UnpublishedAt *time.Time `db:"unpublished_at"` // Có thể NULL - Thời điểm tự động ẩn
 
-	MinPrice    float64 `db:"min_price"`
+	BasePrice   float64 `db:"base_price"` // Giá gốc cho biến thể không có price_override (Admin nhập)
+	MinPrice    float64 `db:"min_price"`  // Tự tính từ giá các biến thể active
+	MaxPrice    float64 `db:"max_price"`  // Tự tính từ giá các biến thể active
 	AvgRating   float64 `db:"avg_rating"`
 	RatingCount int     `db:"rating_count"`
 	CreatedBy   *int64  `db:"created_by"` // Có thể NULL
@@ -35,6 +40,21 @@ type Product struct {
 	Reviews    []ProductReview    `json:"reviews,omitempty"`
 }
 
+// IsVisible - Sản phẩm có đang hiển thị cho User tại thời điểm now hay không
+// (đã publish và nằm trong khung giờ published_at / unpublished_at)
+func (p *Product) IsVisible(now time.Time) bool {
+	if !p.IsPublished {
+		return false
+	}
+	if p.PublishedAt != nil && p.PublishedAt.After(now) {
+		return false
+	}
+	if p.UnpublishedAt != nil && !p.UnpublishedAt.After(now) {
+		return false
+	}
+	return true
+}
+
 // 2. REQUEST DTOs (Data Transfer Objects - Nhận Input)
 
 // GetProductRequest dùng cho tìm kiếm chi tiết một sản phẩm
@@ -48,29 +68,63 @@ type GetProductRequest struct {
 type CreateProductRequest struct {
 	Name             string  `json:"name" validate:"required,min=3,max=255"`
 	Slug             string  `json:"slug" validate:"omitempty,min=3,max=255"`
-	MinPrice         float64 `json:"min_price" validate:"required,gt=0"`
+	BasePrice        float64 `json:"base_price" validate:"required_without=MinPrice,omitempty,gt=0"` // Giá gốc (min_price / max_price tự tính từ biến thể)
+	MinPrice         float64 `json:"min_price" validate:"omitempty,gt=0"`                             // Deprecated: tên cũ của base_price, giữ 1 bản phát hành
 	ShortDescription string  `json:"short_description" validate:"omitempty,max=500"`
 	Description      string  `json:"description" validate:"omitempty"`
 	Brand            string  `json:"brand" validate:"omitempty,max=100"`
 	Status           string  `json:"status" validate:"omitempty,oneof=draft active inactive archived"`
 	IsPublished      bool    `json:"is_published"`
 	PublishedAt      string  `json:"published_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	UnpublishedAt    string  `json:"unpublished_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
 	CategoryIDs      []int64 `json:"category_ids" validate:"required,min=1"`
+	// Thông số kỹ thuật theo schema của danh mục (key = code)
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
 // UpdateProductRequest dùng cho việc cập nhật sản phẩm (Admin)
 type UpdateProductRequest struct {
-	Name             string   `json:"name" validate:"omitempty,min=3,max=255"`
-	Slug             string   `json:"slug" validate:"omitempty,min=3,max=255"`
-	MinPrice         *float64 `json:"min_price" validate:"omitempty,min=0"`
-	ShortDescription string   `json:"short_description" validate:"omitempty,max=500"`
-	Description      string   `json:"description" validate:"omitempty"`
-	Brand            string   `json:"brand" validate:"omitempty,max=100"`
-	Status           string   `json:"status" validate:"omitempty,oneof=draft active inactive archived"`
-	IsPublished      *bool    `json:"is_published"`
-	PublishedAt      string   `json:"published_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
-	Note             string   `json:"note" validate:"required,max=1000"`
-	CategoryIDs      []int64  `json:"category_ids" validate:"omitempty,min=1"`
+	Name             string       `json:"name" validate:"omitempty,min=3,max=255"`
+	Slug             string       `json:"slug" validate:"omitempty,min=3,max=255"`
+	BasePrice        *float64     `json:"base_price" validate:"omitempty,gt=0"`
+	MinPrice         *float64     `json:"min_price" validate:"omitempty,gt=0"` // Deprecated: tên cũ của base_price, giữ 1 bản phát hành
+	ShortDescription string       `json:"short_description" validate:"omitempty,max=500"`
+	Description      string       `json:"description" validate:"omitempty"`
+	Brand            string       `json:"brand" validate:"omitempty,max=100"`
+	Status           string       `json:"status" validate:"omitempty,oneof=draft active inactive archived"`
+	IsPublished      *bool        `json:"is_published"`
+	PublishedAt      OptionalTime `json:"published_at"`   // null / "" = xóa lịch publish
+	UnpublishedAt    OptionalTime `json:"unpublished_at"` // null / "" = xóa lịch gỡ publish
+	Note             string       `json:"note" validate:"required,max=1000"`
+	CategoryIDs      []int64      `json:"category_ids" validate:"omitempty,min=1"`
+	// Thông số gửi kèm khi đổi danh mục (key = code), ghi đè giá trị hiện tại trước khi đối chiếu với schema mới
+	Attributes map[string]any `json:"attributes,omitempty"`
+
+	// Chỉ dùng nội bộ khi revert lịch sử: gán đúng giá trị cũ cho short_description / description / brand (nil = NULL)
+	RevertText map[string]*string `json:"-"`
+}
+
+// OptionalTime - Trường thời gian của request cập nhật: không gửi = giữ nguyên, null / "" = xóa, chuỗi RFC3339 = giá trị mới
+type OptionalTime struct {
+	Set   bool       // Request có gửi trường này
+	Value *time.Time // nil = xóa
+}
+
+func (o *OptionalTime) UnmarshalJSON(data []byte) error {
+	var raw *string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	o.Set, o.Value = true, nil
+	if raw == nil || strings.TrimSpace(*raw) == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, *raw)
+	if err != nil {
+		return err
+	}
+	o.Value = &t
+	return nil
 }
 
 // DeleteProductRequest dùng cho việc xóa sản phẩm (Admin)
@@ -93,18 +147,35 @@ type SearchProductsRequest struct {
 	Search     string `json:"search" validate:"omitempty,max=255"`
 	Brand      string `json:"brand" validate:"omitempty,max=100"`
 	CategoryID int64  `json:"category_id" validate:"omitempty,min=1"`
+	// Lọc theo CategoryID gồm cả các danh mục con cháu
+	IncludeDescendants bool `json:"include_descendants"`
+	// Lọc theo thông số (?attr.ram=8,12 | ?attr.screen_size=6..7)
+	AttributeFilters []AttributeFilter `json:"-"`
+	// Lọc / sắp xếp theo rating đã lưu trên products
+	MinRating float64 `json:"min_rating" validate:"omitempty,min=0,max=5"`
+	Sort      string  `json:"sort" validate:"omitempty,oneof=newest rating reviews"`
 }
 
+// Các kiểu sắp xếp kết quả tìm kiếm
+const (
+	ProductSortNewest  = "newest"  // Mới nhất (mặc định)
+	ProductSortRating  = "rating"  // Điểm trung bình cao nhất
+	ProductSortReviews = "reviews" // Nhiều đánh giá nhất
+)
+
 // =================================================================
 // 3. RESPONSE DTOs - USER (Trả về cho khách hàng)
 // =================================================================
 
 // UserProductResponse - Thông tin sản phẩm cho User
 type UserProductResponse struct {
-	ID       int64   `json:"id"`
-	Name     string  `json:"name"`
-	Brand    *string `json:"brand,omitempty"`
-	MinPrice float64 `json:"min_price"`
+	ID          int64   `json:"id"`
+	Name        string  `json:"name"`
+	Brand       *string `json:"brand,omitempty"`
+	MinPrice    float64 `json:"min_price"`
+	MaxPrice    float64 `json:"max_price"`
+	AvgRating   float64 `json:"avg_rating"`
+	RatingCount int     `json:"rating_count"`
 }
 
 // UserProductListResponse - Danh sách sản phẩm cho User
@@ -122,13 +193,19 @@ type UserProductDetailResponse struct {
 	Description      *string    `json:"description,omitempty"`
 	Brand            *string    `json:"brand,omitempty"`
 	MinPrice         float64    `json:"min_price"`
+	MaxPrice         float64    `json:"max_price"`
 	AvgRating        float64    `json:"avg_rating"`
 	RatingCount      int        `json:"rating_count"`
 	PublishedAt      *time.Time `json:"published_at,omitempty"`
 
-	Categories []Category            `json:"categories,omitempty"`
-	Variants   []UserVariantResponse `json:"variants,omitempty"`
-	Reviews    []ProductReview       `json:"reviews,omitempty"`
+	Categories   []Category              `json:"categories,omitempty"`
+	Breadcrumb   []CategoryBreadcrumb    `json:"breadcrumb,omitempty"`
+	Attributes   []ProductAttributeValue `json:"attributes,omitempty"`
+	Variants     []UserVariantResponse   `json:"variants,omitempty"`
+	OptionMatrix *VariantOptionMatrix    `json:"option_matrix,omitempty"`
+	Reviews      []ProductReview         `json:"reviews,omitempty"`
+
+	RatingHistogram *RatingHistogram `json:"rating_histogram,omitempty"`
 }
 
 // =================================================================
@@ -137,26 +214,30 @@ type UserProductDetailResponse struct {
 
 // AdminProductResponse - Thông tin đầy đủ sản phẩm cho Admin
 type AdminProductResponse struct {
-	ID               int64              `json:"id"`
-	Name             string             `json:"name"`
-	Slug             string             `json:"slug"`
-	ShortDescription *string            `json:"short_description,omitempty"`
-	Description      *string            `json:"description,omitempty"`
-	Brand            *string            `json:"brand,omitempty"`
-	Status           string             `json:"status"`
-	IsPublished      bool               `json:"is_published"`
-	PublishedAt      *time.Time         `json:"published_at,omitempty"`
-	MinPrice         float64            `json:"min_price"`
-	AvgRating        float64            `json:"avg_rating"`
-	RatingCount      int                `json:"rating_count"`
-	CreatedBy        *int64             `json:"created_by,omitempty"`
-	UpdatedBy        *int64             `json:"updated_by,omitempty"`
-	CreatedAt        time.Time          `json:"created_at"`
-	UpdatedAt        time.Time          `json:"updated_at"`
-	DeletedAt        *time.Time         `json:"deleted_at,omitempty"`
-	Categories       []Category         `json:"categories,omitempty"`
-	Variants         []ProductsVariants `json:"variants,omitempty"`
-	Reviews          []ProductReview    `json:"reviews,omitempty"`
+	ID               int64                   `json:"id"`
+	Name             string                  `json:"name"`
+	Slug             string                  `json:"slug"`
+	ShortDescription *string                 `json:"short_description,omitempty"`
+	Description      *string                 `json:"description,omitempty"`
+	Brand            *string                 `json:"brand,omitempty"`
+	Status           string                  `json:"status"`
+	IsPublished      bool                    `json:"is_published"`
+	PublishedAt      *time.Time              `json:"published_at,omitempty"`
+	UnpublishedAt    *time.Time              `json:"unpublished_at,omitempty"`
+	BasePrice        float64                 `json:"base_price"`
+	MinPrice         float64                 `json:"min_price"`
+	MaxPrice         float64                 `json:"max_price"`
+	AvgRating        float64                 `json:"avg_rating"`
+	RatingCount      int                     `json:"rating_count"`
+	CreatedBy        *int64                  `json:"created_by,omitempty"`
+	UpdatedBy        *int64                  `json:"updated_by,omitempty"`
+	CreatedAt        time.Time               `json:"created_at"`
+	UpdatedAt        time.Time               `json:"updated_at"`
+	DeletedAt        *time.Time              `json:"deleted_at,omitempty"`
+	Categories       []Category              `json:"categories,omitempty"`
+	Attributes       []ProductAttributeValue `json:"attributes,omitempty"`
+	Variants         []ProductsVariants      `json:"variants,omitempty"`
+	Reviews          []ProductReview         `json:"reviews,omitempty"`
 }
 
 // AdminProductListResponse - Danh sách sản phẩm cho Admin
@@ -195,3 +276,82 @@ type GetManyProductsResponse struct {
 	Message  string                `json:"message,omitempty"`
 	Products []UserProductResponse `json:"products"`
 }
+
+// =================================================================
+// 5. SCHEDULED PUBLISH (Lên lịch publish / unpublish)
+// =================================================================
+
+const (
+	ScheduleActionPublish   = "publish"
+	ScheduleActionUnpublish = "unpublish"
+)
+
+// ScheduledProductChange - Một thay đổi trạng thái publish đã được lên lịch
+type ScheduledProductChange struct {
+	ProductID   int64     `json:"product_id"`
+	Name        string    `json:"name"`
+	Action      string    `json:"action"` // publish | unpublish
+	ScheduledAt time.Time `json:"scheduled_at"`
+}
+
+// AdminScheduledChangesResponse - Danh sách thay đổi sắp diễn ra cho Admin
+type AdminScheduledChangesResponse struct {
+	Message string                   `json:"message,omitempty"`
+	Changes []ScheduledProductChange `json:"changes"`
+}
+
+// =================================================================
+// 6. PRICE RANGE (min_price / max_price tự tính từ biến thể)
+// =================================================================
+
+// PriceRangeChange - Kết quả tính lại khoảng giá của một sản phẩm
+type PriceRangeChange struct {
+	ProductID   int64   `json:"product_id"`
+	OldMinPrice float64 `json:"old_min_price"`
+	OldMaxPrice float64 `json:"old_max_price"`
+	NewMinPrice float64 `json:"new_min_price"`
+	NewMaxPrice float64 `json:"new_max_price"`
+}
+
+// AdminRecomputePricesResponse - Response sau khi tính lại giá toàn bộ sản phẩm
+type AdminRecomputePricesResponse struct {
+	Message string             `json:"message"`
+	Updated []PriceRangeChange `json:"updated"`
+}
+
+// =================================================================
+// 7. TRASH / RESTORE (Thùng rác sản phẩm)
+// =================================================================
+
+// RestoreProductsRequest - Khôi phục nhiều sản phẩm từ thùng rác
+type RestoreProductsRequest struct {
+	IDs []int64 `json:"ids" validate:"required,min=1,dive,min=1"`
+}
+
+// ProductRestoreConflict - Sản phẩm không thể khôi phục kèm lý do
+type ProductRestoreConflict struct {
+	ProductID int64  `json:"product_id"`
+	Reason    string `json:"reason"`
+}
+
+// AdminRestoreProductsResponse - Kết quả khôi phục
+type AdminRestoreProductsResponse struct {
+	Message   string                   `json:"message"`
+	Restored  []int64                  `json:"restored"`
+	Conflicts []ProductRestoreConflict `json:"conflicts,omitempty"`
+}
+
+// BlockingOrder - Đơn hàng chưa kết thúc đang tham chiếu sản phẩm
+type BlockingOrder struct {
+	ProductID   int64  `json:"product_id"`
+	OrderID     int64  `json:"order_id"`
+	OrderNumber string `json:"order_number"`
+	Status      string `json:"status"`
+}
+
+// AdminPurgeProductsResponse - Kết quả xóa cứng sản phẩm trong thùng rác
+type AdminPurgeProductsResponse struct {
+	Message string          `json:"message"`
+	Deleted []int64         `json:"deleted"`
+	Blocked []BlockingOrder `json:"blocked,omitempty"`
+}
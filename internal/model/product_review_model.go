@@ -1,13 +1,47 @@
 package model
 
+import "time"
+
+// Trạng thái kiểm duyệt review
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+// Loại vote của user trên review
+const (
+	ReviewVoteHelpful = "helpful"
+	ReviewVoteReport  = "report"
+)
+
+// Các kiểu sắp xếp danh sách review
+const (
+	ReviewSortNewest  = "newest"
+	ReviewSortHighest = "highest"
+	ReviewSortLowest  = "lowest"
+	ReviewSortHelpful = "helpful"
+)
+
 type ProductReview struct {
-	ID        int64   `json:"id"`
-	ProductID int64   `json:"product_id"`
-	Body      *string `json:"body"`
-	Rating    int     `json:"rating"`
-	UserID    int64   `json:"user_id"`
-	CreatedAt string  `json:"created_at"`
-	UpdatedAt string  `json:"updated_at"`
+	ID                 int64      `json:"id"`
+	ProductID          int64      `json:"product_id"`
+	Body               *string    `json:"body"`
+	Rating             int        `json:"rating"`
+	UserID             int64      `json:"user_id"`
+	Status             string     `json:"status"`
+	OrderItemID        *int64     `json:"order_item_id,omitempty"`
+	IsVerifiedPurchase bool       `json:"is_verified_purchase"`
+	ModeratedBy        *int64     `json:"moderated_by,omitempty"`
+	ModeratedAt        *time.Time `json:"moderated_at,omitempty"`
+	ModerationNote     *string    `json:"moderation_note,omitempty"`
+	HelpfulCount       int        `json:"helpful_count"`
+	ReportCount        int        `json:"report_count"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	// Tên người review (JOIN users, chỉ có ở API danh sách)
+	Username *string `json:"-"`
 }
 
 // Request create product review
@@ -16,15 +50,60 @@ type CreateProductReviewRequest struct {
 	Rating int     `json:"rating" binding:"required" validate:"min=1,max=5"`
 }
 
+// Request update own review (review quay lại trạng thái chờ duyệt)
+type UpdateProductReviewRequest struct {
+	Body   *string `json:"body" validate:"omitempty,min=5,max=1000,badwords"`
+	Rating *int    `json:"rating" validate:"omitempty,min=1,max=5"`
+}
+
+// Request report review
+type ReportReviewRequest struct {
+	Reason string `json:"reason" validate:"required,min=3,max=255"`
+}
+
+// ReviewListQuery - Tham số lọc / sắp xếp / phân trang danh sách review
+type ReviewListQuery struct {
+	Sort     string `json:"sort" validate:"omitempty,oneof=newest highest lowest helpful"`
+	Rating   int    `json:"rating" validate:"omitempty,min=1,max=5"` // Chỉ lấy review có số sao này
+	WithText bool   `json:"with_text"`                               // Chỉ lấy review có nội dung
+	Cursor   string `json:"cursor"`
+	Limit    int    `json:"limit" validate:"min=1,max=50"`
+
+	// Vị trí đã giải mã từ Cursor (controller gán)
+	After *ReviewCursor `json:"-"`
+}
+
+// ReviewCursor - Khóa sắp xếp của review cuối trang trước (mã hóa base64 trong ?cursor=)
+type ReviewCursor struct {
+	Sort         string    `json:"s"`
+	Rating       int       `json:"r"`
+	HelpfulCount int       `json:"h"`
+	CreatedAt    time.Time `json:"c"`
+	ID           int64     `json:"i"`
+}
+
+// Request admin moderate review
+type ModerateReviewRequest struct {
+	Status string `json:"status" validate:"required,oneof=approved rejected"`
+	Note   string `json:"note" validate:"omitempty,max=500"`
+}
+
 // Response product review
 type ProductReviewResponse struct {
-	ID        int64   `json:"id"`
-	ProductID int64   `json:"product_id"`
-	Body      *string `json:"body"`
-	Rating    int     `json:"rating"`
-	UserID    int64   `json:"user_id"`
-	CreatedAt string  `json:"created_at"`
-	UpdatedAt string  `json:"updated_at"`
+	ID                 int64   `json:"id"`
+	ProductID          int64   `json:"product_id"`
+	Body               *string `json:"body"`
+	Rating             int     `json:"rating"`
+	UserID             int64   `json:"user_id"`
+	Status             string  `json:"status,omitempty"`
+	IsVerifiedPurchase bool    `json:"is_verified_purchase"`
+	ModerationNote     *string `json:"moderation_note,omitempty"`
+	HelpfulCount       int     `json:"helpful_count"`
+	ReportCount        int     `json:"report_count,omitempty"`
+
+	Reviewer  *UserPublicResponse `json:"reviewer,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
 }
 
 // Response for creating a review
@@ -39,7 +118,49 @@ type ProductReviewListResponse struct {
 	ProductID   int64                   `json:"product_id"`
 	AvgRating   float64                 `json:"avg_rating"`
 	RatingCount int64                   `json:"rating_count"`
+	Histogram   RatingHistogram         `json:"histogram"`
 	Reviews     []ProductReviewResponse `json:"reviews"`
+	NextCursor  string                  `json:"next_cursor,omitempty"`
+	HasMore     bool                    `json:"has_more"`
+}
+
+// Response for helpful vote / report
+type ReviewVoteResponse struct {
+	Message      string `json:"message"`
+	ReviewID     int64  `json:"review_id"`
+	HelpfulCount int    `json:"helpful_count"`
+}
+
+// RatingHistogram - Số review đã duyệt theo từng mức sao
+type RatingHistogram struct {
+	Star1 int64 `json:"1"`
+	Star2 int64 `json:"2"`
+	Star3 int64 `json:"3"`
+	Star4 int64 `json:"4"`
+	Star5 int64 `json:"5"`
+}
+
+// ProductRatingSummary - Tổng hợp rating đã lưu của sản phẩm (products + product_rating_stats)
+type ProductRatingSummary struct {
+	ProductID   int64           `json:"product_id"`
+	AvgRating   float64         `json:"avg_rating"`
+	RatingCount int64           `json:"rating_count"`
+	Histogram   RatingHistogram `json:"histogram"`
+}
+
+// RatingDrift - Sản phẩm có rating đã lưu lệch so với review thực tế
+type RatingDrift struct {
+	ProductID      int64   `json:"product_id"`
+	OldAvgRating   float64 `json:"old_avg_rating"`
+	OldRatingCount int64   `json:"old_rating_count"`
+	NewAvgRating   float64 `json:"new_avg_rating"`
+	NewRatingCount int64   `json:"new_rating_count"`
+}
+
+// Response for admin recompute ratings
+type AdminRecomputeRatingsResponse struct {
+	Message string        `json:"message"`
+	Fixed   []RatingDrift `json:"fixed"`
 }
 
 // Response for deleting a review
@@ -47,14 +168,24 @@ type DeleteProductReviewResponse struct {
 	Message string `json:"message"`
 }
 
+// Response for admin moderation queue
+type ReviewModerationQueueResponse struct {
+	Message string                  `json:"message"`
+	Status  string                  `json:"status"`
+	Page    int                     `json:"page"`
+	Limit   int                     `json:"limit"`
+	Total   int                     `json:"total"`
+	Reviews []ProductReviewResponse `json:"reviews"`
+}
+
 type UserProductReviewResponse struct {
 	Body *string `json:"body"`
 }
 type AdminProductReviewResponse struct {
-	ID        int64   `json:"id"`
-	UserID    int64   `json:"user_id"`
-	Body      *string `json:"body"`
-	Rating    int     `json:"rating"`
-	CreatedAt string  `json:"created_at"`
-	UpdatedAt string  `json:"updated_at"`
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Body      *string   `json:"body"`
+	Rating    int       `json:"rating"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
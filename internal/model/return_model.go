@@ -0,0 +1,174 @@
+package model
+
+import "time"
+
+// Trạng thái yêu cầu trả hàng (RMA)
+// requested -> approved -> received -> refunded, requested -> rejected / cancelled
+const (
+	ReturnStatusRequested = "requested" // Khách gửi yêu cầu
+	ReturnStatusApproved  = "approved"  // Shop đồng ý, chờ khách gửi hàng về
+	ReturnStatusRejected  = "rejected"  // Shop từ chối
+	ReturnStatusReceived  = "received"  // Shop đã nhận hàng trả về
+	ReturnStatusRefunded  = "refunded"  // Đã hoàn tiền
+	ReturnStatusCancelled = "cancelled" // Khách hủy yêu cầu
+)
+
+// ReturnRequest: Bảng return_requests
+type ReturnRequest struct {
+	ID             int64      `db:"id"`
+	ReturnNumber   string     `db:"return_number"`
+	OrderID        int64      `db:"order_id"`
+	OrderNumber    string     `db:"-"` // orders.order_number
+	UserID         int64      `db:"user_id"`
+	Status         string     `db:"status"`
+	Reason         string     `db:"reason"`
+	RefundAmount   float64    `db:"refund_amount"` // Tổng tiền hàng trả lại (đơn giá lúc mua x số lượng)
+	RefundedAmount *float64   `db:"refunded_amount"`
+	Restocked      bool       `db:"restocked"`
+	RequestedAt    time.Time  `db:"requested_at"`
+	ApprovedAt     *time.Time `db:"approved_at"`
+	RejectedAt     *time.Time `db:"rejected_at"`
+	ReceivedAt     *time.Time `db:"received_at"`
+	RefundedAt     *time.Time `db:"refunded_at"`
+	CancelledAt    *time.Time `db:"cancelled_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+	Items          []ReturnItem
+	Photos         []ReturnPhoto
+}
+
+// ReturnItem: Bảng return_items (snapshot dòng đơn hàng lúc tạo yêu cầu)
+type ReturnItem struct {
+	ID          int64   `db:"id"`
+	ReturnID    int64   `db:"return_id"`
+	OrderItemID int64   `db:"order_item_id"`
+	ProductID   int64   `db:"-"` // order_items.product_id
+	VariantID   *int64  `db:"-"` // order_items.variant_id
+	SKU         string  `db:"-"`
+	Title       string  `db:"-"`
+	Quantity    int     `db:"quantity"`
+	UnitPrice   float64 `db:"unit_price"`
+	LineAmount  float64 `db:"line_amount"`
+}
+
+// ReturnPhoto: Bảng return_photos (file nằm trong Storage theo storage_key)
+type ReturnPhoto struct {
+	ID          int64     `db:"id"`
+	ReturnID    int64     `db:"return_id"`
+	FileName    string    `db:"file_name"`
+	ContentType string    `db:"content_type"`
+	SizeBytes   int64     `db:"size_bytes"`
+	StorageKey  string    `db:"storage_key"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// ReturnStatusHistory: Bảng return_status_history
+type ReturnStatusHistory struct {
+	ID         int64     `db:"id"`
+	ReturnID   int64     `db:"return_id"`
+	FromStatus *string   `db:"from_status"`
+	ToStatus   string    `db:"to_status"`
+	ChangedBy  *int64    `db:"changed_by"`
+	Note       string    `db:"note"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// ReturnRefundResult: Kết quả hoàn tiền, đơn hàng đổi trạng thái khi toàn bộ sản phẩm đã được trả
+type ReturnRefundResult struct {
+	PaymentStatus string
+	OrderRefunded bool
+	TotalRefunded float64
+}
+
+type CreateReturnItemRequest struct {
+	OrderItemID int64 `json:"order_item_id" validate:"required,gt=0"`
+	Quantity    int   `json:"quantity"      validate:"required,gt=0"`
+}
+
+// Khách tạo yêu cầu trả hàng (JSON hoặc multipart/form-data: field "items" là chuỗi JSON, file "photos")
+type CreateReturnRequest struct {
+	Items  []CreateReturnItemRequest `json:"items"  validate:"required,min=1,dive"`
+	Reason string                    `json:"reason" validate:"required,max=1000"`
+}
+
+// Admin duyệt / từ chối
+type ReturnDecisionRequest struct {
+	Note string `json:"note" validate:"omitempty,max=1000"`
+}
+
+// Admin xác nhận đã nhận hàng, restock = true thì cộng lại tồn kho
+type ReceiveReturnRequest struct {
+	Restock bool   `json:"restock"`
+	Note    string `json:"note" validate:"omitempty,max=1000"`
+}
+
+// Admin hoàn tiền, không truyền amount thì hoàn đúng tiền hàng trả lại
+type RefundReturnRequest struct {
+	Amount   *float64 `json:"amount"   validate:"omitempty,gt=0"`
+	Override bool     `json:"override"` // Cho phép hoàn nhiều hơn refund_amount của yêu cầu (bắt buộc kèm note)
+	Note     string   `json:"note"     validate:"omitempty,max=1000"`
+}
+
+// Tìm kiếm / lọc yêu cầu trả hàng
+type ReturnFilter struct {
+	Status  string `validate:"omitempty,oneof=requested approved rejected received refunded cancelled"`
+	OrderID int64  `validate:"omitempty,min=0"`
+	UserID  int64  `validate:"omitempty,min=0"`
+	Page    int    `validate:"min=1"`
+	Limit   int    `validate:"min=1,max=100"`
+}
+
+type ReturnItemResponse struct {
+	ID          int64  `json:"id"`
+	OrderItemID int64  `json:"order_item_id"`
+	ProductID   int64  `json:"product_id"`
+	VariantID   *int64 `json:"variant_id,omitempty"`
+	SKU         string `json:"sku"`
+	Title       string `json:"title"`
+	Quantity    int    `json:"quantity"`
+	UnitPrice   string `json:"unit_price"`
+	LineAmount  string `json:"line_amount"`
+}
+
+type ReturnPhotoResponse struct {
+	ID          int64  `json:"id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	SizeBytes   int64  `json:"size_bytes"`
+}
+
+type ReturnStatusHistoryResponse struct {
+	ID         int64     `json:"id"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	ChangedBy  *int64    `json:"changed_by,omitempty"`
+	Note       string    `json:"note,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ReturnResponse struct {
+	ID             int64                         `json:"id"`
+	ReturnNumber   string                        `json:"return_number"`
+	OrderID        int64                         `json:"order_id"`
+	OrderNumber    string                        `json:"order_number"`
+	UserID         int64                         `json:"user_id"`
+	Status         string                        `json:"status"`
+	Reason         string                        `json:"reason"`
+	RefundAmount   string                        `json:"refund_amount"`
+	RefundedAmount string                        `json:"refunded_amount,omitempty"`
+	Restocked      bool                          `json:"restocked"`
+	Items          []ReturnItemResponse          `json:"items,omitempty"`
+	Photos         []ReturnPhotoResponse         `json:"photos,omitempty"`
+	History        []ReturnStatusHistoryResponse `json:"history,omitempty"`
+	RequestedAt    time.Time                     `json:"requested_at"`
+	ApprovedAt     *time.Time                    `json:"approved_at,omitempty"`
+	RejectedAt     *time.Time                    `json:"rejected_at,omitempty"`
+	ReceivedAt     *time.Time                    `json:"received_at,omitempty"`
+	RefundedAt     *time.Time                    `json:"refunded_at,omitempty"`
+	CancelledAt    *time.Time                    `json:"cancelled_at,omitempty"`
+	UpdatedAt      time.Time                     `json:"updated_at"`
+}
+
+type ReturnListResponse struct {
+	Returns []ReturnResponse `json:"returns"`
+	Total   int              `json:"total"`
+}
@@ -0,0 +1,117 @@
+package model
+
+import "time"
+
+// Mã phương thức giao hàng
+const (
+	ShippingMethodStandard = "standard"
+	ShippingMethodExpress  = "express"
+)
+
+// Cách tính phí của phương thức giao hàng
+const (
+	ShippingRateFlat   = "flat"   // Đồng giá
+	ShippingRateWeight = "weight" // Theo bậc khối lượng
+)
+
+// ShippingZone - Vùng giao hàng; State / City = nil nghĩa là áp dụng cho mọi tỉnh / thành
+type ShippingZone struct {
+	ID           int64            `json:"id" db:"id"`
+	Name         string           `json:"name" db:"name"`
+	Country      string           `json:"country" db:"country"`
+	State        *string          `json:"state" db:"state"`
+	City         *string          `json:"city" db:"city"`
+	ProvinceCode *string          `json:"province_code" db:"province_code"` // Mã tỉnh / thành (/api/locations), khớp theo mã thay vì tên
+	Priority     int              `json:"priority" db:"priority"`
+	IsActive     bool             `json:"is_active" db:"is_active"`
+	CreatedAt    time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at" db:"updated_at"`
+	Methods      []ShippingMethod `json:"methods,omitempty"`
+}
+
+// ShippingMethod - Phương thức giao hàng của một vùng
+type ShippingMethod struct {
+	ID            int64                `json:"id" db:"id"`
+	ZoneID        int64                `json:"zone_id" db:"zone_id"`
+	Code          string               `json:"code" db:"code"`
+	Name          string               `json:"name" db:"name"`
+	RateType      string               `json:"rate_type" db:"rate_type"`
+	FlatFee       float64              `json:"flat_fee" db:"flat_fee"`
+	FreeThreshold *float64             `json:"free_threshold" db:"free_threshold"` // Miễn phí khi tiền hàng >= ngưỡng
+	IsActive      bool                 `json:"is_active" db:"is_active"`
+	CreatedAt     time.Time            `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at" db:"updated_at"`
+	WeightTiers   []ShippingWeightTier `json:"weight_tiers,omitempty"`
+}
+
+// ShippingWeightTier - Bậc giá theo khối lượng (đơn nặng <= MaxWeightGrams thì tính Fee)
+type ShippingWeightTier struct {
+	MaxWeightGrams int     `json:"max_weight_grams" db:"max_weight_grams" validate:"required,gt=0"`
+	Fee            float64 `json:"fee" db:"fee" validate:"gte=0"`
+}
+
+// ShippingZoneRequest - Admin tạo / cập nhật vùng giao hàng
+type ShippingZoneRequest struct {
+	Name    string `json:"name" validate:"required,max=100"`
+	Country string `json:"country" validate:"required,max=100"`
+	State   string `json:"state" validate:"omitempty,max=100"`
+	City    string `json:"city" validate:"omitempty,max=100"`
+	// Mã tỉnh / thành của địa chỉ Việt Nam, bỏ trống = mọi tỉnh / thành
+	ProvinceCode string `json:"province_code" validate:"omitempty,max=10"`
+	Priority     int    `json:"priority"`
+	IsActive     *bool  `json:"is_active"`
+}
+
+// ShippingMethodRequest - Admin tạo / cập nhật phương thức giao hàng của vùng
+type ShippingMethodRequest struct {
+	Code          string               `json:"code" validate:"required,oneof=standard express"`
+	Name          string               `json:"name" validate:"required,max=100"`
+	RateType      string               `json:"rate_type" validate:"required,oneof=flat weight"`
+	FlatFee       float64              `json:"flat_fee" validate:"gte=0"`
+	FreeThreshold *float64             `json:"free_threshold" validate:"omitempty,gt=0"`
+	IsActive      *bool                `json:"is_active"`
+	WeightTiers   []ShippingWeightTier `json:"weight_tiers" validate:"omitempty,dive"`
+}
+
+// ShippingDestination - Địa chỉ nhận hàng dùng để tìm vùng giao hàng
+type ShippingDestination struct {
+	Country      string
+	State        string
+	City         string
+	ProvinceCode string // Rỗng với địa chỉ nước ngoài / chưa có dữ liệu hành chính
+}
+
+// ShippingQuote - Phí giao hàng của một phương thức cho đơn hàng cụ thể
+type ShippingQuote struct {
+	ZoneID           int64   `json:"zone_id"`
+	ZoneName         string  `json:"zone_name"`
+	MethodID         int64   `json:"method_id"`
+	Code             string  `json:"code"`
+	Name             string  `json:"name"`
+	RateType         string  `json:"rate_type"`
+	TotalWeightGrams int     `json:"total_weight_grams"`
+	Fee              float64 `json:"fee"`
+	IsFree           bool    `json:"is_free"`
+}
+
+// OrderShippingLine - Snapshot phương thức + phí giao hàng của đơn
+type OrderShippingLine struct {
+	ID               int64     `json:"id" db:"id"`
+	OrderID          int64     `json:"order_id" db:"order_id"`
+	ZoneID           *int64    `json:"zone_id" db:"zone_id"`
+	ZoneName         string    `json:"zone_name" db:"zone_name"`
+	MethodCode       string    `json:"method_code" db:"method_code"`
+	MethodName       string    `json:"method_name" db:"method_name"`
+	RateType         string    `json:"rate_type" db:"rate_type"`
+	TotalWeightGrams int       `json:"total_weight_grams" db:"total_weight_grams"`
+	Fee              float64   `json:"fee" db:"fee"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+type OrderShippingLineResponse struct {
+	MethodCode       string `json:"method_code"`
+	MethodName       string `json:"method_name"`
+	ZoneName         string `json:"zone_name"`
+	TotalWeightGrams int    `json:"total_weight_grams"`
+	Fee              string `json:"fee"`
+}
@@ -26,6 +26,11 @@ type ProductSalesDaily struct {
 	UnitsSold   int64     `json:"units_sold"    db:"units_sold"`
 	Revenue     float64   `json:"revenue"       db:"revenue"`
 	OrderCount  int64     `json:"order_count"   db:"order_count"`
+
+	// Giá vốn / lợi nhuận gộp (chỉ tính các dòng đơn có snapshot giá vốn)
+	Cost          float64 `json:"cost"           db:"cost"`
+	GrossProfit   float64 `json:"gross_profit"   db:"gross_profit"`
+	UncostedUnits int64   `json:"uncosted_units" db:"uncosted_units"`
 }
 
 // REQUEST / FILTER 
@@ -37,8 +42,50 @@ type StatsFilter struct {
 	Limit     int    `validate:"omitempty,min=1,max=100"`       // Dùng cho Top Products
 }
 
+// StatsBackfillRequest: Tính lại thống kê cho khoảng ngày (query ?from=&to=)
+type StatsBackfillRequest struct {
+	From string `validate:"required,datetime=2006-01-02"` // YYYY-MM-DD
+	To   string `validate:"required,datetime=2006-01-02"` // YYYY-MM-DD
+}
+
+// MarginFilter: Lọc báo cáo lợi nhuận gộp
+type MarginFilter struct {
+	StartDate string `validate:"omitempty,datetime=2006-01-02"`               // YYYY-MM-DD
+	EndDate   string `validate:"omitempty,datetime=2006-01-02"`               // YYYY-MM-DD
+	Limit     int    `validate:"omitempty,min=1,max=100"`                     // Không áp dụng cho báo cáo theo ngày
+	SortBy    string `validate:"omitempty,oneof=gross_profit revenue margin"` // Mặc định gross_profit
+}
+
+// Độ chia của báo cáo doanh thu theo thời gian
+const (
+	RevenueGranularityDay   = "day"
+	RevenueGranularityWeek  = "week" // Tuần ISO, bắt đầu thứ 2
+	RevenueGranularityMonth = "month"
+)
+
+// Kỳ so sánh của báo cáo doanh thu
+const (
+	RevenueComparePreviousPeriod = "previous_period" // Kỳ liền trước, cùng độ dài
+	RevenueComparePreviousYear   = "previous_year"   // Cùng kỳ năm trước
+)
+
+// RevenueSeriesRequest: Báo cáo doanh thu theo thời gian (query ?from=&to=&granularity=&compare=)
+type RevenueSeriesRequest struct {
+	From        string `validate:"required,datetime=2006-01-02"`                  // YYYY-MM-DD
+	To          string `validate:"required,datetime=2006-01-02"`                  // YYYY-MM-DD
+	Granularity string `validate:"omitempty,oneof=day week month"`                // Mặc định day
+	Compare     string `validate:"omitempty,oneof=previous_period previous_year"` // Bỏ trống = không so sánh
+}
+
 //  RESPONSES 
 
+// StatsBackfillResponse: Kết quả tính lại thống kê
+type StatsBackfillResponse struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Days int    `json:"days"` // Số ngày đã tính lại
+}
+
 // ProductDailyStatsResponse: Dùng cho API thống kê của 1 sản phẩm
 type ProductDailyStatsResponse struct {
 	Date        string  `json:"date"`         // Ngày (YYYY-MM-DD)
@@ -68,4 +115,346 @@ type DashboardOverviewResponse struct {
 	// Số liệu thực tế (Real Revenue)
 	RealRevenue      string `json:"real_revenue"`
 	RealOrders       int64  `json:"real_orders"`
-}
\ No newline at end of file
+}
+
+// MarginFigures: Số liệu lợi nhuận gộp dùng chung cho các báo cáo margin
+type MarginFigures struct {
+	UnitsSold     int64    `json:"units_sold"`
+	Revenue       float64  `json:"revenue"`
+	Cost          float64  `json:"cost"`
+	GrossProfit   float64  `json:"gross_profit"`
+	MarginPercent *float64 `json:"margin_percent"` // gross_profit / doanh thu có giá vốn; nil nếu chưa có dòng nào có giá vốn
+	UncostedUnits int64    `json:"uncosted_units"` // Số lượng bán chưa khai báo giá vốn (không tính vào lợi nhuận)
+}
+
+// ProductMarginResponse: Lợi nhuận gộp theo sản phẩm
+type ProductMarginResponse struct {
+	ProductID   int64  `json:"product_id"`
+	ProductName string `json:"product_name"`
+	MarginFigures
+}
+
+// VariantMarginResponse: Lợi nhuận gộp theo biến thể
+type VariantMarginResponse struct {
+	ProductID    int64  `json:"product_id"`
+	VariantID    int64  `json:"variant_id"`
+	ProductName  string `json:"product_name"`
+	VariantTitle string `json:"variant_title"`
+	SKU          string `json:"sku"`
+	MarginFigures
+}
+
+// CategoryMarginResponse: Lợi nhuận gộp theo danh mục (sản phẩm thuộc nhiều danh mục được tính ở mỗi danh mục)
+type CategoryMarginResponse struct {
+	CategoryID   int64  `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	MarginFigures
+}
+
+// DailyMarginResponse: Lợi nhuận gộp theo ngày
+type DailyMarginResponse struct {
+	Date string `json:"date"` // YYYY-MM-DD
+	MarginFigures
+}
+
+// MarginAlertResponse: Biến thể đang bán dưới giá vốn (giá hiện tại < giá vốn hoặc có đơn bán lỗ trong kỳ)
+type MarginAlertResponse struct {
+	ProductID      int64   `json:"product_id"`
+	VariantID      int64   `json:"variant_id"`
+	ProductName    string  `json:"product_name"`
+	VariantTitle   string  `json:"variant_title"`
+	SKU            string  `json:"sku"`
+	CurrentPrice   float64 `json:"current_price"`
+	CostPrice      float64 `json:"cost_price"`
+	PriceBelowCost bool    `json:"price_below_cost"` // Giá bán hiện tại thấp hơn giá vốn
+	UnitsBelowCost int64   `json:"units_below_cost"` // Số lượng đã bán dưới giá vốn trong kỳ
+	LossAmount     float64 `json:"loss_amount"`      // Tổng tiền lỗ của các dòng bán dưới giá vốn trong kỳ
+}
+
+// RevenueMetrics: Số liệu doanh thu của 1 kỳ (đọc từ sales_summary_daily)
+type RevenueMetrics struct {
+	GMV         float64 `json:"gmv"`          // Doanh thu theo ngày đặt (trừ đơn hủy / hoàn tiền)
+	RealRevenue float64 `json:"real_revenue"` // Doanh thu đơn hoàn thành theo ngày hoàn thành
+	Orders      int64   `json:"orders"`
+	RealOrders  int64   `json:"real_orders"`
+	AOV         float64 `json:"aov"` // Giá trị trung bình đơn = gmv / orders
+	Units       int64   `json:"units"`
+}
+
+// MetricDelta: Chênh lệch so với kỳ so sánh
+type MetricDelta struct {
+	Change  float64  `json:"change"`
+	Percent *float64 `json:"percent"` // nil nếu kỳ so sánh bằng 0
+}
+
+// RevenueDeltas: Chênh lệch từng chỉ số
+type RevenueDeltas struct {
+	GMV         MetricDelta `json:"gmv"`
+	RealRevenue MetricDelta `json:"real_revenue"`
+	Orders      MetricDelta `json:"orders"`
+	AOV         MetricDelta `json:"aov"`
+	Units       MetricDelta `json:"units"`
+}
+
+// RevenueBucket: 1 điểm trên biểu đồ (ngày / tuần / tháng, đã cắt theo khoảng from -> to)
+type RevenueBucket struct {
+	Period        string          `json:"period"` // 2025-01-06 | 2025-W02 | 2025-01
+	Start         string          `json:"start"`
+	End           string          `json:"end"`
+	Incomplete    bool            `json:"incomplete,omitempty"` // Có ngày chưa được tổng hợp (hôm nay / tương lai)
+	Current       RevenueMetrics  `json:"current"`
+	PreviousStart string          `json:"previous_start,omitempty"`
+	PreviousEnd   string          `json:"previous_end,omitempty"`
+	Previous      *RevenueMetrics `json:"previous,omitempty"`
+	Delta         *RevenueDeltas  `json:"delta,omitempty"`
+}
+
+// RevenueSeriesResponse: Báo cáo doanh thu theo thời gian
+type RevenueSeriesResponse struct {
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	Granularity    string          `json:"granularity"`
+	Timezone       string          `json:"timezone"`
+	Compare        string          `json:"compare,omitempty"`
+	CompareFrom    string          `json:"compare_from,omitempty"`
+	CompareTo      string          `json:"compare_to,omitempty"`
+	Totals         RevenueMetrics  `json:"totals"`
+	PreviousTotals *RevenueMetrics `json:"previous_totals,omitempty"`
+	Delta          *RevenueDeltas  `json:"delta,omitempty"`
+	Buckets        []RevenueBucket `json:"buckets"`
+}
+
+// Phân khúc khách hàng theo điểm RFM (Recency - Frequency - Monetary, thang 1-5)
+const (
+	RFMSegmentChampions         = "champions"          // Mua gần đây, thường xuyên, chi tiêu cao
+	RFMSegmentLoyal             = "loyal"              // Mua thường xuyên
+	RFMSegmentPotentialLoyalist = "potential_loyalist" // Mua gần đây, đã quay lại vài lần
+	RFMSegmentNewCustomer       = "new_customer"       // Mới mua lần đầu gần đây
+	RFMSegmentAtRisk            = "at_risk"            // Từng mua nhiều nhưng lâu chưa quay lại
+	RFMSegmentNeedAttention     = "need_attention"     // Ở mức trung bình
+	RFMSegmentHibernating       = "hibernating"        // Ít mua, lâu chưa quay lại
+	RFMSegmentLost              = "lost"               // Ít mua, rất lâu chưa quay lại
+)
+
+// CustomerStatsFilter: Lọc danh sách khách hàng (query ?segment=&sort_by=&page=&limit=)
+type CustomerStatsFilter struct {
+	Segment string `validate:"omitempty,oneof=champions loyal potential_loyalist new_customer at_risk need_attention hibernating lost"`
+	SortBy  string `validate:"omitempty,oneof=ltv orders recency"` // Mặc định ltv
+	Page    int    `validate:"min=1"`
+	Limit   int    `validate:"min=1,max=100"`
+}
+
+// CohortFilter: Khoảng tháng cohort (YYYY-MM), mặc định 12 tháng gần nhất
+type CohortFilter struct {
+	FromMonth string `validate:"omitempty,datetime=2006-01"`
+	ToMonth   string `validate:"omitempty,datetime=2006-01"`
+}
+
+// RepeatPurchaseFilter: Tỷ lệ khách quay lại mua trong N ngày sau đơn đầu tiên
+type RepeatPurchaseFilter struct {
+	WithinDays int `validate:"min=1,max=730"`
+}
+
+// CustomerStatsResponse: Chỉ số của 1 khách hàng (LTV, số đơn, RFM)
+type CustomerStatsResponse struct {
+	UserID        int64     `json:"user_id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	CohortMonth   string    `json:"cohort_month"` // YYYY-MM
+	FirstOrderAt  time.Time `json:"first_order_at"`
+	LastOrderAt   time.Time `json:"last_order_at"`
+	OrderCount    int64     `json:"order_count"`
+	LifetimeValue float64   `json:"lifetime_value"`
+	AvgOrderValue float64   `json:"avg_order_value"`
+	RecencyDays   int       `json:"recency_days"` // Số ngày từ đơn gần nhất tới lúc tính
+	RScore        int       `json:"r_score"`
+	FScore        int       `json:"f_score"`
+	MScore        int       `json:"m_score"`
+	Segment       string    `json:"segment"`
+	ComputedAt    time.Time `json:"computed_at"`
+}
+
+// RFMSegmentSummary: Tổng hợp theo phân khúc
+type RFMSegmentSummary struct {
+	Segment       string  `json:"segment"`
+	Customers     int64   `json:"customers"`
+	LifetimeValue float64 `json:"lifetime_value"` // Tổng LTV của phân khúc
+	AvgOrderCount float64 `json:"avg_order_count"`
+	AvgRecency    float64 `json:"avg_recency_days"`
+}
+
+// CohortCell: 1 ô của ma trận cohort (số tháng sau tháng mua đầu tiên)
+type CohortCell struct {
+	MonthOffset     int      `json:"month_offset"`
+	ActiveCustomers int64    `json:"active_customers"`
+	RetentionRate   *float64 `json:"retention_rate"` // % khách của cohort có mua trong tháng này
+	Orders          int64    `json:"orders"`
+	Revenue         float64  `json:"revenue"`
+}
+
+// CohortRow: Dữ liệu thô 1 ô cohort đọc từ customer_cohort_monthly
+type CohortRow struct {
+	CohortMonth string // YYYY-MM
+	CohortSize  int64
+	CohortCell
+}
+
+// CohortResponse: 1 dòng của ma trận cohort
+type CohortResponse struct {
+	Cohort    string       `json:"cohort"` // YYYY-MM
+	Size      int64        `json:"size"`   // Số khách mua lần đầu trong tháng
+	Retention []CohortCell `json:"retention"`
+}
+
+// RepeatPurchaseResponse: Tỷ lệ mua lặp lại
+type RepeatPurchaseResponse struct {
+	WithinDays        int        `json:"within_days"`
+	EligibleCustomers int64      `json:"eligible_customers"` // Khách có đơn đầu tiên cách đây >= within_days ngày
+	RepeatCustomers   int64      `json:"repeat_customers"`   // Trong số đó, có đơn thứ 2 trong within_days ngày
+	RepeatRate        *float64   `json:"repeat_rate"`        // %
+	TotalCustomers    int64      `json:"total_customers"`
+	EverRepeated      int64      `json:"ever_repeated"` // Khách có từ 2 đơn trở lên
+	EverRepeatRate    *float64   `json:"ever_repeat_rate"`
+	ComputedAt        *time.Time `json:"computed_at"` // Lần tính gần nhất của job (nil nếu chưa chạy)
+}
+
+
+// Trạng thái tồn kho của biến thể (báo cáo inventory-health), xét theo thứ tự ưu tiên
+const (
+	InventoryStatusOutOfStock   = "out_of_stock"  // Tồn kho <= 0
+	InventoryStatusLow          = "low"           // Tồn kho <= ngưỡng đặt hàng lại
+	InventoryStatusProjectedOut = "projected_out" // Theo tốc độ bán sẽ hết trong horizon_days ngày
+	InventoryStatusDead         = "dead"          // Còn hàng nhưng không bán được trong dead_days ngày
+	InventoryStatusOK           = "ok"
+)
+
+// InventoryHealthFilter: Lọc báo cáo tồn kho (query ?status=&horizon_days=&dead_days=&page=&limit=)
+type InventoryHealthFilter struct {
+	Status      string `validate:"omitempty,oneof=out_of_stock low projected_out dead ok"`
+	HorizonDays int    `validate:"omitempty,min=1,max=365"` // Mặc định INVENTORY_FORECAST_DAYS
+	DeadDays    int    `validate:"omitempty,min=1,max=730"` // Mặc định INVENTORY_DEAD_STOCK_DAYS
+	Page        int    `validate:"min=1"`
+	Limit       int    `validate:"min=1,max=100"`
+}
+
+// InventoryHealthParams: Ngưỡng dùng phân loại tồn kho
+type InventoryHealthParams struct {
+	DefaultThreshold int // Ngưỡng của biến thể chưa đặt reorder_threshold
+	HorizonDays      int
+	DeadDays         int
+	Today            time.Time // Ngày hiện tại theo múi giờ báo cáo
+}
+
+// InventoryHealthItem: Tình trạng tồn kho 1 biến thể
+type InventoryHealthItem struct {
+	VariantID         int64    `json:"variant_id"`
+	ProductID         int64    `json:"product_id"`
+	ProductName       string   `json:"product_name"`
+	VariantTitle      string   `json:"variant_title"`
+	SKU               string   `json:"sku"`
+	StockQuantity     int      `json:"stock_quantity"`
+	ReorderThreshold  int      `json:"reorder_threshold"` // Ngưỡng đang áp dụng (riêng của biến thể hoặc mặc định)
+	AllowBackorder    bool     `json:"allow_backorder"`
+	UnitsSold         int      `json:"units_sold"`              // Số lượng bán trong velocity_window_days
+	Velocity          float64  `json:"velocity"`                // Số lượng bán trung bình / ngày
+	DaysUntilStockout *float64 `json:"days_until_stockout"`     // nil nếu không bán được
+	StockoutDate      *string  `json:"projected_stockout_date"` // YYYY-MM-DD
+	LastSaleDate      *string  `json:"last_sale_date"`          // YYYY-MM-DD, nil nếu chưa từng bán
+	Status            string   `json:"status"`
+}
+
+// InventoryHealthSummary: Số biến thể theo từng trạng thái
+type InventoryHealthSummary struct {
+	OutOfStock   int `json:"out_of_stock"`
+	Low          int `json:"low"`
+	ProjectedOut int `json:"projected_out"`
+	Dead         int `json:"dead"`
+	OK           int `json:"ok"`
+}
+
+// InventoryHealthResponse: Báo cáo tồn kho
+type InventoryHealthResponse struct {
+	Summary            InventoryHealthSummary `json:"summary"`
+	Items              []InventoryHealthItem  `json:"items"`
+	Total              int                    `json:"total"` // Số biến thể khớp status (để phân trang)
+	HorizonDays        int                    `json:"horizon_days"`
+	DeadDays           int                    `json:"dead_days"`
+	VelocityWindowDays int                    `json:"velocity_window_days"`
+	ComputedAt         *time.Time             `json:"computed_at"` // Lần tính velocity gần nhất (nil nếu chưa chạy)
+}
+
+// AbandonedCartFilter: Lọc danh sách giỏ hàng bỏ quên (query ?min_hours=&max_days=&page=&limit=)
+type AbandonedCartFilter struct {
+	MinHours int `validate:"omitempty,min=1,max=720"` // Không thao tác ít nhất N giờ, mặc định CART_ABANDON_HOURS
+	MaxDays  int `validate:"omitempty,min=1,max=365"` // Bỏ qua giỏ quá cũ, mặc định CART_ABANDON_MAX_DAYS
+	Page     int `validate:"min=1"`
+	Limit    int `validate:"min=1,max=100"`
+}
+
+// FunnelMetrics: Số giỏ qua từng bước của phễu + tỷ lệ (%, nil nếu không có giỏ nào)
+type FunnelMetrics struct {
+	CartsStarted    int64    `json:"carts_started"`
+	CartsCheckout   int64    `json:"carts_checkout"`
+	CartsConverted  int64    `json:"carts_converted"`
+	CartsAbandoned  int64    `json:"carts_abandoned"`
+	AddEvents       int64    `json:"add_events"`
+	RemoveEvents    int64    `json:"remove_events"`
+	PreviewEvents   int64    `json:"preview_events"`
+	OrderEvents     int64    `json:"order_events"`
+	CheckoutRate    *float64 `json:"checkout_rate"`
+	ConversionRate  *float64 `json:"conversion_rate"`
+	AbandonmentRate *float64 `json:"abandonment_rate"`
+}
+
+// FunnelDay: Phễu của các giỏ bắt đầu thêm sản phẩm trong 1 ngày
+type FunnelDay struct {
+	Date string `json:"date"` // YYYY-MM-DD
+	FunnelMetrics
+	Complete bool `json:"complete"` // false: chưa hết window_days, giỏ còn có thể chuyển đổi
+}
+
+// CartFunnelResponse: Báo cáo phễu giỏ hàng -> đơn hàng
+type CartFunnelResponse struct {
+	StartDate  string        `json:"start_date"`
+	EndDate    string        `json:"end_date"`
+	WindowDays int           `json:"window_days"`
+	Totals     FunnelMetrics `json:"totals"`
+	Days       []FunnelDay   `json:"days"`
+	ComputedAt *time.Time    `json:"computed_at"` // Lần job tính gần nhất (nil nếu chưa chạy)
+}
+
+// AbandonedProductResponse: Sản phẩm bị bỏ lại trong giỏ nhiều nhất
+type AbandonedProductResponse struct {
+	ProductID    int64   `json:"product_id"`
+	VariantID    int64   `json:"variant_id"`
+	ProductName  string  `json:"product_name"`
+	VariantTitle string  `json:"variant_title"`
+	SKU          string  `json:"sku"`
+	Carts        int64   `json:"carts"` // Số giỏ bỏ quên có sản phẩm này
+	Units        int64   `json:"units"`
+	Amount       float64 `json:"amount"` // Giá trị theo giá lúc thêm vào giỏ
+}
+
+// AbandonedCartItem: 1 dòng trong giỏ bỏ quên (giá hiện tại)
+type AbandonedCartItem struct {
+	ProductID    int64   `json:"product_id"`
+	VariantID    int64   `json:"variant_id"`
+	ProductName  string  `json:"product_name"`
+	VariantTitle string  `json:"variant_title"`
+	SKU          string  `json:"sku"`
+	Quantity     int     `json:"quantity"`
+	UnitPrice    float64 `json:"unit_price"`
+}
+
+// AbandonedCartResponse: Giỏ hàng bỏ quên của user đã đồng ý nhận marketing
+type AbandonedCartResponse struct {
+	CartID         int64               `json:"cart_id"`
+	UserID         int64               `json:"user_id"`
+	Username       string              `json:"username"`
+	Email          string              `json:"email"`
+	ItemCount      int                 `json:"item_count"`
+	Units          int                 `json:"units"`
+	CartValue      float64             `json:"cart_value"`
+	LastActivityAt time.Time           `json:"last_activity_at"`
+	Items          []AbandonedCartItem `json:"items"`
+}
@@ -13,6 +13,7 @@ type User struct {
 	IsActive           bool       `db:"is_active"`
 	RefreshToken       *string    `db:"refresh_token"`
 	RefreshTokenExpiry *time.Time `db:"refresh_token_expiry"`
+	MarketingOptIn     bool       `db:"marketing_opt_in"` // Đồng ý nhận email marketing (nhắc giỏ hàng bỏ quên...)
 	CreatedAt          time.Time  `db:"created_at"`
 	UpdatedAt          time.Time  `db:"updated_at"`
 	DeletedAt          *time.Time `db:"deleted_at"`
@@ -22,6 +23,9 @@ type RegisterRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=100,alphanum"`
 	Email    string `json:"email"    validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6,max=30"`
+
+	// Cart token của khách (header/cookie hoặc body), giỏ khách sẽ được gộp vào giỏ của user
+	CartToken string `json:"cart_token,omitempty"`
 }
 
 // LoginRequest: Dùng khi đăng nhập
@@ -29,6 +33,9 @@ type LoginRequest struct {
 	// Dùng 1 trường identifier để cho phép nhập username HOẶC email
 	Identifier string `json:"identifier" validate:"required,min=3,max=100"`
 	Password   string `json:"password"   validate:"required,min=6,max=30"`
+
+	// Cart token của khách (header/cookie hoặc body), giỏ khách sẽ được gộp vào giỏ của user
+	CartToken string `json:"cart_token,omitempty"`
 }
 
 // Lọc dữ liệu tài khoản
@@ -46,6 +53,9 @@ type UserUpdateProfileRequest struct {
 	Username *string `json:"username,omitempty" validate:"omitempty,min=3,max=100,alphanum"`
 	Email    *string `json:"email,omitempty"    validate:"omitempty,email"`
 	Password *string `json:"password,omitempty" validate:"omitempty,min=6,max=30"`
+
+	// Đồng ý / từ chối nhận email marketing
+	MarketingOptIn *bool `json:"marketing_opt_in,omitempty"`
 }
 
 // AdminUpdateUserRequest: Dùng khi admin cập nhật thông tin user
@@ -73,13 +83,14 @@ type UserPublicResponse struct {
 
 // UserProfileResponse: Dùng cho User xem và chỉnh sửa profile cá nhân
 type UserProfileResponse struct {
-	ID        int64     `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Role      string    `json:"role"`
-	IsActive  bool      `json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             int64     `json:"id"`
+	Username       string    `json:"username"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	IsActive       bool      `json:"is_active"`
+	MarketingOptIn bool      `json:"marketing_opt_in"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 // AdminUserResponse: Dùng cho Admin quản lý
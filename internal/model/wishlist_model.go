@@ -0,0 +1,80 @@
+package model
+
+import "time"
+
+// WishlistItem ánh xạ bảng 'wishlist_items'
+type WishlistItem struct {
+	ID        int64     `db:"id"`
+	UserID    int64     `db:"user_id"`
+	ProductID int64     `db:"product_id"`
+	VariantID *int64    `db:"variant_id"` // NULL: lưu cả sản phẩm
+	CreatedAt time.Time `db:"created_at"`
+
+	// Thông tin join để hiển thị
+	ProductName   string
+	ProductSlug   string
+	MinPrice      float64
+	BasePrice     float64
+	VariantTitle  *string
+	VariantSKU    *string
+	PriceOverride *float64
+	StockQuantity *int
+	IsPublished   bool
+}
+
+// StockSubscription ánh xạ bảng 'stock_subscriptions'
+type StockSubscription struct {
+	ID         int64      `db:"id"`
+	UserID     int64      `db:"user_id"`
+	VariantID  int64      `db:"variant_id"`
+	CreatedAt  time.Time  `db:"created_at"`
+	NotifiedAt *time.Time `db:"notified_at"`
+
+	// Thông tin join
+	Email        string
+	ProductID    int64
+	ProductName  string
+	VariantTitle *string
+	VariantSKU   string
+}
+
+// REQUEST DTOs
+
+// AddWishlistItemRequest: Thêm sản phẩm (hoặc 1 biến thể cụ thể) vào wishlist
+type AddWishlistItemRequest struct {
+	ProductID int64  `json:"product_id" validate:"required,min=1"`
+	VariantID *int64 `json:"variant_id" validate:"omitempty,min=1"`
+}
+
+// StockAlertRequest: Đăng ký nhận thông báo khi biến thể có hàng trở lại
+type StockAlertRequest struct {
+	VariantID int64 `json:"variant_id" validate:"required,min=1"`
+}
+
+// RESPONSE DTOs
+
+// WishlistItemResponse: 1 dòng wishlist
+type WishlistItemResponse struct {
+	ID           int64     `json:"id"`
+	ProductID    int64     `json:"product_id"`
+	ProductName  string    `json:"product_name"`
+	ProductSlug  string    `json:"product_slug"`
+	VariantID    *int64    `json:"variant_id,omitempty"`
+	VariantTitle *string   `json:"variant_title,omitempty"`
+	Price        float64   `json:"price"`
+	InStock      bool      `json:"in_stock"`
+	Available    bool      `json:"available"` // Sản phẩm còn hiển thị
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// StockAlertResponse: 1 đăng ký báo có hàng
+type StockAlertResponse struct {
+	ID           int64      `json:"id"`
+	VariantID    int64      `json:"variant_id"`
+	ProductID    int64      `json:"product_id"`
+	ProductName  string     `json:"product_name"`
+	VariantTitle *string    `json:"variant_title,omitempty"`
+	Notified     bool       `json:"notified"`
+	NotifiedAt   *time.Time `json:"notified_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -9,14 +9,14 @@ import (
 	"net/http"
 )
 
-// InitAddressModule - Khởi tạo module Address
-func InitAddressModule(db *sql.DB, mux *http.ServeMux) {
+// InitAddressModule - Khởi tạo module Address (cần Location để kiểm tra tỉnh / quận / phường)
+func InitAddressModule(db *sql.DB, mux *http.ServeMux, locations addressController.LocationResolver) {
 
 	// Khởi tạo Repository
 	repo := address.NewAddressDb(db)
 
 	//  Khởi tạo Controller
-	ctrl := addressController.NewAddressController(repo)
+	ctrl := addressController.NewAddressController(repo, locations)
 
 	// Khởi tạo Handler (Cần Validator)
 	hdl := addressHandler.NewAddressHandler(ctrl)
@@ -5,8 +5,10 @@ import (
 	"net/http"
 
 	cartCtrl "golang/internal/controller/cart"
+	"golang/internal/cron"
 	cartHdl "golang/internal/handler/cart"
 
+	addressRepo "golang/internal/repository/address"
 	cartRepo "golang/internal/repository/cart"
 	productRepo "golang/internal/repository/product"
 	variantRepo "golang/internal/repository/productvariant"
@@ -14,17 +16,24 @@ import (
 	"golang/internal/router"
 )
 
-func InitCartModule(db *sql.DB, mux *http.ServeMux) {
+// InitCartModule - Khởi tạo module Cart, trả về controller để module User gộp giỏ hàng khách khi đăng nhập
+func InitCartModule(db *sql.DB, mux *http.ServeMux, cronManager *cron.CronManager, shipping cartCtrl.ShippingQuoter) cartCtrl.CartController {
 	//  Khởi tạo Repository
 	repositoryCart := cartRepo.NewCartRepository(db)
 
 	repositoryProduct := productRepo.NewProductRepo(db)
 	repositoryVariant := variantRepo.NewVariantRepo(db)
+	repositoryAddress := addressRepo.NewAddressDb(db)
 
-	controllerCart := cartCtrl.NewCartController(repositoryCart, repositoryProduct, repositoryVariant)
+	controllerCart := cartCtrl.NewCartController(repositoryCart, repositoryProduct, repositoryVariant, repositoryAddress, shipping)
 
 	handlerCart := cartHdl.NewCartHandler(controllerCart)
 
 	//  Đăng ký Router
 	router.NewCartRouter(mux, handlerCart)
+
+	// đăng ký Cron Job: xóa giỏ hàng khách hết hạn (02:30 sáng)
+	cronManager.Register("PurgeGuestCarts", "30 2 * * *", controllerCart.PurgeAbandonedGuestCarts)
+
+	return controllerCart
 }
\ No newline at end of file
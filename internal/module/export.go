@@ -0,0 +1,40 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	exportController "golang/internal/controller/export"
+	"golang/internal/cron"
+	exportHandler "golang/internal/handler/export"
+	"golang/internal/logger"
+	exportRepo "golang/internal/repository/export"
+	orderRepo "golang/internal/repository/order"
+	statsRepo "golang/internal/repository/stats"
+	userRepo "golang/internal/repository/user"
+	"golang/internal/router"
+)
+
+// InitExportModule: Export CSV / XLSX cho đơn hàng, sản phẩm bán chạy, user (đọc trực tiếp repository của các module đó)
+func InitExportModule(db *sql.DB, mux *http.ServeMux, cronManager *cron.CronManager) {
+	repo := exportRepo.NewExportRepository(db)
+
+	ctrl := exportController.NewExportController(
+		repo,
+		orderRepo.NewOrderRepository(db),
+		userRepo.NewUserDb(db),
+		statsRepo.NewStatsRepository(db),
+	)
+	hdl := exportHandler.NewExportHandler(ctrl)
+
+	router.NewExportRouter(mux, hdl)
+
+	// Job bị bỏ dở khi server tắt sẽ không bao giờ xong
+	if err := ctrl.FailInterruptedJobs(context.Background()); err != nil {
+		logger.ErrorLogger.Printf("Lỗi cập nhật job export bị bỏ dở: %v", err)
+	}
+
+	// Dọn file export hết hạn - mỗi giờ
+	cronManager.Register("PurgeExpiredExports", "0 * * * *", ctrl.PurgeExpiredExports)
+}
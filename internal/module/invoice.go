@@ -0,0 +1,25 @@
+package module
+
+import (
+	"database/sql"
+	"net/http"
+
+	invoiceController "golang/internal/controller/invoice"
+	invoiceHandler "golang/internal/handler/invoice"
+	invoiceRepo "golang/internal/repository/invoice"
+	orderRepo "golang/internal/repository/order"
+	"golang/internal/router"
+)
+
+// InitInvoiceModule: Hóa đơn PDF cho đơn đã thanh toán / hoàn thành, trả về controller để module order cấp số khi đổi trạng thái
+func InitInvoiceModule(db *sql.DB, mux *http.ServeMux) invoiceController.InvoiceController {
+	ctrl := invoiceController.NewInvoiceController(
+		invoiceRepo.NewInvoiceRepository(db),
+		orderRepo.NewOrderRepository(db),
+	)
+	hdl := invoiceHandler.NewInvoiceHandler(ctrl)
+
+	router.NewInvoiceRouter(mux, hdl)
+
+	return ctrl
+}
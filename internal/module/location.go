@@ -0,0 +1,27 @@
+package module
+
+import (
+	"net/http"
+
+	locationCtrl "golang/internal/controller/location"
+	locationHdl "golang/internal/handler/location"
+	"golang/internal/logger"
+	locationRepo "golang/internal/repository/location"
+	"golang/internal/router"
+)
+
+// InitLocationModule - Nạp dữ liệu đơn vị hành chính khi khởi động, trả về controller để Address kiểm tra địa chỉ
+func InitLocationModule(mux *http.ServeMux) locationCtrl.LocationController {
+	repositoryLocation, err := locationRepo.NewLocationRepository()
+	if err != nil {
+		logger.FatalLogger.Fatalf("Không thể nạp dữ liệu đơn vị hành chính: %v", err)
+	}
+
+	controllerLocation := locationCtrl.NewLocationController(repositoryLocation)
+
+	handlerLocation := locationHdl.NewLocationHandler(controllerLocation)
+
+	router.NewLocationRouter(mux, handlerLocation)
+
+	return controllerLocation
+}
@@ -15,7 +15,7 @@ import (
 	"golang/internal/router"
 )
 
-func InitOrderModule(db *sql.DB, mux *http.ServeMux) {
+func InitOrderModule(db *sql.DB, mux *http.ServeMux, shipping orderController.ShippingCalculator, stats orderController.StatsRecomputer, cartEvents orderController.CartEventRecorder, invoices orderController.InvoiceIssuer) {
 	orderRepo := order.NewOrderRepository(db)
 	productRepo := product.NewProductRepo(db)
 	variantRepo := productvariant.NewVariantRepo(db)
@@ -27,6 +27,10 @@ func InitOrderModule(db *sql.DB, mux *http.ServeMux) {
 		productRepo,
 		variantRepo,
 		addressRepo,
+		shipping,
+		stats,
+		cartEvents,
+		invoices,
 	)
 
 	//  Khởi tạo Handler
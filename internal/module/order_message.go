@@ -0,0 +1,25 @@
+package module
+
+import (
+	"database/sql"
+	"net/http"
+
+	messageController "golang/internal/controller/ordermessage"
+	messageHandler "golang/internal/handler/ordermessage"
+	orderRepo "golang/internal/repository/order"
+	messageRepo "golang/internal/repository/ordermessage"
+	"golang/internal/router"
+	"golang/internal/storage"
+)
+
+// InitOrderMessageModule: Trao đổi trong đơn hàng (tin nhắn với khách + ghi chú nội bộ), file đính kèm lưu qua Storage
+func InitOrderMessageModule(db *sql.DB, mux *http.ServeMux, fileStorage storage.Storage) {
+	ctrl := messageController.NewOrderMessageController(
+		messageRepo.NewOrderMessageRepository(db),
+		orderRepo.NewOrderRepository(db),
+		fileStorage,
+	)
+	hdl := messageHandler.NewOrderMessageHandler(ctrl)
+
+	router.NewOrderMessageRouter(mux, hdl)
+}
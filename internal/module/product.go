@@ -10,6 +10,8 @@ import (
 	productHistoryHandler "golang/internal/handler/producthistory"
 	productReviewHandler "golang/internal/handler/productreview"
 	productVariantHandler "golang/internal/handler/productvariant"
+	"golang/internal/cron"
+	category "golang/internal/repository/category"
 	order "golang/internal/repository/order"
 	product "golang/internal/repository/product"
 	producthistory "golang/internal/repository/producthistory"
@@ -19,17 +21,18 @@ import (
 	"net/http"
 )
 
-func InitProductModule(db *sql.DB, mux *http.ServeMux) {
+func InitProductModule(db *sql.DB, mux *http.ServeMux, cronManager *cron.CronManager, restockNotifier productVariantController.RestockNotifier) productVariantController.ProductVariantController {
 	// khởi tạo repo
 	repoProduct := product.NewProductRepo(db)
 	repoVariant := productVariant.NewVariantRepo(db)
 	repoHistory := producthistory.NewProductHistoryRepo(db)
 	repoReview := productreview.NewProductReviewRepo(db)
 	orderRepo := order.NewOrderRepository(db)
+	repoCategory := category.NewCategoryDb(db)
 
-	// khởi tạo Controller
-	ctrlProduct := productController.NewProductController(repoProduct, repoVariant, repoHistory, repoReview)
-	ctrlVariant := productVariantController.NewProductVariantController(repoVariant)
+	// khởi tạo Controller (sửa tồn kho biến thể thì báo Stats kiểm tra cảnh báo sắp hết hàng ngay)
+	ctrlVariant := productVariantController.NewProductVariantController(repoVariant, repoHistory, restockNotifier, cronManager.StatsController)
+	ctrlProduct := productController.NewProductController(repoProduct, repoVariant, repoHistory, repoReview, ctrlVariant, repoCategory)
 	ctrlHistory := producthistoryController.NewProductHistoryController(repoHistory)
 	ctrlReview := productReviewsController.NewProductReviewsController(repoReview, orderRepo)
 	// khởi tạo Handler
@@ -42,4 +45,15 @@ func InitProductModule(db *sql.DB, mux *http.ServeMux) {
 	router.NewProductVariantRouter(mux, hdlVariant)
 	router.NewProductHistoryRouter(mux, hdlHistory)
 	router.NewProductReviewRouter(mux, hdlReview)
+
+	// đăng ký Cron Job: publish / unpublish theo lịch (mỗi phút)
+	cronManager.Register("ScheduledPublishing", "* * * * *", ctrlProduct.RunScheduledPublishing)
+
+	// đăng ký Cron Job: dọn thùng rác sản phẩm quá hạn (03:00 sáng)
+	cronManager.Register("PurgeProductTrash", "0 3 * * *", ctrlProduct.PurgeExpiredTrash)
+
+	// đăng ký Cron Job: sửa lệch rating tổng hợp của sản phẩm (04:00 sáng)
+	cronManager.Register("RecomputeProductRatings", "0 4 * * *", ctrlReview.RunRatingRecompute)
+
+	return ctrlVariant
 }
@@ -0,0 +1,27 @@
+package module
+
+import (
+	"database/sql"
+	"net/http"
+
+	returnController "golang/internal/controller/returns"
+	returnHandler "golang/internal/handler/returns"
+	orderRepo "golang/internal/repository/order"
+	returnRepo "golang/internal/repository/returns"
+	"golang/internal/router"
+	"golang/internal/storage"
+)
+
+// InitReturnModule: Trả hàng (RMA), ảnh lưu qua Storage, nhận hàng thì cộng lại tồn kho qua module product
+func InitReturnModule(db *sql.DB, mux *http.ServeMux, fileStorage storage.Storage, stock returnController.StockAdjuster, stats returnController.StatsRecomputer) {
+	ctrl := returnController.NewReturnController(
+		returnRepo.NewReturnRepository(db),
+		orderRepo.NewOrderRepository(db),
+		fileStorage,
+		stock,
+		stats,
+	)
+	hdl := returnHandler.NewReturnHandler(ctrl)
+
+	router.NewReturnRouter(mux, hdl)
+}
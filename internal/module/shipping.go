@@ -0,0 +1,24 @@
+package module
+
+import (
+	"database/sql"
+	"net/http"
+
+	shippingCtrl "golang/internal/controller/shipping"
+	shippingHdl "golang/internal/handler/shipping"
+	shippingRepo "golang/internal/repository/shipping"
+	"golang/internal/router"
+)
+
+// InitShippingModule - Khởi tạo module Shipping, trả về controller để Cart / Order tính phí giao hàng
+func InitShippingModule(db *sql.DB, mux *http.ServeMux) shippingCtrl.ShippingController {
+	repositoryShipping := shippingRepo.NewShippingRepository(db)
+
+	controllerShipping := shippingCtrl.NewShippingController(repositoryShipping)
+
+	handlerShipping := shippingHdl.NewShippingHandler(controllerShipping)
+
+	router.NewShippingRouter(mux, handlerShipping)
+
+	return controllerShipping
+}
@@ -1,23 +1,28 @@
 package module
 
 import (
+	"context"
 	"database/sql"
 	"net/http"
 
 	statsController "golang/internal/controller/stats"
 	"golang/internal/cron"
 	statsHandler "golang/internal/handler/stats"
+	"golang/internal/logger"
+	"golang/internal/notification"
 	statsRepo "golang/internal/repository/stats"
+	userRepo "golang/internal/repository/user"
 	"golang/internal/router"
 )
 
 // InitStatsModule: Hàm khởi tạo toàn bộ module thống kê
-func InitStatsModule(db *sql.DB, mux *http.ServeMux) *cron.CronManager {
+func InitStatsModule(db *sql.DB, mux *http.ServeMux, notifier notification.Notifier) *cron.CronManager {
 	// Khởi tạo Repository
 	repo := statsRepo.NewStatsRepository(db)
+	repoUser := userRepo.NewUserDb(db)
 
 	//  Khởi tạo Controller
-	ctrl := statsController.NewStatsController(repo)
+	ctrl := statsController.NewStatsController(repo, repoUser, notifier)
 
 	//  Khởi tạo Handler
 	hdl := statsHandler.NewStatsHandler(ctrl)
@@ -25,8 +30,33 @@ func InitStatsModule(db *sql.DB, mux *http.ServeMux) *cron.CronManager {
 	//  Đăng ký Router
 	router.NewStatsRouter(mux, hdl)
 
+	// Bù các ngày thống kê bị thiếu + chỉ số khách hàng lần đầu (chạy nền, không chặn server khởi động)
+	go func() {
+		if _, err := ctrl.FillGaps(context.Background()); err != nil {
+			logger.ErrorLogger.Printf("Lỗi bù dữ liệu thống kê khi khởi động: %v", err)
+		}
+		if err := ctrl.EnsureCustomerAnalytics(context.Background()); err != nil {
+			logger.ErrorLogger.Printf("Lỗi tính chỉ số khách hàng khi khởi động: %v", err)
+		}
+		if err := ctrl.EnsureInventoryForecast(context.Background()); err != nil {
+			logger.ErrorLogger.Printf("Lỗi tính dự báo tồn kho khi khởi động: %v", err)
+		}
+	}()
+
 	// Khởi tạo Cron Manager
 	cronManager := cron.NewCronManager(ctrl)
 
+	// Chỉ số khách hàng (LTV, RFM, cohort) - 01:00 sáng, sau job thống kê doanh thu
+	cronManager.Register("RefreshCustomerAnalytics", "0 1 * * *", ctrl.RefreshCustomerAnalytics)
+
+	// Dự báo tồn kho (velocity từ product_sales_daily) - 01:30 sáng, sau job thống kê doanh thu
+	cronManager.Register("RefreshInventoryForecast", "30 1 * * *", ctrl.RefreshInventoryForecast)
+
+	// Cảnh báo sắp hết hàng - mỗi giờ, bù cho tồn kho giảm qua đơn hàng (sửa / điều chỉnh tồn kho biến thể thì kiểm tra ngay)
+	cronManager.Register("CheckLowStock", "0 * * * *", ctrl.CheckLowStock)
+
+	// Phễu giỏ hàng (chuyển đổi, bỏ quên) - 01:45 sáng, tính lại các ngày còn trong cửa sổ chuyển đổi
+	cronManager.Register("RefreshCartFunnel", "45 1 * * *", ctrl.RefreshCartFunnel)
+
 	return cronManager
 }
@@ -9,12 +9,13 @@ import (
 	"net/http"
 )
 
-// InitUserModule
-func InitUserModule(db *sql.DB, mux *http.ServeMux) {
+// InitUserModule - cartMerger (Cart controller) dùng để gộp giỏ hàng khách khi đăng nhập / đăng ký
+func InitUserModule(db *sql.DB, mux *http.ServeMux, cartMerger userController.CartMerger) {
 
 	// Khởi tạo các tầng
 	repo := user.NewUserDb(db)
-	ctrl := userController.NewUserController(repo)
+
+	ctrl := userController.NewUserController(repo, cartMerger)
 	hdl := userHandler.NewUserHandler(ctrl)
 
 	// Đăng ký router User
@@ -0,0 +1,29 @@
+package module
+
+import (
+	"database/sql"
+	"net/http"
+
+	wishlistCtrl "golang/internal/controller/wishlist"
+	wishlistHdl "golang/internal/handler/wishlist"
+	"golang/internal/notification"
+	productRepo "golang/internal/repository/product"
+	variantRepo "golang/internal/repository/productvariant"
+	wishlistRepo "golang/internal/repository/wishlist"
+	"golang/internal/router"
+)
+
+// InitWishlistModule - Khởi tạo module Wishlist, trả về controller để module Product báo khi biến thể có hàng trở lại
+func InitWishlistModule(db *sql.DB, mux *http.ServeMux, notifier notification.Notifier) wishlistCtrl.WishlistController {
+	repositoryWishlist := wishlistRepo.NewWishlistRepository(db)
+	repositoryProduct := productRepo.NewProductRepo(db)
+	repositoryVariant := variantRepo.NewVariantRepo(db)
+
+	controllerWishlist := wishlistCtrl.NewWishlistController(repositoryWishlist, repositoryProduct, repositoryVariant, notifier)
+
+	handlerWishlist := wishlistHdl.NewWishlistHandler(controllerWishlist)
+
+	router.NewWishlistRouter(mux, handlerWishlist)
+
+	return controllerWishlist
+}
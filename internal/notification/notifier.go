@@ -0,0 +1,27 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+
+	"golang/internal/logger"
+	"golang/internal/model"
+)
+
+// Notifier - Kênh gửi thông báo, có thể thay bằng email / push mà không đổi nghiệp vụ
+type Notifier interface {
+	Send(ctx context.Context, n model.Notification) error
+}
+
+type logNotifier struct{}
+
+// NewLogNotifier - Notifier mặc định: chỉ ghi thông báo ra log
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+func (l *logNotifier) Send(ctx context.Context, n model.Notification) error {
+	data, _ := json.Marshal(n.Data)
+	logger.InfoLogger.Printf("Notification [%s] -> user %d <%s>: %s - %s %s", n.Type, n.UserID, n.Email, n.Title, n.Body, data)
+	return nil
+}
@@ -20,8 +20,9 @@ func (r *AddressDb) CreateAddress(address model.Address) (model.Address, error)
 	logger.DebugLogger.Printf("Starting CreateAddress for UserID: %d", address.UserID)
 
 	query := `
-        INSERT INTO addresses (user_id, label, recipient_name, phone, line1, line2, city, state, country, is_default_shipping, created_at, updated_at)
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+        INSERT INTO addresses (user_id, label, recipient_name, phone, line1, line2, city, state, ward, country,
+            province_code, district_code, ward_code, is_default_shipping, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?)`
 
 	now := time.Now()
 
@@ -34,7 +35,11 @@ func (r *AddressDb) CreateAddress(address model.Address) (model.Address, error)
 		address.Line2,
 		address.City,
 		address.State,
+		address.Ward,
 		address.Country,
+		address.ProvinceCode,
+		address.DistrictCode,
+		address.WardCode,
 		address.IsDefaultShipping,
 		now,
 		now,
@@ -64,7 +69,8 @@ func (r *AddressDb) GetAddressByID(id int64, userID int64) (model.Address, error
 	logger.DebugLogger.Printf("Starting GetAddressByID: %d", id)
 
 	query := `
-        SELECT id, user_id, COALESCE(label, ''), recipient_name, phone, line1, COALESCE(line2, ''), city, COALESCE(state, ''), country, is_default_shipping, created_at, updated_at
+        SELECT id, user_id, COALESCE(label, ''), recipient_name, phone, line1, COALESCE(line2, ''), city, COALESCE(state, ''), COALESCE(ward, ''), country,
+               COALESCE(province_code, ''), COALESCE(district_code, ''), COALESCE(ward_code, ''), is_default_shipping, created_at, updated_at
         FROM addresses
         WHERE id = ? AND user_id = ?`
 
@@ -79,7 +85,11 @@ func (r *AddressDb) GetAddressByID(id int64, userID int64) (model.Address, error
 		&addr.Line2,
 		&addr.City,
 		&addr.State,
+		&addr.Ward,
 		&addr.Country,
+		&addr.ProvinceCode,
+		&addr.DistrictCode,
+		&addr.WardCode,
 		&addr.IsDefaultShipping,
 		&addr.CreatedAt,
 		&addr.UpdatedAt,
@@ -102,7 +112,8 @@ func (r *AddressDb) GetAddressesByUserID(userID int64) ([]model.Address, error)
 	logger.DebugLogger.Printf("Starting GetAddressesByUserID: %d", userID)
 
 	query := `
-        SELECT id, user_id, COALESCE(label, ''), recipient_name, phone, line1, COALESCE(line2, ''), city, COALESCE(state, ''), country, is_default_shipping, created_at, updated_at
+        SELECT id, user_id, COALESCE(label, ''), recipient_name, phone, line1, COALESCE(line2, ''), city, COALESCE(state, ''), COALESCE(ward, ''), country,
+               COALESCE(province_code, ''), COALESCE(district_code, ''), COALESCE(ward_code, ''), is_default_shipping, created_at, updated_at
         FROM addresses
         WHERE user_id = ?
         ORDER BY is_default_shipping DESC, created_at DESC`
@@ -127,7 +138,11 @@ func (r *AddressDb) GetAddressesByUserID(userID int64) ([]model.Address, error)
 			&addr.Line2,
 			&addr.City,
 			&addr.State,
+			&addr.Ward,
 			&addr.Country,
+			&addr.ProvinceCode,
+			&addr.DistrictCode,
+			&addr.WardCode,
 			&addr.IsDefaultShipping,
 			&addr.CreatedAt,
 			&addr.UpdatedAt,
@@ -157,7 +172,11 @@ func (r *AddressDb) UpdateAddress(id int64, userID int64, req model.UpdateAddres
             line2 = COALESCE(?, line2),
             city = COALESCE(?, city),
             state = COALESCE(?, state),
+            ward = NULLIF(COALESCE(?, ward), ''),
             country = COALESCE(?, country),
+            province_code = NULLIF(COALESCE(?, province_code), ''),
+            district_code = NULLIF(COALESCE(?, district_code), ''),
+            ward_code = NULLIF(COALESCE(?, ward_code), ''),
             is_default_shipping = COALESCE(?, is_default_shipping),
             updated_at = ?
         WHERE id = ?`
@@ -170,7 +189,11 @@ func (r *AddressDb) UpdateAddress(id int64, userID int64, req model.UpdateAddres
 		req.Line2,
 		req.City,
 		req.State,
+		req.Ward,
 		req.Country,
+		req.ProvinceCode,
+		req.DistrictCode,
+		req.WardCode,
 		req.IsDefaultShipping,
 		now,
 		id,
@@ -3,6 +3,7 @@ package cart
 import (
 	"context"
 	"golang/internal/model"
+	"time"
 )
 
 type ICartRepository interface {
@@ -12,18 +13,42 @@ type ICartRepository interface {
 	// Tạo giỏ hàng mới cho user
 	CreateCart(ctx context.Context, userID int64) (int64, error)
 
+	// Lấy ID giỏ hàng của khách theo guest token (chỉ giỏ còn hạn, không có thì trả về 0)
+	GetCartIDByGuestToken(ctx context.Context, guestToken string) (int64, error)
+
+	// Tạo giỏ hàng mới cho khách
+	CreateGuestCart(ctx context.Context, guestToken string, expiresAt time.Time) (int64, error)
+
+	// Gia hạn giỏ hàng khách khi có thao tác
+	TouchGuestCart(ctx context.Context, cartID int64, expiresAt time.Time) error
+
+	// Gộp các dòng của giỏ khách vào giỏ đích (cộng dồn, giới hạn tồn kho) rồi xóa giỏ khách
+	MergeCartItems(ctx context.Context, guestCartID int64, targetCartID int64, lines []model.CartMergeLine) error
+
+	// Xóa các giỏ hàng khách đã hết hạn
+	DeleteExpiredGuestCarts(ctx context.Context) (int64, error)
+
 	// Lấy danh sách item thô trong giỏ (chưa join product)
 	GetCartItems(ctx context.Context, cartID int64) ([]model.CartItem, error)
 
-	// Thêm sản phẩm vào giỏ (Nếu đã có thì cộng dồn số lượng)
-	UpsertCartItem(ctx context.Context, cartID int64, req model.AddToCartRequest) error
+	// Thêm sản phẩm vào giỏ (Nếu đã có thì cộng dồn số lượng), lưu lại giá hiện tại làm snapshot
+	UpsertCartItem(ctx context.Context, cartID int64, req model.AddToCartRequest, price float64) error
 
 	// Cập nhật số lượng cụ thể (VD: user sửa số lượng từ 1 thành 5)
 	UpdateItemQuantity(ctx context.Context, cartID int64, variantID int64, quantity int) error
 
+	// Cập nhật giá snapshot (khi user chấp nhận giá mới)
+	UpdateItemPriceSnapshot(ctx context.Context, cartID int64, variantID int64, price float64) error
+
 	// Xóa sản phẩm
 	RemoveItems(ctx context.Context, cartID int64, variantIDs []int64) error
 	
 	// Đếm số lượng loại sản phẩm trong giỏ (để hiện badge trên icon giỏ hàng nếu cần)
 	CountCartItems(ctx context.Context, cartID int64) (int, error)
+
+	// Ghi sự kiện giỏ hàng (thêm / xóa / preview / tạo đơn) cho báo cáo phễu
+	RecordCartEvent(ctx context.Context, event model.CartEvent) error
+
+	// Chuyển sự kiện của giỏ khách sang giỏ user sau khi gộp
+	ReassignCartEvents(ctx context.Context, fromCartID int64, toCartID int64, userID int64) error
 }
\ No newline at end of file
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"golang/internal/logger" 
 	"golang/internal/model"
@@ -66,7 +67,7 @@ func (r *cartRepository) GetCartItems(ctx context.Context, cartID int64) ([]mode
 
 	var items []model.CartItem
 	query := `
-		SELECT id, cart_id, product_id, variant_id, quantity, created_at, updated_at 
+		SELECT id, cart_id, product_id, variant_id, quantity, price_snapshot, created_at, updated_at 
 		FROM cart_items 
 		WHERE cart_id = ?
 		ORDER BY created_at DESC
@@ -88,6 +89,7 @@ func (r *cartRepository) GetCartItems(ctx context.Context, cartID int64) ([]mode
 			&i.ProductID,
 			&i.VariantID,
 			&i.Quantity,
+			&i.PriceSnapshot,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		)
@@ -108,18 +110,20 @@ func (r *cartRepository) GetCartItems(ctx context.Context, cartID int64) ([]mode
 }
 
 // Thêm hoặc Cộng dồn số lượng (user bấm thêm vào giỏ ở trang chi tiết sản phẩm)
-func (r *cartRepository) UpsertCartItem(ctx context.Context, cartID int64, req model.AddToCartRequest) error {
+func (r *cartRepository) UpsertCartItem(ctx context.Context, cartID int64, req model.AddToCartRequest, price float64) error {
 	logger.DebugLogger.Printf("Repo: Upserting item for CartID: %d, VariantID: %d", cartID, req.VariantID)
 
+	// Thêm lại cùng sản phẩm -> user đã thấy giá hiện tại nên làm mới snapshot
 	query := `
-		INSERT INTO cart_items (cart_id, product_id, variant_id, quantity, created_at, updated_at)
-		VALUES (?, ?, ?, ?, NOW(), NOW())
+		INSERT INTO cart_items (cart_id, product_id, variant_id, quantity, price_snapshot, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, NOW(), NOW())
 		ON DUPLICATE KEY UPDATE 
 			quantity = quantity + VALUES(quantity),
+			price_snapshot = VALUES(price_snapshot),
 			updated_at = NOW()
 	`
 
-	_, err := r.db.ExecContext(ctx, query, cartID, req.ProductID, req.VariantID, req.Quantity)
+	_, err := r.db.ExecContext(ctx, query, cartID, req.ProductID, req.VariantID, req.Quantity, price)
 	if err != nil {
 		logger.ErrorLogger.Printf("Repo: Failed to upsert cart item: %v", err)
 		return err
@@ -145,6 +149,16 @@ func (r *cartRepository) UpdateItemQuantity(ctx context.Context, cartID int64, v
 	return nil
 }
 
+// Cập nhật giá snapshot của 1 dòng
+func (r *cartRepository) UpdateItemPriceSnapshot(ctx context.Context, cartID int64, variantID int64, price float64) error {
+	query := `UPDATE cart_items SET price_snapshot = ?, updated_at = NOW() WHERE cart_id = ? AND variant_id = ?`
+	if _, err := r.db.ExecContext(ctx, query, price, cartID, variantID); err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to update price snapshot: %v", err)
+		return err
+	}
+	return nil
+}
+
 // Xóa 1 hoặc nhiều sản phẩm 
 func (r *cartRepository) RemoveItems(ctx context.Context, cartID int64, variantIDs []int64) error {
 	if len(variantIDs) == 0 {
@@ -191,4 +205,131 @@ func (r *cartRepository) CountCartItems(ctx context.Context, cartID int64) (int,
 		return 0, err
 	}
 	return count, nil
-}
\ No newline at end of file
+}
+// Lấy CartID của khách theo guest token (bỏ qua giỏ đã hết hạn)
+func (r *cartRepository) GetCartIDByGuestToken(ctx context.Context, guestToken string) (int64, error) {
+	var id int64
+	query := `SELECT id FROM carts WHERE guest_token = ? AND user_id IS NULL AND expires_at > NOW() LIMIT 1`
+
+	err := r.db.QueryRowContext(ctx, query, guestToken).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		logger.ErrorLogger.Printf("Repo: Error getting guest cart ID: %v", err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Tạo giỏ hàng cho khách (xóa giỏ cũ đã hết hạn cùng token nếu còn sót lại)
+func (r *cartRepository) CreateGuestCart(ctx context.Context, guestToken string, expiresAt time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM carts WHERE guest_token = ? AND expires_at <= NOW()`, guestToken); err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to clear expired guest cart: %v", err)
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO carts (guest_token, expires_at, created_at, updated_at) VALUES (?, ?, NOW(), NOW())`,
+		guestToken, expiresAt)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to create guest cart: %v", err)
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	logger.InfoLogger.Printf("Repo: Guest cart created with ID: %d", id)
+	return id, nil
+}
+
+// Gia hạn giỏ hàng khách
+func (r *cartRepository) TouchGuestCart(ctx context.Context, cartID int64, expiresAt time.Time) error {
+	query := `UPDATE carts SET expires_at = ?, updated_at = NOW() WHERE id = ? AND user_id IS NULL`
+	if _, err := r.db.ExecContext(ctx, query, expiresAt, cartID); err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to touch guest cart %d: %v", cartID, err)
+		return err
+	}
+	return nil
+}
+
+// Gộp giỏ khách vào giỏ đích trong 1 transaction
+func (r *cartRepository) MergeCartItems(ctx context.Context, guestCartID int64, targetCartID int64, lines []model.CartMergeLine) error {
+	logger.DebugLogger.Printf("Repo: Merging guest cart %d into cart %d (%d lines)", guestCartID, targetCartID, len(lines))
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Cộng dồn giống UpsertCartItem nhưng không vượt quá tồn kho
+	query := `
+		INSERT INTO cart_items (cart_id, product_id, variant_id, quantity, price_snapshot, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, NOW(), NOW())
+		ON DUPLICATE KEY UPDATE
+			quantity = LEAST(quantity + VALUES(quantity), ?),
+			updated_at = NOW()
+	`
+	for _, l := range lines {
+		if _, err := tx.ExecContext(ctx, query, targetCartID, l.ProductID, l.VariantID, l.Quantity, l.PriceSnapshot, l.MaxQuantity); err != nil {
+			logger.ErrorLogger.Printf("Repo: Failed to merge variant %d: %v", l.VariantID, err)
+			return err
+		}
+	}
+
+	// cart_items của giỏ khách bị xóa theo ON DELETE CASCADE
+	if _, err := tx.ExecContext(ctx, `DELETE FROM carts WHERE id = ? AND user_id IS NULL`, guestCartID); err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to delete guest cart %d: %v", guestCartID, err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Xóa giỏ hàng khách đã hết hạn
+func (r *cartRepository) DeleteExpiredGuestCarts(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM carts WHERE user_id IS NULL AND expires_at <= NOW()`)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to purge guest carts: %v", err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Ghi 1 sự kiện giỏ hàng (báo cáo phễu bán hàng)
+func (r *cartRepository) RecordCartEvent(ctx context.Context, event model.CartEvent) error {
+	query := `INSERT INTO cart_events (event_type, cart_id, user_id, product_id, variant_id, quantity, amount, order_id, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW())`
+	_, err := r.db.ExecContext(ctx, query,
+		event.EventType, event.CartID, event.UserID, event.ProductID, event.VariantID,
+		event.Quantity, event.Amount, event.OrderID,
+	)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to record cart event %s: %v", event.EventType, err)
+	}
+	return err
+}
+
+// Chuyển sự kiện của giỏ khách sang giỏ user sau khi gộp (để đơn hàng của user được tính là chuyển đổi của giỏ khách)
+func (r *cartRepository) ReassignCartEvents(ctx context.Context, fromCartID int64, toCartID int64, userID int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE cart_events SET cart_id = ?, user_id = ? WHERE cart_id = ?`, toCartID, userID, fromCartID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to reassign cart events %d -> %d: %v", fromCartID, toCartID, err)
+	}
+	return err
+}
@@ -2,9 +2,11 @@ package category
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"golang/internal/logger"
 	"golang/internal/model"
+	"strings"
 	"time"
 
 	"github.com/gosimple/slug"
@@ -25,8 +27,10 @@ func (r *CategoryDb) CreateCategory(category *model.Category) (*model.Category,
 	logger.DebugLogger.Printf("Starting CreateCategory: %s", category.Name)
 
 	now := time.Now()
-	query := `INSERT INTO categories (name, slug, description, is_active, created_at, updated_at) 
-			  VALUES (?, ?, ?, ?, ?, ?)`
+	// Danh mục mới luôn đứng cuối trong nhóm anh em cùng cha
+	query := `INSERT INTO categories (name, slug, description, is_active, parent_id, position, created_at, updated_at) 
+			  SELECT ?, ?, ?, ?, ?, COALESCE(MAX(position) + 1, 0), ?, ?
+			  FROM categories WHERE parent_id <=> ?`
 
 	// Exec trả về Result
 	result, err := r.db.Exec(query,
@@ -34,8 +38,10 @@ func (r *CategoryDb) CreateCategory(category *model.Category) (*model.Category,
 		category.Slug,
 		category.Description,
 		category.IsActive,
+		category.ParentID,
 		now,
 		now,
+		category.ParentID,
 	)
 
 	if err != nil {
@@ -52,6 +58,9 @@ func (r *CategoryDb) CreateCategory(category *model.Category) (*model.Category,
 
 	// Gán lại thông tin để trả về
 	category.ID = id
+	if err := r.db.QueryRow("SELECT position FROM categories WHERE id = ?", id).Scan(&category.Position); err != nil {
+		return nil, err
+	}
 	category.CreatedAt = now
 	category.UpdatedAt = now
 
@@ -63,13 +72,13 @@ func (r *CategoryDb) CreateCategory(category *model.Category) (*model.Category,
 func (r *CategoryDb) GetCategoryByID(id int64) (*model.Category, error) {
 	logger.DebugLogger.Printf("Starting GetCategoryByID: %d", id)
 
-	query := `SELECT id, name, slug, description, is_active, created_at, updated_at 
+	query := `SELECT id, name, slug, description, is_active, parent_id, position, created_at, updated_at 
 			  FROM categories WHERE id = ?`
 
 	var cat model.Category
 	err := r.db.QueryRow(query, id).Scan(
 		&cat.ID, &cat.Name, &cat.Slug, &cat.Description,
-		&cat.IsActive, &cat.CreatedAt, &cat.UpdatedAt,
+		&cat.IsActive, &cat.ParentID, &cat.Position, &cat.CreatedAt, &cat.UpdatedAt,
 	)
 
 	if err != nil {
@@ -87,7 +96,7 @@ func (r *CategoryDb) GetCategoryByID(id int64) (*model.Category, error) {
 // AdminSearchCategories: Tìm kiếm danh mục theo tên (Lấy cả Active và Inactive)
 func (r *CategoryDb) SearchAllCategories(keyword string, isActive *bool) ([]model.Category, error) {
 	// Tìm theo Name hoặc Slug
-	query := `SELECT id, name, slug, description, is_active, created_at, updated_at 
+	query := `SELECT id, name, slug, description, is_active, parent_id, position, created_at, updated_at 
 			  FROM categories 
 			  WHERE (name LIKE ? OR slug LIKE ?)`
 	
@@ -116,7 +125,7 @@ func (r *CategoryDb) SearchAllCategories(keyword string, isActive *bool) ([]mode
 	var categories []model.Category
 	for rows.Next() {
 		var cat model.Category
-		if err := rows.Scan(&cat.ID, &cat.Name, &cat.Slug, &cat.Description, &cat.IsActive, &cat.CreatedAt, &cat.UpdatedAt); err != nil {
+		if err := rows.Scan(&cat.ID, &cat.Name, &cat.Slug, &cat.Description, &cat.IsActive, &cat.ParentID, &cat.Position, &cat.CreatedAt, &cat.UpdatedAt); err != nil {
 			return nil, err
 		}
 		categories = append(categories, cat)
@@ -133,7 +142,7 @@ func (r *CategoryDb) SearchActiveCategories(keyword string) ([]model.Category, e
 	nameKeyword := "%" + keyword + "%"
 	slugKeyword := "%" + slug.Make(keyword) + "%"
 
-	query := `SELECT id, name, slug, description, is_active, created_at, updated_at 
+	query := `SELECT id, name, slug, description, is_active, parent_id, position, created_at, updated_at 
               FROM categories 
               WHERE (name LIKE ? OR slug LIKE ?) AND is_active = 1 
               ORDER BY created_at DESC`
@@ -148,7 +157,7 @@ func (r *CategoryDb) SearchActiveCategories(keyword string) ([]model.Category, e
 	var categories []model.Category
 	for rows.Next() {
 		var cat model.Category
-		err := rows.Scan(&cat.ID, &cat.Name, &cat.Slug, &cat.Description, &cat.IsActive, &cat.CreatedAt, &cat.UpdatedAt)
+		err := rows.Scan(&cat.ID, &cat.Name, &cat.Slug, &cat.Description, &cat.IsActive, &cat.ParentID, &cat.Position, &cat.CreatedAt, &cat.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -170,7 +179,7 @@ func (r *CategoryDb) GetAllCategories(req model.AdminGetCategoriesRequest) ([]mo
 	}
 
 	// 3. Query Lấy dữ liệu
-	query := `SELECT id, name, slug, description, is_active, created_at, updated_at 
+	query := `SELECT id, name, slug, description, is_active, parent_id, position, created_at, updated_at 
 			  FROM categories 
 			  ORDER BY created_at DESC 
 			  LIMIT ? OFFSET ?`
@@ -184,7 +193,7 @@ func (r *CategoryDb) GetAllCategories(req model.AdminGetCategoriesRequest) ([]mo
 	var categories []model.Category
 	for rows.Next() {
 		var cat model.Category
-		if err := rows.Scan(&cat.ID, &cat.Name, &cat.Slug, &cat.Description, &cat.IsActive, &cat.CreatedAt, &cat.UpdatedAt); err != nil {
+		if err := rows.Scan(&cat.ID, &cat.Name, &cat.Slug, &cat.Description, &cat.IsActive, &cat.ParentID, &cat.Position, &cat.CreatedAt, &cat.UpdatedAt); err != nil {
 			return nil, 0, err
 		}
 		categories = append(categories, cat)
@@ -197,7 +206,7 @@ func (r *CategoryDb) GetAllCategories(req model.AdminGetCategoriesRequest) ([]mo
 func (r *CategoryDb) GetActiveCategories() ([]model.Category, error) {
 	logger.DebugLogger.Println("Starting GetActiveCategories")
 
-	query := `SELECT id, name, slug, description, is_active, created_at, updated_at 
+	query := `SELECT id, name, slug, description, is_active, parent_id, position, created_at, updated_at 
               FROM categories 
               WHERE is_active = 1 
               ORDER BY created_at DESC`
@@ -215,7 +224,7 @@ func (r *CategoryDb) GetActiveCategories() ([]model.Category, error) {
 		var cat model.Category
 		err := rows.Scan(
 			&cat.ID, &cat.Name, &cat.Slug, &cat.Description,
-			&cat.IsActive, &cat.CreatedAt, &cat.UpdatedAt,
+			&cat.IsActive, &cat.ParentID, &cat.Position, &cat.CreatedAt, &cat.UpdatedAt,
 		)
 		if err != nil {
 			logger.ErrorLogger.Printf("Scan Row Failed: %v", err)
@@ -320,6 +329,16 @@ func (r *CategoryDb) DeleteCategoryHard(id int64) error {
 		return fmt.Errorf("không thể xóa: danh mục này đang chứa %d sản phẩm. Vui lòng gỡ sản phẩm trước", count)
 	}
 
+	// Kiểm tra ràng buộc danh mục con
+	children, err := r.CountChildCategories(id)
+	if err != nil {
+		return err
+	}
+
+	if children > 0 {
+		return fmt.Errorf("không thể xóa: danh mục này đang có %d danh mục con. Vui lòng chuyển hoặc xóa danh mục con trước", children)
+	}
+
 	//  Thực hiện xóa vĩnh viễn
 	query := "DELETE FROM categories WHERE id = ?"
 	res, err := r.db.Exec(query, id)
@@ -360,3 +379,281 @@ func (r *CategoryDb) CheckSlugExist(slug string) (bool, error) {
 	}
 	return exists, nil
 }
+
+// GetCategoryTreeRows - Lấy toàn bộ danh mục (sắp theo vị trí) để dựng cây
+func (r *CategoryDb) GetCategoryTreeRows() ([]model.Category, error) {
+	query := `SELECT id, name, slug, description, is_active, parent_id, position, created_at, updated_at 
+			  FROM categories 
+			  ORDER BY position ASC, id ASC`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		logger.ErrorLogger.Printf("GetCategoryTreeRows Failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []model.Category{}
+	for rows.Next() {
+		var cat model.Category
+		if err := rows.Scan(&cat.ID, &cat.Name, &cat.Slug, &cat.Description, &cat.IsActive, &cat.ParentID, &cat.Position, &cat.CreatedAt, &cat.UpdatedAt); err != nil {
+			return nil, err
+		}
+		categories = append(categories, cat)
+	}
+	return categories, rows.Err()
+}
+
+// CountChildCategories - Đếm số danh mục con trực tiếp
+func (r *CategoryDb) CountChildCategories(id int64) (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM categories WHERE parent_id = ?", id).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// MoveCategory - Chuyển danh mục sang cha mới và chèn vào vị trí position, đánh số lại anh em (Transaction)
+func (r *CategoryDb) MoveCategory(id int64, parentID *int64, position int) error {
+	logger.DebugLogger.Printf("Starting MoveCategory ID: %d -> parent %v, position %d", id, parentID, position)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lấy danh sách anh em ở cha mới (không tính chính nó)
+	rows, err := tx.Query(`SELECT id FROM categories WHERE parent_id <=> ? AND id <> ? ORDER BY position ASC, id ASC FOR UPDATE`, parentID, id)
+	if err != nil {
+		return err
+	}
+	siblings := []int64{}
+	for rows.Next() {
+		var sid int64
+		if err := rows.Scan(&sid); err != nil {
+			rows.Close()
+			return err
+		}
+		siblings = append(siblings, sid)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if position < 0 || position > len(siblings) {
+		position = len(siblings)
+	}
+	ordered := make([]int64, 0, len(siblings)+1)
+	ordered = append(ordered, siblings[:position]...)
+	ordered = append(ordered, id)
+	ordered = append(ordered, siblings[position:]...)
+
+	now := time.Now()
+	if _, err := tx.Exec("UPDATE categories SET parent_id = ?, updated_at = ? WHERE id = ?", parentID, now, id); err != nil {
+		logger.ErrorLogger.Printf("MoveCategory: update parent failed: %v", err)
+		return err
+	}
+
+	stmt, err := tx.Prepare("UPDATE categories SET position = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for idx, cid := range ordered {
+		if _, err := stmt.Exec(idx, cid); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	logger.InfoLogger.Printf("MoveCategory success ID: %d", id)
+	return nil
+}
+
+// scanCategoryAttributes - Đọc danh sách thuộc tính (enum_values lưu dạng JSON)
+func scanCategoryAttributes(rows *sql.Rows) ([]model.CategoryAttribute, error) {
+	attrs := []model.CategoryAttribute{}
+	for rows.Next() {
+		var attr model.CategoryAttribute
+		var rawEnum sql.NullString
+		if err := rows.Scan(&attr.ID, &attr.CategoryID, &attr.Code, &attr.Name, &attr.DataType, &attr.Unit,
+			&rawEnum, &attr.IsRequired, &attr.IsFilterable, &attr.Position, &attr.CreatedAt, &attr.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if rawEnum.Valid && rawEnum.String != "" {
+			if err := json.Unmarshal([]byte(rawEnum.String), &attr.EnumValues); err != nil {
+				return nil, fmt.Errorf("invalid enum_values for attribute %d: %w", attr.ID, err)
+			}
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs, rows.Err()
+}
+
+// enumValuesJSON - enum_values -> JSON (nil nếu rỗng)
+func enumValuesJSON(values []string) (*string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	s := string(b)
+	return &s, nil
+}
+
+// GetEffectiveAttributes - Thông số áp dụng cho các danh mục (gồm cả thông số kế thừa từ danh mục cha)
+func (r *CategoryDb) GetEffectiveAttributes(categoryIDs []int64) ([]model.CategoryAttribute, error) {
+	if len(categoryIDs) == 0 {
+		return []model.CategoryAttribute{}, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(categoryIDs)), ",")
+	args := make([]interface{}, 0, len(categoryIDs))
+	for _, id := range categoryIDs {
+		args = append(args, id)
+	}
+
+	// Danh mục gốc xếp trước (depth lớn hơn) để thông số chung hiển thị trước
+	query := fmt.Sprintf(`
+		WITH RECURSIVE lineage AS (
+			SELECT id, parent_id, 0 AS depth FROM categories WHERE id IN (%s)
+			UNION ALL
+			SELECT c.id, c.parent_id, l.depth + 1 FROM categories c JOIN lineage l ON c.id = l.parent_id
+		)
+		SELECT a.id, a.category_id, a.code, a.name, a.data_type, a.unit, a.enum_values,
+		       a.is_required, a.is_filterable, a.position, a.created_at, a.updated_at
+		FROM category_attributes a
+		JOIN (SELECT id, MAX(depth) AS depth FROM lineage GROUP BY id) l ON l.id = a.category_id
+		ORDER BY l.depth DESC, a.position ASC, a.id ASC`, placeholders)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("GetEffectiveAttributes Failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCategoryAttributes(rows)
+}
+
+// GetCategoryAttributeByID - Lấy 1 thông số của danh mục
+func (r *CategoryDb) GetCategoryAttributeByID(categoryID, attrID int64) (*model.CategoryAttribute, error) {
+	rows, err := r.db.Query(`
+		SELECT id, category_id, code, name, data_type, unit, enum_values,
+		       is_required, is_filterable, position, created_at, updated_at
+		FROM category_attributes WHERE id = ? AND category_id = ?`, attrID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attrs, err := scanCategoryAttributes(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(attrs) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &attrs[0], nil
+}
+
+// CheckAttributeCodeInLineage - Code đã được dùng ở danh mục này, danh mục cha hoặc danh mục con chưa
+func (r *CategoryDb) CheckAttributeCodeInLineage(categoryID int64, code string) (bool, error) {
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM categories WHERE id = ?
+			UNION ALL
+			SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+		),
+		descendants AS (
+			SELECT id FROM categories WHERE id = ?
+			UNION ALL
+			SELECT c.id FROM categories c JOIN descendants d ON c.parent_id = d.id
+		)
+		SELECT EXISTS(
+			SELECT 1 FROM category_attributes
+			WHERE code = ? AND (category_id IN (SELECT id FROM ancestors) OR category_id IN (SELECT id FROM descendants))
+		)`
+
+	var exists bool
+	if err := r.db.QueryRow(query, categoryID, categoryID, code).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// CreateCategoryAttribute - Thêm thông số cho danh mục
+func (r *CategoryDb) CreateCategoryAttribute(attr *model.CategoryAttribute) (*model.CategoryAttribute, error) {
+	enumJSON, err := enumValuesJSON(attr.EnumValues)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	res, err := r.db.Exec(`
+		INSERT INTO category_attributes (category_id, code, name, data_type, unit, enum_values,
+			is_required, is_filterable, position, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		attr.CategoryID, attr.Code, attr.Name, attr.DataType, attr.Unit, enumJSON,
+		attr.IsRequired, attr.IsFilterable, attr.Position, now, now,
+	)
+	if err != nil {
+		logger.ErrorLogger.Printf("CreateCategoryAttribute Failed: %v", err)
+		return nil, fmt.Errorf("cannot create category attribute: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	attr.ID = id
+	attr.CreatedAt = now
+	attr.UpdatedAt = now
+
+	logger.InfoLogger.Printf("CreateCategoryAttribute success with ID: %d", id)
+	return attr, nil
+}
+
+// UpdateCategoryAttribute - Cập nhật thông số (ghi đè toàn bộ các trường được phép sửa)
+func (r *CategoryDb) UpdateCategoryAttribute(attr *model.CategoryAttribute) error {
+	enumJSON, err := enumValuesJSON(attr.EnumValues)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		UPDATE category_attributes
+		SET name = ?, unit = ?, enum_values = ?, is_required = ?, is_filterable = ?, position = ?, updated_at = ?
+		WHERE id = ? AND category_id = ?`,
+		attr.Name, attr.Unit, enumJSON, attr.IsRequired, attr.IsFilterable, attr.Position, time.Now(),
+		attr.ID, attr.CategoryID,
+	)
+	if err != nil {
+		logger.ErrorLogger.Printf("UpdateCategoryAttribute Failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteCategoryAttribute - Xóa thông số (giá trị của sản phẩm bị xóa theo FK CASCADE)
+func (r *CategoryDb) DeleteCategoryAttribute(categoryID, attrID int64) error {
+	res, err := r.db.Exec("DELETE FROM category_attributes WHERE id = ? AND category_id = ?", attrID, categoryID)
+	if err != nil {
+		logger.ErrorLogger.Printf("DeleteCategoryAttribute Failed: %v", err)
+		return err
+	}
+
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
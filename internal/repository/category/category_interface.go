@@ -20,4 +20,17 @@ type CategoryRepo interface {
 
 	CountProductsByCategoryID(categoryID int64) (int, error)
 	CheckSlugExist(slug string) (bool, error)
+
+	// Cây danh mục
+	GetCategoryTreeRows() ([]model.Category, error)
+	CountChildCategories(id int64) (int, error)
+	MoveCategory(id int64, parentID *int64, position int) error
+
+	// Thông số kỹ thuật theo danh mục
+	GetEffectiveAttributes(categoryIDs []int64) ([]model.CategoryAttribute, error)
+	GetCategoryAttributeByID(categoryID, attrID int64) (*model.CategoryAttribute, error)
+	CheckAttributeCodeInLineage(categoryID int64, code string) (bool, error)
+	CreateCategoryAttribute(attr *model.CategoryAttribute) (*model.CategoryAttribute, error)
+	UpdateCategoryAttribute(attr *model.CategoryAttribute) error
+	DeleteCategoryAttribute(categoryID, attrID int64) error
 }
\ No newline at end of file
@@ -0,0 +1,27 @@
+package export
+
+import (
+	"context"
+	"golang/internal/model"
+	"time"
+)
+
+type ExportRepository interface {
+	// Tạo job export (status pending), gán ID vào job
+	CreateJob(ctx context.Context, job *model.ExportJob) error
+
+	// Lấy job theo ID (trả về sql.ErrNoRows nếu không có)
+	GetJobByID(ctx context.Context, id int64) (*model.ExportJob, error)
+
+	// Cập nhật trạng thái khi job chạy / xong / lỗi
+	MarkJobRunning(ctx context.Context, id int64) error
+	MarkJobDone(ctx context.Context, id int64, filePath string, rowCount int64, expiresAt time.Time) error
+	MarkJobFailed(ctx context.Context, id int64, message string) error
+
+	// Job pending / running còn sót lại (server tắt giữa chừng) -> failed
+	FailInterruptedJobs(ctx context.Context) (int64, error)
+
+	// Job đã xong nhưng file hết hạn
+	GetExpiredJobs(ctx context.Context, now time.Time) ([]model.ExportJob, error)
+	MarkJobExpired(ctx context.Context, id int64) error
+}
@@ -0,0 +1,142 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"golang/internal/logger"
+	"golang/internal/model"
+)
+
+type exportRepository struct {
+	db *sql.DB
+}
+
+func NewExportRepository(db *sql.DB) ExportRepository {
+	return &exportRepository{db: db}
+}
+
+const exportJobColumns = `id, kind, format, filters, status, row_count, file_name, file_path, error_message,
+		requested_by, created_at, started_at, finished_at, expires_at`
+
+// rowScanner: *sql.Row hoặc *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanExportJob(row rowScanner) (*model.ExportJob, error) {
+	var j model.ExportJob
+	if err := row.Scan(
+		&j.ID, &j.Kind, &j.Format, &j.Filters, &j.Status, &j.RowCount, &j.FileName, &j.FilePath, &j.ErrorMessage,
+		&j.RequestedBy, &j.CreatedAt, &j.StartedAt, &j.FinishedAt, &j.ExpiresAt,
+	); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// Tạo job export
+func (r *exportRepository) CreateJob(ctx context.Context, job *model.ExportJob) error {
+	query := `
+		INSERT INTO export_jobs (kind, format, filters, status, file_name, requested_by)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	res, err := r.db.ExecContext(ctx, query, job.Kind, job.Format, job.Filters, model.ExportStatusPending, job.FileName, job.RequestedBy)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: CreateJob failed: %v", err)
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	job.ID = id
+	job.Status = model.ExportStatusPending
+	job.CreatedAt = time.Now()
+	return nil
+}
+
+// Lấy job theo ID
+func (r *exportRepository) GetJobByID(ctx context.Context, id int64) (*model.ExportJob, error) {
+	query := "SELECT " + exportJobColumns + " FROM export_jobs WHERE id = ?"
+	job, err := scanExportJob(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.ErrorLogger.Printf("Repo: GetJobByID failed: %v", err)
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// Đánh dấu job đang chạy
+func (r *exportRepository) MarkJobRunning(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE export_jobs SET status = ?, started_at = NOW() WHERE id = ?`,
+		model.ExportStatusRunning, id)
+	return err
+}
+
+// Đánh dấu job đã xong, lưu đường dẫn file
+func (r *exportRepository) MarkJobDone(ctx context.Context, id int64, filePath string, rowCount int64, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE export_jobs
+		SET status = ?, file_path = ?, row_count = ?, finished_at = NOW(), expires_at = ?
+		WHERE id = ?`,
+		model.ExportStatusDone, filePath, rowCount, expiresAt, id)
+	return err
+}
+
+// Đánh dấu job lỗi
+func (r *exportRepository) MarkJobFailed(ctx context.Context, id int64, message string) error {
+	if len(message) > 1000 {
+		message = message[:1000]
+	}
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE export_jobs SET status = ?, error_message = ?, finished_at = NOW() WHERE id = ?`,
+		model.ExportStatusFailed, message, id)
+	return err
+}
+
+// Job còn pending / running từ lần chạy trước -> failed
+func (r *exportRepository) FailInterruptedJobs(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE export_jobs
+		SET status = ?, error_message = 'Server khởi động lại khi đang export', finished_at = NOW()
+		WHERE status IN (?, ?)`,
+		model.ExportStatusFailed, model.ExportStatusPending, model.ExportStatusRunning)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: FailInterruptedJobs failed: %v", err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Lấy các job đã hết hạn tải
+func (r *exportRepository) GetExpiredJobs(ctx context.Context, now time.Time) ([]model.ExportJob, error) {
+	query := "SELECT " + exportJobColumns + " FROM export_jobs WHERE status = ? AND expires_at <= ?"
+	rows, err := r.db.QueryContext(ctx, query, model.ExportStatusDone, now)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: GetExpiredJobs failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []model.ExportJob{}
+	for rows.Next() {
+		job, err := scanExportJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// Đánh dấu file của job đã bị xóa
+func (r *exportRepository) MarkJobExpired(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE export_jobs SET status = ?, file_path = NULL WHERE id = ?`,
+		model.ExportStatusExpired, id)
+	return err
+}
@@ -0,0 +1,19 @@
+package invoice
+
+import (
+	"context"
+	"golang/internal/model"
+	"time"
+)
+
+// InvoiceRenderer: Render file PDF khi đã biết số hóa đơn (chạy trong transaction cấp số)
+type InvoiceRenderer func(invoiceNumber string, issuedAt time.Time) ([]byte, error)
+
+type InvoiceRepository interface {
+	// Lấy hóa đơn của đơn hàng (trả về sql.ErrNoRows nếu chưa cấp)
+	GetInvoiceByOrderID(ctx context.Context, orderID int64) (*model.Invoice, error)
+
+	// Cấp số hóa đơn tiếp theo của năm + lưu PDF trong cùng 1 transaction.
+	// Đơn đã có hóa đơn thì trả về hóa đơn cũ, render lỗi thì không tiêu tốn số
+	CreateInvoice(ctx context.Context, orderID int64, issuedAt time.Time, render InvoiceRenderer) (*model.Invoice, error)
+}
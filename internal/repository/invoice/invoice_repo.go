@@ -0,0 +1,123 @@
+package invoice
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"golang/internal/logger"
+	"golang/internal/model"
+)
+
+type invoiceRepository struct {
+	db *sql.DB
+}
+
+func NewInvoiceRepository(db *sql.DB) InvoiceRepository {
+	return &invoiceRepository{db: db}
+}
+
+const invoiceColumns = "id, order_id, invoice_number, year, sequence_number, pdf, issued_at, created_at"
+
+// queryRower: *sql.DB hoặc *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func getInvoiceByOrderID(ctx context.Context, q queryRower, orderID int64, forUpdate bool) (*model.Invoice, error) {
+	query := "SELECT " + invoiceColumns + " FROM invoices WHERE order_id = ?"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+	var inv model.Invoice
+	err := q.QueryRowContext(ctx, query, orderID).Scan(
+		&inv.ID, &inv.OrderID, &inv.InvoiceNumber, &inv.Year, &inv.SequenceNumber, &inv.PDF, &inv.IssuedAt, &inv.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// Lấy hóa đơn theo đơn hàng
+func (r *invoiceRepository) GetInvoiceByOrderID(ctx context.Context, orderID int64) (*model.Invoice, error) {
+	inv, err := getInvoiceByOrderID(ctx, r.db, orderID, false)
+	if err != nil && err != sql.ErrNoRows {
+		logger.ErrorLogger.Printf("Repo: GetInvoiceByOrderID failed: %v", err)
+	}
+	return inv, err
+}
+
+// Cấp hóa đơn: khóa dòng đơn hàng (tránh 2 request cùng cấp cho 1 đơn) rồi khóa dòng số thứ tự của năm.
+// Số chỉ được ghi nhận khi commit nên không có khoảng trống giữa các số hóa đơn
+func (r *invoiceRepository) CreateInvoice(ctx context.Context, orderID int64, issuedAt time.Time, render InvoiceRenderer) (*model.Invoice, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var lockedID int64
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM orders WHERE id = ? FOR UPDATE", orderID).Scan(&lockedID); err != nil {
+		return nil, err
+	}
+
+	// Request khác đã cấp trước
+	existing, err := getInvoiceByOrderID(ctx, tx, orderID, false)
+	if err == nil {
+		return existing, nil
+	}
+	if err != sql.ErrNoRows {
+		logger.ErrorLogger.Printf("Repo: CreateInvoice check existing failed: %v", err)
+		return nil, err
+	}
+
+	year := issuedAt.Year()
+	if _, err := tx.ExecContext(ctx, "INSERT IGNORE INTO invoice_sequences (year, last_number) VALUES (?, 0)", year); err != nil {
+		logger.ErrorLogger.Printf("Repo: CreateInvoice init sequence failed: %v", err)
+		return nil, err
+	}
+	var last int64
+	if err := tx.QueryRowContext(ctx, "SELECT last_number FROM invoice_sequences WHERE year = ? FOR UPDATE", year).Scan(&last); err != nil {
+		logger.ErrorLogger.Printf("Repo: CreateInvoice lock sequence failed: %v", err)
+		return nil, err
+	}
+
+	inv := &model.Invoice{
+		OrderID:        orderID,
+		Year:           year,
+		SequenceNumber: last + 1,
+		IssuedAt:       issuedAt,
+	}
+	inv.InvoiceNumber = fmt.Sprintf("%s-%d-%06d", model.InvoiceNumberPrefix, year, inv.SequenceNumber)
+
+	if inv.PDF, err = render(inv.InvoiceNumber, issuedAt); err != nil {
+		logger.ErrorLogger.Printf("Repo: CreateInvoice render %s failed: %v", inv.InvoiceNumber, err)
+		return nil, err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO invoices (order_id, invoice_number, year, sequence_number, pdf, issued_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		inv.OrderID, inv.InvoiceNumber, inv.Year, inv.SequenceNumber, inv.PDF, inv.IssuedAt)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: CreateInvoice insert failed: %v", err)
+		return nil, err
+	}
+	if inv.ID, err = res.LastInsertId(); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE invoice_sequences SET last_number = ? WHERE year = ?", inv.SequenceNumber, year); err != nil {
+		logger.ErrorLogger.Printf("Repo: CreateInvoice update sequence failed: %v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.ErrorLogger.Printf("Repo: CreateInvoice commit failed: %v", err)
+		return nil, err
+	}
+	inv.CreatedAt = time.Now()
+	logger.InfoLogger.Printf("Repo: Issued invoice %s for OrderID %d", inv.InvoiceNumber, orderID)
+	return inv, nil
+}
@@ -0,0 +1,20 @@
+package location
+
+import "golang/internal/model"
+
+// LocationRepository - Bộ dữ liệu đơn vị hành chính Việt Nam (nạp vào bộ nhớ khi khởi động)
+type LocationRepository interface {
+	// Danh sách tỉnh / thành phố
+	GetProvinces() []model.AdministrativeUnit
+
+	// Danh sách quận / huyện của tỉnh (chỉ có với file dữ liệu cũ 3 cấp; nil nếu không có dữ liệu)
+	GetDistricts(provinceCode string) []model.AdministrativeUnit
+
+	// Danh sách phường / xã theo mã cấp trên: mã tỉnh (cấu trúc 2 cấp hiện hành) hoặc mã quận / huyện (file cũ)
+	GetWards(parentCode string) []model.AdministrativeUnit
+
+	// Tìm theo mã, trả về nil nếu không tồn tại
+	GetProvinceByCode(code string) *model.AdministrativeUnit
+	GetDistrictByCode(code string) *model.AdministrativeUnit
+	GetWardByCode(code string) *model.AdministrativeUnit
+}
@@ -0,0 +1,179 @@
+package location
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"golang/internal/logger"
+	"golang/internal/model"
+)
+
+// Bộ dữ liệu đi kèm theo cấu trúc 2 cấp hiện hành (từ 01/07/2025): đủ 34 tỉnh / thành, chưa kèm phường / xã.
+// Production (APP_ENV=production) bắt buộc trỏ VN_ADDRESS_DATA_FILE tới file đầy đủ có phường / xã.
+//
+//go:embed data/vn_divisions.json
+var embeddedDivisions []byte
+
+// Định dạng file JSON: tỉnh -> wards (hiện hành); file cũ 3 cấp tỉnh -> districts -> wards vẫn đọc được
+type rawWard struct {
+	Code         string `json:"code"`
+	Name         string `json:"name"`
+	DivisionType string `json:"division_type"`
+}
+
+type rawDistrict struct {
+	Code         string    `json:"code"`
+	Name         string    `json:"name"`
+	DivisionType string    `json:"division_type"`
+	Wards        []rawWard `json:"wards"`
+}
+
+type rawProvince struct {
+	Code         string        `json:"code"`
+	Name         string        `json:"name"`
+	DivisionType string        `json:"division_type"`
+	Districts    []rawDistrict `json:"districts"` // Chỉ có ở file cũ (trước sáp nhập 07/2025)
+	Wards        []rawWard     `json:"wards"`
+}
+
+type locationRepository struct {
+	provinces           []model.AdministrativeUnit
+	districtsByProvince map[string][]model.AdministrativeUnit
+	wardsByParent       map[string][]model.AdministrativeUnit // Khóa: mã quận / huyện (file cũ) hoặc mã tỉnh (hiện hành)
+
+	provinceByCode map[string]*model.AdministrativeUnit
+	districtByCode map[string]*model.AdministrativeUnit
+	wardByCode     map[string]*model.AdministrativeUnit
+}
+
+// NewLocationRepository - Nạp bộ dữ liệu hành chính (file VN_ADDRESS_DATA_FILE nếu có, ngược lại dùng file nhúng).
+// Ở production không có / không đọc được file thì trả lỗi thay vì lặng lẽ dùng bản mẫu.
+func NewLocationRepository() (LocationRepository, error) {
+	production := os.Getenv("APP_ENV") == "production"
+	data := embeddedDivisions
+	source := "embedded"
+	path := os.Getenv("VN_ADDRESS_DATA_FILE")
+	if path == "" && production {
+		return nil, fmt.Errorf("VN_ADDRESS_DATA_FILE is required when APP_ENV=production (embedded dataset is incomplete)")
+	}
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil && production {
+			return nil, fmt.Errorf("read VN_ADDRESS_DATA_FILE %s: %w", path, err)
+		}
+		if err != nil {
+			logger.ErrorLogger.Printf("Repo: Cannot read VN_ADDRESS_DATA_FILE %s, fallback to embedded dataset: %v", path, err)
+		} else {
+			data = content
+			source = path
+		}
+	}
+
+	repo, err := buildLocationRepository(data)
+	if err != nil {
+		return nil, fmt.Errorf("load vn divisions (%s): %w", source, err)
+	}
+	logger.InfoLogger.Printf("Loaded %d provinces, %d districts, %d wards from %s",
+		len(repo.provinceByCode), len(repo.districtByCode), len(repo.wardByCode), source)
+	return repo, nil
+}
+
+func buildLocationRepository(data []byte) (*locationRepository, error) {
+	var raw []rawProvince
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("dataset is empty")
+	}
+
+	r := &locationRepository{
+		districtsByProvince: make(map[string][]model.AdministrativeUnit),
+		wardsByParent:       make(map[string][]model.AdministrativeUnit),
+		provinceByCode:      make(map[string]*model.AdministrativeUnit),
+		districtByCode:      make(map[string]*model.AdministrativeUnit),
+		wardByCode:          make(map[string]*model.AdministrativeUnit),
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range raw {
+		if seen["p"+p.Code] {
+			return nil, fmt.Errorf("duplicate province code %s", p.Code)
+		}
+		seen["p"+p.Code] = true
+		r.provinces = append(r.provinces, model.AdministrativeUnit{
+			Code: p.Code, Name: p.Name, DivisionType: p.DivisionType, HasChildren: len(p.Districts) > 0 || len(p.Wards) > 0,
+		})
+		if err := r.addWards(p.Code, p.Wards, seen); err != nil {
+			return nil, err
+		}
+
+		for _, d := range p.Districts {
+			if seen["d"+d.Code] {
+				return nil, fmt.Errorf("duplicate district code %s", d.Code)
+			}
+			seen["d"+d.Code] = true
+			r.districtsByProvince[p.Code] = append(r.districtsByProvince[p.Code], model.AdministrativeUnit{
+				Code: d.Code, Name: d.Name, DivisionType: d.DivisionType, ParentCode: p.Code, HasChildren: len(d.Wards) > 0,
+			})
+			if err := r.addWards(d.Code, d.Wards, seen); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Index theo mã sau khi các slice đã ổn định (append có thể cấp phát lại mảng)
+	for i := range r.provinces {
+		r.provinceByCode[r.provinces[i].Code] = &r.provinces[i]
+	}
+	for _, districts := range r.districtsByProvince {
+		for i := range districts {
+			r.districtByCode[districts[i].Code] = &districts[i]
+		}
+	}
+	for _, wards := range r.wardsByParent {
+		for i := range wards {
+			r.wardByCode[wards[i].Code] = &wards[i]
+		}
+	}
+	return r, nil
+}
+
+func (r *locationRepository) addWards(parentCode string, wards []rawWard, seen map[string]bool) error {
+	for _, w := range wards {
+		if seen["w"+w.Code] {
+			return fmt.Errorf("duplicate ward code %s", w.Code)
+		}
+		seen["w"+w.Code] = true
+		r.wardsByParent[parentCode] = append(r.wardsByParent[parentCode], model.AdministrativeUnit{
+			Code: w.Code, Name: w.Name, DivisionType: w.DivisionType, ParentCode: parentCode,
+		})
+	}
+	return nil
+}
+
+func (r *locationRepository) GetProvinces() []model.AdministrativeUnit {
+	return r.provinces
+}
+
+func (r *locationRepository) GetDistricts(provinceCode string) []model.AdministrativeUnit {
+	return r.districtsByProvince[provinceCode]
+}
+
+func (r *locationRepository) GetWards(parentCode string) []model.AdministrativeUnit {
+	return r.wardsByParent[parentCode]
+}
+
+func (r *locationRepository) GetProvinceByCode(code string) *model.AdministrativeUnit {
+	return r.provinceByCode[code]
+}
+
+func (r *locationRepository) GetDistrictByCode(code string) *model.AdministrativeUnit {
+	return r.districtByCode[code]
+}
+
+func (r *locationRepository) GetWardByCode(code string) *model.AdministrativeUnit {
+	return r.wardByCode[code]
+}
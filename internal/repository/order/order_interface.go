@@ -7,8 +7,8 @@ import (
 
 type IOrderRepository interface {
 
-	// Tạo đơn hàng 
-	CreateOrder(ctx context.Context, order *model.Order, items []model.OrderItem, address *model.OrderAddress, initialPayment *model.OrderPayment) error
+	// Tạo đơn hàng (kèm dòng phí giao hàng)
+	CreateOrder(ctx context.Context, order *model.Order, items []model.OrderItem, address *model.OrderAddress, shipping *model.OrderShippingLine, initialPayment *model.OrderPayment) error
 
 	//  Cập nhật trạng thái đơn hàng.
 	UpdateOrderStatus(ctx context.Context, orderID int64, newStatus string, note string, changedBy *int64) error
@@ -25,12 +25,19 @@ type IOrderRepository interface {
 	// GetOrders: Lấy danh sách đơn hàng có phân trang & lọc 
 	GetOrders(ctx context.Context, filter model.OrderFilter) ([]model.Order, int, error)
 
+	// Đếm / duyệt từng dòng sản phẩm của các đơn khớp bộ lọc (dùng cho export)
+	CountOrderExportLines(ctx context.Context, filter model.OrderFilter) (int64, error)
+	EachOrderExportLine(ctx context.Context, filter model.OrderFilter, fn func(model.OrderExportLine) error) error
+
 	// Lấy danh sách sản phẩm trong đơn.
 	GetOrderItems(ctx context.Context, orderID int64) ([]model.OrderItem, error)
 
 	// Lấy địa chỉ giao hàng 
 	GetOrderAddress(ctx context.Context, orderID int64) (*model.OrderAddress, error)
 
+	// Lấy dòng phí giao hàng (nil nếu đơn cũ chưa có)
+	GetOrderShippingLine(ctx context.Context, orderID int64) (*model.OrderShippingLine, error)
+
 	// Lấy lịch sử giao dịch thanh toán
 	GetOrderPayments(ctx context.Context, orderID int64) ([]model.OrderPayment, error)
 
@@ -39,4 +46,7 @@ type IOrderRepository interface {
 	
 	// Kiểm tra người dùng đã mua sản phẩm chưa
 	HasUserPurchasedProduct(ctx context.Context, userID int64, productID int64) (bool, error)
+
+	// Lấy dòng đơn hàng (đơn đã hoàn thành) gần nhất của user cho sản phẩm, nil nếu chưa mua
+	GetCompletedOrderItemForProduct(ctx context.Context, userID int64, productID int64) (*int64, error)
 }
\ No newline at end of file
@@ -19,7 +19,7 @@ func NewOrderRepository(db *sql.DB) IOrderRepository {
 }
 
 // CreateOrder: Tạo đơn hàng
-func (r *OrderRepository) CreateOrder(ctx context.Context, order *model.Order, items []model.OrderItem, address *model.OrderAddress, initialPayment *model.OrderPayment) error {
+func (r *OrderRepository) CreateOrder(ctx context.Context, order *model.Order, items []model.OrderItem, address *model.OrderAddress, shipping *model.OrderShippingLine, initialPayment *model.OrderPayment) error {
 	logger.DebugLogger.Printf("Starting CreateOrder for UserID: %d, TotalAmount: %.2f", order.UserID, order.TotalAmount)
 	// Bắt đầu Transaction
 	tx, err := r.db.BeginTx(ctx, nil)
@@ -32,11 +32,11 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order *model.Order, i
 
 	//  Insert vào bảng ORDERS
 	queryOrder := `
-		INSERT INTO orders (order_number, user_id, status, total_amount, payment_status, note, placed_at) 
-		VALUES (?, ?, ?, ?, ?, ?, ?)`
+		INSERT INTO orders (order_number, user_id, status, subtotal_amount, shipping_fee, total_amount, payment_status, note, placed_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	res, err := tx.ExecContext(ctx, queryOrder,
-		order.OrderNumber, order.UserID, order.Status, order.TotalAmount,
+		order.OrderNumber, order.UserID, order.Status, order.SubtotalAmount, order.ShippingFee, order.TotalAmount,
 		order.PaymentStatus, order.Note, order.PlacedAt,
 	)
 	if err != nil {
@@ -54,8 +54,8 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order *model.Order, i
 
 	//  Insert vào bảng ORDER_ITEMS
 	queryItem := `
-		INSERT INTO order_items (order_id, product_id, variant_id, sku, title, option_values, unit_price, quantity, line_subtotal)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		INSERT INTO order_items (order_id, product_id, variant_id, sku, title, option_values, unit_price, unit_cost, quantity, line_subtotal)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	stmtItem, err := tx.PrepareContext(ctx, queryItem) // Prepare statement cho tối ưu vì loop nhiều lần
 
@@ -68,7 +68,7 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order *model.Order, i
 	for _, item := range items {
 		_, err := stmtItem.ExecContext(ctx,
 			orderID, item.ProductID, item.VariantID, item.SKU, item.Title,
-			item.OptionValues, item.UnitPrice, item.Quantity, item.LineSubtotal,
+			item.OptionValues, item.UnitPrice, item.UnitCost, item.Quantity, item.LineSubtotal,
 		)
 		if err != nil {
 			logger.ErrorLogger.Printf("CreateOrder: Insert Item (ProductID: %d) failed: %v", item.ProductID, err)
@@ -78,18 +78,36 @@ func (r *OrderRepository) CreateOrder(ctx context.Context, order *model.Order, i
 
 	//  Insert vào bảng ORDER_ADDRESSES 
 	queryAddress := `
-		INSERT INTO order_addresses (order_id, type, recipient_name, phone, line1, line2, city, state, country)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		INSERT INTO order_addresses (order_id, type, recipient_name, phone, line1, line2, city, state, ward, country,
+			province_code, district_code, ward_code)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''))`
 
 	_, err = tx.ExecContext(ctx, queryAddress,
 		orderID, address.Type, address.RecipientName, address.Phone,
-		address.Line1, address.Line2, address.City, address.State, address.Country,
+		address.Line1, address.Line2, address.City, address.State, address.Ward, address.Country,
+		address.ProvinceCode, address.DistrictCode, address.WardCode,
 	)
 	if err != nil {
 		logger.ErrorLogger.Printf("CreateOrder: Insert Address failed: %v", err)
 		return fmt.Errorf("failed to insert order address: %v", err)
 	}
 
+	// Insert vào bảng ORDER_SHIPPING_LINES (snapshot phương thức + phí giao hàng)
+	if shipping != nil {
+		queryShipping := `
+			INSERT INTO order_shipping_lines (order_id, zone_id, zone_name, method_code, method_name, rate_type, total_weight_grams, fee)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+		_, err = tx.ExecContext(ctx, queryShipping,
+			orderID, shipping.ZoneID, shipping.ZoneName, shipping.MethodCode, shipping.MethodName,
+			shipping.RateType, shipping.TotalWeightGrams, shipping.Fee,
+		)
+		if err != nil {
+			logger.ErrorLogger.Printf("CreateOrder: Insert Shipping line failed: %v", err)
+			return fmt.Errorf("failed to insert order shipping line: %v", err)
+		}
+		shipping.OrderID = orderID
+	}
+
 	// Insert vào bảng ORDER_PAYMENTS
 	if initialPayment != nil {
 		queryPayment := `
@@ -238,13 +256,13 @@ func (r *OrderRepository) ConfirmPayment(ctx context.Context, orderID int64, pay
 func (r *OrderRepository) GetOrderByID(ctx context.Context, id int64) (*model.Order, error) {
 	logger.DebugLogger.Printf("Starting GetOrderByID: %d", id)
 	query := `
-		SELECT id, order_number, user_id, status, total_amount, payment_status, note, 
+		SELECT id, order_number, user_id, status, subtotal_amount, shipping_fee, total_amount, payment_status, note, 
 		       placed_at, created_at, updated_at, paid_at, completed_at, cancelled_at
 		FROM orders WHERE id = ?`
 
 	var o model.Order
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&o.ID, &o.OrderNumber, &o.UserID, &o.Status, &o.TotalAmount, &o.PaymentStatus, &o.Note,
+		&o.ID, &o.OrderNumber, &o.UserID, &o.Status, &o.SubtotalAmount, &o.ShippingFee, &o.TotalAmount, &o.PaymentStatus, &o.Note,
 		&o.PlacedAt, &o.CreatedAt, &o.UpdatedAt,
 		&o.PaidAt, &o.CompletedAt, &o.CancelledAt,
 	)
@@ -264,13 +282,13 @@ func (r *OrderRepository) GetOrderByID(ctx context.Context, id int64) (*model.Or
 func (r *OrderRepository) GetByOrderNumber(ctx context.Context, orderNumber string) (*model.Order, error) {
 	logger.DebugLogger.Printf("Starting GetByOrderNumber: %s", orderNumber)
 	query := `
-		SELECT id, order_number, user_id, status, total_amount, payment_status, note, 
+		SELECT id, order_number, user_id, status, subtotal_amount, shipping_fee, total_amount, payment_status, note, 
 		       placed_at, created_at, updated_at, paid_at, completed_at, cancelled_at
 		FROM orders WHERE order_number = ?`
 
 	var o model.Order
 	err := r.db.QueryRowContext(ctx, query, orderNumber).Scan(
-		&o.ID, &o.OrderNumber, &o.UserID, &o.Status, &o.TotalAmount, &o.PaymentStatus, &o.Note,
+		&o.ID, &o.OrderNumber, &o.UserID, &o.Status, &o.SubtotalAmount, &o.ShippingFee, &o.TotalAmount, &o.PaymentStatus, &o.Note,
 		&o.PlacedAt, &o.CreatedAt, &o.UpdatedAt,
 		&o.PaidAt, &o.CompletedAt, &o.CancelledAt,
 	)
@@ -286,10 +304,8 @@ func (r *OrderRepository) GetByOrderNumber(ctx context.Context, orderNumber stri
 	return &o, nil
 }
 
-//  Lọc và Phân trang 
-func (r *OrderRepository) GetOrders(ctx context.Context, filter model.OrderFilter) ([]model.Order, int, error) {
-	logger.DebugLogger.Printf("Starting GetOrders with Filter: %+v", filter)
-	
+// orderFilterWhere: Điều kiện WHERE (trên bảng orders) dùng chung cho danh sách và export đơn hàng
+func orderFilterWhere(filter model.OrderFilter) (string, []interface{}) {
 	whereClauses := []string{"1=1"}
 	args := []interface{}{}
 
@@ -334,7 +350,14 @@ func (r *OrderRepository) GetOrders(ctx context.Context, filter model.OrderFilte
 		args = append(args, kw, kw) 
 	}
 
-	whereQuery := strings.Join(whereClauses, " AND ")
+	return strings.Join(whereClauses, " AND "), args
+}
+
+//  Lọc và Phân trang 
+func (r *OrderRepository) GetOrders(ctx context.Context, filter model.OrderFilter) ([]model.Order, int, error) {
+	logger.DebugLogger.Printf("Starting GetOrders with Filter: %+v", filter)
+	
+	whereQuery, args := orderFilterWhere(filter)
 
 	//  Đếm tổng số lượng cho phân trang
 	countQuery := "SELECT COUNT(*) FROM orders WHERE " + whereQuery
@@ -350,7 +373,7 @@ func (r *OrderRepository) GetOrders(ctx context.Context, filter model.OrderFilte
 	offset := (filter.Page - 1) * limit
 
 	dataQuery := fmt.Sprintf(`
-		SELECT id, order_number, user_id, status, total_amount, payment_status, 
+		SELECT id, order_number, user_id, status, subtotal_amount, shipping_fee, total_amount, payment_status, 
 		       placed_at, created_at, paid_at, completed_at, cancelled_at
 		FROM orders
 		WHERE %s 
@@ -370,7 +393,7 @@ func (r *OrderRepository) GetOrders(ctx context.Context, filter model.OrderFilte
 	var orders []model.Order
 	for rows.Next() {
 		var o model.Order
-		if err := rows.Scan(&o.ID, &o.OrderNumber, &o.UserID, &o.Status, &o.TotalAmount, &o.PaymentStatus, &o.PlacedAt, &o.CreatedAt,&o.PaidAt, &o.CompletedAt, &o.CancelledAt,); err != nil {
+		if err := rows.Scan(&o.ID, &o.OrderNumber, &o.UserID, &o.Status, &o.SubtotalAmount, &o.ShippingFee, &o.TotalAmount, &o.PaymentStatus, &o.PlacedAt, &o.CreatedAt,&o.PaidAt, &o.CompletedAt, &o.CancelledAt,); err != nil {
 			logger.ErrorLogger.Printf("GetOrders: Scan row failed: %v", err)
 			return nil, 0, err
 		}
@@ -380,11 +403,69 @@ func (r *OrderRepository) GetOrders(ctx context.Context, filter model.OrderFilte
 	return orders, total, nil
 }
 
+// CountOrderExportLines: Đếm số dòng sản phẩm sẽ export theo bộ lọc
+func (r *OrderRepository) CountOrderExportLines(ctx context.Context, filter model.OrderFilter) (int64, error) {
+	whereQuery, args := orderFilterWhere(filter)
+	query := "SELECT COUNT(*) FROM order_items WHERE order_id IN (SELECT id FROM orders WHERE " + whereQuery + ")"
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		logger.ErrorLogger.Printf("CountOrderExportLines failed: %v", err)
+		return 0, err
+	}
+	return total, nil
+}
+
+// EachOrderExportLine: Duyệt lần lượt từng dòng sản phẩm của các đơn khớp bộ lọc (không phân trang, không giữ hết trong bộ nhớ).
+// Tiền hàng của đơn cũ chưa tách phí giao hàng lấy theo total_amount (giống orderSubtotal bên controller order)
+func (r *OrderRepository) EachOrderExportLine(ctx context.Context, filter model.OrderFilter, fn func(model.OrderExportLine) error) error {
+	logger.DebugLogger.Printf("Starting EachOrderExportLine with Filter: %+v", filter)
+	whereQuery, args := orderFilterWhere(filter)
+
+	query := fmt.Sprintf(`
+		SELECT o.id, o.order_number, o.placed_at, o.status, o.payment_status, o.user_id,
+		       COALESCE(u.username, ''), COALESCE(u.email, ''),
+		       COALESCE(a.recipient_name, ''), COALESCE(a.phone, ''),
+		       CONCAT_WS(', ', a.line1, NULLIF(a.line2, ''), a.ward, a.state, a.city),
+		       IF(o.subtotal_amount = 0 AND o.shipping_fee = 0, o.total_amount, o.subtotal_amount),
+		       o.shipping_fee, o.total_amount, o.paid_at, o.completed_at,
+		       COALESCE(oi.sku, ''), COALESCE(oi.title, ''), oi.option_values, oi.unit_price, oi.quantity, oi.line_subtotal
+		FROM (SELECT * FROM orders WHERE %s) o
+		JOIN order_items oi ON oi.order_id = o.id
+		LEFT JOIN users u ON u.id = o.user_id
+		LEFT JOIN order_addresses a ON a.order_id = o.id AND a.type = 'shipping'
+		ORDER BY o.placed_at DESC, o.id DESC, oi.id`, whereQuery)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("EachOrderExportLine: Query failed: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l model.OrderExportLine
+		if err := rows.Scan(
+			&l.OrderID, &l.OrderNumber, &l.PlacedAt, &l.Status, &l.PaymentStatus, &l.UserID,
+			&l.Username, &l.Email, &l.RecipientName, &l.Phone, &l.ShippingAddress,
+			&l.SubtotalAmount, &l.ShippingFee, &l.TotalAmount, &l.PaidAt, &l.CompletedAt,
+			&l.SKU, &l.Title, &l.OptionValues, &l.UnitPrice, &l.Quantity, &l.LineSubtotal,
+		); err != nil {
+			logger.ErrorLogger.Printf("EachOrderExportLine: Scan row failed: %v", err)
+			return err
+		}
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // Lấy thông tin sản phẩm trong đơn hàng
 func (r *OrderRepository) GetOrderItems(ctx context.Context, orderID int64) ([]model.OrderItem, error) {
 	logger.DebugLogger.Printf("Starting GetOrderItems for OrderID: %d", orderID)
 	query := `
-		SELECT id, order_id, product_id, variant_id, sku, title, option_values, unit_price, quantity, line_subtotal
+		SELECT id, order_id, product_id, variant_id, sku, title, option_values, unit_price, unit_cost, quantity, returned_quantity, line_subtotal
 		FROM order_items WHERE order_id = ?`
 
 	rows, err := r.db.QueryContext(ctx, query, orderID)
@@ -397,7 +478,7 @@ func (r *OrderRepository) GetOrderItems(ctx context.Context, orderID int64) ([]m
 	var items []model.OrderItem
 	for rows.Next() {
 		var i model.OrderItem
-		if err := rows.Scan(&i.ID, &i.OrderID, &i.ProductID, &i.VariantID, &i.SKU, &i.Title, &i.OptionValues, &i.UnitPrice, &i.Quantity, &i.LineSubtotal); err != nil {
+		if err := rows.Scan(&i.ID, &i.OrderID, &i.ProductID, &i.VariantID, &i.SKU, &i.Title, &i.OptionValues, &i.UnitPrice, &i.UnitCost, &i.Quantity, &i.ReturnedQuantity, &i.LineSubtotal); err != nil {
 			logger.ErrorLogger.Printf("GetOrderItems Scan failed: %v", err)
 			return nil, err
 		}
@@ -411,13 +492,15 @@ func (r *OrderRepository) GetOrderAddress(ctx context.Context, orderID int64) (*
 	logger.DebugLogger.Printf("Starting GetOrderAddress for OrderID: %d", orderID)
 	query := `
 		SELECT id, order_id, type, recipient_name, phone, line1, 
-		       COALESCE(line2, ''), city, COALESCE(state, ''), country
+		       COALESCE(line2, ''), city, COALESCE(state, ''), COALESCE(ward, ''), country,
+		       COALESCE(province_code, ''), COALESCE(district_code, ''), COALESCE(ward_code, '')
 		FROM order_addresses WHERE order_id = ?`
 
 	var a model.OrderAddress
 	err := r.db.QueryRowContext(ctx, query, orderID).Scan(
 		&a.ID, &a.OrderID, &a.Type, &a.RecipientName, &a.Phone,
-		&a.Line1, &a.Line2, &a.City, &a.State, &a.Country,
+		&a.Line1, &a.Line2, &a.City, &a.State, &a.Ward, &a.Country,
+		&a.ProvinceCode, &a.DistrictCode, &a.WardCode,
 	)
 	if err == sql.ErrNoRows {
 		logger.WarnLogger.Printf("GetOrderAddress: No address found for OrderID: %d", orderID)
@@ -430,6 +513,28 @@ func (r *OrderRepository) GetOrderAddress(ctx context.Context, orderID int64) (*
 	return &a, nil
 }
 
+// Lấy dòng phí giao hàng của đơn
+func (r *OrderRepository) GetOrderShippingLine(ctx context.Context, orderID int64) (*model.OrderShippingLine, error) {
+	logger.DebugLogger.Printf("Starting GetOrderShippingLine for OrderID: %d", orderID)
+	query := `
+		SELECT id, order_id, zone_id, zone_name, method_code, method_name, rate_type, total_weight_grams, fee, created_at
+		FROM order_shipping_lines WHERE order_id = ?`
+
+	var l model.OrderShippingLine
+	err := r.db.QueryRowContext(ctx, query, orderID).Scan(
+		&l.ID, &l.OrderID, &l.ZoneID, &l.ZoneName, &l.MethodCode, &l.MethodName,
+		&l.RateType, &l.TotalWeightGrams, &l.Fee, &l.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		logger.ErrorLogger.Printf("GetOrderShippingLine failed: %v", err)
+		return nil, err
+	}
+	return &l, nil
+}
+
 // Lấy dữ liệu log lịch sử trạng thái thanh toán
 func (r *OrderRepository) GetOrderPayments(ctx context.Context, orderID int64) ([]model.OrderPayment, error) {
 	logger.DebugLogger.Printf("Starting GetOrderPayments for OrderID: %d", orderID)
@@ -503,4 +608,28 @@ func (r *OrderRepository) HasUserPurchasedProduct(ctx context.Context, userID in
 	}
 
 	return true, nil // Đã mua và đơn đã hoàn thành
-}
\ No newline at end of file
+}
+
+// GetCompletedOrderItemForProduct: Lấy order_item gần nhất thuộc đơn đã hoàn thành của user (dùng cho verified purchase)
+func (r *OrderRepository) GetCompletedOrderItemForProduct(ctx context.Context, userID int64, productID int64) (*int64, error) {
+	query := `
+		SELECT oi.id
+		FROM orders o
+		JOIN order_items oi ON o.id = oi.order_id
+		WHERE o.user_id = ?
+		AND oi.product_id = ?
+		AND o.status = 'completed'
+		ORDER BY o.completed_at DESC, oi.id DESC
+		LIMIT 1`
+
+	var itemID int64
+	err := r.db.QueryRowContext(ctx, query, userID, productID).Scan(&itemID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		logger.ErrorLogger.Printf("GetCompletedOrderItemForProduct error: %v", err)
+		return nil, err
+	}
+	return &itemID, nil
+}
@@ -0,0 +1,24 @@
+package ordermessage
+
+import (
+	"context"
+	"golang/internal/model"
+)
+
+type OrderMessageRepository interface {
+	// Lưu tin nhắn kèm file đính kèm (1 transaction), gán ID + CreatedAt
+	CreateMessage(ctx context.Context, msg *model.OrderMessage) error
+
+	// Tin nhắn của đơn theo thứ tự thời gian (includeInternal = false -> chỉ tin public), kèm file đính kèm
+	GetMessages(ctx context.Context, orderID int64, includeInternal bool) ([]model.OrderMessage, error)
+
+	// File đính kèm thuộc đơn hàng (trả về sql.ErrNoRows nếu không có) + phạm vi hiển thị của tin nhắn chứa file
+	GetAttachment(ctx context.Context, orderID int64, attachmentID int64) (*model.OrderMessageAttachment, string, error)
+
+	// Đánh dấu phía reader (customer | staff) đã đọc đến tin lastMessageID
+	MarkRead(ctx context.Context, orderID int64, reader string, lastMessageID int64) error
+
+	// Đơn có tin chưa đọc: khách (tin public của nhân viên, chỉ đơn của userID) / nhân viên (tin của khách)
+	GetCustomerUnread(ctx context.Context, userID int64) ([]model.OrderUnreadMessages, error)
+	GetStaffUnread(ctx context.Context) ([]model.OrderUnreadMessages, error)
+}
@@ -0,0 +1,205 @@
+package ordermessage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"golang/internal/logger"
+	"golang/internal/model"
+)
+
+type orderMessageRepository struct {
+	db *sql.DB
+}
+
+func NewOrderMessageRepository(db *sql.DB) OrderMessageRepository {
+	return &orderMessageRepository{db: db}
+}
+
+// Lưu tin nhắn + file đính kèm
+func (r *orderMessageRepository) CreateMessage(ctx context.Context, msg *model.OrderMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO order_messages (order_id, author_id, author_role, visibility, body)
+		VALUES (?, ?, ?, ?, ?)`,
+		msg.OrderID, msg.AuthorID, msg.AuthorRole, msg.Visibility, msg.Body)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: CreateMessage insert message failed: %v", err)
+		return err
+	}
+	if msg.ID, err = res.LastInsertId(); err != nil {
+		return err
+	}
+
+	for i := range msg.Attachments {
+		a := &msg.Attachments[i]
+		a.MessageID = msg.ID
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO order_message_attachments (message_id, file_name, content_type, size_bytes, storage_key)
+			VALUES (?, ?, ?, ?, ?)`,
+			a.MessageID, a.FileName, a.ContentType, a.SizeBytes, a.StorageKey)
+		if err != nil {
+			logger.ErrorLogger.Printf("Repo: CreateMessage insert attachment failed: %v", err)
+			return err
+		}
+		if a.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.ErrorLogger.Printf("Repo: CreateMessage commit failed: %v", err)
+		return err
+	}
+	now := time.Now()
+	msg.CreatedAt = now
+	for i := range msg.Attachments {
+		msg.Attachments[i].CreatedAt = now
+	}
+	return nil
+}
+
+// Tin nhắn của đơn hàng
+func (r *orderMessageRepository) GetMessages(ctx context.Context, orderID int64, includeInternal bool) ([]model.OrderMessage, error) {
+	query := `
+		SELECT m.id, m.order_id, m.author_id, m.author_role, COALESCE(u.username, ''), m.visibility, m.body, m.created_at
+		FROM order_messages m
+		LEFT JOIN users u ON u.id = m.author_id
+		WHERE m.order_id = ?`
+	args := []interface{}{orderID}
+	if !includeInternal {
+		query += " AND m.visibility = ?"
+		args = append(args, model.OrderMessagePublic)
+	}
+	query += " ORDER BY m.id ASC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: GetMessages failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []model.OrderMessage{}
+	index := map[int64]int{}
+	for rows.Next() {
+		var m model.OrderMessage
+		if err := rows.Scan(&m.ID, &m.OrderID, &m.AuthorID, &m.AuthorRole, &m.AuthorName, &m.Visibility, &m.Body, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		m.Attachments = []model.OrderMessageAttachment{}
+		index[m.ID] = len(messages)
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return messages, nil
+	}
+
+	// File đính kèm của các tin nhắn trên
+	ids := make([]interface{}, 0, len(messages))
+	for _, m := range messages {
+		ids = append(ids, m.ID)
+	}
+	attRows, err := r.db.QueryContext(ctx, `
+		SELECT id, message_id, file_name, content_type, size_bytes, storage_key, created_at
+		FROM order_message_attachments
+		WHERE message_id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+		ORDER BY id ASC`, ids...)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: GetMessages attachments failed: %v", err)
+		return nil, err
+	}
+	defer attRows.Close()
+	for attRows.Next() {
+		var a model.OrderMessageAttachment
+		if err := attRows.Scan(&a.ID, &a.MessageID, &a.FileName, &a.ContentType, &a.SizeBytes, &a.StorageKey, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		m := &messages[index[a.MessageID]]
+		m.Attachments = append(m.Attachments, a)
+	}
+	return messages, attRows.Err()
+}
+
+// File đính kèm thuộc đơn hàng
+func (r *orderMessageRepository) GetAttachment(ctx context.Context, orderID int64, attachmentID int64) (*model.OrderMessageAttachment, string, error) {
+	var a model.OrderMessageAttachment
+	var visibility string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT a.id, a.message_id, a.file_name, a.content_type, a.size_bytes, a.storage_key, a.created_at, m.visibility
+		FROM order_message_attachments a
+		JOIN order_messages m ON m.id = a.message_id
+		WHERE a.id = ? AND m.order_id = ?`, attachmentID, orderID,
+	).Scan(&a.ID, &a.MessageID, &a.FileName, &a.ContentType, &a.SizeBytes, &a.StorageKey, &a.CreatedAt, &visibility)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.ErrorLogger.Printf("Repo: GetAttachment failed: %v", err)
+		}
+		return nil, "", err
+	}
+	return &a, visibility, nil
+}
+
+// Đánh dấu đã đọc (không bao giờ lùi lại)
+func (r *orderMessageRepository) MarkRead(ctx context.Context, orderID int64, reader string, lastMessageID int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO order_message_reads (order_id, reader, last_read_message_id)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE last_read_message_id = GREATEST(last_read_message_id, VALUES(last_read_message_id))`,
+		orderID, reader, lastMessageID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: MarkRead failed: %v", err)
+	}
+	return err
+}
+
+// unreadQuery: Đếm tin của phía bên kia có id lớn hơn tin cuối cùng reader đã đọc
+const unreadQuery = `
+	SELECT o.id, o.order_number, COUNT(m.id), MAX(m.created_at)
+	FROM order_messages m
+	JOIN orders o ON o.id = m.order_id
+	LEFT JOIN order_message_reads rd ON rd.order_id = m.order_id AND rd.reader = ?
+	WHERE m.author_role = ? AND m.visibility = ? AND m.id > COALESCE(rd.last_read_message_id, 0)`
+
+func (r *orderMessageRepository) queryUnread(ctx context.Context, query string, args ...interface{}) ([]model.OrderUnreadMessages, error) {
+	rows, err := r.db.QueryContext(ctx, query+`
+	GROUP BY o.id, o.order_number
+	ORDER BY MAX(m.id) DESC`, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: queryUnread failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []model.OrderUnreadMessages{}
+	for rows.Next() {
+		var u model.OrderUnreadMessages
+		if err := rows.Scan(&u.OrderID, &u.OrderNumber, &u.Unread, &u.LastMessageAt); err != nil {
+			return nil, err
+		}
+		result = append(result, u)
+	}
+	return result, rows.Err()
+}
+
+// Khách: tin public của nhân viên trong các đơn của mình
+func (r *orderMessageRepository) GetCustomerUnread(ctx context.Context, userID int64) ([]model.OrderUnreadMessages, error) {
+	return r.queryUnread(ctx, unreadQuery+" AND o.user_id = ?",
+		model.OrderMessageAuthorCustomer, model.OrderMessageAuthorStaff, model.OrderMessagePublic, userID)
+}
+
+// Nhân viên: tin của khách (ghi chú nội bộ do nhân viên viết nên không tính)
+func (r *orderMessageRepository) GetStaffUnread(ctx context.Context) ([]model.OrderUnreadMessages, error) {
+	return r.queryUnread(ctx, unreadQuery,
+		model.OrderMessageAuthorStaff, model.OrderMessageAuthorCustomer, model.OrderMessagePublic)
+}
@@ -7,8 +7,9 @@ import (
 // ProductRepository - Interface định nghĩa các phương thức
 type ProductRepository interface {
 	// Create & Update
-	CreateProduct(product *model.Product, categoryIDs []int64) (*model.Product, error)
-	UpdateProduct(product *model.Product, categoryIDs []int64) (*model.Product, error)
+	// Danh mục và thông số kỹ thuật ghi cùng transaction với sản phẩm (UpdateProduct: attributeValues nil = giữ nguyên)
+	CreateProduct(product *model.Product, categoryIDs []int64, attributeValues []model.ProductAttributeValueInput) (*model.Product, error)
+	UpdateProduct(product *model.Product, categoryIDs []int64, attributeValues []model.ProductAttributeValueInput) (*model.Product, error)
 
 	// Check Conflict
 	GetConflictProductByName(name string) (bool, error)
@@ -24,11 +25,31 @@ type ProductRepository interface {
 	
 	// Helper
 	GetCategoriesByProductID(productID int64) ([]model.Category, error)
+	GetCategoryBreadcrumb(categoryID int64) ([]model.CategoryBreadcrumb, bool, error)
+
+	// Thông số kỹ thuật của sản phẩm
+	GetProductAttributeValues(productID int64) ([]model.ProductAttributeValue, error)
+	ReplaceProductAttributeValues(productID int64, values []model.ProductAttributeValueInput) error
+
+	// Scheduled publish
+	GetDueScheduledChanges() ([]model.ScheduledProductChange, error)
+	GetUpcomingScheduledChanges() ([]model.ScheduledProductChange, error)
+	ApplyScheduledChange(id int64, action string) (bool, error)
+
+	// Price range
+	SyncPriceRange(productID int64) (*model.PriceRangeChange, error)
 
 	// Delete
 	DeleteSoftProduct(id int64) error
 	BulkDeleteSoftProducts(ids []int64) error
 	GetAllProductsSoftDeleted() ([]model.Product, error)
 	DeleteAllProductsSoftDeleted() error
-	DeleteAllProducts() error
+	HardDeleteProducts(ids []int64) error
+
+	// Trash / Restore
+	GetSoftDeletedProductsByIDs(ids []int64) ([]model.Product, error)
+	CheckRestoreConflict(id int64, name, slug string) (nameConflict bool, slugConflict bool, err error)
+	RestoreProduct(id int64) error
+	GetExpiredSoftDeletedProductIDs(retentionDays int) ([]int64, error)
+	GetBlockingOrders(productIDs []int64) ([]model.BlockingOrder, error)
 }
\ No newline at end of file
@@ -18,17 +18,18 @@ func NewProductRepo(db *sql.DB) ProductRepository {
 }
 
 // CreateProduct - Tạo sản phẩm (Có Transaction để đảm bảo toàn vẹn dữ liệu)
-func (pr *ProductRepo) CreateProduct(product *model.Product, categoryIDs []int64) (*model.Product, error) {
+func (pr *ProductRepo) CreateProduct(product *model.Product, categoryIDs []int64, attributeValues []model.ProductAttributeValueInput) (*model.Product, error) {
 
 	tx, err := pr.DB.Begin()
 	if err != nil {
 		return nil, err
 	}
 
-	query := `INSERT INTO products(name, slug, short_description, description, brand, status, is_published, published_at, min_price) 
-              VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO products(name, slug, short_description, description, brand, status, is_published, published_at, unpublished_at, base_price, min_price, max_price) 
+              VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	res, err := tx.Exec(query, product.Name, product.Slug, product.ShortDescription, product.Description, product.Brand, product.Status, product.IsPublished, product.PublishedAt, product.MinPrice)
+	// Chưa có biến thể -> khoảng giá chính là giá gốc
+	res, err := tx.Exec(query, product.Name, product.Slug, product.ShortDescription, product.Description, product.Brand, product.Status, product.IsPublished, product.PublishedAt, product.UnpublishedAt, product.BasePrice, product.BasePrice, product.BasePrice)
 	if err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("cannot insert product: %v", err)
@@ -59,6 +60,12 @@ func (pr *ProductRepo) CreateProduct(product *model.Product, categoryIDs []int64
 		}
 	}
 
+	// Thông số kỹ thuật lưu cùng transaction: lỗi thì không để lại sản phẩm thiếu thông số
+	if err := replaceAttributeValuesTx(tx, product.ID, attributeValues); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -69,13 +76,13 @@ func (pr *ProductRepo) CreateProduct(product *model.Product, categoryIDs []int64
 // GetProductByID - Lấy sản phẩm theo ID
 func (pr *ProductRepo) GetProductByID(id int64) (*model.Product, error) {
 
-	query := `SELECT id, name, slug, short_description, description, brand, status, is_published, published_at, min_price, avg_rating, rating_count, created_by, updated_by, created_at, updated_at, deleted_at 
+	query := `SELECT id, name, slug, short_description, description, brand, status, is_published, published_at, unpublished_at, base_price, min_price, max_price, avg_rating, rating_count, created_by, updated_by, created_at, updated_at, deleted_at 
 			  FROM products 
 			  WHERE id=? AND deleted_at IS NULL`
 
 	rows := pr.DB.QueryRow(query, id)
 	var product model.Product
-	err := rows.Scan(&product.ID, &product.Name, &product.Slug, &product.ShortDescription, &product.Description, &product.Brand, &product.Status, &product.IsPublished, &product.PublishedAt, &product.MinPrice, &product.AvgRating, &product.RatingCount, &product.CreatedBy, &product.UpdatedBy, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt)
+	err := rows.Scan(&product.ID, &product.Name, &product.Slug, &product.ShortDescription, &product.Description, &product.Brand, &product.Status, &product.IsPublished, &product.PublishedAt, &product.UnpublishedAt, &product.BasePrice, &product.MinPrice, &product.MaxPrice, &product.AvgRating, &product.RatingCount, &product.CreatedBy, &product.UpdatedBy, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -84,13 +91,13 @@ func (pr *ProductRepo) GetProductByID(id int64) (*model.Product, error) {
 
 // GetProductByName
 func (pr *ProductRepo) GetProductByName(name string) (*model.Product, error) {
-	query := `SELECT id, name, slug, short_description, description, brand, status, is_published, published_at, min_price, avg_rating, rating_count, created_by, updated_by, created_at, updated_at, deleted_at 
+	query := `SELECT id, name, slug, short_description, description, brand, status, is_published, published_at, unpublished_at, base_price, min_price, max_price, avg_rating, rating_count, created_by, updated_by, created_at, updated_at, deleted_at 
 			  FROM products 
 			  WHERE name=? AND deleted_at IS NULL`
 
 	rows := pr.DB.QueryRow(query, name)
 	var product model.Product
-	err := rows.Scan(&product.ID, &product.Name, &product.Slug, &product.ShortDescription, &product.Description, &product.Brand, &product.Status, &product.IsPublished, &product.PublishedAt, &product.MinPrice, &product.AvgRating, &product.RatingCount, &product.CreatedBy, &product.UpdatedBy, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt)
+	err := rows.Scan(&product.ID, &product.Name, &product.Slug, &product.ShortDescription, &product.Description, &product.Brand, &product.Status, &product.IsPublished, &product.PublishedAt, &product.UnpublishedAt, &product.BasePrice, &product.MinPrice, &product.MaxPrice, &product.AvgRating, &product.RatingCount, &product.CreatedBy, &product.UpdatedBy, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -99,13 +106,13 @@ func (pr *ProductRepo) GetProductByName(name string) (*model.Product, error) {
 
 // GetProductBySlug
 func (pr *ProductRepo) GetProductBySlug(slug string) (*model.Product, error) {
-	query := `SELECT id, name, slug, short_description, description, brand, status, is_published, published_at, min_price, avg_rating, rating_count, created_by, updated_by, created_at, updated_at, deleted_at 
+	query := `SELECT id, name, slug, short_description, description, brand, status, is_published, published_at, unpublished_at, base_price, min_price, max_price, avg_rating, rating_count, created_by, updated_by, created_at, updated_at, deleted_at 
 			  FROM products 
 			  WHERE slug=? AND deleted_at IS NULL`
 
 	rows := pr.DB.QueryRow(query, slug)
 	var product model.Product
-	err := rows.Scan(&product.ID, &product.Name, &product.Slug, &product.ShortDescription, &product.Description, &product.Brand, &product.Status, &product.IsPublished, &product.PublishedAt, &product.MinPrice, &product.AvgRating, &product.RatingCount, &product.CreatedBy, &product.UpdatedBy, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt)
+	err := rows.Scan(&product.ID, &product.Name, &product.Slug, &product.ShortDescription, &product.Description, &product.Brand, &product.Status, &product.IsPublished, &product.PublishedAt, &product.UnpublishedAt, &product.BasePrice, &product.MinPrice, &product.MaxPrice, &product.AvgRating, &product.RatingCount, &product.CreatedBy, &product.UpdatedBy, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +129,7 @@ func (pr *ProductRepo) GetManyProduct(ids []int64) ([]model.Product, error) {
 	placeholders = placeholders[:len(placeholders)-1]
 
 	query := fmt.Sprintf(`
-        SELECT id, name, slug, short_description, description, brand, status, is_published, published_at, min_price, avg_rating, rating_count, created_by, updated_by, created_at, updated_at, deleted_at 
+        SELECT id, name, slug, short_description, description, brand, status, is_published, published_at, unpublished_at, base_price, min_price, max_price, avg_rating, rating_count, created_by, updated_by, created_at, updated_at, deleted_at 
         FROM products 
         WHERE id IN (%s) AND deleted_at IS NULL`, placeholders)
 
@@ -140,7 +147,7 @@ func (pr *ProductRepo) GetManyProduct(ids []int64) ([]model.Product, error) {
 	products := []model.Product{}
 	for rows.Next() {
 		var product model.Product
-		err := rows.Scan(&product.ID, &product.Name, &product.Slug, &product.ShortDescription, &product.Description, &product.Brand, &product.Status, &product.IsPublished, &product.PublishedAt, &product.MinPrice, &product.AvgRating, &product.RatingCount, &product.CreatedBy, &product.UpdatedBy, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt)
+		err := rows.Scan(&product.ID, &product.Name, &product.Slug, &product.ShortDescription, &product.Description, &product.Brand, &product.Status, &product.IsPublished, &product.PublishedAt, &product.UnpublishedAt, &product.BasePrice, &product.MinPrice, &product.MaxPrice, &product.AvgRating, &product.RatingCount, &product.CreatedBy, &product.UpdatedBy, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -153,7 +160,7 @@ func (pr *ProductRepo) GetManyProduct(ids []int64) ([]model.Product, error) {
 func (pr *ProductRepo) SearchProducts(req *model.SearchProductsRequest) ([]model.Product, error) {
 	baseQuery := `
 		SELECT p.id, p.name, p.slug, p.short_description, p.description, p.brand, 
-		       p.status, p.is_published, p.published_at, p.min_price, 
+		       p.status, p.is_published, p.published_at, p.unpublished_at, p.base_price, p.min_price, p.max_price, 
 		       p.avg_rating, p.rating_count, p.created_by, p.updated_by,
 		       p.created_at, p.updated_at, p.deleted_at
 		FROM products p
@@ -162,12 +169,49 @@ func (pr *ProductRepo) SearchProducts(req *model.SearchProductsRequest) ([]model
 	whereClauses := []string{"p.deleted_at IS NULL"}
 	args := []interface{}{}
 
-	if req.CategoryID > 0 {
+	if req.CategoryID > 0 && req.IncludeDescendants {
+		// Dùng subquery để không bị trùng dòng khi SP thuộc nhiều danh mục con
+		whereClauses = append(whereClauses, `p.id IN (
+			SELECT pc.product_id FROM product_categories pc
+			WHERE pc.category_id IN (
+				WITH RECURSIVE subtree AS (
+					SELECT id FROM categories WHERE id = ?
+					UNION ALL
+					SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+				)
+				SELECT id FROM subtree
+			))`)
+		args = append(args, req.CategoryID)
+	} else if req.CategoryID > 0 {
 		joinClause += " JOIN product_categories pc ON p.id = pc.product_id"
 		whereClauses = append(whereClauses, "pc.category_id = ?")
 		args = append(args, req.CategoryID)
 	}
 
+	// Mỗi bộ lọc thông số là 1 điều kiện EXISTS (AND giữa các thông số, OR giữa các giá trị)
+	for _, f := range req.AttributeFilters {
+		cond := `EXISTS (
+			SELECT 1 FROM product_attribute_values pav
+			JOIN category_attributes ca ON ca.id = pav.attribute_id
+			WHERE pav.product_id = p.id AND ca.code = ? AND ca.is_filterable = 1`
+		args = append(args, f.Code)
+		if len(f.Values) > 0 {
+			cond += " AND pav.value_text IN (" + strings.TrimSuffix(strings.Repeat("?,", len(f.Values)), ",") + ")"
+			for _, v := range f.Values {
+				args = append(args, v)
+			}
+		}
+		if f.Min != nil {
+			cond += " AND pav.value_number >= ?"
+			args = append(args, *f.Min)
+		}
+		if f.Max != nil {
+			cond += " AND pav.value_number <= ?"
+			args = append(args, *f.Max)
+		}
+		whereClauses = append(whereClauses, cond+")")
+	}
+
 	if req.Search != "" {
 		whereClauses = append(whereClauses, "p.name LIKE ?")
 		args = append(args, "%"+req.Search+"%")
@@ -178,10 +222,25 @@ func (pr *ProductRepo) SearchProducts(req *model.SearchProductsRequest) ([]model
 		args = append(args, "%"+req.Brand+"%")
 	}
 
-	finalQuery := fmt.Sprintf("%s %s WHERE %s ORDER BY p.created_at DESC",
+	// Rating đã tổng hợp sẵn trên products nên lọc / sắp xếp không cần JOIN bảng review
+	if req.MinRating > 0 {
+		whereClauses = append(whereClauses, "p.avg_rating >= ?")
+		args = append(args, req.MinRating)
+	}
+
+	orderBy := "p.created_at DESC"
+	switch req.Sort {
+	case model.ProductSortRating:
+		orderBy = "p.avg_rating DESC, p.rating_count DESC, p.created_at DESC"
+	case model.ProductSortReviews:
+		orderBy = "p.rating_count DESC, p.avg_rating DESC, p.created_at DESC"
+	}
+
+	finalQuery := fmt.Sprintf("%s %s WHERE %s ORDER BY %s",
 		baseQuery,
 		joinClause,
 		strings.Join(whereClauses, " AND "),
+		orderBy,
 	)
 
 	rows, err := pr.DB.Query(finalQuery, args...)
@@ -195,8 +254,8 @@ func (pr *ProductRepo) SearchProducts(req *model.SearchProductsRequest) ([]model
 		var p model.Product
 		err := rows.Scan(
 			&p.ID, &p.Name, &p.Slug, &p.ShortDescription, &p.Description,
-			&p.Brand, &p.Status, &p.IsPublished, &p.PublishedAt,
-			&p.MinPrice, &p.AvgRating, &p.RatingCount,
+			&p.Brand, &p.Status, &p.IsPublished, &p.PublishedAt, &p.UnpublishedAt,
+			&p.BasePrice, &p.MinPrice, &p.MaxPrice, &p.AvgRating, &p.RatingCount,
 			&p.CreatedBy, &p.UpdatedBy, &p.CreatedAt, &p.UpdatedAt, &p.DeletedAt,
 		)
 		if err != nil {
@@ -234,17 +293,20 @@ func (pr *ProductRepo) GetConflictProductBySlug(slug string) (bool, error) {
 }
 
 // UpdateProduct - Cập nhật thông tin và danh mục
-func (pr *ProductRepo) UpdateProduct(product *model.Product, categoryIDs []int64) (*model.Product, error) {
+func (pr *ProductRepo) UpdateProduct(product *model.Product, categoryIDs []int64, attributeValues []model.ProductAttributeValueInput) (*model.Product, error) {
 	tx, err := pr.DB.Begin()
 	if err != nil {
 		return nil, err
 	}
 
+	// Đổi lịch publish / unpublish thì lịch mới được áp dụng lại (MySQL gán SET theo thứ tự trái -> phải)
 	query := `UPDATE products 
-              SET name=?, slug=?, short_description=?, description=?, brand=?, status=?, is_published=?, published_at=?, min_price=?, updated_at=NOW() 
+              SET publish_applied_at = IF(published_at <=> ?, publish_applied_at, NULL), 
+                  unpublish_applied_at = IF(unpublished_at <=> ?, unpublish_applied_at, NULL), 
+                  name=?, slug=?, short_description=?, description=?, brand=?, status=?, is_published=?, published_at=?, unpublished_at=?, base_price=?, updated_at=NOW() 
               WHERE id=? AND deleted_at IS NULL`
 
-	res, err := tx.Exec(query, product.Name, product.Slug, product.ShortDescription, product.Description, product.Brand, product.Status, product.IsPublished, product.PublishedAt, product.MinPrice, product.ID)
+	res, err := tx.Exec(query, product.PublishedAt, product.UnpublishedAt, product.Name, product.Slug, product.ShortDescription, product.Description, product.Brand, product.Status, product.IsPublished, product.PublishedAt, product.UnpublishedAt, product.BasePrice, product.ID)
 	if err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("cannot update product: %v", err)
@@ -288,6 +350,25 @@ func (pr *ProductRepo) UpdateProduct(product *model.Product, categoryIDs []int64
 		}
 	}
 
+	// Đổi danh mục thì thông số đã được đối chiếu lại theo schema mới (nil = giữ nguyên)
+	if attributeValues != nil {
+		if err := replaceAttributeValuesTx(tx, product.ID, attributeValues); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	// Biến thể không có price_override bán theo giá gốc -> tính lại khoảng giá cùng transaction
+	change, err := SyncPriceRangeTx(tx, product.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if change != nil {
+		product.MinPrice = change.NewMinPrice
+		product.MaxPrice = change.NewMaxPrice
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -320,10 +401,43 @@ func (pr *ProductRepo) GetCategoriesByProductID(productID int64) ([]model.Catego
 	return categories, nil
 }
 
+// GetCategoryBreadcrumb - Đường dẫn từ danh mục gốc tới categoryID (ok = false nếu có mắt xích đang ẩn)
+func (pr *ProductRepo) GetCategoryBreadcrumb(categoryID int64) ([]model.CategoryBreadcrumb, bool, error) {
+	query := `
+		WITH RECURSIVE path AS (
+			SELECT id, name, slug, is_active, parent_id, 0 AS depth FROM categories WHERE id = ?
+			UNION ALL
+			SELECT c.id, c.name, c.slug, c.is_active, c.parent_id, p.depth + 1
+			FROM categories c JOIN path p ON c.id = p.parent_id
+		)
+		SELECT id, name, slug, is_active FROM path ORDER BY depth DESC
+	`
+	rows, err := pr.DB.Query(query, categoryID)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	crumbs := []model.CategoryBreadcrumb{}
+	ok := true
+	for rows.Next() {
+		var c model.CategoryBreadcrumb
+		var isActive bool
+		if err := rows.Scan(&c.ID, &c.Name, &c.Slug, &isActive); err != nil {
+			return nil, false, err
+		}
+		if !isActive {
+			ok = false
+		}
+		crumbs = append(crumbs, c)
+	}
+	return crumbs, ok, rows.Err()
+}
+
 // GetAllProducts - Lấy tất cả (kèm Categories)
 func (pr *ProductRepo) GetAllProducts() ([]model.Product, error) {
 	query := `SELECT id, name, slug, short_description, description, brand, 
-                     status, is_published, published_at, min_price, avg_rating, 
+                     status, is_published, published_at, unpublished_at, base_price, min_price, max_price, avg_rating, 
                      rating_count, created_by, updated_by, created_at, updated_at, deleted_at 
               FROM products 
               WHERE deleted_at IS NULL
@@ -342,7 +456,7 @@ func (pr *ProductRepo) GetAllProducts() ([]model.Product, error) {
 		err := rows.Scan(
 			&product.ID, &product.Name, &product.Slug, &product.ShortDescription,
 			&product.Description, &product.Brand, &product.Status, &product.IsPublished,
-			&product.PublishedAt, &product.MinPrice, &product.AvgRating, &product.RatingCount,
+			&product.PublishedAt, &product.UnpublishedAt, &product.BasePrice, &product.MinPrice, &product.MaxPrice, &product.AvgRating, &product.RatingCount,
 			&product.CreatedBy, &product.UpdatedBy, &product.CreatedAt, &product.UpdatedAt,
 			&product.DeletedAt,
 		)
@@ -404,7 +518,7 @@ func (pr *ProductRepo) BulkDeleteSoftProducts(ids []int64) error {
 }
 
 func (pr *ProductRepo) GetAllProductsSoftDeleted() ([]model.Product, error) {
-	rows, err := pr.DB.Query("SELECT id, name, slug, short_description, description, brand, status, is_published, published_at, min_price, avg_rating, rating_count, created_by, updated_by, created_at, updated_at, deleted_at FROM products where status='archived' AND deleted_at IS NOT NULL")
+	rows, err := pr.DB.Query("SELECT id, name, slug, short_description, description, brand, status, is_published, published_at, unpublished_at, base_price, min_price, max_price, avg_rating, rating_count, created_by, updated_by, created_at, updated_at, deleted_at FROM products where status='archived' AND deleted_at IS NOT NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -412,7 +526,7 @@ func (pr *ProductRepo) GetAllProductsSoftDeleted() ([]model.Product, error) {
 	products := []model.Product{}
 	for rows.Next() {
 		var product model.Product
-		if err := rows.Scan(&product.ID, &product.Name, &product.Slug, &product.ShortDescription, &product.Description, &product.Brand, &product.Status, &product.IsPublished, &product.PublishedAt, &product.MinPrice, &product.AvgRating, &product.RatingCount, &product.CreatedBy, &product.UpdatedBy, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt); err != nil {
+		if err := rows.Scan(&product.ID, &product.Name, &product.Slug, &product.ShortDescription, &product.Description, &product.Brand, &product.Status, &product.IsPublished, &product.PublishedAt, &product.UnpublishedAt, &product.BasePrice, &product.MinPrice, &product.MaxPrice, &product.AvgRating, &product.RatingCount, &product.CreatedBy, &product.UpdatedBy, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt); err != nil {
 			return nil, err
 		}
 		products = append(products, product)
@@ -428,10 +542,384 @@ func (pr *ProductRepo) DeleteAllProductsSoftDeleted() error {
 	return nil
 }
 
-func (pr *ProductRepo) DeleteAllProducts() error {
-	_, err := pr.DB.Exec("DELETE FROM products")
+// HardDeleteProducts - Xóa cứng sản phẩm (Transaction)
+// Snapshot order_items giữ lại SKU/Title nên chỉ gỡ liên kết variant_id; cart_items bị xóa theo
+func (pr *ProductRepo) HardDeleteProducts(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	placeholders := strings.Repeat("?,", len(ids))
+	placeholders = placeholders[:len(placeholders)-1]
+
+	params := make([]interface{}, len(ids))
+	for i, id := range ids {
+		params[i] = id
+	}
+
+	tx, err := pr.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := []string{
+		fmt.Sprintf("UPDATE order_items SET variant_id = NULL WHERE product_id IN (%s)", placeholders),
+		fmt.Sprintf("DELETE FROM cart_items WHERE product_id IN (%s)", placeholders),
+		fmt.Sprintf("DELETE FROM products WHERE id IN (%s) AND deleted_at IS NOT NULL", placeholders),
+	}
+	for _, q := range queries {
+		if _, err := tx.Exec(q, params...); err != nil {
+			return fmt.Errorf("Cannot hard delete products: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetSoftDeletedProductsByIDs - Lấy các sản phẩm trong thùng rác theo list ID
+func (pr *ProductRepo) GetSoftDeletedProductsByIDs(ids []int64) ([]model.Product, error) {
+	if len(ids) == 0 {
+		return []model.Product{}, nil
+	}
+	placeholders := strings.Repeat("?,", len(ids))
+	placeholders = placeholders[:len(placeholders)-1]
+
+	query := fmt.Sprintf(`
+        SELECT id, name, slug, short_description, description, brand, status, is_published, published_at, unpublished_at, base_price, min_price, max_price, avg_rating, rating_count, created_by, updated_by, created_at, updated_at, deleted_at 
+        FROM products 
+        WHERE id IN (%s) AND deleted_at IS NOT NULL`, placeholders)
+
+	params := make([]interface{}, len(ids))
+	for i, id := range ids {
+		params[i] = id
+	}
+
+	rows, err := pr.DB.Query(query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying soft deleted products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []model.Product{}
+	for rows.Next() {
+		var product model.Product
+		err := rows.Scan(&product.ID, &product.Name, &product.Slug, &product.ShortDescription, &product.Description, &product.Brand, &product.Status, &product.IsPublished, &product.PublishedAt, &product.UnpublishedAt, &product.BasePrice, &product.MinPrice, &product.MaxPrice, &product.AvgRating, &product.RatingCount, &product.CreatedBy, &product.UpdatedBy, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, nil
+}
+
+// CheckRestoreConflict - Kiểm tra name / slug có trùng sản phẩm đang hoạt động khác không
+func (pr *ProductRepo) CheckRestoreConflict(id int64, name, slug string) (bool, bool, error) {
+	var nameCount, slugCount int
+	err := pr.DB.QueryRow(`
+		SELECT COALESCE(SUM(name = ?), 0), COALESCE(SUM(slug = ?), 0)
+		FROM products
+		WHERE id <> ? AND deleted_at IS NULL AND (name = ? OR slug = ?)`,
+		name, slug, id, name, slug).Scan(&nameCount, &slugCount)
+	if err != nil {
+		return false, false, err
+	}
+	return nameCount > 0, slugCount > 0, nil
+}
+
+// RestoreProduct - Khôi phục sản phẩm từ thùng rác
+// Trạng thái cũ không còn (đã chuyển 'archived') nên đưa về 'draft' và chưa publish
+func (pr *ProductRepo) RestoreProduct(id int64) error {
+	res, err := pr.DB.Exec("UPDATE products SET deleted_at = NULL, status = 'draft', is_published = 0, updated_at = NOW() WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return fmt.Errorf("Cannot restore product: %w", err)
+	}
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("product not found in trash")
+	}
+	return nil
+}
+
+// GetExpiredSoftDeletedProductIDs - Lấy ID sản phẩm đã nằm trong thùng rác quá số ngày lưu trữ
+func (pr *ProductRepo) GetExpiredSoftDeletedProductIDs(retentionDays int) ([]int64, error) {
+	rows, err := pr.DB.Query("SELECT id FROM products WHERE deleted_at IS NOT NULL AND deleted_at < DATE_SUB(NOW(), INTERVAL ? DAY)", retentionDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// GetBlockingOrders - Lấy các đơn hàng chưa kết thúc có chứa sản phẩm (chặn xóa cứng)
+func (pr *ProductRepo) GetBlockingOrders(productIDs []int64) ([]model.BlockingOrder, error) {
+	if len(productIDs) == 0 {
+		return []model.BlockingOrder{}, nil
+	}
+	placeholders := strings.Repeat("?,", len(productIDs))
+	placeholders = placeholders[:len(placeholders)-1]
+
+	query := fmt.Sprintf(`
+		SELECT DISTINCT oi.product_id, o.id, o.order_number, o.status
+		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id
+		WHERE oi.product_id IN (%s)
+		  AND o.status NOT IN ('completed', 'cancelled', 'refunded')
+		ORDER BY oi.product_id, o.id`, placeholders)
+
+	params := make([]interface{}, len(productIDs))
+	for i, id := range productIDs {
+		params[i] = id
+	}
+
+	rows, err := pr.DB.Query(query, params...)
 	if err != nil {
-		return fmt.Errorf("Cannot delete all products: %w", err)
+		return nil, fmt.Errorf("error querying blocking orders: %w", err)
+	}
+	defer rows.Close()
+
+	blocking := []model.BlockingOrder{}
+	for rows.Next() {
+		var b model.BlockingOrder
+		if err := rows.Scan(&b.ProductID, &b.OrderID, &b.OrderNumber, &b.Status); err != nil {
+			return nil, err
+		}
+		blocking = append(blocking, b)
+	}
+	return blocking, nil
+}
+
+// SCHEDULED PUBLISH
+
+// scanScheduledChanges - Hàm hỗ trợ đọc danh sách thay đổi theo lịch
+func scanScheduledChanges(rows *sql.Rows) ([]model.ScheduledProductChange, error) {
+	defer rows.Close()
+
+	changes := []model.ScheduledProductChange{}
+	for rows.Next() {
+		var c model.ScheduledProductChange
+		if err := rows.Scan(&c.ProductID, &c.Name, &c.Action, &c.ScheduledAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// GetDueScheduledChanges - Lấy các lịch publish / unpublish đã đến giờ nhưng chưa được áp dụng
+// Mỗi lịch chỉ áp dụng 1 lần (publish_applied_at / unpublish_applied_at) để không ghi đè thao tác tay của Admin
+func (pr *ProductRepo) GetDueScheduledChanges() ([]model.ScheduledProductChange, error) {
+	query := `
+		SELECT id, name, 'publish' AS action, published_at AS scheduled_at
+		FROM products
+		WHERE deleted_at IS NULL AND publish_applied_at IS NULL
+		  AND published_at IS NOT NULL AND published_at <= NOW()
+		  AND (unpublished_at IS NULL OR unpublished_at > NOW())
+		UNION ALL
+		SELECT id, name, 'unpublish' AS action, unpublished_at AS scheduled_at
+		FROM products
+		WHERE deleted_at IS NULL AND unpublish_applied_at IS NULL
+		  AND unpublished_at IS NOT NULL AND unpublished_at <= NOW()
+		ORDER BY scheduled_at ASC`
+
+	rows, err := pr.DB.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying due scheduled products: %w", err)
+	}
+	return scanScheduledChanges(rows)
+}
+
+// GetUpcomingScheduledChanges - Lấy các thay đổi publish / unpublish sắp diễn ra
+func (pr *ProductRepo) GetUpcomingScheduledChanges() ([]model.ScheduledProductChange, error) {
+	query := `
+		SELECT id, name, 'publish' AS action, published_at AS scheduled_at
+		FROM products
+		WHERE deleted_at IS NULL AND published_at IS NOT NULL AND published_at > NOW()
+		UNION ALL
+		SELECT id, name, 'unpublish' AS action, unpublished_at AS scheduled_at
+		FROM products
+		WHERE deleted_at IS NULL AND unpublished_at IS NOT NULL AND unpublished_at > NOW()
+		ORDER BY scheduled_at ASC`
+
+	rows, err := pr.DB.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying upcoming scheduled products: %w", err)
+	}
+	return scanScheduledChanges(rows)
+}
+
+// ApplyScheduledChange - Áp dụng lịch publish / unpublish và đánh dấu lịch đã chạy
+// Trả về true nếu trạng thái publish thực sự thay đổi (sản phẩm chưa ở trạng thái đích)
+func (pr *ProductRepo) ApplyScheduledChange(id int64, action string) (bool, error) {
+	isPublished := action == model.ScheduleActionPublish
+	appliedColumn := "unpublish_applied_at"
+	if isPublished {
+		appliedColumn = "publish_applied_at"
+	}
+
+	tx, err := pr.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var current bool
+	err = tx.QueryRow("SELECT is_published FROM products WHERE id = ? AND deleted_at IS NULL FOR UPDATE", id).Scan(&current)
+	if err == sql.ErrNoRows {
+		return false, fmt.Errorf("product not found or already deleted")
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot load publish state: %w", err)
+	}
+
+	query := "UPDATE products SET " + appliedColumn + " = NOW() WHERE id = ?"
+	if current != isPublished {
+		query = "UPDATE products SET is_published = ?, " + appliedColumn + " = NOW(), updated_at = NOW() WHERE id = ?"
+		_, err = tx.Exec(query, isPublished, id)
+	} else {
+		_, err = tx.Exec(query, id)
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot update publish state: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return current != isPublished, nil
+}
+
+// PRICE RANGE
+
+// SyncPriceRange - Tính lại min_price / max_price của 1 sản phẩm trong transaction riêng
+// Trả về nil nếu không thay đổi
+func (pr *ProductRepo) SyncPriceRange(productID int64) (*model.PriceRangeChange, error) {
+	tx, err := pr.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	change, err := SyncPriceRangeTx(tx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// SyncPriceRangeTx - Tính lại min_price / max_price từ giá bán các biến thể active (utils.VariantPrice) trong transaction của thao tác ghi
+// Biến thể không có price_override (NULL / 0) tính theo base_price; không có biến thể active thì min = max = base_price
+// Trả về nil nếu không thay đổi
+func SyncPriceRangeTx(tx *sql.Tx, productID int64) (*model.PriceRangeChange, error) {
+	change := &model.PriceRangeChange{ProductID: productID}
+	var basePrice float64
+	err := tx.QueryRow("SELECT base_price, min_price, max_price FROM products WHERE id = ? FOR UPDATE", productID).
+		Scan(&basePrice, &change.OldMinPrice, &change.OldMaxPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	var minPrice, maxPrice sql.NullFloat64
+	err = tx.QueryRow(`
+		SELECT MIN(IF(price_override > 0, price_override, ?)), MAX(IF(price_override > 0, price_override, ?))
+		FROM product_variants
+		WHERE product_id = ? AND is_active = 1`, basePrice, basePrice, productID).Scan(&minPrice, &maxPrice)
+	if err != nil {
+		return nil, fmt.Errorf("cannot compute price range: %w", err)
+	}
+
+	change.NewMinPrice = basePrice
+	change.NewMaxPrice = basePrice
+	if minPrice.Valid && maxPrice.Valid {
+		change.NewMinPrice = minPrice.Float64
+		change.NewMaxPrice = maxPrice.Float64
+	}
+
+	if change.NewMinPrice == change.OldMinPrice && change.NewMaxPrice == change.OldMaxPrice {
+		return nil, nil
+	}
+
+	if _, err := tx.Exec("UPDATE products SET min_price = ?, max_price = ?, updated_at = NOW() WHERE id = ?", change.NewMinPrice, change.NewMaxPrice, productID); err != nil {
+		return nil, fmt.Errorf("cannot update price range: %w", err)
+	}
+	return change, nil
+}
+
+// GetProductAttributeValues - Lấy thông số của sản phẩm kèm định nghĩa (Value trả về dạng text / số)
+func (pr *ProductRepo) GetProductAttributeValues(productID int64) ([]model.ProductAttributeValue, error) {
+	rows, err := pr.DB.Query(`
+		SELECT ca.id, ca.code, ca.name, ca.data_type, ca.unit, pav.value_text, pav.value_number
+		FROM product_attribute_values pav
+		JOIN category_attributes ca ON ca.id = pav.attribute_id
+		WHERE pav.product_id = ?
+		ORDER BY ca.position ASC, ca.id ASC`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot get product attributes: %w", err)
+	}
+	defer rows.Close()
+
+	values := []model.ProductAttributeValue{}
+	for rows.Next() {
+		var v model.ProductAttributeValue
+		var text string
+		var number sql.NullFloat64
+		if err := rows.Scan(&v.AttributeID, &v.Code, &v.Name, &v.DataType, &v.Unit, &text, &number); err != nil {
+			return nil, fmt.Errorf("Cannot scan product attribute: %w", err)
+		}
+		v.Value = text
+		if v.DataType == model.AttributeTypeNumber && number.Valid {
+			v.Value = number.Float64
+		} else if v.DataType == model.AttributeTypeBoolean {
+			v.Value = text == "true"
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// ReplaceProductAttributeValues - Ghi đè toàn bộ thông số của sản phẩm (Transaction)
+func (pr *ProductRepo) ReplaceProductAttributeValues(productID int64, values []model.ProductAttributeValueInput) error {
+	tx, err := pr.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceAttributeValuesTx(tx, productID, values); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// replaceAttributeValuesTx - Ghi đè thông số của sản phẩm trong transaction hiện tại
+func replaceAttributeValuesTx(tx *sql.Tx, productID int64, values []model.ProductAttributeValueInput) error {
+	if _, err := tx.Exec("DELETE FROM product_attribute_values WHERE product_id = ?", productID); err != nil {
+		return fmt.Errorf("Cannot clear product attributes: %w", err)
+	}
+
+	if len(values) > 0 {
+		stmt, err := tx.Prepare(`INSERT INTO product_attribute_values (product_id, attribute_id, value_text, value_number) VALUES (?, ?, ?, ?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, v := range values {
+			if _, err := stmt.Exec(productID, v.AttributeID, v.ValueText, v.ValueNumber); err != nil {
+				return fmt.Errorf("Cannot save attribute %d: %w", v.AttributeID, err)
+			}
+		}
 	}
 	return nil
 }
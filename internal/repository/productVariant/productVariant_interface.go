@@ -6,9 +6,19 @@ import (
 
 // ProductVariantsRepository - Interface định nghĩa các phương thức
 type ProductVariantsRepository interface {
-	CreateProductVariant(variant *model.ProductsVariants) (*model.ProductsVariants, error)
+	// Các thao tác ghi tính lại min_price / max_price của sản phẩm trong cùng transaction
+	CreateProductVariant(variant *model.ProductsVariants) (*model.ProductsVariants, *model.PriceRangeChange, error)
 	GetProductVariantByID(productID int64) ([]model.ProductsVariants, error)
 	GetVariantByID(variantID int64) (*model.ProductsVariants, error)
-	UpdateProductVariant(variant *model.ProductsVariants) error
-	DeleteProductVariant(variantID int64) error
+	UpdateProductVariant(variant *model.ProductsVariants) (*model.PriceRangeChange, error)
+	DeleteProductVariant(productID int64, variantID int64) (*model.PriceRangeChange, error)
+	// Cộng / trừ tồn kho trong transaction, trả về tồn kho trước và sau (ErrNegativeStock nếu âm)
+	AdjustStock(variantID int64, delta int) (oldStock int, newStock int, err error)
+
+	// Option matrix
+	GetProductOptions(productID int64) ([]model.ProductOption, error)
+	ReplaceProductOptions(productID int64, options []model.ProductOption) error
+	CreateProductVariants(productID int64, variants []model.ProductsVariants) ([]model.ProductsVariants, *model.PriceRangeChange, error)
+	// Các SKU trong danh sách đã tồn tại (kiểm tra trước khi sinh matrix)
+	GetExistingSKUs(skus []string) ([]string, error)
 }
\ No newline at end of file
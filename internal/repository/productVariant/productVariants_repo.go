@@ -2,11 +2,22 @@ package productvariant
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"golang/internal/model"
+	"golang/internal/repository/product"
+	"golang/internal/utils"
+	"strings"
 	"time"
 )
 
+// ErrNegativeStock - Điều chỉnh làm tồn kho âm
+var ErrNegativeStock = errors.New("stock cannot be negative")
+
+// ErrDuplicateVariant - Trùng SKU hoặc trùng tổ hợp option với biến thể khác (khóa UNIQUE)
+var ErrDuplicateVariant = errors.New("duplicate variant")
+
 type VariantRepo struct {
 	DB *sql.DB
 }
@@ -15,27 +26,66 @@ func NewVariantRepo(db *sql.DB) ProductVariantsRepository {
 	return &VariantRepo{DB: db}
 }
 
-// CreateProductVariant - Tạo biến thể mới trong database
-func (provariant *VariantRepo) CreateProductVariant(variant *model.ProductsVariants) (*model.ProductsVariants, error) {
-	query, err := provariant.DB.Exec(`insert into product_variants (product_id,sku,title,option_values,price_override,cost_price,stock_quantity,allow_backorder,is_active) values(?,?,?,?,?,?,?,?,?)`,
-		variant.ProductID, variant.SKU, variant.Title, variant.OptionValues, variant.PriceOverride, variant.CostPrice, variant.StockQuantity, variant.AllowBackorder, variant.IsActive)
+// withPriceRangeSync - Chạy thao tác ghi biến thể và tính lại min_price / max_price của sản phẩm trong cùng 1 transaction
+func (provariant *VariantRepo) withPriceRangeSync(productID int64, fn func(tx *sql.Tx) error) (*model.PriceRangeChange, error) {
+	tx, err := provariant.DB.Begin()
 	if err != nil {
-		return nil, fmt.Errorf("Cannot create product variant: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Khóa dòng sản phẩm trước khi ghi biến thể để các thay đổi đồng thời không ghi đè khoảng giá của nhau
+	var lockedID int64
+	if err := tx.QueryRow(`SELECT id FROM products WHERE id = ? FOR UPDATE`, productID).Scan(&lockedID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("Product not found")
+		}
+		return nil, err
 	}
-	id, err := query.LastInsertId()
+
+	if err := fn(tx); err != nil {
+		return nil, err
+	}
+	change, err := product.SyncPriceRangeTx(tx, productID)
 	if err != nil {
 		return nil, err
 	}
-	variant.ID = id
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// CreateProductVariant - Tạo biến thể mới trong database, trả về kèm thay đổi khoảng giá của sản phẩm (nil nếu không đổi)
+func (provariant *VariantRepo) CreateProductVariant(variant *model.ProductsVariants) (*model.ProductsVariants, *model.PriceRangeChange, error) {
+	change, err := provariant.withPriceRangeSync(variant.ProductID, func(tx *sql.Tx) error {
+		query, err := tx.Exec(`insert into product_variants (product_id,sku,title,option_values,price_override,cost_price,stock_quantity,allow_backorder,is_active,weight_grams,reorder_threshold) values(?,?,?,?,?,?,?,?,?,?,?)`,
+			variant.ProductID, variant.SKU, variant.Title, variant.OptionValues, variant.PriceOverride, variant.CostPrice, variant.StockQuantity, variant.AllowBackorder, variant.IsActive, variant.WeightGrams, variant.ReorderThreshold)
+		if err != nil {
+			if utils.IsDuplicateKey(err) {
+				return fmt.Errorf("%w: %v", ErrDuplicateVariant, err)
+			}
+			return fmt.Errorf("Cannot create product variant: %v", err)
+		}
+		id, err := query.LastInsertId()
+		if err != nil {
+			return err
+		}
+		variant.ID = id
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 	variant.CreatedAt = time.Now()
-	return variant, nil
+	return variant, change, nil
 }
 
 // GetProductVariantByID - Lấy tất cả biến thể của một sản phẩm
 func (provariant *VariantRepo) GetProductVariantByID(productID int64) ([]model.ProductsVariants, error) {
 	rows, err := provariant.DB.Query(`
         SELECT id, product_id, sku, title, option_values, price_override, cost_price, 
-               stock_quantity, allow_backorder, is_active, created_at, updated_at
+               stock_quantity, allow_backorder, is_active, weight_grams, reorder_threshold, created_at, updated_at
         FROM product_variants 
         WHERE product_id = ?`, productID)
 	if err != nil {
@@ -47,7 +97,7 @@ func (provariant *VariantRepo) GetProductVariantByID(productID int64) ([]model.P
 		var v model.ProductsVariants
 		err := rows.Scan(&v.ID, &v.ProductID, &v.SKU, &v.Title, &v.OptionValues,
 			&v.PriceOverride, &v.CostPrice, &v.StockQuantity,
-			&v.AllowBackorder, &v.IsActive, &v.CreatedAt, &v.UpdatedAt)
+			&v.AllowBackorder, &v.IsActive, &v.WeightGrams, &v.ReorderThreshold, &v.CreatedAt, &v.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("Cannot scan variant: %w", err)
 		}
@@ -56,22 +106,57 @@ func (provariant *VariantRepo) GetProductVariantByID(productID int64) ([]model.P
 	return variants, nil
 }
 
-// UpdateProductVariant - Cập nhật thông tin variant
-func (provariant *VariantRepo) UpdateProductVariant(variant *model.ProductsVariants) error {
-	_, err := provariant.DB.Exec(`
-		UPDATE product_variants 
-		SET sku=?, title=?, option_values=?, price_override=?, cost_price=?, 
-		    stock_quantity=?, allow_backorder=?, is_active=?, updated_at=NOW()
-		WHERE id=?`,
-		variant.SKU, variant.Title, variant.OptionValues, variant.PriceOverride,
-		variant.CostPrice, variant.StockQuantity, variant.AllowBackorder,
-		variant.IsActive, variant.ID)
+// UpdateProductVariant - Cập nhật thông tin variant, trả về thay đổi khoảng giá của sản phẩm (nil nếu không đổi)
+func (provariant *VariantRepo) UpdateProductVariant(variant *model.ProductsVariants) (*model.PriceRangeChange, error) {
+	return provariant.withPriceRangeSync(variant.ProductID, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			UPDATE product_variants 
+			SET sku=?, title=?, option_values=?, price_override=?, cost_price=?, 
+			    stock_quantity=?, allow_backorder=?, is_active=?, weight_grams=?, reorder_threshold=?, updated_at=NOW()
+			WHERE id=? AND product_id=?`,
+			variant.SKU, variant.Title, variant.OptionValues, variant.PriceOverride,
+			variant.CostPrice, variant.StockQuantity, variant.AllowBackorder,
+			variant.IsActive, variant.WeightGrams, variant.ReorderThreshold, variant.ID, variant.ProductID)
+
+		if err != nil {
+			if utils.IsDuplicateKey(err) {
+				return fmt.Errorf("%w: %v", ErrDuplicateVariant, err)
+			}
+			return fmt.Errorf("Cannot update product variant: %w", err)
+		}
+		return nil
+	})
+}
 
+// AdjustStock - Cộng / trừ tồn kho (khóa dòng để đọc đúng tồn kho trước khi đổi)
+func (provariant *VariantRepo) AdjustStock(variantID int64, delta int) (int, int, error) {
+	tx, err := provariant.DB.Begin()
 	if err != nil {
-		return fmt.Errorf("Cannot update product variant: %w", err)
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	var oldStock int
+	if err := tx.QueryRow(`SELECT stock_quantity FROM product_variants WHERE id = ? FOR UPDATE`, variantID).Scan(&oldStock); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, 0, fmt.Errorf("Variant not found")
+		}
+		return 0, 0, fmt.Errorf("Cannot get variant stock: %w", err)
 	}
 
-	return nil
+	newStock := oldStock + delta
+	if newStock < 0 {
+		return oldStock, oldStock, ErrNegativeStock
+	}
+
+	if _, err := tx.Exec(`UPDATE product_variants SET stock_quantity = ?, updated_at = NOW() WHERE id = ?`, newStock, variantID); err != nil {
+		return 0, 0, fmt.Errorf("Cannot adjust stock: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return oldStock, newStock, nil
 }
 
 // GetVariantByID - Lấy thông tin một variant theo ID
@@ -79,12 +164,12 @@ func (provariant *VariantRepo) GetVariantByID(variantID int64) (*model.ProductsV
 	var v model.ProductsVariants
 	err := provariant.DB.QueryRow(`
 		SELECT id, product_id, sku, title, option_values, price_override, cost_price,
-		       stock_quantity, allow_backorder, is_active, created_at, updated_at
+		       stock_quantity, allow_backorder, is_active, weight_grams, reorder_threshold, created_at, updated_at
 		FROM product_variants
 		WHERE id = ?`, variantID).Scan(
 		&v.ID, &v.ProductID, &v.SKU, &v.Title, &v.OptionValues,
 		&v.PriceOverride, &v.CostPrice, &v.StockQuantity,
-		&v.AllowBackorder, &v.IsActive, &v.CreatedAt, &v.UpdatedAt)
+		&v.AllowBackorder, &v.IsActive, &v.WeightGrams, &v.ReorderThreshold, &v.CreatedAt, &v.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -96,21 +181,138 @@ func (provariant *VariantRepo) GetVariantByID(variantID int64) (*model.ProductsV
 	return &v, nil
 }
 
-// DeleteProductVariant - Xóa variant
-func (provariant *VariantRepo) DeleteProductVariant(variantID int64) error {
-	result, err := provariant.DB.Exec(`DELETE FROM product_variants WHERE id = ?`, variantID)
+// DeleteProductVariant - Xóa variant, trả về thay đổi khoảng giá của sản phẩm (nil nếu không đổi)
+func (provariant *VariantRepo) DeleteProductVariant(productID int64, variantID int64) (*model.PriceRangeChange, error) {
+	return provariant.withPriceRangeSync(productID, func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM product_variants WHERE id = ? AND product_id = ?`, variantID, productID)
+		if err != nil {
+			return fmt.Errorf("Cannot delete product variant: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("Cannot check rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("Variant not found")
+		}
+		return nil
+	})
+}
+
+// GetProductOptions - Lấy danh sách option của sản phẩm theo thứ tự position
+func (provariant *VariantRepo) GetProductOptions(productID int64) ([]model.ProductOption, error) {
+	rows, err := provariant.DB.Query(`
+		SELECT id, product_id, name, value_list, position
+		FROM product_options
+		WHERE product_id = ?
+		ORDER BY position ASC, id ASC`, productID)
 	if err != nil {
-		return fmt.Errorf("Cannot delete product variant: %w", err)
+		return nil, fmt.Errorf("Cannot get product options: %w", err)
+	}
+	defer rows.Close()
+
+	options := []model.ProductOption{}
+	for rows.Next() {
+		var opt model.ProductOption
+		var rawValues string
+		if err := rows.Scan(&opt.ID, &opt.ProductID, &opt.Name, &rawValues, &opt.Position); err != nil {
+			return nil, fmt.Errorf("Cannot scan product option: %w", err)
+		}
+		if err := json.Unmarshal([]byte(rawValues), &opt.Values); err != nil {
+			return nil, fmt.Errorf("Invalid option values for option %d: %w", opt.ID, err)
+		}
+		options = append(options, opt)
+	}
+	return options, nil
+}
+
+// ReplaceProductOptions - Ghi đè toàn bộ option của sản phẩm (Transaction)
+func (provariant *VariantRepo) ReplaceProductOptions(productID int64, options []model.ProductOption) error {
+	tx, err := provariant.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM product_options WHERE product_id = ?`, productID); err != nil {
+		return fmt.Errorf("Cannot clear product options: %w", err)
+	}
+
+	for _, opt := range options {
+		rawValues, err := json.Marshal(opt.Values)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO product_options (product_id, name, value_list, position) VALUES (?, ?, ?, ?)`,
+			productID, opt.Name, string(rawValues), opt.Position); err != nil {
+			return fmt.Errorf("Cannot insert product option '%s': %w", opt.Name, err)
+		}
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return tx.Commit()
+}
+
+// GetExistingSKUs - Các SKU trong danh sách đã được biến thể khác sử dụng
+func (provariant *VariantRepo) GetExistingSKUs(skus []string) ([]string, error) {
+	if len(skus) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(skus)), ",")
+	args := make([]interface{}, len(skus))
+	for i, s := range skus {
+		args[i] = s
+	}
+	rows, err := provariant.DB.Query(`SELECT sku FROM product_variants WHERE sku IN (`+placeholders+`)`, args...)
 	if err != nil {
-		return fmt.Errorf("Cannot check rows affected: %w", err)
+		return nil, err
 	}
+	defer rows.Close()
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("Variant not found")
+	existing := []string{}
+	for rows.Next() {
+		var sku string
+		if err := rows.Scan(&sku); err != nil {
+			return nil, err
+		}
+		existing = append(existing, sku)
 	}
+	return existing, rows.Err()
+}
 
-	return nil
+// CreateProductVariants - Tạo nhiều biến thể của 1 sản phẩm trong 1 Transaction (dùng khi sinh matrix)
+// Trả về kèm thay đổi khoảng giá của sản phẩm (nil nếu không đổi)
+func (provariant *VariantRepo) CreateProductVariants(productID int64, variants []model.ProductsVariants) ([]model.ProductsVariants, *model.PriceRangeChange, error) {
+	now := time.Now()
+	change, err := provariant.withPriceRangeSync(productID, func(tx *sql.Tx) error {
+		stmt, err := tx.Prepare(`insert into product_variants (product_id,sku,title,option_values,price_override,cost_price,stock_quantity,allow_backorder,is_active,weight_grams,reorder_threshold) values(?,?,?,?,?,?,?,?,?,?,?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for i := range variants {
+			v := &variants[i]
+			res, err := stmt.Exec(productID, v.SKU, v.Title, v.OptionValues, v.PriceOverride, v.CostPrice, v.StockQuantity, v.AllowBackorder, v.IsActive, v.WeightGrams, v.ReorderThreshold)
+			if err != nil {
+				if utils.IsDuplicateKey(err) {
+					return fmt.Errorf("%w: %s", ErrDuplicateVariant, v.SKU)
+				}
+				return fmt.Errorf("Cannot create variant %s: %v", v.SKU, err)
+			}
+			id, err := res.LastInsertId()
+			if err != nil {
+				return err
+			}
+			v.ID = id
+			v.ProductID = productID
+			v.CreatedAt = now
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return variants, change, nil
 }
@@ -7,5 +7,6 @@ type ProductHistoryRepository interface {
 	GetProductHistoryByProductID(productID []int64) ([]model.ProductHistory, error)
 	GetAllProductsHistory(limit, offset int) ([]model.ProductHistory, error)
 	CountProductsHistory() (int, error)
+	GetProductHistoryByID(id int64) (*model.ProductHistory, error)
 }
 
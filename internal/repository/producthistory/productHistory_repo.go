@@ -111,3 +111,23 @@ func (r *HistoryRepo) CountProductsHistory() (int, error) {
 	}
 	return count, nil
 }
+
+// GetProductHistoryByID - Lấy một bản ghi lịch sử theo ID
+func (r *HistoryRepo) GetProductHistoryByID(id int64) (*model.ProductHistory, error) {
+	query := `SELECT id, product_id, variant_id, admin_id, changed_at, changes, note 
+	          FROM product_history 
+	          WHERE id = ?`
+
+	var h model.ProductHistory
+	var changesBytes []byte
+	err := r.DB.QueryRow(query, id).Scan(&h.ID, &h.ProductID, &h.VariantID, &h.AdminID, &h.ChangedAt, &changesBytes, &h.Note)
+	if err != nil {
+		return nil, err
+	}
+	if changesBytes != nil {
+		h.Changes = changesBytes
+	} else {
+		h.Changes = json.RawMessage("{}")
+	}
+	return &h, nil
+}
@@ -5,7 +5,23 @@ import "golang/internal/model"
 type ProductReviewRepository interface {
 	CreateProductReview(review *model.ProductReview) (*model.ProductReview, error)
 	GetProductReviewsByProductID(productID int64) ([]model.ProductReview, error)
-	GetAverageRatingByProductID(productID int64) (float64, error)
-	GetCountRatingByProductID(productID int64) (int64, error)
 	DeleteReviewByID(reviewID int64) error
+
+	// Rating tổng hợp (cập nhật cùng transaction với create / edit / moderate / delete)
+	GetRatingSummary(productID int64) (*model.ProductRatingSummary, error)
+	GetRatingDrifts() ([]model.RatingDrift, error)
+	RefreshProductRating(productID int64) error
+
+	// Kiểm duyệt & quản lý review của chính tác giả
+	GetReviewByID(reviewID int64) (*model.ProductReview, error)
+	GetReviewByUserAndProduct(userID, productID int64) (*model.ProductReview, error)
+	UpdateReviewContent(reviewID int64, body *string, rating int) error
+	ModerateReview(reviewID int64, status string, adminID *int64, note *string) error
+	GetReviewsByStatus(status string, limit, offset int) ([]model.ProductReview, int, error)
+
+	// Danh sách công khai (cursor) & vote hữu ích / báo cáo
+	ListApprovedReviews(productID int64, q model.ReviewListQuery) ([]model.ProductReview, error)
+	AddHelpfulVote(reviewID, userID int64) (int, error)
+	RemoveHelpfulVote(reviewID, userID int64) (int, error)
+	AddReport(reviewID, userID int64, reason string, threshold int) (bool, error)
 }
@@ -2,10 +2,15 @@ package productreview
 
 import (
 	"database/sql"
+	"errors"
 	"golang/internal/model"
+	"strings"
 	"time"
 )
 
+// ErrAlreadyVoted - User đã vote / báo cáo review này trước đó
+var ErrAlreadyVoted = errors.New("already voted")
+
 type productReviewRepo struct {
 	DB *sql.DB
 }
@@ -15,34 +20,323 @@ func NewProductReviewRepo(db *sql.DB) ProductReviewRepository {
 		DB: db,
 	}
 }
-func (pr *productReviewRepo) CreateProductReview(review *model.ProductReview) (*model.ProductReview, error) {
-	res, err := pr.DB.Exec(`
-		INSERT INTO product_reviews (product_id, user_id, rating, body, created_at, updated_at)
-		VALUES (?, ?, ?, ?, NOW(), NOW())`,
-		review.ProductID, review.UserID, review.Rating, review.Body,
-	)
+
+// Cột dùng chung cho các câu SELECT review (alias r = product_reviews)
+const reviewColumns = `r.id, r.product_id, r.user_id, r.rating, r.body, r.status, r.order_item_id, r.is_verified_purchase,
+		r.moderated_by, r.moderated_at, r.moderation_note, r.helpful_count, r.report_count, r.created_at, r.updated_at`
+
+func scanReview(scanner interface{ Scan(dest ...any) error }, r *model.ProductReview, extra ...any) error {
+	dest := []any{&r.ID, &r.ProductID, &r.UserID, &r.Rating, &r.Body, &r.Status, &r.OrderItemID,
+		&r.IsVerifiedPurchase, &r.ModeratedBy, &r.ModeratedAt, &r.ModerationNote, &r.HelpfulCount, &r.ReportCount,
+		&r.CreatedAt, &r.UpdatedAt}
+	return scanner.Scan(append(dest, extra...)...)
+}
+
+func scanReviews(rows *sql.Rows) ([]model.ProductReview, error) {
+	reviews := []model.ProductReview{}
+	for rows.Next() {
+		var r model.ProductReview
+		if err := scanReview(rows, &r); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, rows.Err()
+}
+
+// Biểu thức tính histogram từ các review đã duyệt
+const ratingHistogramColumns = `COALESCE(SUM(rating = 1), 0), COALESCE(SUM(rating = 2), 0), COALESCE(SUM(rating = 3), 0),
+		COALESCE(SUM(rating = 4), 0), COALESCE(SUM(rating = 5), 0)`
+
+// refreshProductRating - Tính lại histogram, avg_rating, rating_count của 1 sản phẩm trong transaction hiện tại
+func refreshProductRating(tx *sql.Tx, productID int64) error {
+	_, err := tx.Exec(`
+		INSERT INTO product_rating_stats (product_id, star_1, star_2, star_3, star_4, star_5)
+		SELECT ?, `+ratingHistogramColumns+`
+		FROM product_reviews
+		WHERE product_id = ? AND status = 'approved'
+		ON DUPLICATE KEY UPDATE star_1 = VALUES(star_1), star_2 = VALUES(star_2), star_3 = VALUES(star_3),
+			star_4 = VALUES(star_4), star_5 = VALUES(star_5)`, productID, productID)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	id, err := res.LastInsertId()
+	// Giữ nguyên updated_at: thay đổi rating không phải là sửa sản phẩm
+	_, err = tx.Exec(`
+		UPDATE products p
+		JOIN product_rating_stats s ON s.product_id = p.id
+		SET p.rating_count = s.star_1 + s.star_2 + s.star_3 + s.star_4 + s.star_5,
+			p.avg_rating = COALESCE(ROUND((s.star_1 + 2*s.star_2 + 3*s.star_3 + 4*s.star_4 + 5*s.star_5)
+				/ NULLIF(s.star_1 + s.star_2 + s.star_3 + s.star_4 + s.star_5, 0), 2), 0),
+			p.updated_at = p.updated_at
+		WHERE p.id = ?`, productID)
+	return err
+}
+
+// withRatingRefresh - Chạy thay đổi review và cập nhật lại rating của sản phẩm trong cùng 1 transaction
+func (pr *productReviewRepo) withRatingRefresh(productID int64, fn func(tx *sql.Tx) error) error {
+	tx, err := pr.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Khóa dòng sản phẩm để các thay đổi review đồng thời không ghi đè kết quả của nhau
+	var lockedID int64
+	if err := tx.QueryRow(`SELECT id FROM products WHERE id = ? FOR UPDATE`, productID).Scan(&lockedID); err != nil {
+		return err
+	}
+
+	if fn != nil {
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+	if err := refreshProductRating(tx, productID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// productIDOfReview - Lấy product_id của review (sql.ErrNoRows nếu không tồn tại)
+func (pr *productReviewRepo) productIDOfReview(reviewID int64) (int64, error) {
+	var productID int64
+	err := pr.DB.QueryRow(`SELECT product_id FROM product_reviews WHERE id = ?`, reviewID).Scan(&productID)
+	return productID, err
+}
+
+func (pr *productReviewRepo) CreateProductReview(review *model.ProductReview) (*model.ProductReview, error) {
+	err := pr.withRatingRefresh(review.ProductID, func(tx *sql.Tx) error {
+		res, err := tx.Exec(`
+			INSERT INTO product_reviews (product_id, user_id, rating, body, status, order_item_id, is_verified_purchase, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, NOW(), NOW())`,
+			review.ProductID, review.UserID, review.Rating, review.Body, review.Status, review.OrderItemID, review.IsVerifiedPurchase,
+		)
+		if err != nil {
+			return err
+		}
+		review.ID, err = res.LastInsertId()
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	review.ID = id
-	now := time.Now().Format(time.RFC3339)
+	now := time.Now()
 	review.CreatedAt = now
 	review.UpdatedAt = now
 	return review, nil
 }
 
+// GetProductReviewsByProductID - Chỉ lấy review đã được duyệt (hiển thị công khai)
 func (pr *productReviewRepo) GetProductReviewsByProductID(productID int64) ([]model.ProductReview, error) {
 	rows, err := pr.DB.Query(`
-		SELECT id, product_id, user_id, rating, body, created_at, updated_at
-		FROM product_reviews
-		WHERE product_id = ?
-		ORDER BY created_at DESC`, productID)
+		SELECT `+reviewColumns+`
+		FROM product_reviews r
+		WHERE r.product_id = ? AND r.status = 'approved'
+		ORDER BY r.created_at DESC`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanReviews(rows)
+}
+
+// GetRatingSummary - Đọc rating đã lưu (không tính lại từ bảng review)
+func (pr *productReviewRepo) GetRatingSummary(productID int64) (*model.ProductRatingSummary, error) {
+	s := model.ProductRatingSummary{ProductID: productID}
+	err := pr.DB.QueryRow(`
+		SELECT p.avg_rating, p.rating_count,
+			COALESCE(h.star_1, 0), COALESCE(h.star_2, 0), COALESCE(h.star_3, 0), COALESCE(h.star_4, 0), COALESCE(h.star_5, 0)
+		FROM products p
+		LEFT JOIN product_rating_stats h ON h.product_id = p.id
+		WHERE p.id = ?`, productID).Scan(&s.AvgRating, &s.RatingCount,
+		&s.Histogram.Star1, &s.Histogram.Star2, &s.Histogram.Star3, &s.Histogram.Star4, &s.Histogram.Star5)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetRatingDrifts - Các sản phẩm có avg_rating / rating_count / histogram lệch so với review đã duyệt
+func (pr *productReviewRepo) GetRatingDrifts() ([]model.RatingDrift, error) {
+	rows, err := pr.DB.Query(`
+		SELECT p.id, p.avg_rating, p.rating_count, COALESCE(r.avg_rating, 0), COALESCE(r.rating_count, 0)
+		FROM products p
+		LEFT JOIN (
+			SELECT product_id, ROUND(AVG(rating), 2) AS avg_rating, COUNT(*) AS rating_count,
+				SUM(rating = 1) AS s1, SUM(rating = 2) AS s2, SUM(rating = 3) AS s3, SUM(rating = 4) AS s4, SUM(rating = 5) AS s5
+			FROM product_reviews
+			WHERE status = 'approved'
+			GROUP BY product_id
+		) r ON r.product_id = p.id
+		LEFT JOIN product_rating_stats h ON h.product_id = p.id
+		WHERE p.avg_rating <> COALESCE(r.avg_rating, 0)
+			OR p.rating_count <> COALESCE(r.rating_count, 0)
+			OR COALESCE(h.star_1, 0) <> COALESCE(r.s1, 0)
+			OR COALESCE(h.star_2, 0) <> COALESCE(r.s2, 0)
+			OR COALESCE(h.star_3, 0) <> COALESCE(r.s3, 0)
+			OR COALESCE(h.star_4, 0) <> COALESCE(r.s4, 0)
+			OR COALESCE(h.star_5, 0) <> COALESCE(r.s5, 0)
+		ORDER BY p.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drifts := []model.RatingDrift{}
+	for rows.Next() {
+		var d model.RatingDrift
+		if err := rows.Scan(&d.ProductID, &d.OldAvgRating, &d.OldRatingCount, &d.NewAvgRating, &d.NewRatingCount); err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, d)
+	}
+	return drifts, rows.Err()
+}
+
+// RefreshProductRating - Tính lại rating của 1 sản phẩm từ review đã duyệt
+func (pr *productReviewRepo) RefreshProductRating(productID int64) error {
+	return pr.withRatingRefresh(productID, nil)
+}
+
+func (pr *productReviewRepo) DeleteReviewByID(reviewID int64) error {
+	productID, err := pr.productIDOfReview(reviewID)
+	if err != nil {
+		return err
+	}
+	return pr.withRatingRefresh(productID, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`DELETE FROM product_reviews WHERE id = ?`, reviewID)
+		return err
+	})
+}
+
+func (pr *productReviewRepo) GetReviewByID(reviewID int64) (*model.ProductReview, error) {
+	var r model.ProductReview
+	row := pr.DB.QueryRow(`SELECT `+reviewColumns+` FROM product_reviews r WHERE r.id = ?`, reviewID)
+	if err := scanReview(row, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetReviewByUserAndProduct - Mỗi user chỉ có 1 review cho 1 sản phẩm (trả về sql.ErrNoRows nếu chưa có)
+func (pr *productReviewRepo) GetReviewByUserAndProduct(userID, productID int64) (*model.ProductReview, error) {
+	var r model.ProductReview
+	row := pr.DB.QueryRow(`SELECT `+reviewColumns+` FROM product_reviews r WHERE r.user_id = ? AND r.product_id = ?`, userID, productID)
+	if err := scanReview(row, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// UpdateReviewContent - Sửa nội dung review, đưa về trạng thái chờ duyệt lại
+func (pr *productReviewRepo) UpdateReviewContent(reviewID int64, body *string, rating int) error {
+	productID, err := pr.productIDOfReview(reviewID)
+	if err != nil {
+		return err
+	}
+	return pr.withRatingRefresh(productID, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			UPDATE product_reviews
+			SET body = ?, rating = ?, status = 'pending', moderated_by = NULL, moderated_at = NULL, moderation_note = NULL, updated_at = NOW()
+			WHERE id = ?`, body, rating, reviewID)
+		return err
+	})
+}
+
+// ModerateReview - Admin duyệt / từ chối review
+func (pr *productReviewRepo) ModerateReview(reviewID int64, status string, adminID *int64, note *string) error {
+	productID, err := pr.productIDOfReview(reviewID)
+	if err != nil {
+		return err
+	}
+	return pr.withRatingRefresh(productID, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			UPDATE product_reviews
+			SET status = ?, moderated_by = ?, moderated_at = NOW(), moderation_note = ?
+			WHERE id = ?`, status, adminID, note, reviewID)
+		return err
+	})
+}
+
+// GetReviewsByStatus - Hàng đợi kiểm duyệt (cũ nhất trước), có phân trang
+func (pr *productReviewRepo) GetReviewsByStatus(status string, limit, offset int) ([]model.ProductReview, int, error) {
+	var total int
+	if err := pr.DB.QueryRow(`SELECT COUNT(*) FROM product_reviews WHERE status = ?`, status).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := pr.DB.Query(`
+		SELECT `+reviewColumns+`
+		FROM product_reviews r
+		WHERE r.status = ?
+		ORDER BY r.created_at ASC, r.id ASC
+		LIMIT ? OFFSET ?`, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	reviews, err := scanReviews(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+// ListApprovedReviews - Danh sách review đã duyệt theo keyset (cursor), kèm username người review.
+// Trả về tối đa limit+1 dòng để controller biết còn trang sau hay không.
+func (pr *productReviewRepo) ListApprovedReviews(productID int64, q model.ReviewListQuery) ([]model.ProductReview, error) {
+	where := []string{"r.product_id = ?", "r.status = 'approved'"}
+	args := []any{productID}
+
+	if q.Rating > 0 {
+		where = append(where, "r.rating = ?")
+		args = append(args, q.Rating)
+	}
+	if q.WithText {
+		where = append(where, "r.body IS NOT NULL AND TRIM(r.body) <> ''")
+	}
+
+	// Thứ tự luôn kết thúc bằng (created_at, id) DESC để khóa sắp xếp là duy nhất
+	var orderBy string
+	switch q.Sort {
+	case model.ReviewSortHighest:
+		orderBy = "r.rating DESC, r.created_at DESC, r.id DESC"
+		if c := q.After; c != nil {
+			where = append(where, "(r.rating, r.created_at, r.id) < (?, ?, ?)")
+			args = append(args, c.Rating, c.CreatedAt, c.ID)
+		}
+	case model.ReviewSortLowest:
+		orderBy = "r.rating ASC, r.created_at DESC, r.id DESC"
+		if c := q.After; c != nil {
+			where = append(where, "(r.rating > ? OR (r.rating = ? AND (r.created_at, r.id) < (?, ?)))")
+			args = append(args, c.Rating, c.Rating, c.CreatedAt, c.ID)
+		}
+	case model.ReviewSortHelpful:
+		orderBy = "r.helpful_count DESC, r.created_at DESC, r.id DESC"
+		if c := q.After; c != nil {
+			where = append(where, "(r.helpful_count, r.created_at, r.id) < (?, ?, ?)")
+			args = append(args, c.HelpfulCount, c.CreatedAt, c.ID)
+		}
+	default:
+		orderBy = "r.created_at DESC, r.id DESC"
+		if c := q.After; c != nil {
+			where = append(where, "(r.created_at, r.id) < (?, ?)")
+			args = append(args, c.CreatedAt, c.ID)
+		}
+	}
+	args = append(args, q.Limit+1)
+
+	rows, err := pr.DB.Query(`
+		SELECT `+reviewColumns+`, u.username
+		FROM product_reviews r
+		LEFT JOIN users u ON u.id = r.user_id
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+orderBy+`
+		LIMIT ?`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -51,36 +345,103 @@ func (pr *productReviewRepo) GetProductReviewsByProductID(productID int64) ([]mo
 	reviews := []model.ProductReview{}
 	for rows.Next() {
 		var r model.ProductReview
-		if err := rows.Scan(&r.ID, &r.ProductID, &r.UserID, &r.Rating, &r.Body, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		if err := scanReview(rows, &r, &r.Username); err != nil {
 			return nil, err
 		}
 		reviews = append(reviews, r)
 	}
-
-	return reviews, nil
+	return reviews, rows.Err()
 }
 
-func (pr *productReviewRepo) GetAverageRatingByProductID(productID int64) (float64, error) {
-	var avg sql.NullFloat64
-	err := pr.DB.QueryRow(`SELECT AVG(rating) FROM product_reviews WHERE product_id = ?`, productID).Scan(&avg)
+// AddHelpfulVote - User đánh dấu review hữu ích (ErrAlreadyVoted nếu đã vote)
+func (pr *productReviewRepo) AddHelpfulVote(reviewID, userID int64) (int, error) {
+	tx, err := pr.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT IGNORE INTO review_votes (review_id, user_id, vote_type) VALUES (?, ?, 'helpful')`, reviewID, userID)
 	if err != nil {
 		return 0, err
 	}
-	if !avg.Valid {
-		return 0, nil
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, ErrAlreadyVoted
 	}
-	return avg.Float64, nil
+
+	if _, err := tx.Exec(`UPDATE product_reviews SET helpful_count = helpful_count + 1, updated_at = updated_at WHERE id = ?`, reviewID); err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := tx.QueryRow(`SELECT helpful_count FROM product_reviews WHERE id = ?`, reviewID).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, tx.Commit()
 }
 
-func (pr *productReviewRepo) GetCountRatingByProductID(productID int64) (int64, error) {
-	var count int64
-	if err := pr.DB.QueryRow(`SELECT COUNT(*) FROM product_reviews WHERE product_id = ?`, productID).Scan(&count); err != nil {
+// RemoveHelpfulVote - Bỏ đánh dấu hữu ích (sql.ErrNoRows nếu chưa vote)
+func (pr *productReviewRepo) RemoveHelpfulVote(reviewID, userID int64) (int, error) {
+	tx, err := pr.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM review_votes WHERE review_id = ? AND user_id = ? AND vote_type = 'helpful'`, reviewID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, sql.ErrNoRows
+	}
+
+	if _, err := tx.Exec(`UPDATE product_reviews SET helpful_count = GREATEST(helpful_count - 1, 0), updated_at = updated_at WHERE id = ?`, reviewID); err != nil {
 		return 0, err
 	}
-	return count, nil
+
+	var count int
+	if err := tx.QueryRow(`SELECT helpful_count FROM product_reviews WHERE id = ?`, reviewID).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, tx.Commit()
 }
 
-func (pr *productReviewRepo) DeleteReviewByID(reviewID int64) error {
-	_, err := pr.DB.Exec(`DELETE FROM product_reviews WHERE id = ?`, reviewID)
-	return err
+// AddReport - User báo cáo review. Khi số báo cáo chạm ngưỡng, review đang approved bị đưa về hàng đợi kiểm duyệt
+// (cập nhật rating của sản phẩm trong cùng transaction). Trả về true nếu review vừa bị gắn cờ.
+func (pr *productReviewRepo) AddReport(reviewID, userID int64, reason string, threshold int) (bool, error) {
+	productID, err := pr.productIDOfReview(reviewID)
+	if err != nil {
+		return false, err
+	}
+
+	flagged := false
+	err = pr.withRatingRefresh(productID, func(tx *sql.Tx) error {
+		res, err := tx.Exec(`INSERT IGNORE INTO review_votes (review_id, user_id, vote_type, reason) VALUES (?, ?, 'report', ?)`,
+			reviewID, userID, reason)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrAlreadyVoted
+		}
+
+		if _, err := tx.Exec(`UPDATE product_reviews SET report_count = report_count + 1, updated_at = updated_at WHERE id = ?`, reviewID); err != nil {
+			return err
+		}
+
+		// Chỉ gắn cờ đúng lúc vượt ngưỡng, review đã được admin duyệt lại sẽ không bị gắn cờ lặp lại
+		res, err = tx.Exec(`
+			UPDATE product_reviews
+			SET status = 'pending', moderated_by = NULL, moderated_at = NULL,
+				moderation_note = CONCAT('Auto-flagged: ', report_count, ' reports'), updated_at = updated_at
+			WHERE id = ? AND status = 'approved' AND report_count = ?`, reviewID, threshold)
+		if err != nil {
+			return err
+		}
+		n, _ := res.RowsAffected()
+		flagged = n > 0
+		return nil
+	})
+	return flagged, err
 }
@@ -0,0 +1,38 @@
+package returns
+
+import (
+	"context"
+	"golang/internal/model"
+)
+
+type ReturnRepository interface {
+	// Tạo yêu cầu trả hàng kèm sản phẩm + ảnh, ghi lịch sử "requested" (1 transaction).
+	// Khóa đơn hàng để kiểm tra số lượng còn được trả của từng dòng (ErrItemNotInOrder / ErrQuantityExceeded)
+	CreateReturn(ctx context.Context, ret *model.ReturnRequest) error
+
+	// Chi tiết yêu cầu kèm sản phẩm + ảnh (sql.ErrNoRows nếu không có)
+	GetReturnByID(ctx context.Context, id int64) (*model.ReturnRequest, error)
+
+	// Danh sách yêu cầu có phân trang & lọc (mới nhất lên đầu, không kèm sản phẩm / ảnh)
+	GetReturns(ctx context.Context, filter model.ReturnFilter) ([]model.ReturnRequest, int, error)
+
+	// Lịch sử đổi trạng thái
+	GetStatusHistory(ctx context.Context, returnID int64) ([]model.ReturnStatusHistory, error)
+
+	// Ảnh thuộc yêu cầu trả hàng (sql.ErrNoRows nếu không có)
+	GetPhoto(ctx context.Context, returnID int64, photoID int64) (*model.ReturnPhoto, error)
+
+	// Đổi trạng thái from -> to (approved / rejected / cancelled) + ghi lịch sử, ErrInvalidTransition nếu trạng thái hiện tại khác from
+	UpdateStatus(ctx context.Context, returnID int64, from string, to string, changedBy *int64, note string) error
+
+	// Nhận hàng trả về: approved -> received, cộng order_items.returned_quantity (restocked = false)
+	MarkReceived(ctx context.Context, returnID int64, changedBy *int64, note string) error
+
+	// Đánh dấu đã cộng lại tồn kho (chỉ gọi sau khi điều chỉnh tồn kho thành công)
+	MarkRestocked(ctx context.Context, returnID int64) error
+
+	// Hoàn tiền: received -> refunded, ghi order_payments (status refunded) + cập nhật payment_status của đơn.
+	// Đơn đã trả hết sản phẩm thì chuyển sang refunded (ghi order_status_history).
+	// amount vượt refund_amount của yêu cầu chỉ được chấp nhận khi override = true
+	Refund(ctx context.Context, returnID int64, amount float64, override bool, changedBy *int64, note string) (*model.ReturnRefundResult, error)
+}
@@ -0,0 +1,514 @@
+package returns
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang/internal/logger"
+	"golang/internal/model"
+)
+
+var (
+	ErrItemNotInOrder    = errors.New("sản phẩm không thuộc đơn hàng")
+	ErrQuantityExceeded  = errors.New("số lượng trả vượt quá số lượng còn được trả")
+	ErrInvalidTransition = errors.New("không thể chuyển trạng thái yêu cầu trả hàng")
+	ErrOrderNotPaid      = errors.New("đơn hàng chưa thanh toán, không thể hoàn tiền")
+	ErrRefundExceeded    = errors.New("số tiền hoàn vượt quá số tiền còn lại của đơn hàng")
+	ErrRefundAboveReturn = errors.New("số tiền hoàn vượt quá giá trị yêu cầu trả hàng")
+)
+
+// Cột thời gian ghi lại khi chuyển sang trạng thái tương ứng
+var statusTimeColumns = map[string]string{
+	model.ReturnStatusApproved:  "approved_at",
+	model.ReturnStatusRejected:  "rejected_at",
+	model.ReturnStatusReceived:  "received_at",
+	model.ReturnStatusRefunded:  "refunded_at",
+	model.ReturnStatusCancelled: "cancelled_at",
+}
+
+const returnColumns = `
+	rr.id, rr.return_number, rr.order_id, o.order_number, rr.user_id, rr.status, rr.reason,
+	rr.refund_amount, rr.refunded_amount, rr.restocked, rr.requested_at, rr.approved_at, rr.rejected_at,
+	rr.received_at, rr.refunded_at, rr.cancelled_at, rr.updated_at`
+
+type returnRepository struct {
+	db *sql.DB
+}
+
+func NewReturnRepository(db *sql.DB) ReturnRepository {
+	return &returnRepository{db: db}
+}
+
+func scanReturn(row interface{ Scan(...interface{}) error }) (*model.ReturnRequest, error) {
+	var ret model.ReturnRequest
+	err := row.Scan(&ret.ID, &ret.ReturnNumber, &ret.OrderID, &ret.OrderNumber, &ret.UserID, &ret.Status, &ret.Reason,
+		&ret.RefundAmount, &ret.RefundedAmount, &ret.Restocked, &ret.RequestedAt, &ret.ApprovedAt, &ret.RejectedAt,
+		&ret.ReceivedAt, &ret.RefundedAt, &ret.CancelledAt, &ret.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func insertHistory(ctx context.Context, tx *sql.Tx, returnID int64, from *string, to string, changedBy *int64, note string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO return_status_history (return_id, from_status, to_status, changed_by, note)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''))`,
+		returnID, from, to, changedBy, note)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Insert return history for ReturnID %d failed: %v", returnID, err)
+	}
+	return err
+}
+
+// lockReturn: Khóa yêu cầu trả hàng, trạng thái hiện tại phải là expected
+func lockReturn(ctx context.Context, tx *sql.Tx, returnID int64, expected string) (*model.ReturnRequest, error) {
+	var ret model.ReturnRequest
+	err := tx.QueryRowContext(ctx, `
+		SELECT id, return_number, order_id, status, refund_amount
+		FROM return_requests WHERE id = ? FOR UPDATE`, returnID,
+	).Scan(&ret.ID, &ret.ReturnNumber, &ret.OrderID, &ret.Status, &ret.RefundAmount)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != expected {
+		return nil, fmt.Errorf("%w (đang ở trạng thái %s)", ErrInvalidTransition, ret.Status)
+	}
+	return &ret, nil
+}
+
+// Tạo yêu cầu trả hàng
+func (r *returnRepository) CreateReturn(ctx context.Context, ret *model.ReturnRequest) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Khóa đơn hàng: các yêu cầu trả hàng của cùng 1 đơn tạo lần lượt, không trả quá số lượng đã mua
+	var orderID int64
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM orders WHERE id = ? FOR UPDATE", ret.OrderID).Scan(&orderID); err != nil {
+		logger.ErrorLogger.Printf("Repo: CreateReturn lock order %d failed: %v", ret.OrderID, err)
+		return err
+	}
+
+	// Số lượng còn được trả = đã mua - đã nằm trong các yêu cầu chưa bị từ chối / hủy
+	ret.RefundAmount = 0
+	for i := range ret.Items {
+		item := &ret.Items[i]
+		var bought, used int
+		err := tx.QueryRowContext(ctx, `
+			SELECT oi.quantity, oi.unit_price,
+			       COALESCE((SELECT SUM(ri.quantity) FROM return_items ri
+			                 JOIN return_requests rr ON rr.id = ri.return_id
+			                 WHERE ri.order_item_id = oi.id AND rr.status NOT IN (?, ?)), 0)
+			FROM order_items oi
+			WHERE oi.id = ? AND oi.order_id = ?`,
+			model.ReturnStatusRejected, model.ReturnStatusCancelled, item.OrderItemID, ret.OrderID,
+		).Scan(&bought, &item.UnitPrice, &used)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w (order_item_id %d)", ErrItemNotInOrder, item.OrderItemID)
+		}
+		if err != nil {
+			logger.ErrorLogger.Printf("Repo: CreateReturn check item %d failed: %v", item.OrderItemID, err)
+			return err
+		}
+		if item.Quantity > bought-used {
+			return fmt.Errorf("%w (order_item_id %d còn %d)", ErrQuantityExceeded, item.OrderItemID, max(bought-used, 0))
+		}
+		item.LineAmount = item.UnitPrice * float64(item.Quantity)
+		ret.RefundAmount += item.LineAmount
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO return_requests (return_number, order_id, user_id, status, reason, refund_amount)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		ret.ReturnNumber, ret.OrderID, ret.UserID, ret.Status, ret.Reason, ret.RefundAmount)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: CreateReturn insert request failed: %v", err)
+		return err
+	}
+	if ret.ID, err = res.LastInsertId(); err != nil {
+		return err
+	}
+
+	for i := range ret.Items {
+		item := &ret.Items[i]
+		item.ReturnID = ret.ID
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO return_items (return_id, order_item_id, quantity, unit_price, line_amount)
+			VALUES (?, ?, ?, ?, ?)`,
+			item.ReturnID, item.OrderItemID, item.Quantity, item.UnitPrice, item.LineAmount)
+		if err != nil {
+			logger.ErrorLogger.Printf("Repo: CreateReturn insert item failed: %v", err)
+			return err
+		}
+		if item.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	}
+
+	for i := range ret.Photos {
+		p := &ret.Photos[i]
+		p.ReturnID = ret.ID
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO return_photos (return_id, file_name, content_type, size_bytes, storage_key)
+			VALUES (?, ?, ?, ?, ?)`,
+			p.ReturnID, p.FileName, p.ContentType, p.SizeBytes, p.StorageKey)
+		if err != nil {
+			logger.ErrorLogger.Printf("Repo: CreateReturn insert photo failed: %v", err)
+			return err
+		}
+		if p.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	}
+
+	userID := ret.UserID
+	if err := insertHistory(ctx, tx, ret.ID, nil, ret.Status, &userID, ""); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.ErrorLogger.Printf("Repo: CreateReturn commit failed: %v", err)
+		return err
+	}
+	now := time.Now()
+	ret.RequestedAt = now
+	ret.UpdatedAt = now
+	return nil
+}
+
+// Chi tiết yêu cầu trả hàng
+func (r *returnRepository) GetReturnByID(ctx context.Context, id int64) (*model.ReturnRequest, error) {
+	ret, err := scanReturn(r.db.QueryRowContext(ctx, `
+		SELECT `+returnColumns+`
+		FROM return_requests rr
+		JOIN orders o ON o.id = rr.order_id
+		WHERE rr.id = ?`, id))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.ErrorLogger.Printf("Repo: GetReturnByID %d failed: %v", id, err)
+		}
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT ri.id, ri.return_id, ri.order_item_id, oi.product_id, oi.variant_id, COALESCE(oi.sku, ''), COALESCE(oi.title, ''),
+		       ri.quantity, ri.unit_price, ri.line_amount
+		FROM return_items ri
+		JOIN order_items oi ON oi.id = ri.order_item_id
+		WHERE ri.return_id = ?
+		ORDER BY ri.id ASC`, id)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: GetReturnByID items failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	ret.Items = []model.ReturnItem{}
+	for rows.Next() {
+		var i model.ReturnItem
+		if err := rows.Scan(&i.ID, &i.ReturnID, &i.OrderItemID, &i.ProductID, &i.VariantID, &i.SKU, &i.Title,
+			&i.Quantity, &i.UnitPrice, &i.LineAmount); err != nil {
+			return nil, err
+		}
+		ret.Items = append(ret.Items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	photoRows, err := r.db.QueryContext(ctx, `
+		SELECT id, return_id, file_name, content_type, size_bytes, storage_key, created_at
+		FROM return_photos WHERE return_id = ?
+		ORDER BY id ASC`, id)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: GetReturnByID photos failed: %v", err)
+		return nil, err
+	}
+	defer photoRows.Close()
+	ret.Photos = []model.ReturnPhoto{}
+	for photoRows.Next() {
+		var p model.ReturnPhoto
+		if err := photoRows.Scan(&p.ID, &p.ReturnID, &p.FileName, &p.ContentType, &p.SizeBytes, &p.StorageKey, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		ret.Photos = append(ret.Photos, p)
+	}
+	return ret, photoRows.Err()
+}
+
+// Danh sách yêu cầu trả hàng
+func (r *returnRepository) GetReturns(ctx context.Context, filter model.ReturnFilter) ([]model.ReturnRequest, int, error) {
+	var where []string
+	var args []interface{}
+	if filter.Status != "" {
+		where = append(where, "rr.status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.OrderID > 0 {
+		where = append(where, "rr.order_id = ?")
+		args = append(args, filter.OrderID)
+	}
+	if filter.UserID > 0 {
+		where = append(where, "rr.user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM return_requests rr"+whereSQL, args...).Scan(&total); err != nil {
+		logger.ErrorLogger.Printf("Repo: GetReturns count failed: %v", err)
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT ` + returnColumns + `
+		FROM return_requests rr
+		JOIN orders o ON o.id = rr.order_id` + whereSQL + `
+		ORDER BY rr.requested_at DESC, rr.id DESC
+		LIMIT ? OFFSET ?`
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: GetReturns failed: %v", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	returns := []model.ReturnRequest{}
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		returns = append(returns, *ret)
+	}
+	return returns, total, rows.Err()
+}
+
+// Lịch sử đổi trạng thái
+func (r *returnRepository) GetStatusHistory(ctx context.Context, returnID int64) ([]model.ReturnStatusHistory, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, return_id, from_status, to_status, changed_by, COALESCE(note, ''), created_at
+		FROM return_status_history WHERE return_id = ?
+		ORDER BY id ASC`, returnID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: GetStatusHistory failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	histories := []model.ReturnStatusHistory{}
+	for rows.Next() {
+		var h model.ReturnStatusHistory
+		if err := rows.Scan(&h.ID, &h.ReturnID, &h.FromStatus, &h.ToStatus, &h.ChangedBy, &h.Note, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		histories = append(histories, h)
+	}
+	return histories, rows.Err()
+}
+
+// Ảnh thuộc yêu cầu trả hàng
+func (r *returnRepository) GetPhoto(ctx context.Context, returnID int64, photoID int64) (*model.ReturnPhoto, error) {
+	var p model.ReturnPhoto
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, return_id, file_name, content_type, size_bytes, storage_key, created_at
+		FROM return_photos WHERE id = ? AND return_id = ?`, photoID, returnID,
+	).Scan(&p.ID, &p.ReturnID, &p.FileName, &p.ContentType, &p.SizeBytes, &p.StorageKey, &p.CreatedAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.ErrorLogger.Printf("Repo: GetPhoto failed: %v", err)
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Đổi trạng thái (duyệt / từ chối / hủy)
+func (r *returnRepository) UpdateStatus(ctx context.Context, returnID int64, from string, to string, changedBy *int64, note string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockReturn(ctx, tx, returnID, from); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE return_requests SET status = ?, "+statusTimeColumns[to]+" = NOW() WHERE id = ?", to, returnID); err != nil {
+		logger.ErrorLogger.Printf("Repo: UpdateStatus ReturnID %d failed: %v", returnID, err)
+		return err
+	}
+	if err := insertHistory(ctx, tx, returnID, &from, to, changedBy, note); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Nhận hàng trả về
+func (r *returnRepository) MarkReceived(ctx context.Context, returnID int64, changedBy *int64, note string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	from := model.ReturnStatusApproved
+	if _, err := lockReturn(ctx, tx, returnID, from); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE return_requests SET status = ?, received_at = NOW(), restocked = FALSE WHERE id = ?`,
+		model.ReturnStatusReceived, returnID); err != nil {
+		logger.ErrorLogger.Printf("Repo: MarkReceived ReturnID %d failed: %v", returnID, err)
+		return err
+	}
+
+	// Đơn hàng ghi nhận số lượng đã trả của từng dòng
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE order_items oi
+		JOIN return_items ri ON ri.order_item_id = oi.id
+		SET oi.returned_quantity = oi.returned_quantity + ri.quantity
+		WHERE ri.return_id = ?`, returnID); err != nil {
+		logger.ErrorLogger.Printf("Repo: MarkReceived update order_items failed: %v", err)
+		return err
+	}
+
+	if err := insertHistory(ctx, tx, returnID, &from, model.ReturnStatusReceived, changedBy, note); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Đánh dấu đã cộng lại tồn kho
+func (r *returnRepository) MarkRestocked(ctx context.Context, returnID int64) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE return_requests SET restocked = TRUE WHERE id = ?`, returnID); err != nil {
+		logger.ErrorLogger.Printf("Repo: MarkRestocked ReturnID %d failed: %v", returnID, err)
+		return err
+	}
+	return nil
+}
+
+// Hoàn tiền
+func (r *returnRepository) Refund(ctx context.Context, returnID int64, amount float64, override bool, changedBy *int64, note string) (*model.ReturnRefundResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	from := model.ReturnStatusReceived
+	ret, err := lockReturn(ctx, tx, returnID, from)
+	if err != nil {
+		return nil, err
+	}
+	// Không hoàn quá giá trị yêu cầu trả hàng, trừ khi admin chủ động xác nhận (ghi rõ vào lịch sử)
+	if amount-ret.RefundAmount > 0.005 {
+		if !override {
+			return nil, fmt.Errorf("%w (tối đa %.0f)", ErrRefundAboveReturn, ret.RefundAmount)
+		}
+		note = fmt.Sprintf("Hoàn vượt giá trị yêu cầu (%.0f / %.0f): %s", amount, ret.RefundAmount, note)
+	}
+
+	var totalAmount float64
+	var orderStatus, paymentStatus string
+	if err := tx.QueryRowContext(ctx, `
+		SELECT total_amount, status, payment_status FROM orders WHERE id = ? FOR UPDATE`, ret.OrderID,
+	).Scan(&totalAmount, &orderStatus, &paymentStatus); err != nil {
+		logger.ErrorLogger.Printf("Repo: Refund lock order %d failed: %v", ret.OrderID, err)
+		return nil, err
+	}
+	if paymentStatus != model.PaymentStatusPaid && paymentStatus != model.PaymentStatusPartiallyRefunded {
+		return nil, ErrOrderNotPaid
+	}
+
+	var refunded float64
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM order_payments WHERE order_id = ? AND status = ?`,
+		ret.OrderID, model.PaymentTransStatusRefunded,
+	).Scan(&refunded); err != nil {
+		return nil, err
+	}
+	// Làm tròn đến đồng (DECIMAL(12,2)) để tránh sai số float
+	if amount-(totalAmount-refunded) > 0.005 {
+		return nil, fmt.Errorf("%w (còn %.0f)", ErrRefundExceeded, max(totalAmount-refunded, 0))
+	}
+
+	// Hoàn tiền theo phương thức thanh toán lúc tạo đơn
+	method := "UNKNOWN"
+	err = tx.QueryRowContext(ctx, "SELECT method FROM order_payments WHERE order_id = ? ORDER BY id ASC LIMIT 1", ret.OrderID).Scan(&method)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO order_payments (order_id, method, amount, status, paid_at)
+		VALUES (?, ?, ?, ?, NOW())`,
+		ret.OrderID, method, amount, model.PaymentTransStatusRefunded); err != nil {
+		logger.ErrorLogger.Printf("Repo: Refund insert order_payments failed: %v", err)
+		return nil, err
+	}
+
+	result := &model.ReturnRefundResult{
+		PaymentStatus: model.PaymentStatusPartiallyRefunded,
+		TotalRefunded: refunded + amount,
+	}
+	if result.TotalRefunded >= totalAmount-0.005 {
+		result.PaymentStatus = model.PaymentStatusRefunded
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE orders SET payment_status = ?, updated_at = NOW() WHERE id = ?",
+		result.PaymentStatus, ret.OrderID); err != nil {
+		logger.ErrorLogger.Printf("Repo: Refund update order payment_status failed: %v", err)
+		return nil, err
+	}
+
+	// Toàn bộ sản phẩm của đơn đã được trả -> đơn chuyển sang refunded
+	var remaining int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM order_items WHERE order_id = ? AND returned_quantity < quantity`, ret.OrderID,
+	).Scan(&remaining); err != nil {
+		return nil, err
+	}
+	if remaining == 0 && orderStatus != model.OrderStatusRefunded {
+		if _, err := tx.ExecContext(ctx, "UPDATE orders SET status = ?, updated_at = NOW() WHERE id = ?",
+			model.OrderStatusRefunded, ret.OrderID); err != nil {
+			logger.ErrorLogger.Printf("Repo: Refund update order status failed: %v", err)
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO order_status_history (order_id, from_status, to_status, changed_by, note)
+			VALUES (?, ?, ?, ?, ?)`,
+			ret.OrderID, orderStatus, model.OrderStatusRefunded, changedBy, "Trả hàng "+ret.ReturnNumber); err != nil {
+			logger.ErrorLogger.Printf("Repo: Refund insert order history failed: %v", err)
+			return nil, err
+		}
+		result.OrderRefunded = true
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE return_requests SET status = ?, refunded_amount = ?, refunded_at = NOW() WHERE id = ?`,
+		model.ReturnStatusRefunded, amount, returnID); err != nil {
+		logger.ErrorLogger.Printf("Repo: Refund update return failed: %v", err)
+		return nil, err
+	}
+	if err := insertHistory(ctx, tx, returnID, &from, model.ReturnStatusRefunded, changedBy, note); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.ErrorLogger.Printf("Repo: Refund commit failed: %v", err)
+		return nil, err
+	}
+	return result, nil
+}
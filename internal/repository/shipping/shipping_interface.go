@@ -0,0 +1,37 @@
+package shipping
+
+import (
+	"context"
+	"golang/internal/model"
+)
+
+type ShippingRepository interface {
+	// Danh sách vùng giao hàng (kèm phương thức)
+	GetZones(ctx context.Context) ([]model.ShippingZone, error)
+
+	// Lấy 1 vùng theo ID (kèm phương thức), trả về sql.ErrNoRows nếu không có
+	GetZoneByID(ctx context.Context, zoneID int64) (*model.ShippingZone, error)
+
+	CreateZone(ctx context.Context, zone *model.ShippingZone) error
+
+	// Cập nhật vùng
+	UpdateZone(ctx context.Context, zone *model.ShippingZone) error
+
+	// Xóa vùng và các phương thức của vùng (trả về sql.ErrNoRows nếu không có)
+	DeleteZone(ctx context.Context, zoneID int64) error
+
+	// Tìm vùng đang hoạt động khớp địa chỉ nhất (city > state > country, rồi đến priority), nil nếu không có
+	MatchZone(ctx context.Context, dest model.ShippingDestination) (*model.ShippingZone, error)
+
+	// Lấy phương thức theo ID (kèm bậc khối lượng), trả về sql.ErrNoRows nếu không có
+	GetMethodByID(ctx context.Context, methodID int64) (*model.ShippingMethod, error)
+
+	// Tạo phương thức kèm bậc khối lượng (1 transaction)
+	CreateMethod(ctx context.Context, method *model.ShippingMethod) error
+
+	// Cập nhật phương thức và ghi đè toàn bộ bậc khối lượng (1 transaction)
+	UpdateMethod(ctx context.Context, method *model.ShippingMethod) error
+
+	// Xóa phương thức (trả về sql.ErrNoRows nếu không có)
+	DeleteMethod(ctx context.Context, methodID int64) error
+}
@@ -0,0 +1,320 @@
+package shipping
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"golang/internal/logger"
+	"golang/internal/model"
+)
+
+type shippingRepository struct {
+	db *sql.DB
+}
+
+func NewShippingRepository(db *sql.DB) ShippingRepository {
+	return &shippingRepository{db: db}
+}
+
+const zoneColumns = `id, name, country, state, city, province_code, priority, is_active, created_at, updated_at`
+
+const methodColumns = `id, zone_id, code, name, rate_type, flat_fee, free_threshold, is_active, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanZone(row rowScanner) (model.ShippingZone, error) {
+	var z model.ShippingZone
+	err := row.Scan(&z.ID, &z.Name, &z.Country, &z.State, &z.City, &z.ProvinceCode, &z.Priority, &z.IsActive, &z.CreatedAt, &z.UpdatedAt)
+	return z, err
+}
+
+func scanMethod(row rowScanner) (model.ShippingMethod, error) {
+	var m model.ShippingMethod
+	err := row.Scan(&m.ID, &m.ZoneID, &m.Code, &m.Name, &m.RateType, &m.FlatFee, &m.FreeThreshold, &m.IsActive, &m.CreatedAt, &m.UpdatedAt)
+	return m, err
+}
+
+// loadMethods: Gắn phương thức + bậc khối lượng vào các vùng
+func (r *shippingRepository) loadMethods(ctx context.Context, zones []model.ShippingZone) error {
+	if len(zones) == 0 {
+		return nil
+	}
+
+	zoneIndex := make(map[int64]int, len(zones))
+	placeholders := make([]string, 0, len(zones))
+	args := make([]interface{}, 0, len(zones))
+	for i, z := range zones {
+		zoneIndex[z.ID] = i
+		placeholders = append(placeholders, "?")
+		args = append(args, z.ID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(
+		`SELECT %s FROM shipping_methods WHERE zone_id IN (%s) ORDER BY zone_id, code`,
+		methodColumns, strings.Join(placeholders, ",")), args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Error query shipping methods: %v", err)
+		return err
+	}
+	var methods []model.ShippingMethod
+	for rows.Next() {
+		m, err := scanMethod(rows)
+		if err != nil {
+			rows.Close()
+			logger.ErrorLogger.Printf("Repo: Error scanning shipping method: %v", err)
+			return err
+		}
+		methods = append(methods, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range methods {
+		tiers, err := r.getWeightTiers(ctx, methods[i].ID)
+		if err != nil {
+			return err
+		}
+		methods[i].WeightTiers = tiers
+		idx := zoneIndex[methods[i].ZoneID]
+		zones[idx].Methods = append(zones[idx].Methods, methods[i])
+	}
+	return nil
+}
+
+// getWeightTiers: Bậc khối lượng của phương thức, sắp xếp tăng dần
+func (r *shippingRepository) getWeightTiers(ctx context.Context, methodID int64) ([]model.ShippingWeightTier, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT max_weight_grams, fee FROM shipping_weight_tiers WHERE method_id = ? ORDER BY max_weight_grams ASC`, methodID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Error query weight tiers: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tiers []model.ShippingWeightTier
+	for rows.Next() {
+		var t model.ShippingWeightTier
+		if err := rows.Scan(&t.MaxWeightGrams, &t.Fee); err != nil {
+			logger.ErrorLogger.Printf("Repo: Error scanning weight tier: %v", err)
+			return nil, err
+		}
+		tiers = append(tiers, t)
+	}
+	return tiers, rows.Err()
+}
+
+// Danh sách vùng giao hàng
+func (r *shippingRepository) GetZones(ctx context.Context) ([]model.ShippingZone, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+zoneColumns+` FROM shipping_zones ORDER BY country, state, city, priority DESC, id`)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Error query shipping zones: %v", err)
+		return nil, err
+	}
+
+	zones := []model.ShippingZone{}
+	for rows.Next() {
+		z, err := scanZone(rows)
+		if err != nil {
+			rows.Close()
+			logger.ErrorLogger.Printf("Repo: Error scanning shipping zone: %v", err)
+			return nil, err
+		}
+		zones = append(zones, z)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadMethods(ctx, zones); err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
+
+// Lấy 1 vùng theo ID
+func (r *shippingRepository) GetZoneByID(ctx context.Context, zoneID int64) (*model.ShippingZone, error) {
+	z, err := scanZone(r.db.QueryRowContext(ctx, `SELECT `+zoneColumns+` FROM shipping_zones WHERE id = ?`, zoneID))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.ErrorLogger.Printf("Repo: Error get shipping zone %d: %v", zoneID, err)
+		}
+		return nil, err
+	}
+
+	zones := []model.ShippingZone{z}
+	if err := r.loadMethods(ctx, zones); err != nil {
+		return nil, err
+	}
+	return &zones[0], nil
+}
+
+func (r *shippingRepository) CreateZone(ctx context.Context, zone *model.ShippingZone) error {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO shipping_zones (name, country, state, city, province_code, priority, is_active) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		zone.Name, zone.Country, zone.State, zone.City, zone.ProvinceCode, zone.Priority, zone.IsActive)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to create shipping zone: %v", err)
+		return err
+	}
+	zone.ID, err = res.LastInsertId()
+	return err
+}
+
+func (r *shippingRepository) UpdateZone(ctx context.Context, zone *model.ShippingZone) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE shipping_zones SET name = ?, country = ?, state = ?, city = ?, province_code = ?, priority = ?, is_active = ? WHERE id = ?`,
+		zone.Name, zone.Country, zone.State, zone.City, zone.ProvinceCode, zone.Priority, zone.IsActive, zone.ID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to update shipping zone %d: %v", zone.ID, err)
+	}
+	return err
+}
+
+func (r *shippingRepository) DeleteZone(ctx context.Context, zoneID int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM shipping_zones WHERE id = ?`, zoneID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to delete shipping zone %d: %v", zoneID, err)
+		return err
+	}
+	return requireAffected(res)
+}
+
+// Tìm vùng khớp địa chỉ: vùng chỉ định city được ưu tiên hơn state, state hơn country
+// province_code (địa chỉ Việt Nam) khớp theo mã tỉnh / thành, cùng mức ưu tiên với state
+func (r *shippingRepository) MatchZone(ctx context.Context, dest model.ShippingDestination) (*model.ShippingZone, error) {
+	query := `
+		SELECT ` + zoneColumns + `
+		FROM shipping_zones
+		WHERE is_active = 1
+		  AND country = ?
+		  AND (state IS NULL OR state = ?)
+		  AND (city IS NULL OR city = ?)
+		  AND (province_code IS NULL OR province_code = ?)
+		ORDER BY (city IS NOT NULL) DESC, (state IS NOT NULL OR province_code IS NOT NULL) DESC, priority DESC, id ASC
+		LIMIT 1
+	`
+	z, err := scanZone(r.db.QueryRowContext(ctx, query,
+		strings.TrimSpace(dest.Country), strings.TrimSpace(dest.State), strings.TrimSpace(dest.City), strings.TrimSpace(dest.ProvinceCode)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Error matching shipping zone: %v", err)
+		return nil, err
+	}
+
+	zones := []model.ShippingZone{z}
+	if err := r.loadMethods(ctx, zones); err != nil {
+		return nil, err
+	}
+	return &zones[0], nil
+}
+
+func (r *shippingRepository) GetMethodByID(ctx context.Context, methodID int64) (*model.ShippingMethod, error) {
+	m, err := scanMethod(r.db.QueryRowContext(ctx, `SELECT `+methodColumns+` FROM shipping_methods WHERE id = ?`, methodID))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.ErrorLogger.Printf("Repo: Error get shipping method %d: %v", methodID, err)
+		}
+		return nil, err
+	}
+
+	m.WeightTiers, err = r.getWeightTiers(ctx, m.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *shippingRepository) CreateMethod(ctx context.Context, method *model.ShippingMethod) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO shipping_methods (zone_id, code, name, rate_type, flat_fee, free_threshold, is_active)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		method.ZoneID, method.Code, method.Name, method.RateType, method.FlatFee, method.FreeThreshold, method.IsActive)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to create shipping method: %v", err)
+		return err
+	}
+	if method.ID, err = res.LastInsertId(); err != nil {
+		return err
+	}
+
+	if err := insertWeightTiers(ctx, tx, method.ID, method.WeightTiers); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *shippingRepository) UpdateMethod(ctx context.Context, method *model.ShippingMethod) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE shipping_methods
+		SET code = ?, name = ?, rate_type = ?, flat_fee = ?, free_threshold = ?, is_active = ?
+		WHERE id = ?`,
+		method.Code, method.Name, method.RateType, method.FlatFee, method.FreeThreshold, method.IsActive, method.ID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to update shipping method %d: %v", method.ID, err)
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM shipping_weight_tiers WHERE method_id = ?`, method.ID); err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to clear weight tiers of method %d: %v", method.ID, err)
+		return err
+	}
+	if err := insertWeightTiers(ctx, tx, method.ID, method.WeightTiers); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *shippingRepository) DeleteMethod(ctx context.Context, methodID int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM shipping_methods WHERE id = ?`, methodID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to delete shipping method %d: %v", methodID, err)
+		return err
+	}
+	return requireAffected(res)
+}
+
+func insertWeightTiers(ctx context.Context, tx *sql.Tx, methodID int64, tiers []model.ShippingWeightTier) error {
+	for _, t := range tiers {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO shipping_weight_tiers (method_id, max_weight_grams, fee) VALUES (?, ?, ?)`,
+			methodID, t.MaxWeightGrams, t.Fee); err != nil {
+			logger.ErrorLogger.Printf("Repo: Failed to insert weight tier for method %d: %v", methodID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// requireAffected: UPDATE / DELETE không tác động dòng nào thì trả về sql.ErrNoRows
+func requireAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package stats
 import (
 	"context"
 	"golang/internal/model"
+	"time"
 )
 
 type IStatsRepository interface {
@@ -12,9 +13,77 @@ type IStatsRepository interface {
 	// Lấy Top sản phẩm bán chạy (Table)
 	GetTopSellingProducts(ctx context.Context, filter model.StatsFilter) ([]model.ProductSalesStatsResponse, error)
 
+	// Đếm / duyệt doanh số sản phẩm không giới hạn số dòng (dùng cho export)
+	CountProductSales(ctx context.Context, startDate, endDate string) (int64, error)
+	EachProductSales(ctx context.Context, filter model.StatsFilter, fn func(model.ProductSalesStatsResponse) error) error
+
 	//  Lấy số liệu thống kê sản phẩm 
 	GetProductStats(ctx context.Context, productID int64, filter model.StatsFilter) ([]model.ProductDailyStatsResponse, error)
 
-	//  Refresh thống kê hàng ngày
-	RunDailyStatJob(ctx context.Context) error
+	// Tính lại thống kê từng ngày trong khoảng [from, to] (idempotent: ghi đè số liệu cũ của ngày).
+	// from / to là 00:00 theo múi giờ báo cáo, ngày được chia theo location của from
+	AggregateDailyStats(ctx context.Context, from time.Time, to time.Time) error
+
+	// Các ngày (YYYY-MM-DD) trong khoảng đã có dòng tổng hợp
+	GetSummarizedDays(ctx context.Context, from time.Time, to time.Time) (map[string]bool, error)
+
+	// Số liệu sales_summary_daily trong khoảng [from, to] (YYYY-MM-DD), key theo ngày YYYY-MM-DD
+	GetDailySummaries(ctx context.Context, from string, to string) (map[string]model.RevenueMetrics, error)
+
+	// Ngày đặt đơn sớm nhất (nil nếu chưa có đơn)
+	GetFirstOrderDate(ctx context.Context) (*time.Time, error)
+
+	// Lợi nhuận gộp theo sản phẩm / biến thể / danh mục / ngày
+	GetProductMargins(ctx context.Context, filter model.MarginFilter) ([]model.ProductMarginResponse, error)
+	GetVariantMargins(ctx context.Context, filter model.MarginFilter) ([]model.VariantMarginResponse, error)
+	GetCategoryMargins(ctx context.Context, filter model.MarginFilter) ([]model.CategoryMarginResponse, error)
+	GetDailyMargins(ctx context.Context, filter model.MarginFilter) ([]model.DailyMarginResponse, error)
+
+	// Biến thể đang bán dưới giá vốn
+	GetMarginAlerts(ctx context.Context, filter model.MarginFilter) ([]model.MarginAlertResponse, error)
+
+	// Tính lại chỉ số khách hàng, điểm RFM và ma trận cohort (job hàng đêm)
+	RefreshCustomerStats(ctx context.Context, asOf time.Time) error
+
+	// Danh sách khách hàng (phân trang) / duyệt toàn bộ để export
+	SearchCustomerStats(ctx context.Context, filter model.CustomerStatsFilter) ([]model.CustomerStatsResponse, int64, error)
+	EachCustomerStats(ctx context.Context, filter model.CustomerStatsFilter, fn func(model.CustomerStatsResponse) error) error
+
+	// Tổng hợp theo phân khúc RFM
+	GetRFMSegmentSummary(ctx context.Context) ([]model.RFMSegmentSummary, error)
+
+	// Các ô cohort có dữ liệu trong khoảng tháng (YYYY-MM-DD, ngày 1 của tháng)
+	GetCohortRows(ctx context.Context, fromMonth string, toMonth string) ([]model.CohortRow, error)
+
+	// Số khách mua lặp lại trong withinDays ngày sau đơn đầu tiên
+	GetRepeatPurchase(ctx context.Context, withinDays int) (*model.RepeatPurchaseResponse, error)
+
+	// Lần tính chỉ số khách hàng gần nhất (nil nếu chưa có)
+	GetCustomerStatsComputedAt(ctx context.Context) (*time.Time, error)
+
+	// Tính lại tốc độ bán của từng biến thể (job hàng đêm)
+	RefreshInventoryForecast(ctx context.Context, today time.Time, windowDays int) error
+
+	// Báo cáo tồn kho: số biến thể theo trạng thái + danh sách
+	GetInventoryHealth(ctx context.Context, p model.InventoryHealthParams, filter model.InventoryHealthFilter) (*model.InventoryHealthSummary, []model.InventoryHealthItem, error)
+
+	// Lần tính velocity gần nhất (nil nếu chưa có)
+	GetInventoryForecastComputedAt(ctx context.Context) (*time.Time, error)
+
+	// Cảnh báo sắp hết hàng: xóa đánh dấu khi đã lên lại trên ngưỡng / lấy biến thể chưa báo / đánh dấu đã báo
+	ResetLowStockAlerts(ctx context.Context, defaultThreshold int) (int64, error)
+	GetPendingLowStockAlerts(ctx context.Context, p model.InventoryHealthParams) ([]model.InventoryHealthItem, error)
+	MarkLowStockNotified(ctx context.Context, variantIDs []int64) error
+
+	// Tính lại phễu giỏ hàng + sản phẩm bị bỏ quên cho khoảng ngày (job hàng đêm)
+	RefreshCartFunnel(ctx context.Context, from time.Time, to time.Time, windowDays int) error
+
+	// Phễu giỏ hàng theo ngày + lần tính gần nhất
+	GetCartFunnel(ctx context.Context, startDate string, endDate string) ([]model.FunnelDay, *time.Time, error)
+
+	// Sản phẩm bị bỏ lại trong giỏ nhiều nhất
+	GetAbandonedProducts(ctx context.Context, filter model.StatsFilter) ([]model.AbandonedProductResponse, error)
+
+	// Giỏ bỏ quên của user đã đồng ý nhận marketing (remarketing)
+	GetAbandonedCarts(ctx context.Context, filter model.AbandonedCartFilter) ([]model.AbandonedCartResponse, int64, error)
 }
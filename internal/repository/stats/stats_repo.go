@@ -3,6 +3,8 @@ package stats
 import (
 	"context"
 	"database/sql"
+	"math"
+	"strings"
 	"time"
 
 	"golang/internal/logger"
@@ -92,11 +94,8 @@ func (r *StatsRepository) GetDashboardOverview(ctx context.Context, filter model
 	return &resp, nil
 }
 
-// GetTopSellingProducts: Top sản phẩm bán chạy
-func (r *StatsRepository) GetTopSellingProducts(ctx context.Context, filter model.StatsFilter) ([]model.ProductSalesStatsResponse, error) {
-	logger.DebugLogger.Println("StatsRepo: GetTopSellingProducts")
-	// Query tổng hợp từ bảng product_sales_daily
-	query := `
+// productSalesQuery: Doanh số theo sản phẩm / biến thể trong khoảng ngày, bán chạy nhất trước
+const productSalesQuery = `
 		SELECT 
 			d.product_id,
 			d.variant_id,
@@ -111,9 +110,13 @@ func (r *StatsRepository) GetTopSellingProducts(ctx context.Context, filter mode
 		LEFT JOIN product_variants v ON d.variant_id = v.id
 		WHERE d.summary_date >= ? AND d.summary_date <= ?
 		GROUP BY d.product_id, d.variant_id, p.name, v.title, v.sku
-		ORDER BY total_sold DESC
-		LIMIT ?`
+		ORDER BY total_sold DESC`
 
+// GetTopSellingProducts: Top sản phẩm bán chạy
+func (r *StatsRepository) GetTopSellingProducts(ctx context.Context, filter model.StatsFilter) ([]model.ProductSalesStatsResponse, error) {
+	logger.DebugLogger.Println("StatsRepo: GetTopSellingProducts")
+	// Query tổng hợp từ bảng product_sales_daily
+	query := productSalesQuery + " LIMIT ?"
 
 	if filter.Limit <= 0 {
 		filter.Limit = 10
@@ -148,6 +151,52 @@ func (r *StatsRepository) GetTopSellingProducts(ctx context.Context, filter mode
 }
 
 
+// CountProductSales: Số dòng (sản phẩm / biến thể có phát sinh bán) trong khoảng ngày
+func (r *StatsRepository) CountProductSales(ctx context.Context, startDate, endDate string) (int64, error) {
+	query := `
+		SELECT COUNT(*) FROM (
+			SELECT 1 FROM product_sales_daily
+			WHERE summary_date >= ? AND summary_date <= ?
+			GROUP BY product_id, variant_id
+		) t`
+	var total int64
+	if err := r.db.QueryRowContext(ctx, query, startDate, endDate).Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// EachProductSales: Duyệt doanh số sản phẩm theo thứ tự bán chạy (Limit = 0 -> lấy tất cả)
+func (r *StatsRepository) EachProductSales(ctx context.Context, filter model.StatsFilter, fn func(model.ProductSalesStatsResponse) error) error {
+	logger.DebugLogger.Printf("StatsRepo: EachProductSales %+v", filter)
+	query := productSalesQuery
+	args := []interface{}{filter.StartDate, filter.EndDate}
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p model.ProductSalesStatsResponse
+		if err := rows.Scan(
+			&p.ProductID, &p.VariantID, &p.ProductName, &p.VariantTitle, &p.SKU,
+			&p.TotalUnitsSold, &p.TotalRevenue, &p.TotalOrders,
+		); err != nil {
+			return err
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // GetProductStats: Lấy số liệu thống kê sản phẩm 
 func (r *StatsRepository) GetProductStats(ctx context.Context, productID int64, filter model.StatsFilter) ([]model.ProductDailyStatsResponse, error) {
 	logger.DebugLogger.Printf("StatsRepo: GetProductStats for ID %d", productID)
@@ -195,17 +244,1153 @@ func (r *StatsRepository) GetProductStats(ctx context.Context, productID int64,
 	return stats, nil
 }
 
-// Chức năng Refresh thống kê hàng ngày
-func (r *StatsRepository) RunDailyStatJob(ctx context.Context) error {
-    logger.InfoLogger.Println("StatsRepo: Executing sp_Job_UpdateDailyStats...")
-    
-    _, err := r.db.ExecContext(ctx, "CALL sp_Job_UpdateDailyStats()")
-    
-    if err != nil {
-        logger.ErrorLogger.Printf("StatsRepo: sp_Job_UpdateDailyStats failed: %v", err)
-        return err
-    }
-    
-    logger.InfoLogger.Println("StatsRepo: sp_Job_UpdateDailyStats completed successfully")
-    return nil
-}
\ No newline at end of file
+// Số lượng / tiền hàng của dòng sau khi trừ phần khách đã trả lại (returned_quantity cộng khi nhận hàng trả về),
+// tiền hàng chia theo tỷ lệ số lượng còn lại
+const (
+	netQuantityExpr     = `(oi.quantity - oi.returned_quantity)`
+	netLineSubtotalExpr = `(oi.line_subtotal * (oi.quantity - oi.returned_quantity) / NULLIF(oi.quantity, 0))`
+)
+
+// aggregateDay: Tính lại thống kê của 1 ngày trong 1 transaction.
+// Số lượng / doanh thu / giá vốn tính theo phần chưa bị trả lại (đơn trả 1 phần vẫn giữ trạng thái cũ).
+// start là 00:00 theo múi giờ báo cáo; khoảng [start, start + 1 ngày) truyền vào SQL dưới dạng time.Time
+// để driver quy đổi đúng theo loc của kết nối, không dùng DATE() / chuỗi ngày của server.
+// Dòng sales_summary_daily luôn được ghi (kể cả ngày không có đơn) để đánh dấu ngày đã tổng hợp
+func (r *StatsRepository) aggregateDay(ctx context.Context, start time.Time) error {
+	day := start.Format("2006-01-02")
+	end := start.AddDate(0, 0, 1)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// BƯỚC 1: GMV (theo placed_at, bỏ đơn hủy / hoàn tiền)
+	var totalOrders, totalQuantity int64
+	var totalRevenue float64
+	queryGMV := `
+		SELECT COUNT(DISTINCT o.id), IFNULL(SUM(`+netQuantityExpr+`), 0), IFNULL(SUM(`+netLineSubtotalExpr+`), 0)
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		WHERE o.placed_at >= ? AND o.placed_at < ?
+		  AND o.status NOT IN ('cancelled', 'refunded')`
+	if err := tx.QueryRowContext(ctx, queryGMV, start, end).Scan(&totalOrders, &totalQuantity, &totalRevenue); err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Aggregate GMV %s failed: %v", day, err)
+		return err
+	}
+
+	// BƯỚC 2: Doanh thu thực (theo completed_at, chỉ đơn completed)
+	var realOrders int64
+	var realRevenue float64
+	queryReal := `
+		SELECT COUNT(DISTINCT o.id), IFNULL(SUM(`+netLineSubtotalExpr+`), 0)
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		WHERE o.completed_at >= ? AND o.completed_at < ?
+		  AND o.status = 'completed'`
+	if err := tx.QueryRowContext(ctx, queryReal, start, end).Scan(&realOrders, &realRevenue); err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Aggregate real revenue %s failed: %v", day, err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO sales_summary_daily (summary_date, total_orders, total_quantity, total_revenue, total_discount, real_orders, real_revenue, created_at)
+		VALUES (?, ?, ?, ?, 0, ?, ?, NOW())
+		ON DUPLICATE KEY UPDATE
+			total_orders = VALUES(total_orders),
+			total_quantity = VALUES(total_quantity),
+			total_revenue = VALUES(total_revenue),
+			real_orders = VALUES(real_orders),
+			real_revenue = VALUES(real_revenue),
+			created_at = NOW()`,
+		day, totalOrders, totalQuantity, totalRevenue, realOrders, realRevenue)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Upsert sales_summary_daily %s failed: %v", day, err)
+		return err
+	}
+
+	// BƯỚC 3: Chi tiết sản phẩm - xóa số liệu cũ của ngày rồi tính lại (sản phẩm không còn bán trong ngày cũng bị loại).
+	// Giá vốn / lợi nhuận chỉ tính trên các dòng có snapshot unit_cost, phần còn lại đếm vào uncosted_units
+	if _, err := tx.ExecContext(ctx, `DELETE FROM product_sales_daily WHERE summary_date = ?`, day); err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Clear product_sales_daily %s failed: %v", day, err)
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO product_sales_daily (product_id, variant_id, summary_date, units_sold, revenue, cost, gross_profit, uncosted_units, order_count)
+		SELECT oi.product_id, IFNULL(oi.variant_id, 0), ?,
+			IFNULL(SUM(`+netQuantityExpr+`), 0),
+			IFNULL(SUM(`+netLineSubtotalExpr+`), 0),
+			IFNULL(SUM(oi.unit_cost * `+netQuantityExpr+`), 0),
+			IFNULL(SUM(CASE WHEN oi.unit_cost IS NOT NULL THEN `+netLineSubtotalExpr+` - oi.unit_cost * `+netQuantityExpr+` END), 0),
+			IFNULL(SUM(CASE WHEN oi.unit_cost IS NULL THEN `+netQuantityExpr+` END), 0),
+			COUNT(DISTINCT o.id)
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		WHERE o.placed_at >= ? AND o.placed_at < ?
+		  AND o.status NOT IN ('cancelled', 'refunded')
+		GROUP BY oi.product_id, IFNULL(oi.variant_id, 0)`,
+		day, start, end)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Insert product_sales_daily %s failed: %v", day, err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AggregateDailyStats: Tính lại thống kê cho từng ngày trong khoảng [from, to]
+func (r *StatsRepository) AggregateDailyStats(ctx context.Context, from time.Time, to time.Time) error {
+	logger.InfoLogger.Printf("StatsRepo: Aggregating daily stats %s -> %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if err := r.aggregateDay(ctx, day); err != nil {
+			return err
+		}
+	}
+
+	logger.InfoLogger.Println("StatsRepo: Aggregate daily stats completed successfully")
+	return nil
+}
+
+// GetSummarizedDays: Các ngày đã có dòng sales_summary_daily
+func (r *StatsRepository) GetSummarizedDays(ctx context.Context, from time.Time, to time.Time) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT summary_date FROM sales_summary_daily WHERE summary_date >= ? AND summary_date <= ?`,
+		from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetSummarizedDays failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make(map[string]bool)
+	for rows.Next() {
+		var d time.Time
+		if err := rows.Scan(&d); err != nil {
+			return nil, err
+		}
+		days[d.Format("2006-01-02")] = true
+	}
+	return days, rows.Err()
+}
+
+// GetDailySummaries: Số liệu tổng hợp theo ngày.
+// summary_date được format ngay trong SQL để không bị lệch ngày theo tham số loc của kết nối
+func (r *StatsRepository) GetDailySummaries(ctx context.Context, from string, to string) (map[string]model.RevenueMetrics, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DATE_FORMAT(summary_date, '%Y-%m-%d'), total_orders, total_quantity, total_revenue, real_orders, real_revenue
+		FROM sales_summary_daily
+		WHERE summary_date >= ? AND summary_date <= ?`, from, to)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetDailySummaries failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make(map[string]model.RevenueMetrics)
+	for rows.Next() {
+		var day string
+		var m model.RevenueMetrics
+		if err := rows.Scan(&day, &m.Orders, &m.Units, &m.GMV, &m.RealOrders, &m.RealRevenue); err != nil {
+			return nil, err
+		}
+		days[day] = m
+	}
+	return days, rows.Err()
+}
+
+// GetFirstOrderDate: Thời điểm đặt đơn sớm nhất
+func (r *StatsRepository) GetFirstOrderDate(ctx context.Context) (*time.Time, error) {
+	var first sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `SELECT MIN(placed_at) FROM orders`).Scan(&first); err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetFirstOrderDate failed: %v", err)
+		return nil, err
+	}
+	if !first.Valid {
+		return nil, nil
+	}
+	return &first.Time, nil
+}
+
+// Các cột tổng hợp lợi nhuận dùng chung (bảng product_sales_daily alias d)
+const marginAggregateColumns = `
+			IFNULL(SUM(d.units_sold), 0),
+			IFNULL(SUM(d.revenue), 0),
+			IFNULL(SUM(d.cost), 0),
+			IFNULL(SUM(d.gross_profit), 0),
+			SUM(d.gross_profit) / NULLIF(SUM(d.gross_profit + d.cost), 0) * 100 AS margin_percent,
+			IFNULL(SUM(d.uncosted_units), 0)`
+
+// Cột sắp xếp hợp lệ của báo cáo lợi nhuận (đã validate ở handler, map lại để không ghép chuỗi từ input)
+var marginSortColumns = map[string]string{
+	"gross_profit": "SUM(d.gross_profit)",
+	"revenue":      "SUM(d.revenue)",
+	"margin":       "margin_percent",
+}
+
+// normalizeMarginFilter: Mặc định 30 ngày gần nhất, top 10, sắp theo lợi nhuận gộp
+func normalizeMarginFilter(filter model.MarginFilter) (model.MarginFilter, string) {
+	if filter.StartDate == "" {
+		filter.StartDate = time.Now().AddDate(0, 0, -30).Format("2006-01-02")
+	}
+	if filter.EndDate == "" {
+		filter.EndDate = time.Now().Format("2006-01-02")
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	orderBy, ok := marginSortColumns[filter.SortBy]
+	if !ok {
+		orderBy = marginSortColumns["gross_profit"]
+	}
+	return filter, orderBy
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMarginFigures: Scan các cột định danh (prefix) rồi tới các cột lợi nhuận
+func scanMarginFigures(row rowScanner, prefix ...interface{}) (model.MarginFigures, error) {
+	var f model.MarginFigures
+	var margin sql.NullFloat64
+	dest := append(prefix, &f.UnitsSold, &f.Revenue, &f.Cost, &f.GrossProfit, &margin, &f.UncostedUnits)
+	if err := row.Scan(dest...); err != nil {
+		return f, err
+	}
+	if margin.Valid {
+		f.MarginPercent = &margin.Float64
+	}
+	return f, nil
+}
+
+// GetProductMargins: Lợi nhuận gộp theo sản phẩm
+func (r *StatsRepository) GetProductMargins(ctx context.Context, filter model.MarginFilter) ([]model.ProductMarginResponse, error) {
+	filter, orderBy := normalizeMarginFilter(filter)
+	query := `
+		SELECT d.product_id, p.name,` + marginAggregateColumns + `
+		FROM product_sales_daily d
+		JOIN products p ON d.product_id = p.id
+		WHERE d.summary_date >= ? AND d.summary_date <= ?
+		GROUP BY d.product_id, p.name
+		ORDER BY ` + orderBy + ` DESC
+		LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.StartDate, filter.EndDate, filter.Limit)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetProductMargins query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]model.ProductMarginResponse, 0)
+	for rows.Next() {
+		var m model.ProductMarginResponse
+		if m.MarginFigures, err = scanMarginFigures(rows, &m.ProductID, &m.ProductName); err != nil {
+			return nil, err
+		}
+		res = append(res, m)
+	}
+	return res, rows.Err()
+}
+
+// GetVariantMargins: Lợi nhuận gộp theo biến thể
+func (r *StatsRepository) GetVariantMargins(ctx context.Context, filter model.MarginFilter) ([]model.VariantMarginResponse, error) {
+	filter, orderBy := normalizeMarginFilter(filter)
+	query := `
+		SELECT d.product_id, d.variant_id, p.name, COALESCE(v.title, ''), COALESCE(v.sku, ''),` + marginAggregateColumns + `
+		FROM product_sales_daily d
+		JOIN products p ON d.product_id = p.id
+		LEFT JOIN product_variants v ON d.variant_id = v.id
+		WHERE d.summary_date >= ? AND d.summary_date <= ?
+		GROUP BY d.product_id, d.variant_id, p.name, v.title, v.sku
+		ORDER BY ` + orderBy + ` DESC
+		LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.StartDate, filter.EndDate, filter.Limit)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetVariantMargins query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]model.VariantMarginResponse, 0)
+	for rows.Next() {
+		var m model.VariantMarginResponse
+		if m.MarginFigures, err = scanMarginFigures(rows, &m.ProductID, &m.VariantID, &m.ProductName, &m.VariantTitle, &m.SKU); err != nil {
+			return nil, err
+		}
+		res = append(res, m)
+	}
+	return res, rows.Err()
+}
+
+// GetCategoryMargins: Lợi nhuận gộp theo danh mục
+func (r *StatsRepository) GetCategoryMargins(ctx context.Context, filter model.MarginFilter) ([]model.CategoryMarginResponse, error) {
+	filter, orderBy := normalizeMarginFilter(filter)
+	query := `
+		SELECT c.id, c.name,` + marginAggregateColumns + `
+		FROM product_sales_daily d
+		JOIN product_categories pc ON pc.product_id = d.product_id
+		JOIN categories c ON c.id = pc.category_id
+		WHERE d.summary_date >= ? AND d.summary_date <= ?
+		GROUP BY c.id, c.name
+		ORDER BY ` + orderBy + ` DESC
+		LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.StartDate, filter.EndDate, filter.Limit)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetCategoryMargins query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]model.CategoryMarginResponse, 0)
+	for rows.Next() {
+		var m model.CategoryMarginResponse
+		if m.MarginFigures, err = scanMarginFigures(rows, &m.CategoryID, &m.CategoryName); err != nil {
+			return nil, err
+		}
+		res = append(res, m)
+	}
+	return res, rows.Err()
+}
+
+// GetDailyMargins: Lợi nhuận gộp theo ngày (tăng dần theo ngày, bỏ qua Limit / SortBy)
+func (r *StatsRepository) GetDailyMargins(ctx context.Context, filter model.MarginFilter) ([]model.DailyMarginResponse, error) {
+	filter, _ = normalizeMarginFilter(filter)
+	query := `
+		SELECT d.summary_date,` + marginAggregateColumns + `
+		FROM product_sales_daily d
+		WHERE d.summary_date >= ? AND d.summary_date <= ?
+		GROUP BY d.summary_date
+		ORDER BY d.summary_date ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.StartDate, filter.EndDate)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetDailyMargins query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]model.DailyMarginResponse, 0)
+	for rows.Next() {
+		var m model.DailyMarginResponse
+		var date time.Time
+		if m.MarginFigures, err = scanMarginFigures(rows, &date); err != nil {
+			return nil, err
+		}
+		m.Date = date.Format("2006-01-02")
+		res = append(res, m)
+	}
+	return res, rows.Err()
+}
+
+// GetMarginAlerts: Biến thể có giá bán hiện tại < giá vốn, hoặc có dòng đơn bán dưới giá vốn trong kỳ.
+// Đọc trực tiếp từ order_items để cảnh báo được cả đơn hôm nay (chưa tổng hợp)
+func (r *StatsRepository) GetMarginAlerts(ctx context.Context, filter model.MarginFilter) ([]model.MarginAlertResponse, error) {
+	filter, _ = normalizeMarginFilter(filter)
+	query := `
+		SELECT v.product_id, v.id, p.name, COALESCE(v.title, ''), COALESCE(v.sku, ''),
+			` + utils.VariantPriceSQL + ` AS current_price,
+			v.cost_price,
+			` + utils.VariantPriceSQL + ` < v.cost_price AS price_below_cost,
+			COALESCE(s.units, 0),
+			COALESCE(s.loss, 0)
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		LEFT JOIN (
+			SELECT oi.variant_id,
+				SUM(oi.quantity) AS units,
+				SUM(oi.unit_cost * oi.quantity - oi.line_subtotal) AS loss
+			FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.placed_at >= ? AND o.placed_at < DATE_ADD(?, INTERVAL 1 DAY)
+			  AND o.status NOT IN ('cancelled', 'refunded')
+			  AND oi.unit_cost IS NOT NULL
+			  AND oi.line_subtotal < oi.unit_cost * oi.quantity
+			GROUP BY oi.variant_id
+		) s ON s.variant_id = v.id
+		WHERE v.cost_price IS NOT NULL
+		  AND p.deleted_at IS NULL
+		  AND (` + utils.VariantPriceSQL + ` < v.cost_price OR s.variant_id IS NOT NULL)
+		ORDER BY COALESCE(s.loss, 0) DESC, (v.cost_price - ` + utils.VariantPriceSQL + `) DESC
+		LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.StartDate, filter.EndDate, filter.Limit)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetMarginAlerts query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]model.MarginAlertResponse, 0)
+	for rows.Next() {
+		var a model.MarginAlertResponse
+		if err := rows.Scan(&a.ProductID, &a.VariantID, &a.ProductName, &a.VariantTitle, &a.SKU,
+			&a.CurrentPrice, &a.CostPrice, &a.PriceBelowCost, &a.UnitsBelowCost, &a.LossAmount); err != nil {
+			return nil, err
+		}
+		res = append(res, a)
+	}
+	return res, rows.Err()
+}
+
+// orderValueExpr: Tiền hàng của đơn (đơn cũ chưa tách phí giao hàng thì lấy total_amount), giống orderSubtotal bên order
+const orderValueExpr = `IF(subtotal_amount = 0 AND shipping_fee = 0, total_amount, subtotal_amount)`
+
+// RefreshCustomerStats: Tính lại toàn bộ customer_stats + customer_cohort_monthly trong 1 transaction.
+// Chỉ tính đơn không bị hủy / hoàn tiền (cùng điều kiện với GMV).
+// Điểm RFM:
+//   - R, M: chia 5 nhóm theo phân vị (CUME_DIST, khách bằng nhau cùng điểm), R càng gần đây điểm càng cao
+//   - F: theo số đơn tuyệt đối (1, 2, 3, 4-5, 6+) vì đa số khách chỉ có 1 đơn, chia phân vị sẽ lệch
+func (r *StatsRepository) RefreshCustomerStats(ctx context.Context, asOf time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM customer_stats`); err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Clear customer_stats failed: %v", err)
+		return err
+	}
+
+	// BƯỚC 1: Chỉ số từng khách
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO customer_stats (user_id, cohort_month, first_order_at, second_order_at, last_order_at,
+			order_count, lifetime_value, avg_order_value, recency_days, computed_at)
+		SELECT o.user_id,
+			DATE_FORMAT(MIN(o.placed_at), '%Y-%m-01'),
+			MIN(o.placed_at),
+			MIN(CASE WHEN o.rn = 2 THEN o.placed_at END),
+			MAX(o.placed_at),
+			COUNT(*),
+			SUM(o.order_value),
+			AVG(o.order_value),
+			GREATEST(DATEDIFF(?, MAX(o.placed_at)), 0),
+			?
+		FROM (
+			SELECT user_id, placed_at, `+orderValueExpr+` AS order_value,
+				ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY placed_at, id) AS rn
+			FROM orders
+			WHERE status NOT IN ('cancelled', 'refunded') AND placed_at IS NOT NULL
+		) o
+		GROUP BY o.user_id`, asOf, asOf)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Insert customer_stats failed: %v", err)
+		return err
+	}
+
+	// BƯỚC 2: Điểm RFM
+	_, err = tx.ExecContext(ctx, `
+		UPDATE customer_stats cs
+		JOIN (
+			SELECT user_id,
+				6 - CEIL(CUME_DIST() OVER (ORDER BY recency_days) * 5) AS r,
+				CASE WHEN order_count >= 6 THEN 5 WHEN order_count >= 4 THEN 4 ELSE order_count END AS f,
+				CEIL(CUME_DIST() OVER (ORDER BY lifetime_value) * 5) AS m
+			FROM customer_stats
+		) s ON s.user_id = cs.user_id
+		SET cs.r_score = s.r, cs.f_score = s.f, cs.m_score = s.m`)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Score customer_stats failed: %v", err)
+		return err
+	}
+
+	// BƯỚC 3: Phân khúc (xét theo thứ tự, khớp điều kiện đầu tiên)
+	_, err = tx.ExecContext(ctx, `
+		UPDATE customer_stats SET rfm_segment = CASE
+			WHEN r_score >= 4 AND f_score >= 4 AND m_score >= 4 THEN 'champions'
+			WHEN r_score >= 3 AND f_score >= 4 THEN 'loyal'
+			WHEN r_score >= 4 AND order_count = 1 THEN 'new_customer'
+			WHEN r_score >= 4 AND f_score >= 2 THEN 'potential_loyalist'
+			WHEN r_score <= 2 AND f_score >= 3 THEN 'at_risk'
+			WHEN r_score = 1 THEN 'lost'
+			WHEN r_score = 2 THEN 'hibernating'
+			ELSE 'need_attention'
+		END`)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Segment customer_stats failed: %v", err)
+		return err
+	}
+
+	// BƯỚC 4: Ma trận cohort
+	if _, err := tx.ExecContext(ctx, `DELETE FROM customer_cohort_monthly`); err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Clear customer_cohort_monthly failed: %v", err)
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO customer_cohort_monthly (cohort_month, month_offset, cohort_size, active_customers, orders, revenue, computed_at)
+		SELECT cs.cohort_month,
+			PERIOD_DIFF(DATE_FORMAT(o.placed_at, '%Y%m'), DATE_FORMAT(cs.cohort_month, '%Y%m')) AS month_offset,
+			sz.cohort_size,
+			COUNT(DISTINCT o.user_id),
+			COUNT(*),
+			SUM(`+orderValueExpr+`),
+			?
+		FROM orders o
+		JOIN customer_stats cs ON cs.user_id = o.user_id
+		JOIN (SELECT cohort_month, COUNT(*) AS cohort_size FROM customer_stats GROUP BY cohort_month) sz
+			ON sz.cohort_month = cs.cohort_month
+		WHERE o.status NOT IN ('cancelled', 'refunded') AND o.placed_at IS NOT NULL
+		GROUP BY cs.cohort_month, month_offset, sz.cohort_size`, asOf)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Insert customer_cohort_monthly failed: %v", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const customerStatsColumns = `
+		cs.user_id, u.username, u.email, DATE_FORMAT(cs.cohort_month, '%Y-%m'), cs.first_order_at, cs.last_order_at,
+		cs.order_count, cs.lifetime_value, cs.avg_order_value, cs.recency_days,
+		cs.r_score, cs.f_score, cs.m_score, cs.rfm_segment, cs.computed_at`
+
+var customerSortColumns = map[string]string{
+	"ltv":     "cs.lifetime_value DESC",
+	"orders":  "cs.order_count DESC, cs.lifetime_value DESC",
+	"recency": "cs.recency_days ASC, cs.lifetime_value DESC",
+}
+
+// customerStatsWhere: Điều kiện lọc + sắp xếp dùng chung cho danh sách và export
+func customerStatsWhere(filter model.CustomerStatsFilter) (string, []interface{}, string) {
+	where := ` FROM customer_stats cs JOIN users u ON u.id = cs.user_id WHERE u.deleted_at IS NULL`
+	var args []interface{}
+	if filter.Segment != "" {
+		where += ` AND cs.rfm_segment = ?`
+		args = append(args, filter.Segment)
+	}
+	orderBy, ok := customerSortColumns[filter.SortBy]
+	if !ok {
+		orderBy = customerSortColumns["ltv"]
+	}
+	return where, args, orderBy + `, cs.user_id ASC`
+}
+
+func scanCustomerStats(row rowScanner) (model.CustomerStatsResponse, error) {
+	var c model.CustomerStatsResponse
+	err := row.Scan(&c.UserID, &c.Username, &c.Email, &c.CohortMonth, &c.FirstOrderAt, &c.LastOrderAt,
+		&c.OrderCount, &c.LifetimeValue, &c.AvgOrderValue, &c.RecencyDays,
+		&c.RScore, &c.FScore, &c.MScore, &c.Segment, &c.ComputedAt)
+	return c, err
+}
+
+// SearchCustomerStats: Danh sách khách hàng có phân trang
+func (r *StatsRepository) SearchCustomerStats(ctx context.Context, filter model.CustomerStatsFilter) ([]model.CustomerStatsResponse, int64, error) {
+	where, args, orderBy := customerStatsWhere(filter)
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*)`+where, args...).Scan(&total); err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Count customer_stats failed: %v", err)
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + customerStatsColumns + where + ` ORDER BY ` + orderBy + ` LIMIT ? OFFSET ?`
+	rows, err := r.db.QueryContext(ctx, query, append(args, filter.Limit, (filter.Page-1)*filter.Limit)...)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: SearchCustomerStats failed: %v", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	customers := make([]model.CustomerStatsResponse, 0)
+	for rows.Next() {
+		c, err := scanCustomerStats(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		customers = append(customers, c)
+	}
+	return customers, total, rows.Err()
+}
+
+// EachCustomerStats: Duyệt toàn bộ khách theo filter (bỏ qua phân trang), từng dòng một để export không giữ hết trong bộ nhớ
+func (r *StatsRepository) EachCustomerStats(ctx context.Context, filter model.CustomerStatsFilter, fn func(model.CustomerStatsResponse) error) error {
+	where, args, orderBy := customerStatsWhere(filter)
+	rows, err := r.db.QueryContext(ctx, `SELECT `+customerStatsColumns+where+` ORDER BY `+orderBy, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: EachCustomerStats failed: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanCustomerStats(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetRFMSegmentSummary: Số khách + LTV theo phân khúc
+func (r *StatsRepository) GetRFMSegmentSummary(ctx context.Context) ([]model.RFMSegmentSummary, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT cs.rfm_segment, COUNT(*), SUM(cs.lifetime_value), AVG(cs.order_count), AVG(cs.recency_days)
+		FROM customer_stats cs
+		JOIN users u ON u.id = cs.user_id
+		WHERE u.deleted_at IS NULL
+		GROUP BY cs.rfm_segment
+		ORDER BY SUM(cs.lifetime_value) DESC`)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetRFMSegmentSummary failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]model.RFMSegmentSummary, 0)
+	for rows.Next() {
+		var s model.RFMSegmentSummary
+		if err := rows.Scan(&s.Segment, &s.Customers, &s.LifetimeValue, &s.AvgOrderCount, &s.AvgRecency); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+// GetCohortRows: Các ô cohort có dữ liệu trong khoảng tháng [fromMonth, toMonth] (YYYY-MM-DD, ngày 1)
+func (r *StatsRepository) GetCohortRows(ctx context.Context, fromMonth string, toMonth string) ([]model.CohortRow, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DATE_FORMAT(cohort_month, '%Y-%m'), cohort_size, month_offset, active_customers, orders, revenue
+		FROM customer_cohort_monthly
+		WHERE cohort_month >= ? AND cohort_month <= ?
+		ORDER BY cohort_month ASC, month_offset ASC`, fromMonth, toMonth)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetCohortRows failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []model.CohortRow
+	for rows.Next() {
+		var c model.CohortRow
+		if err := rows.Scan(&c.CohortMonth, &c.CohortSize, &c.MonthOffset, &c.ActiveCustomers, &c.Orders, &c.Revenue); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+// GetRepeatPurchase: Số khách đủ điều kiện / quay lại trong withinDays ngày (tính tới thời điểm chạy job)
+func (r *StatsRepository) GetRepeatPurchase(ctx context.Context, withinDays int) (*model.RepeatPurchaseResponse, error) {
+	res := &model.RepeatPurchaseResponse{WithinDays: withinDays}
+	var computedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(CASE WHEN first_order_at <= DATE_SUB(computed_at, INTERVAL ? DAY) THEN 1 END),
+			COUNT(CASE WHEN first_order_at <= DATE_SUB(computed_at, INTERVAL ? DAY)
+				AND second_order_at <= DATE_ADD(first_order_at, INTERVAL ? DAY) THEN 1 END),
+			COUNT(*),
+			COUNT(CASE WHEN order_count >= 2 THEN 1 END),
+			MAX(computed_at)
+		FROM customer_stats`, withinDays, withinDays, withinDays).Scan(
+		&res.EligibleCustomers, &res.RepeatCustomers, &res.TotalCustomers, &res.EverRepeated, &computedAt)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetRepeatPurchase failed: %v", err)
+		return nil, err
+	}
+	if computedAt.Valid {
+		res.ComputedAt = &computedAt.Time
+	}
+	return res, nil
+}
+
+// GetCustomerStatsComputedAt: Lần chạy job khách hàng gần nhất (nil nếu chưa có dữ liệu)
+func (r *StatsRepository) GetCustomerStatsComputedAt(ctx context.Context) (*time.Time, error) {
+	var computedAt sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `SELECT MAX(computed_at) FROM customer_stats`).Scan(&computedAt); err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetCustomerStatsComputedAt failed: %v", err)
+		return nil, err
+	}
+	if !computedAt.Valid {
+		return nil, nil
+	}
+	return &computedAt.Time, nil
+}
+
+// RefreshInventoryForecast: Tính lại tốc độ bán của từng biến thể trong windowDays ngày trước today
+func (r *StatsRepository) RefreshInventoryForecast(ctx context.Context, today time.Time, windowDays int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM inventory_forecast`); err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Clear inventory_forecast failed: %v", err)
+		return err
+	}
+
+	// product_sales_daily.variant_id = 0 là dòng không có biến thể -> bỏ qua
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO inventory_forecast (variant_id, product_id, window_days, units_sold, velocity, last_sale_date, computed_at)
+		SELECT v.id, v.product_id, ?, COALESCE(w.units, 0), COALESCE(w.units, 0) / ?, l.last_sale_date, NOW()
+		FROM product_variants v
+		LEFT JOIN (
+			SELECT variant_id, SUM(units_sold) AS units
+			FROM product_sales_daily
+			WHERE summary_date >= ? AND summary_date < ? AND variant_id > 0
+			GROUP BY variant_id
+		) w ON w.variant_id = v.id
+		LEFT JOIN (
+			SELECT variant_id, MAX(summary_date) AS last_sale_date
+			FROM product_sales_daily
+			WHERE units_sold > 0 AND variant_id > 0
+			GROUP BY variant_id
+		) l ON l.variant_id = v.id`,
+		windowDays, windowDays,
+		today.AddDate(0, 0, -windowDays).Format("2006-01-02"), today.Format("2006-01-02"),
+	)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Insert inventory_forecast failed: %v", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// inventoryHealthQuery: Bảng tạm tình trạng tồn kho từng biến thể đang bán (tồn kho hiện tại + velocity đã tính)
+func inventoryHealthQuery(p model.InventoryHealthParams) (string, []interface{}) {
+	query := `
+		SELECT variant_id, product_id, product_name, variant_title, sku, stock_quantity, reorder_threshold,
+		       allow_backorder, units_sold, velocity, days_until_stockout, last_sale_date, status
+		FROM (
+			SELECT v.id AS variant_id, v.product_id, p.name AS product_name,
+			       COALESCE(v.title, '') AS variant_title, COALESCE(v.sku, '') AS sku,
+			       v.stock_quantity, COALESCE(v.reorder_threshold, ?) AS reorder_threshold,
+			       COALESCE(v.allow_backorder, 0) AS allow_backorder,
+			       COALESCE(f.units_sold, 0) AS units_sold, COALESCE(f.velocity, 0) AS velocity,
+			       CASE WHEN f.velocity > 0 THEN GREATEST(v.stock_quantity, 0) / f.velocity END AS days_until_stockout,
+			       DATE_FORMAT(f.last_sale_date, '%Y-%m-%d') AS last_sale_date,
+			       v.low_stock_notified_at,
+			       CASE
+			           WHEN v.stock_quantity <= 0 THEN 'out_of_stock'
+			           WHEN v.stock_quantity <= COALESCE(v.reorder_threshold, ?) THEN 'low'
+			           WHEN f.velocity > 0 AND v.stock_quantity / f.velocity <= ? THEN 'projected_out'
+			           WHEN COALESCE(f.last_sale_date, DATE(v.created_at)) < ? THEN 'dead'
+			           ELSE 'ok'
+			       END AS status
+			FROM product_variants v
+			JOIN products p ON p.id = v.product_id AND p.deleted_at IS NULL
+			LEFT JOIN inventory_forecast f ON f.variant_id = v.id
+			WHERE v.is_active = 1
+		) h`
+	deadSince := p.Today.AddDate(0, 0, -p.DeadDays).Format("2006-01-02")
+	return query, []interface{}{p.DefaultThreshold, p.DefaultThreshold, p.HorizonDays, deadSince}
+}
+
+func scanInventoryHealthItem(row rowScanner, today time.Time) (model.InventoryHealthItem, error) {
+	var item model.InventoryHealthItem
+	var days sql.NullFloat64
+	var lastSale sql.NullString
+	if err := row.Scan(
+		&item.VariantID, &item.ProductID, &item.ProductName, &item.VariantTitle, &item.SKU,
+		&item.StockQuantity, &item.ReorderThreshold, &item.AllowBackorder,
+		&item.UnitsSold, &item.Velocity, &days, &lastSale, &item.Status,
+	); err != nil {
+		return item, err
+	}
+	if days.Valid {
+		d := math.Round(days.Float64*10) / 10
+		date := today.AddDate(0, 0, int(days.Float64)).Format("2006-01-02")
+		item.DaysUntilStockout = &d
+		item.StockoutDate = &date
+	}
+	if lastSale.Valid {
+		item.LastSaleDate = &lastSale.String
+	}
+	return item, nil
+}
+
+// GetInventoryHealth: Số biến thể theo trạng thái + danh sách (lọc status, phân trang)
+func (r *StatsRepository) GetInventoryHealth(ctx context.Context, p model.InventoryHealthParams, filter model.InventoryHealthFilter) (*model.InventoryHealthSummary, []model.InventoryHealthItem, error) {
+	base, args := inventoryHealthQuery(p)
+
+	rows, err := r.db.QueryContext(ctx, "SELECT status, COUNT(*) FROM ("+base+") s GROUP BY status", args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetInventoryHealth summary failed: %v", err)
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	summary := &model.InventoryHealthSummary{}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, nil, err
+		}
+		switch status {
+		case model.InventoryStatusOutOfStock:
+			summary.OutOfStock = count
+		case model.InventoryStatusLow:
+			summary.Low = count
+		case model.InventoryStatusProjectedOut:
+			summary.ProjectedOut = count
+		case model.InventoryStatusDead:
+			summary.Dead = count
+		default:
+			summary.OK = count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// Gấp nhất lên trước: hết hàng -> dưới ngưỡng -> sắp hết (ít ngày nhất) -> tồn đọng (nhiều hàng nhất)
+	query := base + `
+		WHERE (? = '' OR status = ?)
+		ORDER BY FIELD(status, 'out_of_stock', 'low', 'projected_out', 'dead', 'ok'),
+		         days_until_stockout IS NULL, days_until_stockout,
+		         CASE WHEN status = 'dead' THEN -stock_quantity ELSE stock_quantity END, variant_id
+		LIMIT ? OFFSET ?`
+	args = append(args, filter.Status, filter.Status, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	itemRows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetInventoryHealth items failed: %v", err)
+		return nil, nil, err
+	}
+	defer itemRows.Close()
+
+	items := []model.InventoryHealthItem{}
+	for itemRows.Next() {
+		item, err := scanInventoryHealthItem(itemRows, p.Today)
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, item)
+	}
+	return summary, items, itemRows.Err()
+}
+
+// GetInventoryForecastComputedAt: Lần tính velocity gần nhất (nil nếu chưa có)
+func (r *StatsRepository) GetInventoryForecastComputedAt(ctx context.Context) (*time.Time, error) {
+	var computedAt sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `SELECT MAX(computed_at) FROM inventory_forecast`).Scan(&computedAt); err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetInventoryForecastComputedAt failed: %v", err)
+		return nil, err
+	}
+	if !computedAt.Valid {
+		return nil, nil
+	}
+	return &computedAt.Time, nil
+}
+
+// ResetLowStockAlerts: Biến thể đã lên lại trên ngưỡng thì lần xuống ngưỡng sau sẽ được báo tiếp
+func (r *StatsRepository) ResetLowStockAlerts(ctx context.Context, defaultThreshold int) (int64, error) {
+	// updated_at = updated_at: không tính là admin sửa biến thể
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE product_variants
+		SET low_stock_notified_at = NULL, updated_at = updated_at
+		WHERE low_stock_notified_at IS NOT NULL AND stock_quantity > COALESCE(reorder_threshold, ?)`,
+		defaultThreshold)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: ResetLowStockAlerts failed: %v", err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetPendingLowStockAlerts: Biến thể hết hàng / dưới ngưỡng chưa được báo
+func (r *StatsRepository) GetPendingLowStockAlerts(ctx context.Context, p model.InventoryHealthParams) ([]model.InventoryHealthItem, error) {
+	base, args := inventoryHealthQuery(p)
+	query := base + `
+		WHERE status IN ('out_of_stock', 'low') AND low_stock_notified_at IS NULL
+		ORDER BY stock_quantity, variant_id`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetPendingLowStockAlerts failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []model.InventoryHealthItem{}
+	for rows.Next() {
+		item, err := scanInventoryHealthItem(rows, p.Today)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// MarkLowStockNotified: Đánh dấu đã báo sắp hết hàng
+func (r *StatsRepository) MarkLowStockNotified(ctx context.Context, variantIDs []int64) error {
+	if len(variantIDs) == 0 {
+		return nil
+	}
+	placeholders := make([]string, len(variantIDs))
+	args := make([]interface{}, len(variantIDs))
+	for i, id := range variantIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE product_variants
+		SET low_stock_notified_at = NOW(), updated_at = updated_at
+		WHERE id IN (`+strings.Join(placeholders, ",")+`)`, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: MarkLowStockNotified failed: %v", err)
+	}
+	return err
+}
+
+// cartStartsQuery: Các giỏ có thêm sản phẩm trong ngày (mỗi giỏ / ngày 1 dòng, kèm lần thêm đầu tiên)
+const cartStartsQuery = `
+	SELECT cart_id, DATE(created_at) AS summary_date, MIN(created_at) AS first_add
+	FROM cart_events
+	WHERE event_type = 'add_to_cart' AND cart_id IS NOT NULL AND created_at >= ? AND created_at < ?
+	GROUP BY cart_id, DATE(created_at)`
+
+// RefreshCartFunnel: Tính lại phễu giỏ hàng + sản phẩm bị bỏ quên cho khoảng ngày [from, to]
+func (r *StatsRepository) RefreshCartFunnel(ctx context.Context, from time.Time, to time.Time, windowDays int) error {
+	start, end := from.Format("2006-01-02"), to.AddDate(0, 0, 1).Format("2006-01-02")
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM cart_funnel_daily WHERE summary_date >= ? AND summary_date < ?`, start, end); err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Clear cart_funnel_daily failed: %v", err)
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM cart_abandoned_products_daily WHERE summary_date >= ? AND summary_date < ?`, start, end); err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Clear cart_abandoned_products_daily failed: %v", err)
+		return err
+	}
+
+	// Giỏ có preview / tạo đơn trong windowDays ngày kể từ lần thêm đầu tiên
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO cart_funnel_daily (summary_date, carts_started, carts_checkout, carts_converted, carts_abandoned, window_days, computed_at)
+		SELECT s.summary_date, COUNT(*), SUM(s.checkout), SUM(s.converted), COUNT(*) - SUM(s.converted), ?, NOW()
+		FROM (
+			SELECT a.summary_date,
+			       EXISTS (SELECT 1 FROM cart_events p
+			               WHERE p.cart_id = a.cart_id AND p.event_type = 'checkout_preview'
+			                 AND p.created_at >= a.first_add AND p.created_at < a.first_add + INTERVAL ? DAY) AS checkout,
+			       EXISTS (SELECT 1 FROM cart_events o
+			               WHERE o.cart_id = a.cart_id AND o.event_type = 'order_created'
+			                 AND o.created_at >= a.first_add AND o.created_at < a.first_add + INTERVAL ? DAY) AS converted
+			FROM (`+cartStartsQuery+`) a
+		) s
+		GROUP BY s.summary_date`,
+		windowDays, windowDays, windowDays, start, end,
+	)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Insert cart_funnel_daily failed: %v", err)
+		return err
+	}
+
+	// Số sự kiện theo ngày phát sinh
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO cart_funnel_daily (summary_date, add_events, remove_events, preview_events, order_events, window_days, computed_at)
+		SELECT DATE(created_at),
+		       SUM(event_type = 'add_to_cart'), SUM(event_type = 'remove_from_cart'),
+		       SUM(event_type = 'checkout_preview'), SUM(event_type = 'order_created'), ?, NOW()
+		FROM cart_events
+		WHERE created_at >= ? AND created_at < ?
+		GROUP BY DATE(created_at)
+		ON DUPLICATE KEY UPDATE
+			add_events = VALUES(add_events),
+			remove_events = VALUES(remove_events),
+			preview_events = VALUES(preview_events),
+			order_events = VALUES(order_events)`,
+		windowDays, start, end,
+	)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Update cart_funnel_daily events failed: %v", err)
+		return err
+	}
+
+	// Sản phẩm được thêm trong ngày vào các giỏ không tạo đơn
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO cart_abandoned_products_daily (summary_date, product_id, variant_id, carts, units, amount)
+		SELECT a.summary_date, e.product_id, COALESCE(e.variant_id, 0), COUNT(DISTINCT e.cart_id), SUM(e.quantity), SUM(e.amount)
+		FROM (`+cartStartsQuery+`) a
+		JOIN cart_events e ON e.cart_id = a.cart_id AND e.event_type = 'add_to_cart'
+		     AND e.created_at >= a.first_add AND e.created_at < a.summary_date + INTERVAL 1 DAY
+		WHERE e.product_id IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM cart_events o
+		                  WHERE o.cart_id = a.cart_id AND o.event_type = 'order_created'
+		                    AND o.created_at >= a.first_add AND o.created_at < a.first_add + INTERVAL ? DAY)
+		GROUP BY a.summary_date, e.product_id, COALESCE(e.variant_id, 0)`,
+		start, end, windowDays,
+	)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: Insert cart_abandoned_products_daily failed: %v", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetCartFunnel: Phễu giỏ hàng theo ngày (chỉ các ngày có dữ liệu) + lần tính gần nhất
+func (r *StatsRepository) GetCartFunnel(ctx context.Context, startDate string, endDate string) ([]model.FunnelDay, *time.Time, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DATE_FORMAT(summary_date, '%Y-%m-%d'), carts_started, carts_checkout, carts_converted, carts_abandoned,
+		       add_events, remove_events, preview_events, order_events
+		FROM cart_funnel_daily
+		WHERE summary_date >= ? AND summary_date <= ?
+		ORDER BY summary_date`, startDate, endDate)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetCartFunnel query failed: %v", err)
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	days := []model.FunnelDay{}
+	for rows.Next() {
+		var d model.FunnelDay
+		if err := rows.Scan(
+			&d.Date, &d.CartsStarted, &d.CartsCheckout, &d.CartsConverted, &d.CartsAbandoned,
+			&d.AddEvents, &d.RemoveEvents, &d.PreviewEvents, &d.OrderEvents,
+		); err != nil {
+			return nil, nil, err
+		}
+		days = append(days, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var computedAt sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `SELECT MAX(computed_at) FROM cart_funnel_daily`).Scan(&computedAt); err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetCartFunnel computed_at failed: %v", err)
+		return nil, nil, err
+	}
+	if !computedAt.Valid {
+		return days, nil, nil
+	}
+	return days, &computedAt.Time, nil
+}
+
+// GetAbandonedProducts: Sản phẩm bị bỏ lại trong giỏ nhiều nhất (theo số giỏ)
+func (r *StatsRepository) GetAbandonedProducts(ctx context.Context, filter model.StatsFilter) ([]model.AbandonedProductResponse, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT d.product_id, d.variant_id, p.name, COALESCE(v.title, ''), COALESCE(v.sku, ''),
+		       SUM(d.carts) AS carts, SUM(d.units), SUM(d.amount)
+		FROM cart_abandoned_products_daily d
+		JOIN products p ON p.id = d.product_id
+		LEFT JOIN product_variants v ON v.id = d.variant_id
+		WHERE d.summary_date >= ? AND d.summary_date <= ?
+		GROUP BY d.product_id, d.variant_id, p.name, v.title, v.sku
+		ORDER BY carts DESC, SUM(d.amount) DESC
+		LIMIT ?`, filter.StartDate, filter.EndDate, filter.Limit)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetAbandonedProducts query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]model.AbandonedProductResponse, 0)
+	for rows.Next() {
+		var a model.AbandonedProductResponse
+		if err := rows.Scan(&a.ProductID, &a.VariantID, &a.ProductName, &a.VariantTitle, &a.SKU, &a.Carts, &a.Units, &a.Amount); err != nil {
+			return nil, err
+		}
+		res = append(res, a)
+	}
+	return res, rows.Err()
+}
+
+// abandonedCartsQuery: Giỏ của user đã đồng ý nhận marketing, không thao tác trong minHours giờ (nhưng chưa quá maxDays ngày)
+// và chưa đặt đơn nào kể từ lần thao tác cuối. Giá trị giỏ tính theo giá hiện tại
+const abandonedCartsQuery = `
+	FROM (
+		SELECT c.id AS cart_id, c.user_id, COUNT(*) AS item_count, SUM(ci.quantity) AS units,
+		       SUM(ci.quantity * ` + utils.VariantPriceSQL + `) AS cart_value,
+		       MAX(ci.updated_at) AS last_activity_at
+		FROM carts c
+		JOIN cart_items ci ON ci.cart_id = c.id
+		JOIN product_variants v ON v.id = ci.variant_id
+		JOIN products p ON p.id = ci.product_id
+		WHERE c.user_id IS NOT NULL
+		GROUP BY c.id, c.user_id
+	) a
+	JOIN users u ON u.id = a.user_id AND u.marketing_opt_in = 1 AND u.is_active = 1 AND u.deleted_at IS NULL
+	WHERE a.last_activity_at < NOW() - INTERVAL ? HOUR
+	  AND a.last_activity_at >= NOW() - INTERVAL ? DAY
+	  AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.user_id = a.user_id AND o.placed_at >= a.last_activity_at)`
+
+// GetAbandonedCarts: Danh sách giỏ bỏ quên để gửi email nhắc (giá trị cao lên trước) kèm các sản phẩm trong giỏ
+func (r *StatsRepository) GetAbandonedCarts(ctx context.Context, filter model.AbandonedCartFilter) ([]model.AbandonedCartResponse, int64, error) {
+	var total int64
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) "+abandonedCartsQuery, filter.MinHours, filter.MaxDays).Scan(&total); err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetAbandonedCarts count failed: %v", err)
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT a.cart_id, u.id, u.username, u.email, a.item_count, a.units, a.cart_value, a.last_activity_at `+abandonedCartsQuery+`
+		ORDER BY a.cart_value DESC, a.last_activity_at DESC
+		LIMIT ? OFFSET ?`,
+		filter.MinHours, filter.MaxDays, filter.Limit, (filter.Page-1)*filter.Limit)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetAbandonedCarts query failed: %v", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	carts := []model.AbandonedCartResponse{}
+	index := make(map[int64]int)
+	for rows.Next() {
+		var c model.AbandonedCartResponse
+		if err := rows.Scan(&c.CartID, &c.UserID, &c.Username, &c.Email, &c.ItemCount, &c.Units, &c.CartValue, &c.LastActivityAt); err != nil {
+			return nil, 0, err
+		}
+		c.Items = []model.AbandonedCartItem{}
+		index[c.CartID] = len(carts)
+		carts = append(carts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if len(carts) == 0 {
+		return carts, total, nil
+	}
+
+	placeholders := make([]string, len(carts))
+	args := make([]interface{}, len(carts))
+	for i, c := range carts {
+		placeholders[i] = "?"
+		args[i] = c.CartID
+	}
+	itemRows, err := r.db.QueryContext(ctx, `
+		SELECT ci.cart_id, ci.product_id, ci.variant_id, p.name, COALESCE(v.title, ''), COALESCE(v.sku, ''),
+		       ci.quantity, ` + utils.VariantPriceSQL + `
+		FROM cart_items ci
+		JOIN product_variants v ON v.id = ci.variant_id
+		JOIN products p ON p.id = ci.product_id
+		WHERE ci.cart_id IN (`+strings.Join(placeholders, ",")+`)
+		ORDER BY ci.cart_id, ci.created_at`, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("StatsRepo: GetAbandonedCarts items failed: %v", err)
+		return nil, 0, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var cartID int64
+		var item model.AbandonedCartItem
+		if err := itemRows.Scan(&cartID, &item.ProductID, &item.VariantID, &item.ProductName, &item.VariantTitle, &item.SKU, &item.Quantity, &item.UnitPrice); err != nil {
+			return nil, 0, err
+		}
+		if i, ok := index[cartID]; ok {
+			carts[i].Items = append(carts[i].Items, item)
+		}
+	}
+	return carts, total, itemRows.Err()
+}
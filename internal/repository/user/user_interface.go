@@ -11,6 +11,8 @@ type UserRepo interface {
 	GetAllUsers() ([]model.User, error)
 	GetUserByID(id int64) (model.User, error)
 	SearchUsers(filter model.UserFilter) ([]model.User, int, error)
+	CountUsers(filter model.UserFilter) (int64, error)
+	EachUser(filter model.UserFilter, fn func(model.User) error) error
 	GetUserByIdentifier(identifier string) (model.User, error)
 	GetUserByRefreshToken(refreshToken string) (model.User, error)
 
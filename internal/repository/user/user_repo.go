@@ -4,7 +4,6 @@ import (
 	"database/sql"
 	"golang/internal/logger"
 	"golang/internal/model"
-	"strings"
 	"time"
 )
 
@@ -22,7 +21,7 @@ func NewUserDb(db *sql.DB) UserRepo {
 func (u *UserDb) GetUserByIdentifier(identifier string) (model.User, error) {
 	logger.DebugLogger.Printf("Starting GetUserByIdentifier for: %s", identifier)
 
-	query := "SELECT id, username, email, password_hash, role, is_active, refresh_token, refresh_token_expiry, created_at, updated_at, deleted_at FROM users WHERE (username = ? OR email = ?) "
+	query := "SELECT id, username, email, password_hash, role, is_active, refresh_token, refresh_token_expiry, marketing_opt_in, created_at, updated_at, deleted_at FROM users WHERE (username = ? OR email = ?) "
 
 	var user model.User
 
@@ -36,6 +35,7 @@ func (u *UserDb) GetUserByIdentifier(identifier string) (model.User, error) {
 		&user.IsActive,
 		&user.RefreshToken,
 		&user.RefreshTokenExpiry,
+		&user.MarketingOptIn,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.DeletedAt,
@@ -85,9 +85,9 @@ func (u *UserDb) GetAllUsers() ([]model.User, error) {
 func (u *UserDb) GetUserByID(id int64) (model.User, error) {
 	logger.DebugLogger.Printf("Starting GetUserByID for ID: %d\n", id)
 	var user model.User
-	query := "SELECT id, username, email, role, is_active, created_at, updated_at, deleted_at FROM users WHERE id = ?"
+	query := "SELECT id, username, email, role, is_active, marketing_opt_in, created_at, updated_at, deleted_at FROM users WHERE id = ?"
 
-	err := u.db.QueryRow(query, id).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt)
+	err := u.db.QueryRow(query, id).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.IsActive, &user.MarketingOptIn, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt)
 	if err != nil {
 		logger.ErrorLogger.Printf("GetUserById failed: %v", err)
 		return model.User{}, err
@@ -96,48 +96,55 @@ func (u *UserDb) GetUserByID(id int64) (model.User, error) {
 	return user, nil
 }
 
-// Hàm tìm kiếm Users theo từ khóa (username hoặc email)
-func (u *UserDb) SearchUsers(filter model.UserFilter) ([]model.User, int, error) {
-	logger.DebugLogger.Printf("Repo: Starting SearchUsers with Filter: %+v", filter)
-	query := `SELECT id, username, email, role, is_active, created_at, updated_at, deleted_at 
-              FROM users 
-              WHERE 1=1`
-
+// userFilterWhere: Điều kiện WHERE dùng chung cho tìm kiếm và export user
+func userFilterWhere(filter model.UserFilter) (string, []interface{}) {
+	where := "1=1"
 	var args []interface{}
 
 	// Lọc theo Keyword (Username hoặc Email)
 	if filter.Keyword != "" {
-		query += " AND (username LIKE ? OR email LIKE ?)"
+		where += " AND (username LIKE ? OR email LIKE ?)"
 		kw := "%" + filter.Keyword + "%"
 		args = append(args, kw, kw)
 	}
 
 	// Lọc theo Role
 	if filter.Role != "" {
-		query += " AND role = ?"
+		where += " AND role = ?"
 		args = append(args, filter.Role)
 	}
 
 	// Lọc theo IsActive 
 	if filter.IsActive != nil {
-		query += " AND is_active = ?"
+		where += " AND is_active = ?"
 		args = append(args, *filter.IsActive)
 	}
 
 	// Lọc theo DeletedAt 
 	if filter.IsDeleted != nil {
 		if *filter.IsDeleted {
-			query += " AND deleted_at IS NOT NULL"
+			where += " AND deleted_at IS NOT NULL"
 		} else {
-			query += " AND deleted_at IS NULL"
+			where += " AND deleted_at IS NULL"
 		}
 	} else {
 		// Mặc định chỉ lấy user chưa xóa 
-		query += " AND deleted_at IS NULL"
+		where += " AND deleted_at IS NULL"
 	}
 
+	return where, args
+}
+
+// Hàm tìm kiếm Users theo từ khóa (username hoặc email)
+func (u *UserDb) SearchUsers(filter model.UserFilter) ([]model.User, int, error) {
+	logger.DebugLogger.Printf("Repo: Starting SearchUsers with Filter: %+v", filter)
+	where, args := userFilterWhere(filter)
+	query := `SELECT id, username, email, role, is_active, created_at, updated_at, deleted_at 
+              FROM users 
+              WHERE ` + where
+
 	// Đếm tổng số bản ghi để phân trang
-	countQuery := "SELECT COUNT(*) FROM users WHERE " + query[strings.Index(query, "WHERE")+6:]
+	countQuery := "SELECT COUNT(*) FROM users WHERE " + where
 	var total int64
 
 	if err := u.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
@@ -177,6 +184,47 @@ func (u *UserDb) SearchUsers(filter model.UserFilter) ([]model.User, int, error)
 	return users, int(total), nil
 }
 
+// CountUsers: Đếm số user khớp bộ lọc (dùng cho export)
+func (u *UserDb) CountUsers(filter model.UserFilter) (int64, error) {
+	where, args := userFilterWhere(filter)
+	var total int64
+	if err := u.db.QueryRow("SELECT COUNT(*) FROM users WHERE "+where, args...).Scan(&total); err != nil {
+		logger.ErrorLogger.Printf("Repo: CountUsers failed: %v", err)
+		return 0, err
+	}
+	return total, nil
+}
+
+// EachUser: Duyệt lần lượt toàn bộ user khớp bộ lọc (không phân trang)
+func (u *UserDb) EachUser(filter model.UserFilter, fn func(model.User) error) error {
+	where, args := userFilterWhere(filter)
+	query := `SELECT id, username, email, role, is_active, created_at, updated_at, deleted_at 
+              FROM users 
+              WHERE ` + where + " ORDER BY created_at DESC, id DESC"
+
+	rows, err := u.db.Query(query, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: EachUser query failed: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user model.User
+		if err := rows.Scan(
+			&user.ID, &user.Username, &user.Email, &user.Role,
+			&user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
+		); err != nil {
+			logger.ErrorLogger.Printf("Repo: EachUser scan failed: %v", err)
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // Hàm CreateUser (Tạo mới User)
 func (u *UserDb) CreateUser(user model.User) (model.User, error) {
 	logger.DebugLogger.Println("Starting CreateUser")
@@ -249,6 +297,7 @@ func (u *UserDb) UpdateUserProfile(id int64, req model.UserUpdateProfileRequest)
 					SET username = COALESCE(?, username), 
 						email = COALESCE(?, email), 
 						password_hash = COALESCE(?, password_hash),
+						marketing_opt_in = COALESCE(?, marketing_opt_in),
 						updated_at = ? 
 					WHERE id = ? AND deleted_at IS NULL`
 
@@ -256,6 +305,7 @@ func (u *UserDb) UpdateUserProfile(id int64, req model.UserUpdateProfileRequest)
 		req.Username,
 		req.Email,
 		req.Password,
+		req.MarketingOptIn,
 		now,
 		id,
 	)
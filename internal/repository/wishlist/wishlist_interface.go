@@ -0,0 +1,29 @@
+package wishlist
+
+import (
+	"context"
+	"golang/internal/model"
+)
+
+type WishlistRepository interface {
+	// Lấy wishlist của user (kèm thông tin sản phẩm / biến thể)
+	GetWishlistByUserID(ctx context.Context, userID int64) ([]model.WishlistItem, error)
+
+	// Thêm vào wishlist (đã có thì bỏ qua), trả về ID dòng
+	AddWishlistItem(ctx context.Context, userID int64, productID int64, variantID *int64) (int64, error)
+
+	// Xóa 1 dòng wishlist của user (trả về sql.ErrNoRows nếu không có)
+	DeleteWishlistItem(ctx context.Context, userID int64, itemID int64) error
+
+	// Đăng ký báo có hàng (đăng ký lại sau khi đã báo sẽ kích hoạt lượt mới)
+	UpsertStockSubscription(ctx context.Context, userID int64, variantID int64) error
+
+	// Hủy đăng ký báo có hàng (trả về sql.ErrNoRows nếu không có)
+	DeleteStockSubscription(ctx context.Context, userID int64, variantID int64) error
+
+	// Danh sách đăng ký của user
+	GetStockSubscriptionsByUserID(ctx context.Context, userID int64) ([]model.StockSubscription, error)
+
+	// Lấy và đánh dấu đã báo các đăng ký đang chờ của biến thể (mỗi đăng ký chỉ được lấy 1 lần)
+	ClaimPendingSubscriptions(ctx context.Context, variantID int64) ([]model.StockSubscription, error)
+}
@@ -0,0 +1,189 @@
+package wishlist
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"golang/internal/logger"
+	"golang/internal/model"
+)
+
+type wishlistRepository struct {
+	db *sql.DB
+}
+
+func NewWishlistRepository(db *sql.DB) WishlistRepository {
+	return &wishlistRepository{db: db}
+}
+
+// Lấy wishlist của user
+func (r *wishlistRepository) GetWishlistByUserID(ctx context.Context, userID int64) ([]model.WishlistItem, error) {
+	query := `
+		SELECT w.id, w.user_id, w.product_id, w.variant_id, w.created_at,
+		       p.name, p.slug, p.min_price, p.base_price, p.is_published,
+		       v.title, v.sku, v.price_override, v.stock_quantity
+		FROM wishlist_items w
+		JOIN products p ON p.id = w.product_id AND p.deleted_at IS NULL
+		LEFT JOIN product_variants v ON v.id = w.variant_id
+		WHERE w.user_id = ?
+		ORDER BY w.created_at DESC, w.id DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Error query wishlist: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []model.WishlistItem{}
+	for rows.Next() {
+		var i model.WishlistItem
+		if err := rows.Scan(&i.ID, &i.UserID, &i.ProductID, &i.VariantID, &i.CreatedAt,
+			&i.ProductName, &i.ProductSlug, &i.MinPrice, &i.BasePrice, &i.IsPublished,
+			&i.VariantTitle, &i.VariantSKU, &i.PriceOverride, &i.StockQuantity); err != nil {
+			logger.ErrorLogger.Printf("Repo: Error scanning wishlist row: %v", err)
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+// Thêm vào wishlist (INSERT IGNORE theo UNIQUE user/product/variant)
+func (r *wishlistRepository) AddWishlistItem(ctx context.Context, userID int64, productID int64, variantID *int64) (int64, error) {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT IGNORE INTO wishlist_items (user_id, product_id, variant_id, created_at) VALUES (?, ?, ?, NOW())`,
+		userID, productID, variantID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to add wishlist item: %v", err)
+		return 0, err
+	}
+
+	var id int64
+	err = r.db.QueryRowContext(ctx,
+		`SELECT id FROM wishlist_items WHERE user_id = ? AND product_id = ? AND variant_key = IFNULL(?, 0)`,
+		userID, productID, variantID).Scan(&id)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to get wishlist item id: %v", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+// Xóa 1 dòng wishlist
+func (r *wishlistRepository) DeleteWishlistItem(ctx context.Context, userID int64, itemID int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM wishlist_items WHERE id = ? AND user_id = ?`, itemID, userID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to delete wishlist item: %v", err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Đăng ký báo có hàng: đã báo rồi thì mở lại lượt mới (notified_at = NULL)
+func (r *wishlistRepository) UpsertStockSubscription(ctx context.Context, userID int64, variantID int64) error {
+	query := `
+		INSERT INTO stock_subscriptions (user_id, variant_id, created_at)
+		VALUES (?, ?, NOW())
+		ON DUPLICATE KEY UPDATE
+			created_at = IF(notified_at IS NULL, created_at, NOW()),
+			notified_at = NULL
+	`
+	if _, err := r.db.ExecContext(ctx, query, userID, variantID); err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to upsert stock subscription: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Hủy đăng ký báo có hàng
+func (r *wishlistRepository) DeleteStockSubscription(ctx context.Context, userID int64, variantID int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM stock_subscriptions WHERE user_id = ? AND variant_id = ?`, userID, variantID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to delete stock subscription: %v", err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+const subscriptionSelect = `
+	SELECT s.id, s.user_id, s.variant_id, s.created_at, s.notified_at,
+	       u.email, v.product_id, p.name, v.title, v.sku
+	FROM stock_subscriptions s
+	JOIN users u ON u.id = s.user_id
+	JOIN product_variants v ON v.id = s.variant_id
+	JOIN products p ON p.id = v.product_id
+`
+
+func scanSubscriptions(rows *sql.Rows) ([]model.StockSubscription, error) {
+	subs := []model.StockSubscription{}
+	for rows.Next() {
+		var s model.StockSubscription
+		if err := rows.Scan(&s.ID, &s.UserID, &s.VariantID, &s.CreatedAt, &s.NotifiedAt,
+			&s.Email, &s.ProductID, &s.ProductName, &s.VariantTitle, &s.VariantSKU); err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
+
+// Danh sách đăng ký của user
+func (r *wishlistRepository) GetStockSubscriptionsByUserID(ctx context.Context, userID int64) ([]model.StockSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, subscriptionSelect+` WHERE s.user_id = ? ORDER BY s.created_at DESC`, userID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Error query stock subscriptions: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	return scanSubscriptions(rows)
+}
+
+// Lấy các đăng ký đang chờ của biến thể và đánh dấu đã báo trong cùng transaction (FOR UPDATE),
+// nên 2 lần nhập kho đồng thời không báo trùng 1 đăng ký
+func (r *wishlistRepository) ClaimPendingSubscriptions(ctx context.Context, variantID int64) ([]model.StockSubscription, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, subscriptionSelect+` WHERE s.variant_id = ? AND s.notified_at IS NULL FOR UPDATE`, variantID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Repo: Error claim stock subscriptions: %v", err)
+		return nil, err
+	}
+	subs, err := scanSubscriptions(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) == 0 {
+		return subs, nil
+	}
+
+	placeholders := make([]string, len(subs))
+	args := make([]interface{}, len(subs))
+	for i, s := range subs {
+		placeholders[i] = "?"
+		args[i] = s.ID
+	}
+	query := fmt.Sprintf("UPDATE stock_subscriptions SET notified_at = NOW() WHERE id IN (%s)", strings.Join(placeholders, ","))
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		logger.ErrorLogger.Printf("Repo: Failed to mark subscriptions notified: %v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
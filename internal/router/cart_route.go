@@ -9,7 +9,8 @@ import (
 // NewCartRouter định nghĩa các routes cho module Cart
 func NewCartRouter(mux *http.ServeMux, cartHandler cart.CartHandler) http.Handler {
 	
-	cartGroup := newGroup(mux, "/api/cart", middleware.AuthMiddleware)
+	// Khách chưa đăng nhập dùng cart token (header X-Cart-Token hoặc cookie cart_token)
+	cartGroup := newGroup(mux, "/api/cart", middleware.OptionalAuthMiddleware)
 
 	//  Xem giỏ hàng
 	cartGroup.HandleFunc("GET", "", cartHandler.GetCart)
@@ -11,6 +11,8 @@ func NewCategoryRouter(mux *http.ServeMux, catHandler category.CategoryHandler)
 
 	publicGroup.HandleFunc("GET", "", catHandler.UserGetActiveCategories)     // Lấy danh sách danh mục (Active)
 	publicGroup.HandleFunc("GET", "/search", catHandler.UserSearchCategories) // Tìm kiếm (Active)
+	publicGroup.HandleFunc("GET", "/tree", catHandler.UserGetCategoryTree)    // Cây danh mục (Active)
+	publicGroup.HandleFunc("GET", "/{id}/filters", catHandler.UserGetCategoryFilters) // Thông số dùng để lọc SP
 
 	// =================================================================
 	adminGroup := newGroup(mux, "/api/admin/categories", middleware.AdminOnlyMiddleware)
@@ -18,14 +20,22 @@ func NewCategoryRouter(mux *http.ServeMux, catHandler category.CategoryHandler)
 	// Các chức năng quản lý
 	adminGroup.HandleFunc("GET", "", catHandler.AdminGetAllCategories)        // Lấy tất cả danh mục (Cả ẩn)
 	adminGroup.HandleFunc("GET", "/search", catHandler.AdminSearchCategories) // Tìm kiếm (Cả ẩn)
+	adminGroup.HandleFunc("GET", "/tree", catHandler.AdminGetCategoryTree)    // Cây danh mục (Cả ẩn)
 	adminGroup.HandleFunc("POST", "", catHandler.CreateCategory)              // Tạo mới danh mục
 	adminGroup.HandleFunc("DELETE", "", catHandler.DeleteSoftCategories)          // Xóa danh mục
 
 	// Các chức năng theo ID
 	adminGroup.HandleFunc("GET", "/{id}", catHandler.AdminGetCategoryByID) // Xem chi tiết danh mục
 	adminGroup.HandleFunc("PUT", "/{id}", catHandler.UpdateCategory)       // Cập nhật
+	adminGroup.HandleFunc("PUT", "/{id}/move", catHandler.MoveCategory)    // Chuyển cha / đổi vị trí
 	// adminGroup.HandleFunc("DELETE", "/{id}", catHandler.DeleteCategory)    // Xóa mềm (Ẩn)
 
+	// Thông số kỹ thuật theo danh mục
+	adminGroup.HandleFunc("GET", "/{id}/attributes", catHandler.AdminGetCategoryAttributes)              // Schema thông số (kể cả kế thừa)
+	adminGroup.HandleFunc("POST", "/{id}/attributes", catHandler.CreateCategoryAttribute)                // Thêm thông số
+	adminGroup.HandleFunc("PUT", "/{id}/attributes/{attrId}", catHandler.UpdateCategoryAttribute)        // Cập nhật thông số
+	adminGroup.HandleFunc("DELETE", "/{id}/attributes/{attrId}", catHandler.DeleteCategoryAttribute)     // Xóa thông số
+
 	// Chức năng nâng cao
 	adminGroup.HandleFunc("DELETE", "/hard/{id}", catHandler.DeleteCategoryHard) // Xóa cứng (Vĩnh viễn)
 
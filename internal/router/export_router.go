@@ -0,0 +1,23 @@
+package router
+
+import (
+	"golang/internal/handler/export"
+	"golang/internal/middleware"
+	"net/http"
+)
+
+func NewExportRouter(mux *http.ServeMux, exportHandler export.ExportHandler) http.Handler {
+
+	adminGroup := newGroup(mux, "/api/admin/exports", middleware.AdminOnlyMiddleware)
+
+	//  Export CSV / XLSX (?format=csv|xlsx&async=true + bộ lọc như màn hình danh sách)
+	adminGroup.HandleFunc("GET", "/orders", exportHandler.ExportOrders)
+	adminGroup.HandleFunc("GET", "/top-products", exportHandler.ExportTopProducts)
+	adminGroup.HandleFunc("GET", "/users", exportHandler.ExportUsers)
+
+	//  Job export chạy nền
+	adminGroup.HandleFunc("GET", "/jobs/{id}", exportHandler.GetJob)
+	adminGroup.HandleFunc("GET", "/jobs/{id}/download", exportHandler.DownloadJob)
+
+	return mux
+}
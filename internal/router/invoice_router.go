@@ -0,0 +1,22 @@
+package router
+
+import (
+	"golang/internal/handler/invoice"
+	"golang/internal/middleware"
+	"net/http"
+)
+
+func NewInvoiceRouter(mux *http.ServeMux, invoiceHandler invoice.InvoiceHandler) http.Handler {
+
+	userGroup := newGroup(mux, "/api/orders", middleware.AuthMiddleware)
+
+	//  Tải hóa đơn PDF (chỉ chủ đơn hàng)
+	userGroup.HandleFunc("GET", "/{id}/invoice.pdf", invoiceHandler.DownloadMyInvoice)
+
+	adminGroup := newGroup(mux, "/api/admin/orders", middleware.AdminOnlyMiddleware)
+
+	//  Admin tải hóa đơn PDF
+	adminGroup.HandleFunc("GET", "/{id}/invoice.pdf", invoiceHandler.DownloadInvoice)
+
+	return mux
+}
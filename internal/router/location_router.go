@@ -0,0 +1,18 @@
+package router
+
+import (
+	"golang/internal/handler/location"
+	"net/http"
+)
+
+// NewLocationRouter định nghĩa các routes tra cứu đơn vị hành chính (Public - dùng cho dropdown địa chỉ)
+func NewLocationRouter(mux *http.ServeMux, h location.LocationHandler) http.Handler {
+	publicGroup := newGroup(mux, "/api/locations")
+
+	publicGroup.HandleFunc("GET", "/provinces", h.ListProvinces)                  // Tỉnh / thành phố
+	publicGroup.HandleFunc("GET", "/provinces/{code}/districts", h.ListDistricts) // Quận / huyện của tỉnh (file dữ liệu cũ)
+	publicGroup.HandleFunc("GET", "/provinces/{code}/wards", h.ListProvinceWards) // Phường / xã trực thuộc tỉnh
+	publicGroup.HandleFunc("GET", "/districts/{code}/wards", h.ListWards)         // Phường / xã của quận / huyện (file dữ liệu cũ)
+
+	return mux
+}
@@ -0,0 +1,37 @@
+package router
+
+import (
+	"golang/internal/handler/ordermessage"
+	"golang/internal/middleware"
+	"net/http"
+)
+
+func NewOrderMessageRouter(mux *http.ServeMux, messageHandler ordermessage.OrderMessageHandler) http.Handler {
+
+	userGroup := newGroup(mux, "/api/orders", middleware.AuthMiddleware)
+
+	//  Số tin nhắn chưa đọc trên các đơn hàng của tôi
+	userGroup.HandleFunc("GET", "/messages/unread", messageHandler.GetMyUnread)
+
+	//  Xem / gửi tin nhắn của đơn hàng (JSON hoặc multipart kèm file "attachments")
+	userGroup.HandleFunc("GET", "/{id}/messages", messageHandler.GetMyMessages)
+	userGroup.HandleFunc("POST", "/{id}/messages", messageHandler.SendMyMessage)
+
+	//  Tải file đính kèm
+	userGroup.HandleFunc("GET", "/{id}/messages/attachments/{attachmentId}", messageHandler.DownloadMyAttachment)
+
+	// =================================================================
+	adminGroup := newGroup(mux, "/api/admin/orders", middleware.AdminOnlyMiddleware)
+
+	//  Các đơn có tin nhắn khách gửi chưa đọc
+	adminGroup.HandleFunc("GET", "/messages/unread", messageHandler.GetStaffUnread)
+
+	//  Xem toàn bộ tin nhắn + ghi chú nội bộ, gửi tin (visibility = public | internal)
+	adminGroup.HandleFunc("GET", "/{id}/messages", messageHandler.GetMessages)
+	adminGroup.HandleFunc("POST", "/{id}/messages", messageHandler.SendStaffMessage)
+
+	//  Tải file đính kèm
+	adminGroup.HandleFunc("GET", "/{id}/messages/attachments/{attachmentId}", messageHandler.DownloadAttachment)
+
+	return mux
+}
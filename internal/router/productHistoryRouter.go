@@ -14,5 +14,8 @@ func NewProductHistoryRouter(mux *http.ServeMux, h producthistory.ProductHistory
 
 	// Lấy lịch sử thay đổi của tất cả sản phẩm
 	historyGroup.HandleFunc("GET", "/history/all", h.GetAllProductsHistoryHandler)
+
+	// Diff từng field của một bản ghi lịch sử
+	historyGroup.HandleFunc("GET", "/{id}/history/{historyId}/diff", h.GetProductHistoryDiffHandler)
 	return mux
 }
@@ -18,10 +18,26 @@ func NewProductReviewRouter(mux *http.ServeMux, h productreview.ProductReviewHan
 	// Authenticated user: create review
 	authUserGroup.HandleFunc("POST", "/product/{id}/reviews", h.CreateReviewHandler)
 
+	// Authenticated user: edit / delete own review
+	authUserGroup.HandleFunc("PUT", "/reviews/{reviewId}", h.UpdateMyReviewHandler)
+	authUserGroup.HandleFunc("DELETE", "/reviews/{reviewId}", h.DeleteMyReviewHandler)
+
+	// Authenticated user: helpful vote / report
+	authUserGroup.HandleFunc("POST", "/reviews/{reviewId}/helpful", h.MarkHelpfulHandler)
+	authUserGroup.HandleFunc("DELETE", "/reviews/{reviewId}/helpful", h.UnmarkHelpfulHandler)
+	authUserGroup.HandleFunc("POST", "/reviews/{reviewId}/report", h.ReportReviewHandler)
+
 	// Admin: delete review
 	adminGroup.HandleFunc("DELETE", "/product/reviews/{reviewId}", h.DeleteReviewHandler)
 
 	adminGroup.HandleFunc("GET", "/product/{id}/reviews", h.ListReviewsHandler)
 
+	// Admin: moderation queue & approve / reject
+	adminGroup.HandleFunc("GET", "/reviews/moderation", h.ModerationQueueHandler)
+	adminGroup.HandleFunc("PUT", "/reviews/{reviewId}/moderate", h.ModerateReviewHandler)
+
+	// Admin: sửa lệch rating tổng hợp của sản phẩm
+	adminGroup.HandleFunc("POST", "/reviews/ratings/recompute", h.RecomputeRatingsHandler)
+
 	return mux
 }
@@ -17,8 +17,20 @@ func NewProductVariantRouter(mux *http.ServeMux, h productvariant.ProductVariant
 	// Cập nhật biến thể
 	variantGroup.HandleFunc("PUT", "/{id}/variant/{variantId}", h.UpdateVariantHandler)
 
+	// Nhập / xuất kho (báo có hàng cho người đăng ký khi tồn kho từ 0 lên dương)
+	variantGroup.HandleFunc("POST", "/{id}/variant/{variantId}/stock", h.AdjustVariantStockHandler)
+
 	// Xóa biến thể
 	variantGroup.HandleFunc("DELETE", "/{id}/variant/{variantId}", h.DeleteVariantHandler)
 
+	// Option của sản phẩm (Color, Size...)
+	// (nhóm /admin/products: dạng /{id}/options dưới /admin/product sẽ xung đột pattern với PUT /admin/product/update/{id})
+	optionGroup := newGroup(mux, "/admin/products", middleware.AdminOnlyMiddleware)
+	optionGroup.HandleFunc("GET", "/{id}/options", h.GetProductOptionsHandler)
+	optionGroup.HandleFunc("PUT", "/{id}/options", h.SetProductOptionsHandler)
+
+	// Sinh biến thể theo tổ hợp option
+	variantGroup.HandleFunc("POST", "/{id}/variants/generate", h.GenerateVariantMatrixHandler)
+
 	return mux
 }
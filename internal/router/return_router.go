@@ -0,0 +1,45 @@
+package router
+
+import (
+	"golang/internal/handler/returns"
+	"golang/internal/middleware"
+	"net/http"
+)
+
+func NewReturnRouter(mux *http.ServeMux, returnHandler returns.ReturnHandler) http.Handler {
+
+	orderGroup := newGroup(mux, "/api/orders", middleware.AuthMiddleware)
+
+	//  Tạo yêu cầu trả hàng cho đơn đã hoàn thành (JSON hoặc multipart kèm ảnh "photos")
+	orderGroup.HandleFunc("POST", "/{id}/returns", returnHandler.CreateReturn)
+
+	userGroup := newGroup(mux, "/api/returns", middleware.AuthMiddleware)
+
+	//  Danh sách / chi tiết yêu cầu trả hàng của tôi
+	userGroup.HandleFunc("GET", "", returnHandler.GetMyReturns)
+	userGroup.HandleFunc("GET", "/{id}", returnHandler.GetMyReturn)
+
+	//  Hủy yêu cầu (chỉ khi shop chưa xử lý)
+	userGroup.HandleFunc("POST", "/{id}/cancel", returnHandler.CancelMyReturn)
+
+	//  Tải ảnh
+	userGroup.HandleFunc("GET", "/{id}/photos/{photoId}", returnHandler.DownloadMyPhoto)
+
+	// =================================================================
+	adminGroup := newGroup(mux, "/api/admin/returns", middleware.AdminOnlyMiddleware)
+
+	//  Tìm kiếm (status, order_id, user_id) / chi tiết kèm lịch sử trạng thái
+	adminGroup.HandleFunc("GET", "", returnHandler.SearchReturns)
+	adminGroup.HandleFunc("GET", "/{id}", returnHandler.GetReturn)
+
+	//  Duyệt / từ chối -> nhận hàng (restock tùy chọn) -> hoàn tiền
+	adminGroup.HandleFunc("POST", "/{id}/approve", returnHandler.ApproveReturn)
+	adminGroup.HandleFunc("POST", "/{id}/reject", returnHandler.RejectReturn)
+	adminGroup.HandleFunc("POST", "/{id}/receive", returnHandler.ReceiveReturn)
+	adminGroup.HandleFunc("POST", "/{id}/refund", returnHandler.RefundReturn)
+
+	//  Tải ảnh
+	adminGroup.HandleFunc("GET", "/{id}/photos/{photoId}", returnHandler.DownloadPhoto)
+
+	return mux
+}
@@ -17,6 +17,9 @@ func NewProductRouter(mux *http.ServeMux, h product.ProductHandler) http.Handler
 	adminGroup.HandleFunc("GET", "/product/all", h.AdminGetAllProductHandler)				  // Lấy tất cả (cả đã xóa mềm)
 	adminGroup.HandleFunc("POST", "/products", h.AdminGetManyProductHandler)                  // Lấy nhiều (Active)
 	adminGroup.HandleFunc("PUT", "/product/update/{id}", h.UpdateProductHandler)               // Cập nhật
+	adminGroup.HandleFunc("POST", "/product/{id}/history/{historyId}/revert", h.AdminRevertProductHistoryHandler) // Khôi phục theo lịch sử
+	adminGroup.HandleFunc("GET", "/products/{id}/attributes", h.AdminGetProductAttributesHandler)    // Thông số kỹ thuật
+	adminGroup.HandleFunc("PUT", "/products/{id}/attributes", h.AdminSetProductAttributesHandler)    // Ghi đè thông số kỹ thuật
 	
 
 	//  Nhóm quản lý nhiều
@@ -24,6 +27,11 @@ func NewProductRouter(mux *http.ServeMux, h product.ProductHandler) http.Handler
 	adminGroup.HandleFunc("GET", "/products/deleted", h.AdminGetAllSoftDeletedProductsHandler)      // Lấy thùng rác
 	adminGroup.HandleFunc("POST", "/products/delesoft", h.AdminBulkDeleteSoftProductsHandler) 		// Xóa mềm 
 	adminGroup.HandleFunc("DELETE", "/products/deleall", h.AdminDeleteAllProductsHandler)           // Dọn sạch thùng rác (Hard delete)
+	adminGroup.HandleFunc("DELETE", "/product/hard/{id}", h.AdminHardDeleteProductHandler)          // Xóa cứng 1 SP trong thùng rác
+	adminGroup.HandleFunc("POST", "/product/{id}/restore", h.AdminRestoreProductHandler)            // Khôi phục 1 SP
+	adminGroup.HandleFunc("POST", "/products/restore", h.AdminBulkRestoreProductsHandler)           // Khôi phục nhiều SP
+	adminGroup.HandleFunc("GET", "/products/scheduled", h.AdminGetScheduledChangesHandler)          // Lịch publish / unpublish sắp tới
+	adminGroup.HandleFunc("POST", "/products/prices/recompute", h.AdminRecomputePricesHandler)      // Tính lại min/max price từ biến thể
 
 	// adminGroup.HandleFunc("GET", "/product/", h.AdminGetProductHandler)
 	// adminGroup.HandleFunc("DELETE", "/product/delesoft/{id}", h.AdminDeleteSoftProductHandler)
@@ -0,0 +1,26 @@
+package router
+
+import (
+	"golang/internal/handler/shipping"
+	"golang/internal/middleware"
+	"net/http"
+)
+
+// NewShippingRouter định nghĩa các routes cấu hình vùng / phương thức giao hàng (Admin)
+func NewShippingRouter(mux *http.ServeMux, h shipping.ShippingHandler) http.Handler {
+	adminGroup := newGroup(mux, "/api/admin/shipping", middleware.AdminOnlyMiddleware)
+
+	// Vùng giao hàng
+	adminGroup.HandleFunc("GET", "/zones", h.ListZones)
+	adminGroup.HandleFunc("POST", "/zones", h.CreateZone)
+	adminGroup.HandleFunc("GET", "/zones/{id}", h.GetZone)
+	adminGroup.HandleFunc("PUT", "/zones/{id}", h.UpdateZone)
+	adminGroup.HandleFunc("DELETE", "/zones/{id}", h.DeleteZone)
+
+	// Phương thức giao hàng của vùng
+	adminGroup.HandleFunc("POST", "/zones/{id}/methods", h.CreateMethod)
+	adminGroup.HandleFunc("PUT", "/zones/{id}/methods/{methodId}", h.UpdateMethod)
+	adminGroup.HandleFunc("DELETE", "/zones/{id}/methods/{methodId}", h.DeleteMethod)
+
+	return mux
+}
@@ -22,5 +22,39 @@ func NewStatsRouter(mux *http.ServeMux, statsHandler stats.StatsHandler) http.Ha
 	//  Sync dữ liệu thủ công
 	adminGroup.HandleFunc("POST", "/sync", statsHandler.SyncDailyStats)
 
+	//  Tính lại thống kê cho khoảng ngày (?from=&to=)
+	adminGroup.HandleFunc("POST", "/backfill", statsHandler.Backfill)
+
+	//  Doanh thu theo thời gian (?from=&to=&granularity=day|week|month&compare=previous_period|previous_year)
+	adminGroup.HandleFunc("GET", "/revenue", statsHandler.GetRevenueSeries)
+
+	//  Lợi nhuận gộp (?start_date=&end_date=&limit=&sort_by=)
+	adminGroup.HandleFunc("GET", "/margins/products", statsHandler.GetProductMargins)
+	adminGroup.HandleFunc("GET", "/margins/variants", statsHandler.GetVariantMargins)
+	adminGroup.HandleFunc("GET", "/margins/categories", statsHandler.GetCategoryMargins)
+	adminGroup.HandleFunc("GET", "/margins/daily", statsHandler.GetDailyMargins)
+
+	//  Cảnh báo biến thể bán dưới giá vốn
+	adminGroup.HandleFunc("GET", "/margins/alerts", statsHandler.GetMarginAlerts)
+
+	//  Phân tích khách hàng
+	adminGroup.HandleFunc("GET", "/customers", statsHandler.SearchCustomers)
+	adminGroup.HandleFunc("GET", "/customers/segments", statsHandler.GetRFMSegments)
+	adminGroup.HandleFunc("GET", "/customers/cohorts", statsHandler.GetCohorts)
+	adminGroup.HandleFunc("GET", "/customers/repeat-rate", statsHandler.GetRepeatPurchase)
+	adminGroup.HandleFunc("POST", "/customers/refresh", statsHandler.RefreshCustomerAnalytics)
+
+	//  Tồn kho: hết hàng, dưới ngưỡng, sắp hết theo tốc độ bán, tồn đọng (?status=&horizon_days=&dead_days=&page=&limit=)
+	adminGroup.HandleFunc("GET", "/inventory-health", statsHandler.GetInventoryHealth)
+	adminGroup.HandleFunc("POST", "/inventory-health/refresh", statsHandler.RefreshInventoryForecast)
+
+	//  Phễu giỏ hàng -> đơn hàng (?start_date=&end_date=&limit=)
+	adminGroup.HandleFunc("GET", "/funnel", statsHandler.GetCartFunnel)
+	adminGroup.HandleFunc("GET", "/funnel/abandoned-products", statsHandler.GetAbandonedProducts)
+	adminGroup.HandleFunc("POST", "/funnel/refresh", statsHandler.RefreshCartFunnel)
+
+	//  Giỏ hàng bỏ quên của user đồng ý nhận marketing (?min_hours=&max_days=&page=&limit=)
+	adminGroup.HandleFunc("GET", "/abandoned-carts", statsHandler.GetAbandonedCarts)
+
 	return mux
 }
\ No newline at end of file
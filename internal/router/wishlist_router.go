@@ -0,0 +1,23 @@
+package router
+
+import (
+	"golang/internal/handler/wishlist"
+	"golang/internal/middleware"
+	"net/http"
+)
+
+// NewWishlistRouter định nghĩa các routes cho wishlist và báo có hàng
+func NewWishlistRouter(mux *http.ServeMux, h wishlist.WishlistHandler) http.Handler {
+	wishlistGroup := newGroup(mux, "/api/wishlist", middleware.AuthMiddleware)
+
+	wishlistGroup.HandleFunc("GET", "", h.GetWishlist)                // Xem wishlist
+	wishlistGroup.HandleFunc("POST", "", h.AddToWishlist)             // Thêm sản phẩm / biến thể
+	wishlistGroup.HandleFunc("DELETE", "/{id}", h.RemoveFromWishlist) // Xóa 1 dòng
+
+	// Báo có hàng trở lại
+	wishlistGroup.HandleFunc("GET", "/alerts", h.GetStockAlerts)                       // Danh sách đăng ký
+	wishlistGroup.HandleFunc("POST", "/alerts", h.SubscribeStockAlert)                 // Đăng ký
+	wishlistGroup.HandleFunc("DELETE", "/alerts/{variantId}", h.UnsubscribeStockAlert) // Hủy đăng ký
+
+	return mux
+}
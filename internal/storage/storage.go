@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const defaultLocalDir = "uploads"
+
+var ErrInvalidKey = errors.New("storage: key không hợp lệ")
+
+// Storage - Nơi lưu file (đính kèm, ảnh...), có thể thay bằng S3 / GCS mà không đổi nghiệp vụ.
+// Key dạng đường dẫn tương đối, phân cách bằng "/" (VD: order-messages/12/ab12cd.png)
+type Storage interface {
+	Save(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type localStorage struct {
+	dir string
+}
+
+// NewLocalStorage - Lưu file trên ổ đĩa của server (dir rỗng -> thư mục uploads)
+func NewLocalStorage(dir string) Storage {
+	if dir == "" {
+		dir = defaultLocalDir
+	}
+	return &localStorage{dir: dir}
+}
+
+// path: Chặn key thoát ra ngoài thư mục gốc (../)
+func (s *localStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || strings.Contains(key, "\\") || clean != "/"+key {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean[1:])), nil
+}
+
+func (s *localStorage) Save(ctx context.Context, key string, r io.Reader) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	// Ghi ra file tạm rồi đổi tên để không bao giờ đọc phải file ghi dở
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *localStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+func (s *localStorage) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
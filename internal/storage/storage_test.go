@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoragePath(t *testing.T) {
+	s := &localStorage{dir: "/data/uploads"}
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "order-messages/12/ab12cd.png", want: "/data/uploads/order-messages/12/ab12cd.png"},
+		{key: "a.txt", want: "/data/uploads/a.txt"},
+		{key: "", wantErr: true},
+		{key: "/", wantErr: true},
+		{key: "../secret", wantErr: true},
+		{key: "a/../../secret", wantErr: true},
+		{key: "a/../b.txt", wantErr: true}, // Không tự chuẩn hoá, key phải ở dạng sạch
+		{key: "./a.txt", wantErr: true},
+		{key: "/etc/passwd", wantErr: true},
+		{key: "a//b.txt", wantErr: true},
+		{key: "a/b/", wantErr: true},
+		{key: "..", wantErr: true},
+		{key: `..\secret`, wantErr: true},
+		{key: `a\b.txt`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := s.path(tt.key)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidKey) {
+					t.Fatalf("path(%q) = (%q, %v), want ErrInvalidKey", tt.key, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("path(%q) unexpected error: %v", tt.key, err)
+			}
+			if got != filepath.FromSlash(tt.want) {
+				t.Errorf("path(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalStorageSaveOpenDelete(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalStorage(dir)
+	ctx := context.Background()
+	key := "order-messages/1/note.txt"
+
+	if err := s.Save(ctx, key, strings.NewReader("xin chào")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	rc, err := s.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(body) != "xin chào" {
+		t.Errorf("content = %q", body)
+	}
+
+	// Không để lại file tạm sau khi ghi
+	entries, _ := os.ReadDir(filepath.Join(dir, "order-messages", "1"))
+	if len(entries) != 1 {
+		t.Errorf("got %d files in key dir, want 1", len(entries))
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("Delete missing file should be nil, got %v", err)
+	}
+	if _, err := s.Open(ctx, key); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Open after delete = %v, want ErrNotExist", err)
+	}
+	if err := s.Save(ctx, "../escape.txt", strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Save outside dir = %v, want ErrInvalidKey", err)
+	}
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrFileTooLarge = errors.New("file vượt quá dung lượng cho phép")
+	ErrFileType     = errors.New("định dạng file không được hỗ trợ")
+)
+
+// Ảnh và pdf (content type -> đuôi file khi lưu)
+var (
+	ImageTypes = map[string]string{
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/gif":  ".gif",
+		"image/webp": ".webp",
+	}
+	ImageAndPDFTypes = map[string]string{
+		"image/jpeg":      ".jpg",
+		"image/png":       ".png",
+		"image/gif":       ".gif",
+		"image/webp":      ".webp",
+		"application/pdf": ".pdf",
+	}
+)
+
+// Upload: File đã lưu
+type Upload struct {
+	Key         string
+	FileName    string
+	ContentType string
+	SizeBytes   int64
+}
+
+// limitedReader: Báo lỗi khi đọc quá max byte (không tin dung lượng client gửi lên)
+type limitedReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	if l.left -= int64(n); l.left < 0 {
+		return n, ErrFileTooLarge
+	}
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// SaveUpload: Nhận diện định dạng theo nội dung file (không tin đuôi file), chặn file quá maxBytes
+// rồi lưu với tên ngẫu nhiên trong thư mục prefix (VD: order-messages/12)
+func SaveUpload(ctx context.Context, s Storage, prefix string, fileName string, r io.Reader, maxBytes int64, allowed map[string]string) (*Upload, error) {
+	br := bufio.NewReaderSize(&limitedReader{r: r, left: maxBytes}, 512)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	contentType := http.DetectContentType(head)
+	ext, ok := allowed[contentType]
+	if !ok {
+		return nil, ErrFileType
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s/%s%s", strings.Trim(prefix, "/"), hex.EncodeToString(buf), ext)
+
+	counter := &countingReader{r: br}
+	if err := s.Save(ctx, key, counter); err != nil {
+		_ = s.Delete(context.WithoutCancel(ctx), key)
+		return nil, err
+	}
+	return &Upload{
+		Key:         key,
+		FileName:    uploadName(fileName, ext),
+		ContentType: contentType,
+		SizeBytes:   counter.n,
+	}, nil
+}
+
+// uploadName: Tên file gốc (bỏ đường dẫn, tối đa 255 byte), không có thì đặt tên mặc định
+func uploadName(name string, ext string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "file" + ext
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
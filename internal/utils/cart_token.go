@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tên header / cookie mang cart token của khách
+const (
+	CartTokenHeader = "X-Cart-Token"
+	CartTokenCookie = "cart_token"
+)
+
+// Thời gian sống mặc định của giỏ hàng khách (ngày)
+const defaultGuestCartTTLDays = 30
+
+// cartTokenSecret: khóa ký cart token, ưu tiên CART_TOKEN_SECRET, fallback JWT_SECRET
+func cartTokenSecret() []byte {
+	if s := os.Getenv("CART_TOKEN_SECRET"); s != "" {
+		return []byte(s)
+	}
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
+func signCartNonce(nonce string) string {
+	mac := hmac.New(sha256.New, cartTokenSecret())
+	mac.Write([]byte(nonce))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GuestCartTTL: thời gian sống của giỏ hàng khách (GUEST_CART_TTL_DAYS)
+func GuestCartTTL() time.Duration {
+	days := defaultGuestCartTTLDays
+	if v, err := strconv.Atoi(os.Getenv("GUEST_CART_TTL_DAYS")); err == nil && v > 0 {
+		days = v
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// NewCartToken: sinh cart token mới dạng "<nonce>.<chữ ký>", trả về cả token và nonce (lưu DB)
+func NewCartToken() (token string, nonce string, err error) {
+	buf := make([]byte, 16)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	nonce = hex.EncodeToString(buf)
+	return nonce + "." + signCartNonce(nonce), nonce, nil
+}
+
+// ParseCartToken: kiểm tra chữ ký, trả về nonce nếu hợp lệ
+func ParseCartToken(token string) (string, bool) {
+	nonce, sig, found := strings.Cut(token, ".")
+	if !found || len(nonce) != 32 {
+		return "", false
+	}
+	if !hmac.Equal([]byte(sig), []byte(signCartNonce(nonce))) {
+		return "", false
+	}
+	return nonce, true
+}
+
+// CartTokenFromRequest: lấy cart token từ header, nếu không có thì lấy từ cookie
+func CartTokenFromRequest(r *http.Request) string {
+	if t := strings.TrimSpace(r.Header.Get(CartTokenHeader)); t != "" {
+		return t
+	}
+	if c, err := r.Cookie(CartTokenCookie); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+// SetCartTokenCookie: trả cart token cho client qua cả header lẫn cookie
+func SetCartTokenCookie(w http.ResponseWriter, token string) {
+	w.Header().Set(CartTokenHeader, token)
+	http.SetCookie(w, &http.Cookie{
+		Name:     CartTokenCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(GuestCartTTL().Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearCartTokenCookie: xóa cookie cart token (sau khi đã gộp vào giỏ của user)
+func ClearCartTokenCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CartTokenCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCartToken(t *testing.T) {
+	t.Setenv("CART_TOKEN_SECRET", "cart-secret")
+	token, nonce, err := NewCartToken()
+	if err != nil {
+		t.Fatalf("NewCartToken: %v", err)
+	}
+	if len(nonce) != 32 || !strings.HasPrefix(token, nonce+".") {
+		t.Fatalf("unexpected token %q / nonce %q", token, nonce)
+	}
+	sig := strings.TrimPrefix(token, nonce+".")
+	otherNonce := strings.Repeat("a", 32)
+
+	tests := []struct {
+		name      string
+		token     string
+		wantNonce string
+		wantOK    bool
+	}{
+		{"valid", token, nonce, true},
+		{"empty", "", "", false},
+		{"missing signature", nonce, "", false},
+		{"empty signature", nonce + ".", "", false},
+		{"tampered signature", nonce + "." + strings.Repeat("0", len(sig)), "", false},
+		{"signature of another nonce", otherNonce + "." + sig, "", false},
+		{"short nonce", nonce[:31] + "." + sig, "", false},
+		{"uppercase signature", nonce + "." + strings.ToUpper(sig), "", false},
+		{"valid for other nonce", otherNonce + "." + signCartNonce(otherNonce), otherNonce, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotNonce, ok := ParseCartToken(tt.token)
+			if ok != tt.wantOK || gotNonce != tt.wantNonce {
+				t.Errorf("ParseCartToken(%q) = (%q, %v), want (%q, %v)", tt.token, gotNonce, ok, tt.wantNonce, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCartTokenSecret(t *testing.T) {
+	const nonce = "0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		name       string
+		signSecret string
+		signJWT    string
+		readSecret string
+		readJWT    string
+		wantOK     bool
+	}{
+		{"same cart secret", "s1", "", "s1", "", true},
+		{"cart secret rotated", "s1", "", "s2", "", false},
+		{"fallback to jwt secret", "", "jwt", "", "jwt", true},
+		{"jwt secret changed", "", "jwt", "", "jwt2", false},
+		{"cart secret preferred over jwt", "s1", "jwt", "s1", "other", true},
+		{"cart secret added later", "", "jwt", "s1", "jwt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CART_TOKEN_SECRET", tt.signSecret)
+			t.Setenv("JWT_SECRET", tt.signJWT)
+			token := nonce + "." + signCartNonce(nonce)
+
+			t.Setenv("CART_TOKEN_SECRET", tt.readSecret)
+			t.Setenv("JWT_SECRET", tt.readJWT)
+			if _, ok := ParseCartToken(token); ok != tt.wantOK {
+				t.Errorf("ParseCartToken ok = %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGuestCartTTL(t *testing.T) {
+	tests := []struct {
+		env  string
+		want time.Duration
+	}{
+		{"", 30 * 24 * time.Hour},
+		{"7", 7 * 24 * time.Hour},
+		{"0", 30 * 24 * time.Hour},
+		{"-3", 30 * 24 * time.Hour},
+		{"abc", 30 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Setenv("GUEST_CART_TTL_DAYS", tt.env)
+		if got := GuestCartTTL(); got != tt.want {
+			t.Errorf("GuestCartTTL() with %q = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestCartTokenFromRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		cookie string
+		want   string
+	}{
+		{"header", "from-header", "", "from-header"},
+		{"header wins over cookie", " from-header ", "from-cookie", "from-header"},
+		{"cookie", "", "from-cookie", "from-cookie"},
+		{"none", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/cart", nil)
+			if tt.header != "" {
+				r.Header.Set(CartTokenHeader, tt.header)
+			}
+			if tt.cookie != "" {
+				r.Header.Set("Cookie", CartTokenCookie+"="+tt.cookie)
+			}
+			if got := CartTokenFromRequest(r); got != tt.want {
+				t.Errorf("CartTokenFromRequest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"fmt"
+	"golang/internal/model"
+	"strconv"
+	"strings"
+)
+
+// ValidateAttributeDefinition - Kiểm tra định nghĩa thông số: enum phải có danh sách giá trị, unit chỉ dùng cho number
+func ValidateAttributeDefinition(dataType string, unit *string, enumValues []string) error {
+	if dataType == model.AttributeTypeEnum {
+		if len(enumValues) == 0 {
+			return fmt.Errorf("thuộc tính kiểu enum phải có enum_values")
+		}
+		seen := make(map[string]bool, len(enumValues))
+		for _, v := range enumValues {
+			if seen[v] {
+				return fmt.Errorf("enum_values bị trùng giá trị '%s'", v)
+			}
+			seen[v] = true
+		}
+	} else if len(enumValues) > 0 {
+		return fmt.Errorf("enum_values chỉ dùng cho thuộc tính kiểu enum")
+	}
+	if unit != nil && *unit != "" && dataType != model.AttributeTypeNumber {
+		return fmt.Errorf("unit chỉ dùng cho thuộc tính kiểu number")
+	}
+	return nil
+}
+
+// NormalizeAttributeValue - Kiểm tra và chuẩn hóa giá trị theo kiểu của thuộc tính
+func NormalizeAttributeValue(def model.CategoryAttribute, raw any) (model.ProductAttributeValueInput, error) {
+	out := model.ProductAttributeValueInput{AttributeID: def.ID}
+
+	switch def.DataType {
+	case model.AttributeTypeNumber:
+		var n float64
+		switch v := raw.(type) {
+		case float64:
+			n = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return out, fmt.Errorf("'%s' phải là số", def.Code)
+			}
+			n = parsed
+		default:
+			return out, fmt.Errorf("'%s' phải là số", def.Code)
+		}
+		out.ValueText = strconv.FormatFloat(n, 'f', -1, 64)
+		out.ValueNumber = &n
+	case model.AttributeTypeBoolean:
+		b, ok := raw.(bool)
+		if !ok {
+			return out, fmt.Errorf("'%s' phải là true/false", def.Code)
+		}
+		out.ValueText = strconv.FormatBool(b)
+	case model.AttributeTypeEnum:
+		s, ok := raw.(string)
+		if !ok {
+			return out, fmt.Errorf("'%s' phải là chuỗi", def.Code)
+		}
+		allowed := false
+		for _, v := range def.EnumValues {
+			if v == s {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return out, fmt.Errorf("giá trị '%s' không hợp lệ cho '%s'", s, def.Code)
+		}
+		out.ValueText = s
+	default:
+		s, ok := raw.(string)
+		if !ok {
+			return out, fmt.Errorf("'%s' phải là chuỗi", def.Code)
+		}
+		s = strings.TrimSpace(s)
+		if s == "" || len(s) > 255 {
+			return out, fmt.Errorf("'%s' phải dài 1-255 ký tự", def.Code)
+		}
+		out.ValueText = s
+	}
+	return out, nil
+}
+
+// BuildProductAttributeValues - Đối chiếu map code -> giá trị với schema, bắt buộc đủ các thuộc tính required
+func BuildProductAttributeValues(defs []model.CategoryAttribute, input map[string]any) ([]model.ProductAttributeValueInput, error) {
+	byCode := make(map[string]model.CategoryAttribute, len(defs))
+	for _, def := range defs {
+		// Cùng code ở nhiều danh mục: giữ định nghĩa xuất hiện trước
+		if _, ok := byCode[def.Code]; !ok {
+			byCode[def.Code] = def
+		}
+	}
+
+	values := []model.ProductAttributeValueInput{}
+	for code, raw := range input {
+		def, ok := byCode[code]
+		if !ok {
+			return nil, fmt.Errorf("thuộc tính '%s' không thuộc danh mục của sản phẩm", code)
+		}
+		if raw == nil {
+			continue
+		}
+		val, err := NormalizeAttributeValue(def, raw)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, val)
+	}
+
+	for code, def := range byCode {
+		if !def.IsRequired {
+			continue
+		}
+		if raw, ok := input[code]; !ok || raw == nil {
+			return nil, fmt.Errorf("thiếu thuộc tính bắt buộc '%s'", code)
+		}
+	}
+	return values, nil
+}
+
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"golang/internal/model"
+)
+
+func TestBuildProductAttributeValues(t *testing.T) {
+	defs := []model.CategoryAttribute{
+		{ID: 1, Code: "brand", DataType: model.AttributeTypeText, IsRequired: true},
+		{ID: 2, Code: "ram_gb", DataType: model.AttributeTypeNumber},
+		{ID: 3, Code: "color", DataType: model.AttributeTypeEnum, EnumValues: []string{"Đen", "Trắng"}},
+		{ID: 4, Code: "waterproof", DataType: model.AttributeTypeBoolean},
+		// Trùng code ở danh mục khác: giữ định nghĩa đầu tiên
+		{ID: 5, Code: "brand", DataType: model.AttributeTypeNumber},
+	}
+
+	type value struct {
+		id     int64
+		text   string
+		number *float64
+	}
+	num := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name    string
+		input   map[string]any
+		want    []value
+		wantErr string
+	}{
+		{
+			name:  "all types",
+			input: map[string]any{"brand": "  Apple ", "ram_gb": 8.0, "color": "Đen", "waterproof": true},
+			want: []value{
+				{id: 1, text: "Apple"},
+				{id: 2, text: "8", number: num(8)},
+				{id: 3, text: "Đen"},
+				{id: 4, text: "true"},
+			},
+		},
+		{
+			name:  "number from string",
+			input: map[string]any{"brand": "Samsung", "ram_gb": " 12.5 "},
+			want:  []value{{id: 1, text: "Samsung"}, {id: 2, text: "12.5", number: num(12.5)}},
+		},
+		{
+			name:  "nil optional value is skipped",
+			input: map[string]any{"brand": "Xiaomi", "color": nil},
+			want:  []value{{id: 1, text: "Xiaomi"}},
+		},
+		{name: "missing required", input: map[string]any{"ram_gb": 8.0}, wantErr: "thiếu thuộc tính bắt buộc 'brand'"},
+		{name: "nil required", input: map[string]any{"brand": nil}, wantErr: "thiếu thuộc tính bắt buộc 'brand'"},
+		{name: "unknown code", input: map[string]any{"brand": "A", "cpu": "M3"}, wantErr: "không thuộc danh mục"},
+		{name: "invalid number", input: map[string]any{"brand": "A", "ram_gb": "tám"}, wantErr: "'ram_gb' phải là số"},
+		{name: "enum not allowed", input: map[string]any{"brand": "A", "color": "Đỏ"}, wantErr: "giá trị 'Đỏ' không hợp lệ"},
+		{name: "boolean as string", input: map[string]any{"brand": "A", "waterproof": "yes"}, wantErr: "'waterproof' phải là true/false"},
+		{name: "blank text", input: map[string]any{"brand": "   "}, wantErr: "'brand' phải dài 1-255 ký tự"},
+		{name: "text too long", input: map[string]any{"brand": strings.Repeat("a", 256)}, wantErr: "'brand' phải dài 1-255 ký tự"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildProductAttributeValues(defs, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			sort.Slice(got, func(i, j int) bool { return got[i].AttributeID < got[j].AttributeID })
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d values, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.AttributeID != w.id || g.ValueText != w.text {
+					t.Errorf("value %d = {%d %q}, want {%d %q}", i, g.AttributeID, g.ValueText, w.id, w.text)
+				}
+				if (g.ValueNumber == nil) != (w.number == nil) || (g.ValueNumber != nil && *g.ValueNumber != *w.number) {
+					t.Errorf("value %d number = %v, want %v", i, g.ValueNumber, w.number)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package utils
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// IsDuplicateKey: lỗi vi phạm khóa UNIQUE của MySQL (1062), dùng khi 2 request cùng vượt qua bước kiểm tra trùng
+func IsDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
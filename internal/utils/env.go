@@ -0,0 +1,14 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+)
+
+// EnvInt: số nguyên dương từ biến môi trường, không có / sai thì dùng mặc định
+func EnvInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,34 @@
+package utils
+
+import "context"
+
+// AdminIDFromContext: Lấy ID admin từ context (key "userID" do middleware gán), nil nếu không có
+// JWT có thể lưu số dưới dạng float64 nên chấp nhận cả int64 / int / float64
+func AdminIDFromContext(ctx context.Context) *int64 {
+	switch v := ctx.Value("userID").(type) {
+	case int64:
+		return &v
+	case int:
+		id := int64(v)
+		return &id
+	case float64:
+		id := int64(v)
+		return &id
+	}
+	return nil
+}
+
+// ToInt64Slice: Đọc mảng ID từ giá trị JSON đã unmarshal ([]interface{} gồm các float64)
+func ToInt64Slice(v interface{}) []int64 {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return []int64{}
+	}
+	res := make([]int64, 0, len(arr))
+	for _, item := range arr {
+		if f, ok := item.(float64); ok {
+			res = append(res, int64(f))
+		}
+	}
+	return res
+}
@@ -0,0 +1,539 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/gosimple/unidecode"
+)
+
+// Khổ giấy A4 (đơn vị point, 1pt = 1/72 inch)
+const (
+	PDFPageWidth  = 595.28
+	PDFPageHeight = 841.89
+)
+
+var ErrInvalidFont = errors.New("file font không hợp lệ (chỉ hỗ trợ TrueType .ttf)")
+
+// TrueTypeFont: Font .ttf đã đọc sẵn, dùng chung cho nhiều tài liệu (chỉ đọc)
+type TrueTypeFont struct {
+	name       string
+	data       []byte
+	unitsPerEm float64
+	bbox       [4]int
+	ascent     int
+	descent    int
+	advances   []uint16
+	glyphs     map[rune]uint16
+}
+
+// LoadTrueTypeFont: Đọc font .ttf (cần có bảng cmap Unicode) để nhúng vào PDF, hiển thị đủ tiếng Việt
+func LoadTrueTypeFont(path string) (*TrueTypeFont, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.Map(func(r rune) rune {
+		if r < 128 && (r == '-' || r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z') {
+			return r
+		}
+		return -1
+	}, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	if name == "" {
+		name = "EmbeddedFont"
+	}
+	return parseTrueType(name, data)
+}
+
+func parseTrueType(name string, data []byte) (font *TrueTypeFont, err error) {
+	// Dữ liệu hỏng (offset vượt quá file) -> trả lỗi thay vì panic
+	defer func() {
+		if recover() != nil {
+			font, err = nil, ErrInvalidFont
+		}
+	}()
+
+	u16 := func(off int) int { return int(binary.BigEndian.Uint16(data[off:])) }
+	i16 := func(off int) int { return int(int16(binary.BigEndian.Uint16(data[off:]))) }
+	u32 := func(off int) int { return int(binary.BigEndian.Uint32(data[off:])) }
+
+	if len(data) < 12 || (u32(0) != 0x00010000 && string(data[:4]) != "true") {
+		return nil, ErrInvalidFont
+	}
+	tables := map[string]int{}
+	for i, n := 0, u16(4); i < n; i++ {
+		rec := 12 + 16*i
+		tables[string(data[rec:rec+4])] = u32(rec + 8)
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "cmap"} {
+		if _, ok := tables[tag]; !ok {
+			return nil, ErrInvalidFont
+		}
+	}
+
+	f := &TrueTypeFont{name: name, data: data, glyphs: map[rune]uint16{}}
+	head := tables["head"]
+	f.unitsPerEm = float64(u16(head + 18))
+	if f.unitsPerEm == 0 {
+		return nil, ErrInvalidFont
+	}
+	for i := range f.bbox {
+		f.bbox[i] = f.scale(i16(head + 36 + 2*i))
+	}
+	hhea := tables["hhea"]
+	f.ascent = f.scale(i16(hhea + 4))
+	f.descent = f.scale(i16(hhea + 6))
+	hmtx := tables["hmtx"]
+	f.advances = make([]uint16, u16(hhea+34))
+	for i := range f.advances {
+		f.advances[i] = uint16(u16(hmtx + 4*i))
+	}
+	if len(f.advances) == 0 {
+		return nil, ErrInvalidFont
+	}
+
+	// cmap: ưu tiên format 12 (đủ Unicode), sau đó format 4 (BMP)
+	cmap := tables["cmap"]
+	sub4, sub12 := -1, -1
+	for i, n := 0, u16(cmap+2); i < n; i++ {
+		rec := cmap + 4 + 8*i
+		platform, encoding, off := u16(rec), u16(rec+2), cmap+u32(rec+4)
+		if platform != 0 && !(platform == 3 && (encoding == 1 || encoding == 10)) {
+			continue
+		}
+		switch u16(off) {
+		case 4:
+			sub4 = off
+		case 12:
+			sub12 = off
+		}
+	}
+	switch {
+	case sub12 >= 0:
+		for i, n := 0, u32(sub12+12); i < n; i++ {
+			g := sub12 + 16 + 12*i
+			start, end, gid := u32(g), u32(g+4), u32(g+8)
+			for c := start; c <= end && c <= 0x10FFFF; c++ {
+				f.glyphs[rune(c)] = uint16(gid + c - start)
+			}
+		}
+	case sub4 >= 0:
+		segX2 := u16(sub4 + 6)
+		ends, starts := sub4+14, sub4+16+segX2
+		deltas, ranges := starts+segX2, starts+2*segX2
+		for s := 0; s < segX2; s += 2 {
+			start, end, delta, rangeOff := u16(starts+s), u16(ends+s), u16(deltas+s), u16(ranges+s)
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				gid := (c + delta) & 0xFFFF
+				if rangeOff != 0 {
+					if gid = u16(ranges + s + rangeOff + 2*(c-start)); gid != 0 {
+						gid = (gid + delta) & 0xFFFF
+					}
+				}
+				if gid != 0 {
+					f.glyphs[rune(c)] = uint16(gid)
+				}
+			}
+		}
+	default:
+		return nil, ErrInvalidFont
+	}
+	return f, nil
+}
+
+func (f *TrueTypeFont) scale(v int) int {
+	return int(math.Round(float64(v) * 1000 / f.unitsPerEm))
+}
+
+// advance: Độ rộng glyph (đơn vị 1/1000 cỡ chữ)
+func (f *TrueTypeFont) advance(gid uint16) int {
+	if int(gid) >= len(f.advances) {
+		return f.scale(int(f.advances[len(f.advances)-1]))
+	}
+	return f.scale(int(f.advances[gid]))
+}
+
+// Độ rộng ký tự ASCII 32..126 của font chuẩn Helvetica / Helvetica-Bold (đơn vị 1/1000)
+var (
+	helveticaWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// Font chuẩn không có dấu tiếng Việt -> chuyển về ASCII (₫ -> VND thay vì chữ D)
+var asciiReplacer = strings.NewReplacer("₫", "VND")
+
+func toASCII(s string) string {
+	s = unidecode.Unidecode(asciiReplacer.Replace(s))
+	return strings.Map(func(r rune) rune {
+		if r < 32 || r > 126 {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// pdfFont: Font dùng trong 1 tài liệu (ghi nhận glyph đã dùng để xuất bảng độ rộng)
+type pdfFont struct {
+	resource string
+	ttf      *TrueTypeFont // nil -> font chuẩn Helvetica
+	bold     bool
+	used     map[uint16]rune
+}
+
+func (f *pdfFont) width(s string) int {
+	w := 0
+	if f.ttf == nil {
+		table := &helveticaWidths
+		if f.bold {
+			table = &helveticaBoldWidths
+		}
+		for _, c := range toASCII(s) {
+			w += table[c-32]
+		}
+		return w
+	}
+	for _, r := range s {
+		w += f.ttf.advance(f.ttf.glyphs[r])
+	}
+	return w
+}
+
+// encode: Chuỗi PDF cho toán tử Tj
+func (f *pdfFont) encode(s string) string {
+	if f.ttf == nil {
+		var b strings.Builder
+		b.WriteByte('(')
+		for _, c := range toASCII(s) {
+			if c == '(' || c == ')' || c == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(c)
+		}
+		b.WriteByte(')')
+		return b.String()
+	}
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range s {
+		gid := f.ttf.glyphs[r]
+		if _, ok := f.used[gid]; !ok {
+			f.used[gid] = r
+		}
+		fmt.Fprintf(&b, "%04X", gid)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// PDFDocument: Tạo file PDF đơn giản (chữ, đường kẻ, ô nền) khổ A4.
+// Tọa độ tính từ góc trên bên trái, y tăng dần xuống dưới
+type PDFDocument struct {
+	fonts   [2]*pdfFont // thường, đậm
+	font    *pdfFont
+	size    float64
+	pages   []*bytes.Buffer
+	current int
+}
+
+// NewPDFDocument: regular = nil thì dùng font chuẩn Helvetica (chữ tiếng Việt bị bỏ dấu),
+// bold = nil thì chữ đậm dùng chung font thường
+func NewPDFDocument(regular, bold *TrueTypeFont) *PDFDocument {
+	d := &PDFDocument{size: 10}
+	d.fonts[0] = &pdfFont{resource: "F1", ttf: regular, used: map[uint16]rune{}}
+	if regular != nil && bold == nil {
+		d.fonts[1] = d.fonts[0]
+	} else {
+		d.fonts[1] = &pdfFont{resource: "F2", ttf: bold, bold: true, used: map[uint16]rune{}}
+	}
+	d.font = d.fonts[0]
+	return d
+}
+
+// AddPage: Thêm trang mới và chuyển sang vẽ trên trang đó
+func (d *PDFDocument) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.current = len(d.pages) - 1
+}
+
+// PageCount / SetPage: Quay lại trang cũ (VD: ghi số trang sau khi vẽ xong), page tính từ 1
+func (d *PDFDocument) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *PDFDocument) SetPage(page int) {
+	if page >= 1 && page <= len(d.pages) {
+		d.current = page - 1
+	}
+}
+
+func (d *PDFDocument) SetFont(bold bool, size float64) {
+	d.font = d.fonts[0]
+	if bold {
+		d.font = d.fonts[1]
+	}
+	d.size = size
+}
+
+// TextWidth: Độ rộng chuỗi với font hiện tại (point)
+func (d *PDFDocument) TextWidth(s string) float64 {
+	return float64(d.font.width(s)) * d.size / 1000
+}
+
+// Text: Viết chữ, (x, y) là điểm đầu dòng chân chữ
+func (d *PDFDocument) Text(x, y float64, s string) {
+	if s == "" || len(d.pages) == 0 {
+		return
+	}
+	fmt.Fprintf(d.pages[d.current], "BT /%s %s Tf %s %s Td %s Tj ET\n",
+		d.font.resource, pdfNum(d.size), pdfNum(x), pdfNum(PDFPageHeight-y), d.font.encode(s))
+}
+
+// TextRight: Viết chữ căn phải tại x
+func (d *PDFDocument) TextRight(x, y float64, s string) {
+	d.Text(x-d.TextWidth(s), y, s)
+}
+
+// WrapText: Ngắt chuỗi thành nhiều dòng theo độ rộng tối đa (ngắt ở khoảng trắng, từ quá dài thì cắt cứng)
+func (d *PDFDocument) WrapText(s string, maxWidth float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if d.TextWidth(candidate) <= maxWidth {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		line = ""
+		for _, r := range word {
+			if line != "" && d.TextWidth(line+string(r)) > maxWidth {
+				lines = append(lines, line)
+				line = ""
+			}
+			line += string(r)
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Line: Kẻ đường thẳng
+func (d *PDFDocument) Line(x1, y1, x2, y2, width float64) {
+	if len(d.pages) == 0 {
+		return
+	}
+	fmt.Fprintf(d.pages[d.current], "%s w %s %s m %s %s l S\n",
+		pdfNum(width), pdfNum(x1), pdfNum(PDFPageHeight-y1), pdfNum(x2), pdfNum(PDFPageHeight-y2))
+}
+
+// FillRect: Tô nền ô chữ nhật màu xám (gray: 0 đen -> 1 trắng)
+func (d *PDFDocument) FillRect(x, y, w, h, gray float64) {
+	if len(d.pages) == 0 {
+		return
+	}
+	fmt.Fprintf(d.pages[d.current], "%s g %s %s %s %s re f 0 g\n",
+		pdfNum(gray), pdfNum(x), pdfNum(PDFPageHeight-y-h), pdfNum(w), pdfNum(h))
+}
+
+// Output: Ghi file PDF. Cùng nội dung + cùng createdAt thì cho ra cùng 1 file
+func (d *PDFDocument) Output(w io.Writer, title string, createdAt time.Time) error {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	pw := &pdfWriter{}
+	pw.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// Số object: 1 catalog, 2 pages, 3 info, sau đó font, rồi từng trang (nội dung + trang)
+	fontRefs := make([]int, len(d.fonts))
+	next := 4
+	for i, f := range d.fonts {
+		if i > 0 && f == d.fonts[0] {
+			fontRefs[i] = fontRefs[0]
+			continue
+		}
+		fontRefs[i] = next
+		if f.ttf == nil {
+			next++
+		} else {
+			next += 5
+		}
+	}
+	pageRefs := make([]int, len(d.pages))
+	for i := range d.pages {
+		pageRefs[i] = next + 2*i + 1
+	}
+
+	pw.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pageRefs))
+	for i, ref := range pageRefs {
+		kids[i] = fmt.Sprintf("%d 0 R", ref)
+	}
+	pw.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pageRefs)))
+	pw.object(3, fmt.Sprintf("<< /Title %s /Producer (ECommerce) /CreationDate (D:%s) >>",
+		pdfUTF16(title), createdAt.Format("20060102150405")))
+
+	for i, f := range d.fonts {
+		if i > 0 && f == d.fonts[0] {
+			continue
+		}
+		if err := pw.font(fontRefs[i], f); err != nil {
+			return err
+		}
+	}
+
+	resources := fmt.Sprintf("<< /Font << /F1 %d 0 R /F2 %d 0 R >> >>", fontRefs[0], fontRefs[1])
+	for i, content := range d.pages {
+		if err := pw.stream(pageRefs[i]-1, "", content.Bytes()); err != nil {
+			return err
+		}
+		pw.object(pageRefs[i], fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			pdfNum(PDFPageWidth), pdfNum(PDFPageHeight), resources, pageRefs[i]-1))
+	}
+
+	// Bảng xref
+	xref := pw.buf.Len()
+	fmt.Fprintf(&pw.buf, "xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)+1)
+	for id := 1; id <= len(pw.offsets); id++ {
+		fmt.Fprintf(&pw.buf, "%010d 00000 n \n", pw.offsets[id])
+	}
+	fmt.Fprintf(&pw.buf, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pw.offsets)+1, xref)
+
+	_, err := w.Write(pw.buf.Bytes())
+	return err
+}
+
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+func (pw *pdfWriter) object(id int, body string) {
+	if pw.offsets == nil {
+		pw.offsets = map[int]int{}
+	}
+	pw.offsets[id] = pw.buf.Len()
+	fmt.Fprintf(&pw.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream: Ghi stream nén Flate, extra là các khóa thêm vào dictionary
+func (pw *pdfWriter) stream(id int, extra string, data []byte) error {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	pw.object(id, fmt.Sprintf("<< /Length %d /Filter /FlateDecode%s >>\nstream\n%s\nendstream", z.Len(), extra, z.Bytes()))
+	return nil
+}
+
+// font: Font chuẩn (1 object) hoặc font TrueType nhúng dạng Type0 / Identity-H (5 object liên tiếp từ id)
+func (pw *pdfWriter) font(id int, f *pdfFont) error {
+	if f.ttf == nil {
+		base := "Helvetica"
+		if f.bold {
+			base = "Helvetica-Bold"
+		}
+		pw.object(id, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", base))
+		return nil
+	}
+
+	t := f.ttf
+	gids := make([]int, 0, len(f.used))
+	for gid := range f.used {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+
+	var widths, cmap strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", gid, t.advance(uint16(gid)))
+	}
+	// ToUnicode: cho phép copy / tìm kiếm chữ trong file PDF
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(gids); start += 100 {
+		end := min(start+100, len(gids))
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", end-start)
+		for _, gid := range gids[start:end] {
+			var hex strings.Builder
+			for _, u := range utf16.Encode([]rune{f.used[uint16(gid)]}) {
+				fmt.Fprintf(&hex, "%04X", u)
+			}
+			fmt.Fprintf(&cmap, "<%04X> <%s>\n", gid, hex.String())
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+
+	pw.object(id, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		t.name, id+1, id+4))
+	pw.object(id+1, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+		"/FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s] >>", t.name, id+2, strings.TrimSpace(widths.String())))
+	pw.object(id+2, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] "+
+		"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		t.name, t.bbox[0], t.bbox[1], t.bbox[2], t.bbox[3], t.ascent, t.descent, t.ascent, id+3))
+	if err := pw.stream(id+3, fmt.Sprintf(" /Length1 %d", len(t.data)), t.data); err != nil {
+		return err
+	}
+	return pw.stream(id+4, "", []byte(cmap.String()))
+}
+
+// pdfNum: Số thực gọn (tối đa 2 chữ số thập phân)
+func pdfNum(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// pdfUTF16: Chuỗi văn bản PDF dạng UTF-16BE (cho metadata có dấu)
+func pdfUTF16(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Mã quốc gia Việt Nam
+const VietnamCallingCode = "84"
+
+var ErrInvalidPhone = errors.New("số điện thoại không hợp lệ")
+
+var (
+	phoneSeparators = strings.NewReplacer(" ", "", ".", "", "-", "", "(", "", ")", "")
+	e164Pattern     = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+	// Di động: +84 + 9 số (3x, 5x, 7x, 8x, 9x); cố định: +84 2xx + 8 số
+	vnPhonePattern = regexp.MustCompile(`^\+84([35789][0-9]{8}|2[0-9]{9})$`)
+)
+
+// NormalizePhoneE164: chuẩn hoá số điện thoại về dạng E.164 (+84987654321).
+// defaultCallingCode dùng cho số nhập dạng nội địa (0987654321); rỗng nghĩa là bắt buộc nhập kèm +mã quốc gia
+func NormalizePhoneE164(raw string, defaultCallingCode string) (string, error) {
+	s := phoneSeparators.Replace(strings.TrimSpace(raw))
+
+	switch {
+	case strings.HasPrefix(s, "+"):
+		// Đã có mã quốc gia
+	case strings.HasPrefix(s, "00"):
+		s = "+" + s[2:]
+	case defaultCallingCode == "":
+		return "", ErrInvalidPhone
+	case strings.HasPrefix(s, "0"):
+		s = "+" + defaultCallingCode + s[1:]
+	case strings.HasPrefix(s, defaultCallingCode):
+		s = "+" + s
+	default:
+		s = "+" + defaultCallingCode + s
+	}
+
+	if !e164Pattern.MatchString(s) {
+		return "", ErrInvalidPhone
+	}
+	if strings.HasPrefix(s, "+"+VietnamCallingCode) && !vnPhonePattern.MatchString(s) {
+		return "", ErrInvalidPhone
+	}
+	return s, nil
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+)
+
+// Khối lượng mặc định (gram) cho biến thể chưa khai báo weight_grams
+const defaultItemWeightGrams = 500
+
+// DefaultItemWeightGrams: khối lượng mặc định của 1 sản phẩm (SHIPPING_DEFAULT_WEIGHT_GRAMS)
+func DefaultItemWeightGrams() int {
+	if v, err := strconv.Atoi(os.Getenv("SHIPPING_DEFAULT_WEIGHT_GRAMS")); err == nil && v >= 0 {
+		return v
+	}
+	return defaultItemWeightGrams
+}
+
+// LineWeightGrams: khối lượng của 1 dòng hàng = khối lượng biến thể (hoặc mặc định) x số lượng
+func LineWeightGrams(weightGrams *int, quantity int) int {
+	w := DefaultItemWeightGrams()
+	if weightGrams != nil {
+		w = *weightGrams
+	}
+	return w * quantity
+}
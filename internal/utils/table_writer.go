@@ -0,0 +1,266 @@
+package utils
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Định dạng file export
+const (
+	TableFormatCSV  = "csv"
+	TableFormatXLSX = "xlsx"
+)
+
+// Số dòng tối đa của 1 sheet Excel (gồm dòng tiêu đề)
+const XLSXMaxRows = 1048576
+
+var ErrTooManyRows = errors.New("vượt quá số dòng tối đa của file Excel (1.048.576)")
+
+// TableWriter: Ghi bảng dữ liệu lần lượt từng dòng ra io.Writer (không giữ toàn bộ dữ liệu trong bộ nhớ).
+// Giá trị int / int64 / float64 được ghi thành ô số trong XLSX, còn lại ghi dạng chữ
+type TableWriter interface {
+	WriteRow(values ...interface{}) error
+	// Close: Ghi phần kết thúc file (XLSX bắt buộc gọi, nếu không file sẽ hỏng)
+	Close() error
+}
+
+// NewTableWriter: Tạo writer theo định dạng (csv | xlsx)
+func NewTableWriter(format string, w io.Writer, sheetName string) (TableWriter, error) {
+	switch format {
+	case TableFormatCSV:
+		return newCSVTableWriter(w)
+	case TableFormatXLSX:
+		return newXLSXTableWriter(w, sheetName)
+	}
+	return nil, fmt.Errorf("unsupported table format %q", format)
+}
+
+// TableContentType: Content-Type của định dạng export
+func TableContentType(format string) string {
+	if format == TableFormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+func cellText(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case int:
+		return strconv.Itoa(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		if val {
+			return "true"
+		}
+		return "false"
+	}
+	return fmt.Sprint(v)
+}
+
+// Ký tự đầu khiến Excel / Sheets hiểu ô là công thức (CSV / formula injection)
+const formulaPrefixes = "=+-@\t\r"
+
+// textCell: Nội dung ô dạng chữ; chuỗi bắt đầu bằng ký tự công thức được thêm ' phía trước để không bị thực thi khi mở file.
+// Ô số (int / int64 / float64) giữ nguyên để số âm không bị đổi thành chữ
+func textCell(v interface{}) string {
+	switch v.(type) {
+	case int, int64, float64:
+		return cellText(v)
+	}
+	s := cellText(v)
+	if s != "" && strings.ContainsRune(formulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// ===== CSV =====
+
+type csvTableWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+// newCSVTableWriter: Ghi BOM UTF-8 để Excel mở đúng tiếng Việt
+func newCSVTableWriter(w io.Writer) (*csvTableWriter, error) {
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return nil, err
+	}
+	return &csvTableWriter{w: csv.NewWriter(w)}, nil
+}
+
+func (t *csvTableWriter) WriteRow(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = textCell(v)
+	}
+	if err := t.w.Write(record); err != nil {
+		return err
+	}
+	// Đẩy dữ liệu ra định kỳ để client nhận dần
+	t.rows++
+	if t.rows%500 == 0 {
+		t.w.Flush()
+		return t.w.Error()
+	}
+	return nil
+}
+
+func (t *csvTableWriter) Close() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+// ===== XLSX (SpreadsheetML tối thiểu, 1 sheet, chuỗi inline) =====
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// Style 1 = chữ đậm (dòng tiêu đề)
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
+
+type xlsxTableWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+}
+
+func newXLSXTableWriter(w io.Writer, sheetName string) (*xlsxTableWriter, error) {
+	zw := zip.NewWriter(w)
+
+	var name strings.Builder
+	xml.EscapeText(&name, []byte(sanitizeSheetName(sheetName)))
+	workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return nil, err
+		}
+	}
+
+	// Sheet là entry cuối cùng của file zip nên có thể ghi dần tới khi Close
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(f)
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return &xlsxTableWriter{zw: zw, sheet: sheet}, nil
+}
+
+// sanitizeSheetName: Tên sheet tối đa 31 ký tự, không chứa : \ / ? * [ ]
+func sanitizeSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return "Sheet1"
+	}
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	return name
+}
+
+// xlsxColumn: 0 -> A, 25 -> Z, 26 -> AA
+func xlsxColumn(i int) string {
+	col := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		col = string(rune('A'+(i-1)%26)) + col
+	}
+	return col
+}
+
+func (t *xlsxTableWriter) WriteRow(values ...interface{}) error {
+	if t.rows >= XLSXMaxRows {
+		return ErrTooManyRows
+	}
+	t.rows++
+
+	// Dòng đầu tiên là tiêu đề -> in đậm
+	style := ""
+	if t.rows == 1 {
+		style = ` s="1"`
+	}
+
+	fmt.Fprintf(t.sheet, `<row r="%d">`, t.rows)
+	for i, v := range values {
+		ref := xlsxColumn(i) + strconv.Itoa(t.rows)
+		switch v.(type) {
+		case int, int64, float64:
+			fmt.Fprintf(t.sheet, `<c r="%s"%s><v>%s</v></c>`, ref, style, cellText(v))
+		default:
+			fmt.Fprintf(t.sheet, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+			if err := xml.EscapeText(t.sheet, []byte(textCell(v))); err != nil {
+				return err
+			}
+			t.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := t.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (t *xlsxTableWriter) Close() error {
+	t.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := t.sheet.Flush(); err != nil {
+		return err
+	}
+	return t.zw.Close()
+}
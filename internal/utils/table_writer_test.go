@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTextCell(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"plain text", "Áo thun", "Áo thun"},
+		{"empty", "", ""},
+		{"nil", nil, ""},
+		{"formula", "=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"plus", "+84901234567", "'+84901234567"},
+		{"minus text", "-1+1", "'-1+1"},
+		{"at", "@SUM(A1)", "'@SUM(A1)"},
+		{"tab", "\t=1", "'\t=1"},
+		{"carriage return", "\r=1", "'\r=1"},
+		{"formula char not first", "a=b", "a=b"},
+		{"negative int", -5, "-5"},
+		{"negative int64", int64(-12), "-12"},
+		{"negative float", -1.5, "-1.5"},
+		{"bool", true, "true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := textCell(tt.value); got != tt.want {
+				t.Errorf("textCell(%#v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVTableWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewTableWriter(TableFormatCSV, &buf, "Orders")
+	if err != nil {
+		t.Fatalf("NewTableWriter: %v", err)
+	}
+	rows := [][]interface{}{
+		{"Mã đơn", "Khách hàng", "Tổng tiền"},
+		{int64(1), "=cmd|' /C calc'!A0", -100.5},
+		{int64(2), "Nguyễn Văn A", 250000},
+	}
+	for _, r := range rows {
+		if err := w.WriteRow(r...); err != nil {
+			t.Fatalf("WriteRow: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "\xEF\xBB\xBF") {
+		t.Fatalf("missing UTF-8 BOM")
+	}
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(out, "\xEF\xBB\xBF"))).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	want := [][]string{
+		{"Mã đơn", "Khách hàng", "Tổng tiền"},
+		{"1", "'=cmd|' /C calc'!A0", "-100.5"},
+		{"2", "Nguyễn Văn A", "250000"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(records), len(want))
+	}
+	for i := range want {
+		for j := range want[i] {
+			if records[i][j] != want[i][j] {
+				t.Errorf("row %d col %d = %q, want %q", i, j, records[i][j], want[i][j])
+			}
+		}
+	}
+}
+
+func TestXLSXTableWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewTableWriter(TableFormatXLSX, &buf, "Đơn hàng [2025/01]")
+	if err != nil {
+		t.Fatalf("NewTableWriter: %v", err)
+	}
+	if err := w.WriteRow("Mã", "Ghi chú"); err != nil {
+		t.Fatalf("WriteRow: %v", err)
+	}
+	if err := w.WriteRow(-3, "@SUM(1+1)<b>"); err != nil {
+		t.Fatalf("WriteRow: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("open xlsx: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		body, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(body)
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"xl/workbook.xml", `<sheet name="Đơn hàng _2025_01_"`},
+		{"xl/worksheets/sheet1.xml", `<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">Mã</t></is></c>`},
+		{"xl/worksheets/sheet1.xml", `<c r="A2"><v>-3</v></c>`},
+		{"xl/worksheets/sheet1.xml", `<t xml:space="preserve">&#39;@SUM(1+1)&lt;b&gt;</t>`},
+		{"xl/worksheets/sheet1.xml", `</sheetData></worksheet>`},
+	}
+	for _, tt := range tests {
+		body, ok := files[tt.file]
+		if !ok {
+			t.Fatalf("missing %s", tt.file)
+		}
+		if !strings.Contains(body, tt.want) {
+			t.Errorf("%s does not contain %q", tt.file, tt.want)
+		}
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+	for _, tt := range tests {
+		if got := xlsxColumn(tt.index); got != tt.want {
+			t.Errorf("xlsxColumn(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/gosimple/slug"
+)
+
+// FoldText: bỏ dấu tiếng Việt, chữ thường, chỉ giữ chữ / số cách nhau 1 dấu cách
+// ("Thành phố Hồ Chí Minh" -> "thanh pho ho chi minh")
+func FoldText(s string) string {
+	return strings.ReplaceAll(slug.Make(s), "-", " ")
+}
+
+// EditDistance: khoảng cách Levenshtein giữa 2 chuỗi (tính theo rune)
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
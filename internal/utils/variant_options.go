@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang/internal/model"
+	"strings"
+)
+
+// ParseOptionValues - Đọc option_values của biến thể (JSON object {"Color":"Red","Size":"L"})
+func ParseOptionValues(raw string) (map[string]string, error) {
+	var values map[string]string
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return nil, fmt.Errorf("option_values phải là JSON object dạng {\"tên\": \"giá trị\"}")
+	}
+	return values, nil
+}
+
+// TrimOptionValues - Bỏ khoảng trắng đầu / cuối ở tên và giá trị option, báo lỗi nếu tên rỗng hoặc trùng sau khi trim
+func TrimOptionValues(values map[string]string) (map[string]string, error) {
+	trimmed := make(map[string]string, len(values))
+	for name, val := range values {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("tên thuộc tính không được để trống")
+		}
+		if _, ok := trimmed[name]; ok {
+			return nil, fmt.Errorf("thuộc tính '%s' bị lặp", name)
+		}
+		trimmed[name] = strings.TrimSpace(val)
+	}
+	return trimmed, nil
+}
+
+// CanonicalOptionValues - Chuẩn hóa option_values (key sắp xếp) để so sánh trùng lặp
+func CanonicalOptionValues(values map[string]string) string {
+	// json.Marshal luôn sắp xếp key của map
+	b, _ := json.Marshal(values)
+	return string(b)
+}
+
+// ValidateOptionValues - Kiểm tra option_values khớp chính xác với option của sản phẩm
+func ValidateOptionValues(values map[string]string, options []model.ProductOption) error {
+	if len(values) != len(options) {
+		return fmt.Errorf("option_values phải có đúng %d thuộc tính", len(options))
+	}
+	for _, opt := range options {
+		val, ok := values[opt.Name]
+		if !ok {
+			return fmt.Errorf("thiếu thuộc tính '%s'", opt.Name)
+		}
+		allowed := false
+		for _, v := range opt.Values {
+			if v == val {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("giá trị '%s' không hợp lệ cho thuộc tính '%s'", val, opt.Name)
+		}
+	}
+	return nil
+}
+
+// CartesianOptions - Sinh tất cả tổ hợp option theo thứ tự position
+func CartesianOptions(options []model.ProductOption) []map[string]string {
+	if len(options) == 0 {
+		return nil
+	}
+	result := []map[string]string{{}}
+	for _, opt := range options {
+		next := make([]map[string]string, 0, len(result)*len(opt.Values))
+		for _, combo := range result {
+			for _, v := range opt.Values {
+				c := make(map[string]string, len(combo)+1)
+				for k, val := range combo {
+					c[k] = val
+				}
+				c[opt.Name] = v
+				next = append(next, c)
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+// OptionTitle - Tên hiển thị của tổ hợp theo thứ tự option (VD: "Red / L")
+func OptionTitle(values map[string]string, options []model.ProductOption) string {
+	parts := make([]string, 0, len(options))
+	for _, opt := range options {
+		parts = append(parts, values[opt.Name])
+	}
+	return strings.Join(parts, " / ")
+}
@@ -0,0 +1,14 @@
+package utils
+
+// VariantPrice: giá bán của biến thể = price_override (> 0), nếu không có thì lấy giá gốc (base_price) của sản phẩm
+// Dùng chung cho giỏ hàng, checkout và khoảng giá min_price / max_price để các nơi tính giá giống nhau
+func VariantPrice(priceOverride *float64, basePrice float64) float64 {
+	if priceOverride != nil && *priceOverride > 0 {
+		return *priceOverride
+	}
+	return basePrice
+}
+
+// VariantPriceSQL: biểu thức SQL tương ứng với VariantPrice (alias v = product_variants, p = products)
+// để các truy vấn thống kê tính giá hiện tại cùng một quy tắc
+const VariantPriceSQL = `IF(v.price_override > 0, v.price_override, p.base_price)`
@@ -0,0 +1,27 @@
+package utils
+
+import "testing"
+
+func TestVariantPrice(t *testing.T) {
+	price := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name          string
+		priceOverride *float64
+		basePrice     float64
+		want          float64
+	}{
+		{"no override", nil, 200000, 200000},
+		{"override", price(150000), 200000, 150000},
+		{"override above base", price(250000), 200000, 250000},
+		{"zero override falls back", price(0), 200000, 200000},
+		{"negative override falls back", price(-1), 200000, 200000},
+		{"zero base without override", nil, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VariantPrice(tt.priceOverride, tt.basePrice); got != tt.want {
+				t.Errorf("VariantPrice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package validator
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gosimple/slug"
+)
+
+// Danh sách mặc định khi không cấu hình REVIEW_BAD_WORDS
+var defaultBadWords = []string{
+	"fake",
+	"scam",
+	"fraud",
+	"spam",
+}
+
+var (
+	badWordsOnce sync.Once
+	badWords     []string
+)
+
+// loadBadWords - Đọc danh sách từ cấm (env REVIEW_BAD_WORDS, phân cách bằng dấu phẩy).
+// Đọc lười vì init() chạy trước khi main nạp file .env
+func loadBadWords() []string {
+	badWordsOnce.Do(func() {
+		words := defaultBadWords
+		if raw := os.Getenv("REVIEW_BAD_WORDS"); raw != "" {
+			words = strings.Split(raw, ",")
+		}
+		for _, w := range words {
+			if folded := slug.Make(w); folded != "" {
+				badWords = append(badWords, folded)
+			}
+		}
+	})
+	return badWords
+}
+
+// ContainsBadWords - Kiểm tra text có chứa từ cấm theo nguyên từ (không phân biệt hoa thường, bỏ dấu tiếng Việt).
+// slug.Make vừa bỏ dấu vừa tách từ bằng "-", nên bọc "-" 2 đầu để so khớp nguyên từ / cụm từ
+func ContainsBadWords(text string) bool {
+	folded := "-" + slug.Make(text) + "-"
+	for _, w := range loadBadWords() {
+		if strings.Contains(folded, "-"+w+"-") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package validator
+
+import (
+	"sync"
+	"testing"
+)
+
+// resetBadWords: Nạp lại danh sách từ cấm theo env hiện tại
+func resetBadWords(t *testing.T, env string) {
+	t.Setenv("REVIEW_BAD_WORDS", env)
+	badWordsOnce = sync.Once{}
+	badWords = nil
+	t.Cleanup(func() {
+		badWordsOnce = sync.Once{}
+		badWords = nil
+	})
+}
+
+func TestContainsBadWords(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		text string
+		want bool
+	}{
+		{"clean text", "", "Sản phẩm tốt, giao hàng nhanh", false},
+		{"default word", "", "This shop is a scam", true},
+		{"case insensitive", "", "Hàng FAKE rồi", true},
+		{"punctuation around word", "", "fake!!!", true},
+		{"part of another word", "", "Hàng này không phải fakeness", false},
+		{"prefix of word", "", "scampi rất ngon", false},
+		{"empty text", "", "", false},
+		{"custom list replaces default", "lừa đảo, hàng dỏm", "Shop lừa đảo", true},
+		{"custom phrase without accents", "lừa đảo, hàng dỏm", "toan hang dom", true},
+		{"default ignored with custom list", "lừa đảo, hàng dỏm", "scam", false},
+		{"phrase must be contiguous", "hàng dỏm", "hàng rất dỏm", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetBadWords(t, tt.env)
+			if got := ContainsBadWords(tt.text); got != tt.want {
+				t.Errorf("ContainsBadWords(%q) with REVIEW_BAD_WORDS=%q = %v, want %v", tt.text, tt.env, got, tt.want)
+			}
+		})
+	}
+}
@@ -10,14 +10,6 @@ import (
 // 1. Tạo biến toàn cục (private) để lưu instance của thư viện gốc
 var validate *validator.Validate
 
-var badWordList = []string{
-	"abc",
-	"fake",
-	"scam",
-	"fraud",
-	"spam",
-}
-
 // CustomValidator struct (nếu cần thêm phương thức khác, có thể thêm vào đây)
 
 // 2. Hàm init() sẽ tự động chạy khi chương trình bắt đầu
@@ -27,16 +19,8 @@ func init() {
 	validate.RegisterValidation("badwords", containsBadWords)
 }
 func containsBadWords(fl validator.FieldLevel) bool {
-	text := fl.Field().String()
-	textLower := strings.ToLower(text)
-
-	for _, word := range badWordList {
-		if strings.Contains(textLower, word) {
-			return false // Trả về false nghĩa là Vi phạm (Lỗi)
-		}
-	}
-	return true // Trả về true nghĩa là Hợp lệ
-
+	// Trả về false nghĩa là Vi phạm (Lỗi)
+	return !ContainsBadWords(fl.Field().String())
 }
 
 // 3. Hàm Validate công khai (Global Function) - Không cần receiver (cv *CustomValidator) nữa
@@ -63,6 +47,8 @@ func getErrorMessage(fe validator.FieldError) string {
 	switch fe.Tag() {
 	case "required":
 		return "Trường này không được để trống"
+	case "required_without":
+		return fmt.Sprintf("Trường này không được để trống khi không có %s", fe.Param())
 	case "email":
 		return "Định dạng email không hợp lệ"
 	case "min":
@@ -75,6 +61,8 @@ func getErrorMessage(fe validator.FieldError) string {
 		return fmt.Sprintf("Giá trị phải là một trong các loại: %s", strings.ReplaceAll(fe.Param(), " ", ", "))
 	case "gt":
 		return fmt.Sprintf("Giá trị phải lớn hơn %s", fe.Param())
+	case "datetime":
+		return fmt.Sprintf("Định dạng ngày giờ phải là %s", fe.Param())
 	case "badwords":
 		return "Nội dung chứa từ ngữ không phù hợp"
 	default: